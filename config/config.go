@@ -31,6 +31,13 @@ type Config struct {
 
 	// HTTP server configuration
 	WebServer WebServerConfig
+
+	// ReadOnly runs the server as an observer: only non-mutating tools are
+	// registered and the HTTP server rejects changes
+	ReadOnly bool
+
+	// Event forwarding to external HTTP endpoints (environment only, not persisted)
+	EventForwarding EventForwardingConfig
 }
 
 // ToolGroupConfig controls which tool categories are enabled
@@ -54,6 +61,12 @@ type WebServerConfig struct {
 	ThumbnailCacheSec int    // Cache duration for thumbnails in seconds (0 to disable)
 }
 
+// EventForwardingConfig controls where OBS events are POSTed
+type EventForwardingConfig struct {
+	URLs       []string // Target URLs; forwarding is off when empty
+	EventTypes []string // Event types to forward; empty forwards all
+}
+
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	homeDir, err := os.UserHomeDir()
@@ -259,6 +272,14 @@ func LoadFromStorage(ctx context.Context, dbPath string) (*Config, error) {
 		}
 	}
 
+	// Load read-only mode
+	readOnly, err := db.GetReadOnly(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load read-only mode: %v", err)
+	} else {
+		cfg.ReadOnly = readOnly
+	}
+
 	// Load webserver configuration
 	webCfg, err := db.LoadWebServerConfig(ctx)
 	if err != nil {
@@ -329,6 +350,11 @@ func SaveToStorage(ctx context.Context, cfg *Config) error {
 		return fmt.Errorf("failed to save webserver config: %w", err)
 	}
 
+	// Save read-only mode
+	if err := db.SetReadOnly(ctx, cfg.ReadOnly); err != nil {
+		return fmt.Errorf("failed to save read-only mode: %w", err)
+	}
+
 	// Mark first run as complete
 	if err := db.MarkFirstRunComplete(ctx); err != nil {
 		return fmt.Errorf("failed to mark first run complete: %w", err)
@@ -386,25 +412,29 @@ func (c *Config) String() string {
 	}
 
 	return fmt.Sprintf(
-		"Config{ServerName: %s, ServerVersion: %s, OBS: %s:%s, Password: %s, DBPath: %s}",
+		"Config{ServerName: %s, ServerVersion: %s, OBS: %s:%s, Password: %s, DBPath: %s, ReadOnly: %v}",
 		c.ServerName,
 		c.ServerVersion,
 		c.OBSHost,
 		c.OBSPort,
 		password,
 		c.DBPath,
+		c.ReadOnly,
 	)
 }
 
 // Environment variable names for configuration overrides
 const (
-	EnvOBSHost     = "OBS_HOST"
-	EnvOBSPort     = "OBS_PORT"
-	EnvOBSPassword = "OBS_PASSWORD"
-	EnvDBPath      = "AGENTIC_OBS_DB"
-	EnvDBPathAlt   = "DB_PATH" // Legacy alias for backwards compatibility
-	EnvHTTPPort    = "AGENTIC_OBS_HTTP_PORT"
-	EnvHTTPEnabled = "AGENTIC_OBS_HTTP_ENABLED"
+	EnvOBSHost       = "OBS_HOST"
+	EnvOBSPort       = "OBS_PORT"
+	EnvOBSPassword   = "OBS_PASSWORD"
+	EnvDBPath        = "AGENTIC_OBS_DB"
+	EnvDBPathAlt     = "DB_PATH" // Legacy alias for backwards compatibility
+	EnvHTTPPort      = "AGENTIC_OBS_HTTP_PORT"
+	EnvHTTPEnabled   = "AGENTIC_OBS_HTTP_ENABLED"
+	EnvReadOnly      = "AGENTIC_OBS_READ_ONLY"
+	EnvForwardURLs   = "AGENTIC_OBS_FORWARD_URLS"   // Comma-separated event forwarding targets
+	EnvForwardEvents = "AGENTIC_OBS_FORWARD_EVENTS" // Comma-separated event types to forward
 )
 
 // ApplyEnvOverrides applies environment variable overrides to the configuration.
@@ -468,5 +498,43 @@ func (c *Config) ApplyEnvOverrides() bool {
 		}
 	}
 
+	if val := os.Getenv(EnvReadOnly); val != "" {
+		switch strings.ToLower(val) {
+		case "true", "1", "yes", "on":
+			c.ReadOnly = true
+			applied = true
+			log.Printf("Config override: %s=true", EnvReadOnly)
+		case "false", "0", "no", "off":
+			c.ReadOnly = false
+			applied = true
+			log.Printf("Config override: %s=false", EnvReadOnly)
+		default:
+			log.Printf("Warning: invalid %s value '%s', expected true/false", EnvReadOnly, val)
+		}
+	}
+
+	if val := os.Getenv(EnvForwardURLs); val != "" {
+		c.EventForwarding.URLs = splitList(val)
+		applied = true
+		log.Printf("Config override: %s=%d URL(s)", EnvForwardURLs, len(c.EventForwarding.URLs))
+	}
+
+	if val := os.Getenv(EnvForwardEvents); val != "" {
+		c.EventForwarding.EventTypes = splitList(val)
+		applied = true
+		log.Printf("Config override: %s=%s", EnvForwardEvents, strings.Join(c.EventForwarding.EventTypes, ","))
+	}
+
 	return applied
 }
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
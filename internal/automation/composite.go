@@ -0,0 +1,351 @@
+package automation
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Composite triggers.
+//
+// A composite rule fires on a combination of events rather than a single
+// one. Its trigger_config lists the events it watches, each an event_type
+// with an optional event_filter, and a mode:
+//
+//	all       every listed event has occurred within window_seconds, in any order
+//	any       any listed event occurs
+//	sequence  the listed events occur in order within window_seconds
+//	count     listed events occur count times within window_seconds
+//
+// window_seconds is optional for all and sequence. Once the mode is
+// satisfied the rule fires with the event that completed it, unless it has
+// one of two modifiers:
+//
+//	hold_seconds      fire only if no event breaks the match for this long;
+//	                  an event of a watched type that fails its filter breaks
+//	                  it, e.g. a switch away from the watched scene
+//	debounce_seconds  fire once the mode has not been satisfied again for
+//	                  this long, so a burst fires once
+//
+// The state of each rule is kept in memory and starts afresh when the rule
+// is changed or the engine restarts.
+
+// Composite trigger modes.
+const (
+	CompositeAll      = "all"
+	CompositeAny      = "any"
+	CompositeSequence = "sequence"
+	CompositeCount    = "count"
+)
+
+// Composite trigger limits.
+const (
+	MaxCompositeEvents  = 10
+	MaxCompositeCount   = 1000
+	MaxCompositeSeconds = 24 * 60 * 60
+)
+
+// SupportedCompositeModes returns the valid composite trigger modes.
+func SupportedCompositeModes() []string {
+	return []string{CompositeAll, CompositeAny, CompositeSequence, CompositeCount}
+}
+
+// Clock tells the time and runs functions after a delay. The engine reads
+// time through it for composite triggers, so tests can control time.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine after d. The returned function
+	// stops the call, reporting whether it was still pending.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// realClock is the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// CompositeEvent is an event a composite trigger watches.
+type CompositeEvent struct {
+	EventType string                 `json:"event_type"`
+	Filter    map[string]interface{} `json:"event_filter,omitempty"`
+}
+
+// CompositeSpec is the parsed trigger config of a composite rule.
+type CompositeSpec struct {
+	Mode     string
+	Events   []CompositeEvent
+	Window   time.Duration
+	Count    int
+	Hold     time.Duration
+	Debounce time.Duration
+}
+
+// ParseCompositeConfig parses and validates a composite trigger config.
+func ParseCompositeConfig(config map[string]interface{}) (*CompositeSpec, error) {
+	spec := &CompositeSpec{}
+
+	spec.Mode, _ = config["mode"].(string)
+	if !slices.Contains(SupportedCompositeModes(), spec.Mode) {
+		return nil, fmt.Errorf("composite trigger requires a 'mode' in trigger_config. Valid modes: %v", SupportedCompositeModes())
+	}
+
+	raw, _ := config["events"].([]interface{})
+	if len(raw) == 0 || len(raw) > MaxCompositeEvents {
+		return nil, fmt.Errorf("composite trigger requires 'events', a list of 1 to %d {event_type, event_filter} objects", MaxCompositeEvents)
+	}
+	for i, item := range raw {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("composite event %d must be an object with 'event_type'", i)
+		}
+		eventType, _ := entry["event_type"].(string)
+		if !slices.Contains(SupportedEventTypes(), eventType) {
+			return nil, fmt.Errorf("composite event %d has unknown event_type '%s'. Valid types: %v", i, eventType, SupportedEventTypes())
+		}
+		event := CompositeEvent{EventType: eventType}
+		if rawFilter, ok := entry["event_filter"]; ok && rawFilter != nil {
+			filter, ok := rawFilter.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("composite event %d: event_filter must be an object mapping event data keys to values or operators", i)
+			}
+			if err := ValidateEventFilter(filter); err != nil {
+				return nil, fmt.Errorf("composite event %d: %w", i, err)
+			}
+			event.Filter = filter
+		}
+		spec.Events = append(spec.Events, event)
+	}
+	if (spec.Mode == CompositeAll || spec.Mode == CompositeSequence) && len(spec.Events) < 2 {
+		return nil, fmt.Errorf("composite mode '%s' needs at least 2 events", spec.Mode)
+	}
+
+	var err error
+	if spec.Window, err = compositeSeconds(config, "window_seconds"); err != nil {
+		return nil, err
+	}
+	if spec.Hold, err = compositeSeconds(config, "hold_seconds"); err != nil {
+		return nil, err
+	}
+	if spec.Debounce, err = compositeSeconds(config, "debounce_seconds"); err != nil {
+		return nil, err
+	}
+	if spec.Hold > 0 && spec.Debounce > 0 {
+		return nil, fmt.Errorf("composite trigger can have 'hold_seconds' or 'debounce_seconds', not both")
+	}
+
+	if spec.Mode == CompositeCount {
+		count, ok := config["count"].(float64)
+		if !ok || count != float64(int(count)) || count < 2 || count > MaxCompositeCount {
+			return nil, fmt.Errorf("composite mode 'count' requires a whole 'count' between 2 and %d", MaxCompositeCount)
+		}
+		spec.Count = int(count)
+		if spec.Window == 0 {
+			return nil, fmt.Errorf("composite mode 'count' requires 'window_seconds'")
+		}
+	}
+	return spec, nil
+}
+
+// compositeSeconds reads an optional duration in seconds from config.
+func compositeSeconds(config map[string]interface{}, key string) (time.Duration, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	seconds, ok := raw.(float64)
+	if !ok || seconds < 0 || seconds > MaxCompositeSeconds {
+		return 0, fmt.Errorf("'%s' must be a number of seconds between 0 and %d", key, MaxCompositeSeconds)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// compositeTracker is the in-memory state machine of one composite rule.
+// fire is called from the clock's goroutine, with the completing event,
+// when a hold or debounce elapses.
+type compositeTracker struct {
+	mu    sync.Mutex
+	spec  *CompositeSpec
+	clock Clock
+	fire  func(payload EventPayload)
+
+	seen  []time.Time // all: when each event last occurred
+	step  int         // sequence: index of the next expected event
+	begun time.Time   // sequence: when the first event occurred
+	hits  []time.Time // count: when matching events occurred
+
+	stop       func() bool // Stops the pending hold or debounce
+	generation int         // Incremented whenever the pending fire changes
+}
+
+func newCompositeTracker(spec *CompositeSpec, clock Clock, fire func(payload EventPayload)) *compositeTracker {
+	return &compositeTracker{spec: spec, clock: clock, fire: fire, seen: make([]time.Time, len(spec.Events))}
+}
+
+// observe feeds an event to the tracker. It reports whether the rule should
+// fire now, with this event; fires delayed by a hold or debounce are made
+// through the tracker's fire function instead.
+func (c *compositeTracker) observe(payload EventPayload) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	matched, broken := c.match(payload)
+	if broken && c.spec.Hold > 0 && c.stop != nil {
+		c.cancelLocked()
+	}
+	if len(matched) == 0 || !c.advance(matched, now) {
+		return false
+	}
+
+	switch {
+	case c.spec.Hold > 0:
+		if c.stop == nil {
+			c.scheduleLocked(c.spec.Hold, payload)
+		}
+		return false
+	case c.spec.Debounce > 0:
+		c.cancelLocked()
+		c.scheduleLocked(c.spec.Debounce, payload)
+		return false
+	}
+	return true
+}
+
+// compositePeek is what a tracker would do with an event, as reported by
+// peek.
+type compositePeek struct {
+	watched   bool // The event has a watched type
+	matched   bool // It matches one of the watched events
+	satisfied bool // It satisfies the mode
+	pending   bool // A hold or debounce is waiting to fire
+}
+
+// peek reports what observe would do with payload without changing the
+// tracker's state.
+func (c *compositeTracker) peek(payload EventPayload) compositePeek {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matched, broken := c.match(payload)
+	peek := compositePeek{watched: len(matched) > 0 || broken, matched: len(matched) > 0, pending: c.stop != nil}
+	if peek.matched {
+		scratch := &compositeTracker{spec: c.spec, seen: slices.Clone(c.seen), step: c.step, begun: c.begun, hits: slices.Clone(c.hits)}
+		peek.satisfied = scratch.advance(matched, c.clock.Now())
+	}
+	return peek
+}
+
+// match returns the indexes of the watched events that payload matches,
+// and whether it breaks a hold: it has a watched type but matches none of
+// the events of that type.
+func (c *compositeTracker) match(payload EventPayload) (matched []int, broken bool) {
+	watched := false
+	for i, event := range c.spec.Events {
+		if event.EventType != payload.EventType {
+			continue
+		}
+		watched = true
+		if ok, _ := matchFilter(event.Filter, payload.Data); ok {
+			matched = append(matched, i)
+		}
+	}
+	return matched, watched && len(matched) == 0
+}
+
+// advance records matched events and reports whether the mode is now
+// satisfied, resetting the state if so.
+func (c *compositeTracker) advance(matched []int, now time.Time) bool {
+	window := c.spec.Window
+	expired := func(t time.Time) bool { return window > 0 && now.Sub(t) > window }
+
+	switch c.spec.Mode {
+	case CompositeAny:
+		return true
+
+	case CompositeAll:
+		for _, i := range matched {
+			c.seen[i] = now
+		}
+		for _, t := range c.seen {
+			if t.IsZero() || expired(t) {
+				return false
+			}
+		}
+		clear(c.seen)
+		return true
+
+	case CompositeSequence:
+		if c.step > 0 && expired(c.begun) {
+			c.step = 0
+		}
+		switch {
+		case slices.Contains(matched, c.step):
+			if c.step == 0 {
+				c.begun = now
+			}
+			c.step++
+		case slices.Contains(matched, 0):
+			// The sequence starts over
+			c.step, c.begun = 1, now
+		}
+		if c.step == len(c.spec.Events) {
+			c.step = 0
+			return true
+		}
+		return false
+
+	case CompositeCount:
+		c.hits = slices.DeleteFunc(append(c.hits, now), expired)
+		if len(c.hits) >= c.spec.Count {
+			c.hits = nil
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleLocked fires the rule with payload after d unless cancelled.
+// Caller must hold c.mu.
+func (c *compositeTracker) scheduleLocked(d time.Duration, payload EventPayload) {
+	c.generation++
+	generation := c.generation
+	c.stop = c.clock.AfterFunc(d, func() {
+		c.mu.Lock()
+		if c.generation != generation {
+			c.mu.Unlock()
+			return
+		}
+		c.stop = nil
+		c.mu.Unlock()
+		c.fire(payload)
+	})
+}
+
+// cancelLocked cancels the pending hold or debounce, if any. Caller must
+// hold c.mu.
+func (c *compositeTracker) cancelLocked() {
+	if c.stop != nil {
+		c.stop()
+		c.stop = nil
+	}
+	c.generation++
+}
+
+// close cancels the pending hold or debounce when the rule is unloaded.
+func (c *compositeTracker) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelLocked()
+}
+
+// pending reports whether a hold or debounce is waiting to fire.
+func (c *compositeTracker) pending() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stop != nil
+}
@@ -0,0 +1,370 @@
+package automation
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		pending := !timer.stopped
+		timer.stopped = true
+		return pending
+	}
+}
+
+// Advance moves the clock forward, running the timers that come due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []func()
+	for _, timer := range c.timers {
+		if !timer.stopped && !timer.at.After(c.now) {
+			timer.stopped = true
+			due = append(due, timer.f)
+		}
+	}
+	c.mu.Unlock()
+	for _, f := range due {
+		f()
+	}
+}
+
+func sceneEvent(name string) EventPayload {
+	return EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": name}}
+}
+
+func muteEvent(muted bool) EventPayload {
+	return EventPayload{EventType: EventInputMuteChanged, Data: map[string]interface{}{"input_name": "Mic", "muted": muted}}
+}
+
+// newTestTracker parses config and returns a tracker on clock with the
+// events it fired with after a hold or debounce.
+func newTestTracker(t *testing.T, clock *fakeClock, config map[string]interface{}) (*compositeTracker, *[]EventPayload) {
+	t.Helper()
+	spec, err := ParseCompositeConfig(config)
+	require.NoError(t, err)
+	var fired []EventPayload
+	return newCompositeTracker(spec, clock, func(payload EventPayload) { fired = append(fired, payload) }), &fired
+}
+
+func TestParseCompositeConfig(t *testing.T) {
+	spec, err := ParseCompositeConfig(map[string]interface{}{
+		"mode":           CompositeCount,
+		"events":         []interface{}{map[string]interface{}{"event_type": EventInputMuteChanged, "event_filter": map[string]interface{}{"muted": true}}},
+		"count":          3.0,
+		"window_seconds": 60.0,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, spec.Count)
+	assert.Equal(t, time.Minute, spec.Window)
+	assert.Equal(t, map[string]interface{}{"muted": true}, spec.Events[0].Filter)
+
+	streaming := map[string]interface{}{"event_type": EventStreamingStarted}
+	for name, tc := range map[string]struct {
+		config map[string]interface{}
+		want   string
+	}{
+		"no mode":           {map[string]interface{}{"events": []interface{}{streaming}}, "requires a 'mode'"},
+		"no events":         {map[string]interface{}{"mode": CompositeAny}, "requires 'events'"},
+		"event not object":  {map[string]interface{}{"mode": CompositeAny, "events": []interface{}{"streaming_started"}}, "must be an object"},
+		"unknown event":     {map[string]interface{}{"mode": CompositeAny, "events": []interface{}{map[string]interface{}{"event_type": "lunch"}}}, "unknown event_type"},
+		"bad filter":        {map[string]interface{}{"mode": CompositeAny, "events": []interface{}{map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": map[string]interface{}{"like": "x"}}}}}, "unknown operator"},
+		"all of one":        {map[string]interface{}{"mode": CompositeAll, "events": []interface{}{streaming}}, "at least 2 events"},
+		"negative window":   {map[string]interface{}{"mode": CompositeAny, "events": []interface{}{streaming}, "window_seconds": -1.0}, "'window_seconds'"},
+		"hold and debounce": {map[string]interface{}{"mode": CompositeAny, "events": []interface{}{streaming}, "hold_seconds": 5.0, "debounce_seconds": 5.0}, "not both"},
+		"count missing":     {map[string]interface{}{"mode": CompositeCount, "events": []interface{}{streaming}, "window_seconds": 60.0}, "whole 'count'"},
+		"count fractional":  {map[string]interface{}{"mode": CompositeCount, "events": []interface{}{streaming}, "window_seconds": 60.0, "count": 2.5}, "whole 'count'"},
+		"count no window":   {map[string]interface{}{"mode": CompositeCount, "events": []interface{}{streaming}, "count": 3.0}, "requires 'window_seconds'"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseCompositeConfig(tc.config)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestCompositeTracker(t *testing.T) {
+	recording := EventPayload{EventType: EventRecordingStarted}
+	streaming := EventPayload{EventType: EventStreamingStarted}
+
+	t.Run("all of within a window", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, _ := newTestTracker(t, clock, map[string]interface{}{
+			"mode":           CompositeAll,
+			"events":         []interface{}{map[string]interface{}{"event_type": EventRecordingStarted}, map[string]interface{}{"event_type": EventStreamingStarted}},
+			"window_seconds": 10.0,
+		})
+
+		assert.False(t, tracker.observe(streaming))
+		assert.True(t, tracker.observe(recording), "order does not matter")
+		assert.False(t, tracker.observe(recording), "state resets after firing")
+
+		clock.Advance(11 * time.Second)
+		assert.False(t, tracker.observe(streaming), "recording started too long ago")
+		assert.True(t, tracker.observe(recording))
+	})
+
+	t.Run("any of", func(t *testing.T) {
+		tracker, _ := newTestTracker(t, newFakeClock(), map[string]interface{}{
+			"mode":   CompositeAny,
+			"events": []interface{}{map[string]interface{}{"event_type": EventRecordingStarted}, map[string]interface{}{"event_type": EventStreamingStarted}},
+		})
+		assert.True(t, tracker.observe(streaming))
+		assert.True(t, tracker.observe(recording))
+		assert.False(t, tracker.observe(sceneEvent("Gameplay")))
+	})
+
+	t.Run("sequence in order within a window", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, _ := newTestTracker(t, clock, map[string]interface{}{
+			"mode": CompositeSequence,
+			"events": []interface{}{
+				map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Starting"}},
+				map[string]interface{}{"event_type": EventStreamingStarted},
+				map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Live"}},
+			},
+			"window_seconds": 60.0,
+		})
+
+		assert.False(t, tracker.observe(streaming), "out of order")
+		assert.False(t, tracker.observe(sceneEvent("Starting")))
+		assert.False(t, tracker.observe(sceneEvent("Live")), "streaming has not started")
+		assert.False(t, tracker.observe(streaming))
+		assert.True(t, tracker.observe(sceneEvent("Live")))
+
+		assert.False(t, tracker.observe(sceneEvent("Starting")))
+		assert.False(t, tracker.observe(streaming))
+		clock.Advance(61 * time.Second)
+		assert.False(t, tracker.observe(sceneEvent("Live")), "the sequence expired")
+	})
+
+	t.Run("count threshold within a window", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, _ := newTestTracker(t, clock, map[string]interface{}{
+			"mode":           CompositeCount,
+			"events":         []interface{}{map[string]interface{}{"event_type": EventInputMuteChanged, "event_filter": map[string]interface{}{"muted": true}}},
+			"count":          3.0,
+			"window_seconds": 60.0,
+		})
+
+		assert.False(t, tracker.observe(muteEvent(true)))
+		assert.False(t, tracker.observe(muteEvent(false)), "unmuting does not count")
+		clock.Advance(50 * time.Second)
+		assert.False(t, tracker.observe(muteEvent(true)))
+		clock.Advance(20 * time.Second)
+		assert.False(t, tracker.observe(muteEvent(true)), "the first mute left the window")
+		assert.True(t, tracker.observe(muteEvent(true)))
+		assert.False(t, tracker.observe(muteEvent(true)), "the count starts over after firing")
+	})
+
+	t.Run("hold fires if not broken", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, fired := newTestTracker(t, clock, map[string]interface{}{
+			"mode":         CompositeAny,
+			"events":       []interface{}{map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Gameplay"}}},
+			"hold_seconds": 30.0,
+		})
+
+		assert.False(t, tracker.observe(sceneEvent("Gameplay")))
+		assert.True(t, tracker.pending())
+		clock.Advance(20 * time.Second)
+		assert.False(t, tracker.observe(sceneEvent("Menu")))
+		assert.False(t, tracker.pending(), "switching away breaks the hold")
+		clock.Advance(20 * time.Second)
+		assert.Empty(t, *fired)
+
+		assert.False(t, tracker.observe(sceneEvent("Gameplay")))
+		clock.Advance(20 * time.Second)
+		assert.False(t, tracker.observe(sceneEvent("Gameplay")), "a repeat does not restart the hold")
+		clock.Advance(10 * time.Second)
+		require.Len(t, *fired, 1)
+		assert.Equal(t, "Gameplay", (*fired)[0].Data["scene_name"])
+		assert.False(t, tracker.pending())
+	})
+
+	t.Run("debounce fires once after a burst", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, fired := newTestTracker(t, clock, map[string]interface{}{
+			"mode":             CompositeAny,
+			"events":           []interface{}{map[string]interface{}{"event_type": EventInputMuteChanged}},
+			"debounce_seconds": 5.0,
+		})
+
+		for range 4 {
+			assert.False(t, tracker.observe(muteEvent(true)))
+			clock.Advance(2 * time.Second)
+		}
+		assert.False(t, tracker.observe(muteEvent(false)))
+		clock.Advance(4 * time.Second)
+		assert.Empty(t, *fired)
+		clock.Advance(time.Second)
+		require.Len(t, *fired, 1)
+		assert.Equal(t, false, (*fired)[0].Data["muted"], "fires with the last event")
+	})
+
+	t.Run("close cancels a pending hold", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, fired := newTestTracker(t, clock, map[string]interface{}{
+			"mode":         CompositeAny,
+			"events":       []interface{}{map[string]interface{}{"event_type": EventStreamingStarted}},
+			"hold_seconds": 1.0,
+		})
+		tracker.observe(streaming)
+		tracker.close()
+		clock.Advance(time.Minute)
+		assert.Empty(t, *fired)
+	})
+}
+
+func TestCompositeTrackerPeek(t *testing.T) {
+	streaming := EventPayload{EventType: EventStreamingStarted}
+
+	t.Run("sequence state is not advanced", func(t *testing.T) {
+		tracker, _ := newTestTracker(t, newFakeClock(), map[string]interface{}{
+			"mode": CompositeSequence,
+			"events": []interface{}{
+				map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Starting"}},
+				map[string]interface{}{"event_type": EventStreamingStarted},
+			},
+		})
+
+		assert.Equal(t, compositePeek{}, tracker.peek(EventPayload{EventType: EventRecordingStarted}))
+		assert.Equal(t, compositePeek{watched: true}, tracker.peek(sceneEvent("Gameplay")))
+		assert.Equal(t, compositePeek{watched: true, matched: true}, tracker.peek(sceneEvent("Starting")))
+		assert.Equal(t, compositePeek{watched: true, matched: true}, tracker.peek(streaming), "out of order")
+
+		assert.False(t, tracker.observe(sceneEvent("Starting")))
+		for range 3 {
+			assert.Equal(t, compositePeek{watched: true, matched: true, satisfied: true}, tracker.peek(streaming))
+		}
+		assert.True(t, tracker.observe(streaming), "peeking did not complete the sequence")
+	})
+
+	t.Run("pending hold is left alone", func(t *testing.T) {
+		clock := newFakeClock()
+		tracker, fired := newTestTracker(t, clock, map[string]interface{}{
+			"mode":         CompositeAny,
+			"events":       []interface{}{map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Gameplay"}}},
+			"hold_seconds": 30.0,
+		})
+
+		assert.False(t, tracker.observe(sceneEvent("Gameplay")))
+		assert.Equal(t, compositePeek{watched: true, pending: true}, tracker.peek(sceneEvent("Menu")), "would break the hold")
+		assert.True(t, tracker.pending())
+		clock.Advance(30 * time.Second)
+		assert.Len(t, *fired, 1)
+	})
+
+	t.Run("count hits are not recorded", func(t *testing.T) {
+		tracker, _ := newTestTracker(t, newFakeClock(), map[string]interface{}{
+			"mode":           CompositeCount,
+			"events":         []interface{}{map[string]interface{}{"event_type": EventStreamingStarted}},
+			"window_seconds": 60.0,
+			"count":          2.0,
+		})
+
+		assert.False(t, tracker.peek(streaming).satisfied)
+		assert.False(t, tracker.peek(streaming).satisfied)
+		assert.False(t, tracker.observe(streaming))
+		assert.True(t, tracker.peek(streaming).satisfied)
+	})
+}
+
+func TestEngineCompositeTrigger(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	held, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:        "settled-in-gameplay",
+		Enabled:     true,
+		TriggerType: TriggerTypeComposite,
+		TriggerConfig: map[string]interface{}{
+			"mode":         CompositeAny,
+			"events":       []interface{}{map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Gameplay"}}},
+			"hold_seconds": 30.0,
+		},
+		Actions: []storage.RuleAction{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}} (settled)"}}},
+	})
+	require.NoError(t, err)
+	_, err = db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:        "live",
+		Enabled:     true,
+		TriggerType: TriggerTypeComposite,
+		TriggerConfig: map[string]interface{}{
+			"mode":   CompositeAll,
+			"events": []interface{}{map[string]interface{}{"event_type": EventRecordingStarted}, map[string]interface{}{"event_type": EventStreamingStarted}},
+		},
+		Actions: []storage.RuleAction{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}}},
+	})
+	require.NoError(t, err)
+
+	client := NewMockOBSClient()
+	clock := newFakeClock()
+	engine := NewAutomationEngine(db, client)
+	engine.SetClock(clock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	engine.HandleEvent(EventPayload{EventType: EventRecordingStarted})
+	engine.HandleEvent(EventPayload{EventType: EventStreamingStarted})
+	require.Eventually(t, func() bool { return len(client.GetActions()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"set_scene:Live"}, client.GetActions())
+
+	pending := func() bool {
+		engine.mu.RLock()
+		defer engine.mu.RUnlock()
+		return engine.composites[held] != nil && engine.composites[held].pending()
+	}
+	engine.HandleEvent(sceneEvent("Gameplay"))
+	require.Eventually(t, pending, time.Second, 5*time.Millisecond)
+	clock.Advance(30 * time.Second)
+	require.Eventually(t, func() bool { return len(client.GetActions()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "set_scene:Gameplay (settled)", client.GetActions()[1])
+
+	t.Run("changing the rule drops its state", func(t *testing.T) {
+		engine.HandleEvent(sceneEvent("Gameplay"))
+		require.Eventually(t, pending, time.Second, 5*time.Millisecond)
+		engine.NotifyRuleChange(held, false)
+		assert.False(t, pending())
+		clock.Advance(time.Minute)
+		assert.Len(t, client.GetActions(), 2)
+	})
+}
@@ -0,0 +1,160 @@
+package automation
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Concurrency policies.
+//
+// The engine tracks the in-flight executions of every rule. Before a rule
+// runs, its policy decides what to do about executions already in flight:
+//
+//	parallel         run alongside them
+//	skip_if_running  skip the new execution
+//	queue            wait until they finish, in trigger order
+//	restart          cancel them (and any queued executions) and then run
+//
+// Executions waiting in a queue are bounded by maxQueuedRuns; a rule
+// triggered while its queue is full skips the new execution.
+
+// maxQueuedRuns is the most executions of one rule that wait in a queue.
+const maxQueuedRuns = 10
+
+// Reasons reported when a concurrency policy keeps an execution from running.
+const (
+	skipReasonRunning    = "skipped: rule is already running"
+	skipReasonQueueFull  = "skipped: execution queue is full"
+	skipReasonSuperseded = "skipped: superseded by a newer execution"
+)
+
+// ruleRun is one execution of a rule, either running or waiting its turn.
+// Fields other than cancel and ready are guarded by e.runsMu.
+type ruleRun struct {
+	cancel     context.CancelFunc // Cancels the execution's context
+	ready      chan struct{}      // Closed when a waiting run may start or is superseded
+	superseded bool               // Set before ready is closed if the run must not start
+
+	rule        *Rule
+	executionID int64 // Zero until the execution is recorded
+	actionIndex int   // Index of the top-level action being run
+	startedAt   time.Time
+	finished    bool // Set once the actions are done; no longer listed or cancellable
+}
+
+// ruleRuns holds the in-flight executions of one rule.
+type ruleRuns struct {
+	active  []*ruleRun
+	waiting []*ruleRun
+}
+
+// acquireRun waits until the rule's concurrency policy lets a new
+// execution start. On success it returns the run, which must be passed to
+// releaseRun when the execution ends. Otherwise it returns the reason the
+// execution did not start, with cancelled set if ctx ended while it waited.
+// cancel must cancel the execution's context; restart and CancelExecution
+// use it to stop the execution.
+func (e *AutomationEngine) acquireRun(ctx context.Context, rule *Rule, cancel context.CancelFunc) (run *ruleRun, reason string, cancelled bool) {
+	run = &ruleRun{cancel: cancel, ready: make(chan struct{}), rule: rule}
+	policy := rule.GetConcurrency()
+
+	e.runsMu.Lock()
+	runs := e.runs[rule.ID]
+	if runs == nil {
+		runs = &ruleRuns{}
+		e.runs[rule.ID] = runs
+	}
+	busy := len(runs.active) > 0 || len(runs.waiting) > 0
+
+	switch {
+	case policy == ConcurrencyParallel || !busy:
+		run.startedAt = time.Now()
+		runs.active = append(runs.active, run)
+		e.runsMu.Unlock()
+		return run, "", false
+
+	case policy == ConcurrencySkipIfRunning:
+		e.runsMu.Unlock()
+		logger.Debugf("Rule '%s' skipped (already running)", rule.Name)
+		return nil, skipReasonRunning, false
+
+	case policy == ConcurrencyRestart:
+		for _, active := range runs.active {
+			active.cancel()
+		}
+		for _, waiting := range runs.waiting {
+			waiting.superseded = true
+			close(waiting.ready)
+		}
+		runs.waiting = nil
+		logger.Infof("Rule '%s' restarting, cancelled %d running execution(s)", rule.Name, len(runs.active))
+
+	case len(runs.waiting) >= maxQueuedRuns:
+		e.runsMu.Unlock()
+		logger.Warnf("Rule '%s' skipped (execution queue full)", rule.Name)
+		return nil, skipReasonQueueFull, false
+	}
+
+	runs.waiting = append(runs.waiting, run)
+	e.runsMu.Unlock()
+
+	select {
+	case <-run.ready:
+	case <-ctx.Done():
+	}
+
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	if run.superseded {
+		return nil, skipReasonSuperseded, false
+	}
+	if slices.Contains(runs.active, run) {
+		// Granted, possibly just as ctx ended; the execution notices the
+		// cancellation itself
+		return run, "", false
+	}
+	runs.waiting = slices.DeleteFunc(runs.waiting, func(r *ruleRun) bool { return r == run })
+	e.pruneRunsLocked(rule.ID, runs)
+	return nil, "execution cancelled", true
+}
+
+// releaseRun ends run and starts the next waiting execution once no other
+// execution of the rule is running.
+func (e *AutomationEngine) releaseRun(ruleID int64, run *ruleRun) {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+
+	runs := e.runs[ruleID]
+	if runs == nil {
+		return
+	}
+	runs.active = slices.DeleteFunc(runs.active, func(r *ruleRun) bool { return r == run })
+	if len(runs.active) == 0 && len(runs.waiting) > 0 {
+		next := runs.waiting[0]
+		runs.waiting = runs.waiting[1:]
+		next.startedAt = time.Now()
+		runs.active = append(runs.active, next)
+		close(next.ready)
+	}
+	e.pruneRunsLocked(ruleID, runs)
+}
+
+// pruneRunsLocked forgets a rule with no in-flight executions. Caller must
+// hold e.runsMu.
+func (e *AutomationEngine) pruneRunsLocked(ruleID int64, runs *ruleRuns) {
+	if len(runs.active) == 0 && len(runs.waiting) == 0 {
+		delete(e.runs, ruleID)
+	}
+}
+
+// RunningExecutionCount returns how many executions of a rule are running
+// and how many are waiting in its queue.
+func (e *AutomationEngine) RunningExecutionCount(ruleID int64) (running, queued int) {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	if runs := e.runs[ruleID]; runs != nil {
+		return len(runs.active), len(runs.waiting)
+	}
+	return 0, 0
+}
@@ -0,0 +1,263 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineConcurrencyPolicies(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ids := map[string]int64{}
+	for _, policy := range []string{"", ConcurrencySkipIfRunning, ConcurrencyQueue, ConcurrencyRestart} {
+		name := "policy-" + policy
+		id, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+			Name:          name,
+			Enabled:       true,
+			TriggerType:   TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Concurrency:   policy,
+			Actions: []storage.RuleAction{
+				{Type: ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": float64(150)}},
+				{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": name}},
+			},
+		})
+		require.NoError(t, err)
+		ids[name] = id
+	}
+
+	stored, err := db.GetAutomationRuleByName(ctx, "policy-"+ConcurrencyQueue)
+	require.NoError(t, err)
+	assert.Equal(t, ConcurrencyQueue, stored.Concurrency)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	// start runs a rule in the background and waits until it is running or
+	// queued, so tests control the order in which executions arrive
+	start := func(name string, running, queued int) <-chan *ExecutionResult {
+		done := make(chan *ExecutionResult, 1)
+		go func() {
+			result, err := engine.ExecuteRuleByName(ctx, name, nil)
+			assert.NoError(t, err)
+			done <- result
+		}()
+		require.Eventually(t, func() bool {
+			r, q := engine.RunningExecutionCount(ids[name])
+			return r == running && q == queued
+		}, 2*time.Second, 5*time.Millisecond)
+		return done
+	}
+
+	t.Run("parallel runs overlapping executions", func(t *testing.T) {
+		first := start("policy-", 1, 0)
+		second := start("policy-", 2, 0)
+		assert.Equal(t, storage.ExecutionStatusCompleted, (<-first).Status)
+		assert.Equal(t, storage.ExecutionStatusCompleted, (<-second).Status)
+	})
+
+	t.Run("skip_if_running skips new executions", func(t *testing.T) {
+		first := start("policy-skip_if_running", 1, 0)
+
+		result, err := engine.ExecuteRuleByName(ctx, "policy-skip_if_running", nil)
+		require.NoError(t, err)
+		assert.Equal(t, storage.ExecutionStatusSkipped, result.Status)
+		assert.Contains(t, result.Error, "already running")
+		assert.Zero(t, result.ExecutionID, "skipped executions are not recorded")
+
+		assert.Equal(t, storage.ExecutionStatusCompleted, (<-first).Status)
+		executions, err := db.GetRuleExecutions(ctx, ids["policy-skip_if_running"], 10)
+		require.NoError(t, err)
+		assert.Len(t, executions, 1)
+	})
+
+	t.Run("queue runs executions one after another", func(t *testing.T) {
+		first := start("policy-queue", 1, 0)
+		second := start("policy-queue", 1, 1)
+		third := start("policy-queue", 1, 2)
+
+		results := []*ExecutionResult{<-first, <-second, <-third}
+		for i, result := range results {
+			assert.Equal(t, storage.ExecutionStatusCompleted, result.Status)
+			if i > 0 {
+				assert.False(t, result.StartedAt.Before(results[i-1].CompletedAt), "execution %d overlapped the previous one", i)
+			}
+		}
+	})
+
+	t.Run("queued executions can be cancelled", func(t *testing.T) {
+		first := start("policy-queue", 1, 0)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer cancel()
+		result, err := engine.ExecuteRuleByName(waitCtx, "policy-queue", nil)
+		require.NoError(t, err)
+		assert.Equal(t, storage.ExecutionStatusCancelled, result.Status)
+		assert.Zero(t, result.ExecutionID)
+
+		running, queued := engine.RunningExecutionCount(ids["policy-queue"])
+		assert.Equal(t, 1, running)
+		assert.Zero(t, queued)
+		assert.Equal(t, storage.ExecutionStatusCompleted, (<-first).Status)
+	})
+
+	t.Run("restart cancels the running execution", func(t *testing.T) {
+		mock.ClearActions()
+		first := start("policy-restart", 1, 0)
+		second := start("policy-restart", 1, 0)
+
+		assert.Equal(t, storage.ExecutionStatusCancelled, (<-first).Status)
+		assert.Equal(t, storage.ExecutionStatusCompleted, (<-second).Status)
+		assert.Equal(t, []string{"set_scene:policy-restart"}, mock.GetActions())
+	})
+
+	t.Run("restart supersedes queued executions", func(t *testing.T) {
+		first := start("policy-restart", 1, 0)
+
+		// An execution that ignores cancellation keeps the next one waiting
+		blocker := &ruleRun{cancel: func() {}}
+		engine.runsMu.Lock()
+		runs := engine.runs[ids["policy-restart"]]
+		runs.active = append(runs.active, blocker)
+		engine.runsMu.Unlock()
+
+		second := start("policy-restart", 1, 1)
+		third := start("policy-restart", 1, 1)
+
+		assert.Equal(t, storage.ExecutionStatusCancelled, (<-first).Status)
+		result := <-second
+		assert.Equal(t, storage.ExecutionStatusSkipped, result.Status)
+		assert.Contains(t, result.Error, "superseded")
+
+		engine.releaseRun(ids["policy-restart"], blocker)
+		assert.Equal(t, storage.ExecutionStatusCompleted, (<-third).Status)
+	})
+
+	running, queued := engine.RunningExecutionCount(ids["policy-queue"])
+	assert.Zero(t, running+queued)
+}
+
+// blockingOBSClient is a mock whose scene switches hang until released.
+type blockingOBSClient struct {
+	*MockOBSClient
+	release chan struct{}
+}
+
+func (b *blockingOBSClient) SetCurrentScene(name string) error {
+	<-b.release
+	return b.MockOBSClient.SetCurrentScene(name)
+}
+
+func TestEngineRunningExecutions(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for name, action := range map[string]storage.RuleAction{
+		"long-delay": {Type: ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": float64(60000)}},
+		"stuck-obs":  {Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}},
+	} {
+		_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+			Name:          name,
+			Enabled:       true,
+			TriggerType:   TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions:       []storage.RuleAction{{Type: ActionTypeStartRecording}, action},
+		})
+		require.NoError(t, err)
+	}
+
+	client := &blockingOBSClient{MockOBSClient: NewMockOBSClient(), release: make(chan struct{})}
+	defer close(client.release)
+	engine := NewAutomationEngine(db, client)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	for _, name := range []string{"long-delay", "stuck-obs"} {
+		t.Run("cancels "+name, func(t *testing.T) {
+			done := make(chan *ExecutionResult, 1)
+			go func() {
+				result, err := engine.ExecuteRuleByName(ctx, name, nil)
+				assert.NoError(t, err)
+				done <- result
+			}()
+
+			var running RunningExecution
+			require.Eventually(t, func() bool {
+				for _, exec := range engine.RunningExecutions() {
+					if exec.RuleName == name && exec.ActionIndex == 1 {
+						running = exec
+						return true
+					}
+				}
+				return false
+			}, 2*time.Second, 5*time.Millisecond)
+			assert.NotZero(t, running.ExecutionID)
+			assert.Equal(t, 2, running.ActionCount)
+			assert.Equal(t, TriggerTypeManual, running.TriggerType)
+			assert.False(t, running.StartedAt.IsZero())
+
+			cancelledAt := time.Now()
+			require.NoError(t, engine.CancelExecution(running.ExecutionID))
+
+			var result *ExecutionResult
+			select {
+			case result = <-done:
+			case <-time.After(time.Second):
+				t.Fatal("execution did not stop after cancellation")
+			}
+			assert.Less(t, time.Since(cancelledAt), 500*time.Millisecond)
+			assert.Equal(t, storage.ExecutionStatusCancelled, result.Status)
+			assert.Equal(t, running.ExecutionID, result.ExecutionID)
+
+			stored, err := db.GetRuleExecutions(ctx, running.RuleID, 1)
+			require.NoError(t, err)
+			require.Len(t, stored, 1)
+			assert.Equal(t, running.ExecutionID, stored[0].ID)
+			assert.Equal(t, storage.ExecutionStatusCancelled, stored[0].Status)
+			require.Len(t, stored[0].ActionResults, 2)
+			assert.True(t, stored[0].ActionResults[1].Cancelled)
+
+			// Only the OBS call was in flight and may still land
+			if name == "stuck-obs" {
+				assert.True(t, stored[0].ActionResults[1].Abandoned)
+				assert.Contains(t, stored[0].Error, "action 1 (set_scene) was abandoned")
+			} else {
+				assert.False(t, stored[0].ActionResults[1].Abandoned)
+				assert.Equal(t, "execution cancelled", stored[0].Error)
+			}
+
+			assert.ErrorIs(t, engine.CancelExecution(running.ExecutionID), ErrExecutionNotRunning)
+			assert.Empty(t, engine.RunningExecutions())
+		})
+	}
+}
+
+func TestFinishedRunsAreNotRunning(t *testing.T) {
+	engine := NewAutomationEngine(nil, NewMockOBSClient())
+	rule := &Rule{ID: 1, Name: "r", Actions: []Action{{Type: ActionTypeStartRecording}}}
+
+	run, _, _ := engine.acquireRun(context.Background(), rule, func() {})
+	require.NotNil(t, run)
+	engine.trackRun(run, 42, 0)
+	require.Len(t, engine.RunningExecutions(), 1)
+
+	engine.finishRun(run)
+	assert.Empty(t, engine.RunningExecutions())
+	assert.ErrorIs(t, engine.CancelExecution(42), ErrExecutionNotRunning)
+	running, _ := engine.RunningExecutionCount(rule.ID)
+	assert.Equal(t, 1, running, "a finished run keeps its slot until released")
+
+	engine.releaseRun(rule.ID, run)
+	running, _ = engine.RunningExecutionCount(rule.ID)
+	assert.Zero(t, running)
+}
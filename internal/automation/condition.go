@@ -0,0 +1,509 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Condition expressions.
+//
+// Rules and if actions can be guarded by a small expression language that
+// is parsed, never executed as code:
+//
+//	obs.streaming && event.scene_name != 'BRB'
+//	not (obs.recording or var.mode == "rehearsal")
+//	event.volume_db >= -20
+//
+// Operands are literals (numbers, 'single' or "double" quoted strings, true,
+// false, null) and names using the template namespaces: event.*, rule.*,
+// obs.*, var.* and bare event/variable keys. A name without a value is null.
+// Operators are ==, !=, <, <=, >, >=, && (and), || (or), ! (not), and
+// parentheses. Numbers compare by value whatever their type; ordering
+// operators need two numbers or two strings. The result is true when the
+// value is true, a non-zero number, or a non-empty string.
+
+// Limits that keep conditions cheap to evaluate.
+const (
+	maxConditionLength = 1000
+	maxConditionDepth  = 32
+)
+
+// exprNode is a parsed condition expression.
+type exprNode interface {
+	eval(r *templateResolver) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+type nameNode struct{ path string }
+
+type notNode struct{ operand exprNode }
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n literalNode) eval(*templateResolver) (interface{}, error) { return n.value, nil }
+
+func (n nameNode) eval(r *templateResolver) (interface{}, error) {
+	value, _, err := r.lookup(n.path)
+	return value, err
+}
+
+func (n notNode) eval(r *templateResolver) (interface{}, error) {
+	value, err := n.operand.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(value), nil
+}
+
+func (n binaryNode) eval(r *templateResolver) (interface{}, error) {
+	left, err := n.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Boolean operators short-circuit
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(r)
+		return truthy(right), err
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(r)
+		return truthy(right), err
+	}
+
+	right, err := n.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	}
+
+	cmp, err := compareValues(left, right)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.op, err)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default: // ">="
+		return cmp >= 0, nil
+	}
+}
+
+// truthy reports whether a value counts as true.
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+// toNumber converts any Go number to float64.
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// valuesEqual compares numbers by value and other values by type and value.
+func valuesEqual(a, b interface{}) bool {
+	if an, ok := toNumber(a); ok {
+		bn, ok := toNumber(b)
+		return ok && an == bn
+	}
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		bs, ok := b.(string)
+		return ok && a == bs
+	case bool:
+		bb, ok := b.(bool)
+		return ok && a == bb
+	}
+	return false
+}
+
+// compareValues orders two numbers or two strings.
+func compareValues(a, b interface{}) (int, error) {
+	if an, ok := toNumber(a); ok {
+		if bn, ok := toNumber(b); ok {
+			switch {
+			case an < bn:
+				return -1, nil
+			case an > bn:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bs, ok := b.(string); ok {
+			return strings.Compare(as, bs), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", describeValue(a), describeValue(b))
+}
+
+func describeValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ValidateCondition checks that expr is a well-formed condition.
+func ValidateCondition(expr string) error {
+	_, err := parseCondition(expr)
+	return err
+}
+
+// EvaluateCondition evaluates a condition expression against scope, reading
+// OBS state and variables through the executor as needed.
+func (e *Executor) EvaluateCondition(ctx context.Context, expr string, scope *TemplateScope) (bool, error) {
+	node, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	r := &templateResolver{ctx: ctx, executor: e, scope: scope}
+	if r.scope == nil {
+		r.scope = &TemplateScope{}
+	}
+	value, err := node.eval(r)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition '%s': %w", expr, err)
+	}
+	return truthy(value), nil
+}
+
+// Parser
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{} // Parsed literal for numbers and strings
+	pos   int
+}
+
+// tokenize splits a condition into tokens.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+
+		case c == '\'' || c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for i < len(expr) && expr[i] != c {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				b.WriteByte(expr[i])
+				i++
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: expr[start:i], value: b.String(), pos: start})
+
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9' && negativeAllowed(tokens):
+			start := i
+			i++
+			for i < len(expr) && (expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(expr[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s' at position %d", expr[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i], value: n, pos: start})
+
+		case isNameStart(c):
+			start := i
+			for i < len(expr) && (isNameStart(expr[i]) || expr[i] >= '0' && expr[i] <= '9' || expr[i] == '.') {
+				i++
+			}
+			word := expr[start:i]
+			switch word {
+			case "and":
+				tokens = append(tokens, token{kind: tokenOp, text: "&&", pos: start})
+			case "or":
+				tokens = append(tokens, token{kind: tokenOp, text: "||", pos: start})
+			case "not":
+				tokens = append(tokens, token{kind: tokenOp, text: "!", pos: start})
+			default:
+				tokens = append(tokens, token{kind: tokenName, text: word, pos: start})
+			}
+
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"} {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// negativeAllowed reports whether a '-' starts a negative number rather
+// than following an operand.
+func negativeAllowed(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokenOp, tokenLParen:
+		return true
+	}
+	return false
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c))
+}
+
+type conditionParser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// parseCondition parses and validates a condition expression.
+func parseCondition(expr string) (exprNode, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("condition is empty")
+	}
+	if len(expr) > maxConditionLength {
+		return nil, fmt.Errorf("condition is longer than %d characters", maxConditionLength)
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition: %w", err)
+	}
+	p := &conditionParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition: %w", err)
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("invalid condition: unexpected '%s' at position %d", tok.text, tok.pos)
+	}
+	return node, nil
+}
+
+func (p *conditionParser) peek() token { return p.tokens[p.pos] }
+
+func (p *conditionParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *conditionParser) enter() error {
+	p.depth++
+	if p.depth > maxConditionDepth {
+		return fmt.Errorf("expression nests deeper than %d levels", maxConditionDepth)
+	}
+	return nil
+}
+
+func (p *conditionParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseNot() (exprNode, error) {
+	if tok := p.peek(); tok.kind == tokenOp && tok.text == "!" {
+		p.next()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (exprNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind == tokenOp {
+		switch tok.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return binaryNode{op: tok.text, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseOperand() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at position %d", closing.pos)
+		}
+		return node, nil
+
+	case tokenNumber, tokenString:
+		return literalNode{value: tok.value}, nil
+
+	case tokenName:
+		switch tok.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		}
+		if err := validatePlaceholder(tok.text); err != nil {
+			return nil, fmt.Errorf("unknown name '%s' at position %d", tok.text, tok.pos)
+		}
+		return nameNode{path: tok.text}, nil
+
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("unexpected '%s' at position %d", tok.text, tok.pos)
+	}
+}
@@ -0,0 +1,269 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	mock := NewMockOBSClient()
+	mock.streaming = true
+	executor := NewExecutor(mock)
+	executor.SetVariableStore(db)
+	require.NoError(t, db.SetAutomationVariable(ctx, "mode", "live"))
+	require.NoError(t, db.SetAutomationVariable(ctx, "threshold", 3))
+
+	scope := &TemplateScope{
+		Rule: &Rule{ID: 4, Name: "guarded", RunCount: 2},
+		Event: &EventPayload{
+			EventType: EventSceneChanged,
+			Data: map[string]interface{}{
+				"scene_name": "Gaming",
+				"volume_db":  -12.5,
+				"count":      3,
+				"source":     map[string]interface{}{"kind": "game_capture"},
+			},
+		},
+	}
+
+	for expr, want := range map[string]bool{
+		"true":                                  true,
+		"null":                                  false,
+		"obs.streaming":                         true,
+		"obs.recording":                         false,
+		"!obs.recording":                        true,
+		"not obs.recording and obs.streaming":   true,
+		"obs.current_scene == 'Default'":        true,
+		`event.scene_name == "Gaming"`:          true,
+		"scene_name != 'BRB'":                   true,
+		"event.volume_db >= -20":                true,
+		"event.volume_db < -20":                 false,
+		"event.count == 3.0":                    true,
+		"event.count == var.threshold":          true,
+		"rule.run_count > 1 && rule.id == 4":    true,
+		"var.mode == 'live' || obs.recording":   true,
+		"(obs.recording || obs.streaming) && 1": true,
+		"event.source.kind == 'game_capture'":   true,
+		"event.missing == null":                 true,
+		"event.missing":                         false,
+		"event.scene_name < 'Hello'":            true,
+		"event.type == 'scene_changed'":         true,
+		"'' or 0":                               false,
+		"obs.recording and event.count > 'x'":   false, // Short-circuits before the type error
+	} {
+		got, err := executor.EvaluateCondition(ctx, expr, scope)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, want, got, expr)
+		}
+	}
+
+	t.Run("comparing mismatched types fails", func(t *testing.T) {
+		_, err := executor.EvaluateCondition(ctx, "event.scene_name > 3", scope)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot compare string with number")
+	})
+
+	t.Run("manual runs have no event", func(t *testing.T) {
+		met, err := executor.EvaluateCondition(ctx, "event.scene_name == 'Gaming'", &TemplateScope{Rule: scope.Rule})
+		require.NoError(t, err)
+		assert.False(t, met)
+	})
+}
+
+func TestValidateCondition(t *testing.T) {
+	assert.NoError(t, ValidateCondition("obs.streaming && (event.volume_db > -3.5 || var.force)"))
+
+	for expr, want := range map[string]string{
+		"":                        "empty",
+		"obs.streaming &&":        "unexpected end",
+		"(obs.streaming":          "expected ')'",
+		"obs.streaming)":          "unexpected ')'",
+		"a == b == c":             "unexpected '=='",
+		"obs.scene == 'x'":        "unknown name 'obs.scene'",
+		"rule.owner":              "unknown name",
+		"event.name == 'x":        "unterminated string",
+		"event.name = 'x'":        "unexpected character '='",
+		"event.count > 1.2.3":     "invalid number",
+		"obs.streaming; rm -rf /": "unexpected character ';'",
+		"len(event.name) > 3":     "unexpected '('",
+		"event.name == 'x' extra": "unexpected 'extra'",
+		"((((((((((((((((((((((((((((((((((true)))))))))))))))))))))))))))))))))": "nests deeper",
+	} {
+		err := ValidateCondition(expr)
+		if assert.Error(t, err, expr) {
+			assert.Contains(t, err.Error(), want, expr)
+		}
+	}
+
+	long := make([]byte, maxConditionLength+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	assert.ErrorContains(t, ValidateCondition(string(long)), "longer than")
+}
+
+func TestExecutorIfAction(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockOBSClient()
+	executor := NewExecutor(mock)
+
+	ifAction := Action{
+		Type:      ActionTypeIf,
+		Condition: "obs.streaming",
+		Then: []Action{
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}},
+		},
+		Else: []Action{
+			{Type: ActionTypeStartStreaming},
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name|Starting}}"}},
+		},
+	}
+
+	t.Run("takes the else branch", func(t *testing.T) {
+		mock.ClearActions()
+		result := executor.ExecuteActionContext(ctx, ifAction, 0)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "else", result.Branch)
+		require.Len(t, result.Results, 2)
+		assert.Equal(t, "Starting", result.Results[1].Parameters["scene_name"])
+		assert.Equal(t, []string{"start_streaming", "set_scene:Starting"}, mock.GetActions())
+	})
+
+	t.Run("takes the then branch", func(t *testing.T) {
+		mock.ClearActions()
+		result := executor.ExecuteActionContext(ctx, ifAction, 0)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "then", result.Branch)
+		require.Len(t, result.Results, 1)
+		assert.Equal(t, []string{"set_scene:Live"}, mock.GetActions())
+	})
+
+	t.Run("fails when a branch stops on error", func(t *testing.T) {
+		mock.ClearActions()
+		result := executor.ExecuteActionContext(ctx, Action{
+			Type:      ActionTypeIf,
+			Condition: "true",
+			Then: []Action{
+				{Type: ActionTypeSetScene, OnError: ActionErrorStop},
+				{Type: ActionTypeStopStreaming},
+			},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "action 0 (set_scene) failed")
+		assert.Len(t, result.Results, 1)
+		assert.Empty(t, mock.GetActions())
+	})
+
+	t.Run("branch failures that continue do not fail the if", func(t *testing.T) {
+		result := executor.ExecuteActionContext(ctx, Action{
+			Type:      ActionTypeIf,
+			Condition: "false",
+			Else:      []Action{{Type: ActionTypeSetScene}, {Type: ActionTypeSaveReplay}},
+		}, 0)
+		assert.True(t, result.Success, result.Error)
+		require.Len(t, result.Results, 2)
+		assert.False(t, result.Results[0].Success)
+	})
+
+	t.Run("cancellation reaches nested delays", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		time.AfterFunc(20*time.Millisecond, cancel)
+		result := executor.ExecuteActionContext(cctx, Action{
+			Type:      ActionTypeIf,
+			Condition: "true",
+			Then:      []Action{{Type: ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": 5000}}},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.True(t, result.Cancelled)
+	})
+}
+
+func TestEngineRuleConditions(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	eventRuleID, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "live-only",
+		Enabled:       true,
+		TriggerType:   TriggerTypeEvent,
+		TriggerConfig: map[string]interface{}{"event_type": EventInputMuteChanged},
+		Condition:     "obs.streaming && input_name == 'Microphone'",
+		CooldownMs:    60000,
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeIf, Condition: "muted", Then: []storage.RuleAction{
+				{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Muted"}},
+			}, Else: []storage.RuleAction{
+				{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Talking"}},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "guarded-manual",
+		Enabled:       true,
+		TriggerType:   TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Condition:     "obs.recording",
+		Actions:       []storage.RuleAction{{Type: ActionTypeSaveReplay}},
+	})
+	require.NoError(t, err)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	t.Run("event rule waits for its condition", func(t *testing.T) {
+		engine.HandleEvent(EventPayload{
+			EventType: EventInputMuteChanged,
+			Data:      map[string]interface{}{"input_name": "Microphone", "muted": true},
+		})
+		time.Sleep(100 * time.Millisecond)
+		assert.Empty(t, mock.GetActions(), "not streaming")
+
+		// A skipped event does not start the cooldown
+		mock.streaming = true
+		engine.HandleEvent(EventPayload{
+			EventType: EventInputMuteChanged,
+			Data:      map[string]interface{}{"input_name": "Microphone", "muted": true},
+		})
+		require.Eventually(t, func() bool {
+			executions, err := db.GetRuleExecutions(ctx, eventRuleID, 1)
+			return err == nil && len(executions) == 1 && executions[0].Status == storage.ExecutionStatusCompleted
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"set_scene:Muted"}, mock.GetActions())
+
+		executions, err := db.GetRuleExecutions(ctx, eventRuleID, 1)
+		require.NoError(t, err)
+		require.Len(t, executions[0].ActionResults, 1)
+		assert.Equal(t, "then", executions[0].ActionResults[0].Branch)
+		require.Len(t, executions[0].ActionResults[0].Results, 1)
+		assert.Equal(t, ActionTypeSetScene, executions[0].ActionResults[0].Results[0].ActionType)
+	})
+
+	t.Run("manual rule is skipped when its condition fails", func(t *testing.T) {
+		mock.ClearActions()
+		result, err := engine.ExecuteRuleByName(ctx, "guarded-manual", nil)
+		require.NoError(t, err)
+		assert.Equal(t, storage.ExecutionStatusSkipped, result.Status)
+		assert.Contains(t, result.Error, "condition not met")
+		assert.Zero(t, result.ExecutionID)
+
+		mock.recording = true
+		result, err = engine.ExecuteRuleByName(ctx, "guarded-manual", nil)
+		require.NoError(t, err)
+		assert.Equal(t, storage.ExecutionStatusCompleted, result.Status)
+		assert.Equal(t, []string{"save_replay"}, mock.GetActions())
+	})
+}
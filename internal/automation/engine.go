@@ -2,12 +2,15 @@ package automation
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/ironystock/agentic-obs/internal/logging"
 	"github.com/ironystock/agentic-obs/internal/storage"
 )
 
@@ -18,6 +21,9 @@ const (
 	defaultRetentionSweepInterval = 1 * time.Hour       // sweep hourly
 )
 
+// logger is shared by the engine and executor.
+var logger = logging.New("automation")
+
 // AutomationEngine manages automation rules and their execution.
 type AutomationEngine struct {
 	mu        sync.RWMutex
@@ -30,6 +36,14 @@ type AutomationEngine struct {
 	rules     map[int64]*Rule     // In-memory rule cache
 	cooldowns map[int64]time.Time // Last execution time per rule
 
+	// State of composite rules, keyed by rule ID. Guarded by e.mu.
+	composites map[int64]*compositeTracker
+	clock      Clock
+
+	// In-flight executions per rule, for concurrency policies
+	runsMu sync.Mutex
+	runs   map[int64]*ruleRuns
+
 	eventChan chan EventPayload
 	wg        sync.WaitGroup // Tracks in-flight processEvents + executeRule goroutines
 	running   bool
@@ -38,6 +52,10 @@ type AutomationEngine struct {
 	// Accessed via sync/atomic so callers don't need to hold e.mu.
 	droppedEvents atomic.Uint64
 
+	// Signatures of accepted webhook requests, for replay protection
+	webhookMu   sync.Mutex
+	webhookSeen map[string]time.Time
+
 	// Retention sweep configuration. Guarded by e.mu.
 	executionRetention     time.Duration
 	retentionSweepInterval time.Duration
@@ -54,10 +72,18 @@ func NewAutomationEngine(db *storage.DB, obsClient OBSClient) *AutomationEngine
 		executor:               NewExecutor(obsClient),
 		rules:                  make(map[int64]*Rule),
 		cooldowns:              make(map[int64]time.Time),
+		composites:             make(map[int64]*compositeTracker),
+		clock:                  realClock{},
+		runs:                   make(map[int64]*ruleRuns),
 		eventChan:              make(chan EventPayload, 100),
+		webhookSeen:            make(map[string]time.Time),
 		executionRetention:     defaultExecutionRetention,
 		retentionSweepInterval: defaultRetentionSweepInterval,
 	}
+	if db != nil {
+		engine.executor.SetVariableStore(db)
+		engine.executor.SetMacroStore(db)
+	}
 
 	return engine
 }
@@ -84,7 +110,7 @@ func (e *AutomationEngine) Start() error {
 	for _, rule := range e.rules {
 		if rule.Enabled && rule.TriggerType == TriggerTypeSchedule {
 			if err := e.scheduler.Schedule(rule); err != nil {
-				log.Printf("[Automation] Warning: failed to schedule rule '%s': %v", rule.Name, err)
+				logger.Warnf("failed to schedule rule '%s': %v", rule.Name, err)
 			}
 		}
 	}
@@ -98,10 +124,18 @@ func (e *AutomationEngine) Start() error {
 	go e.retentionSweeper()
 
 	e.running = true
-	log.Printf("[Automation] Engine started with %d rules", len(e.rules))
+	logger.Infof("Engine started with %d rules", len(e.rules))
 	return nil
 }
 
+// SetClock replaces the clock composite triggers read time from. Must be
+// called before Start.
+func (e *AutomationEngine) SetClock(c Clock) {
+	e.mu.Lock()
+	e.clock = c
+	e.mu.Unlock()
+}
+
 // SetExecutionRetention overrides how long execution history is kept
 // before the background sweeper deletes it. Must be called before Start
 // or the next sweep tick will use the new value.
@@ -140,7 +174,7 @@ func (e *AutomationEngine) retentionSweeper() {
 			return
 		case <-ticker.C:
 			if _, err := e.RunRetentionSweep(); err != nil && e.ctx.Err() == nil {
-				log.Printf("[Automation] Retention sweep failed: %v", err)
+				logger.Errorf("Retention sweep failed: %v", err)
 			}
 		}
 	}
@@ -162,7 +196,7 @@ func (e *AutomationEngine) RunRetentionSweep() (int64, error) {
 		return 0, err
 	}
 	if deleted > 0 {
-		log.Printf("[Automation] Retention sweep: removed %d executions older than %s", deleted, retention)
+		logger.Infof("Retention sweep: removed %d executions older than %s", deleted, retention)
 	}
 	return deleted, nil
 }
@@ -177,6 +211,10 @@ func (e *AutomationEngine) Stop() {
 		return
 	}
 	e.running = false
+	for id, tracker := range e.composites {
+		tracker.close()
+		delete(e.composites, id)
+	}
 	e.mu.Unlock()
 
 	e.cancel()
@@ -187,7 +225,7 @@ func (e *AutomationEngine) Stop() {
 
 	close(e.eventChan)
 	e.wg.Wait()
-	log.Println("[Automation] Engine stopped")
+	logger.Infof("Engine stopped")
 }
 
 // IsRunning returns whether the engine is running.
@@ -207,7 +245,7 @@ func (e *AutomationEngine) HandleEvent(payload EventPayload) {
 	case e.eventChan <- payload:
 	default:
 		dropped := e.droppedEvents.Add(1)
-		log.Printf("[Automation] Event buffer full, dropping event: %s (total dropped: %d)", payload.EventType, dropped)
+		logger.Warnf("Event buffer full, dropping event: %s (total dropped: %d)", payload.EventType, dropped)
 	}
 }
 
@@ -228,28 +266,60 @@ func (e *AutomationEngine) TriggerRule(ruleID int64) error {
 	}
 
 	e.wg.Add(1)
-	go e.executeRule(rule, nil)
+	go e.executeGuardedRule(rule, nil)
 	return nil
 }
 
 // TriggerRuleByName manually triggers a rule by name.
 func (e *AutomationEngine) TriggerRuleByName(name string) error {
-	e.mu.RLock()
-	var rule *Rule
-	for _, r := range e.rules {
-		if r.Name == name {
-			rule = r
-			break
-		}
+	rule := e.findRuleByName(name)
+	if rule == nil {
+		return &RuleNotFoundError{Name: name}
 	}
-	e.mu.RUnlock()
 
+	e.wg.Add(1)
+	go e.executeGuardedRule(rule, nil)
+	return nil
+}
+
+// ExecuteRuleByName runs a rule synchronously and returns its result.
+// A rule whose condition does not hold is skipped without being recorded.
+// Cancelling ctx stops the action sequence before the next action (or
+// mid-delay) and records the execution as cancelled. onProgress, if
+// non-nil, is called after each action completes.
+func (e *AutomationEngine) ExecuteRuleByName(ctx context.Context, name string, onProgress ProgressFunc) (*ExecutionResult, error) {
+	rule := e.findRuleByName(name)
 	if rule == nil {
-		return &RuleNotFoundError{Name: name}
+		return nil, &RuleNotFoundError{Name: name}
 	}
 
 	e.wg.Add(1)
-	go e.executeRule(rule, nil)
+	defer e.wg.Done()
+
+	if !e.conditionMet(ctx, rule, nil) {
+		now := time.Now()
+		return &ExecutionResult{
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			TriggerType: rule.TriggerType,
+			StartedAt:   now,
+			CompletedAt: now,
+			Status:      storage.ExecutionStatusSkipped,
+			Error:       fmt.Sprintf("condition not met: %s", rule.Condition),
+		}, nil
+	}
+	return e.runRule(ctx, rule, nil, onProgress), nil
+}
+
+// findRuleByName looks up a loaded rule by name.
+func (e *AutomationEngine) findRuleByName(name string) *Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, r := range e.rules {
+		if r.Name == name {
+			return r
+		}
+	}
 	return nil
 }
 
@@ -277,13 +347,17 @@ func (e *AutomationEngine) loadRulesLocked() error {
 
 	// Clear and rebuild
 	e.rules = make(map[int64]*Rule)
+	for id := range e.composites {
+		e.untrackCompositeLocked(id)
+	}
 
 	for _, dbRule := range dbRules {
 		rule := convertStorageRule(dbRule)
 		e.rules[rule.ID] = rule
+		e.trackCompositeLocked(rule)
 	}
 
-	log.Printf("[Automation] Loaded %d enabled rules", len(e.rules))
+	logger.Infof("Loaded %d enabled rules", len(e.rules))
 	return nil
 }
 
@@ -300,28 +374,50 @@ func (e *AutomationEngine) processEvents() {
 // Cooldown is recorded at dispatch time (not at execute-end) so that a burst
 // of events arriving faster than executeRule can complete cannot re-trigger
 // the same rule. Because cooldown check + record must be atomic, the match
-// loop runs under a write lock.
+// loop runs under a write lock. Rule conditions may query OBS, so they are
+// evaluated with the lock released and their rules recorded afterwards.
 func (e *AutomationEngine) dispatchEvent(payload EventPayload) {
 	e.mu.Lock()
 
 	// Find matching rules, recording cooldown atomically for each match.
-	var matching []*Rule
+	// Schedule rules that fire after this event are delayed rather than run.
+	var matching, conditional, delayed []*Rule
 	now := time.Now()
 	for _, rule := range e.rules {
 		if !rule.Enabled {
 			continue
 		}
-		if rule.TriggerType != TriggerTypeEvent {
+		if rule.TriggerType == TriggerTypeSchedule && rule.GetAfterEvent() == payload.EventType {
+			if ok, _ := e.matchesFilter(rule.GetEventFilter(), payload.Data); ok && e.checkCooldownLocked(rule) {
+				if rule.CooldownMs > 0 {
+					e.cooldowns[rule.ID] = now
+				}
+				delayed = append(delayed, rule)
+			}
 			continue
 		}
-		if rule.GetEventType() != payload.EventType {
+		switch rule.TriggerType {
+		case TriggerTypeEvent:
+			if rule.GetEventType() != payload.EventType {
+				continue
+			}
+			if ok, reason := e.matchesFilter(rule.GetEventFilter(), payload.Data); !ok {
+				logger.Debugf("Rule '%s' skipped (filter): %s", rule.Name, reason)
+				continue
+			}
+		case TriggerTypeComposite:
+			if tracker := e.composites[rule.ID]; tracker == nil || !tracker.observe(payload) {
+				continue
+			}
+		default:
 			continue
 		}
-		if !e.matchesFilter(rule.GetEventFilter(), payload.Data) {
+		if !e.checkCooldownLocked(rule) {
+			logger.Debugf("Rule '%s' skipped (cooldown)", rule.Name)
 			continue
 		}
-		if !e.checkCooldownLocked(rule) {
-			log.Printf("[Automation] Rule '%s' skipped (cooldown)", rule.Name)
+		if rule.Condition != "" {
+			conditional = append(conditional, rule)
 			continue
 		}
 		if rule.CooldownMs > 0 {
@@ -330,8 +426,36 @@ func (e *AutomationEngine) dispatchEvent(payload EventPayload) {
 		matching = append(matching, rule)
 	}
 
+	scheduler := e.scheduler
 	e.mu.Unlock()
 
+	for _, rule := range delayed {
+		if scheduler != nil {
+			scheduler.ScheduleAfterEvent(rule.ID, payload)
+		}
+	}
+
+	if len(conditional) > 0 {
+		var met []*Rule
+		for _, rule := range conditional {
+			if e.conditionMet(e.ctx, rule, &payload) {
+				met = append(met, rule)
+			}
+		}
+
+		e.mu.Lock()
+		for _, rule := range met {
+			if !e.checkCooldownLocked(rule) {
+				continue
+			}
+			if rule.CooldownMs > 0 {
+				e.cooldowns[rule.ID] = now
+			}
+			matching = append(matching, rule)
+		}
+		e.mu.Unlock()
+	}
+
 	// Sort by priority (higher first)
 	sort.Slice(matching, func(i, j int) bool {
 		return matching[i].Priority > matching[j].Priority
@@ -344,24 +468,70 @@ func (e *AutomationEngine) dispatchEvent(payload EventPayload) {
 	}
 }
 
-// matchesFilter checks if event data matches the rule's event filter.
-func (e *AutomationEngine) matchesFilter(filter map[string]interface{}, data map[string]interface{}) bool {
-	if filter == nil || len(filter) == 0 {
-		return true
+// trackCompositeLocked starts tracking an enabled composite rule, dropping
+// any state it had. Caller must hold e.mu.
+func (e *AutomationEngine) trackCompositeLocked(rule *Rule) {
+	e.untrackCompositeLocked(rule.ID)
+	if !rule.Enabled || rule.TriggerType != TriggerTypeComposite {
+		return
+	}
+	spec, err := ParseCompositeConfig(rule.TriggerConfig)
+	if err != nil {
+		logger.Warnf("failed to load composite trigger of rule '%s': %v", rule.Name, err)
+		return
+	}
+	ruleID := rule.ID
+	e.composites[ruleID] = newCompositeTracker(spec, e.clock, func(payload EventPayload) {
+		e.fireComposite(ruleID, payload)
+	})
+}
+
+// untrackCompositeLocked drops a composite rule's state, cancelling a
+// pending hold or debounce. Caller must hold e.mu.
+func (e *AutomationEngine) untrackCompositeLocked(ruleID int64) {
+	if tracker, exists := e.composites[ruleID]; exists {
+		tracker.close()
+		delete(e.composites, ruleID)
 	}
+}
 
-	for key, expected := range filter {
-		actual, exists := data[key]
-		if !exists {
-			return false
-		}
-		// Simple equality check
-		if actual != expected {
-			return false
-		}
+// fireComposite runs a composite rule whose hold or debounce has elapsed,
+// with the event that completed its trigger.
+func (e *AutomationEngine) fireComposite(ruleID int64, payload EventPayload) {
+	e.mu.Lock()
+	rule := e.rules[ruleID]
+	if !e.running || rule == nil || !e.checkCooldownLocked(rule) {
+		e.mu.Unlock()
+		return
 	}
+	if rule.CooldownMs > 0 {
+		e.cooldowns[rule.ID] = time.Now()
+	}
+	e.wg.Add(1)
+	e.mu.Unlock()
+
+	logger.Infof("Composite trigger for rule '%s'", rule.Name)
+	e.executeGuardedRule(rule, &payload)
+}
+
+// RunMacro runs a macro outside any rule, with args. The run is cancelled
+// when either ctx or the engine context is done.
+func (e *AutomationEngine) RunMacro(ctx context.Context, name string, args map[string]interface{}) ([]ActionResult, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(e.ctx, cancel)
+	defer stop()
+
+	return e.executor.RunMacro(runCtx, name, args, nil)
+}
 
-	return true
+// matchesFilter checks if event data matches the rule's event filter. When
+// it does not, the reason says which condition failed.
+func (e *AutomationEngine) matchesFilter(filter map[string]interface{}, data map[string]interface{}) (bool, string) {
+	if len(filter) == 0 {
+		return true, ""
+	}
+	return matchFilter(filter, data)
 }
 
 // checkCooldownLocked returns true if the rule can be executed (not in cooldown).
@@ -380,19 +550,85 @@ func (e *AutomationEngine) checkCooldownLocked(rule *Rule) bool {
 	return time.Since(lastRun) >= cooldown
 }
 
-// executeScheduledRule is called by the scheduler.
-func (e *AutomationEngine) executeScheduledRule(rule *Rule) {
-	log.Printf("[Automation] Scheduled trigger for rule '%s'", rule.Name)
+// conditionMet reports whether the rule's condition holds for the payload.
+// Rules without a condition always pass; evaluation errors are logged and
+// count as not met.
+func (e *AutomationEngine) conditionMet(ctx context.Context, rule *Rule, payload *EventPayload) bool {
+	if rule.Condition == "" {
+		return true
+	}
+	met, err := e.executor.EvaluateCondition(ctx, rule.Condition, &TemplateScope{Rule: rule, Event: payload})
+	if err != nil {
+		logger.Warnf("Rule '%s' skipped: %v", rule.Name, err)
+		return false
+	}
+	if !met {
+		logger.Debugf("Rule '%s' skipped (condition not met)", rule.Name)
+	}
+	return met
+}
+
+// executeScheduledRule is called by the scheduler, with the triggering
+// event for rules that fire after an event.
+func (e *AutomationEngine) executeScheduledRule(rule *Rule, payload *EventPayload) {
+	logger.Infof("Scheduled trigger for rule '%s'", rule.Name)
 	e.wg.Add(1)
-	e.executeRule(rule, nil)
+	e.executeGuardedRule(rule, payload)
+}
+
+// executeGuardedRule runs a rule if its condition holds for payload, which
+// is nil for rules without a triggering event. Like executeRule, it must be
+// preceded by e.wg.Add(1).
+func (e *AutomationEngine) executeGuardedRule(rule *Rule, payload *EventPayload) {
+	defer e.wg.Done()
+	if !e.conditionMet(e.ctx, rule, payload) {
+		return
+	}
+	e.runRule(e.ctx, rule, payload, nil)
 }
 
 // executeRule runs a single automation rule. Every call path into this
 // method must precede it with e.wg.Add(1); executeRule matches with Done.
 func (e *AutomationEngine) executeRule(rule *Rule, payload *EventPayload) {
 	defer e.wg.Done()
+	e.runRule(e.ctx, rule, payload, nil)
+}
+
+// runRule executes a rule's actions in order and records the execution.
+// The run is cancelled when either ctx or the engine context is done.
+//
+// The rule's concurrency policy is applied first. An execution that is
+// skipped, or cancelled while queued, is not recorded.
+func (e *AutomationEngine) runRule(ctx context.Context, rule *Rule, payload *EventPayload, onProgress ProgressFunc) *ExecutionResult {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(e.ctx, cancel)
+	defer stop()
+
+	run, reason, cancelled := e.acquireRun(runCtx, rule, cancel)
+	if run == nil {
+		now := time.Now()
+		status := storage.ExecutionStatusSkipped
+		if cancelled {
+			status = storage.ExecutionStatusCancelled
+		}
+		return &ExecutionResult{
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			TriggerType: rule.TriggerType,
+			StartedAt:   now,
+			CompletedAt: now,
+			Status:      status,
+			Error:       reason,
+		}
+	}
+	defer e.releaseRun(rule.ID, run)
+
 	startTime := time.Now()
-	log.Printf("[Automation] Executing rule '%s' (ID: %d)", rule.Name, rule.ID)
+	logger.Infof("Executing rule '%s' (ID: %d)", rule.Name, rule.ID)
+
+	// Storage writes must outlive cancellation so the record is always finalized.
+	dbCtx := context.WithoutCancel(e.ctx)
 
 	// Create execution record
 	exec := storage.RuleExecution{
@@ -401,32 +637,43 @@ func (e *AutomationEngine) executeRule(rule *Rule, payload *EventPayload) {
 		TriggerType: rule.TriggerType,
 		StartedAt:   startTime,
 		Status:      storage.ExecutionStatusRunning,
+		Revision:    rule.Revision,
 	}
 	if payload != nil {
 		exec.TriggerData = payload.Data
 	}
 
-	execID, err := e.storage.CreateRuleExecution(e.ctx, exec)
+	execID, err := e.storage.CreateRuleExecution(dbCtx, exec)
 	if err != nil {
-		log.Printf("[Automation] Warning: failed to create execution record: %v", err)
+		logger.Warnf("failed to create execution record: %v", err)
 	}
 	exec.ID = execID
+	e.trackRun(run, execID, 0)
 
 	// Execute actions sequentially
-	var actionResults []storage.ActionResult
+	var results []ActionResult
 	var execError error
 
 	for i, action := range rule.Actions {
-		result := e.executor.ExecuteAction(action, i)
-		actionResults = append(actionResults, storage.ActionResult{
-			ActionType: result.ActionType,
-			Index:      result.Index,
-			Success:    result.Success,
-			Error:      result.Error,
-			DurationMs: result.DurationMs,
-		})
+		if runCtx.Err() != nil {
+			cancelled = true
+			break
+		}
+		e.trackRun(run, execID, i)
+
+		result := e.executor.ExecuteActionWithScope(runCtx, action, i, &TemplateScope{Rule: rule, Event: payload})
+		results = append(results, result)
+
+		if result.Cancelled {
+			cancelled = true
+			break
+		}
 
-		if !result.Success && action.GetOnError() == ActionErrorStop {
+		if onProgress != nil {
+			onProgress(i+1, len(rule.Actions), fmt.Sprintf("Action %d/%d (%s) finished", i+1, len(rule.Actions), action.Type))
+		}
+
+		if action.haltsOn(result) {
 			execError = &ActionError{
 				ActionType: action.Type,
 				Index:      i,
@@ -436,30 +683,120 @@ func (e *AutomationEngine) executeRule(rule *Rule, payload *EventPayload) {
 		}
 	}
 
+	// The actions are done; stop listing the execution as running before its
+	// record is finalized
+	e.finishRun(run)
+
 	// Update execution record
 	completedAt := time.Now()
 	exec.CompletedAt = &completedAt
 	exec.DurationMs = time.Since(startTime).Milliseconds()
-	exec.ActionResults = actionResults
-
-	if execError != nil {
+	exec.ActionResults = convertActionResults(results)
+
+	switch {
+	case cancelled:
+		exec.Status = storage.ExecutionStatusCancelled
+		exec.Error = "execution cancelled"
+		if abandoned := abandonedAction(results); abandoned != nil {
+			exec.Error += fmt.Sprintf("; action %d (%s) was abandoned while its OBS request was in flight and may still have taken effect", abandoned.Index, abandoned.ActionType)
+		}
+		logger.Warnf("Rule '%s' cancelled after %d of %d actions", rule.Name, len(results), len(rule.Actions))
+	case execError != nil:
 		exec.Status = storage.ExecutionStatusFailed
 		exec.Error = execError.Error()
-		log.Printf("[Automation] Rule '%s' failed: %v", rule.Name, execError)
-	} else {
+		logger.Errorf("Rule '%s' failed: %v", rule.Name, execError)
+	default:
 		exec.Status = storage.ExecutionStatusCompleted
-		log.Printf("[Automation] Rule '%s' completed in %dms", rule.Name, exec.DurationMs)
+		logger.Infof("Rule '%s' completed in %dms", rule.Name, exec.DurationMs)
 	}
 
 	if execID > 0 {
-		if err := e.storage.UpdateRuleExecution(e.ctx, exec); err != nil {
-			log.Printf("[Automation] Warning: failed to update execution record: %v", err)
+		if err := e.storage.UpdateRuleExecution(dbCtx, exec); err != nil {
+			logger.Warnf("failed to update execution record: %v", err)
 		}
 	}
 
 	// Update rule run stats
-	if err := e.storage.UpdateRuleRunStats(e.ctx, rule.ID, startTime); err != nil {
-		log.Printf("[Automation] Warning: failed to update rule stats: %v", err)
+	if err := e.storage.UpdateRuleRunStats(dbCtx, rule.ID, startTime); err != nil {
+		logger.Warnf("failed to update rule stats: %v", err)
+	}
+
+	e.recordAudit(dbCtx, rule, exec, results)
+
+	return &ExecutionResult{
+		ExecutionID:   execID,
+		RuleID:        rule.ID,
+		RuleName:      rule.Name,
+		TriggerType:   rule.TriggerType,
+		TriggerData:   exec.TriggerData,
+		StartedAt:     startTime,
+		CompletedAt:   completedAt,
+		Status:        exec.Status,
+		ActionResults: results,
+		Error:         exec.Error,
+		DurationMs:    exec.DurationMs,
+	}
+}
+
+// recordAudit adds the execution to the action history, attributed to the
+// rule, with each action as a child record.
+func (e *AutomationEngine) recordAudit(ctx context.Context, rule *Rule, exec storage.RuleExecution, results []ActionResult) {
+	input, _ := json.Marshal(map[string]interface{}{
+		"execution_id": exec.ID,
+		"trigger_type": exec.TriggerType,
+		"trigger_data": exec.TriggerData,
+	})
+	output, _ := json.Marshal(map[string]interface{}{
+		"status": exec.Status,
+		"error":  exec.Error,
+	})
+
+	actorID := strconv.FormatInt(rule.ID, 10)
+	parentID, err := e.storage.RecordAction(ctx, storage.ActionRecord{
+		Action:     fmt.Sprintf("Run automation rule '%s'", rule.Name),
+		ToolName:   "automation",
+		Input:      string(input),
+		Output:     string(output),
+		Success:    exec.Status == storage.ExecutionStatusCompleted,
+		DurationMs: exec.DurationMs,
+		ActorType:  storage.ActorAutomation,
+		ActorID:    actorID,
+	})
+	if err != nil {
+		logger.Warnf("failed to record rule '%s' in action history: %v", rule.Name, err)
+		return
+	}
+
+	for _, result := range results {
+		var params []byte
+		if result.Parameters != nil {
+			params, _ = json.Marshal(result.Parameters)
+		} else if result.Index < len(rule.Actions) {
+			action := rule.Actions[result.Index]
+			switch action.Type {
+			case ActionTypeIf:
+				params, _ = json.Marshal(map[string]interface{}{"condition": action.Condition, "branch": result.Branch})
+			case ActionTypeParallel:
+				params, _ = json.Marshal(map[string]interface{}{"branches": len(action.Branches)})
+			case ActionTypeRepeat:
+				params, _ = json.Marshal(map[string]interface{}{"count": action.Count, "until": action.Until, "iterations": len(result.Iterations)})
+			default:
+				params, _ = json.Marshal(action.Parameters)
+			}
+		}
+		if _, err := e.storage.RecordAction(ctx, storage.ActionRecord{
+			Action:     fmt.Sprintf("Automation action %d (%s)", result.Index+1, result.ActionType),
+			ToolName:   result.ActionType,
+			Input:      string(params),
+			Output:     result.Error,
+			Success:    result.Success,
+			DurationMs: result.DurationMs,
+			ParentID:   parentID,
+			ActorType:  storage.ActorAutomation,
+			ActorID:    actorID,
+		}); err != nil {
+			logger.Warnf("failed to record action %d of rule '%s' in action history: %v", result.Index+1, rule.Name, err)
+		}
 	}
 }
 
@@ -473,6 +810,7 @@ func (e *AutomationEngine) NotifyRuleChange(ruleID int64, deleted bool) {
 			}
 			delete(e.rules, ruleID)
 		}
+		e.untrackCompositeLocked(ruleID)
 		delete(e.cooldowns, ruleID)
 		e.mu.Unlock()
 		return
@@ -481,7 +819,7 @@ func (e *AutomationEngine) NotifyRuleChange(ruleID int64, deleted bool) {
 	// Reload the specific rule
 	dbRule, err := e.storage.GetAutomationRule(e.ctx, ruleID)
 	if err != nil {
-		log.Printf("[Automation] Warning: failed to reload rule %d: %v", ruleID, err)
+		logger.Warnf("failed to reload rule %d: %v", ruleID, err)
 		return
 	}
 
@@ -498,7 +836,7 @@ func (e *AutomationEngine) NotifyRuleChange(ruleID int64, deleted bool) {
 		// Add new schedule if applicable
 		if rule.Enabled && rule.TriggerType == TriggerTypeSchedule {
 			if err := e.scheduler.Schedule(rule); err != nil {
-				log.Printf("[Automation] Warning: failed to schedule rule '%s': %v", rule.Name, err)
+				logger.Warnf("failed to schedule rule '%s': %v", rule.Name, err)
 			}
 		}
 	}
@@ -508,19 +846,11 @@ func (e *AutomationEngine) NotifyRuleChange(ruleID int64, deleted bool) {
 	} else {
 		delete(e.rules, ruleID)
 	}
+	e.trackCompositeLocked(rule)
 }
 
 // convertStorageRule converts a storage rule to an automation rule.
 func convertStorageRule(dbRule *storage.AutomationRule) *Rule {
-	actions := make([]Action, len(dbRule.Actions))
-	for i, a := range dbRule.Actions {
-		actions[i] = Action{
-			Type:       a.Type,
-			Parameters: a.Parameters,
-			OnError:    a.OnError,
-		}
-	}
-
 	return &Rule{
 		ID:            dbRule.ID,
 		Name:          dbRule.Name,
@@ -528,9 +858,12 @@ func convertStorageRule(dbRule *storage.AutomationRule) *Rule {
 		Enabled:       dbRule.Enabled,
 		TriggerType:   dbRule.TriggerType,
 		TriggerConfig: dbRule.TriggerConfig,
-		Actions:       actions,
+		Actions:       convertStorageActions(dbRule.Actions),
+		Condition:     dbRule.Condition,
+		Concurrency:   dbRule.Concurrency,
 		CooldownMs:    dbRule.CooldownMs,
 		Priority:      dbRule.Priority,
+		Revision:      dbRule.Revision,
 		CreatedAt:     dbRule.CreatedAt,
 		UpdatedAt:     dbRule.UpdatedAt,
 		LastRun:       dbRule.LastRun,
@@ -538,6 +871,79 @@ func convertStorageRule(dbRule *storage.AutomationRule) *Rule {
 	}
 }
 
+// convertStorageActions converts stored actions, including nested action
+// lists, to automation actions.
+func convertStorageActions(dbActions []storage.RuleAction) []Action {
+	if dbActions == nil {
+		return nil
+	}
+	actions := make([]Action, len(dbActions))
+	for i, a := range dbActions {
+		actions[i] = Action{
+			Type:       a.Type,
+			Parameters: a.Parameters,
+			OnError:    a.OnError,
+			OnMissing:  a.OnMissing,
+			Condition:  a.Condition,
+			Then:       convertStorageActions(a.Then),
+			Else:       convertStorageActions(a.Else),
+			Actions:    convertStorageActions(a.Actions),
+			Count:      a.Count,
+			Until:      a.Until,
+			TimeoutMs:  a.TimeoutMs,
+			Fallback:   convertStorageActions(a.Fallback),
+		}
+		for _, branch := range a.Branches {
+			actions[i].Branches = append(actions[i].Branches, convertStorageActions(branch))
+		}
+		if a.Retry != nil {
+			actions[i].Retry = &RetryPolicy{
+				MaxAttempts:  a.Retry.MaxAttempts,
+				BackoffMs:    a.Retry.BackoffMs,
+				MaxBackoffMs: a.Retry.MaxBackoffMs,
+				Jitter:       a.Retry.Jitter,
+			}
+		}
+	}
+	return actions
+}
+
+// convertActionResults converts action results, including the results of
+// nested action lists, for storage.
+func convertActionResults(results []ActionResult) []storage.ActionResult {
+	if results == nil {
+		return nil
+	}
+	out := make([]storage.ActionResult, len(results))
+	for i, r := range results {
+		out[i] = storage.ActionResult{
+			ActionType: r.ActionType,
+			Index:      r.Index,
+			Success:    r.Success,
+			Error:      r.Error,
+			DurationMs: r.DurationMs,
+			Cancelled:  r.Cancelled,
+			Abandoned:  r.Abandoned,
+			Parameters: r.Parameters,
+			Branch:     r.Branch,
+			Macro:      r.Macro,
+			Results:    convertActionResults(r.Results),
+			Fallback:   convertActionResults(r.Fallback),
+			Recovered:  r.Recovered,
+		}
+		for _, branch := range r.Branches {
+			out[i].Branches = append(out[i].Branches, convertActionResults(branch))
+		}
+		for _, iteration := range r.Iterations {
+			out[i].Iterations = append(out[i].Iterations, convertActionResults(iteration))
+		}
+		for _, a := range r.Attempts {
+			out[i].Attempts = append(out[i].Attempts, storage.ActionAttempt(a))
+		}
+	}
+	return out
+}
+
 // Error types
 
 // RuleNotFoundError is returned when a rule is not found.
@@ -3,6 +3,7 @@ package automation
 import (
 	"context"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -19,6 +20,8 @@ type MockOBSClient struct {
 	actions       []string
 	currentScene  string
 	muted         map[string]bool
+	streaming     bool
+	recording     bool
 	failNextCall  bool
 	eventCallback obs.EventCallback
 }
@@ -42,10 +45,23 @@ func (m *MockOBSClient) SetCurrentScene(name string) error {
 	return nil
 }
 
+func (m *MockOBSClient) GetSceneList() ([]string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return []string{m.currentScene}, m.currentScene, nil
+}
+
+func (m *MockOBSClient) GetRecordingStatus() (*obs.RecordingStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &obs.RecordingStatus{Active: m.recording}, nil
+}
+
 func (m *MockOBSClient) StartRecording() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.actions = append(m.actions, "start_recording")
+	m.recording = true
 	return nil
 }
 
@@ -70,10 +86,17 @@ func (m *MockOBSClient) ResumeRecording() error {
 	return nil
 }
 
+func (m *MockOBSClient) GetStreamingStatus() (*obs.StreamingStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &obs.StreamingStatus{Active: m.streaming}, nil
+}
+
 func (m *MockOBSClient) StartStreaming() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.actions = append(m.actions, "start_streaming")
+	m.streaming = true
 	return nil
 }
 
@@ -855,9 +878,134 @@ func TestExecutorDelay(t *testing.T) {
 	assert.GreaterOrEqual(t, elapsed.Milliseconds(), int64(100))
 }
 
+func TestExecutorDelayCancelled(t *testing.T) {
+	mock := NewMockOBSClient()
+	executor := NewExecutor(mock)
+
+	action := Action{
+		Type: ActionTypeDelay,
+		Parameters: map[string]interface{}{
+			"delay_ms": float64(5000),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := executor.ExecuteActionContext(ctx, action, 0)
+	elapsed := time.Since(start)
+
+	assert.False(t, result.Success)
+	assert.True(t, result.Cancelled)
+	assert.Less(t, elapsed, time.Second)
+
+	t.Run("skips OBS call when already cancelled", func(t *testing.T) {
+		result := executor.ExecuteActionContext(ctx, Action{Type: ActionTypeStartRecording}, 1)
+		assert.True(t, result.Cancelled)
+		assert.Empty(t, mock.GetActions())
+	})
+}
+
+func TestEngineExecuteRuleByName(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "sync-rule",
+		Enabled:       true,
+		TriggerType:   TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeStartRecording},
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}},
+		},
+	})
+	require.NoError(t, err)
+
+	delayedID, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "delayed-rule",
+		Enabled:       true,
+		TriggerType:   TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeStartRecording},
+			{Type: ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": float64(5000)}},
+			{Type: ActionTypeStopRecording},
+		},
+	})
+	require.NoError(t, err)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	t.Run("reports progress per action", func(t *testing.T) {
+		var progress []int
+		result, err := engine.ExecuteRuleByName(ctx, "sync-rule", func(completed, total int, message string) {
+			assert.Equal(t, 2, total)
+			progress = append(progress, completed)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, storage.ExecutionStatusCompleted, result.Status)
+		assert.Equal(t, []int{1, 2}, progress)
+		assert.Len(t, result.ActionResults, 2)
+		assert.NotZero(t, result.ExecutionID)
+	})
+
+	t.Run("records the run in action history", func(t *testing.T) {
+		result, err := engine.ExecuteRuleByName(ctx, "sync-rule", nil)
+		require.NoError(t, err)
+
+		actions, err := db.FilterActions(ctx, storage.ActionFilter{ActorType: storage.ActorAutomation, ActorID: strconv.FormatInt(result.RuleID, 10)}, 1)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "automation", actions[0].ToolName)
+		assert.Contains(t, actions[0].Action, "sync-rule")
+		assert.True(t, actions[0].Success)
+
+		children, err := db.GetChildActions(ctx, actions[0].ID)
+		require.NoError(t, err)
+		require.Len(t, children, 2)
+		assert.Equal(t, ActionTypeStartRecording, children[0].ToolName)
+		assert.Equal(t, ActionTypeSetScene, children[1].ToolName)
+		assert.Equal(t, storage.ActorAutomation, children[1].ActorType)
+	})
+
+	t.Run("cancellation records cancelled status", func(t *testing.T) {
+		mock.ClearActions()
+
+		runCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		result, err := engine.ExecuteRuleByName(runCtx, "delayed-rule", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, storage.ExecutionStatusCancelled, result.Status)
+		require.Len(t, result.ActionResults, 2)
+		assert.True(t, result.ActionResults[1].Cancelled)
+		assert.NotContains(t, mock.GetActions(), "stop_recording")
+
+		executions, err := db.GetRuleExecutions(ctx, delayedID, 1)
+		require.NoError(t, err)
+		require.Len(t, executions, 1)
+		assert.Equal(t, storage.ExecutionStatusCancelled, executions[0].Status)
+		assert.NotNil(t, executions[0].CompletedAt)
+	})
+
+	t.Run("returns error for non-existent rule", func(t *testing.T) {
+		_, err := engine.ExecuteRuleByName(ctx, "missing", nil)
+		assert.IsType(t, &RuleNotFoundError{}, err)
+	})
+}
+
 func TestScheduleManager(t *testing.T) {
 	executed := make(chan string, 10)
-	executor := func(rule *Rule) {
+	executor := func(rule *Rule, _ *EventPayload) {
 		executed <- rule.Name
 	}
 
@@ -1,8 +1,10 @@
 package automation
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"time"
 
 	"github.com/ironystock/agentic-obs/internal/obs"
@@ -12,15 +14,18 @@ import (
 // This matches the mcp.OBSClient interface.
 type OBSClient interface {
 	// Scene operations
+	GetSceneList() ([]string, string, error)
 	SetCurrentScene(name string) error
 
 	// Recording operations
+	GetRecordingStatus() (*obs.RecordingStatus, error)
 	StartRecording() error
 	StopRecording() (string, error)
 	PauseRecording() error
 	ResumeRecording() error
 
 	// Streaming operations
+	GetStreamingStatus() (*obs.StreamingStatus, error)
 	StartStreaming() error
 	StopStreaming() error
 
@@ -54,40 +59,176 @@ type OBSClient interface {
 
 // Executor handles action execution against OBS.
 type Executor struct {
-	obsClient OBSClient
+	obsClient  OBSClient
+	variables  VariableStore // Backs {{var.*}} placeholders and set_variable; may be nil
+	macros     MacroStore    // Backs run_macro; may be nil
+	httpClient *http.Client  // Used by http_request actions
 }
 
 // NewExecutor creates a new action executor.
 func NewExecutor(client OBSClient) *Executor {
 	return &Executor{
-		obsClient: client,
+		obsClient:  client,
+		httpClient: &http.Client{},
 	}
 }
 
+// SetVariableStore sets where automation variables are read and written.
+func (e *Executor) SetVariableStore(store VariableStore) {
+	e.variables = store
+}
+
 // ExecuteAction runs a single action and returns the result.
 func (e *Executor) ExecuteAction(action Action, index int) ActionResult {
+	return e.ExecuteActionContext(context.Background(), action, index)
+}
+
+// ExecuteActionContext runs a single action, aborting early if ctx is
+// cancelled. A request already sent to OBS cannot be withdrawn, so a
+// cancelled action stops waiting for OBS's reply and returns at once.
+func (e *Executor) ExecuteActionContext(ctx context.Context, action Action, index int) ActionResult {
+	return e.ExecuteActionWithScope(ctx, action, index, nil)
+}
+
+// ExecuteActionWithScope runs a single action after resolving the template
+// variables in its parameters against scope. The resolved parameters are
+// returned in the result.
+func (e *Executor) ExecuteActionWithScope(ctx context.Context, action Action, index int, scope *TemplateScope) ActionResult {
 	start := time.Now()
 	result := ActionResult{
 		ActionType: action.Type,
 		Index:      index,
 	}
 
-	err := e.runAction(action)
+	err := ctx.Err()
+	switch {
+	case err != nil:
+	case action.Type == ActionTypeIf:
+		err = e.runIf(ctx, action, scope, &result)
+	case action.Type == ActionTypeParallel:
+		err = e.runParallel(ctx, action, scope, &result)
+	case action.Type == ActionTypeRepeat:
+		err = e.runRepeat(ctx, action, scope, &result)
+	case action.Type == ActionTypeRunMacro:
+		err = e.runMacro(ctx, action, scope, &result)
+	default:
+		if hasTemplates(action.Parameters) {
+			action.Parameters, err = e.resolveParameters(ctx, action.Parameters, scope, action.GetOnMissing())
+			result.Parameters = action.Parameters
+		}
+		if err == nil {
+			err = e.runAttempts(ctx, action, &result)
+		}
+	}
+	if err != nil && ctx.Err() == nil && action.GetOnError() == ActionErrorGoto {
+		err = e.runFallback(ctx, action, scope, &result, err)
+	}
 
 	result.DurationMs = time.Since(start).Milliseconds()
 	result.Success = err == nil
 	if err != nil {
 		result.Error = err.Error()
-		log.Printf("[Automation] Action %d (%s) failed: %v", index, action.Type, err)
+		// A timeout of the action itself is a failure, not a cancellation
+		result.Cancelled = ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+		result.Abandoned = errors.Is(err, errCallAbandoned)
+		logger.Warnf("Action %d (%s) failed: %v", index, action.Type, err)
 	} else {
-		log.Printf("[Automation] Action %d (%s) completed in %dms", index, action.Type, result.DurationMs)
+		logger.Debugf("Action %d (%s) completed in %dms", index, action.Type, result.DurationMs)
 	}
 
 	return result
 }
 
+// runIf evaluates an if action's condition and runs the matching branch,
+// recording the branch taken and its results in result. The if action fails
+// when an action in the branch fails with on_error "stop".
+func (e *Executor) runIf(ctx context.Context, action Action, scope *TemplateScope, result *ActionResult) error {
+	met, err := e.EvaluateCondition(ctx, action.Condition, scope)
+	if err != nil {
+		return err
+	}
+
+	branch := action.Else
+	result.Branch = "else"
+	if met {
+		branch = action.Then
+		result.Branch = "then"
+	}
+
+	result.Results, err = e.runSequence(ctx, branch, scope)
+	return err
+}
+
+// runSequence runs actions in order, stopping early when ctx is cancelled
+// or an action with on_error "stop" fails.
+func (e *Executor) runSequence(ctx context.Context, actions []Action, scope *TemplateScope) ([]ActionResult, error) {
+	var results []ActionResult
+	for i, action := range actions {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		result := e.ExecuteActionWithScope(ctx, action, i, scope)
+		results = append(results, result)
+
+		if result.Cancelled {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			return results, context.Canceled
+		}
+		if action.haltsOn(result) {
+			return results, &ActionError{
+				ActionType: action.Type,
+				Index:      i,
+				Message:    fmt.Sprintf("action %d (%s) failed: %s", i, action.Type, result.Error),
+			}
+		}
+	}
+	return results, nil
+}
+
 // runAction dispatches to the appropriate handler based on action type.
-func (e *Executor) runAction(action Action) error {
+func (e *Executor) runAction(ctx context.Context, action Action) error {
+	switch action.Type {
+	case ActionTypeDelay:
+		return e.delay(ctx, action.Parameters)
+
+	case ActionTypeSetVariable:
+		return e.setVariable(ctx, action.Parameters)
+
+	case ActionTypeHTTPRequest:
+		return e.httpRequest(ctx, action.Parameters)
+
+	default:
+		return callContext(ctx, func() error { return e.runOBSAction(action) })
+	}
+}
+
+// errCallAbandoned is wrapped, with ctx's error, by the error of a call that
+// callContext stopped waiting for. The call goes on in the background, so
+// its OBS request may still take effect.
+var errCallAbandoned = errors.New("the OBS request was abandoned in flight and may still take effect")
+
+// callContext runs call, returning an error wrapping errCallAbandoned and
+// ctx's error as soon as ctx is cancelled. The call itself keeps running in
+// the background until it returns.
+func callContext(ctx context.Context, call func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- call() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errCallAbandoned, ctx.Err())
+	}
+}
+
+// runOBSAction runs an action that calls OBS.
+func (e *Executor) runOBSAction(action Action) error {
 	switch action.Type {
 	case ActionTypeSetScene:
 		return e.setScene(action.Parameters)
@@ -152,9 +293,6 @@ func (e *Executor) runAction(action Action) error {
 	case ActionTypeSetPreviewScene:
 		return e.setPreviewScene(action.Parameters)
 
-	case ActionTypeDelay:
-		return e.delay(action.Parameters)
-
 	default:
 		return fmt.Errorf("unknown action type: %s", action.Type)
 	}
@@ -271,8 +409,9 @@ func (e *Executor) setPreviewScene(params map[string]interface{}) error {
 	return e.obsClient.SetCurrentPreviewScene(sceneName)
 }
 
-// delay pauses execution for the specified duration.
-func (e *Executor) delay(params map[string]interface{}) error {
+// delay pauses execution for the specified duration, returning early with
+// the context error if ctx is cancelled while waiting.
+func (e *Executor) delay(ctx context.Context, params map[string]interface{}) error {
 	delayMs, ok := getIntParam(params, "delay_ms")
 	if !ok {
 		return fmt.Errorf("delay requires 'delay_ms' parameter")
@@ -285,12 +424,35 @@ func (e *Executor) delay(params map[string]interface{}) error {
 	// Cap delay at 5 minutes to prevent excessive waits
 	const maxDelayMs = 5 * 60 * 1000
 	if delayMs > maxDelayMs {
-		log.Printf("[Automation] Capping delay from %dms to %dms", delayMs, maxDelayMs)
+		logger.Warnf("Capping delay from %dms to %dms", delayMs, maxDelayMs)
 		delayMs = maxDelayMs
 	}
 
-	time.Sleep(time.Duration(delayMs) * time.Millisecond)
-	return nil
+	timer := time.NewTimer(time.Duration(delayMs) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// setVariable stores a value in the persistent variable store.
+func (e *Executor) setVariable(ctx context.Context, params map[string]interface{}) error {
+	name, ok := getStringParam(params, "name")
+	if !ok || name == "" {
+		return fmt.Errorf("set_variable requires 'name' parameter")
+	}
+	value, ok := params["value"]
+	if !ok {
+		return fmt.Errorf("set_variable requires 'value' parameter")
+	}
+	if e.variables == nil {
+		return fmt.Errorf("set_variable requires a variable store")
+	}
+	return e.variables.SetAutomationVariable(ctx, name, value)
 }
 
 // Parameter extraction helpers
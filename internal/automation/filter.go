@@ -0,0 +1,256 @@
+package automation
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Event filters.
+//
+// An event rule's event_filter maps event data keys to what their values
+// must be. A plain value matches by equality. An object holds one or more
+// operators, all of which must hold:
+//
+//	{"scene_name": "BRB"}
+//	{"scene_name": {"prefix": "Game"}, "input_name": {"in": ["Mic", "Desktop Audio"]}}
+//	{"volume_db": {"gt": -30, "lt": -6}}
+//
+// Numbers compare by value whatever their type, so a filter value of 3
+// matches event data holding int 3 or float64 3.0. A key missing from the
+// event data never matches.
+
+// Event filter operators.
+const (
+	FilterOpEq       = "eq"       // Equal to the operand
+	FilterOpNe       = "ne"       // Not equal to the operand
+	FilterOpIn       = "in"       // Equal to one of the operand's values
+	FilterOpNotIn    = "not_in"   // Equal to none of the operand's values
+	FilterOpRegex    = "regex"    // String matching the operand's regular expression
+	FilterOpPrefix   = "prefix"   // String starting with the operand
+	FilterOpContains = "contains" // String containing the operand, or list containing it
+	FilterOpGt       = "gt"       // Number greater than the operand
+	FilterOpLt       = "lt"       // Number less than the operand
+)
+
+// SupportedFilterOperators returns the operators valid in event filters.
+func SupportedFilterOperators() []string {
+	return []string{
+		FilterOpEq, FilterOpNe, FilterOpIn, FilterOpNotIn, FilterOpRegex,
+		FilterOpPrefix, FilterOpContains, FilterOpGt, FilterOpLt,
+	}
+}
+
+// maxFilterRegexps bounds the compiled regex cache. Rules in use hold few
+// patterns; the bound keeps those of edited and deleted rules from piling up.
+const maxFilterRegexps = 256
+
+// filterRegexps caches compiled regex operands by pattern.
+var filterRegexps = &regexpCache{entries: make(map[string]*list.Element), order: list.New()}
+
+// regexpCache holds compiled patterns, dropping the least recently used
+// one once it holds maxFilterRegexps.
+type regexpCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element // Pattern → element of order
+	order   *list.List               // *regexp.Regexp values, most recently used first
+}
+
+func compileFilterRegex(pattern string) (*regexp.Regexp, error) {
+	c := filterRegexps
+	c.mu.Lock()
+	if elem, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*regexp.Regexp), nil
+	}
+	c.mu.Unlock()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[pattern]; !ok {
+		c.entries[pattern] = c.order.PushFront(re)
+		if c.order.Len() > maxFilterRegexps {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*regexp.Regexp).String())
+		}
+	}
+	return re, nil
+}
+
+// ValidateEventFilter checks the keys, operators and operands of an
+// event_filter.
+func ValidateEventFilter(filter map[string]interface{}) error {
+	for _, key := range sortedKeys(filter) {
+		if key == "" {
+			return fmt.Errorf("event_filter has an empty key")
+		}
+		expected := filter[key]
+		switch expected.(type) {
+		case map[string]interface{}:
+		case []interface{}:
+			return fmt.Errorf("event_filter '%s': use {\"in\": [...]} to match one of several values", key)
+		default:
+			continue // Plain value
+		}
+
+		ops := expected.(map[string]interface{})
+		if len(ops) == 0 {
+			return fmt.Errorf("event_filter '%s' has no operators. Valid operators: %v", key, SupportedFilterOperators())
+		}
+		for _, op := range sortedKeys(ops) {
+			if err := validateFilterOperand(op, ops[op]); err != nil {
+				return fmt.Errorf("event_filter '%s': %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateFilterOperand(op string, operand interface{}) error {
+	switch op {
+	case FilterOpEq, FilterOpNe, FilterOpContains:
+		if !isFilterScalar(operand) {
+			return fmt.Errorf("'%s' needs a string, number, boolean or null", op)
+		}
+	case FilterOpIn, FilterOpNotIn:
+		values, ok := operand.([]interface{})
+		if !ok {
+			return fmt.Errorf("'%s' needs a list of values", op)
+		}
+		for _, v := range values {
+			if !isFilterScalar(v) {
+				return fmt.Errorf("'%s' values must be strings, numbers, booleans or null", op)
+			}
+		}
+	case FilterOpRegex:
+		pattern, ok := operand.(string)
+		if !ok {
+			return fmt.Errorf("'%s' needs a string", op)
+		}
+		// Compiled without caching; the pattern is cached once a rule matches with it
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	case FilterOpPrefix:
+		if _, ok := operand.(string); !ok {
+			return fmt.Errorf("'%s' needs a string", op)
+		}
+	case FilterOpGt, FilterOpLt:
+		if _, ok := toNumber(operand); !ok {
+			return fmt.Errorf("'%s' needs a number", op)
+		}
+	default:
+		return fmt.Errorf("unknown operator '%s'. Valid operators: %v", op, SupportedFilterOperators())
+	}
+	return nil
+}
+
+func isFilterScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil, string, bool:
+		return true
+	}
+	_, ok := toNumber(v)
+	return ok
+}
+
+// matchFilter reports whether data satisfies filter. When it does not, the
+// reason describes the first condition that failed.
+func matchFilter(filter map[string]interface{}, data map[string]interface{}) (bool, string) {
+	for _, key := range sortedKeys(filter) {
+		actual, exists := data[key]
+		if !exists {
+			return false, fmt.Sprintf("'%s' is not in the event data", key)
+		}
+
+		ops, ok := filter[key].(map[string]interface{})
+		if !ok {
+			ops = map[string]interface{}{FilterOpEq: filter[key]}
+		}
+		for _, op := range sortedKeys(ops) {
+			if !matchFilterOp(op, actual, ops[op]) {
+				return false, fmt.Sprintf("'%s' is %s, want %s %s", key, formatTemplateValue(actual), op, formatTemplateValue(ops[op]))
+			}
+		}
+	}
+	return true, ""
+}
+
+// matchFilterOp applies one operator. Operands that fail validation never
+// match.
+func matchFilterOp(op string, actual, operand interface{}) bool {
+	switch op {
+	case FilterOpEq:
+		return valuesEqual(actual, operand)
+	case FilterOpNe:
+		return !valuesEqual(actual, operand)
+	case FilterOpIn, FilterOpNotIn:
+		values, ok := operand.([]interface{})
+		if !ok {
+			return false
+		}
+		found := false
+		for _, v := range values {
+			if valuesEqual(actual, v) {
+				found = true
+				break
+			}
+		}
+		return found == (op == FilterOpIn)
+	case FilterOpRegex:
+		s, ok := actual.(string)
+		pattern, isString := operand.(string)
+		if !ok || !isString {
+			return false
+		}
+		re, err := compileFilterRegex(pattern)
+		return err == nil && re.MatchString(s)
+	case FilterOpPrefix:
+		s, ok := actual.(string)
+		prefix, isString := operand.(string)
+		return ok && isString && strings.HasPrefix(s, prefix)
+	case FilterOpContains:
+		switch actual := actual.(type) {
+		case string:
+			sub, ok := operand.(string)
+			return ok && strings.Contains(actual, sub)
+		case []interface{}:
+			for _, v := range actual {
+				if valuesEqual(v, operand) {
+					return true
+				}
+			}
+		}
+		return false
+	case FilterOpGt, FilterOpLt:
+		a, ok := toNumber(actual)
+		b, isNumber := toNumber(operand)
+		if !ok || !isNumber {
+			return false
+		}
+		if op == FilterOpGt {
+			return a > b
+		}
+		return a < b
+	}
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
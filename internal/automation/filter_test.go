@@ -0,0 +1,177 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchFilter(t *testing.T) {
+	data := map[string]interface{}{
+		"scene_name":    "Gaming 2",
+		"input_name":    "Microphone",
+		"scene_item_id": 3,
+		"volume_db":     -12.5,
+		"muted":         true,
+		"tags":          []interface{}{"live", "main"},
+	}
+
+	for name, tc := range map[string]struct {
+		filter map[string]interface{}
+		want   bool
+	}{
+		"plain equality":             {map[string]interface{}{"scene_name": "Gaming 2"}, true},
+		"plain mismatch":             {map[string]interface{}{"scene_name": "BRB"}, false},
+		"int matches float":          {map[string]interface{}{"scene_item_id": float64(3)}, true},
+		"float matches int":          {map[string]interface{}{"volume_db": map[string]interface{}{"eq": -12.5}}, true},
+		"bool":                       {map[string]interface{}{"muted": true}, true},
+		"missing key":                {map[string]interface{}{"source_name": "Cam"}, false},
+		"missing key with ne":        {map[string]interface{}{"source_name": map[string]interface{}{"ne": "Cam"}}, false},
+		"ne":                         {map[string]interface{}{"scene_name": map[string]interface{}{"ne": "BRB"}}, true},
+		"in":                         {map[string]interface{}{"input_name": map[string]interface{}{"in": []interface{}{"Mic", "Microphone"}}}, true},
+		"in numbers":                 {map[string]interface{}{"scene_item_id": map[string]interface{}{"in": []interface{}{float64(1), float64(3)}}}, true},
+		"not in":                     {map[string]interface{}{"input_name": map[string]interface{}{"not_in": []interface{}{"Mic", "Microphone"}}}, false},
+		"regex":                      {map[string]interface{}{"scene_name": map[string]interface{}{"regex": `^Gaming \d$`}}, true},
+		"regex on number":            {map[string]interface{}{"scene_item_id": map[string]interface{}{"regex": `3`}}, false},
+		"prefix":                     {map[string]interface{}{"scene_name": map[string]interface{}{"prefix": "Gam"}}, true},
+		"prefix mismatch":            {map[string]interface{}{"scene_name": map[string]interface{}{"prefix": "Just"}}, false},
+		"contains substring":         {map[string]interface{}{"input_name": map[string]interface{}{"contains": "phone"}}, true},
+		"contains list element":      {map[string]interface{}{"tags": map[string]interface{}{"contains": "live"}}, true},
+		"gt and lt":                  {map[string]interface{}{"volume_db": map[string]interface{}{"gt": -30, "lt": -6}}, true},
+		"lt fails":                   {map[string]interface{}{"volume_db": map[string]interface{}{"lt": -20}}, false},
+		"gt on string":               {map[string]interface{}{"scene_name": map[string]interface{}{"gt": 1}}, false},
+		"every key must match":       {map[string]interface{}{"muted": true, "input_name": map[string]interface{}{"prefix": "Desk"}}, false},
+		"operators and plain values": {map[string]interface{}{"muted": true, "input_name": map[string]interface{}{"prefix": "Micro"}}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, ValidateEventFilter(tc.filter))
+			got, reason := matchFilter(tc.filter, data)
+			assert.Equal(t, tc.want, got)
+			if tc.want {
+				assert.Empty(t, reason)
+			} else {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+
+	t.Run("reason names the failing condition", func(t *testing.T) {
+		_, reason := matchFilter(map[string]interface{}{"volume_db": map[string]interface{}{"gt": -10}}, data)
+		assert.Equal(t, "'volume_db' is -12.5, want gt -10", reason)
+
+		_, reason = matchFilter(map[string]interface{}{"source_name": "Cam"}, data)
+		assert.Equal(t, "'source_name' is not in the event data", reason)
+	})
+}
+
+func TestValidateEventFilter(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter map[string]interface{}
+		want   string
+	}{
+		"unknown operator": {map[string]interface{}{"scene_name": map[string]interface{}{"starts_with": "G"}}, "unknown operator 'starts_with'"},
+		"no operators":     {map[string]interface{}{"scene_name": map[string]interface{}{}}, "has no operators"},
+		"bare list":        {map[string]interface{}{"scene_name": []interface{}{"A", "B"}}, `use {"in": [...]}`},
+		"bad regex":        {map[string]interface{}{"scene_name": map[string]interface{}{"regex": "("}}, "invalid regex"},
+		"in needs list":    {map[string]interface{}{"scene_name": map[string]interface{}{"in": "A"}}, "needs a list"},
+		"in nested values": {map[string]interface{}{"scene_name": map[string]interface{}{"in": []interface{}{[]interface{}{}}}}, "must be strings"},
+		"gt needs number":  {map[string]interface{}{"volume_db": map[string]interface{}{"gt": "-10"}}, "needs a number"},
+		"prefix string":    {map[string]interface{}{"scene_name": map[string]interface{}{"prefix": 1}}, "needs a string"},
+		"eq object":        {map[string]interface{}{"scene_name": map[string]interface{}{"eq": map[string]interface{}{}}}, "needs a string, number"},
+		"empty key":        {map[string]interface{}{"": "x"}, "empty key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateEventFilter(tc.filter)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestEngineFilterOperators(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ruleID, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:        "game-scenes",
+		Enabled:     true,
+		TriggerType: TriggerTypeEvent,
+		TriggerConfig: map[string]interface{}{
+			"event_type": EventSourceVisibilityChanged,
+			"event_filter": map[string]interface{}{
+				"scene_name":    map[string]interface{}{"prefix": "Game"},
+				"scene_item_id": float64(4), // As decoded from stored JSON
+			},
+		},
+		Actions: []storage.RuleAction{{Type: ActionTypeSaveReplay}},
+	})
+	require.NoError(t, err)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	engine.HandleEvent(EventPayload{
+		EventType: EventSourceVisibilityChanged,
+		Data:      map[string]interface{}{"scene_name": "Chatting", "scene_item_id": 4},
+	})
+	engine.HandleEvent(EventPayload{
+		EventType: EventSourceVisibilityChanged,
+		Data:      map[string]interface{}{"scene_name": "Gameplay", "scene_item_id": 4},
+	})
+
+	require.Eventually(t, func() bool {
+		executions, err := db.GetRuleExecutions(ctx, ruleID, 10)
+		return err == nil && len(executions) == 1 && executions[0].Status == storage.ExecutionStatusCompleted
+	}, 2*time.Second, 10*time.Millisecond)
+
+	executions, err := db.GetRuleExecutions(ctx, ruleID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "Gameplay", executions[0].TriggerData["scene_name"])
+}
+
+func TestFilterRegexCacheIsBounded(t *testing.T) {
+	cached := func(pattern string) bool {
+		filterRegexps.mu.Lock()
+		defer filterRegexps.mu.Unlock()
+		_, ok := filterRegexps.entries[pattern]
+		return ok
+	}
+
+	// Validating a pattern does not cache it
+	require.NoError(t, ValidateEventFilter(map[string]interface{}{"scene_name": map[string]interface{}{"regex": "^validated$"}}))
+	assert.False(t, cached("^validated$"))
+
+	kept := "^kept$"
+	_, err := compileFilterRegex(kept)
+	require.NoError(t, err)
+	_, err = compileFilterRegex("^first$")
+	require.NoError(t, err)
+
+	for i := range maxFilterRegexps {
+		_, err := compileFilterRegex(fmt.Sprintf("^scene %d$", i))
+		require.NoError(t, err)
+		if i%10 == 0 {
+			_, err = compileFilterRegex(kept) // Recently used patterns stay cached
+			require.NoError(t, err)
+		}
+	}
+
+	filterRegexps.mu.Lock()
+	assert.Len(t, filterRegexps.entries, maxFilterRegexps)
+	assert.Equal(t, maxFilterRegexps, filterRegexps.order.Len())
+	filterRegexps.mu.Unlock()
+	assert.True(t, cached(kept))
+	assert.False(t, cached("^first$"), "the least recently used pattern is dropped")
+
+	re, err := compileFilterRegex("^first$")
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("first"), "dropped patterns are compiled again")
+}
@@ -0,0 +1,103 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Parallel and repeat actions.
+//
+// A parallel action runs each of its branches (action lists) concurrently
+// and waits for all of them. It fails if any branch halts, after the other
+// branches finish. A repeat action runs its action list count times, or
+// until its until condition holds after an iteration, whichever comes
+// first. A repeat without a count stops after MaxRepeatCount iterations and
+// fails if its condition never held.
+//
+// Action lists nest through if branches, parallel branches, repeat bodies
+// and fallbacks, at most MaxActionDepth levels deep.
+
+// Control flow limits.
+const (
+	MaxActionDepth      = 5   // Levels of nested action lists
+	MaxParallelBranches = 10  // Branches of one parallel action
+	MaxRepeatCount      = 100 // Iterations of one repeat action
+)
+
+// IsControlAction reports whether an action type runs nested action lists
+// rather than a single operation.
+func IsControlAction(actionType string) bool {
+	switch actionType {
+	case ActionTypeIf, ActionTypeParallel, ActionTypeRepeat, ActionTypeRunMacro:
+		return true
+	}
+	return false
+}
+
+// runParallel runs a parallel action's branches concurrently, recording
+// each branch's results in result.
+func (e *Executor) runParallel(ctx context.Context, action Action, scope *TemplateScope, result *ActionResult) error {
+	result.Branches = make([][]ActionResult, len(action.Branches))
+	errs := make([]error, len(action.Branches))
+
+	var wg sync.WaitGroup
+	for i, branch := range action.Branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Branches[i], errs[i] = e.runSequence(ctx, branch, scope)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("branch %d: %v", i, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// runRepeat runs a repeat action's actions until its count or until
+// condition ends the loop, recording each iteration's results in result.
+func (e *Executor) runRepeat(ctx context.Context, action Action, scope *TemplateScope, result *ActionResult) error {
+	count := action.Count
+	if count <= 0 {
+		count = MaxRepeatCount
+	}
+
+	for n := 1; n <= count; n++ {
+		results, err := e.runSequence(ctx, action.Actions, scope)
+		result.Iterations = append(result.Iterations, results)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return fmt.Errorf("iteration %d: %w", n, err)
+		}
+
+		if action.Until != "" {
+			met, err := e.EvaluateCondition(ctx, action.Until, scope)
+			if err != nil {
+				return err
+			}
+			if met {
+				return nil
+			}
+		}
+	}
+
+	if action.Count <= 0 {
+		return fmt.Errorf("repeat condition '%s' did not hold after %d iterations", action.Until, MaxRepeatCount)
+	}
+	return nil
+}
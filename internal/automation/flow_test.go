@@ -0,0 +1,167 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func delayAction(ms int) Action {
+	return Action{Type: ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": ms}}
+}
+
+func TestExecutorParallel(t *testing.T) {
+	t.Run("runs branches concurrently", func(t *testing.T) {
+		client := NewMockOBSClient()
+		start := time.Now()
+		result := NewExecutor(client).ExecuteAction(Action{
+			Type: ActionTypeParallel,
+			Branches: [][]Action{
+				{delayAction(100), {Type: ActionTypeStartRecording}},
+				{delayAction(100), {Type: ActionTypeStartStreaming}},
+				{delayAction(100)},
+			},
+		}, 0)
+		assert.Less(t, time.Since(start), 250*time.Millisecond, "branches should not run one after another")
+
+		require.True(t, result.Success, result.Error)
+		require.Len(t, result.Branches, 3)
+		assert.Len(t, result.Branches[0], 2)
+		assert.Len(t, result.Branches[2], 1)
+		assert.ElementsMatch(t, []string{"start_recording", "start_streaming"}, client.GetActions())
+	})
+
+	t.Run("fails after every branch finishes", func(t *testing.T) {
+		client := NewMockOBSClient()
+		client.failNextCall = true
+		result := NewExecutor(client).ExecuteAction(Action{
+			Type: ActionTypeParallel,
+			Branches: [][]Action{
+				{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}, OnError: ActionErrorStop}},
+				{delayAction(20), {Type: ActionTypeStartRecording}},
+			},
+		}, 0)
+
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "branch 0: action 0 (set_scene) failed")
+		require.Len(t, result.Branches, 2)
+		assert.False(t, result.Branches[0][0].Success)
+		assert.True(t, result.Branches[1][1].Success)
+		assert.Equal(t, []string{"start_recording"}, client.GetActions())
+	})
+
+	t.Run("stops every branch when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result := NewExecutor(NewMockOBSClient()).ExecuteActionContext(ctx, Action{
+			Type:     ActionTypeParallel,
+			Branches: [][]Action{{delayAction(60000)}, {delayAction(60000)}},
+		}, 0)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.True(t, result.Cancelled)
+	})
+}
+
+func TestExecutorRepeat(t *testing.T) {
+	t.Run("repeats count times", func(t *testing.T) {
+		client := NewMockOBSClient()
+		result := NewExecutor(client).ExecuteAction(Action{
+			Type:    ActionTypeRepeat,
+			Count:   3,
+			Actions: []Action{{Type: ActionTypeToggleMute, Parameters: map[string]interface{}{"input_name": "Mic"}}},
+		}, 0)
+
+		require.True(t, result.Success, result.Error)
+		assert.Len(t, result.Iterations, 3)
+		assert.Equal(t, []string{"toggle_mute:Mic", "toggle_mute:Mic", "toggle_mute:Mic"}, client.GetActions())
+	})
+
+	t.Run("stops when until holds", func(t *testing.T) {
+		client := NewMockOBSClient()
+		result := NewExecutor(client).ExecuteAction(Action{
+			Type:  ActionTypeRepeat,
+			Count: 10,
+			Until: "obs.recording",
+			Actions: []Action{
+				{Type: ActionTypeIf, Condition: "obs.current_scene == 'Live'", Then: []Action{{Type: ActionTypeStartRecording}}},
+				{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}},
+			},
+		}, 0)
+
+		require.True(t, result.Success, result.Error)
+		assert.Len(t, result.Iterations, 2)
+		assert.Equal(t, []string{"set_scene:Live", "start_recording", "set_scene:Live"}, client.GetActions())
+	})
+
+	t.Run("fails when until never holds", func(t *testing.T) {
+		result := NewExecutor(NewMockOBSClient()).ExecuteAction(Action{
+			Type:    ActionTypeRepeat,
+			Until:   "obs.streaming",
+			Actions: []Action{{Type: ActionTypeStartRecording}},
+		}, 0)
+
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "did not hold after 100 iterations")
+		assert.Len(t, result.Iterations, MaxRepeatCount)
+	})
+
+	t.Run("stops at a failing iteration", func(t *testing.T) {
+		client := NewMockOBSClient()
+		client.failNextCall = true
+		result := NewExecutor(client).ExecuteAction(Action{
+			Type:    ActionTypeRepeat,
+			Count:   3,
+			Actions: []Action{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}, OnError: ActionErrorStop}},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "iteration 1:")
+		assert.Len(t, result.Iterations, 1)
+		assert.Empty(t, client.GetActions())
+	})
+}
+
+func TestEngineRecordsFlowResults(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "go-live",
+		Enabled:       true,
+		TriggerType:   TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeParallel, Branches: [][]storage.RuleAction{
+				{{Type: ActionTypeStartRecording}},
+				{{Type: ActionTypeRepeat, Count: 2, Actions: []storage.RuleAction{
+					{Type: ActionTypeToggleMute, Parameters: map[string]interface{}{"input_name": "Mic"}},
+				}}},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	client := NewMockOBSClient()
+	engine := NewAutomationEngine(db, client)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	result, err := engine.ExecuteRuleByName(ctx, "go-live", nil)
+	require.NoError(t, err)
+	assert.Equal(t, storage.ExecutionStatusCompleted, result.Status)
+	assert.ElementsMatch(t, []string{"start_recording", "toggle_mute:Mic", "toggle_mute:Mic"}, client.GetActions())
+
+	executions, err := db.GetRuleExecutions(ctx, result.RuleID, 1)
+	require.NoError(t, err)
+	stored := executions[0].ActionResults[0]
+	require.Len(t, stored.Branches, 2)
+	assert.Len(t, stored.Branches[0], 1)
+	require.Len(t, stored.Branches[1], 1)
+	assert.Len(t, stored.Branches[1][0].Iterations, 2)
+}
@@ -0,0 +1,308 @@
+package automation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event forwarding.
+//
+// An EventForwarder POSTs OBS events to external services such as chat bots,
+// logging services or home-automation bridges. Events are queued and sent in
+// batches as a JSON object:
+//
+//	{"events": [{"event_type": "scene_changed", "data": {...}, "timestamp": "..."}], "sent_at": "..."}
+//
+// A batch is sent when it reaches BatchSize or FlushInterval has passed since
+// its first event. Failed deliveries are retried with exponential backoff;
+// events arriving while the queue is full are dropped and counted.
+
+// Forwarder defaults.
+const (
+	defaultForwardBatchSize      = 20
+	defaultForwardFlushInterval  = 2 * time.Second
+	defaultForwardMaxRetries     = 3
+	defaultForwardInitialBackoff = 500 * time.Millisecond
+	defaultForwardMaxBackoff     = 30 * time.Second
+	defaultForwardTimeout        = 10 * time.Second
+	defaultForwardQueueSize      = 1000
+)
+
+// ForwarderConfig configures an EventForwarder. Zero values use defaults.
+type ForwarderConfig struct {
+	URLs           []string          // Targets; each batch is POSTed to every URL
+	EventTypes     []string          // Event types to forward; empty forwards all
+	Headers        map[string]string // Extra request headers
+	BatchSize      int               // Maximum events per request
+	FlushInterval  time.Duration     // Maximum time an event waits for its batch
+	MaxRetries     int               // Retries per target after a failed delivery; negative disables
+	InitialBackoff time.Duration     // Wait before the first retry, doubled each time
+	MaxBackoff     time.Duration     // Upper bound on the wait between retries
+	Timeout        time.Duration     // Per-request timeout
+	QueueSize      int               // Events buffered before new ones are dropped
+	Client         *http.Client      // Defaults to a new http.Client
+}
+
+// ForwarderStats counts forwarded and lost events.
+type ForwarderStats struct {
+	Forwarded uint64 `json:"forwarded"` // Events delivered (counted once per target)
+	Failed    uint64 `json:"failed"`    // Events whose delivery failed after all retries (per target)
+	Dropped   uint64 `json:"dropped"`   // Events discarded because the queue was full
+}
+
+// forwardBatch is the JSON body of a forwarded batch.
+type forwardBatch struct {
+	Events []EventPayload `json:"events"`
+	SentAt time.Time      `json:"sent_at"`
+}
+
+// EventForwarder sends OBS events to external HTTP endpoints.
+type EventForwarder struct {
+	cfg    ForwarderConfig
+	queue  chan EventPayload
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex // Guards running and stopped
+	running bool
+	stopped bool
+
+	forwarded atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewEventForwarder creates a forwarder. It returns an error if no URLs are
+// given or a URL is not http or https.
+func NewEventForwarder(cfg ForwarderConfig) (*EventForwarder, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, fmt.Errorf("event forwarder requires at least one URL")
+	}
+	for _, u := range cfg.URLs {
+		if err := validateHTTPURL(u); err != nil {
+			return nil, fmt.Errorf("invalid forwarding URL: %w", err)
+		}
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultForwardBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultForwardFlushInterval
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultForwardMaxRetries
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultForwardInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultForwardMaxBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultForwardTimeout
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultForwardQueueSize
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EventForwarder{
+		cfg:    cfg,
+		queue:  make(chan EventPayload, cfg.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start begins sending queued events.
+func (f *EventForwarder) Start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.running || f.stopped {
+		return
+	}
+	f.running = true
+
+	f.wg.Add(1)
+	go f.run()
+	logger.Infof("Event forwarder started for %d target(s)", len(f.cfg.URLs))
+}
+
+// Stop sends any queued events, waiting at most one request timeout for
+// them, and stops the forwarder. A stopped forwarder cannot be restarted.
+func (f *EventForwarder) Stop() {
+	f.mu.Lock()
+	if f.stopped {
+		f.mu.Unlock()
+		return
+	}
+	f.stopped = true
+	wasRunning := f.running
+	f.running = false
+	close(f.queue)
+	f.mu.Unlock()
+
+	if wasRunning {
+		// Give the final flush one timeout before abandoning retries
+		timer := time.AfterFunc(f.cfg.Timeout, f.cancel)
+		f.wg.Wait()
+		timer.Stop()
+	}
+	f.cancel()
+	logger.Infof("Event forwarder stopped")
+}
+
+// Forward queues an event for delivery. It returns false if the event type
+// is not forwarded, the forwarder is stopped, or the queue is full.
+func (f *EventForwarder) Forward(payload EventPayload) bool {
+	if len(f.cfg.EventTypes) > 0 && !slices.Contains(f.cfg.EventTypes, payload.EventType) {
+		return false
+	}
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = time.Now()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		return false
+	}
+
+	select {
+	case f.queue <- payload:
+		return true
+	default:
+		dropped := f.dropped.Add(1)
+		logger.Warnf("Forwarding queue full, dropping event: %s (total dropped: %d)", payload.EventType, dropped)
+		return false
+	}
+}
+
+// Stats returns delivery counters.
+func (f *EventForwarder) Stats() ForwarderStats {
+	return ForwarderStats{
+		Forwarded: f.forwarded.Load(),
+		Failed:    f.failed.Load(),
+		Dropped:   f.dropped.Load(),
+	}
+}
+
+// run collects queued events into batches until the queue is closed.
+func (f *EventForwarder) run() {
+	defer f.wg.Done()
+
+	var batch []EventPayload
+	timer := time.NewTimer(f.cfg.FlushInterval)
+	timer.Stop()
+
+	flush := func() {
+		timer.Stop()
+		if len(batch) > 0 {
+			f.send(batch)
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case payload, ok := <-f.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, payload)
+			if len(batch) == 1 {
+				timer.Reset(f.cfg.FlushInterval)
+			}
+			if len(batch) >= f.cfg.BatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// send delivers a batch to every target.
+func (f *EventForwarder) send(events []EventPayload) {
+	body, err := json.Marshal(forwardBatch{Events: events, SentAt: time.Now()})
+	if err != nil {
+		logger.Errorf("Failed to encode %d forwarded event(s): %v", len(events), err)
+		f.failed.Add(uint64(len(events) * len(f.cfg.URLs)))
+		return
+	}
+
+	for _, target := range f.cfg.URLs {
+		if err := f.deliver(target, body); err != nil {
+			logger.Warnf("Failed to forward %d event(s) to %s: %v", len(events), target, err)
+			f.failed.Add(uint64(len(events)))
+			continue
+		}
+		f.forwarded.Add(uint64(len(events)))
+	}
+}
+
+// deliver POSTs body to target, retrying with exponential backoff.
+func (f *EventForwarder) deliver(target string, body []byte) error {
+	backoff := f.cfg.InitialBackoff
+	var err error
+	for attempt := 0; attempt <= f.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleepContext(f.ctx, backoff); waitErr != nil {
+				return err
+			}
+			backoff = min(backoff*2, f.cfg.MaxBackoff)
+		}
+
+		var retry bool
+		if retry, err = f.post(target, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (f *EventForwarder) post(target string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(f.ctx, f.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range f.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := f.cfg.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPResponseBytes))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("target returned %s", resp.Status)
+}
@@ -0,0 +1,167 @@
+package automation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder is an httptest handler that records forwarded batches.
+type batchRecorder struct {
+	mu       sync.Mutex
+	batches  []forwardBatch
+	headers  []http.Header
+	failures atomic.Int32 // Requests fail with 503 until this reaches zero
+}
+
+func (b *batchRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if b.failures.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var batch forwardBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	b.mu.Lock()
+	b.batches = append(b.batches, batch)
+	b.headers = append(b.headers, r.Header.Clone())
+	b.mu.Unlock()
+}
+
+func (b *batchRecorder) eventTypes() [][]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out [][]string
+	for _, batch := range b.batches {
+		var types []string
+		for _, event := range batch.Events {
+			types = append(types, event.EventType)
+		}
+		out = append(out, types)
+	}
+	return out
+}
+
+func TestEventForwarder(t *testing.T) {
+	t.Run("batches selected events", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		forwarder, err := NewEventForwarder(ForwarderConfig{
+			URLs:          []string{server.URL},
+			EventTypes:    []string{EventSceneChanged, EventStreamingStarted},
+			Headers:       map[string]string{"Authorization": "Bearer token"},
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+		require.NoError(t, err)
+		forwarder.Start()
+
+		assert.True(t, forwarder.Forward(EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "Gaming"}}))
+		assert.False(t, forwarder.Forward(EventPayload{EventType: EventInputMuteChanged}), "event type not selected")
+		assert.True(t, forwarder.Forward(EventPayload{EventType: EventStreamingStarted}))
+		assert.True(t, forwarder.Forward(EventPayload{EventType: EventSceneChanged}))
+
+		require.Eventually(t, func() bool { return len(recorder.eventTypes()) == 1 }, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, [][]string{{EventSceneChanged, EventStreamingStarted}}, recorder.eventTypes())
+
+		// Stop flushes the partial batch
+		forwarder.Stop()
+		assert.Equal(t, [][]string{{EventSceneChanged, EventStreamingStarted}, {EventSceneChanged}}, recorder.eventTypes())
+		assert.Equal(t, "Gaming", recorder.batches[0].Events[0].Data["scene_name"])
+		assert.False(t, recorder.batches[0].Events[0].Timestamp.IsZero())
+		assert.Equal(t, "Bearer token", recorder.headers[0].Get("Authorization"))
+		assert.Equal(t, "application/json", recorder.headers[0].Get("Content-Type"))
+		assert.Equal(t, ForwarderStats{Forwarded: 3}, forwarder.Stats())
+
+		assert.False(t, forwarder.Forward(EventPayload{EventType: EventSceneChanged}), "stopped")
+	})
+
+	t.Run("flushes after the interval", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		forwarder, err := NewEventForwarder(ForwarderConfig{URLs: []string{server.URL}, FlushInterval: 20 * time.Millisecond})
+		require.NoError(t, err)
+		forwarder.Start()
+		defer forwarder.Stop()
+
+		forwarder.Forward(EventPayload{EventType: EventRecordingStarted})
+		require.Eventually(t, func() bool { return len(recorder.eventTypes()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		recorder.failures.Store(2)
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		forwarder, err := NewEventForwarder(ForwarderConfig{
+			URLs:           []string{server.URL},
+			BatchSize:      1,
+			MaxRetries:     3,
+			InitialBackoff: time.Millisecond,
+		})
+		require.NoError(t, err)
+		forwarder.Start()
+		defer forwarder.Stop()
+
+		forwarder.Forward(EventPayload{EventType: EventRecordingStarted})
+		require.Eventually(t, func() bool { return forwarder.Stats().Forwarded == 1 }, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, [][]string{{EventRecordingStarted}}, recorder.eventTypes())
+	})
+
+	t.Run("counts failed deliveries per target", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer down.Close()
+
+		forwarder, err := NewEventForwarder(ForwarderConfig{
+			URLs:           []string{down.URL, server.URL},
+			BatchSize:      2,
+			MaxRetries:     1,
+			InitialBackoff: time.Millisecond,
+		})
+		require.NoError(t, err)
+		forwarder.Start()
+
+		forwarder.Forward(EventPayload{EventType: EventSceneChanged})
+		forwarder.Forward(EventPayload{EventType: EventSceneChanged})
+		forwarder.Stop()
+
+		assert.Equal(t, ForwarderStats{Forwarded: 2, Failed: 2}, forwarder.Stats())
+	})
+
+	t.Run("drops events when the queue is full", func(t *testing.T) {
+		forwarder, err := NewEventForwarder(ForwarderConfig{URLs: []string{"http://127.0.0.1:1"}, QueueSize: 1})
+		require.NoError(t, err)
+
+		// Not started, so nothing drains the queue
+		assert.True(t, forwarder.Forward(EventPayload{EventType: EventSceneChanged}))
+		assert.False(t, forwarder.Forward(EventPayload{EventType: EventSceneChanged}))
+		assert.Equal(t, uint64(1), forwarder.Stats().Dropped)
+		forwarder.Stop()
+	})
+
+	t.Run("validates URLs", func(t *testing.T) {
+		_, err := NewEventForwarder(ForwarderConfig{})
+		assert.ErrorContains(t, err, "at least one URL")
+		_, err = NewEventForwarder(ForwarderConfig{URLs: []string{"localhost:9000"}})
+		assert.ErrorContains(t, err, "invalid forwarding URL")
+	})
+}
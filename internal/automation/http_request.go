@@ -0,0 +1,232 @@
+package automation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// http_request actions.
+//
+// Parameters:
+//
+//	url             Required. http or https URL; may contain placeholders
+//	method          GET, POST (default), PUT, PATCH or DELETE
+//	headers         Object of header names to string values
+//	body            A string sent as-is, or an object or list sent as JSON
+//	timeout_ms      Per-attempt timeout (default 10000, max 60000)
+//	retries         Extra attempts after a failure (default 0, max 5)
+//	retry_delay_ms  Wait before the first retry, doubled for each one (default 1000)
+//
+// A 2xx response succeeds. Network errors, 429 and 5xx responses are retried;
+// other responses fail immediately.
+
+// http_request defaults and limits.
+const (
+	defaultHTTPTimeoutMs    = 10000
+	maxHTTPTimeoutMs        = 60000
+	maxHTTPRetries          = 5
+	defaultHTTPRetryDelayMs = 1000
+	maxHTTPRetryDelayMs     = 60000
+	maxHTTPResponseBytes    = 64 * 1024
+)
+
+// SupportedHTTPMethods returns the methods http_request actions may use.
+func SupportedHTTPMethods() []string {
+	return []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+}
+
+// SetHTTPClient sets the client used by http_request actions.
+func (e *Executor) SetHTTPClient(client *http.Client) {
+	e.httpClient = client
+}
+
+// ValidateHTTPRequestParams checks the parameters of an http_request action.
+// A URL containing placeholders is only checked once it is resolved.
+func ValidateHTTPRequestParams(params map[string]interface{}) error {
+	rawURL, ok := getStringParam(params, "url")
+	if !ok || rawURL == "" {
+		return fmt.Errorf("http_request requires 'url' parameter")
+	}
+	if !placeholderPattern.MatchString(rawURL) {
+		if err := validateHTTPURL(rawURL); err != nil {
+			return err
+		}
+	}
+
+	if method, ok := params["method"]; ok {
+		m, _ := method.(string)
+		if !slices.Contains(SupportedHTTPMethods(), strings.ToUpper(m)) {
+			return fmt.Errorf("http_request 'method' must be one of %v", SupportedHTTPMethods())
+		}
+	}
+	if headers, ok := params["headers"]; ok {
+		if _, err := headerParams(headers); err != nil {
+			return err
+		}
+	}
+	if timeout, ok := getIntParam(params, "timeout_ms"); ok && (timeout <= 0 || timeout > maxHTTPTimeoutMs) {
+		return fmt.Errorf("http_request 'timeout_ms' must be between 1 and %d", maxHTTPTimeoutMs)
+	}
+	if retries, ok := getIntParam(params, "retries"); ok && (retries < 0 || retries > maxHTTPRetries) {
+		return fmt.Errorf("http_request 'retries' must be between 0 and %d", maxHTTPRetries)
+	}
+	if delay, ok := getIntParam(params, "retry_delay_ms"); ok && (delay < 0 || delay > maxHTTPRetryDelayMs) {
+		return fmt.Errorf("http_request 'retry_delay_ms' must be between 0 and %d", maxHTTPRetryDelayMs)
+	}
+	return nil
+}
+
+// validateHTTPURL checks that rawURL is an absolute http or https URL.
+func validateHTTPURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("http_request 'url' must be an http or https URL, got %q", rawURL)
+	}
+	return nil
+}
+
+// headerParams converts a headers parameter to a map of strings.
+func headerParams(raw interface{}) (map[string]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("http_request 'headers' must be an object of strings")
+	}
+	headers := make(map[string]string, len(m))
+	for name, value := range m {
+		s, ok := value.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("http_request header '%s' must have a string value", name)
+		}
+		headers[name] = s
+	}
+	return headers, nil
+}
+
+// httpRequest sends an http_request action's request, retrying transient
+// failures. Waits between attempts end early if ctx is cancelled.
+func (e *Executor) httpRequest(ctx context.Context, params map[string]interface{}) error {
+	if err := ValidateHTTPRequestParams(params); err != nil {
+		return err
+	}
+	rawURL, _ := getStringParam(params, "url")
+	if err := validateHTTPURL(rawURL); err != nil {
+		return err
+	}
+
+	method := http.MethodPost
+	if m, ok := getStringParam(params, "method"); ok {
+		method = strings.ToUpper(m)
+	}
+	headers, _ := headerParams(params["headers"])
+
+	var body []byte
+	jsonBody := false
+	switch b := params["body"].(type) {
+	case nil:
+	case string:
+		body = []byte(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("http_request 'body' could not be encoded as JSON: %w", err)
+		}
+		body = encoded
+		jsonBody = true
+	}
+
+	timeoutMs := defaultHTTPTimeoutMs
+	if t, ok := getIntParam(params, "timeout_ms"); ok {
+		timeoutMs = t
+	}
+	retries, _ := getIntParam(params, "retries")
+	delayMs := defaultHTTPRetryDelayMs
+	if d, ok := getIntParam(params, "retry_delay_ms"); ok {
+		delayMs = d
+	}
+
+	delay := time.Duration(delayMs) * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			logger.Debugf("Retrying http_request %s %s in %s (attempt %d of %d): %v", method, rawURL, delay, attempt+1, retries+1, err)
+			if waitErr := sleepContext(ctx, delay); waitErr != nil {
+				return waitErr
+			}
+			delay *= 2
+		}
+
+		var retry bool
+		retry, err = e.sendHTTPRequest(ctx, method, rawURL, headers, body, jsonBody, time.Duration(timeoutMs)*time.Millisecond)
+		if err == nil || !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// sendHTTPRequest makes one attempt and reports whether a failure is worth
+// retrying.
+func (e *Executor) sendHTTPRequest(ctx context.Context, method, rawURL string, headers map[string]string, body []byte, jsonBody bool, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return false, fmt.Errorf("http_request could not build request: %w", err)
+	}
+	if jsonBody {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := e.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("http_request %s %s failed: %w", method, rawURL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPResponseBytes))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("http_request %s %s returned %s", method, rawURL, resp.Status)
+}
+
+// sleepContext waits for d, returning the context error if ctx is
+// cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
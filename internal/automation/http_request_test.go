@@ -0,0 +1,184 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorHTTPRequest(t *testing.T) {
+	type received struct {
+		method      string
+		path        string
+		contentType string
+		token       string
+		body        string
+	}
+	var (
+		mu       sync.Mutex
+		requests []received
+		failures atomic.Int32 // Requests to /flaky fail until this reaches zero
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, received{r.Method, r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("X-Token"), string(body)})
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/flaky":
+			if failures.Add(-1) >= 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	reset := func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		got := requests
+		requests = nil
+		return got
+	}
+
+	executor := NewExecutor(NewMockOBSClient())
+	scope := &TemplateScope{
+		Rule:  &Rule{Name: "notify"},
+		Event: &EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "Gaming"}},
+	}
+
+	t.Run("sends a templated JSON body with headers", func(t *testing.T) {
+		result := executor.ExecuteActionWithScope(context.Background(), Action{
+			Type: ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{
+				"url":     server.URL + "/notify",
+				"headers": map[string]interface{}{"X-Token": "abc"},
+				"body":    map[string]interface{}{"content": "Switched to {{event.scene_name}} by {{rule.name}}"},
+			},
+		}, 0, scope)
+		require.True(t, result.Success, result.Error)
+
+		got := reset()
+		require.Len(t, got, 1)
+		assert.Equal(t, http.MethodPost, got[0].method)
+		assert.Equal(t, "/notify", got[0].path)
+		assert.Equal(t, "application/json", got[0].contentType)
+		assert.Equal(t, "abc", got[0].token)
+
+		var body map[string]string
+		require.NoError(t, json.Unmarshal([]byte(got[0].body), &body))
+		assert.Equal(t, "Switched to Gaming by notify", body["content"])
+	})
+
+	t.Run("sends string bodies as-is", func(t *testing.T) {
+		result := executor.ExecuteAction(Action{
+			Type: ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{
+				"url":     server.URL + "/log",
+				"method":  "put",
+				"headers": map[string]interface{}{"Content-Type": "text/plain"},
+				"body":    "stream started",
+			},
+		}, 0)
+		require.True(t, result.Success, result.Error)
+
+		got := reset()
+		require.Len(t, got, 1)
+		assert.Equal(t, http.MethodPut, got[0].method)
+		assert.Equal(t, "text/plain", got[0].contentType)
+		assert.Equal(t, "stream started", got[0].body)
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		failures.Store(2)
+		result := executor.ExecuteAction(Action{
+			Type:       ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{"url": server.URL + "/flaky", "retries": 2, "retry_delay_ms": 1},
+		}, 0)
+		assert.True(t, result.Success, result.Error)
+		assert.Len(t, reset(), 3)
+	})
+
+	t.Run("fails after the last retry", func(t *testing.T) {
+		failures.Store(5)
+		result := executor.ExecuteAction(Action{
+			Type:       ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{"url": server.URL + "/flaky", "retries": 1, "retry_delay_ms": 1},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "503")
+		assert.Len(t, reset(), 2)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		result := executor.ExecuteAction(Action{
+			Type:       ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{"url": server.URL + "/missing", "retries": 3, "retry_delay_ms": 1},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "404")
+		assert.Len(t, reset(), 1)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		result := executor.ExecuteAction(Action{
+			Type:       ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{"url": server.URL + "/slow", "timeout_ms": 20},
+		}, 0)
+		assert.False(t, result.Success)
+		reset()
+	})
+
+	t.Run("stops retrying when cancelled", func(t *testing.T) {
+		failures.Store(10)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result := executor.ExecuteActionContext(ctx, Action{
+			Type:       ActionTypeHTTPRequest,
+			Parameters: map[string]interface{}{"url": server.URL + "/flaky", "retries": 5, "retry_delay_ms": 10000},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.True(t, result.Cancelled)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		reset()
+	})
+}
+
+func TestValidateHTTPRequestParams(t *testing.T) {
+	assert.NoError(t, ValidateHTTPRequestParams(map[string]interface{}{"url": "https://example.com/hook"}))
+	assert.NoError(t, ValidateHTTPRequestParams(map[string]interface{}{"url": "{{var.hook_url}}", "method": "GET"}))
+
+	for name, tc := range map[string]struct {
+		params map[string]interface{}
+		want   string
+	}{
+		"missing url":    {map[string]interface{}{}, "requires 'url'"},
+		"bad scheme":     {map[string]interface{}{"url": "ftp://example.com"}, "http or https URL"},
+		"relative url":   {map[string]interface{}{"url": "/hook"}, "http or https URL"},
+		"bad method":     {map[string]interface{}{"url": "http://x", "method": "TRACE"}, "'method' must be one of"},
+		"headers list":   {map[string]interface{}{"url": "http://x", "headers": []interface{}{"a"}}, "'headers' must be an object"},
+		"header number":  {map[string]interface{}{"url": "http://x", "headers": map[string]interface{}{"X-N": 1}}, "string value"},
+		"zero timeout":   {map[string]interface{}{"url": "http://x", "timeout_ms": 0}, "'timeout_ms' must be between"},
+		"too many tries": {map[string]interface{}{"url": "http://x", "retries": 10}, "'retries' must be between"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorContains(t, ValidateHTTPRequestParams(tc.params), tc.want)
+		})
+	}
+}
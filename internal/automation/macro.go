@@ -0,0 +1,258 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+)
+
+// Macros.
+//
+// A macro is a named action list that rules call with the run_macro action:
+//
+//	{"type": "run_macro", "parameters": {"macro": "go-to-brb", "args": {"scene": "BRB"}}}
+//
+// The macro's actions read the arguments through {{args.<name>}}
+// placeholders, and see the calling rule and event like the caller does.
+// Placeholders in args resolve against the caller. A macro may call other
+// macros, up to MaxMacroDepth deep; a macro that calls itself, directly or
+// through others, fails. The results of a macro's actions are kept in the
+// run_macro action's result, so they appear nested in the rule's execution
+// history.
+
+// MaxMacroDepth is how deeply macros may call other macros.
+const MaxMacroDepth = 5
+
+// Macro is a named, parameterized action list.
+type Macro struct {
+	ID          int64            `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Parameters  []MacroParameter `json:"parameters,omitempty"`
+	Actions     []Action         `json:"actions"`
+}
+
+// MacroParameter is a parameter a macro takes.
+type MacroParameter struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// MacroStore looks up macros by name. storage.DB implements it.
+type MacroStore interface {
+	GetMacroByName(ctx context.Context, name string) (*storage.Macro, error)
+}
+
+// ConvertStorageMacro converts a stored macro to an automation macro.
+func ConvertStorageMacro(dbMacro *storage.Macro) *Macro {
+	macro := &Macro{
+		ID:          dbMacro.ID,
+		Name:        dbMacro.Name,
+		Description: dbMacro.Description,
+		Actions:     convertStorageActions(dbMacro.Actions),
+	}
+	for _, p := range dbMacro.Parameters {
+		macro.Parameters = append(macro.Parameters, MacroParameter(p))
+	}
+	return macro
+}
+
+// macroParamPattern matches valid macro parameter names.
+var macroParamPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks the macro's parameter names, and that every
+// {{args.<name>}} placeholder in its actions names one of its parameters.
+// The actions themselves are validated like a rule's.
+func (m *Macro) Validate() error {
+	declared := make(map[string]bool, len(m.Parameters))
+	for _, p := range m.Parameters {
+		if !macroParamPattern.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name '%s': use letters, digits and underscores", p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("parameter '%s' is declared twice", p.Name)
+		}
+		declared[p.Name] = true
+	}
+
+	var undeclared []string
+	walkActions(m.Actions, func(action Action) {
+		for _, name := range argsPlaceholders(action.Parameters) {
+			if !declared[name] && !slices.Contains(undeclared, name) {
+				undeclared = append(undeclared, name)
+			}
+		}
+	})
+	if len(undeclared) > 0 {
+		return fmt.Errorf("actions use undeclared parameters: %s", strings.Join(undeclared, ", "))
+	}
+	return nil
+}
+
+// walkActions calls visit for each action, including those in nested
+// action lists.
+func walkActions(actions []Action, visit func(Action)) {
+	for _, action := range actions {
+		visit(action)
+		walkActions(action.Then, visit)
+		walkActions(action.Else, visit)
+		walkActions(action.Actions, visit)
+		walkActions(action.Fallback, visit)
+		for _, branch := range action.Branches {
+			walkActions(branch, visit)
+		}
+	}
+}
+
+// argsPlaceholders returns the parameter names of the {{args.*}}
+// placeholders in v.
+func argsPlaceholders(v interface{}) []string {
+	var names []string
+	switch v := v.(type) {
+	case string:
+		for _, match := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+			if namespace, key, found := strings.Cut(match[1], "."); found && namespace == templateArgs {
+				name, _, _ := strings.Cut(key, ".")
+				names = append(names, name)
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			names = append(names, argsPlaceholders(item)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			names = append(names, argsPlaceholders(item)...)
+		}
+	}
+	return names
+}
+
+// BindArgs checks args against the macro's parameters and returns them with
+// the defaults of optional parameters filled in.
+func (m *Macro) BindArgs(args map[string]interface{}) (map[string]interface{}, error) {
+	bound := make(map[string]interface{}, len(m.Parameters))
+	for name := range args {
+		if !slices.ContainsFunc(m.Parameters, func(p MacroParameter) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("macro '%s' has no parameter '%s'", m.Name, name)
+		}
+	}
+	for _, p := range m.Parameters {
+		value, ok := args[p.Name]
+		switch {
+		case ok:
+			bound[p.Name] = value
+		case p.Required:
+			return nil, fmt.Errorf("macro '%s' requires argument '%s'", m.Name, p.Name)
+		case p.Default != nil:
+			bound[p.Name] = p.Default
+		}
+	}
+	return bound, nil
+}
+
+// SetMacroStore sets where run_macro actions look up macros.
+func (e *Executor) SetMacroStore(store MacroStore) {
+	e.macros = store
+}
+
+// RunMacro runs a macro's actions with args. scope is the caller's, and may
+// be nil.
+func (e *Executor) RunMacro(ctx context.Context, name string, args map[string]interface{}, scope *TemplateScope) ([]ActionResult, error) {
+	actions, macroScope, err := e.enterMacro(ctx, name, args, scope)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("Running macro '%s'", name)
+	return e.runSequence(ctx, actions, macroScope)
+}
+
+// enterMacro looks up a macro called from scope and binds args, returning
+// its actions and the scope they run in. It fails when the call would
+// recurse or nest too deeply.
+func (e *Executor) enterMacro(ctx context.Context, name string, args map[string]interface{}, scope *TemplateScope) ([]Action, *TemplateScope, error) {
+	if scope == nil {
+		scope = &TemplateScope{}
+	}
+	if slices.Contains(scope.Macros, name) {
+		return nil, nil, fmt.Errorf("macro '%s' calls itself (%s -> %s)", name, strings.Join(scope.Macros, " -> "), name)
+	}
+	if len(scope.Macros) >= MaxMacroDepth {
+		return nil, nil, fmt.Errorf("macros are nested too deeply (max %d levels)", MaxMacroDepth)
+	}
+	if e.macros == nil {
+		return nil, nil, fmt.Errorf("macros are not available")
+	}
+
+	dbMacro, err := e.macros.GetMacroByName(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	macro := ConvertStorageMacro(dbMacro)
+	bound, err := macro.BindArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return macro.Actions, &TemplateScope{
+		Rule:   scope.Rule,
+		Event:  scope.Event,
+		Args:   bound,
+		Macros: append(slices.Clone(scope.Macros), name),
+	}, nil
+}
+
+// ValidateRunMacroParams checks the parameters of a run_macro action
+// without looking the macro up, since it may be created later.
+func ValidateRunMacroParams(params map[string]interface{}) error {
+	_, _, err := macroCall(params)
+	return err
+}
+
+// macroCall reads the macro name and arguments from a run_macro action's
+// resolved parameters.
+func macroCall(params map[string]interface{}) (string, map[string]interface{}, error) {
+	name, ok := getStringParam(params, "macro")
+	if !ok {
+		return "", nil, fmt.Errorf("run_macro requires 'macro' parameter")
+	}
+	var args map[string]interface{}
+	if raw, ok := params["args"]; ok && raw != nil {
+		if args, ok = raw.(map[string]interface{}); !ok {
+			return name, nil, fmt.Errorf("run_macro 'args' must be an object")
+		}
+	}
+	return name, args, nil
+}
+
+// runMacro runs a run_macro action, recording the macro's name and the
+// results of its actions in result.
+func (e *Executor) runMacro(ctx context.Context, action Action, scope *TemplateScope, result *ActionResult) error {
+	params := action.Parameters
+	if hasTemplates(params) {
+		var err error
+		params, err = e.resolveParameters(ctx, params, scope, action.GetOnMissing())
+		result.Parameters = params
+		if err != nil {
+			return err
+		}
+	}
+
+	name, args, err := macroCall(params)
+	result.Macro = name
+	if err != nil {
+		return err
+	}
+
+	result.Results, err = e.RunMacro(ctx, name, args, scope)
+	if err != nil {
+		return fmt.Errorf("macro '%s': %w", name, err)
+	}
+	return nil
+}
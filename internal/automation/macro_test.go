@@ -0,0 +1,164 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runMacroAction(name string, args map[string]interface{}) Action {
+	params := map[string]interface{}{"macro": name}
+	if args != nil {
+		params["args"] = args
+	}
+	return Action{Type: ActionTypeRunMacro, Parameters: params, OnError: ActionErrorStop}
+}
+
+func TestExecutorRunMacro(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	create := func(macro storage.Macro) {
+		_, err := db.CreateMacro(ctx, macro)
+		require.NoError(t, err)
+	}
+	create(storage.Macro{
+		Name: "go-to-brb",
+		Parameters: []storage.MacroParameter{
+			{Name: "scene", Required: true},
+			{Name: "mic", Default: "Mic/Aux"},
+		},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{args.scene}}"}},
+			{Type: ActionTypeToggleMute, Parameters: map[string]interface{}{"input_name": "{{args.mic}}"}},
+		},
+	})
+	create(storage.Macro{
+		Name:       "intermission",
+		Parameters: []storage.MacroParameter{{Name: "label"}},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": "go-to-brb", "args": map[string]interface{}{"scene": "{{args.label}} BRB"}}, OnError: ActionErrorStop},
+			{Type: ActionTypeStartRecording},
+		},
+	})
+	create(storage.Macro{Name: "ping", Actions: []storage.RuleAction{{Type: ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": "pong"}, OnError: ActionErrorStop}}})
+	create(storage.Macro{Name: "pong", Actions: []storage.RuleAction{{Type: ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": "ping"}, OnError: ActionErrorStop}}})
+	for i := range MaxMacroDepth + 1 {
+		create(storage.Macro{Name: fmt.Sprintf("level-%d", i), Actions: []storage.RuleAction{
+			{Type: ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": fmt.Sprintf("level-%d", i+1)}, OnError: ActionErrorStop},
+		}})
+	}
+
+	newExecutor := func() (*Executor, *MockOBSClient) {
+		client := NewMockOBSClient()
+		executor := NewExecutor(client)
+		executor.SetMacroStore(db)
+		return executor, client
+	}
+
+	t.Run("binds arguments and defaults", func(t *testing.T) {
+		executor, client := newExecutor()
+		result := executor.ExecuteAction(runMacroAction("go-to-brb", map[string]interface{}{"scene": "BRB"}), 0)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "go-to-brb", result.Macro)
+		assert.Len(t, result.Results, 2)
+		assert.Equal(t, []string{"set_scene:BRB", "toggle_mute:Mic/Aux"}, client.GetActions())
+	})
+
+	t.Run("resolves arguments against the caller", func(t *testing.T) {
+		executor, client := newExecutor()
+		scope := &TemplateScope{Event: &EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "Gaming"}}}
+		result := executor.ExecuteActionWithScope(ctx, runMacroAction("intermission", map[string]interface{}{"label": "{{event.scene_name}}"}), 0, scope)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, []string{"set_scene:Gaming BRB", "toggle_mute:Mic/Aux", "start_recording"}, client.GetActions())
+
+		require.Len(t, result.Results, 2)
+		nested := result.Results[0]
+		assert.Equal(t, "go-to-brb", nested.Macro)
+		assert.Len(t, nested.Results, 2)
+	})
+
+	t.Run("checks arguments", func(t *testing.T) {
+		executor, client := newExecutor()
+		result := executor.ExecuteAction(runMacroAction("go-to-brb", nil), 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "requires argument 'scene'")
+
+		result = executor.ExecuteAction(runMacroAction("go-to-brb", map[string]interface{}{"scene": "BRB", "volume": 3}), 0)
+		assert.Contains(t, result.Error, "has no parameter 'volume'")
+
+		result = executor.ExecuteAction(runMacroAction("missing", nil), 0)
+		assert.Contains(t, result.Error, "macro 'missing' not found")
+		assert.Empty(t, client.GetActions())
+	})
+
+	t.Run("stops recursion", func(t *testing.T) {
+		executor, _ := newExecutor()
+		result := executor.ExecuteAction(runMacroAction("ping", nil), 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "macro 'ping' calls itself (ping -> pong -> ping)")
+	})
+
+	t.Run("limits depth", func(t *testing.T) {
+		executor, _ := newExecutor()
+		result := executor.ExecuteAction(runMacroAction("level-0", nil), 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "macros are nested too deeply")
+	})
+}
+
+func TestEngineRecordsMacroRuns(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := db.CreateMacro(ctx, storage.Macro{
+		Name:       "go-to-brb",
+		Parameters: []storage.MacroParameter{{Name: "scene", Default: "BRB"}},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{args.scene}}"}},
+			{Type: ActionTypeToggleMute, Parameters: map[string]interface{}{"input_name": "Mic"}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "break",
+		Enabled:       true,
+		TriggerType:   TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": "go-to-brb", "args": map[string]interface{}{"scene": "{{rule.name}}"}}},
+			{Type: ActionTypeStartRecording},
+		},
+	})
+	require.NoError(t, err)
+
+	client := NewMockOBSClient()
+	engine := NewAutomationEngine(db, client)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	result, err := engine.ExecuteRuleByName(ctx, "break", nil)
+	require.NoError(t, err)
+	assert.Equal(t, storage.ExecutionStatusCompleted, result.Status)
+	assert.Equal(t, []string{"set_scene:break", "toggle_mute:Mic", "start_recording"}, client.GetActions())
+
+	executions, err := db.GetRuleExecutions(ctx, result.RuleID, 1)
+	require.NoError(t, err)
+	stored := executions[0].ActionResults[0]
+	assert.Equal(t, "go-to-brb", stored.Macro)
+	require.Len(t, stored.Results, 2)
+	assert.Equal(t, ActionTypeSetScene, stored.Results[0].ActionType)
+
+	t.Run("runs outside rules", func(t *testing.T) {
+		results, err := engine.RunMacro(ctx, "go-to-brb", nil)
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, "set_scene:BRB", client.GetActions()[3])
+	})
+}
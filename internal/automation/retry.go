@@ -0,0 +1,182 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Retries, timeouts and fallbacks.
+//
+// An action with a retry policy is attempted up to max_attempts times. The
+// wait before a retry starts at backoff_ms and doubles each time, up to
+// max_backoff_ms; jitter randomly shortens or lengthens each wait by up to
+// that fraction so rules retrying together spread out. timeout_ms limits
+// each attempt. Every attempt is recorded in the action's result.
+//
+// Only failures the action returns are retried. An attempt that times out
+// is not: its OBS call keeps running in the background and may still take
+// effect, and a retry would then apply the action twice, which undoes a
+// toggle or presses a hotkey again. http_request actions cannot have a
+// retry policy; they retry with their own retries parameter, which only
+// repeats requests that failed in a way worth retrying.
+//
+// An action with on_error "goto" runs its fallback actions when it still
+// fails. If they complete, the rule goes on with the next action; if one of
+// them halts, so does the rule.
+
+// Retry and timeout limits.
+const (
+	defaultRetryAttempts     = 3
+	maxRetryAttempts         = 10
+	defaultRetryBackoffMs    = 500
+	defaultRetryMaxBackoffMs = 30000
+	maxRetryBackoffMs        = 5 * 60 * 1000
+	MaxActionTimeoutMs       = 5 * 60 * 1000
+)
+
+// errAttemptTimedOut is wrapped by the error of an attempt that ran out of
+// time. Such attempts are not retried.
+var errAttemptTimedOut = errors.New("timed out")
+
+// RetryPolicy says how a failed action is retried. Zero values use defaults.
+type RetryPolicy struct {
+	MaxAttempts  int     `json:"max_attempts,omitempty"`   // Including the first attempt (default 3, max 10)
+	BackoffMs    int     `json:"backoff_ms,omitempty"`     // Wait before the first retry (default 500)
+	MaxBackoffMs int     `json:"max_backoff_ms,omitempty"` // Upper bound on the wait (default 30000)
+	Jitter       float64 `json:"jitter,omitempty"`         // Fraction of each wait randomly added or removed, 0 to 1
+}
+
+// ValidateRetryPolicy checks a retry policy's limits.
+func ValidateRetryPolicy(p RetryPolicy) error {
+	if p.MaxAttempts < 0 || p.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("retry 'max_attempts' must be between 1 and %d", maxRetryAttempts)
+	}
+	if p.BackoffMs < 0 || p.BackoffMs > maxRetryBackoffMs {
+		return fmt.Errorf("retry 'backoff_ms' must be between 0 and %d", maxRetryBackoffMs)
+	}
+	if p.MaxBackoffMs < 0 || p.MaxBackoffMs > maxRetryBackoffMs {
+		return fmt.Errorf("retry 'max_backoff_ms' must be between 0 and %d", maxRetryBackoffMs)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("retry 'jitter' must be between 0 and 1")
+	}
+	return nil
+}
+
+// ValidateActionTimeout checks an action's timeout_ms. Zero means no limit.
+func ValidateActionTimeout(timeoutMs int) error {
+	if timeoutMs < 0 || timeoutMs > MaxActionTimeoutMs {
+		return fmt.Errorf("'timeout_ms' must be between 0 (no limit) and %d", MaxActionTimeoutMs)
+	}
+	return nil
+}
+
+// attempts returns how many times the action is tried.
+func (p *RetryPolicy) attempts() int {
+	if p == nil {
+		return 1
+	}
+	if p.MaxAttempts <= 0 {
+		return defaultRetryAttempts
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the wait before retry n (starting at 1). random returns a
+// number in [0, 1) and spreads the wait by the policy's jitter.
+func (p *RetryPolicy) backoff(n int, random func() float64) time.Duration {
+	base, limit := p.BackoffMs, p.MaxBackoffMs
+	if base == 0 {
+		base = defaultRetryBackoffMs
+	}
+	if limit == 0 {
+		limit = defaultRetryMaxBackoffMs
+	}
+
+	wait := float64(base)
+	for i := 1; i < n && wait < float64(limit); i++ {
+		wait *= 2
+	}
+	wait = min(wait, float64(limit))
+	wait *= 1 + p.Jitter*(2*random()-1)
+	return time.Duration(wait * float64(time.Millisecond))
+}
+
+// runAttempts runs an action under its retry policy and timeout, recording
+// each attempt in result when the action has a retry policy.
+func (e *Executor) runAttempts(ctx context.Context, action Action, result *ActionResult) error {
+	attempts := action.Retry.attempts()
+
+	var err error
+	for n := 1; n <= attempts; n++ {
+		if n > 1 {
+			if waitErr := sleepContext(ctx, action.Retry.backoff(n-1, rand.Float64)); waitErr != nil {
+				return waitErr
+			}
+		}
+
+		start := time.Now()
+		err = e.runAttempt(ctx, action)
+		if action.Retry != nil {
+			attempt := ActionAttempt{Attempt: n, Success: err == nil, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				attempt.Error = err.Error()
+			}
+			result.Attempts = append(result.Attempts, attempt)
+		}
+
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, errAttemptTimedOut) {
+			if n < attempts {
+				logger.Debugf("Action %d (%s) attempt %d of %d timed out, not retrying: %v", result.Index, action.Type, n, attempts, err)
+			}
+			return err
+		}
+		if n < attempts {
+			logger.Debugf("Action %d (%s) attempt %d of %d failed, retrying: %v", result.Index, action.Type, n, attempts, err)
+		}
+	}
+	return err
+}
+
+// runAttempt runs an action once, within its timeout if it has one.
+func (e *Executor) runAttempt(ctx context.Context, action Action) error {
+	if action.TimeoutMs <= 0 {
+		return e.runAction(ctx, action)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(action.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	err := e.runAction(attemptCtx, action)
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() != nil {
+		if errors.Is(err, errCallAbandoned) {
+			return fmt.Errorf("%s %w after %dms; %w", action.Type, errAttemptTimedOut, action.TimeoutMs, errCallAbandoned)
+		}
+		return fmt.Errorf("%s %w after %dms", action.Type, errAttemptTimedOut, action.TimeoutMs)
+	}
+	return err
+}
+
+// runFallback runs the fallback actions of a failed action with on_error
+// "goto", recording their results in result. The action stays failed with
+// actionErr when the fallback recovers; otherwise the fallback's failure is
+// added to the error.
+func (e *Executor) runFallback(ctx context.Context, action Action, scope *TemplateScope, result *ActionResult, actionErr error) error {
+	results, err := e.runSequence(ctx, action.Fallback, scope)
+	result.Fallback = results
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w; fallback failed: %v", actionErr, err)
+	}
+	result.Recovered = true
+	logger.Infof("Action %d (%s) failed, fallback recovered: %v", result.Index, action.Type, actionErr)
+	return actionErr
+}
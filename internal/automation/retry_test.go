@@ -0,0 +1,225 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyOBSClient is a mock whose scene switches fail until failures reaches
+// zero.
+type flakyOBSClient struct {
+	*MockOBSClient
+	failures atomic.Int32
+}
+
+func (f *flakyOBSClient) SetCurrentScene(name string) error {
+	if f.failures.Add(-1) >= 0 {
+		return errors.New("OBS is busy")
+	}
+	return f.MockOBSClient.SetCurrentScene(name)
+}
+
+// slowOBSClient is a mock whose mute toggles finish after delay.
+type slowOBSClient struct {
+	*MockOBSClient
+	delay time.Duration
+	calls atomic.Int32
+	done  atomic.Int32
+}
+
+func (s *slowOBSClient) ToggleInputMute(name string) error {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	defer s.done.Add(1)
+	return s.MockOBSClient.ToggleInputMute(name)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	half := func() float64 { return 0.5 }
+
+	policy := &RetryPolicy{BackoffMs: 100, MaxBackoffMs: 350}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1, half))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2, half))
+	assert.Equal(t, 350*time.Millisecond, policy.backoff(3, half))
+	assert.Equal(t, 350*time.Millisecond, policy.backoff(30, half))
+
+	defaults := &RetryPolicy{}
+	assert.Equal(t, 3, defaults.attempts())
+	assert.Equal(t, 500*time.Millisecond, defaults.backoff(1, half))
+	assert.Equal(t, 30*time.Second, defaults.backoff(20, half))
+
+	var none *RetryPolicy
+	assert.Equal(t, 1, none.attempts())
+
+	jittered := &RetryPolicy{BackoffMs: 1000, Jitter: 0.2}
+	assert.Equal(t, 800*time.Millisecond, jittered.backoff(1, func() float64 { return 0 }))
+	assert.Equal(t, 1000*time.Millisecond, jittered.backoff(1, half))
+	assert.Equal(t, 1100*time.Millisecond, jittered.backoff(1, func() float64 { return 0.75 }))
+}
+
+func TestValidateRetryPolicy(t *testing.T) {
+	assert.NoError(t, ValidateRetryPolicy(RetryPolicy{}))
+	assert.NoError(t, ValidateRetryPolicy(RetryPolicy{MaxAttempts: 5, BackoffMs: 200, MaxBackoffMs: 2000, Jitter: 0.5}))
+	assert.ErrorContains(t, ValidateRetryPolicy(RetryPolicy{MaxAttempts: 11}), "'max_attempts'")
+	assert.ErrorContains(t, ValidateRetryPolicy(RetryPolicy{BackoffMs: -1}), "'backoff_ms'")
+	assert.ErrorContains(t, ValidateRetryPolicy(RetryPolicy{MaxBackoffMs: 10 * 60 * 1000}), "'max_backoff_ms'")
+	assert.ErrorContains(t, ValidateRetryPolicy(RetryPolicy{Jitter: 1.5}), "'jitter'")
+
+	assert.NoError(t, ValidateActionTimeout(0))
+	assert.ErrorContains(t, ValidateActionTimeout(MaxActionTimeoutMs+1), "'timeout_ms'")
+}
+
+func TestExecutorRetryAndTimeout(t *testing.T) {
+	client := &flakyOBSClient{MockOBSClient: NewMockOBSClient()}
+	executor := NewExecutor(client)
+	setScene := Action{
+		Type:       ActionTypeSetScene,
+		Parameters: map[string]interface{}{"scene_name": "Live"},
+		Retry:      &RetryPolicy{MaxAttempts: 3, BackoffMs: 1},
+	}
+
+	t.Run("retries until the action succeeds", func(t *testing.T) {
+		client.failures.Store(2)
+		result := executor.ExecuteAction(setScene, 0)
+		require.True(t, result.Success, result.Error)
+
+		require.Len(t, result.Attempts, 3)
+		assert.Equal(t, 1, result.Attempts[0].Attempt)
+		assert.Equal(t, "OBS is busy", result.Attempts[0].Error)
+		assert.False(t, result.Attempts[1].Success)
+		assert.True(t, result.Attempts[2].Success)
+		assert.Equal(t, 3, result.Attempts[2].Attempt)
+	})
+
+	t.Run("fails after the last attempt", func(t *testing.T) {
+		client.failures.Store(5)
+		result := executor.ExecuteAction(setScene, 0)
+		assert.False(t, result.Success)
+		assert.Equal(t, "OBS is busy", result.Error)
+		assert.Len(t, result.Attempts, 3)
+		client.failures.Store(0)
+	})
+
+	t.Run("does not record attempts without a retry policy", func(t *testing.T) {
+		client.failures.Store(1)
+		result := executor.ExecuteAction(Action{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}}, 0)
+		assert.False(t, result.Success)
+		assert.Empty(t, result.Attempts)
+	})
+
+	t.Run("does not retry a timed-out attempt", func(t *testing.T) {
+		slow := &slowOBSClient{MockOBSClient: NewMockOBSClient(), delay: 100 * time.Millisecond}
+
+		start := time.Now()
+		result := NewExecutor(slow).ExecuteAction(Action{
+			Type:       ActionTypeToggleMute,
+			Parameters: map[string]interface{}{"input_name": "Mic"},
+			TimeoutMs:  20,
+			Retry:      &RetryPolicy{MaxAttempts: 3, BackoffMs: 1},
+		}, 0)
+		assert.Less(t, time.Since(start), slow.delay)
+		assert.False(t, result.Success)
+		assert.False(t, result.Cancelled, "a timeout is a failure, not a cancellation")
+		assert.Equal(t, "toggle_mute timed out after 20ms; the OBS request was abandoned in flight and may still take effect", result.Error)
+		assert.True(t, result.Abandoned)
+		assert.Len(t, result.Attempts, 1)
+
+		// The abandoned call still lands in OBS, and nothing repeats it
+		require.Eventually(t, func() bool { return slow.done.Load() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(1), slow.calls.Load())
+	})
+
+	t.Run("stops retrying when cancelled", func(t *testing.T) {
+		client.failures.Store(5)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		result := executor.ExecuteActionContext(ctx, Action{
+			Type:       ActionTypeSetScene,
+			Parameters: map[string]interface{}{"scene_name": "Live"},
+			Retry:      &RetryPolicy{MaxAttempts: 5, BackoffMs: 60000},
+		}, 0)
+		assert.True(t, result.Cancelled)
+		assert.Len(t, result.Attempts, 1)
+		client.failures.Store(0)
+	})
+}
+
+func TestEngineFallbackActions(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	create := func(name string, fallback []storage.RuleAction) {
+		_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+			Name:          name,
+			Enabled:       true,
+			TriggerType:   TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions: []storage.RuleAction{
+				{
+					Type:       ActionTypeSetScene,
+					Parameters: map[string]interface{}{"scene_name": "Live"},
+					Retry:      &storage.ActionRetry{MaxAttempts: 2, BackoffMs: 1},
+					OnError:    ActionErrorGoto,
+					Fallback:   fallback,
+				},
+				{Type: ActionTypeStartRecording},
+			},
+		})
+		require.NoError(t, err)
+	}
+	create("recovers", []storage.RuleAction{{Type: ActionTypeStartStreaming}})
+	create("fallback-fails", []storage.RuleAction{
+		{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "BRB"}, OnError: ActionErrorStop},
+	})
+
+	client := &flakyOBSClient{MockOBSClient: NewMockOBSClient()}
+	engine := NewAutomationEngine(db, client)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	t.Run("continues after a successful fallback", func(t *testing.T) {
+		client.failures.Store(2)
+		client.ClearActions()
+		result, err := engine.ExecuteRuleByName(ctx, "recovers", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, storage.ExecutionStatusCompleted, result.Status)
+		require.Len(t, result.ActionResults, 2)
+		first := result.ActionResults[0]
+		assert.False(t, first.Success)
+		assert.True(t, first.Recovered)
+		assert.Len(t, first.Attempts, 2)
+		require.Len(t, first.Fallback, 1)
+		assert.True(t, first.Fallback[0].Success)
+		assert.Equal(t, []string{"start_streaming", "start_recording"}, client.GetActions())
+
+		executions, err := db.GetRuleExecutions(ctx, result.RuleID, 1)
+		require.NoError(t, err)
+		stored := executions[0].ActionResults[0]
+		assert.True(t, stored.Recovered)
+		assert.Len(t, stored.Attempts, 2)
+		assert.Len(t, stored.Fallback, 1)
+	})
+
+	t.Run("stops when the fallback fails", func(t *testing.T) {
+		client.failures.Store(3)
+		client.ClearActions()
+		result, err := engine.ExecuteRuleByName(ctx, "fallback-fails", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, storage.ExecutionStatusFailed, result.Status)
+		assert.Contains(t, result.Error, "fallback failed")
+		require.Len(t, result.ActionResults, 1)
+		assert.False(t, result.ActionResults[0].Recovered)
+		assert.Empty(t, client.GetActions())
+	})
+}
@@ -0,0 +1,126 @@
+package automation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Running executions.
+//
+// Every execution that has started is tracked until it ends, so clients can
+// see what is running and cancel it. Cancelling an execution cancels its
+// context: a delay returns at once, an OBS call in progress is abandoned,
+// and no further action starts. The execution is recorded as cancelled.
+//
+// A request already sent to OBS cannot be withdrawn. An action cancelled
+// while waiting for one is marked abandoned, and the execution's error says
+// the request may still have taken effect.
+
+// ErrExecutionNotRunning is returned when cancelling an execution that is
+// not running, either because it has finished or because it never existed.
+var ErrExecutionNotRunning = errors.New("execution is not running")
+
+// RunningExecution describes an execution in progress.
+type RunningExecution struct {
+	ExecutionID int64     `json:"execution_id"`
+	RuleID      int64     `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	TriggerType string    `json:"trigger_type"`
+	ActionIndex int       `json:"action_index"` // Top-level action being run
+	ActionCount int       `json:"action_count"`
+	ActionType  string    `json:"action_type"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// RunningExecutions returns the recorded executions in progress, oldest
+// first. Executions waiting in a rule's queue are not included.
+func (e *AutomationEngine) RunningExecutions() []RunningExecution {
+	e.runsMu.Lock()
+	var running []RunningExecution
+	for _, runs := range e.runs {
+		for _, run := range runs.active {
+			if run.executionID == 0 || run.finished {
+				continue
+			}
+			exec := RunningExecution{
+				ExecutionID: run.executionID,
+				RuleID:      run.rule.ID,
+				RuleName:    run.rule.Name,
+				TriggerType: run.rule.TriggerType,
+				ActionIndex: run.actionIndex,
+				ActionCount: len(run.rule.Actions),
+				StartedAt:   run.startedAt,
+			}
+			if run.actionIndex < len(run.rule.Actions) {
+				exec.ActionType = run.rule.Actions[run.actionIndex].Type
+			}
+			running = append(running, exec)
+		}
+	}
+	e.runsMu.Unlock()
+
+	sort.Slice(running, func(i, j int) bool {
+		if !running[i].StartedAt.Equal(running[j].StartedAt) {
+			return running[i].StartedAt.Before(running[j].StartedAt)
+		}
+		return running[i].ExecutionID < running[j].ExecutionID
+	})
+	return running
+}
+
+// CancelExecution cancels a running execution. It returns once the
+// cancellation is requested; the execution stops within milliseconds and is
+// recorded as cancelled.
+func (e *AutomationEngine) CancelExecution(executionID int64) error {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+
+	for _, runs := range e.runs {
+		for _, run := range runs.active {
+			if run.executionID == executionID && !run.finished {
+				run.cancel()
+				logger.Infof("Cancelling execution %d of rule '%s'", executionID, run.rule.Name)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("execution %d: %w", executionID, ErrExecutionNotRunning)
+}
+
+// finishRun marks a run whose actions are done. It stops being listed and
+// cancellable while its record is finalized, before releaseRun frees its slot.
+func (e *AutomationEngine) finishRun(run *ruleRun) {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	run.finished = true
+}
+
+// abandonedAction returns the first action in results, or nested in them,
+// that was abandoned with its OBS request in flight, or nil.
+func abandonedAction(results []ActionResult) *ActionResult {
+	for i := range results {
+		r := &results[i]
+		if r.Abandoned {
+			return r
+		}
+		nested := [][]ActionResult{r.Results, r.Fallback}
+		nested = append(nested, r.Branches...)
+		nested = append(nested, r.Iterations...)
+		for _, list := range nested {
+			if found := abandonedAction(list); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// trackRun records the execution ID and current action of a run.
+func (e *AutomationEngine) trackRun(run *ruleRun, executionID int64, actionIndex int) {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+	run.executionID = executionID
+	run.actionIndex = actionIndex
+}
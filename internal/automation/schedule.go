@@ -0,0 +1,404 @@
+package automation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // IANA time zones on systems without a zoneinfo database
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule triggers.
+//
+// A schedule rule fires on a cron expression ("schedule"), once at a given
+// time ("at"), or delay_seconds after each matching event ("after_event",
+// with an optional event_filter). Cron expressions, "at" times without a UTC
+// offset and windows are read in the rule's "timezone", an IANA name such as
+// "America/New_York"; the default is the server's local time zone.
+//
+// "active_windows" limits a rule to times inside one of its windows, such
+// as scheduled show hours, and "blackout_windows" keeps it from firing
+// inside any of its windows. A window has start and end times of day
+// ("HH:MM") and optionally the days it starts on; a window that ends before
+// it starts runs past midnight. A fire time outside the windows is skipped.
+
+// Schedule trigger config keys.
+const (
+	scheduleCronKey     = "schedule"
+	scheduleAtKey       = "at"
+	scheduleEventKey    = "after_event"
+	scheduleDelayKey    = "delay_seconds"
+	scheduleTimezoneKey = "timezone"
+	scheduleActiveKey   = "active_windows"
+	scheduleBlackoutKey = "blackout_windows"
+)
+
+// Schedule limits.
+const (
+	MaxScheduleDelaySeconds = 24 * 60 * 60
+	MaxNextRuns             = 50
+
+	// Candidate fire times examined when looking for one inside the windows
+	maxScheduleCandidates = 1000
+)
+
+// scheduleAtLayouts are the accepted formats of "at" without a UTC offset.
+var scheduleAtLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// weekdays maps day names in window "days" to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ScheduleWindow is a recurring time-of-day window.
+type ScheduleWindow struct {
+	Days  []string `json:"days,omitempty"` // Days the window starts on; all days if empty
+	Start string   `json:"start"`          // "HH:MM"
+	End   string   `json:"end"`            // "HH:MM", before Start to run past midnight
+
+	days       [7]bool
+	start, end int // Minutes after midnight
+}
+
+// ScheduleSpec is the parsed trigger config of a schedule rule. Exactly one
+// of Cron, At and AfterEvent is set. It implements cron.Schedule.
+type ScheduleSpec struct {
+	Cron        string
+	At          time.Time
+	AfterEvent  string
+	Delay       time.Duration
+	Location    *time.Location
+	Active      []ScheduleWindow
+	Blackout    []ScheduleWindow
+	EventFilter map[string]interface{}
+
+	cron cron.Schedule
+}
+
+// ParseScheduleConfig parses and validates a schedule trigger config.
+func ParseScheduleConfig(config map[string]interface{}) (*ScheduleSpec, error) {
+	spec := &ScheduleSpec{Location: time.Local}
+
+	if raw, ok := config[scheduleTimezoneKey]; ok && raw != nil {
+		name, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("'timezone' must be an IANA time zone name such as 'America/New_York'")
+		}
+		if name != "" {
+			loc, err := time.LoadLocation(name)
+			if err != nil {
+				return nil, fmt.Errorf("unknown timezone '%s'", name)
+			}
+			spec.Location = loc
+		}
+	}
+
+	cronExpr, _ := config[scheduleCronKey].(string)
+	at, _ := config[scheduleAtKey].(string)
+	afterEvent, _ := config[scheduleEventKey].(string)
+	set := 0
+	for _, v := range []string{cronExpr, at, afterEvent} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("schedule trigger requires exactly one of 'schedule' (cron), 'at' or 'after_event' in trigger_config")
+	}
+
+	switch {
+	case cronExpr != "":
+		schedule, err := parseCron(cronExpr)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Location = spec.Location
+		spec.Cron = cronExpr
+		spec.cron = schedule
+
+	case at != "":
+		t, err := parseScheduleTime(at, spec.Location)
+		if err != nil {
+			return nil, err
+		}
+		spec.At = t
+
+	default:
+		if !slices.Contains(SupportedEventTypes(), afterEvent) {
+			return nil, fmt.Errorf("unknown after_event '%s'. Valid types: %v", afterEvent, SupportedEventTypes())
+		}
+		delay, ok := config[scheduleDelayKey].(float64)
+		if !ok || delay < 0 || delay > MaxScheduleDelaySeconds {
+			return nil, fmt.Errorf("after_event requires 'delay_seconds' between 0 and %d", MaxScheduleDelaySeconds)
+		}
+		if filter, ok := config["event_filter"].(map[string]interface{}); ok {
+			if err := ValidateEventFilter(filter); err != nil {
+				return nil, err
+			}
+			spec.EventFilter = filter
+		}
+		spec.AfterEvent = afterEvent
+		spec.Delay = time.Duration(delay * float64(time.Second))
+	}
+
+	var err error
+	if spec.Active, err = parseWindows(config, scheduleActiveKey); err != nil {
+		return nil, err
+	}
+	if spec.Blackout, err = parseWindows(config, scheduleBlackoutKey); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// parseCron parses a five-field cron expression.
+func parseCron(expr string) (*cron.SpecSchedule, error) {
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	schedule, err := parser.Parse("0 " + expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	spec, ok := schedule.(*cron.SpecSchedule)
+	if !ok {
+		return nil, fmt.Errorf("invalid cron expression '%s'", expr)
+	}
+	return spec, nil
+}
+
+// parseScheduleTime parses an "at" time, in loc unless it has a UTC offset.
+func parseScheduleTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range scheduleAtLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'at' must be a time such as '2026-05-01T20:00:00' or '2026-05-01T20:00:00Z', got '%s'", value)
+}
+
+// parseWindows parses the window list stored under key.
+func parseWindows(config map[string]interface{}, key string) ([]ScheduleWindow, error) {
+	raw, ok := config[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var windows []ScheduleWindow
+	if err := decoder.Decode(&windows); err != nil {
+		return nil, fmt.Errorf("'%s' must be a list of {days, start, end} windows: %w", key, err)
+	}
+
+	for i := range windows {
+		if err := windows[i].parse(); err != nil {
+			return nil, fmt.Errorf("%s %d: %w", key, i, err)
+		}
+	}
+	return windows, nil
+}
+
+// parse checks a window and fills in its parsed fields.
+func (w *ScheduleWindow) parse() error {
+	var err error
+	if w.start, err = parseTimeOfDay(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = parseTimeOfDay(w.End); err != nil {
+		return err
+	}
+	if w.start == w.end {
+		return fmt.Errorf("window start and end must differ")
+	}
+
+	if len(w.Days) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+		return nil
+	}
+	for _, name := range w.Days {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("unknown day '%s'; use mon, tue, wed, thu, fri, sat or sun", name)
+		}
+		w.days[day] = true
+	}
+	return nil
+}
+
+// parseTimeOfDay parses "HH:MM" into minutes after midnight.
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("window times must be 'HH:MM', got '%s'", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// on returns the occurrence of the window that starts dayOffset days after
+// the day of t, if the window starts on that day.
+func (w *ScheduleWindow) on(t time.Time, dayOffset int) (start, end time.Time, ok bool) {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d+dayOffset, 0, 0, 0, 0, t.Location())
+	if !w.days[day.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	start = time.Date(y, m, d+dayOffset, w.start/60, w.start%60, 0, 0, t.Location())
+	endDay := d + dayOffset
+	if w.end < w.start {
+		endDay++
+	}
+	end = time.Date(y, m, endDay, w.end/60, w.end%60, 0, 0, t.Location())
+	return start, end, true
+}
+
+// contains reports whether t is inside an occurrence of the window, and
+// when that occurrence ends.
+func (w *ScheduleWindow) contains(t time.Time) (bool, time.Time) {
+	for offset := 0; offset >= -1; offset-- {
+		if start, end, ok := w.on(t, offset); ok && !t.Before(start) && t.Before(end) {
+			return true, end
+		}
+	}
+	return false, time.Time{}
+}
+
+// nextStart returns the start of the window's next occurrence after t, or
+// the zero time if it never starts within a week.
+func (w *ScheduleWindow) nextStart(t time.Time) time.Time {
+	for offset := 0; offset <= 7; offset++ {
+		if start, _, ok := w.on(t, offset); ok && start.After(t) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// Allowed reports whether t is inside the spec's active windows, if it has
+// any, and outside its blackout windows.
+func (s *ScheduleSpec) Allowed(t time.Time) bool {
+	allowed, _ := s.allowed(t.In(s.Location))
+	return allowed
+}
+
+// allowed reports whether t is allowed and, if it is not, the earliest time
+// after t that might be.
+func (s *ScheduleSpec) allowed(t time.Time) (bool, time.Time) {
+	var resume time.Time
+	for i := range s.Blackout {
+		if in, end := s.Blackout[i].contains(t); in && end.After(resume) {
+			resume = end
+		}
+	}
+	if !resume.IsZero() {
+		return false, resume
+	}
+
+	if len(s.Active) == 0 {
+		return true, time.Time{}
+	}
+	for i := range s.Active {
+		if in, _ := s.Active[i].contains(t); in {
+			return true, time.Time{}
+		}
+		if start := s.Active[i].nextStart(t); !start.IsZero() && (resume.IsZero() || start.Before(resume)) {
+			resume = start
+		}
+	}
+	return false, resume
+}
+
+// Next returns the first fire time after t that is inside the windows, or
+// the zero time if there is none. After-event specs have no fixed fire
+// times.
+func (s *ScheduleSpec) Next(t time.Time) time.Time {
+	t = t.In(s.Location)
+	switch {
+	case !s.At.IsZero():
+		if t.Before(s.At) && s.Allowed(s.At) {
+			return s.At.In(s.Location)
+		}
+		return time.Time{}
+	case s.cron == nil:
+		return time.Time{}
+	}
+
+	for range maxScheduleCandidates {
+		next := s.cron.Next(t)
+		if next.IsZero() {
+			return next
+		}
+		ok, resume := s.allowed(next)
+		if ok {
+			return next
+		}
+		if resume.IsZero() {
+			return time.Time{}
+		}
+		// The cron schedule has minute resolution, so the next candidate is
+		// at or after resume
+		t = resume.Add(-time.Second)
+	}
+	return time.Time{}
+}
+
+// NextRuns returns up to count fire times after from.
+func (s *ScheduleSpec) NextRuns(from time.Time, count int) []time.Time {
+	var runs []time.Time
+	for t := from; len(runs) < count; {
+		next := s.Next(t)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+		t = next
+	}
+	return runs
+}
+
+// String describes when the spec fires.
+func (s *ScheduleSpec) String() string {
+	var desc string
+	switch {
+	case s.Cron != "":
+		desc = fmt.Sprintf("cron '%s'", s.Cron)
+	case !s.At.IsZero():
+		desc = "once at " + s.At.In(s.Location).Format(time.RFC3339)
+	default:
+		desc = fmt.Sprintf("%s after %s", s.Delay, s.AfterEvent)
+	}
+	desc += " (" + s.Location.String() + ")"
+	if len(s.Active) > 0 {
+		desc += fmt.Sprintf(", %d active window(s)", len(s.Active))
+	}
+	if len(s.Blackout) > 0 {
+		desc += fmt.Sprintf(", %d blackout window(s)", len(s.Blackout))
+	}
+	return desc
+}
+
+// PendingScheduledRuns returns when the waiting runs of an after-event rule
+// fire, earliest first.
+func (e *AutomationEngine) PendingScheduledRuns(ruleID int64) []time.Time {
+	e.mu.RLock()
+	scheduler := e.scheduler
+	e.mu.RUnlock()
+	if scheduler == nil {
+		return nil
+	}
+	return scheduler.PendingRuns(ruleID)
+}
@@ -0,0 +1,174 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScheduleConfig(t *testing.T) {
+	spec, err := ParseScheduleConfig(map[string]interface{}{
+		"schedule":         "0 20 * * 5",
+		"timezone":         "America/New_York",
+		"active_windows":   []interface{}{map[string]interface{}{"days": []interface{}{"fri", "Saturday"}, "start": "19:00", "end": "02:00"}},
+		"blackout_windows": []interface{}{map[string]interface{}{"start": "03:00", "end": "04:00"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", spec.Location.String())
+	assert.Len(t, spec.Active, 1)
+	assert.Len(t, spec.Blackout, 1)
+	assert.Equal(t, "cron '0 20 * * 5' (America/New_York), 1 active window(s), 1 blackout window(s)", spec.String())
+
+	spec, err = ParseScheduleConfig(map[string]interface{}{"after_event": EventStreamingStarted, "delay_seconds": 90.0})
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, spec.Delay)
+	assert.Empty(t, spec.NextRuns(time.Now(), 5), "after-event schedules have no fixed fire times")
+
+	for name, tc := range map[string]struct {
+		config map[string]interface{}
+		want   string
+	}{
+		"nothing":           {map[string]interface{}{}, "exactly one of"},
+		"cron and at":       {map[string]interface{}{"schedule": "* * * * *", "at": "2030-01-01T00:00:00Z"}, "exactly one of"},
+		"bad cron":          {map[string]interface{}{"schedule": "every day"}, "invalid cron expression"},
+		"bad timezone":      {map[string]interface{}{"schedule": "* * * * *", "timezone": "Mars/Olympus"}, "unknown timezone"},
+		"bad at":            {map[string]interface{}{"at": "tomorrow"}, "'at' must be a time"},
+		"unknown event":     {map[string]interface{}{"after_event": "lunch", "delay_seconds": 5.0}, "unknown after_event"},
+		"no delay":          {map[string]interface{}{"after_event": EventStreamingStarted}, "'delay_seconds'"},
+		"delay too long":    {map[string]interface{}{"after_event": EventStreamingStarted, "delay_seconds": 100000.0}, "'delay_seconds'"},
+		"bad event filter":  {map[string]interface{}{"after_event": EventSceneChanged, "delay_seconds": 5.0, "event_filter": map[string]interface{}{"scene_name": map[string]interface{}{"like": "x"}}}, "unknown operator"},
+		"window not a list": {map[string]interface{}{"schedule": "* * * * *", "active_windows": "evenings"}, "must be a list"},
+		"window bad time":   {map[string]interface{}{"schedule": "* * * * *", "active_windows": []interface{}{map[string]interface{}{"start": "7pm", "end": "23:00"}}}, "'HH:MM'"},
+		"window bad day":    {map[string]interface{}{"schedule": "* * * * *", "blackout_windows": []interface{}{map[string]interface{}{"days": []interface{}{"funday"}, "start": "01:00", "end": "02:00"}}}, "unknown day"},
+		"window empty":      {map[string]interface{}{"schedule": "* * * * *", "active_windows": []interface{}{map[string]interface{}{"start": "10:00", "end": "10:00"}}}, "must differ"},
+		"window extra key":  {map[string]interface{}{"schedule": "* * * * *", "active_windows": []interface{}{map[string]interface{}{"start": "10:00", "end": "11:00", "tz": "UTC"}}}, "unknown field"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseScheduleConfig(tc.config)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestScheduleSpecNextRuns(t *testing.T) {
+	from := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC) // Friday
+	format := func(runs []time.Time) []string {
+		out := make([]string, len(runs))
+		for i, run := range runs {
+			out[i] = run.Format("Mon 2006-01-02 15:04 MST")
+		}
+		return out
+	}
+
+	t.Run("reads cron in the rule's time zone", func(t *testing.T) {
+		spec, err := ParseScheduleConfig(map[string]interface{}{"schedule": "0 20 * * *", "timezone": "America/New_York"})
+		require.NoError(t, err)
+		runs := spec.NextRuns(from, 3)
+		// Daylight saving time starts on March 8
+		assert.Equal(t, []string{"Fri 2026-03-06 20:00 EST", "Sat 2026-03-07 20:00 EST", "Sun 2026-03-08 20:00 EDT"}, format(runs))
+		assert.Equal(t, time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC), runs[0].UTC())
+	})
+
+	t.Run("only fires inside active windows", func(t *testing.T) {
+		spec, err := ParseScheduleConfig(map[string]interface{}{
+			"schedule":       "0 * * * *",
+			"timezone":       "America/New_York",
+			"active_windows": []interface{}{map[string]interface{}{"days": []interface{}{"fri"}, "start": "22:00", "end": "01:00"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"Fri 2026-03-06 22:00 EST", "Fri 2026-03-06 23:00 EST", "Sat 2026-03-07 00:00 EST",
+			"Fri 2026-03-13 22:00 EDT",
+		}, format(spec.NextRuns(from, 4)))
+	})
+
+	t.Run("skips blackout windows", func(t *testing.T) {
+		spec, err := ParseScheduleConfig(map[string]interface{}{
+			"schedule":         "*/30 * * * *",
+			"timezone":         "UTC",
+			"blackout_windows": []interface{}{map[string]interface{}{"start": "12:45", "end": "14:00"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Fri 2026-03-06 12:30 UTC", "Fri 2026-03-06 14:00 UTC", "Fri 2026-03-06 14:30 UTC"}, format(spec.NextRuns(from, 3)))
+	})
+
+	t.Run("stops when the windows never allow a run", func(t *testing.T) {
+		spec, err := ParseScheduleConfig(map[string]interface{}{
+			"schedule":       "0 9 * * *",
+			"active_windows": []interface{}{map[string]interface{}{"start": "20:00", "end": "22:00"}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, spec.NextRuns(from, 3))
+	})
+
+	t.Run("fires once at a time", func(t *testing.T) {
+		spec, err := ParseScheduleConfig(map[string]interface{}{"at": "2026-03-06T21:30:00", "timezone": "America/New_York"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Fri 2026-03-06 21:30 EST"}, format(spec.NextRuns(from, 3)))
+		assert.True(t, spec.Next(spec.At).IsZero())
+
+		spec, err = ParseScheduleConfig(map[string]interface{}{"at": "2026-03-06T21:30:00Z", "timezone": "America/New_York"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Fri 2026-03-06 16:30 EST"}, format(spec.NextRuns(from, 3)))
+	})
+}
+
+func TestEngineAfterEventSchedule(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	rule, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:        "back-to-live",
+		Enabled:     true,
+		TriggerType: TriggerTypeSchedule,
+		TriggerConfig: map[string]interface{}{
+			"after_event":   EventSceneChanged,
+			"delay_seconds": 0.2,
+			"event_filter":  map[string]interface{}{"scene_name": "BRB"},
+		},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live after {{event.scene_name}}"}},
+		},
+	})
+	require.NoError(t, err)
+
+	client := NewMockOBSClient()
+	engine := NewAutomationEngine(db, client)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	engine.HandleEvent(EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "Gaming"}})
+	engine.HandleEvent(EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "BRB"}})
+	require.Eventually(t, func() bool { return len(engine.PendingScheduledRuns(rule)) == 1 }, time.Second, 5*time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(client.GetActions()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"set_scene:Live after BRB"}, client.GetActions())
+	assert.Empty(t, engine.PendingScheduledRuns(rule))
+
+	t.Run("unscheduling drops waiting runs", func(t *testing.T) {
+		require.NoError(t, db.UpdateAutomationRule(ctx, storage.AutomationRule{
+			ID:          rule,
+			Name:        "back-to-live",
+			Enabled:     true,
+			TriggerType: TriggerTypeSchedule,
+			TriggerConfig: map[string]interface{}{
+				"after_event":   EventSceneChanged,
+				"delay_seconds": 60.0,
+			},
+			Actions: []storage.RuleAction{{Type: ActionTypeStartRecording}},
+		}))
+		engine.NotifyRuleChange(rule, false)
+
+		engine.HandleEvent(EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "BRB"}})
+		require.Eventually(t, func() bool { return len(engine.PendingScheduledRuns(rule)) == 1 }, time.Second, 5*time.Millisecond)
+
+		engine.NotifyRuleChange(rule, true)
+		assert.Empty(t, engine.PendingScheduledRuns(rule))
+	})
+}
@@ -1,28 +1,50 @@
 package automation
 
 import (
-	"fmt"
-	"log"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
+
+	"github.com/ironystock/agentic-obs/internal/logging"
 )
 
-// ScheduleManager handles cron-like scheduling for automation rules.
+var schedulerLog = logging.New("automation.scheduler")
+
+// ScheduleManager handles cron-like scheduling for automation rules, and
+// the delayed runs of rules that fire some time after an event.
 type ScheduleManager struct {
-	mu       sync.RWMutex
-	cron     *cron.Cron
-	ruleJobs map[int64]cron.EntryID // rule ID → cron entry
-	executor func(rule *Rule)
-	running  bool
+	mu         sync.RWMutex
+	cron       *cron.Cron
+	ruleJobs   map[int64]cron.EntryID    // rule ID → cron entry
+	afterEvent map[int64]*afterEventRule // rule ID → after-event rule
+	executor   func(rule *Rule, payload *EventPayload)
+	running    bool
+}
+
+// afterEventRule is a rule that fires delay_seconds after an event, with
+// its runs still waiting to fire.
+type afterEventRule struct {
+	rule    Rule
+	spec    *ScheduleSpec
+	pending []*delayedRun
+}
+
+// delayedRun is one waiting run of an after-event rule.
+type delayedRun struct {
+	timer  *time.Timer
+	fireAt time.Time
 }
 
-// NewScheduleManager creates a new schedule manager.
-func NewScheduleManager(executor func(rule *Rule)) *ScheduleManager {
+// NewScheduleManager creates a new schedule manager. executor is called
+// with the triggering event for after-event runs and nil otherwise.
+func NewScheduleManager(executor func(rule *Rule, payload *EventPayload)) *ScheduleManager {
 	return &ScheduleManager{
-		cron:     cron.New(cron.WithSeconds()),
-		ruleJobs: make(map[int64]cron.EntryID),
-		executor: executor,
+		cron:       cron.New(cron.WithSeconds()),
+		ruleJobs:   make(map[int64]cron.EntryID),
+		afterEvent: make(map[int64]*afterEventRule),
+		executor:   executor,
 	}
 }
 
@@ -37,10 +59,10 @@ func (sm *ScheduleManager) Start() {
 
 	sm.cron.Start()
 	sm.running = true
-	log.Println("[Scheduler] Started")
+	schedulerLog.Infof("Started")
 }
 
-// Stop halts the scheduler.
+// Stop halts the scheduler and drops runs waiting to fire.
 func (sm *ScheduleManager) Stop() {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -51,54 +73,120 @@ func (sm *ScheduleManager) Stop() {
 
 	ctx := sm.cron.Stop()
 	<-ctx.Done()
+	for _, entry := range sm.afterEvent {
+		entry.stopPending()
+	}
 	sm.running = false
-	log.Println("[Scheduler] Stopped")
+	schedulerLog.Infof("Stopped")
 }
 
 // Schedule adds a rule to the scheduler.
 func (sm *ScheduleManager) Schedule(rule *Rule) error {
+	spec, err := ParseScheduleConfig(rule.TriggerConfig)
+	if err != nil {
+		return err
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	schedule := rule.GetSchedule()
-	if schedule == "" {
-		return fmt.Errorf("rule '%s' has no schedule", rule.Name)
+	// Remove any existing schedule for this rule
+	sm.unscheduleLocked(rule.ID)
+
+	ruleCopy := *rule // Copy to avoid issues with pointer reuse
+	if spec.AfterEvent != "" {
+		sm.afterEvent[rule.ID] = &afterEventRule{rule: ruleCopy, spec: spec}
+	} else {
+		sm.ruleJobs[rule.ID] = sm.cron.Schedule(spec, cron.FuncJob(func() {
+			sm.executor(&ruleCopy, nil)
+		}))
 	}
+	schedulerLog.Infof("Scheduled rule '%s' with %s", rule.Name, spec)
+	return nil
+}
 
-	// Convert 5-field cron to 6-field (add seconds)
-	// The robfig/cron/v3 library with WithSeconds() expects 6 fields
-	cronExpr := "0 " + schedule
+// ScheduleAfterEvent starts a delayed run of an after-event rule for
+// payload. It returns false if the rule is not scheduled as one.
+func (sm *ScheduleManager) ScheduleAfterEvent(ruleID int64, payload EventPayload) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
 
-	// Remove any existing schedule for this rule
-	if entryID, exists := sm.ruleJobs[rule.ID]; exists {
-		sm.cron.Remove(entryID)
+	entry := sm.afterEvent[ruleID]
+	if entry == nil || !sm.running {
+		return false
+	}
+	run := &delayedRun{fireAt: time.Now().Add(entry.spec.Delay)}
+	run.timer = time.AfterFunc(entry.spec.Delay, func() {
+		sm.fireDelayed(entry, run, payload)
+	})
+	entry.pending = append(entry.pending, run)
+	schedulerLog.Debugf("Rule '%s' will fire in %s after %s", entry.rule.Name, entry.spec.Delay, payload.EventType)
+	return true
+}
+
+// fireDelayed runs a delayed run that is still pending, if the rule's
+// windows allow it.
+func (sm *ScheduleManager) fireDelayed(entry *afterEventRule, run *delayedRun, payload EventPayload) {
+	sm.mu.Lock()
+	if !slices.Contains(entry.pending, run) {
+		sm.mu.Unlock()
+		return
 	}
+	entry.pending = slices.DeleteFunc(entry.pending, func(r *delayedRun) bool { return r == run })
+	sm.mu.Unlock()
 
-	// Create a closure that captures the rule
-	ruleCopy := *rule // Copy to avoid issues with pointer reuse
-	job := func() {
-		sm.executor(&ruleCopy)
+	if !entry.spec.Allowed(time.Now()) {
+		schedulerLog.Debugf("Rule '%s' skipped (outside its windows)", entry.rule.Name)
+		return
 	}
+	sm.executor(&entry.rule, &payload)
+}
 
-	entryID, err := sm.cron.AddFunc(cronExpr, job)
-	if err != nil {
-		return fmt.Errorf("invalid cron expression '%s': %w", schedule, err)
+// stopPending drops the runs waiting to fire. Caller must hold sm.mu.
+func (entry *afterEventRule) stopPending() {
+	for _, run := range entry.pending {
+		run.timer.Stop()
 	}
+	entry.pending = nil
+}
 
-	sm.ruleJobs[rule.ID] = entryID
-	log.Printf("[Scheduler] Scheduled rule '%s' with cron '%s'", rule.Name, schedule)
-	return nil
+// PendingRuns returns when the waiting runs of an after-event rule fire,
+// earliest first.
+func (sm *ScheduleManager) PendingRuns(ruleID int64) []time.Time {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	entry := sm.afterEvent[ruleID]
+	if entry == nil {
+		return nil
+	}
+	runs := make([]time.Time, len(entry.pending))
+	for i, run := range entry.pending {
+		runs[i] = run.fireAt
+	}
+	slices.SortFunc(runs, time.Time.Compare)
+	return runs
 }
 
-// Unschedule removes a rule from the scheduler.
+// Unschedule removes a rule from the scheduler, dropping runs waiting to
+// fire.
 func (sm *ScheduleManager) Unschedule(ruleID int64) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	sm.unscheduleLocked(ruleID)
+}
 
+// unscheduleLocked removes a rule from the scheduler. Caller must hold sm.mu.
+func (sm *ScheduleManager) unscheduleLocked(ruleID int64) {
 	if entryID, exists := sm.ruleJobs[ruleID]; exists {
 		sm.cron.Remove(entryID)
 		delete(sm.ruleJobs, ruleID)
-		log.Printf("[Scheduler] Unscheduled rule ID %d", ruleID)
+		schedulerLog.Debugf("Unscheduled rule ID %d", ruleID)
+	}
+	if entry, exists := sm.afterEvent[ruleID]; exists {
+		entry.stopPending()
+		delete(sm.afterEvent, ruleID)
+		schedulerLog.Debugf("Unscheduled rule ID %d", ruleID)
 	}
 }
 
@@ -106,16 +194,11 @@ func (sm *ScheduleManager) Unschedule(ruleID int64) {
 func (sm *ScheduleManager) GetScheduledCount() int {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
-	return len(sm.ruleJobs)
+	return len(sm.ruleJobs) + len(sm.afterEvent)
 }
 
 // ValidateCronExpression checks if a cron expression is valid.
 func ValidateCronExpression(expr string) error {
-	// Test parsing with seconds prefix
-	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	_, err := parser.Parse("0 " + expr)
-	if err != nil {
-		return fmt.Errorf("invalid cron expression: %w", err)
-	}
-	return nil
+	_, err := parseCron(expr)
+	return err
 }
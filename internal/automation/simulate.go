@@ -0,0 +1,332 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rule simulation.
+//
+// Simulate runs an event through the same matching steps as a real event
+// (event type, filter, cooldown, condition, concurrency policy, priority
+// order) and reports the outcome without running anything. The concurrency
+// policy is checked against the executions in flight when Simulate runs.
+// Schedule rules that fire after the event, and composite rules whose hold
+// or debounce the event would start, are reported with their delay; their
+// condition and concurrency policy are checked only when they run. The
+// state of composite triggers is read but not advanced. Cooldowns are read but not recorded,
+// and no action is executed. Template variables and if conditions are
+// resolved against the current OBS state and variables, which are only read.
+
+// SimulationResult reports which rules an event would fire.
+type SimulationResult struct {
+	Event   EventPayload    `json:"event"`
+	Fired   []SimulatedRule `json:"fired"`   // In execution order (highest priority first, delayed runs last)
+	Skipped []SimulatedRule `json:"skipped"` // Event-triggered rules that would not run, with the reason
+}
+
+// SimulatedRule is a rule's outcome in a simulation.
+type SimulatedRule struct {
+	RuleID   int64             `json:"rule_id"`
+	RuleName string            `json:"rule_name"`
+	Priority int               `json:"priority"`
+	Reason   string            `json:"reason,omitempty"`  // Why the rule was skipped
+	Note     string            `json:"note,omitempty"`    // How a fired rule would run, if not right away
+	Actions  []SimulatedAction `json:"actions,omitempty"` // Resolved actions of a fired rule
+}
+
+// SimulatedAction is an action as it would run, with its parameters
+// resolved. For if actions, Branch names the branch that would be taken and
+// Actions lists its actions. Parallel actions list each branch in Branches;
+// repeat actions list one iteration in Actions, and run_macro actions the
+// macro's actions. Fallback lists the actions that would run if an action
+// with on_error "goto" failed.
+type SimulatedAction struct {
+	Index      int                    `json:"index"`
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	OnError    string                 `json:"on_error,omitempty"`
+	Condition  string                 `json:"condition,omitempty"`
+	Branch     string                 `json:"branch,omitempty"`
+	Actions    []SimulatedAction      `json:"actions,omitempty"`
+	Branches   [][]SimulatedAction    `json:"branches,omitempty"`
+	Count      int                    `json:"count,omitempty"`
+	Until      string                 `json:"until,omitempty"`
+	Fallback   []SimulatedAction      `json:"fallback,omitempty"`
+	Error      string                 `json:"error,omitempty"` // Why the action would fail before running
+}
+
+// Simulate reports what the engine would do with payload, without running
+// any action or changing cooldowns.
+func (e *AutomationEngine) Simulate(payload EventPayload) (*SimulationResult, error) {
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = time.Now()
+	}
+
+	e.mu.RLock()
+	if !e.running {
+		e.mu.RUnlock()
+		return nil, fmt.Errorf("automation engine is not running")
+	}
+	now := time.Now()
+	var candidates []simulationCandidate
+	for _, rule := range e.rules {
+		if !rule.Enabled {
+			continue
+		}
+		c := simulationCandidate{rule: rule}
+		switch {
+		case rule.TriggerType == TriggerTypeEvent:
+			if eventType := rule.GetEventType(); eventType != payload.EventType {
+				c.reason = fmt.Sprintf("listens for '%s'", eventType)
+			} else if ok, reason := e.matchesFilter(rule.GetEventFilter(), payload.Data); !ok {
+				c.reason = "filter: " + reason
+			} else if remaining := e.cooldownRemainingLocked(rule, now); remaining > 0 {
+				c.reason = fmt.Sprintf("cooldown: %s remaining", remaining.Round(time.Millisecond))
+			}
+		case rule.GetAfterEvent() != "":
+			e.simulateAfterEventLocked(&c, payload, now)
+		case rule.TriggerType == TriggerTypeComposite:
+			e.simulateCompositeLocked(&c, payload, now)
+		default:
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	e.mu.RUnlock()
+
+	// Rules are visited in a stable order so ties in priority are reported
+	// consistently. Delayed runs come after the rules that run right away.
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].delay != candidates[j].delay {
+			return candidates[i].delay < candidates[j].delay
+		}
+		if candidates[i].rule.Priority != candidates[j].rule.Priority {
+			return candidates[i].rule.Priority > candidates[j].rule.Priority
+		}
+		return candidates[i].rule.ID < candidates[j].rule.ID
+	})
+
+	result := &SimulationResult{Event: payload, Fired: []SimulatedRule{}, Skipped: []SimulatedRule{}}
+	for _, c := range candidates {
+		rule := c.rule
+		sim := SimulatedRule{RuleID: rule.ID, RuleName: rule.Name, Priority: rule.Priority, Reason: c.reason, Note: c.note}
+
+		if sim.Reason == "" && !c.deferred && rule.Condition != "" {
+			met, err := e.executor.EvaluateCondition(e.ctx, rule.Condition, &TemplateScope{Rule: rule, Event: &payload})
+			switch {
+			case err != nil:
+				sim.Reason = fmt.Sprintf("condition error: %v", err)
+			case !met:
+				sim.Reason = "condition not met: " + rule.Condition
+			}
+		}
+
+		if sim.Reason == "" && !c.deferred {
+			sim.Reason, sim.Note = e.simulateConcurrency(rule)
+		}
+
+		if sim.Reason != "" {
+			result.Skipped = append(result.Skipped, sim)
+			continue
+		}
+		sim.Actions = e.executor.simulateActions(e.ctx, rule.Actions, &TemplateScope{Rule: rule, Event: &payload})
+		result.Fired = append(result.Fired, sim)
+	}
+	return result, nil
+}
+
+// simulationCandidate is a rule an event could trigger, with the reason it
+// would not run or a note on how it would.
+type simulationCandidate struct {
+	rule     *Rule
+	reason   string
+	note     string
+	delay    time.Duration // How long after the event the rule would run
+	deferred bool          // The condition and concurrency policy are checked when the rule runs
+}
+
+// simulateAfterEventLocked matches payload against a schedule rule that
+// fires some time after an event, as dispatchEvent would. Caller must hold
+// e.mu (read or write).
+func (e *AutomationEngine) simulateAfterEventLocked(c *simulationCandidate, payload EventPayload, now time.Time) {
+	rule := c.rule
+	spec, err := ParseScheduleConfig(rule.TriggerConfig)
+	if err != nil {
+		c.reason = "schedule: " + err.Error()
+		return
+	}
+	fireAt := now.Add(spec.Delay)
+	if spec.AfterEvent != payload.EventType {
+		c.reason = fmt.Sprintf("fires after '%s'", spec.AfterEvent)
+	} else if ok, reason := e.matchesFilter(rule.GetEventFilter(), payload.Data); !ok {
+		c.reason = "filter: " + reason
+	} else if remaining := e.cooldownRemainingLocked(rule, now); remaining > 0 {
+		c.reason = fmt.Sprintf("cooldown: %s remaining", remaining.Round(time.Millisecond))
+	} else if !spec.Allowed(fireAt) {
+		c.reason = fmt.Sprintf("schedule: would fire at %s, outside its windows", fireAt.In(spec.Location).Format(time.RFC3339))
+	} else {
+		c.delay, c.deferred = spec.Delay, true
+		c.note = fmt.Sprintf("would run %s after the event; its condition and concurrency policy are checked then", spec.Delay)
+	}
+}
+
+// simulateCompositeLocked matches payload against a composite rule's
+// trigger, as dispatchEvent would, without advancing its state. Caller must
+// hold e.mu (read or write).
+func (e *AutomationEngine) simulateCompositeLocked(c *simulationCandidate, payload EventPayload, now time.Time) {
+	tracker := e.composites[c.rule.ID]
+	if tracker == nil {
+		c.reason = "composite: trigger is not loaded"
+		return
+	}
+	spec := tracker.spec
+	peek := tracker.peek(payload)
+
+	switch {
+	case !peek.watched:
+		var types []string
+		for _, event := range spec.Events {
+			if quoted := "'" + event.EventType + "'"; !slices.Contains(types, quoted) {
+				types = append(types, quoted)
+			}
+		}
+		c.reason = "listens for " + strings.Join(types, ", ")
+	case !peek.matched && spec.Hold > 0 && peek.pending:
+		c.reason = "composite: the event breaks the pending hold"
+	case !peek.matched:
+		c.reason = "filter: the event matches none of the watched events"
+	case !peek.satisfied:
+		c.reason = fmt.Sprintf("composite: %s trigger not yet satisfied", spec.Mode)
+	case spec.Hold > 0 && peek.pending:
+		c.reason = "composite: a hold is already pending for an earlier event"
+	case spec.Hold > 0:
+		c.delay, c.deferred = spec.Hold, true
+		c.note = fmt.Sprintf("would run after a %s hold unless an event breaks it; its cooldown, condition and concurrency policy are checked then", spec.Hold)
+	case spec.Debounce > 0:
+		c.delay, c.deferred = spec.Debounce, true
+		c.note = fmt.Sprintf("would run after a %s debounce unless the trigger is satisfied again; its cooldown, condition and concurrency policy are checked then", spec.Debounce)
+	default:
+		if remaining := e.cooldownRemainingLocked(c.rule, now); remaining > 0 {
+			c.reason = fmt.Sprintf("cooldown: %s remaining", remaining.Round(time.Millisecond))
+		}
+	}
+}
+
+// simulateConcurrency reports what the rule's concurrency policy would do
+// with a new execution, as acquireRun would decide it now: the reason the
+// execution would be skipped, or a note if it would wait or cancel others.
+func (e *AutomationEngine) simulateConcurrency(rule *Rule) (reason, note string) {
+	e.runsMu.Lock()
+	defer e.runsMu.Unlock()
+
+	runs := e.runs[rule.ID]
+	if runs == nil || len(runs.active) == 0 && len(runs.waiting) == 0 {
+		return "", ""
+	}
+	running, queued := len(runs.active), len(runs.waiting)
+
+	switch policy := rule.GetConcurrency(); {
+	case policy == ConcurrencyParallel:
+		return "", fmt.Sprintf("would run alongside %d running execution(s)", running)
+	case policy == ConcurrencySkipIfRunning:
+		return "concurrency: " + strings.TrimPrefix(skipReasonRunning, "skipped: "), ""
+	case policy == ConcurrencyRestart:
+		return "", fmt.Sprintf("would cancel %d running and %d queued execution(s) first", running, queued)
+	case queued >= maxQueuedRuns:
+		return "concurrency: " + strings.TrimPrefix(skipReasonQueueFull, "skipped: "), ""
+	}
+	return "", fmt.Sprintf("would wait in the queue behind %d running and %d queued execution(s)", running, queued)
+}
+
+// cooldownRemainingLocked returns how long the rule stays in cooldown at
+// now, or zero. Caller must hold e.mu (read or write).
+func (e *AutomationEngine) cooldownRemainingLocked(rule *Rule, now time.Time) time.Duration {
+	if rule.CooldownMs <= 0 {
+		return 0
+	}
+	lastRun, exists := e.cooldowns[rule.ID]
+	if !exists {
+		return 0
+	}
+	return max(lastRun.Add(time.Duration(rule.CooldownMs)*time.Millisecond).Sub(now), 0)
+}
+
+// simulateActions resolves actions as they would run, choosing if branches
+// from the current state, without executing them. A repeat's actions are
+// resolved once.
+func (e *Executor) simulateActions(ctx context.Context, actions []Action, scope *TemplateScope) []SimulatedAction {
+	simulated := make([]SimulatedAction, 0, len(actions))
+	for i, action := range actions {
+		sim := SimulatedAction{
+			Index:      i,
+			Type:       action.Type,
+			Parameters: action.Parameters,
+			OnError:    action.OnError,
+			Condition:  action.Condition,
+		}
+
+		switch {
+		case action.Type == ActionTypeIf:
+			met, err := e.EvaluateCondition(ctx, action.Condition, scope)
+			if err != nil {
+				sim.Error = err.Error()
+			} else if met {
+				sim.Branch = "then"
+				sim.Actions = e.simulateActions(ctx, action.Then, scope)
+			} else {
+				sim.Branch = "else"
+				sim.Actions = e.simulateActions(ctx, action.Else, scope)
+			}
+		case action.Type == ActionTypeParallel:
+			for _, branch := range action.Branches {
+				sim.Branches = append(sim.Branches, e.simulateActions(ctx, branch, scope))
+			}
+		case action.Type == ActionTypeRepeat:
+			sim.Count = action.Count
+			sim.Until = action.Until
+			sim.Actions = e.simulateActions(ctx, action.Actions, scope)
+		case action.Type == ActionTypeRunMacro:
+			sim.Actions, sim.Parameters, sim.Error = e.simulateMacro(ctx, action, scope)
+		case hasTemplates(action.Parameters):
+			params, err := e.resolveParameters(ctx, action.Parameters, scope, action.GetOnMissing())
+			sim.Parameters = params
+			if err != nil {
+				sim.Error = err.Error()
+			}
+		}
+
+		if action.GetOnError() == ActionErrorGoto {
+			sim.Fallback = e.simulateActions(ctx, action.Fallback, scope)
+		}
+
+		simulated = append(simulated, sim)
+	}
+	return simulated
+}
+
+// simulateMacro resolves the actions a run_macro action would run. It
+// returns them with the action's resolved parameters and, if the call would
+// fail before running, the reason.
+func (e *Executor) simulateMacro(ctx context.Context, action Action, scope *TemplateScope) ([]SimulatedAction, map[string]interface{}, string) {
+	params := action.Parameters
+	if hasTemplates(params) {
+		var err error
+		if params, err = e.resolveParameters(ctx, params, scope, action.GetOnMissing()); err != nil {
+			return nil, params, err.Error()
+		}
+	}
+	name, args, err := macroCall(params)
+	if err != nil {
+		return nil, params, err.Error()
+	}
+	actions, macroScope, err := e.enterMacro(ctx, name, args, scope)
+	if err != nil {
+		return nil, params, fmt.Sprintf("macro '%s': %v", name, err)
+	}
+	return e.simulateActions(ctx, actions, macroScope), params, ""
+}
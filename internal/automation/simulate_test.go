@@ -0,0 +1,363 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineSimulate(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	create := func(rule storage.AutomationRule) int64 {
+		rule.Enabled = true
+		if rule.TriggerType == "" {
+			rule.TriggerType = TriggerTypeEvent
+		}
+		if rule.TriggerConfig == nil {
+			rule.TriggerConfig = map[string]interface{}{"event_type": EventSceneChanged}
+		}
+		if rule.Actions == nil {
+			rule.Actions = []storage.RuleAction{{Type: ActionTypeSaveReplay}}
+		}
+		id, err := db.CreateAutomationRule(ctx, rule)
+		require.NoError(t, err)
+		return id
+	}
+
+	create(storage.AutomationRule{Name: "low", Priority: 1})
+	create(storage.AutomationRule{
+		Name:     "announce",
+		Priority: 5,
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}} Overlay"}},
+			{Type: ActionTypeSetVariable, Parameters: map[string]interface{}{"name": "last", "value": "{{var.missing}}"}},
+			{
+				Type:      ActionTypeIf,
+				Condition: "obs.streaming",
+				Then:      []storage.RuleAction{{Type: ActionTypeStopStreaming}},
+				Else:      []storage.RuleAction{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{rule.name}}"}}},
+			},
+		},
+	})
+	create(storage.AutomationRule{
+		Name:          "filtered",
+		TriggerConfig: map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "BRB"}},
+	})
+	create(storage.AutomationRule{Name: "other-event", TriggerConfig: map[string]interface{}{"event_type": EventRecordingStarted}})
+	cooling := create(storage.AutomationRule{Name: "cooling", CooldownMs: 60000})
+	create(storage.AutomationRule{Name: "needs-stream", Condition: "obs.streaming"})
+	create(storage.AutomationRule{Name: "manual", TriggerType: TriggerTypeManual, TriggerConfig: map[string]interface{}{}})
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+
+	_, err := engine.Simulate(EventPayload{EventType: EventSceneChanged})
+	assert.ErrorContains(t, err, "not running")
+
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	lastRun := time.Now().Add(-10 * time.Second)
+	engine.mu.Lock()
+	engine.cooldowns[cooling] = lastRun
+	engine.mu.Unlock()
+
+	result, err := engine.Simulate(EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "Gaming"}})
+	require.NoError(t, err)
+
+	// Fired rules come in execution order
+	require.Len(t, result.Fired, 2)
+	assert.Equal(t, "announce", result.Fired[0].RuleName)
+	assert.Equal(t, "low", result.Fired[1].RuleName)
+
+	actions := result.Fired[0].Actions
+	require.Len(t, actions, 3)
+	assert.Equal(t, "Gaming Overlay", actions[0].Parameters["scene_name"])
+	assert.Empty(t, actions[0].Error)
+	assert.Contains(t, actions[1].Error, "var.missing")
+	assert.Equal(t, "else", actions[2].Branch)
+	require.Len(t, actions[2].Actions, 1)
+	assert.Equal(t, "announce", actions[2].Actions[0].Parameters["scene_name"])
+
+	reasons := map[string]string{}
+	for _, skipped := range result.Skipped {
+		reasons[skipped.RuleName] = skipped.Reason
+	}
+	assert.Len(t, reasons, 4, "non-event rules are not listed")
+	assert.Equal(t, "filter: 'scene_name' is Gaming, want eq BRB", reasons["filtered"])
+	assert.Equal(t, "listens for 'recording_started'", reasons["other-event"])
+	assert.Regexp(t, `^cooldown: (49|50)(\.\d+)?s remaining$`, reasons["cooling"])
+	assert.Equal(t, "condition not met: obs.streaming", reasons["needs-stream"])
+
+	// Nothing ran and cooldowns are untouched
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, mock.GetActions())
+	engine.mu.RLock()
+	assert.Equal(t, lastRun, engine.cooldowns[cooling])
+	assert.Len(t, engine.cooldowns, 1)
+	engine.mu.RUnlock()
+
+	executions, err := db.GetRecentRuleExecutions(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, executions)
+
+	value, found, err := db.GetAutomationVariable(ctx, "last")
+	require.NoError(t, err)
+	assert.False(t, found, value)
+}
+
+func TestEngineSimulateConcurrency(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ids := map[string]int64{}
+	for _, policy := range []string{ConcurrencyParallel, ConcurrencySkipIfRunning, ConcurrencyQueue, ConcurrencyRestart} {
+		id, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+			Name:          policy,
+			Enabled:       true,
+			TriggerType:   TriggerTypeEvent,
+			TriggerConfig: map[string]interface{}{"event_type": EventSceneChanged},
+			Actions:       []storage.RuleAction{{Type: ActionTypeSaveReplay}},
+			Concurrency:   policy,
+		})
+		require.NoError(t, err)
+		ids[policy] = id
+	}
+
+	engine := NewAutomationEngine(db, NewMockOBSClient())
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	simulate := func() (fired map[string]SimulatedRule, skipped map[string]string) {
+		result, err := engine.Simulate(EventPayload{EventType: EventSceneChanged})
+		require.NoError(t, err)
+		fired, skipped = map[string]SimulatedRule{}, map[string]string{}
+		for _, rule := range result.Fired {
+			fired[rule.RuleName] = rule
+		}
+		for _, rule := range result.Skipped {
+			skipped[rule.RuleName] = rule.Reason
+		}
+		return fired, skipped
+	}
+
+	// Idle rules fire right away whatever their policy
+	fired, skipped := simulate()
+	assert.Len(t, fired, 4)
+	assert.Empty(t, skipped)
+	for _, rule := range fired {
+		assert.Empty(t, rule.Note, rule.RuleName)
+	}
+
+	// Every rule has one execution running
+	engine.runsMu.Lock()
+	for _, id := range ids {
+		engine.runs[id] = &ruleRuns{active: []*ruleRun{{cancel: func() {}, ready: make(chan struct{})}}}
+	}
+	engine.runsMu.Unlock()
+
+	fired, skipped = simulate()
+	assert.Equal(t, map[string]string{ConcurrencySkipIfRunning: "concurrency: rule is already running"}, skipped)
+	require.Len(t, fired, 3)
+	assert.Equal(t, "would run alongside 1 running execution(s)", fired[ConcurrencyParallel].Note)
+	assert.Equal(t, "would wait in the queue behind 1 running and 0 queued execution(s)", fired[ConcurrencyQueue].Note)
+	assert.Equal(t, "would cancel 1 running and 0 queued execution(s) first", fired[ConcurrencyRestart].Note)
+	assert.NotEmpty(t, fired[ConcurrencyQueue].Actions)
+
+	// A full queue skips the new execution
+	engine.runsMu.Lock()
+	for range maxQueuedRuns {
+		runs := engine.runs[ids[ConcurrencyQueue]]
+		runs.waiting = append(runs.waiting, &ruleRun{cancel: func() {}, ready: make(chan struct{})})
+	}
+	engine.runsMu.Unlock()
+
+	_, skipped = simulate()
+	assert.Equal(t, "concurrency: execution queue is full", skipped[ConcurrencyQueue])
+
+	// The simulation leaves the in-flight executions alone
+	running, queued := engine.RunningExecutionCount(ids[ConcurrencyRestart])
+	assert.Equal(t, 1, running)
+	assert.Zero(t, queued)
+	running, queued = engine.RunningExecutionCount(ids[ConcurrencyQueue])
+	assert.Equal(t, 1, running)
+	assert.Equal(t, maxQueuedRuns, queued)
+}
+
+func TestEngineSimulateAfterEvent(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	create := func(name string, config map[string]interface{}, condition string) {
+		_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+			Name:          name,
+			Enabled:       true,
+			TriggerType:   TriggerTypeSchedule,
+			TriggerConfig: config,
+			Condition:     condition,
+			Concurrency:   ConcurrencySkipIfRunning,
+			Actions:       []storage.RuleAction{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}} Recap"}}},
+		})
+		require.NoError(t, err)
+	}
+
+	now := time.Now().UTC()
+	blackout := []interface{}{map[string]interface{}{"start": now.Add(-time.Hour).Format("15:04"), "end": now.Add(2 * time.Hour).Format("15:04")}}
+	create("recap", map[string]interface{}{"after_event": EventSceneChanged, "delay_seconds": 30.0}, "obs.streaming")
+	create("other-event", map[string]interface{}{"after_event": EventStreamingStarted, "delay_seconds": 30.0}, "")
+	create("filtered", map[string]interface{}{"after_event": EventSceneChanged, "delay_seconds": 30.0, "event_filter": map[string]interface{}{"scene_name": "BRB"}}, "")
+	create("blacked-out", map[string]interface{}{"after_event": EventSceneChanged, "delay_seconds": 30.0, "timezone": "UTC", "blackout_windows": blackout}, "")
+	create("cron", map[string]interface{}{"schedule": "0 0 * * * *"}, "")
+	_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "now",
+		Enabled:       true,
+		TriggerType:   TriggerTypeEvent,
+		TriggerConfig: map[string]interface{}{"event_type": EventSceneChanged},
+		Actions:       []storage.RuleAction{{Type: ActionTypeSaveReplay}},
+	})
+	require.NoError(t, err)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	// The recap rule runs later, so its condition and concurrency policy
+	// are not checked now
+	stored, err := db.GetAutomationRuleByName(ctx, "recap")
+	require.NoError(t, err)
+	engine.runsMu.Lock()
+	engine.runs[stored.ID] = &ruleRuns{active: []*ruleRun{{cancel: func() {}, ready: make(chan struct{})}}}
+	engine.runsMu.Unlock()
+
+	result, err := engine.Simulate(EventPayload{EventType: EventSceneChanged, Data: map[string]interface{}{"scene_name": "Gaming"}})
+	require.NoError(t, err)
+
+	require.Len(t, result.Fired, 2)
+	assert.Equal(t, "now", result.Fired[0].RuleName, "rules that run right away come first")
+	assert.Empty(t, result.Fired[0].Note)
+	recap := result.Fired[1]
+	assert.Equal(t, "recap", recap.RuleName)
+	assert.Equal(t, "would run 30s after the event; its condition and concurrency policy are checked then", recap.Note)
+	require.Len(t, recap.Actions, 1)
+	assert.Equal(t, "Gaming Recap", recap.Actions[0].Parameters["scene_name"])
+
+	reasons := map[string]string{}
+	for _, skipped := range result.Skipped {
+		reasons[skipped.RuleName] = skipped.Reason
+	}
+	assert.Len(t, reasons, 3, "cron rules are not listed")
+	assert.Equal(t, "fires after 'streaming_started'", reasons["other-event"])
+	assert.Equal(t, "filter: 'scene_name' is Gaming, want eq BRB", reasons["filtered"])
+	assert.Regexp(t, `^schedule: would fire at \S+, outside its windows$`, reasons["blacked-out"])
+
+	// Nothing is waiting to fire
+	assert.Empty(t, engine.scheduler.PendingRuns(stored.ID))
+	assert.Empty(t, mock.GetActions())
+}
+
+func TestEngineSimulateComposite(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	create := func(name string, config map[string]interface{}, condition string) int64 {
+		id, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+			Name:          name,
+			Enabled:       true,
+			TriggerType:   TriggerTypeComposite,
+			TriggerConfig: config,
+			Condition:     condition,
+			Actions:       []storage.RuleAction{{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}} (settled)"}}},
+		})
+		require.NoError(t, err)
+		return id
+	}
+	gameplay := map[string]interface{}{"event_type": EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "Gameplay"}}
+	streaming := map[string]interface{}{"event_type": EventStreamingStarted}
+
+	create("any", map[string]interface{}{"mode": CompositeAny, "events": []interface{}{gameplay}}, "")
+	create("needs-stream", map[string]interface{}{"mode": CompositeAny, "events": []interface{}{gameplay}}, "obs.streaming")
+	create("held", map[string]interface{}{"mode": CompositeAny, "events": []interface{}{gameplay}, "hold_seconds": 30.0}, "obs.streaming")
+	create("debounced", map[string]interface{}{"mode": CompositeAny, "events": []interface{}{gameplay}, "debounce_seconds": 5.0}, "")
+	sequence := create("sequence", map[string]interface{}{"mode": CompositeSequence, "events": []interface{}{streaming, gameplay}}, "")
+	create("recording", map[string]interface{}{"mode": CompositeAny, "events": []interface{}{map[string]interface{}{"event_type": EventRecordingStarted}, streaming}}, "")
+
+	mock := NewMockOBSClient()
+	clock := newFakeClock()
+	engine := NewAutomationEngine(db, mock)
+	engine.SetClock(clock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	simulate := func(payload EventPayload) (fired map[string]SimulatedRule, skipped map[string]string) {
+		result, err := engine.Simulate(payload)
+		require.NoError(t, err)
+		fired, skipped = map[string]SimulatedRule{}, map[string]string{}
+		for _, rule := range result.Fired {
+			fired[rule.RuleName] = rule
+		}
+		for _, rule := range result.Skipped {
+			skipped[rule.RuleName] = rule.Reason
+		}
+		return fired, skipped
+	}
+
+	fired, skipped := simulate(sceneEvent("Gameplay"))
+	assert.Equal(t, map[string]string{
+		"needs-stream": "condition not met: obs.streaming",
+		"sequence":     "composite: sequence trigger not yet satisfied",
+		"recording":    "listens for 'recording_started', 'streaming_started'",
+	}, skipped)
+	require.Len(t, fired, 3)
+	assert.Empty(t, fired["any"].Note)
+	assert.Equal(t, "Gameplay (settled)", fired["any"].Actions[0].Parameters["scene_name"])
+	assert.Equal(t, "would run after a 30s hold unless an event breaks it; its cooldown, condition and concurrency policy are checked then", fired["held"].Note)
+	assert.Equal(t, "would run after a 5s debounce unless the trigger is satisfied again; its cooldown, condition and concurrency policy are checked then", fired["debounced"].Note)
+
+	_, skipped = simulate(sceneEvent("Menu"))
+	assert.Equal(t, "filter: the event matches none of the watched events", skipped["held"])
+
+	// Simulating does not move a sequence along
+	for range 2 {
+		_, skipped = simulate(EventPayload{EventType: EventStreamingStarted})
+		assert.Equal(t, "composite: sequence trigger not yet satisfied", skipped["sequence"])
+	}
+	engine.mu.RLock()
+	assert.Zero(t, engine.composites[sequence].step)
+	engine.mu.RUnlock()
+
+	engine.HandleEvent(EventPayload{EventType: EventStreamingStarted})
+	require.Eventually(t, func() bool {
+		_, skipped := simulate(sceneEvent("Gameplay"))
+		return skipped["sequence"] == ""
+	}, time.Second, 5*time.Millisecond)
+
+	// A pending hold is reported, and is neither broken nor restarted
+	engine.HandleEvent(sceneEvent("Gameplay"))
+	pending := func() bool {
+		engine.mu.RLock()
+		defer engine.mu.RUnlock()
+		for _, tracker := range engine.composites {
+			if tracker.spec.Hold > 0 {
+				return tracker.pending()
+			}
+		}
+		return false
+	}
+	require.Eventually(t, pending, time.Second, 5*time.Millisecond)
+	_, skipped = simulate(sceneEvent("Gameplay"))
+	assert.Equal(t, "composite: a hold is already pending for an earlier event", skipped["held"])
+	_, skipped = simulate(sceneEvent("Menu"))
+	assert.Equal(t, "composite: the event breaks the pending hold", skipped["held"])
+	assert.True(t, pending())
+}
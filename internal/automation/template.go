@@ -0,0 +1,423 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template variables in action parameters.
+//
+// String parameter values may contain {{name}} placeholders that are resolved
+// each time the action runs:
+//
+//	{{event.<key>}}        Trigger event data ({{event.type}} and {{event.timestamp}} describe the event)
+//	{{rule.<field>}}       Rule metadata: id, name, description, trigger_type, run_count
+//	{{obs.<field>}}        Live OBS state: current_scene, streaming, recording, recording_paused
+//	{{var.<name>}}         Persistent automation variable (see the set_variable action)
+//	{{args.<name>}}        Argument of the macro being run (see the run_macro action)
+//	{{<key>}}              Shorthand for event data, then variables
+//
+// Keys may use dots to reach into nested event data. A placeholder may end
+// with a fallback that is used when the value is missing:
+// {{event.input_name|Microphone}}. A parameter that is exactly one
+// placeholder takes the value's own type, so {{event.scene_item_id}} stays a
+// number; placeholders inside longer strings are formatted as text.
+//
+// A placeholder without a value and without a fallback is a miss. What
+// happens then is decided by the action's on_missing policy.
+
+// Missing-variable policies for placeholders that cannot be resolved.
+const (
+	MissingVarError = "error" // The action fails without running (default)
+	MissingVarEmpty = "empty" // The placeholder resolves to an empty value
+	MissingVarKeep  = "keep"  // The placeholder is left as written
+)
+
+// Template namespaces.
+const (
+	templateEvent = "event"
+	templateRule  = "rule"
+	templateOBS   = "obs"
+	templateVar   = "var"
+	templateArgs  = "args"
+)
+
+// templateFields lists the fields available in the fixed namespaces.
+var templateFields = map[string][]string{
+	templateRule: {"id", "name", "description", "trigger_type", "run_count"},
+	templateOBS:  {"current_scene", "streaming", "recording", "recording_paused"},
+}
+
+// placeholderPattern matches {{path}} and {{path|fallback}}.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}|]*?)\s*(?:\|([^{}]*))?\}\}`)
+
+// TemplateScope is what placeholders in an action's parameters resolve
+// against. Either field may be nil: manually triggered rules have no event.
+// Inside a macro, Args holds its arguments and Macros the macros being run,
+// outermost first.
+type TemplateScope struct {
+	Rule   *Rule
+	Event  *EventPayload
+	Args   map[string]interface{}
+	Macros []string
+}
+
+// VariableStore persists automation variables between executions.
+// storage.DB implements it.
+type VariableStore interface {
+	GetAutomationVariable(ctx context.Context, name string) (interface{}, bool, error)
+	SetAutomationVariable(ctx context.Context, name string, value interface{}) error
+}
+
+// SupportedMissingVarPolicies returns the valid on_missing values.
+func SupportedMissingVarPolicies() []string {
+	return []string{MissingVarError, MissingVarEmpty, MissingVarKeep}
+}
+
+// HasTemplates reports whether any string in params contains a placeholder.
+func HasTemplates(params map[string]interface{}) bool {
+	return hasTemplates(params)
+}
+
+func hasTemplates(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return placeholderPattern.MatchString(v)
+	case map[string]interface{}:
+		for _, item := range v {
+			if hasTemplates(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if hasTemplates(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateTemplates checks the placeholders in params without resolving
+// them. It catches unterminated placeholders, empty names, and unknown
+// fields in the rule and obs namespaces.
+func ValidateTemplates(params map[string]interface{}) error {
+	return validateTemplates(params)
+}
+
+func validateTemplates(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		for _, match := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+			if err := validatePlaceholder(match[1]); err != nil {
+				return err
+			}
+		}
+		// Anything left that opens a placeholder was not closed
+		if strings.Contains(placeholderPattern.ReplaceAllString(v, ""), "{{") {
+			return fmt.Errorf("unterminated placeholder in %q", v)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := validateTemplates(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := validateTemplates(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validatePlaceholder(path string) error {
+	if path == "" {
+		return fmt.Errorf("empty placeholder '{{}}'")
+	}
+	namespace, key, found := strings.Cut(path, ".")
+	if !found {
+		return nil // Shorthand for event data or variables
+	}
+	if fields, ok := templateFields[namespace]; ok {
+		for _, field := range fields {
+			if field == key {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown placeholder '{{%s}}'. Valid %s fields: %v", path, namespace, fields)
+	}
+	if (namespace == templateEvent || namespace == templateVar || namespace == templateArgs) && key == "" {
+		return fmt.Errorf("placeholder '{{%s}}' is missing a name", path)
+	}
+	return nil
+}
+
+// MissingVariablesError is returned when placeholders have no value under
+// the error policy.
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("unresolved template variables: %s", strings.Join(e.Names, ", "))
+}
+
+// templateResolver resolves the placeholders of one action.
+type templateResolver struct {
+	ctx       context.Context
+	executor  *Executor
+	scope     *TemplateScope
+	onMissing string
+	obsState  map[string]interface{} // Fetched on the first obs placeholder
+	missing   []string
+}
+
+// resolveParameters returns a copy of params with every placeholder resolved.
+// Under the error policy a miss returns a *MissingVariablesError together
+// with the parameters as far as they could be resolved.
+func (e *Executor) resolveParameters(ctx context.Context, params map[string]interface{}, scope *TemplateScope, onMissing string) (map[string]interface{}, error) {
+	r := &templateResolver{ctx: ctx, executor: e, scope: scope, onMissing: onMissing}
+	if r.scope == nil {
+		r.scope = &TemplateScope{}
+	}
+
+	resolved, err := r.resolve(params)
+	if err != nil {
+		return params, err
+	}
+	out, _ := resolved.(map[string]interface{})
+	if len(r.missing) > 0 && onMissing == MissingVarError {
+		return out, &MissingVariablesError{Names: r.missing}
+	}
+	return out, nil
+}
+
+func (r *templateResolver) resolve(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return r.resolveString(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func (r *templateResolver) resolveString(s string) (interface{}, error) {
+	matches := placeholderPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	// A lone placeholder keeps the value's type
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		value, ok, err := r.placeholder(s, matches[0])
+		if err != nil || ok {
+			return value, err
+		}
+		if r.onMissing == MissingVarEmpty {
+			return "", nil
+		}
+		return s, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m[0]])
+		last = m[1]
+
+		value, ok, err := r.placeholder(s, m)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case ok:
+			b.WriteString(formatTemplateValue(value))
+		case r.onMissing == MissingVarEmpty:
+		default:
+			b.WriteString(s[m[0]:m[1]])
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// placeholder resolves the placeholder at match m of s, applying its
+// fallback. A miss is recorded and reported as not ok.
+func (r *templateResolver) placeholder(s string, m []int) (interface{}, bool, error) {
+	path := s[m[2]:m[3]]
+	value, ok, err := r.lookup(path)
+	if err != nil || ok {
+		return value, ok, err
+	}
+	if m[4] >= 0 {
+		return s[m[4]:m[5]], true, nil
+	}
+	r.missing = append(r.missing, path)
+	return nil, false, nil
+}
+
+// lookup returns the value of a placeholder path.
+func (r *templateResolver) lookup(path string) (interface{}, bool, error) {
+	namespace, key, found := strings.Cut(path, ".")
+	if !found {
+		// Shorthand: event data, then variables
+		if value, ok := r.eventValue(path); ok {
+			return value, true, nil
+		}
+		return r.variable(path)
+	}
+
+	switch namespace {
+	case templateEvent:
+		value, ok := r.eventValue(key)
+		return value, ok, nil
+	case templateRule:
+		value, ok := r.ruleValue(key)
+		return value, ok, nil
+	case templateOBS:
+		return r.obsValue(key)
+	case templateVar:
+		return r.variable(key)
+	case templateArgs:
+		value, ok := lookupPath(r.scope.Args, key)
+		return value, ok, nil
+	default:
+		// Dotted shorthand into nested event data
+		value, ok := r.eventValue(path)
+		return value, ok, nil
+	}
+}
+
+func (r *templateResolver) eventValue(key string) (interface{}, bool) {
+	event := r.scope.Event
+	if event == nil {
+		return nil, false
+	}
+	switch key {
+	case "type":
+		return event.EventType, true
+	case "timestamp":
+		return event.Timestamp.Format(time.RFC3339), true
+	}
+
+	return lookupPath(event.Data, key)
+}
+
+func (r *templateResolver) ruleValue(key string) (interface{}, bool) {
+	rule := r.scope.Rule
+	if rule == nil {
+		return nil, false
+	}
+	switch key {
+	case "id":
+		return rule.ID, true
+	case "name":
+		return rule.Name, true
+	case "description":
+		return rule.Description, true
+	case "trigger_type":
+		return rule.TriggerType, true
+	case "run_count":
+		return rule.RunCount, true
+	}
+	return nil, false
+}
+
+func (r *templateResolver) obsValue(key string) (interface{}, bool, error) {
+	if r.obsState == nil {
+		state, err := r.executor.obsState()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read OBS state for '{{obs.%s}}': %w", key, err)
+		}
+		r.obsState = state
+	}
+	value, ok := r.obsState[key]
+	return value, ok, nil
+}
+
+func (r *templateResolver) variable(name string) (interface{}, bool, error) {
+	if r.executor.variables == nil {
+		return nil, false, nil
+	}
+	value, ok, err := r.executor.variables.GetAutomationVariable(r.ctx, name)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read variable '%s': %w", name, err)
+	}
+	return value, ok, nil
+}
+
+// obsState reads the OBS state available to {{obs.*}} placeholders.
+func (e *Executor) obsState() (map[string]interface{}, error) {
+	_, currentScene, err := e.obsClient.GetSceneList()
+	if err != nil {
+		return nil, err
+	}
+	streaming, err := e.obsClient.GetStreamingStatus()
+	if err != nil {
+		return nil, err
+	}
+	recording, err := e.obsClient.GetRecordingStatus()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"current_scene":    currentScene,
+		"streaming":        streaming.Active,
+		"recording":        recording.Active,
+		"recording_paused": recording.Paused,
+	}, nil
+}
+
+// formatTemplateValue formats a value substituted into a longer string.
+// Whole numbers print without a decimal point; structured values as JSON.
+func formatTemplateValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool, int, int64:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
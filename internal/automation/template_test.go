@@ -0,0 +1,191 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorTemplates(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	mock := NewMockOBSClient()
+	executor := NewExecutor(mock)
+	executor.SetVariableStore(db)
+	require.NoError(t, db.SetAutomationVariable(ctx, "overlay", "Overlay"))
+
+	scope := &TemplateScope{
+		Rule: &Rule{ID: 7, Name: "mute-overlay", TriggerType: TriggerTypeEvent},
+		Event: &EventPayload{
+			EventType: EventInputMuteChanged,
+			Data: map[string]interface{}{
+				"input_name":    "Microphone",
+				"scene_item_id": 3,
+				"source":        map[string]interface{}{"kind": "wasapi"},
+			},
+			Timestamp: time.Date(2026, 1, 15, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	t.Run("resolves every source", func(t *testing.T) {
+		mock.ClearActions()
+		result := executor.ExecuteActionWithScope(ctx, Action{
+			Type: ActionTypeToggleVisibility,
+			Parameters: map[string]interface{}{
+				"scene_name": "{{var.overlay}}",
+				"source_id":  "{{event.scene_item_id}}",
+				"note":       "{{rule.name}} on {{obs.current_scene}}: {{input_name}} ({{event.source.kind}}, {{event.type}}) streaming={{obs.streaming}}",
+				"nested":     []interface{}{map[string]interface{}{"id": "{{rule.id}}"}},
+			},
+		}, 0, scope)
+		require.True(t, result.Success, result.Error)
+
+		// Lone placeholders keep the value's type
+		assert.Equal(t, "Overlay", result.Parameters["scene_name"])
+		assert.Equal(t, 3, result.Parameters["source_id"])
+		assert.Equal(t, "mute-overlay on Default: Microphone (wasapi, input_mute_changed) streaming=false", result.Parameters["note"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"id": int64(7)}}, result.Parameters["nested"])
+		assert.Equal(t, []string{"toggle_visibility"}, mock.GetActions())
+	})
+
+	t.Run("uses fallbacks", func(t *testing.T) {
+		result := executor.ExecuteActionWithScope(ctx, Action{
+			Type:       ActionTypeSetScene,
+			Parameters: map[string]interface{}{"scene_name": "{{event.scene_name|Main}}"},
+		}, 0, scope)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "Main", result.Parameters["scene_name"])
+	})
+
+	t.Run("miss policies", func(t *testing.T) {
+		params := map[string]interface{}{"scene_name": "{{event.scene_name}}", "label": "Scene {{var.missing}}!"}
+
+		mock.ClearActions()
+		result := executor.ExecuteActionWithScope(ctx, Action{Type: ActionTypeSetScene, Parameters: params}, 0, scope)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "unresolved template variables")
+		assert.Contains(t, result.Error, "event.scene_name")
+		assert.Contains(t, result.Error, "var.missing")
+		assert.Empty(t, mock.GetActions(), "action must not run after a miss")
+
+		result = executor.ExecuteActionWithScope(ctx, Action{Type: ActionTypeSetScene, Parameters: params, OnMissing: MissingVarKeep}, 0, scope)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "{{event.scene_name}}", result.Parameters["scene_name"])
+		assert.Equal(t, "Scene {{var.missing}}!", result.Parameters["label"])
+
+		result = executor.ExecuteActionWithScope(ctx, Action{Type: ActionTypeSetScene, Parameters: params, OnMissing: MissingVarEmpty}, 0, scope)
+		require.True(t, result.Success, result.Error)
+		assert.Equal(t, "", result.Parameters["scene_name"])
+		assert.Equal(t, "Scene !", result.Parameters["label"])
+	})
+
+	t.Run("manual runs have no event", func(t *testing.T) {
+		result := executor.ExecuteActionWithScope(ctx, Action{
+			Type:       ActionTypeSetScene,
+			Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}}"},
+		}, 0, &TemplateScope{Rule: scope.Rule})
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "event.scene_name")
+	})
+
+	t.Run("static parameters are not reported", func(t *testing.T) {
+		result := executor.ExecuteActionWithScope(ctx, Action{
+			Type:       ActionTypeSetScene,
+			Parameters: map[string]interface{}{"scene_name": "Gaming"},
+		}, 0, scope)
+		require.True(t, result.Success, result.Error)
+		assert.Nil(t, result.Parameters)
+	})
+
+	t.Run("set_variable writes the store", func(t *testing.T) {
+		result := executor.ExecuteActionWithScope(ctx, Action{
+			Type:       ActionTypeSetVariable,
+			Parameters: map[string]interface{}{"name": "last_muted", "value": "{{input_name}}"},
+		}, 0, scope)
+		require.True(t, result.Success, result.Error)
+
+		value, found, err := db.GetAutomationVariable(ctx, "last_muted")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "Microphone", value)
+
+		result = NewExecutor(mock).ExecuteAction(Action{
+			Type:       ActionTypeSetVariable,
+			Parameters: map[string]interface{}{"name": "x", "value": 1},
+		}, 0)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "variable store")
+	})
+}
+
+func TestValidateTemplates(t *testing.T) {
+	valid := map[string]interface{}{
+		"a": "{{event.input_name}} {{rule.name}} {{obs.streaming}} {{var.x|1}} {{input_name}}",
+		"b": []interface{}{"{{ event.scene_name }}"},
+		"c": 5,
+	}
+	assert.NoError(t, ValidateTemplates(valid))
+	assert.True(t, HasTemplates(valid))
+	assert.False(t, HasTemplates(map[string]interface{}{"scene_name": "Gaming"}))
+
+	for params, want := range map[string]string{
+		"{{rule.owner}}":    "unknown placeholder",
+		"{{obs.scene}}":     "unknown placeholder",
+		"{{}}":              "empty placeholder",
+		"{{var.}}":          "missing a name",
+		"Hello {{name":      "unterminated",
+		"{{event.x}} {{ok}": "unterminated",
+	} {
+		err := ValidateTemplates(map[string]interface{}{"p": params})
+		if assert.Error(t, err, params) {
+			assert.Contains(t, err.Error(), want, params)
+		}
+	}
+}
+
+func TestEngineTemplateVariables(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ruleID, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:        "follow-mute",
+		Enabled:     true,
+		TriggerType: TriggerTypeEvent,
+		TriggerConfig: map[string]interface{}{
+			"event_type": EventInputMuteChanged,
+		},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeToggleMute, Parameters: map[string]interface{}{"input_name": "{{input_name}} Monitor"}},
+		},
+	})
+	require.NoError(t, err)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	engine.HandleEvent(EventPayload{
+		EventType: EventInputMuteChanged,
+		Data:      map[string]interface{}{"input_name": "Microphone", "muted": true},
+	})
+
+	require.Eventually(t, func() bool {
+		executions, err := db.GetRuleExecutions(ctx, ruleID, 1)
+		return err == nil && len(executions) == 1 && executions[0].Status == storage.ExecutionStatusCompleted
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"toggle_mute:Microphone Monitor"}, mock.GetActions())
+
+	executions, err := db.GetRuleExecutions(ctx, ruleID, 1)
+	require.NoError(t, err)
+	require.Len(t, executions[0].ActionResults, 1)
+	assert.Equal(t, "Microphone Monitor", executions[0].ActionResults[0].Parameters["input_name"])
+}
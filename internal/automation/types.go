@@ -7,9 +7,11 @@ import (
 
 // TriggerType defines the category of automation trigger.
 const (
-	TriggerTypeEvent    = "event"
-	TriggerTypeSchedule = "schedule"
-	TriggerTypeManual   = "manual"
+	TriggerTypeEvent     = "event"
+	TriggerTypeSchedule  = "schedule"
+	TriggerTypeManual    = "manual"
+	TriggerTypeWebhook   = "webhook"
+	TriggerTypeComposite = "composite"
 )
 
 // ActionType constants for all supported actions.
@@ -35,12 +37,28 @@ const (
 	ActionTypeTriggerTransition  = "trigger_transition"
 	ActionTypeSetPreviewScene    = "set_preview_scene"
 	ActionTypeDelay              = "delay"
+	ActionTypeSetVariable        = "set_variable"
+	ActionTypeIf                 = "if"
+	ActionTypeHTTPRequest        = "http_request"
+	ActionTypeParallel           = "parallel"
+	ActionTypeRepeat             = "repeat"
+	ActionTypeRunMacro           = "run_macro"
 )
 
 // ActionErrorPolicy defines what to do when an action fails.
 const (
 	ActionErrorContinue = "continue" // Continue to next action (default)
 	ActionErrorStop     = "stop"     // Stop rule execution
+	ActionErrorGoto     = "goto"     // Run the fallback actions; stop if they fail
+)
+
+// ConcurrencyPolicy defines what happens when a rule is triggered while an
+// earlier execution of it is still running.
+const (
+	ConcurrencyParallel      = "parallel"        // Run alongside earlier executions (default)
+	ConcurrencySkipIfRunning = "skip_if_running" // Skip the new execution
+	ConcurrencyQueue         = "queue"           // Wait for earlier executions to finish
+	ConcurrencyRestart       = "restart"         // Cancel earlier executions and run the new one
 )
 
 // EventType constants for OBS events that can trigger rules.
@@ -73,14 +91,26 @@ type Rule struct {
 	TriggerType   string                 `json:"trigger_type"`
 	TriggerConfig map[string]interface{} `json:"trigger_config"`
 	Actions       []Action               `json:"actions"`
+	Condition     string                 `json:"condition,omitempty"`   // Guard expression; the rule runs only when true
+	Concurrency   string                 `json:"concurrency,omitempty"` // Policy for overlapping executions
 	CooldownMs    int                    `json:"cooldown_ms,omitempty"`
 	Priority      int                    `json:"priority,omitempty"`
+	Revision      int                    `json:"revision,omitempty"` // Stored revision the rule was loaded from
 	CreatedAt     time.Time              `json:"created_at"`
 	UpdatedAt     time.Time              `json:"updated_at"`
 	LastRun       *time.Time             `json:"last_run,omitempty"`
 	RunCount      int64                  `json:"run_count"`
 }
 
+// GetConcurrency returns the concurrency policy, defaulting to "parallel".
+func (r *Rule) GetConcurrency() string {
+	switch r.Concurrency {
+	case ConcurrencySkipIfRunning, ConcurrencyQueue, ConcurrencyRestart:
+		return r.Concurrency
+	}
+	return ConcurrencyParallel
+}
+
 // GetEventType returns the event_type from trigger config, or empty string.
 func (r *Rule) GetEventType() string {
 	if r.TriggerType != TriggerTypeEvent {
@@ -100,6 +130,18 @@ func (r *Rule) GetEventFilter() map[string]interface{} {
 	return nil
 }
 
+// GetAfterEvent returns the event a schedule rule fires after, or empty
+// string.
+func (r *Rule) GetAfterEvent() string {
+	if r.TriggerType != TriggerTypeSchedule {
+		return ""
+	}
+	if et, ok := r.TriggerConfig[scheduleEventKey].(string); ok {
+		return et
+	}
+	return ""
+}
+
 // GetSchedule returns the cron schedule from trigger config, or empty string.
 func (r *Rule) GetSchedule() string {
 	if r.TriggerType != TriggerTypeSchedule {
@@ -115,17 +157,64 @@ func (r *Rule) GetSchedule() string {
 type Action struct {
 	Type       string                 `json:"type"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
-	OnError    string                 `json:"on_error,omitempty"` // "continue" or "stop"
+	OnError    string                 `json:"on_error,omitempty"`   // "continue", "stop" or "goto"
+	OnMissing  string                 `json:"on_missing,omitempty"` // "error", "empty" or "keep"
+
+	// if actions: Condition picks Then when true and Else otherwise.
+	Condition string   `json:"condition,omitempty"`
+	Then      []Action `json:"then,omitempty"`
+	Else      []Action `json:"else,omitempty"`
+
+	// parallel actions: lists run concurrently
+	Branches [][]Action `json:"branches,omitempty"`
+
+	// repeat actions: Actions run Count times, or until Until holds after
+	// an iteration, whichever comes first
+	Actions []Action `json:"actions,omitempty"`
+	Count   int      `json:"count,omitempty"`
+	Until   string   `json:"until,omitempty"`
+
+	Retry     *RetryPolicy `json:"retry,omitempty"`      // Retries a failed action
+	TimeoutMs int          `json:"timeout_ms,omitempty"` // Time limit per attempt
+
+	// on_error "goto": actions run when this action fails
+	Fallback []Action `json:"fallback,omitempty"`
 }
 
 // GetOnError returns the error policy, defaulting to "continue".
 func (a *Action) GetOnError() string {
-	if a.OnError == ActionErrorStop {
-		return ActionErrorStop
+	switch a.OnError {
+	case ActionErrorStop, ActionErrorGoto:
+		return a.OnError
 	}
 	return ActionErrorContinue
 }
 
+// haltsOn reports whether a result stops the action list the action is in:
+// a failure does with on_error "stop", and with "goto" unless the fallback
+// actions recovered from it.
+func (a *Action) haltsOn(result ActionResult) bool {
+	if result.Success {
+		return false
+	}
+	switch a.GetOnError() {
+	case ActionErrorStop:
+		return true
+	case ActionErrorGoto:
+		return !result.Recovered
+	}
+	return false
+}
+
+// GetOnMissing returns the missing-variable policy, defaulting to "error".
+func (a *Action) GetOnMissing() string {
+	switch a.OnMissing {
+	case MissingVarEmpty, MissingVarKeep:
+		return a.OnMissing
+	}
+	return MissingVarError
+}
+
 // EventPayload represents an OBS event that may trigger rules.
 type EventPayload struct {
 	EventType string                 `json:"event_type"`
@@ -140,22 +229,65 @@ type ActionResult struct {
 	Success    bool   `json:"success"`
 	Error      string `json:"error,omitempty"`
 	DurationMs int64  `json:"duration_ms"`
+	Cancelled  bool   `json:"cancelled,omitempty"`
+
+	// Set when the action stopped waiting for an OBS request that was
+	// already sent, on cancellation or timeout. The request may still have
+	// taken effect.
+	Abandoned bool `json:"abandoned,omitempty"`
+
+	// Parameters after template variables were resolved. Only set for
+	// actions whose parameters contain placeholders.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	// if actions: the branch taken ("then" or "else") and the results of
+	// its actions. run_macro actions: the macro run and the results of its
+	// actions.
+	Branch  string         `json:"branch,omitempty"`
+	Macro   string         `json:"macro,omitempty"`
+	Results []ActionResult `json:"results,omitempty"`
+
+	// parallel actions: the results of each branch. repeat actions: the
+	// results of each iteration.
+	Branches   [][]ActionResult `json:"branches,omitempty"`
+	Iterations [][]ActionResult `json:"iterations,omitempty"`
+
+	// Each attempt of an action with a retry policy
+	Attempts []ActionAttempt `json:"attempts,omitempty"`
+
+	// on_error "goto": the results of the fallback actions, and whether they
+	// all completed so the rule went on
+	Fallback  []ActionResult `json:"fallback,omitempty"`
+	Recovered bool           `json:"recovered,omitempty"`
+}
+
+// ActionAttempt is one attempt at running an action.
+type ActionAttempt struct {
+	Attempt    int    `json:"attempt"` // Starting at 1
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 // ExecutionResult represents the complete result of rule execution.
 type ExecutionResult struct {
+	ExecutionID   int64                  `json:"execution_id,omitempty"`
 	RuleID        int64                  `json:"rule_id"`
 	RuleName      string                 `json:"rule_name"`
 	TriggerType   string                 `json:"trigger_type"`
 	TriggerData   map[string]interface{} `json:"trigger_data,omitempty"`
 	StartedAt     time.Time              `json:"started_at"`
 	CompletedAt   time.Time              `json:"completed_at"`
-	Status        string                 `json:"status"` // "completed", "failed", "skipped"
+	Status        string                 `json:"status"` // "completed", "failed", "skipped", "cancelled"
 	ActionResults []ActionResult         `json:"action_results,omitempty"`
 	Error         string                 `json:"error,omitempty"`
 	DurationMs    int64                  `json:"duration_ms"`
 }
 
+// ProgressFunc receives progress updates while a rule executes.
+// completed counts finished actions out of total.
+type ProgressFunc func(completed, total int, message string)
+
 // SupportedEventTypes returns all event types that can be used as triggers.
 func SupportedEventTypes() []string {
 	return []string{
@@ -203,5 +335,26 @@ func SupportedActionTypes() []string {
 		ActionTypeTriggerTransition,
 		ActionTypeSetPreviewScene,
 		ActionTypeDelay,
+		ActionTypeSetVariable,
+		ActionTypeIf,
+		ActionTypeHTTPRequest,
+		ActionTypeParallel,
+		ActionTypeRepeat,
+		ActionTypeRunMacro,
+	}
+}
+
+// SupportedErrorPolicies returns the valid on_error values.
+func SupportedErrorPolicies() []string {
+	return []string{ActionErrorContinue, ActionErrorStop, ActionErrorGoto}
+}
+
+// SupportedConcurrencyPolicies returns all concurrency policies a rule can use.
+func SupportedConcurrencyPolicies() []string {
+	return []string{
+		ConcurrencyParallel,
+		ConcurrencySkipIfRunning,
+		ConcurrencyQueue,
+		ConcurrencyRestart,
 	}
 }
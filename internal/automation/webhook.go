@@ -0,0 +1,235 @@
+package automation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook triggers.
+//
+// A webhook rule is fired by an HTTP POST to /api/hooks/{rule_name}. Each
+// rule has its own secret in trigger_config. Requests must carry:
+//
+//	X-Agentic-OBS-Timestamp: <unix seconds>
+//	X-Agentic-OBS-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Requests older or newer than the replay window are rejected, as is any
+// signature already seen within the window. The JSON body becomes the event
+// data that event_filter, conditions and templates see: by default its
+// top-level fields, or with body_mapping only the mapped fields, e.g.
+// {"scene_name": "payload.scene"}.
+
+// Webhook request headers.
+const (
+	WebhookTimestampHeader = "X-Agentic-OBS-Timestamp"
+	WebhookSignatureHeader = "X-Agentic-OBS-Signature"
+)
+
+// EventWebhook is the event type of payloads built from webhook requests.
+const EventWebhook = "webhook"
+
+// Webhook limits.
+const (
+	WebhookReplayWindow     = 5 * time.Minute
+	MinWebhookSecretLength  = 16
+	webhookSignaturePrefix  = "sha256="
+	webhookSecretConfigKey  = "secret"
+	webhookMappingConfigKey = "body_mapping"
+)
+
+// Webhook errors. Unknown, disabled and non-webhook rules return a
+// *RuleNotFoundError so callers cannot probe which rules exist.
+var (
+	ErrWebhookUnauthorized = errors.New("missing or invalid webhook signature")
+	ErrWebhookExpired      = errors.New("webhook timestamp is outside the replay window")
+	ErrWebhookReplayed     = errors.New("webhook request was already received")
+	ErrWebhookPayload      = errors.New("webhook body must be a JSON object")
+)
+
+// WebhookRequest is an incoming webhook call.
+type WebhookRequest struct {
+	Timestamp string // Value of the timestamp header
+	Signature string // Value of the signature header
+	Body      []byte
+}
+
+// WebhookResult reports what a webhook call did.
+type WebhookResult struct {
+	RuleName  string `json:"rule_name"`
+	Triggered bool   `json:"triggered"`
+	Reason    string `json:"reason,omitempty"` // Why the rule was skipped
+}
+
+// SignWebhook returns the signature header value for a webhook body sent
+// at timestamp (unix seconds). Clients compute the same value.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateWebhookConfig checks the secret and body_mapping of a webhook
+// trigger config.
+func ValidateWebhookConfig(config map[string]interface{}) error {
+	secret, _ := config[webhookSecretConfigKey].(string)
+	if len(secret) < MinWebhookSecretLength {
+		return fmt.Errorf("webhook trigger requires a 'secret' of at least %d characters in trigger_config", MinWebhookSecretLength)
+	}
+
+	raw, ok := config[webhookMappingConfigKey]
+	if !ok || raw == nil {
+		return nil
+	}
+	mapping, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("body_mapping must map event data keys to JSON body paths")
+	}
+	for key, path := range mapping {
+		if p, ok := path.(string); !ok || key == "" || p == "" {
+			return fmt.Errorf("body_mapping entry '%s' must map to a non-empty JSON body path", key)
+		}
+	}
+	return nil
+}
+
+// HandleWebhook verifies a webhook request for the named rule and, if the
+// rule's filter, cooldown and condition allow it, runs the rule in the
+// background.
+func (e *AutomationEngine) HandleWebhook(ruleName string, req WebhookRequest) (*WebhookResult, error) {
+	rule := e.findRuleByName(ruleName)
+	if rule == nil || !rule.Enabled || rule.TriggerType != TriggerTypeWebhook {
+		return nil, &RuleNotFoundError{Name: ruleName}
+	}
+
+	now := time.Now()
+	if err := e.verifyWebhook(rule, req, now); err != nil {
+		logger.Warnf("Rejected webhook for rule '%s': %v", rule.Name, err)
+		return nil, err
+	}
+
+	data, err := webhookData(rule, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	payload := EventPayload{EventType: EventWebhook, Data: data, Timestamp: now}
+	result := &WebhookResult{RuleName: rule.Name}
+
+	if ok, reason := e.matchesFilter(rule.GetEventFilter(), data); !ok {
+		result.Reason = "filter: " + reason
+		return result, nil
+	}
+	if !e.conditionMet(e.ctx, rule, &payload) {
+		result.Reason = "condition not met"
+		return result, nil
+	}
+
+	e.mu.Lock()
+	if !e.running {
+		e.mu.Unlock()
+		return nil, fmt.Errorf("automation engine is not running")
+	}
+	if !e.checkCooldownLocked(rule) {
+		e.mu.Unlock()
+		result.Reason = "cooldown"
+		return result, nil
+	}
+	if rule.CooldownMs > 0 {
+		e.cooldowns[rule.ID] = now
+	}
+	e.wg.Add(1)
+	e.mu.Unlock()
+
+	logger.Infof("Webhook trigger for rule '%s'", rule.Name)
+	go e.executeRule(rule, &payload)
+
+	result.Triggered = true
+	return result, nil
+}
+
+// verifyWebhook checks the signature and timestamp of req, and records the
+// signature so the same request cannot be replayed.
+func (e *AutomationEngine) verifyWebhook(rule *Rule, req WebhookRequest, now time.Time) error {
+	secret, _ := rule.TriggerConfig[webhookSecretConfigKey].(string)
+	if secret == "" || req.Timestamp == "" || !strings.HasPrefix(req.Signature, webhookSignaturePrefix) {
+		return ErrWebhookUnauthorized
+	}
+
+	expected := SignWebhook(secret, req.Timestamp, req.Body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(req.Signature))) {
+		return ErrWebhookUnauthorized
+	}
+
+	sent, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookUnauthorized
+	}
+	if math.Abs(now.Sub(time.Unix(sent, 0)).Seconds()) > WebhookReplayWindow.Seconds() {
+		return ErrWebhookExpired
+	}
+
+	e.webhookMu.Lock()
+	defer e.webhookMu.Unlock()
+	for sig, seen := range e.webhookSeen {
+		if now.Sub(seen) > 2*WebhookReplayWindow {
+			delete(e.webhookSeen, sig)
+		}
+	}
+	if _, seen := e.webhookSeen[expected]; seen {
+		return ErrWebhookReplayed
+	}
+	e.webhookSeen[expected] = now
+	return nil
+}
+
+// webhookData converts a webhook body into event data, applying the rule's
+// body_mapping if it has one.
+func webhookData(rule *Rule, body []byte) (map[string]interface{}, error) {
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed == nil {
+		return nil, ErrWebhookPayload
+	}
+
+	mapping, ok := rule.TriggerConfig[webhookMappingConfigKey].(map[string]interface{})
+	if !ok || len(mapping) == 0 {
+		return parsed, nil
+	}
+
+	data := make(map[string]interface{}, len(mapping))
+	for key, raw := range mapping {
+		path, _ := raw.(string)
+		if value, ok := lookupPath(parsed, path); ok {
+			data[key] = value
+		}
+	}
+	return data, nil
+}
+
+// lookupPath follows a dotted path into nested JSON objects.
+func lookupPath(root map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = root
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
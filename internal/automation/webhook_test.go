@@ -0,0 +1,147 @@
+package automation
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "0123456789abcdef-secret"
+
+// signedWebhook builds a webhook request signed with secret at time at.
+func signedWebhook(secret string, at time.Time, body string) WebhookRequest {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return WebhookRequest{Timestamp: ts, Signature: SignWebhook(secret, ts, []byte(body)), Body: []byte(body)}
+}
+
+func TestEngineWebhook(t *testing.T) {
+	db, cleanup := testAutomationDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ruleID, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:        "deck-button",
+		Enabled:     true,
+		TriggerType: TriggerTypeWebhook,
+		TriggerConfig: map[string]interface{}{
+			"secret":       testWebhookSecret,
+			"body_mapping": map[string]interface{}{"scene_name": "button.scene", "pressed_by": "user"},
+			"event_filter": map[string]interface{}{"scene_name": map[string]interface{}{"ne": "Forbidden"}},
+		},
+		Actions: []storage.RuleAction{
+			{Type: ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}}"}},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "manual-only",
+		Enabled:       true,
+		TriggerType:   TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{"secret": testWebhookSecret},
+		Actions:       []storage.RuleAction{{Type: ActionTypeSaveReplay}},
+	})
+	require.NoError(t, err)
+
+	mock := NewMockOBSClient()
+	engine := NewAutomationEngine(db, mock)
+	require.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	body := `{"button": {"scene": "Gaming"}, "user": "deck", "ignored": true}`
+
+	t.Run("runs the rule with mapped body data", func(t *testing.T) {
+		result, err := engine.HandleWebhook("deck-button", signedWebhook(testWebhookSecret, time.Now(), body))
+		require.NoError(t, err)
+		assert.True(t, result.Triggered)
+
+		require.Eventually(t, func() bool {
+			executions, err := db.GetRuleExecutions(ctx, ruleID, 1)
+			return err == nil && len(executions) == 1 && executions[0].Status == storage.ExecutionStatusCompleted
+		}, 2*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, []string{"set_scene:Gaming"}, mock.GetActions())
+		executions, err := db.GetRuleExecutions(ctx, ruleID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"scene_name": "Gaming", "pressed_by": "deck"}, executions[0].TriggerData)
+	})
+
+	t.Run("rejects a replayed request", func(t *testing.T) {
+		req := signedWebhook(testWebhookSecret, time.Now().Add(time.Second), body)
+		_, err := engine.HandleWebhook("deck-button", req)
+		require.NoError(t, err)
+		_, err = engine.HandleWebhook("deck-button", req)
+		assert.ErrorIs(t, err, ErrWebhookReplayed)
+
+		require.Eventually(t, func() bool {
+			executions, err := db.GetRuleExecutions(ctx, ruleID, 10)
+			return err == nil && len(executions) == 2 && executions[0].Status == storage.ExecutionStatusCompleted
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("rejects bad signatures", func(t *testing.T) {
+		req := signedWebhook("some-other-secret-value", time.Now(), body)
+		_, err := engine.HandleWebhook("deck-button", req)
+		assert.ErrorIs(t, err, ErrWebhookUnauthorized)
+
+		req = signedWebhook(testWebhookSecret, time.Now(), body)
+		req.Body = []byte(`{"button": {"scene": "Other"}}`)
+		_, err = engine.HandleWebhook("deck-button", req)
+		assert.ErrorIs(t, err, ErrWebhookUnauthorized, "body was changed after signing")
+
+		_, err = engine.HandleWebhook("deck-button", WebhookRequest{Body: []byte(body)})
+		assert.ErrorIs(t, err, ErrWebhookUnauthorized)
+	})
+
+	t.Run("rejects stale and future timestamps", func(t *testing.T) {
+		_, err := engine.HandleWebhook("deck-button", signedWebhook(testWebhookSecret, time.Now().Add(-10*time.Minute), body))
+		assert.ErrorIs(t, err, ErrWebhookExpired)
+		_, err = engine.HandleWebhook("deck-button", signedWebhook(testWebhookSecret, time.Now().Add(10*time.Minute), body))
+		assert.ErrorIs(t, err, ErrWebhookExpired)
+	})
+
+	t.Run("rejects bodies that are not objects", func(t *testing.T) {
+		_, err := engine.HandleWebhook("deck-button", signedWebhook(testWebhookSecret, time.Now().Add(2*time.Second), `["Gaming"]`))
+		assert.ErrorIs(t, err, ErrWebhookPayload)
+	})
+
+	t.Run("skips when the filter does not match", func(t *testing.T) {
+		mock.ClearActions()
+		result, err := engine.HandleWebhook("deck-button", signedWebhook(testWebhookSecret, time.Now().Add(3*time.Second), `{"button": {"scene": "Forbidden"}}`))
+		require.NoError(t, err)
+		assert.False(t, result.Triggered)
+		assert.Contains(t, result.Reason, "filter")
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, mock.GetActions())
+	})
+
+	t.Run("only webhook rules can be fired", func(t *testing.T) {
+		var notFound *RuleNotFoundError
+		_, err := engine.HandleWebhook("manual-only", signedWebhook(testWebhookSecret, time.Now(), body))
+		assert.ErrorAs(t, err, &notFound)
+		_, err = engine.HandleWebhook("missing", signedWebhook(testWebhookSecret, time.Now(), body))
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+func TestValidateWebhookConfig(t *testing.T) {
+	assert.NoError(t, ValidateWebhookConfig(map[string]interface{}{"secret": testWebhookSecret}))
+	assert.NoError(t, ValidateWebhookConfig(map[string]interface{}{
+		"secret":       testWebhookSecret,
+		"body_mapping": map[string]interface{}{"scene_name": "data.scene"},
+	}))
+
+	assert.ErrorContains(t, ValidateWebhookConfig(map[string]interface{}{}), "requires a 'secret'")
+	assert.ErrorContains(t, ValidateWebhookConfig(map[string]interface{}{"secret": "short"}), "at least 16")
+	assert.ErrorContains(t, ValidateWebhookConfig(map[string]interface{}{
+		"secret": testWebhookSecret, "body_mapping": "data.scene",
+	}), "body_mapping must map")
+	assert.ErrorContains(t, ValidateWebhookConfig(map[string]interface{}{
+		"secret": testWebhookSecret, "body_mapping": map[string]interface{}{"scene_name": 3},
+	}), "non-empty JSON body path")
+}
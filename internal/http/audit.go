@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+)
+
+// recordUIAction adds a UI tool action to the action history, attributed to
+// the HTTP client that sent it.
+func (s *Server) recordUIAction(r *http.Request, toolName string, params map[string]any, err error, duration time.Duration) {
+	input, _ := json.Marshal(params)
+	output := `{"status":"success"}`
+	if err != nil {
+		data, _ := json.Marshal(map[string]string{"error": err.Error()})
+		output = string(data)
+	}
+
+	record := storage.ActionRecord{
+		Action:     "UI action",
+		ToolName:   toolName,
+		Input:      string(input),
+		Output:     output,
+		Success:    err == nil,
+		DurationMs: duration.Milliseconds(),
+		ActorType:  storage.ActorHTTP,
+		ActorID:    requestActorID(r),
+	}
+	// The record is written even if the client has gone away
+	if _, err := s.storage.RecordAction(context.WithoutCancel(r.Context()), record); err != nil {
+		logger.Warnf("Failed to record UI action %s: %v", toolName, err)
+	}
+}
+
+// requestActorID identifies the HTTP client behind r: a bearer token by a
+// short hash, so the token itself is never stored, or else the remote host.
+func requestActorID(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		if token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")); token != "" {
+			sum := sha256.Sum256([]byte(token))
+			return "token:" + hex.EncodeToString(sum[:])[:12]
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
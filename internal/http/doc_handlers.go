@@ -2,7 +2,6 @@ package http
 
 import (
 	"html/template"
-	"log"
 	"net/http"
 	"strings"
 
@@ -270,7 +269,7 @@ func (s *Server) handleDocsIndex(w http.ResponseWriter, r *http.Request) {
 
 	docList, err := docs.List()
 	if err != nil {
-		log.Printf("Failed to list docs: %v", err)
+		logger.Errorf("Failed to list docs: %v", err)
 		http.Error(w, "Failed to list documentation", http.StatusInternalServerError)
 		return
 	}
@@ -288,7 +287,7 @@ func (s *Server) handleDocsIndex(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := docPageTemplate.Execute(w, data); err != nil {
-		log.Printf("Failed to render docs index: %v", err)
+		logger.Errorf("Failed to render docs index: %v", err)
 	}
 }
 
@@ -318,7 +317,7 @@ func (s *Server) handleDocView(w http.ResponseWriter, r *http.Request) {
 	// Render markdown to HTML
 	htmlContent, err := docs.RenderHTML(path)
 	if err != nil {
-		log.Printf("Failed to render doc %s: %v", path, err)
+		logger.Errorf("Failed to render doc %s: %v", path, err)
 		http.Error(w, "Failed to render documentation", http.StatusInternalServerError)
 		return
 	}
@@ -336,7 +335,7 @@ func (s *Server) handleDocView(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := docPageTemplate.Execute(w, data); err != nil {
-		log.Printf("Failed to render doc page: %v", err)
+		logger.Errorf("Failed to render doc page: %v", err)
 	}
 }
 
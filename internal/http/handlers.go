@@ -2,9 +2,9 @@ package http
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ironystock/agentic-obs/internal/storage"
@@ -55,17 +55,31 @@ func (s *Server) handleAPIHistory(w http.ResponseWriter, r *http.Request) {
 
 	toolFilter := r.URL.Query().Get("tool")
 
+	// actor is an actor type, optionally followed by ":" and an actor ID,
+	// e.g. "mcp", "automation:12" or "http:token:3f2a9c0b1d4e"
+	var actorType, actorID string
+	if actorFilter := r.URL.Query().Get("actor"); actorFilter != "" {
+		actorType, actorID, _ = strings.Cut(actorFilter, ":")
+	}
+
 	var records []storage.ActionRecord
 	var err error
 
-	if toolFilter != "" {
+	switch {
+	case actorType != "":
+		records, err = s.storage.FilterActions(r.Context(), storage.ActionFilter{
+			ToolName:  toolFilter,
+			ActorType: actorType,
+			ActorID:   actorID,
+		}, limit)
+	case toolFilter != "":
 		records, err = s.storage.GetActionsByTool(r.Context(), toolFilter, limit)
-	} else {
+	default:
 		records, err = s.storage.GetRecentActions(r.Context(), limit)
 	}
 
 	if err != nil {
-		log.Printf("Failed to get action history: %v", err)
+		logger.Errorf("Failed to get action history: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve history"})
 		return
 	}
@@ -88,7 +102,7 @@ func (s *Server) handleAPIHistoryStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := s.storage.GetActionStats(r.Context())
 	if err != nil {
-		log.Printf("Failed to get action stats: %v", err)
+		logger.Errorf("Failed to get action stats: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve stats"})
 		return
 	}
@@ -105,7 +119,7 @@ func (s *Server) handleAPIScreenshots(w http.ResponseWriter, r *http.Request) {
 
 	sources, err := s.storage.ListScreenshotSources(r.Context())
 	if err != nil {
-		log.Printf("Failed to list screenshot sources: %v", err)
+		logger.Errorf("Failed to list screenshot sources: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve screenshots"})
 		return
 	}
@@ -139,30 +153,40 @@ func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		s.handleGetConfig(w, r)
 	case http.MethodPost:
+		if s.cfg.ReadOnly {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed: server is in read-only mode", http.StatusMethodNotAllowed)
+			return
+		}
 		s.handleUpdateConfig(w, r)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
+// handleReadOnlyAction rejects UI actions while the server is in read-only mode.
+func (s *Server) handleReadOnlyAction(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusForbidden, map[string]string{"error": "Actions are disabled: server is in read-only mode"})
+}
+
 // handleGetConfig returns current configuration.
 func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	obsConfig, err := s.storage.LoadOBSConfig(r.Context())
 	if err != nil {
-		log.Printf("Failed to load OBS config: %v", err)
+		logger.Errorf("Failed to load OBS config: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to load config"})
 		return
 	}
 
 	toolGroups, err := s.storage.LoadToolGroupConfig(r.Context())
 	if err != nil {
-		log.Printf("Warning: failed to load tool group config: %v", err)
+		logger.Warnf("Failed to load tool group config: %v", err)
 		toolGroups = storage.DefaultToolGroupConfig()
 	}
 
 	webServer, err := s.storage.LoadWebServerConfig(r.Context())
 	if err != nil {
-		log.Printf("Failed to load web server config: %v", err)
+		logger.Errorf("Failed to load web server config: %v", err)
 		webServer = storage.WebServerConfig{Enabled: true, Host: "localhost", Port: 8765}
 	}
 
@@ -187,6 +211,7 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			"host":    webServer.Host,
 			"port":    webServer.Port,
 		},
+		"read_only": s.cfg.ReadOnly,
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -217,7 +242,7 @@ func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 			Transitions: getBool(tg, "transitions", true),
 		}
 		if err := s.storage.SaveToolGroupConfig(r.Context(), config); err != nil {
-			log.Printf("Warning: failed to save tool group config: %v", err)
+			logger.Warnf("Failed to save tool group config: %v", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save tool groups"})
 			return
 		}
@@ -250,7 +275,7 @@ func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 			Port:    port,
 		}
 		if err := s.storage.SaveWebServerConfig(r.Context(), config); err != nil {
-			log.Printf("Failed to save web server config: %v", err)
+			logger.Errorf("Failed to save web server config: %v", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to save web server config"})
 			return
 		}
@@ -267,7 +292,7 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Failed to encode JSON response: %v", err)
+		logger.Errorf("Failed to encode JSON response: %v", err)
 	}
 }
 
@@ -180,6 +180,37 @@ func TestHandleAPIHistory(t *testing.T) {
 		assert.Equal(t, float64(1), response["count"])
 	})
 
+	t.Run("filters by actor", func(t *testing.T) {
+		s, cleanup := testServer(t)
+		defer cleanup()
+
+		for _, record := range []storage.ActionRecord{
+			{Action: "A", ToolName: "tool_a", Success: true, ActorType: storage.ActorMCP, ActorID: "client/1.0"},
+			{Action: "B", ToolName: "tool_a", Success: true, ActorType: storage.ActorAutomation, ActorID: "3"},
+			{Action: "C", ToolName: "tool_b", Success: true, ActorType: storage.ActorAutomation, ActorID: "4"},
+		} {
+			_, err := s.storage.RecordAction(context.Background(), record)
+			require.NoError(t, err)
+		}
+
+		for query, want := range map[string]float64{
+			"actor=automation":             2,
+			"actor=automation:4":           1,
+			"actor=mcp:client/1.0":         1,
+			"actor=automation&tool=tool_a": 1,
+			"actor=tui":                    0,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/history?"+query, nil)
+			w := httptest.NewRecorder()
+			s.handleAPIHistory(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, query)
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, want, response["count"], query)
+		}
+	})
+
 	t.Run("rejects non-GET methods", func(t *testing.T) {
 		s, cleanup := testServer(t)
 		defer cleanup()
@@ -506,6 +537,102 @@ func TestHandleAPIConfig(t *testing.T) {
 	})
 }
 
+func TestReadOnlyMode(t *testing.T) {
+	t.Run("GET config reports read-only mode", func(t *testing.T) {
+		s, cleanup := testServer(t)
+		defer cleanup()
+		s.cfg.ReadOnly = true
+		require.NoError(t, s.storage.SaveOBSConfig(context.Background(), storage.OBSConfig{Host: "localhost", Port: 4455}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+		w := httptest.NewRecorder()
+		s.handleAPIConfig(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, true, response["read_only"])
+	})
+
+	t.Run("POST config is not allowed", func(t *testing.T) {
+		s, cleanup := testServer(t)
+		defer cleanup()
+		s.cfg.ReadOnly = true
+
+		body := `{"tool_groups": {"visual": false}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/config", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.handleAPIConfig(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, http.MethodGet, w.Header().Get("Allow"))
+
+		toolGroups, err := s.storage.LoadToolGroupConfig(context.Background())
+		require.NoError(t, err)
+		assert.True(t, toolGroups.Visual)
+	})
+
+	t.Run("UI actions are forbidden", func(t *testing.T) {
+		s, cleanup := testServer(t)
+		defer cleanup()
+		s.cfg.ReadOnly = true
+
+		executor := &mockActionExecutor{}
+		s.statusProvider = struct {
+			*mockStatusProvider
+			*mockActionExecutor
+		}{&mockStatusProvider{}, executor}
+		mux := s.setupRoutes()
+
+		body := `{"type":"tool","messageId":"msg-1","payload":{"toolName":"set_current_scene","params":{"scene_name":"Gaming"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/ui/action", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, executor.setSceneCalled)
+	})
+}
+
+func TestUIActionAudit(t *testing.T) {
+	s, cleanup := testServer(t)
+	defer cleanup()
+
+	executor := &mockActionExecutor{}
+	s.statusProvider = struct {
+		*mockStatusProvider
+		*mockActionExecutor
+	}{&mockStatusProvider{}, executor}
+	mux := s.setupRoutes()
+
+	body := `{"type":"tool","messageId":"msg-1","payload":{"toolName":"set_current_scene","params":{"scene_name":"Gaming"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/ui/action", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Gaming", executor.setSceneCalled)
+
+	actions, err := s.storage.FilterActions(context.Background(), storage.ActionFilter{ActorType: storage.ActorHTTP}, 0)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "set_current_scene", actions[0].ToolName)
+	assert.True(t, actions[0].Success)
+	assert.True(t, strings.HasPrefix(actions[0].ActorID, "token:"))
+	assert.NotContains(t, actions[0].ActorID, "secret-token")
+	assert.Contains(t, actions[0].Input, "Gaming")
+
+	req = httptest.NewRequest(http.MethodPost, "/ui/action", strings.NewReader(body))
+	req.RemoteAddr = "192.0.2.7:5000"
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	actions, err = s.storage.FilterActions(context.Background(), storage.ActionFilter{ActorType: storage.ActorHTTP, ActorID: "192.0.2.7"}, 0)
+	require.NoError(t, err)
+	assert.Len(t, actions, 1)
+}
+
 func TestHelperFunctions(t *testing.T) {
 	t.Run("getBool returns value when present", func(t *testing.T) {
 		m := map[string]interface{}{"key": true}
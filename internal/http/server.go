@@ -6,16 +6,19 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/fs"
-	"log"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ironystock/agentic-obs/internal/logging"
 	"github.com/ironystock/agentic-obs/internal/storage"
 )
 
+// logger is shared by all HTTP handlers.
+var logger = logging.New("http")
+
 //go:embed static/*
 var staticFiles embed.FS
 
@@ -31,6 +34,8 @@ type Config struct {
 	Port int
 	// ThumbnailCacheSec is the Cache-Control max-age for thumbnails (0 to disable)
 	ThumbnailCacheSec int
+	// ReadOnly rejects UI actions and configuration changes
+	ReadOnly bool
 }
 
 // isValidSourceName validates that a source name is safe to use.
@@ -83,6 +88,7 @@ type Server struct {
 	startTime      time.Time
 	statusProvider StatusProvider
 	uiHandlers     *UIHandlers
+	webhookHandler WebhookHandler
 
 	mu       sync.RWMutex
 	running  bool
@@ -140,6 +146,7 @@ func (s *Server) setupRoutes() *http.ServeMux {
 	mux.HandleFunc("/api/history/stats", s.handleAPIHistoryStats)
 	mux.HandleFunc("/api/screenshots", s.handleAPIScreenshots)
 	mux.HandleFunc("/api/config", s.handleAPIConfig)
+	mux.HandleFunc("/api/hooks/", s.handleWebhook)
 
 	// MCP-UI endpoints (only if status provider is configured)
 	if s.statusProvider != nil {
@@ -150,13 +157,20 @@ func (s *Server) setupRoutes() *http.ServeMux {
 		if executor, ok := s.statusProvider.(ActionExecutor); ok {
 			s.uiHandlers.SetActionExecutor(executor)
 		}
+		if s.storage != nil {
+			s.uiHandlers.SetActionRecorder(s.recordUIAction)
+		}
 
 		mux.HandleFunc("/ui/status", s.uiHandlers.HandleUIStatus)
 		mux.HandleFunc("/ui/scenes", s.uiHandlers.HandleUIScenes)
 		mux.HandleFunc("/ui/audio", s.uiHandlers.HandleUIAudio)
 		mux.HandleFunc("/ui/screenshots", s.uiHandlers.HandleUIScreenshots)
 		mux.HandleFunc("/ui/scene-thumbnail/", s.uiHandlers.HandleSceneThumbnail)
-		mux.HandleFunc("/ui/action", s.uiHandlers.HandleUIAction)
+		if s.cfg.ReadOnly {
+			mux.HandleFunc("/ui/action", s.handleReadOnlyAction)
+		} else {
+			mux.HandleFunc("/ui/action", s.uiHandlers.HandleUIAction)
+		}
 	}
 
 	// Documentation endpoints
@@ -167,7 +181,7 @@ func (s *Server) setupRoutes() *http.ServeMux {
 	// Serve static files for the web dashboard
 	staticFS, err := fs.Sub(staticFiles, "static")
 	if err != nil {
-		log.Printf("Warning: failed to setup static file serving: %v", err)
+		logger.Warnf("Failed to setup static file serving: %v", err)
 	} else {
 		mux.Handle("/", http.FileServer(http.FS(staticFS)))
 	}
@@ -209,7 +223,7 @@ func (s *Server) Start() error {
 
 	go func() {
 		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
-			log.Printf("HTTP server error: %v", err)
+			logger.Errorf("HTTP server error: %v", err)
 			s.mu.Lock()
 			s.running = false
 			s.mu.Unlock()
@@ -273,7 +287,7 @@ func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
 
 	// Validate source name to prevent path traversal attacks
 	if !isValidSourceName(sourceName) {
-		log.Printf("Invalid source name rejected: %q", sourceName)
+		logger.Warnf("Invalid source name rejected: %q", sourceName)
 		http.Error(w, "Invalid source name", http.StatusBadRequest)
 		return
 	}
@@ -281,7 +295,7 @@ func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
 	// Look up the screenshot source by name
 	source, err := s.storage.GetScreenshotSourceByName(r.Context(), sourceName)
 	if err != nil {
-		log.Printf("Screenshot source lookup failed for %q: %v", sourceName, err)
+		logger.Warnf("Screenshot source lookup failed for %q: %v", sourceName, err)
 		http.Error(w, "Source not found", http.StatusNotFound)
 		return
 	}
@@ -289,7 +303,7 @@ func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
 	// Get the latest screenshot for this source
 	screenshot, err := s.storage.GetLatestScreenshot(r.Context(), source.ID)
 	if err != nil {
-		log.Printf("No screenshots available for source %q (ID: %d): %v", sourceName, source.ID, err)
+		logger.Debugf("No screenshots available for source %q (ID: %d): %v", sourceName, source.ID, err)
 		http.Error(w, "No screenshots available", http.StatusNotFound)
 		return
 	}
@@ -297,7 +311,7 @@ func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
 	// Decode base64 image data
 	imageData, err := base64.StdEncoding.DecodeString(screenshot.ImageData)
 	if err != nil {
-		log.Printf("Failed to decode screenshot data for source %q: %v", sourceName, err)
+		logger.Errorf("Failed to decode screenshot data for source %q: %v", sourceName, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
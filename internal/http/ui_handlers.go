@@ -3,8 +3,8 @@ package http
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"time"
 )
 
 // StatusProvider defines the interface for retrieving OBS status data.
@@ -113,6 +113,7 @@ type ScreenshotSourceInfo struct {
 type UIHandlers struct {
 	statusProvider    StatusProvider
 	actionExecutor    ActionExecutor
+	actionRecorder    ActionRecorder
 	baseURL           string // Base URL for constructing absolute URLs in templates
 	thumbnailCacheSec int    // Cache duration for thumbnails (0 to disable caching)
 }
@@ -132,6 +133,15 @@ func (h *UIHandlers) SetActionExecutor(executor ActionExecutor) {
 	h.actionExecutor = executor
 }
 
+// ActionRecorder is called after each UI tool action with the request that
+// triggered it, so the action can be added to the audit history.
+type ActionRecorder func(r *http.Request, toolName string, params map[string]any, err error, duration time.Duration)
+
+// SetActionRecorder sets the recorder called after each UI tool action.
+func (h *UIHandlers) SetActionRecorder(recorder ActionRecorder) {
+	h.actionRecorder = recorder
+}
+
 // HandleUIStatus serves the status dashboard UI.
 func (h *UIHandlers) HandleUIStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -174,7 +184,7 @@ func (h *UIHandlers) HandleUIScenes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("[UI Scenes] Serving scene preview with BaseURL: %s, %d scenes", h.baseURL, len(scenes))
+	logger.Debugf("UI scenes: serving scene preview with BaseURL: %s, %d scenes", h.baseURL, len(scenes))
 
 	data := map[string]any{
 		"Scenes":  scenes,
@@ -284,7 +294,7 @@ func (h *UIHandlers) servePlaceholderThumbnail(w http.ResponseWriter, sceneName
 // HandleUIAction handles UIAction requests from embedded UIs.
 // POST /ui/action - receives UIAction JSON, executes, returns UIResponse.
 func (h *UIHandlers) HandleUIAction(w http.ResponseWriter, r *http.Request) {
-	log.Printf("[UI Action] Received request: %s %s", r.Method, r.URL.Path)
+	logger.Debugf("UI action: received request: %s %s", r.Method, r.URL.Path)
 
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -305,7 +315,7 @@ func (h *UIHandlers) HandleUIAction(w http.ResponseWriter, r *http.Request) {
 	// Handle tool actions
 	if action.Type == "tool" {
 		if h.actionExecutor == nil {
-			log.Printf("[UI Action] ERROR: actionExecutor is nil, cannot execute tool")
+			logger.Errorf("UI action: actionExecutor is nil, cannot execute tool")
 			h.sendActionResponse(w, action.MessageID, nil, fmt.Errorf("action executor not configured"))
 			return
 		}
@@ -315,19 +325,23 @@ func (h *UIHandlers) HandleUIAction(w http.ResponseWriter, r *http.Request) {
 			Params   map[string]any `json:"params"`
 		}
 		if err := json.Unmarshal(action.Payload, &toolPayload); err != nil {
-			log.Printf("[UI Action] ERROR: failed to parse payload: %v", err)
+			logger.Errorf("UI action: failed to parse payload: %v", err)
 			h.sendActionResponse(w, action.MessageID, nil, fmt.Errorf("invalid payload: %w", err))
 			return
 		}
 
-		log.Printf("[UI Action] Executing tool: %s with params: %v", toolPayload.ToolName, toolPayload.Params)
+		logger.Infof("UI action: executing tool: %s with params: %v", toolPayload.ToolName, toolPayload.Params)
+		start := time.Now()
 		err := h.executeToolAction(toolPayload.ToolName, toolPayload.Params)
+		if h.actionRecorder != nil {
+			h.actionRecorder(r, toolPayload.ToolName, toolPayload.Params, err, time.Since(start))
+		}
 		if err != nil {
-			log.Printf("[UI Action] ERROR: tool execution failed: %v", err)
+			logger.Errorf("UI action: tool execution failed: %v", err)
 			h.sendActionResponse(w, action.MessageID, nil, err)
 			return
 		}
-		log.Printf("[UI Action] SUCCESS: tool %s executed", toolPayload.ToolName)
+		logger.Infof("UI action: tool %s executed", toolPayload.ToolName)
 		h.sendActionResponse(w, action.MessageID, map[string]string{"status": "success"}, nil)
 		return
 	}
@@ -392,7 +406,7 @@ func (h *UIHandlers) sendActionResponse(w http.ResponseWriter, messageID string,
 func (h *UIHandlers) renderTemplate(w http.ResponseWriter, templateName string, data map[string]any) {
 	tmpl, err := getTemplate(templateName)
 	if err != nil {
-		log.Printf("Template load error for %s: %v", templateName, err)
+		logger.Errorf("Template load error for %s: %v", templateName, err)
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
@@ -403,7 +417,7 @@ func (h *UIHandlers) renderTemplate(w http.ResponseWriter, templateName string,
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := tmpl.Execute(w, data); err != nil {
 		// Headers already sent, just log
-		log.Printf("Template execution error for %s: %v", templateName, err)
+		logger.Errorf("Template execution error for %s: %v", templateName, err)
 	}
 }
 
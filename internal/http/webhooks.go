@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+)
+
+// maxWebhookBodyBytes caps the size of webhook request bodies, matching the
+// limit on other POST endpoints.
+const maxWebhookBodyBytes = 64 * 1024
+
+// WebhookHandler fires automation rules from webhook requests.
+// automation.AutomationEngine implements it.
+type WebhookHandler interface {
+	HandleWebhook(ruleName string, req automation.WebhookRequest) (*automation.WebhookResult, error)
+}
+
+// SetWebhookHandler configures the handler behind /api/hooks/.
+// This must be called before Start(). Returns an error if the server is
+// already running.
+func (s *Server) SetWebhookHandler(handler WebhookHandler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return fmt.Errorf("cannot set webhook handler: server already running")
+	}
+
+	s.webhookHandler = handler
+	return nil
+}
+
+// handleWebhook fires the webhook rule named in the path: /api/hooks/{rule_name}.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.webhookHandler == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Automation is not available"})
+		return
+	}
+
+	ruleName := strings.TrimPrefix(r.URL.Path, "/api/hooks/")
+	if ruleName == "" || strings.Contains(ruleName, "/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "Request body too large"})
+		return
+	}
+
+	result, err := s.webhookHandler.HandleWebhook(ruleName, automation.WebhookRequest{
+		Timestamp: r.Header.Get(automation.WebhookTimestampHeader),
+		Signature: r.Header.Get(automation.WebhookSignatureHeader),
+		Body:      body,
+	})
+	if err != nil {
+		var notFound *automation.RuleNotFoundError
+		switch {
+		case errors.As(err, &notFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "Webhook not found"})
+		case errors.Is(err, automation.ErrWebhookUnauthorized), errors.Is(err, automation.ErrWebhookExpired):
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		case errors.Is(err, automation.ErrWebhookReplayed):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, automation.ErrWebhookPayload):
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			logger.Errorf("Webhook for rule '%s' failed: %v", ruleName, err)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Failed to trigger rule"})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if result.Triggered {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, result)
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhookHandler records webhook calls and returns a canned outcome.
+type fakeWebhookHandler struct {
+	ruleName string
+	req      automation.WebhookRequest
+	result   *automation.WebhookResult
+	err      error
+}
+
+func (f *fakeWebhookHandler) HandleWebhook(ruleName string, req automation.WebhookRequest) (*automation.WebhookResult, error) {
+	f.ruleName = ruleName
+	f.req = req
+	return f.result, f.err
+}
+
+func TestHandleWebhook(t *testing.T) {
+	s, cleanup := testServer(t)
+	defer cleanup()
+
+	t.Run("passes the signed request to the handler", func(t *testing.T) {
+		handler := &fakeWebhookHandler{result: &automation.WebhookResult{RuleName: "Go Live", Triggered: true}}
+		s.webhookHandler = handler
+
+		req := httptest.NewRequest(http.MethodPost, "/api/hooks/Go%20Live", strings.NewReader(`{"scene":"Gaming"}`))
+		req.Header.Set(automation.WebhookTimestampHeader, "1700000000")
+		req.Header.Set(automation.WebhookSignatureHeader, "sha256=abc")
+		w := httptest.NewRecorder()
+		s.setupRoutes().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "Go Live", handler.ruleName)
+		assert.Equal(t, "1700000000", handler.req.Timestamp)
+		assert.Equal(t, "sha256=abc", handler.req.Signature)
+		assert.JSONEq(t, `{"scene":"Gaming"}`, string(handler.req.Body))
+
+		var result automation.WebhookResult
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.True(t, result.Triggered)
+	})
+
+	t.Run("reports skipped rules", func(t *testing.T) {
+		s.webhookHandler = &fakeWebhookHandler{result: &automation.WebhookResult{RuleName: "x", Reason: "cooldown"}}
+		w := httptest.NewRecorder()
+		s.handleWebhook(w, httptest.NewRequest(http.MethodPost, "/api/hooks/x", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "cooldown")
+	})
+
+	for name, tc := range map[string]struct {
+		err  error
+		want int
+	}{
+		"unknown rule":  {&automation.RuleNotFoundError{Name: "x"}, http.StatusNotFound},
+		"bad signature": {automation.ErrWebhookUnauthorized, http.StatusUnauthorized},
+		"expired":       {automation.ErrWebhookExpired, http.StatusUnauthorized},
+		"replayed":      {automation.ErrWebhookReplayed, http.StatusConflict},
+		"bad body":      {automation.ErrWebhookPayload, http.StatusBadRequest},
+		"engine down":   {fmt.Errorf("automation engine is not running"), http.StatusServiceUnavailable},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s.webhookHandler = &fakeWebhookHandler{err: tc.err}
+			w := httptest.NewRecorder()
+			s.handleWebhook(w, httptest.NewRequest(http.MethodPost, "/api/hooks/x", strings.NewReader("{}")))
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+
+	t.Run("rejects other methods and paths", func(t *testing.T) {
+		s.webhookHandler = &fakeWebhookHandler{}
+
+		w := httptest.NewRecorder()
+		s.handleWebhook(w, httptest.NewRequest(http.MethodGet, "/api/hooks/x", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+		w = httptest.NewRecorder()
+		s.handleWebhook(w, httptest.NewRequest(http.MethodPost, "/api/hooks/", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		s.handleWebhook(w, httptest.NewRequest(http.MethodPost, "/api/hooks/a/b", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("rejects oversized bodies", func(t *testing.T) {
+		s.webhookHandler = &fakeWebhookHandler{}
+		w := httptest.NewRecorder()
+		s.handleWebhook(w, httptest.NewRequest(http.MethodPost, "/api/hooks/x", strings.NewReader(strings.Repeat("a", maxWebhookBodyBytes+1))))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("unavailable without automation", func(t *testing.T) {
+		s.webhookHandler = nil
+		w := httptest.NewRecorder()
+		s.handleWebhook(w, httptest.NewRequest(http.MethodPost, "/api/hooks/x", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,148 @@
+// Package logging provides a leveled, component-tagged logger for background
+// subsystems. Every entry is written to the standard log output and fanned out
+// to registered sinks, which lets the MCP server forward entries to clients as
+// notifications/message.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+// Log levels, ordered from least to most severe. Names match the MCP
+// logging levels so they can be passed through unchanged.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
+)
+
+// String returns the MCP name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseLevel converts a level name ("debug", "info", "warning"/"warn", "error")
+// into a Level.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warning", "warn":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// Entry is a single structured log record.
+type Entry struct {
+	Time      time.Time
+	Level     Level
+	Component string
+	Message   string
+}
+
+// Sink receives every log entry. Sinks are called synchronously from the
+// logging goroutine and must not block or log through this package.
+type Sink func(Entry)
+
+var (
+	sinksMu  sync.RWMutex
+	sinks    = make(map[int]Sink)
+	nextSink int
+)
+
+// AddSink registers a sink and returns a function that removes it.
+func AddSink(sink Sink) (remove func()) {
+	sinksMu.Lock()
+	id := nextSink
+	nextSink++
+	sinks[id] = sink
+	sinksMu.Unlock()
+
+	return func() {
+		sinksMu.Lock()
+		delete(sinks, id)
+		sinksMu.Unlock()
+	}
+}
+
+// Logger writes entries tagged with a component name.
+type Logger struct {
+	component string
+}
+
+// New returns a logger for the named component (e.g. "automation", "obs").
+func New(component string) *Logger {
+	return &Logger{component: component}
+}
+
+// Component returns the component name attached to every entry.
+func (l *Logger) Component() string {
+	return l.component
+}
+
+// Debugf logs a debug-level message.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.emit(LevelDebug, format, args...)
+}
+
+// Infof logs an info-level message.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.emit(LevelInfo, format, args...)
+}
+
+// Warnf logs a warning-level message.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.emit(LevelWarning, format, args...)
+}
+
+// Errorf logs an error-level message.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.emit(LevelError, format, args...)
+}
+
+// emit writes the entry to the standard logger and every registered sink.
+func (l *Logger) emit(level Level, format string, args ...interface{}) {
+	entry := Entry{
+		Time:      time.Now(),
+		Level:     level,
+		Component: l.component,
+		Message:   fmt.Sprintf(format, args...),
+	}
+
+	if level >= LevelWarning {
+		log.Printf("[%s] %s: %s", l.component, strings.ToUpper(level.String()), entry.Message)
+	} else {
+		log.Printf("[%s] %s", l.component, entry.Message)
+	}
+
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	for _, sink := range sinks {
+		sink(entry)
+	}
+}
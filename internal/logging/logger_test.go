@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerSinks(t *testing.T) {
+	var mu sync.Mutex
+	var entries []Entry
+	remove := AddSink(func(e Entry) {
+		mu.Lock()
+		entries = append(entries, e)
+		mu.Unlock()
+	})
+
+	logger := New("test-component")
+	logger.Debugf("debug %d", 1)
+	logger.Infof("info %d", 2)
+	logger.Warnf("warn %d", 3)
+	logger.Errorf("error %d", 4)
+
+	remove()
+	logger.Errorf("after removal")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, entries, 4)
+
+	levels := []Level{LevelDebug, LevelInfo, LevelWarning, LevelError}
+	messages := []string{"debug 1", "info 2", "warn 3", "error 4"}
+	for i, e := range entries {
+		assert.Equal(t, "test-component", e.Component)
+		assert.Equal(t, levels[i], e.Level)
+		assert.Equal(t, messages[i], e.Message)
+		assert.False(t, e.Time.IsZero())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"warning", LevelWarning, false},
+		{"warn", LevelWarning, false},
+		{" error ", LevelError, false},
+		{"verbose", LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLevel(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, mustParse(t, got.String()))
+		})
+	}
+}
+
+func mustParse(t *testing.T, name string) Level {
+	t.Helper()
+	level, err := ParseLevel(name)
+	require.NoError(t, err)
+	return level
+}
@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// actor identifies who a tool call is recorded for in the action history.
+type actor struct {
+	Type string
+	ID   string
+}
+
+type actorContextKey struct{}
+
+// withActor returns a context that attributes recorded actions and saved
+// rule revisions to a.
+func withActor(ctx context.Context, a actor) context.Context {
+	ctx = storage.WithActor(ctx, a.Type, a.ID)
+	return context.WithValue(ctx, actorContextKey{}, a)
+}
+
+// actorFrom returns the actor recorded actions on ctx are attributed to.
+// Calls without one come from an MCP client that could not be identified.
+func actorFrom(ctx context.Context) actor {
+	if ctx != nil {
+		if a, ok := ctx.Value(actorContextKey{}).(actor); ok {
+			return a
+		}
+	}
+	return actor{Type: storage.ActorMCP}
+}
+
+// requestActor identifies the MCP client that sent request by the client
+// info from its initialize request, as "name/version".
+func requestActor(request *mcpsdk.CallToolRequest) actor {
+	a := actor{Type: storage.ActorMCP}
+	if request == nil || request.Session == nil {
+		return a
+	}
+	params := request.Session.InitializeParams()
+	if params == nil || params.ClientInfo == nil {
+		return a
+	}
+	a.ID = params.ClientInfo.Name
+	if params.ClientInfo.Version != "" {
+		a.ID += "/" + params.ClientInfo.Version
+	}
+	return a
+}
+
+type sessionContextKey struct{}
+
+// withSession returns a context for calls made through session.
+func withSession(ctx context.Context, session *mcpsdk.ServerSession) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// sessionFrom returns the MCP session calls on ctx were made through, or nil.
+func sessionFrom(ctx context.Context) *mcpsdk.ServerSession {
+	if ctx != nil {
+		if session, ok := ctx.Value(sessionContextKey{}).(*mcpsdk.ServerSession); ok {
+			return session
+		}
+	}
+	return nil
+}
+
+// withRequestActor attributes the actions a handler records to the MCP
+// client and session that called it. Calls made on behalf of another actor,
+// such as execute_batch steps, keep the actor and session already on the
+// context.
+func withRequestActor[In any](handler mcpsdk.ToolHandlerFor[In, any]) mcpsdk.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, request *mcpsdk.CallToolRequest, input In) (*mcpsdk.CallToolResult, any, error) {
+		if _, ok := ctx.Value(actorContextKey{}).(actor); !ok {
+			ctx = withActor(ctx, requestActor(request))
+			ctx = withSession(ctx, getSession(request))
+		}
+		return handler(ctx, request, input)
+	}
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestActionActor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("attributes tool calls to the MCP client", func(t *testing.T) {
+		server, _, db := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		_, err := session.CallTool(ctx, &mcpsdk.CallToolParams{Name: "set_current_scene", Arguments: map[string]any{"scene_name": "Gaming"}})
+		require.NoError(t, err)
+
+		actions, err := db.FilterActions(ctx, storage.ActionFilter{ActorType: storage.ActorMCP, ActorID: "test-client/0.0.0"}, 0)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "set_current_scene", actions[0].ToolName)
+	})
+
+	t.Run("batch steps keep the caller", func(t *testing.T) {
+		server, _, db := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		_, err := session.CallTool(ctx, &mcpsdk.CallToolParams{Name: "execute_batch", Arguments: map[string]any{
+			"steps": []map[string]any{{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Gaming"}}},
+		}})
+		require.NoError(t, err)
+
+		parent := lastActionID(t, server, "execute_batch")
+		children, err := db.GetChildActions(ctx, parent)
+		require.NoError(t, err)
+		require.Len(t, children, 1)
+		assert.Equal(t, storage.ActorMCP, children[0].ActorType)
+		assert.Equal(t, "test-client/0.0.0", children[0].ActorID)
+	})
+
+	t.Run("direct handler calls default to an unidentified MCP client", func(t *testing.T) {
+		server, _, db := testServerWithStorage(t)
+
+		_, _, err := server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Gaming"})
+		require.NoError(t, err)
+
+		actions, err := db.FilterActions(ctx, storage.ActionFilter{ActorType: storage.ActorMCP}, 0)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Empty(t, actions[0].ActorID)
+	})
+}
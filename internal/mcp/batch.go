@@ -0,0 +1,359 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Batched tool calls.
+//
+// execute_batch runs an ordered list of tool calls through the same handlers
+// the MCP server dispatches to. While a step runs, the action history record
+// and undo operations its handler produces are collected on the step's
+// context instead of being stored. The batch is then recorded as a single
+// action with the steps as child records. When a step fails, the completed
+// steps are reverted newest first using the undo operations they captured.
+
+// maxBatchSteps bounds the number of steps in one execute_batch call.
+const maxBatchSteps = 50
+
+// Compensation outcomes reported for completed steps of a failed batch.
+const (
+	compensationReverted    = "reverted"
+	compensationNotUndoable = "not_undoable"
+	compensationFailed      = "failed"
+	compensationSkipped     = "skipped"
+)
+
+// batchInvoker calls a registered tool handler with raw JSON arguments.
+type batchInvoker func(ctx context.Context, request *mcpsdk.CallToolRequest, args json.RawMessage) (any, error)
+
+// registerBatchTool makes a tool callable from execute_batch. Arguments are
+// validated against the tool's input schema before the handler runs, as the
+// SDK does for direct calls.
+func registerBatchTool[In any](s *Server, tool *mcpsdk.Tool, handler mcpsdk.ToolHandlerFor[In, any]) {
+	if tool.Name == "execute_batch" {
+		return
+	}
+
+	schema, ok := tool.InputSchema.(*jsonschema.Schema)
+	if !ok {
+		var err error
+		schema, err = jsonschema.For[In](&jsonschema.ForOptions{})
+		if err != nil {
+			panic(fmt.Sprintf("registerBatchTool: tool %q: input schema: %v", tool.Name, err))
+		}
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		panic(fmt.Sprintf("registerBatchTool: tool %q: resolve input schema: %v", tool.Name, err))
+	}
+
+	if s.batchTools == nil {
+		s.batchTools = make(map[string]batchInvoker)
+	}
+	s.batchTools[tool.Name] = func(ctx context.Context, request *mcpsdk.CallToolRequest, args json.RawMessage) (any, error) {
+		var value any
+		if err := json.Unmarshal(args, &value); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		if err := resolved.Validate(value); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+
+		var in In
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+
+		result, out, err := handler(ctx, request, in)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil && result.IsError {
+			return out, fmt.Errorf("tool %s reported an error", tool.Name)
+		}
+		return out, nil
+	}
+}
+
+// batchRecorder collects what a tool handler records while it runs as a
+// batch step.
+type batchRecorder struct {
+	records []storage.ActionRecord
+	undo    []storage.UndoOperation
+}
+
+type batchContextKey struct{}
+
+// withBatchRecorder returns a context that routes action recording to rec.
+func withBatchRecorder(ctx context.Context, rec *batchRecorder) context.Context {
+	return context.WithValue(ctx, batchContextKey{}, rec)
+}
+
+// batchRecorderFrom returns the recorder of the batch step running on ctx,
+// or nil outside execute_batch.
+func batchRecorderFrom(ctx context.Context) *batchRecorder {
+	if ctx == nil {
+		return nil
+	}
+	rec, _ := ctx.Value(batchContextKey{}).(*batchRecorder)
+	return rec
+}
+
+// BatchStep is a single tool call within execute_batch
+type BatchStep struct {
+	Tool      string                 `json:"tool" jsonschema:"Name of the tool to call"`
+	Arguments map[string]interface{} `json:"arguments,omitempty" jsonschema:"Arguments for the tool, as they would be passed to a direct call"`
+}
+
+// ExecuteBatchInput is the input for execute_batch
+type ExecuteBatchInput struct {
+	Steps []BatchStep `json:"steps" jsonschema:"Tool calls to run in order (max 50)"`
+}
+
+// batchStepRequest builds the request a step's handler receives. The batch's
+// progress token is not passed on; the batch reports progress per step.
+func batchStepRequest(request *mcpsdk.CallToolRequest, step BatchStep, args json.RawMessage) *mcpsdk.CallToolRequest {
+	if request == nil {
+		return nil
+	}
+	return &mcpsdk.CallToolRequest{
+		Session: request.Session,
+		Params:  &mcpsdk.CallToolParamsRaw{Name: step.Tool, Arguments: args},
+		Extra:   request.Extra,
+	}
+}
+
+func (s *Server) handleExecuteBatch(ctx context.Context, request *mcpsdk.CallToolRequest, input ExecuteBatchInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Executing batch of %d steps", len(input.Steps))
+
+	if err := s.validateBatch(input); err != nil {
+		s.recordAction(ctx, "execute_batch", "Execute batch", redactBatchInput(input), nil, false, time.Since(start))
+		return nil, nil, err
+	}
+
+	progress := newProgressReporter(request)
+	steps := make([]BatchStepResult, 0, len(input.Steps))
+	stepUndo := make([][]storage.UndoOperation, 0, len(input.Steps))
+	var records []storage.ActionRecord
+	var partialUndo []storage.UndoOperation // Reverts what a failed step applied before it failed
+	failed := -1
+
+	for i, step := range input.Steps {
+		stepResult := BatchStepResult{Index: i, Tool: step.Tool}
+
+		rec := &batchRecorder{}
+		out, err := s.runBatchStep(ctx, request, step, rec)
+		records = append(records, rec.records...)
+
+		stepResult.Result = out
+		if err != nil {
+			stepResult.Error = err.Error()
+			steps = append(steps, stepResult)
+			failed = i
+			partialUndo = rec.undo
+			break
+		}
+
+		stepResult.Success = true
+		steps = append(steps, stepResult)
+		stepUndo = append(stepUndo, rec.undo)
+		progress.report(ctx, i+1, len(input.Steps), fmt.Sprintf("Completed step %d (%s)", i+1, step.Tool))
+	}
+
+	result := BatchResult{
+		Success:   failed < 0,
+		Steps:     steps,
+		Completed: len(stepUndo),
+		Total:     len(input.Steps),
+	}
+
+	if failed < 0 {
+		result.Message = fmt.Sprintf("Batch completed: %d steps", len(input.Steps))
+		s.recordBatch(ctx, input, result, records, stepUndo, time.Since(start))
+		return nil, result, nil
+	}
+
+	result.FailedStep = &failed
+	if len(partialUndo) > 0 {
+		stepUndo = append(stepUndo, partialUndo)
+	}
+	compErr := s.compensateBatch(input, steps, stepUndo)
+	applied := notUndoneSteps(steps)
+	result.RolledBack = compErr == nil && len(applied) == 0
+	result.Message = fmt.Sprintf("Batch stopped at step %d (%s): %s", failed+1, input.Steps[failed].Tool, steps[failed].Error)
+	if compErr != nil {
+		result.Message += fmt.Sprintf("; rollback incomplete: %v", compErr)
+	} else if len(applied) > 0 {
+		result.Message += fmt.Sprintf("; not rolled back: %s cannot be undone and stayed applied", strings.Join(applied, ", "))
+	} else if len(stepUndo) > 0 {
+		result.Message += "; completed steps were rolled back"
+	}
+
+	log.Print(result.Message)
+	s.recordBatch(ctx, input, result, records, nil, time.Since(start))
+	return &mcpsdk.CallToolResult{IsError: true}, result, nil
+}
+
+// runBatchStep calls the step's tool with its actions recorded to rec.
+func (s *Server) runBatchStep(ctx context.Context, request *mcpsdk.CallToolRequest, step BatchStep, rec *batchRecorder) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("batch cancelled: %w", err)
+	}
+
+	args := json.RawMessage("{}")
+	if step.Arguments != nil {
+		data, err := json.Marshal(step.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		args = data
+	}
+
+	return s.batchTools[step.Tool](withBatchRecorder(ctx, rec), batchStepRequest(request, step, args), args)
+}
+
+// validateBatch checks every step before any of them runs.
+func (s *Server) validateBatch(input ExecuteBatchInput) error {
+	if len(input.Steps) == 0 {
+		return fmt.Errorf("batch has no steps")
+	}
+	if len(input.Steps) > maxBatchSteps {
+		return fmt.Errorf("batch has %d steps; the maximum is %d", len(input.Steps), maxBatchSteps)
+	}
+	for i, step := range input.Steps {
+		if step.Tool == "execute_batch" {
+			return fmt.Errorf("step %d: execute_batch cannot be nested", i+1)
+		}
+		if _, ok := s.batchTools[step.Tool]; !ok {
+			return fmt.Errorf("step %d: unknown or disabled tool '%s'", i+1, step.Tool)
+		}
+	}
+	return nil
+}
+
+// compensateBatch reverts the completed steps of a failed batch, newest
+// first, and records the outcome on each step. It stops at the first step
+// that cannot be reverted, since earlier steps may depend on it.
+func (s *Server) compensateBatch(input ExecuteBatchInput, steps []BatchStepResult, stepUndo [][]storage.UndoOperation) error {
+	ids := sceneItemIDs{}
+
+	for i := len(stepUndo) - 1; i >= 0; i-- {
+		ops := stepUndo[i]
+		if len(ops) == 0 {
+			if !batchStepIsReadOnly(input.Steps[i]) {
+				steps[i].Compensation = compensationNotUndoable
+			}
+			continue
+		}
+
+		ids.remap(ops)
+		for _, op := range ops {
+			if err := s.applyUndoOperation(op, ids); err != nil {
+				steps[i].Compensation = compensationFailed
+				for j := i - 1; j >= 0; j-- {
+					if len(stepUndo[j]) > 0 {
+						steps[j].Compensation = compensationSkipped
+					}
+				}
+				return fmt.Errorf("failed to revert step %d (%s) at %s: %w", i+1, steps[i].Tool, op.Type, err)
+			}
+		}
+		steps[i].Compensation = compensationReverted
+	}
+
+	return nil
+}
+
+// notUndoneSteps lists the completed steps of a failed batch that stayed
+// applied because their tool has no undo support, as "step N (tool)".
+func notUndoneSteps(steps []BatchStepResult) []string {
+	var applied []string
+	for i, step := range steps {
+		if step.Compensation == compensationNotUndoable {
+			applied = append(applied, fmt.Sprintf("step %d (%s)", i+1, step.Tool))
+		}
+	}
+	return applied
+}
+
+// batchStepIsReadOnly reports whether a step changed nothing that would need
+// reverting: a read-only tool or a dry run.
+func batchStepIsReadOnly(step BatchStep) bool {
+	if dryRun, _ := step.Arguments["dry_run"].(bool); dryRun {
+		return true
+	}
+	return toolSpecs[step.Tool].ReadOnly
+}
+
+// redactBatchInput returns input with webhook secrets in the steps'
+// trigger_config arguments masked, for action history.
+func redactBatchInput(input ExecuteBatchInput) ExecuteBatchInput {
+	steps := make([]BatchStep, len(input.Steps))
+	for i, step := range input.Steps {
+		steps[i] = step
+		config, ok := step.Arguments["trigger_config"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := config["secret"]; !ok {
+			continue
+		}
+		args := make(map[string]interface{}, len(step.Arguments))
+		for k, v := range step.Arguments {
+			args[k] = v
+		}
+		args["trigger_config"] = redactTriggerConfig(config)
+		steps[i].Arguments = args
+	}
+	input.Steps = steps
+	return input
+}
+
+// recordBatch stores the batch as one action with its steps as children.
+// A successful batch gets a single undo entry that reverts every step,
+// newest first.
+func (s *Server) recordBatch(ctx context.Context, input ExecuteBatchInput, result BatchResult, records []storage.ActionRecord, stepUndo [][]storage.UndoOperation, duration time.Duration) {
+	parentID := s.storeAction(ctx, "execute_batch", "Execute batch", redactBatchInput(input), result, result.Success, duration, false)
+	if parentID == 0 {
+		return
+	}
+
+	for _, record := range records {
+		if result.Success && record.Success && !record.DryRun {
+			s.macro.capture(sessionFrom(ctx), record.ToolName, record.Input)
+		}
+		record.ParentID = parentID
+		if _, err := s.storage.RecordAction(s.ctx, record); err != nil {
+			log.Printf("Warning: failed to record batch step %s: %v", record.ToolName, err)
+		}
+	}
+
+	var ops []storage.UndoOperation
+	for i := len(stepUndo) - 1; i >= 0; i-- {
+		ops = append(ops, stepUndo[i]...)
+	}
+	if len(ops) == 0 {
+		return
+	}
+
+	entry := storage.UndoEntry{
+		ActionID:    parentID,
+		ToolName:    "execute_batch",
+		Description: fmt.Sprintf("Execute batch (%d steps)", len(input.Steps)),
+		Operations:  ops,
+	}
+	if _, err := s.storage.RecordUndoEntry(s.ctx, entry); err != nil {
+		log.Printf("Warning: failed to record undo entry for execute_batch: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callExecuteBatch runs execute_batch through an MCP session and decodes its
+// structured result.
+func callExecuteBatch(t *testing.T, session *mcpsdk.ClientSession, steps ...map[string]any) (*mcpsdk.CallToolResult, BatchResult) {
+	t.Helper()
+
+	res, err := session.CallTool(context.Background(), &mcpsdk.CallToolParams{
+		Name:      "execute_batch",
+		Arguments: map[string]any{"steps": steps},
+	})
+	require.NoError(t, err)
+
+	var result BatchResult
+	if res.StructuredContent != nil {
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &result))
+	}
+	return res, result
+}
+
+func TestExecuteBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs steps in order and records one entry with children", func(t *testing.T) {
+		server, mock, db := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		res, result := callExecuteBatch(t, session,
+			map[string]any{"tool": "create_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+			map[string]any{"tool": "list_scenes"},
+		)
+		require.False(t, res.IsError, toolResultText(res))
+		assert.True(t, result.Success)
+		assert.Equal(t, 3, result.Completed)
+		require.Len(t, result.Steps, 3)
+		assert.NotNil(t, result.Steps[2].Result)
+
+		_, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Interview", current)
+
+		recent, err := db.GetRecentActions(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 1)
+		assert.Equal(t, "execute_batch", recent[0].ToolName)
+
+		children, err := db.GetChildActions(ctx, recent[0].ID)
+		require.NoError(t, err)
+		require.Len(t, children, 3)
+		assert.Equal(t, "create_scene", children[0].ToolName)
+		assert.Equal(t, "list_scenes", children[2].ToolName)
+	})
+
+	t.Run("successful batch is undone as a whole", func(t *testing.T) {
+		server, mock, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		res, _ := callExecuteBatch(t, session,
+			map[string]any{"tool": "create_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+		)
+		require.False(t, res.IsError, toolResultText(res))
+
+		_, undo, err := server.handleUndoLastAction(ctx, nil, struct{}{})
+		require.NoError(t, err)
+		assert.Equal(t, "execute_batch", undo.(UndoResult).Undone[0].ToolName)
+
+		scenes, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Scene 1", current)
+		assert.NotContains(t, scenes, "Interview")
+	})
+
+	t.Run("rolls back completed steps when a step fails", func(t *testing.T) {
+		server, mock, db := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		res, result := callExecuteBatch(t, session,
+			map[string]any{"tool": "create_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Missing"}},
+			map[string]any{"tool": "start_recording"},
+		)
+		require.True(t, res.IsError)
+		assert.False(t, result.Success)
+		assert.True(t, result.RolledBack)
+		require.NotNil(t, result.FailedStep)
+		assert.Equal(t, 2, *result.FailedStep)
+		require.Len(t, result.Steps, 3)
+		assert.Equal(t, compensationReverted, result.Steps[0].Compensation)
+		assert.Equal(t, compensationReverted, result.Steps[1].Compensation)
+		assert.NotEmpty(t, result.Steps[2].Error)
+
+		scenes, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Scene 1", current)
+		assert.NotContains(t, scenes, "Interview")
+
+		status, _ := mock.GetRecordingStatus()
+		assert.False(t, status.Active, "steps after the failure must not run")
+
+		recent, err := db.GetRecentActions(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 1)
+		assert.False(t, recent[0].Success)
+
+		_, undoable, err := server.handleListUndoableActions(ctx, nil, ListUndoableActionsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, undoable.(UndoableActionListResult).Count)
+	})
+
+	t.Run("reports steps that cannot be reverted", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		res, result := callExecuteBatch(t, session,
+			map[string]any{"tool": "start_recording"},
+			map[string]any{"tool": "get_recording_status"},
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{}},
+		)
+		require.True(t, res.IsError)
+		require.Len(t, result.Steps, 3)
+		assert.Equal(t, compensationNotUndoable, result.Steps[0].Compensation)
+		assert.Empty(t, result.Steps[1].Compensation)
+		assert.False(t, result.RolledBack)
+		assert.Contains(t, result.Message, "step 1 (start_recording) cannot be undone and stayed applied")
+		assert.NotContains(t, result.Message, "completed steps were rolled back")
+		assert.Contains(t, result.Steps[2].Error, "invalid arguments")
+	})
+
+	t.Run("rejects invalid batches before running anything", func(t *testing.T) {
+		server, mock, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		for _, steps := range [][]map[string]any{
+			{
+				{"tool": "create_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+				{"tool": "no_such_tool"},
+			},
+			{
+				{"tool": "create_scene", "arguments": map[string]any{"scene_name": "Interview"}},
+				{"tool": "execute_batch", "arguments": map[string]any{"steps": []any{}}},
+			},
+			{},
+		} {
+			res, _ := callExecuteBatch(t, session, steps...)
+			assert.True(t, res.IsError)
+		}
+
+		scenes, _, _ := mock.GetSceneList()
+		assert.NotContains(t, scenes, "Interview")
+	})
+}
@@ -0,0 +1,769 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andreykaipov/goobs/api/typedefs"
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Automation bundles.
+//
+// A bundle carries automation rules, the macros they call and scene presets
+// between installations as a versioned JSON or YAML document. Actions use the
+// format create_automation_rule takes, and imported rules and macros go
+// through the same validation as the create tools, so unknown action and
+// event types are rejected. Webhook secrets are left out of exports unless
+// asked for. The same functions back the bundle tools and the
+// "agentic-obs rules" CLI.
+
+// BundleVersion is the bundle format version written by exports. Imports
+// accept bundles up to this version.
+const BundleVersion = 1
+
+// Bundle formats
+const (
+	BundleFormatJSON = "json"
+	BundleFormatYAML = "yaml"
+)
+
+// Conflict strategies for items whose name is already taken
+const (
+	ConflictSkip      = "skip"      // Keep the existing item
+	ConflictRename    = "rename"    // Import under a free name such as "Intro (2)"
+	ConflictOverwrite = "overwrite" // Replace the existing item, keeping its ID and history
+)
+
+// Import statuses of bundle items
+const (
+	BundleItemCreated     = "created"
+	BundleItemRenamed     = "renamed"
+	BundleItemOverwritten = "overwritten"
+	BundleItemSkipped     = "skipped"
+	BundleItemFailed      = "failed"
+)
+
+// SupportedConflictStrategies returns the valid import conflict strategies.
+func SupportedConflictStrategies() []string {
+	return []string{ConflictSkip, ConflictRename, ConflictOverwrite}
+}
+
+// Bundle is an exported set of rules, macros and scene presets.
+type Bundle struct {
+	Version    int            `json:"version"`
+	ExportedAt string         `json:"exported_at,omitempty"`
+	Rules      []BundleRule   `json:"rules,omitempty"`
+	Macros     []BundleMacro  `json:"macros,omitempty"`
+	Presets    []BundlePreset `json:"presets,omitempty"`
+}
+
+// BundleRule is an automation rule in a bundle.
+type BundleRule struct {
+	Name          string                   `json:"name"`
+	Description   string                   `json:"description,omitempty"`
+	Enabled       bool                     `json:"enabled"`
+	TriggerType   string                   `json:"trigger_type"`
+	TriggerConfig map[string]interface{}   `json:"trigger_config"`
+	Actions       []map[string]interface{} `json:"actions"`
+	Condition     string                   `json:"condition,omitempty"`
+	Concurrency   string                   `json:"concurrency,omitempty"`
+	CooldownMs    int                      `json:"cooldown_ms,omitempty"`
+	Priority      int                      `json:"priority,omitempty"`
+}
+
+// BundleMacro is a macro in a bundle.
+type BundleMacro struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	Parameters  []storage.MacroParameter `json:"parameters,omitempty"`
+	Actions     []map[string]interface{} `json:"actions"`
+}
+
+// BundlePreset is a scene preset in a bundle.
+type BundlePreset struct {
+	Name      string                `json:"name"`
+	SceneName string                `json:"scene_name"`
+	Sources   []storage.SourceState `json:"sources,omitempty"`
+}
+
+// ExportOptions selects what ExportBundle exports.
+type ExportOptions struct {
+	Rules          []string // Rules to export; all rules when empty
+	Presets        []string // Scene presets to export besides those for scenes the rules switch to
+	IncludeSecrets bool     // Keep webhook secrets in trigger configs
+}
+
+// ImportOptions controls how ImportBundle applies a bundle.
+type ImportOptions struct {
+	Strategy string        // Conflict strategy; skip when empty
+	DryRun   bool          // Validate and report without writing anything
+	OBS      BundleCatalog // Checks scene and input references when set
+}
+
+// BundleCatalog lists the scenes and inputs of the OBS instance a bundle is
+// imported into. OBSClient implementations satisfy it.
+type BundleCatalog interface {
+	GetSceneList() ([]string, string, error)
+	ListSources() ([]*typedefs.Input, error)
+}
+
+// BundleItemResult is the import outcome of one bundle item.
+type BundleItemResult struct {
+	Kind       string `json:"kind"` // rule, macro or preset
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	ImportedAs string `json:"imported_as,omitempty"` // Name used when renamed
+	Error      string `json:"error,omitempty"`
+}
+
+// BundleImportResult is the outcome of ImportBundle and the output of
+// import_automation_bundle.
+type BundleImportResult struct {
+	DryRun            bool               `json:"dry_run,omitempty"`
+	Strategy          string             `json:"strategy"`
+	Items             []BundleItemResult `json:"items"`
+	Imported          int                `json:"imported"`
+	Skipped           int                `json:"skipped"`
+	Failed            int                `json:"failed"`
+	ReferencesChecked bool               `json:"references_checked"`
+	MissingScenes     []string           `json:"missing_scenes,omitempty"`
+	MissingInputs     []string           `json:"missing_inputs,omitempty"`
+	Message           string             `json:"message"`
+
+	// IDs of the rules created or overwritten, for engine notification
+	ruleIDs []int64
+}
+
+// ExportBundle builds a bundle from storage. Macros called by the exported
+// rules are included, along with the macros those call; exporting every rule
+// exports every macro. Scene presets for scenes the rules and macros switch
+// to are included, as are the presets named in opts.
+func ExportBundle(ctx context.Context, db *storage.DB, opts ExportOptions) (*Bundle, error) {
+	bundle := &Bundle{Version: BundleVersion, ExportedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	var rules []*storage.AutomationRule
+	if len(opts.Rules) == 0 {
+		all, err := db.ListAutomationRules(ctx, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list automation rules: %w", err)
+		}
+		rules = all
+	} else {
+		for _, name := range opts.Rules {
+			rule, err := db.GetAutomationRuleByName(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get automation rule: %w", err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	var refs bundleRefs
+	for _, rule := range rules {
+		actions, err := bundleActions(rule.Actions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export automation rule '%s': %w", rule.Name, err)
+		}
+		config := rule.TriggerConfig
+		if !opts.IncludeSecrets {
+			config = withoutSecret(config)
+		}
+		bundle.Rules = append(bundle.Rules, BundleRule{
+			Name:          rule.Name,
+			Description:   rule.Description,
+			Enabled:       rule.Enabled,
+			TriggerType:   rule.TriggerType,
+			TriggerConfig: config,
+			Actions:       actions,
+			Condition:     rule.Condition,
+			Concurrency:   rule.Concurrency,
+			CooldownMs:    rule.CooldownMs,
+			Priority:      rule.Priority,
+		})
+		refs.collect(actions)
+	}
+
+	macros, err := exportMacros(ctx, db, &refs, len(opts.Rules) == 0)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Macros = macros
+
+	presets, err := exportPresets(ctx, db, refs.scenes, opts.Presets)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Presets = presets
+
+	return bundle, nil
+}
+
+// exportMacros returns the macros the collected actions call, following
+// calls between macros. It returns every macro when all is set or when a
+// call names its macro with a template.
+func exportMacros(ctx context.Context, db *storage.DB, refs *bundleRefs, all bool) ([]BundleMacro, error) {
+	stored, err := db.ListMacros(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list macros: %w", err)
+	}
+	byName := make(map[string]*storage.Macro, len(stored))
+	for _, macro := range stored {
+		byName[macro.Name] = macro
+	}
+
+	included := make(map[string]bool)
+	queue := slices.Clone(refs.macros)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if included[name] {
+			continue
+		}
+		if strings.Contains(name, "{{") {
+			all = true
+			break
+		}
+		macro, ok := byName[name]
+		if !ok {
+			// Calls to macros that do not exist fail at run time; the
+			// import reports nothing for them either
+			continue
+		}
+		included[name] = true
+		before := len(refs.macros)
+		actions, err := bundleActions(macro.Actions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export macro '%s': %w", name, err)
+		}
+		refs.collect(actions)
+		queue = append(queue, refs.macros[before:]...)
+	}
+
+	var macros []BundleMacro
+	for _, macro := range stored {
+		if !all && !included[macro.Name] {
+			continue
+		}
+		actions, err := bundleActions(macro.Actions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export macro '%s': %w", macro.Name, err)
+		}
+		if all && !included[macro.Name] {
+			refs.collect(actions)
+		}
+		macros = append(macros, BundleMacro{
+			Name:        macro.Name,
+			Description: macro.Description,
+			Parameters:  macro.Parameters,
+			Actions:     actions,
+		})
+	}
+	return macros, nil
+}
+
+// exportPresets returns the scene presets for the given scenes and the
+// presets named in extra.
+func exportPresets(ctx context.Context, db *storage.DB, scenes, extra []string) ([]BundlePreset, error) {
+	stored, err := db.ListScenePresets(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scene presets: %w", err)
+	}
+
+	var presets []BundlePreset
+	for _, name := range extra {
+		if !slices.ContainsFunc(stored, func(p *storage.ScenePreset) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("scene preset '%s' not found", name)
+		}
+	}
+	for _, preset := range stored {
+		if !slices.Contains(scenes, preset.SceneName) && !slices.Contains(extra, preset.Name) {
+			continue
+		}
+		presets = append(presets, BundlePreset{Name: preset.Name, SceneName: preset.SceneName, Sources: preset.Sources})
+	}
+	return presets, nil
+}
+
+// bundleActions converts stored actions to the map form
+// create_automation_rule takes.
+func bundleActions(actions []storage.RuleAction) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize actions: %w", err)
+	}
+	var maps []map[string]interface{}
+	if err := json.Unmarshal(data, &maps); err != nil {
+		return nil, fmt.Errorf("failed to serialize actions: %w", err)
+	}
+	return maps, nil
+}
+
+// withoutSecret returns config without its webhook secret.
+func withoutSecret(config map[string]interface{}) map[string]interface{} {
+	if _, ok := config["secret"]; !ok {
+		return config
+	}
+	out := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		if k != "secret" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// EncodeBundle serializes a bundle as JSON or YAML.
+func EncodeBundle(bundle *Bundle, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize bundle: %w", err)
+	}
+
+	switch format {
+	case "", BundleFormatJSON:
+		return append(data, '\n'), nil
+	case BundleFormatYAML:
+		// JSON is valid YAML: parsing it keeps the field order, and
+		// clearing the flow styles turns it into block YAML
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to serialize bundle: %w", err)
+		}
+		clearYAMLStyle(&node)
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, fmt.Errorf("failed to serialize bundle: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("invalid format '%s'. Must be 'json' or 'yaml'", format)
+	}
+}
+
+// clearYAMLStyle resets the style of node and its children, so the encoder
+// picks block style and quotes strings only where needed.
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// DecodeBundle parses a JSON or YAML bundle and checks its version.
+func DecodeBundle(data []byte) (*Bundle, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("bundle is empty")
+	}
+
+	// YAML is decoded generically and converted to JSON, so both formats
+	// produce the same value types (float64 numbers, string-keyed maps)
+	if !json.Valid(data) {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("bundle is neither valid JSON nor valid YAML: %w", err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read YAML bundle: %w", err)
+		}
+		data = converted
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if bundle.Version == 0 {
+		return nil, fmt.Errorf("invalid bundle: missing 'version'")
+	}
+	if bundle.Version > BundleVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than the supported version %d", bundle.Version, BundleVersion)
+	}
+	return &bundle, nil
+}
+
+// bundleTarget is where an imported item goes.
+type bundleTarget struct {
+	name       string
+	existingID int64
+	status     string
+}
+
+// bundleNames decides where the items of one kind are imported. exists
+// reports whether a name is taken in storage and returns the ID of the item
+// holding it. It returns the target of each name, in order.
+func bundleNames(names []string, strategy string, exists func(string) (int64, bool)) []bundleTarget {
+	targets := make([]bundleTarget, len(names))
+	taken := make(map[string]bool)
+	for i, name := range names {
+		id, found := exists(name)
+		switch {
+		case taken[name]:
+			targets[i] = bundleTarget{name: name, status: BundleItemFailed}
+		case !found:
+			targets[i] = bundleTarget{name: name, status: BundleItemCreated}
+		case strategy == ConflictOverwrite:
+			targets[i] = bundleTarget{name: name, existingID: id, status: BundleItemOverwritten}
+		case strategy == ConflictRename:
+			var free string
+			for n := 2; ; n++ {
+				free = fmt.Sprintf("%s (%d)", name, n)
+				if _, found := exists(free); !found && !taken[free] && !slices.Contains(names, free) {
+					break
+				}
+			}
+			targets[i] = bundleTarget{name: free, status: BundleItemRenamed}
+		default:
+			targets[i] = bundleTarget{name: name, existingID: id, status: BundleItemSkipped}
+		}
+		taken[name] = true
+		taken[targets[i].name] = true
+	}
+	return targets
+}
+
+// ImportBundle validates a bundle and writes its macros, scene presets and
+// rules to storage, resolving name conflicts with opts.Strategy. Items that
+// fail validation are reported and left out; the others are still imported.
+// Calls to macros renamed on import are updated to the new names.
+func ImportBundle(ctx context.Context, db *storage.DB, bundle *Bundle, opts ImportOptions) (*BundleImportResult, error) {
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = ConflictSkip
+	}
+	if !slices.Contains(SupportedConflictStrategies(), strategy) {
+		return nil, fmt.Errorf("invalid strategy '%s'. Valid strategies: %v", strategy, SupportedConflictStrategies())
+	}
+
+	result := &BundleImportResult{DryRun: opts.DryRun, Strategy: strategy, Items: []BundleItemResult{}}
+	add := func(kind, name string, target bundleTarget, err error) {
+		item := BundleItemResult{Kind: kind, Name: name, Status: target.status}
+		if target.status == BundleItemRenamed {
+			item.ImportedAs = target.name
+		}
+		if err != nil {
+			item.Status = BundleItemFailed
+			item.Error = err.Error()
+		}
+		switch item.Status {
+		case BundleItemFailed:
+			result.Failed++
+		case BundleItemSkipped:
+			result.Skipped++
+		default:
+			result.Imported++
+		}
+		result.Items = append(result.Items, item)
+	}
+	duplicate := func(kind, name string) error {
+		return fmt.Errorf("%s '%s' appears more than once in the bundle", kind, name)
+	}
+
+	// Macros first, so the rules can be pointed at renamed macros
+	macroNames := make([]string, len(bundle.Macros))
+	for i, m := range bundle.Macros {
+		macroNames[i] = m.Name
+	}
+	macroTargets := bundleNames(macroNames, strategy, func(name string) (int64, bool) {
+		macro, err := db.GetMacroByName(ctx, name)
+		if err != nil {
+			return 0, false
+		}
+		return macro.ID, true
+	})
+	renames := make(map[string]string)
+	for i, target := range macroTargets {
+		if target.status == BundleItemRenamed {
+			renames[macroNames[i]] = target.name
+		}
+	}
+
+	for i, m := range bundle.Macros {
+		target := macroTargets[i]
+		if target.status == BundleItemFailed {
+			add("macro", m.Name, target, duplicate("macro", m.Name))
+			continue
+		}
+		if target.status == BundleItemSkipped {
+			add("macro", m.Name, target, nil)
+			continue
+		}
+
+		params := make([]MacroParameterInput, len(m.Parameters))
+		for j, p := range m.Parameters {
+			params[j] = MacroParameterInput(p)
+		}
+		macro, err := buildMacro(CreateMacroInput{
+			Name:        target.name,
+			Description: m.Description,
+			Parameters:  params,
+			Actions:     renameMacroCalls(m.Actions, renames),
+		})
+		if err == nil && !opts.DryRun {
+			if target.status == BundleItemOverwritten {
+				macro.ID = target.existingID
+				err = db.UpdateMacro(ctx, macro)
+			} else {
+				_, err = db.CreateMacro(ctx, macro)
+			}
+		}
+		add("macro", m.Name, target, err)
+	}
+
+	presetNames := make([]string, len(bundle.Presets))
+	for i, p := range bundle.Presets {
+		presetNames[i] = p.Name
+	}
+	presetTargets := bundleNames(presetNames, strategy, func(name string) (int64, bool) {
+		preset, err := db.GetScenePreset(ctx, name)
+		if err != nil {
+			return 0, false
+		}
+		return preset.ID, true
+	})
+	for i, p := range bundle.Presets {
+		target := presetTargets[i]
+		var err error
+		switch {
+		case target.status == BundleItemFailed:
+			err = duplicate("preset", p.Name)
+		case target.status == BundleItemSkipped:
+		case strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.SceneName) == "":
+			err = fmt.Errorf("scene preset requires a 'name' and a 'scene_name'")
+		case opts.DryRun:
+		case target.status == BundleItemOverwritten:
+			err = db.UpdateScenePreset(ctx, storage.ScenePreset{Name: target.name, SceneName: p.SceneName, Sources: p.Sources})
+		default:
+			_, err = db.CreateScenePreset(ctx, storage.ScenePreset{Name: target.name, SceneName: p.SceneName, Sources: p.Sources})
+		}
+		add("preset", p.Name, target, err)
+	}
+
+	ruleNames := make([]string, len(bundle.Rules))
+	for i, r := range bundle.Rules {
+		ruleNames[i] = r.Name
+	}
+	ruleTargets := bundleNames(ruleNames, strategy, func(name string) (int64, bool) {
+		rule, err := db.GetAutomationRuleByName(ctx, name)
+		if err != nil {
+			return 0, false
+		}
+		return rule.ID, true
+	})
+	for i, r := range bundle.Rules {
+		target := ruleTargets[i]
+		if target.status == BundleItemFailed {
+			add("rule", r.Name, target, duplicate("rule", r.Name))
+			continue
+		}
+		if target.status == BundleItemSkipped {
+			add("rule", r.Name, target, nil)
+			continue
+		}
+
+		enabled := r.Enabled
+		rule, err := buildAutomationRule(CreateAutomationRuleInput{
+			Name:          target.name,
+			Description:   r.Description,
+			TriggerType:   r.TriggerType,
+			TriggerConfig: r.TriggerConfig,
+			Actions:       renameMacroCalls(r.Actions, renames),
+			Condition:     r.Condition,
+			Concurrency:   r.Concurrency,
+			CooldownMs:    r.CooldownMs,
+			Priority:      r.Priority,
+			Enabled:       &enabled,
+		})
+		if err == nil && !opts.DryRun {
+			if target.status == BundleItemOverwritten {
+				rule.ID = target.existingID
+				err = db.UpdateAutomationRule(ctx, rule)
+			} else {
+				rule.ID, err = db.CreateAutomationRule(ctx, rule)
+			}
+			if err == nil {
+				result.ruleIDs = append(result.ruleIDs, rule.ID)
+			}
+		}
+		add("rule", r.Name, target, err)
+	}
+
+	if opts.OBS != nil {
+		result.ReferencesChecked = checkBundleReferences(bundle, opts.OBS, result)
+	}
+
+	verb := "Imported"
+	if opts.DryRun {
+		verb = "Dry run: would import"
+	}
+	result.Message = fmt.Sprintf("%s %d items; %d skipped, %d failed", verb, result.Imported, result.Skipped, result.Failed)
+	if n := len(result.MissingScenes) + len(result.MissingInputs); n > 0 {
+		result.Message += fmt.Sprintf("; %d referenced scenes or inputs are missing in OBS", n)
+	}
+	return result, nil
+}
+
+// renameMacroCalls returns a copy of actions with run_macro calls to renamed
+// macros pointed at their new names.
+func renameMacroCalls(actions []map[string]interface{}, renames map[string]string) []map[string]interface{} {
+	if len(renames) == 0 || actions == nil {
+		return actions
+	}
+	out := make([]map[string]interface{}, len(actions))
+	for i, action := range actions {
+		out[i] = renameMacroCallsIn(action, renames).(map[string]interface{})
+	}
+	return out
+}
+
+// renameMacroCallsIn copies v, renaming the macro of run_macro actions found
+// in it.
+func renameMacroCallsIn(v interface{}, renames map[string]string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = renameMacroCallsIn(item, renames)
+		}
+		if out["type"] == automation.ActionTypeRunMacro {
+			if params, ok := out["parameters"].(map[string]interface{}); ok {
+				if name, ok := params["macro"].(string); ok && renames[name] != "" {
+					params["macro"] = renames[name]
+				}
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = renameMacroCallsIn(item, renames)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// bundleRefs collects the names actions refer to.
+type bundleRefs struct {
+	scenes []string
+	inputs []string
+	macros []string
+}
+
+// collect adds the scenes, inputs and macros named in actions and their
+// nested action lists. Templated names are skipped, except for macros.
+func (r *bundleRefs) collect(actions []map[string]interface{}) {
+	for _, action := range actions {
+		params, _ := action["parameters"].(map[string]interface{})
+		for key, list := range map[string]*[]string{"scene_name": &r.scenes, "input_name": &r.inputs} {
+			if name, ok := params[key].(string); ok && name != "" && !strings.Contains(name, "{{") && !slices.Contains(*list, name) {
+				*list = append(*list, name)
+			}
+		}
+		if action["type"] == automation.ActionTypeRunMacro {
+			if name, ok := params["macro"].(string); ok && !slices.Contains(r.macros, name) {
+				r.macros = append(r.macros, name)
+			}
+		}
+
+		for _, key := range []string{"then", "else", "actions", "fallback"} {
+			r.collect(nestedActionMaps(action[key]))
+		}
+		if branches, ok := action["branches"].([]interface{}); ok {
+			for _, branch := range branches {
+				r.collect(nestedActionMaps(branch))
+			}
+		}
+	}
+}
+
+// nestedActionMaps returns the actions in a decoded nested action list.
+func nestedActionMaps(raw interface{}) []map[string]interface{} {
+	items, _ := raw.([]interface{})
+	var actions []map[string]interface{}
+	for _, item := range items {
+		if action, ok := item.(map[string]interface{}); ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// checkBundleReferences looks up the scenes and inputs the bundle refers to
+// in OBS and records the missing ones in result. Preset sources may be
+// inputs or nested scenes. It returns false when OBS could not be queried.
+func checkBundleReferences(bundle *Bundle, catalog BundleCatalog, result *BundleImportResult) bool {
+	scenes, _, err := catalog.GetSceneList()
+	if err != nil {
+		return false
+	}
+	inputList, err := catalog.ListSources()
+	if err != nil {
+		return false
+	}
+	inputs := make([]string, len(inputList))
+	for i, input := range inputList {
+		inputs[i] = input.InputName
+	}
+
+	var refs bundleRefs
+	for _, rule := range bundle.Rules {
+		refs.collect(rule.Actions)
+	}
+	for _, macro := range bundle.Macros {
+		refs.collect(macro.Actions)
+	}
+
+	missingScenes := make(map[string]bool)
+	missingInputs := make(map[string]bool)
+	for _, scene := range refs.scenes {
+		if !slices.Contains(scenes, scene) {
+			missingScenes[scene] = true
+		}
+	}
+	for _, input := range refs.inputs {
+		if !slices.Contains(inputs, input) {
+			missingInputs[input] = true
+		}
+	}
+	for _, preset := range bundle.Presets {
+		if !slices.Contains(scenes, preset.SceneName) {
+			missingScenes[preset.SceneName] = true
+		}
+		for _, source := range preset.Sources {
+			if !slices.Contains(inputs, source.Name) && !slices.Contains(scenes, source.Name) {
+				missingInputs[source.Name] = true
+			}
+		}
+	}
+
+	result.MissingScenes = sortedKeys(missingScenes)
+	result.MissingInputs = sortedKeys(missingInputs)
+	return true
+}
+
+// sortedKeys returns the keys of set in order, or nil when it is empty.
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
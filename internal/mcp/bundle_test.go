@@ -0,0 +1,258 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedBundleSource stores a rule calling a macro, an unrelated rule, a
+// webhook rule and two presets.
+func seedBundleSource(t *testing.T, db *storage.DB) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := db.CreateMacro(ctx, storage.Macro{
+		Name: "go-to",
+		Actions: []storage.RuleAction{
+			{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "BRB"}},
+			{Type: automation.ActionTypeSetMute, Parameters: map[string]interface{}{"input_name": "Guest Mic", "muted": true}},
+		},
+	})
+	require.NoError(t, err)
+
+	rules := []storage.AutomationRule{
+		{
+			Name:          "break",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions: []storage.RuleAction{
+				{Type: automation.ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": "go-to"}},
+				{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Gaming"}},
+			},
+		},
+		{
+			Name:          "hook",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeWebhook,
+			TriggerConfig: map[string]interface{}{"secret": "0123456789abcdef0123"},
+			Actions:       []storage.RuleAction{{Type: automation.ActionTypeStartRecording}},
+		},
+	}
+	for _, rule := range rules {
+		_, err := db.CreateAutomationRule(ctx, rule)
+		require.NoError(t, err)
+	}
+
+	for _, preset := range []storage.ScenePreset{
+		{Name: "gaming-layout", SceneName: "Gaming", Sources: []storage.SourceState{{Name: "Webcam", Visible: true}}},
+		{Name: "other", SceneName: "Scene 2"},
+	} {
+		_, err := db.CreateScenePreset(ctx, preset)
+		require.NoError(t, err)
+	}
+}
+
+func TestExportBundle(t *testing.T) {
+	_, _, db := testServerWithStorage(t)
+	seedBundleSource(t, db)
+	ctx := context.Background()
+
+	t.Run("selected rule brings its macros and presets", func(t *testing.T) {
+		bundle, err := ExportBundle(ctx, db, ExportOptions{Rules: []string{"break"}})
+		require.NoError(t, err)
+		assert.Equal(t, BundleVersion, bundle.Version)
+		require.Len(t, bundle.Rules, 1)
+		require.Len(t, bundle.Macros, 1)
+		assert.Equal(t, "go-to", bundle.Macros[0].Name)
+		require.Len(t, bundle.Presets, 1)
+		assert.Equal(t, "gaming-layout", bundle.Presets[0].Name)
+	})
+
+	t.Run("named presets are added", func(t *testing.T) {
+		bundle, err := ExportBundle(ctx, db, ExportOptions{Rules: []string{"break"}, Presets: []string{"other"}})
+		require.NoError(t, err)
+		assert.Len(t, bundle.Presets, 2)
+
+		_, err = ExportBundle(ctx, db, ExportOptions{Presets: []string{"missing"}})
+		assert.ErrorContains(t, err, "scene preset 'missing' not found")
+	})
+
+	t.Run("webhook secrets are left out by default", func(t *testing.T) {
+		bundle, err := ExportBundle(ctx, db, ExportOptions{Rules: []string{"hook"}})
+		require.NoError(t, err)
+		assert.NotContains(t, bundle.Rules[0].TriggerConfig, "secret")
+
+		bundle, err = ExportBundle(ctx, db, ExportOptions{Rules: []string{"hook"}, IncludeSecrets: true})
+		require.NoError(t, err)
+		assert.Equal(t, "0123456789abcdef0123", bundle.Rules[0].TriggerConfig["secret"])
+	})
+
+	t.Run("unknown rule", func(t *testing.T) {
+		_, err := ExportBundle(ctx, db, ExportOptions{Rules: []string{"nope"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestEncodeDecodeBundle(t *testing.T) {
+	_, _, db := testServerWithStorage(t)
+	seedBundleSource(t, db)
+
+	bundle, err := ExportBundle(context.Background(), db, ExportOptions{IncludeSecrets: true})
+	require.NoError(t, err)
+
+	for _, format := range []string{BundleFormatJSON, BundleFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := EncodeBundle(bundle, format)
+			require.NoError(t, err)
+
+			decoded, err := DecodeBundle(data)
+			require.NoError(t, err)
+			assert.Equal(t, bundle, decoded)
+		})
+	}
+
+	_, err = EncodeBundle(bundle, "xml")
+	assert.ErrorContains(t, err, "invalid format 'xml'")
+
+	_, err = DecodeBundle(nil)
+	assert.ErrorContains(t, err, "bundle is empty")
+
+	_, err = DecodeBundle([]byte(`{"version": 99, "rules": []}`))
+	assert.ErrorContains(t, err, "newer than the supported version")
+
+	_, err = DecodeBundle([]byte("rules: []\n"))
+	assert.Error(t, err)
+}
+
+func TestImportBundle(t *testing.T) {
+	_, _, source := testServerWithStorage(t)
+	seedBundleSource(t, source)
+	bundle, err := ExportBundle(context.Background(), source, ExportOptions{Rules: []string{"break"}})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("creates items and reports missing references", func(t *testing.T) {
+		_, mock, db := testServerWithStorage(t)
+		result, err := ImportBundle(ctx, db, bundle, ImportOptions{OBS: mock})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Imported)
+		assert.Zero(t, result.Failed)
+		assert.True(t, result.ReferencesChecked)
+		assert.Equal(t, []string{"BRB"}, result.MissingScenes)
+		assert.Equal(t, []string{"Guest Mic"}, result.MissingInputs)
+		assert.Contains(t, result.Message, "missing")
+
+		rule, err := db.GetAutomationRuleByName(ctx, "break")
+		require.NoError(t, err)
+		assert.Equal(t, "go-to", rule.Actions[0].Parameters["macro"])
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		_, _, db := testServerWithStorage(t)
+		result, err := ImportBundle(ctx, db, bundle, ImportOptions{DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 3, result.Imported)
+		assert.False(t, result.ReferencesChecked)
+
+		rules, err := db.ListAutomationRules(ctx, false)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("conflict strategies", func(t *testing.T) {
+		_, _, db := testServerWithStorage(t)
+		_, err := ImportBundle(ctx, db, bundle, ImportOptions{})
+		require.NoError(t, err)
+		original, err := db.GetAutomationRuleByName(ctx, "break")
+		require.NoError(t, err)
+
+		result, err := ImportBundle(ctx, db, bundle, ImportOptions{Strategy: ConflictSkip})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Skipped)
+		assert.Zero(t, result.Imported)
+
+		result, err = ImportBundle(ctx, db, bundle, ImportOptions{Strategy: ConflictOverwrite})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Imported)
+		for _, item := range result.Items {
+			assert.Equal(t, BundleItemOverwritten, item.Status, item.Name)
+		}
+		overwritten, err := db.GetAutomationRuleByName(ctx, "break")
+		require.NoError(t, err)
+		assert.Equal(t, original.ID, overwritten.ID)
+
+		result, err = ImportBundle(ctx, db, bundle, ImportOptions{Strategy: ConflictRename})
+		require.NoError(t, err)
+		for _, item := range result.Items {
+			assert.Equal(t, BundleItemRenamed, item.Status, item.Name)
+			assert.Equal(t, item.Name+" (2)", item.ImportedAs)
+		}
+
+		// The renamed rule calls the renamed macro
+		renamed, err := db.GetAutomationRuleByName(ctx, "break (2)")
+		require.NoError(t, err)
+		assert.Equal(t, "go-to (2)", renamed.Actions[0].Parameters["macro"])
+
+		_, err = ImportBundle(ctx, db, bundle, ImportOptions{Strategy: "merge"})
+		assert.ErrorContains(t, err, "merge")
+	})
+
+	t.Run("invalid items fail alone", func(t *testing.T) {
+		_, _, db := testServerWithStorage(t)
+		bad := &Bundle{Version: BundleVersion, Rules: []BundleRule{
+			{Name: "ok", TriggerType: automation.TriggerTypeManual, TriggerConfig: map[string]interface{}{},
+				Actions: []map[string]interface{}{{"type": automation.ActionTypeStartRecording}}},
+			{Name: "bad-action", TriggerType: automation.TriggerTypeManual, TriggerConfig: map[string]interface{}{},
+				Actions: []map[string]interface{}{{"type": "explode"}}},
+			{Name: "bad-event", TriggerType: automation.TriggerTypeEvent, TriggerConfig: map[string]interface{}{"event_type": "NoSuchEvent"},
+				Actions: []map[string]interface{}{{"type": automation.ActionTypeStartRecording}}},
+		}}
+		result, err := ImportBundle(ctx, db, bad, ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Imported)
+		assert.Equal(t, 2, result.Failed)
+		assert.Equal(t, BundleItemFailed, result.Items[1].Status)
+		assert.NotEmpty(t, result.Items[1].Error)
+	})
+}
+
+func TestAutomationBundleTools(t *testing.T) {
+	server, db := testServerWithAutomation(t)
+	seedBundleSource(t, db)
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, nil)
+
+	res := callTool(t, session, "export_automation_bundle", map[string]any{"rules": []string{"break"}, "format": "yaml"})
+	require.False(t, res.IsError, toolResultText(res))
+	var exported BundleExportResult
+	data, err := json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, 1, exported.RuleCount)
+	assert.Contains(t, exported.Bundle, "version: 1")
+
+	res = callTool(t, session, "import_automation_bundle", map[string]any{"bundle": exported.Bundle, "strategy": "rename"})
+	require.False(t, res.IsError, toolResultText(res))
+	var imported BundleImportResult
+	data, err = json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &imported))
+	assert.Equal(t, 3, imported.Imported)
+	assert.True(t, imported.ReferencesChecked)
+
+	// The imported rule is loaded into the running engine
+	_, err = server.automationEngine.ExecuteRuleByName(context.Background(), "break (2)", nil)
+	assert.NoError(t, err)
+
+	res = callTool(t, session, "import_automation_bundle", map[string]any{"bundle": "not a bundle"})
+	assert.True(t, res.IsError)
+}
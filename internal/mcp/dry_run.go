@@ -0,0 +1,604 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/obs"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Dry-run support for mutating tools.
+//
+// A tool called with dry_run set validates its input against live OBS state
+// (or storage, for automation rules), returns the changes it would make as a
+// DryRunResult, and never calls the mutating OBSClient or storage method.
+// Each planner below reads only what its handler would change.
+
+// dryRunResult finishes a dry-run call: it records the call in action history
+// with the dry-run flag and returns either the planned changes or the
+// validation error.
+func (s *Server) dryRunResult(ctx context.Context, toolName, action string, input interface{}, changes []PlannedChange, err error, start time.Time) (*mcpsdk.CallToolResult, any, error) {
+	if err != nil {
+		s.recordDryRun(ctx, toolName, action, input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("dry run failed: %w", err)
+	}
+
+	if changes == nil {
+		changes = []PlannedChange{}
+	}
+	result := &DryRunResult{
+		DryRun:  true,
+		Tool:    toolName,
+		Changes: changes,
+		Message: fmt.Sprintf("Dry run: %d change(s) planned; nothing was applied", len(changes)),
+	}
+	log.Printf("Dry run of %s: %d change(s) planned", toolName, len(changes))
+	s.recordDryRun(ctx, toolName, action, input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// diffFields compares the JSON forms of before and after and returns an
+// update for every top-level field that differs, in field-name order.
+// Comparing JSON forms keeps numbers from OBS and from tool input comparable.
+// A non-empty prefix is prepended to field names (e.g. "settings.sharpness").
+func diffFields(target, prefix string, before, after interface{}) ([]PlannedChange, error) {
+	b, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []PlannedChange
+	for _, k := range keys {
+		if reflect.DeepEqual(b[k], a[k]) {
+			continue
+		}
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		changes = append(changes, PlannedChange{Op: "update", Target: target, Field: field, Before: b[k], After: a[k]})
+	}
+	return changes, nil
+}
+
+// jsonFields round-trips v through JSON into a field map.
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode state for comparison: %w", err)
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode state for comparison: %w", err)
+	}
+	return fields, nil
+}
+
+// sceneItemTarget names a scene item in planned changes.
+func sceneItemTarget(sceneName string, sceneItemID int) string {
+	return fmt.Sprintf("scene_item:%s/%d", sceneName, sceneItemID)
+}
+
+// findSceneItem looks up a scene item and its position in the scene.
+func (s *Server) findSceneItem(sceneName string, sceneItemID int) (*obs.SceneSource, int, []obs.SceneSource, error) {
+	scene, err := s.obsClient.GetSceneByName(sceneName)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to get scene '%s': %w", sceneName, err)
+	}
+	for i := range scene.Sources {
+		if scene.Sources[i].ID == sceneItemID {
+			return &scene.Sources[i], i, scene.Sources, nil
+		}
+	}
+	return nil, 0, nil, fmt.Errorf("scene item %d not found in scene '%s'", sceneItemID, sceneName)
+}
+
+// Scene planners
+
+func (s *Server) planSetCurrentScene(input SceneNameInput) ([]PlannedChange, error) {
+	scenes, current, err := s.obsClient.GetSceneList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene list: %w", err)
+	}
+	if !slices.Contains(scenes, input.SceneName) {
+		return nil, fmt.Errorf("scene '%s' not found", input.SceneName)
+	}
+	if current == input.SceneName {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: "current_scene", Before: current, After: input.SceneName}}, nil
+}
+
+func (s *Server) planCreateScene(input SceneNameInput) ([]PlannedChange, error) {
+	if input.SceneName == "" {
+		return nil, fmt.Errorf("scene_name is required")
+	}
+	scenes, _, err := s.obsClient.GetSceneList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene list: %w", err)
+	}
+	if slices.Contains(scenes, input.SceneName) {
+		return nil, fmt.Errorf("scene '%s' already exists", input.SceneName)
+	}
+	return []PlannedChange{{Op: "create", Target: "scene:" + input.SceneName}}, nil
+}
+
+func (s *Server) planRemoveScene(input SceneNameInput) ([]PlannedChange, error) {
+	scene, err := s.obsClient.GetSceneByName(input.SceneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene '%s': %w", input.SceneName, err)
+	}
+	sources := make([]string, len(scene.Sources))
+	for i, src := range scene.Sources {
+		sources[i] = src.Name
+	}
+	return []PlannedChange{{
+		Op:     "delete",
+		Target: "scene:" + input.SceneName,
+		Before: map[string]interface{}{"sources": sources},
+	}}, nil
+}
+
+// Source and audio planners
+
+func (s *Server) planToggleSourceVisibility(input SourceVisibilityInput) ([]PlannedChange, error) {
+	item, _, _, err := s.findSceneItem(input.SceneName, int(input.SourceID))
+	if err != nil {
+		return nil, err
+	}
+	return []PlannedChange{{
+		Op:     "update",
+		Target: sceneItemTarget(input.SceneName, item.ID),
+		Field:  "visible",
+		Before: item.Enabled,
+		After:  !item.Enabled,
+	}}, nil
+}
+
+func (s *Server) planToggleInputMute(input InputNameInput) ([]PlannedChange, error) {
+	muted, err := s.obsClient.GetInputMute(input.InputName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input mute status: %w", err)
+	}
+	return []PlannedChange{{Op: "update", Target: "input:" + input.InputName, Field: "muted", Before: muted, After: !muted}}, nil
+}
+
+func (s *Server) planSetInputVolume(input SetVolumeInput) ([]PlannedChange, error) {
+	if input.VolumeDb == nil && input.VolumeMul == nil {
+		return nil, fmt.Errorf("either volume_db or volume_mul is required")
+	}
+	volumeMul, volumeDb, err := s.obsClient.GetInputVolume(input.InputName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input volume: %w", err)
+	}
+
+	target := "input:" + input.InputName
+	var changes []PlannedChange
+	if input.VolumeDb != nil && *input.VolumeDb != volumeDb {
+		changes = append(changes, PlannedChange{Op: "update", Target: target, Field: "volume_db", Before: volumeDb, After: *input.VolumeDb})
+	}
+	if input.VolumeMul != nil && *input.VolumeMul != volumeMul {
+		changes = append(changes, PlannedChange{Op: "update", Target: target, Field: "volume_mul", Before: volumeMul, After: *input.VolumeMul})
+	}
+	return changes, nil
+}
+
+// planCreateInput validates a new input against the target scene, the
+// existing source names, and the input kinds this OBS instance supports.
+func (s *Server) planCreateInput(sceneName, sourceName, inputKind string, settings map[string]interface{}) ([]PlannedChange, error) {
+	if sourceName == "" {
+		return nil, fmt.Errorf("source_name is required")
+	}
+	if _, err := s.obsClient.GetSceneByName(sceneName); err != nil {
+		return nil, fmt.Errorf("failed to get scene '%s': %w", sceneName, err)
+	}
+
+	// Scenes and inputs share one namespace in OBS
+	scenes, _, err := s.obsClient.GetSceneList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene list: %w", err)
+	}
+	if slices.Contains(scenes, sourceName) {
+		return nil, fmt.Errorf("a scene named '%s' already exists", sourceName)
+	}
+	inputs, err := s.obsClient.ListSources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	for _, in := range inputs {
+		if in.InputName == sourceName {
+			return nil, fmt.Errorf("source '%s' already exists", sourceName)
+		}
+	}
+
+	kinds, err := s.obsClient.GetInputKindList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input kinds: %w", err)
+	}
+	if !slices.Contains(kinds, inputKind) {
+		return nil, fmt.Errorf("input kind '%s' is not available in this OBS instance", inputKind)
+	}
+
+	return []PlannedChange{{
+		Op:     "create",
+		Target: "input:" + sourceName,
+		After: map[string]interface{}{
+			"scene_name": sceneName,
+			"input_kind": inputKind,
+			"settings":   settings,
+		},
+	}}, nil
+}
+
+func (s *Server) planDuplicateSource(input DuplicateSourceInput, destScene string) ([]PlannedChange, error) {
+	item, _, _, err := s.findSceneItem(input.SceneName, input.SceneItemID)
+	if err != nil {
+		return nil, err
+	}
+	if destScene != input.SceneName {
+		if _, err := s.obsClient.GetSceneByName(destScene); err != nil {
+			return nil, fmt.Errorf("failed to get scene '%s': %w", destScene, err)
+		}
+	}
+	return []PlannedChange{{
+		Op:     "create",
+		Target: "scene_item:" + destScene,
+		After: map[string]interface{}{
+			"source_name":    item.Name,
+			"source_scene":   input.SceneName,
+			"source_item_id": input.SceneItemID,
+		},
+	}}, nil
+}
+
+func (s *Server) planRemoveSource(input RemoveSourceInput) ([]PlannedChange, error) {
+	item, _, _, err := s.findSceneItem(input.SceneName, input.SceneItemID)
+	if err != nil {
+		return nil, err
+	}
+	return []PlannedChange{{Op: "delete", Target: sceneItemTarget(input.SceneName, input.SceneItemID), Before: item}}, nil
+}
+
+func (s *Server) planSetSourceOrder(input SetSourceOrderInput) ([]PlannedChange, error) {
+	_, index, items, err := s.findSceneItem(input.SceneName, input.SceneItemID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Index < 0 || input.Index >= len(items) {
+		return nil, fmt.Errorf("invalid index %d for scene with %d items", input.Index, len(items))
+	}
+	if index == input.Index {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: sceneItemTarget(input.SceneName, input.SceneItemID), Field: "index", Before: index, After: input.Index}}, nil
+}
+
+func (s *Server) planSetSourceLocked(input SetSourceLockedInput) ([]PlannedChange, error) {
+	locked, err := s.obsClient.GetSceneItemLocked(input.SceneName, input.SceneItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locked state: %w", err)
+	}
+	if locked == input.Locked {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: sceneItemTarget(input.SceneName, input.SceneItemID), Field: "locked", Before: locked, After: input.Locked}}, nil
+}
+
+// Transform planners
+
+// planTransform diffs the current transform of a scene item against the
+// result of applying update to a copy of it.
+func (s *Server) planTransform(sceneName string, sceneItemID int, update func(*obs.SceneItemTransform)) ([]PlannedChange, error) {
+	current, err := s.obsClient.GetSceneItemTransform(sceneName, sceneItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current transform: %w", err)
+	}
+	updated := *current
+	update(&updated)
+	return diffFields(sceneItemTarget(sceneName, sceneItemID), "", current, &updated)
+}
+
+// validBoundsTypes lists the bounds types accepted by OBS.
+var validBoundsTypes = []string{
+	"OBS_BOUNDS_NONE", "OBS_BOUNDS_STRETCH", "OBS_BOUNDS_SCALE_INNER", "OBS_BOUNDS_SCALE_OUTER",
+	"OBS_BOUNDS_SCALE_TO_WIDTH", "OBS_BOUNDS_SCALE_TO_HEIGHT", "OBS_BOUNDS_MAX_ONLY",
+}
+
+func (s *Server) planSetSourceBounds(input SetSourceBoundsInput) ([]PlannedChange, error) {
+	if !slices.Contains(validBoundsTypes, input.BoundsType) {
+		return nil, fmt.Errorf("invalid bounds_type '%s'. Valid types: %v", input.BoundsType, validBoundsTypes)
+	}
+	return s.planTransform(input.SceneName, input.SceneItemID, func(t *obs.SceneItemTransform) {
+		applySourceBounds(t, input)
+	})
+}
+
+// Filter planners
+
+func filterTarget(sourceName, filterName string) string {
+	return fmt.Sprintf("filter:%s/%s", sourceName, filterName)
+}
+
+func (s *Server) planCreateSourceFilter(input CreateSourceFilterInput) ([]PlannedChange, error) {
+	filters, err := s.obsClient.GetSourceFilterList(input.SourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list filters: %w", err)
+	}
+	for _, f := range filters {
+		if f.Name == input.FilterName {
+			return nil, fmt.Errorf("filter '%s' already exists on source '%s'", input.FilterName, input.SourceName)
+		}
+	}
+
+	kinds, err := s.obsClient.GetSourceFilterKindList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list filter kinds: %w", err)
+	}
+	if !slices.Contains(kinds, input.FilterKind) {
+		return nil, fmt.Errorf("unknown filter kind '%s' (use list_filter_kinds to see available types)", input.FilterKind)
+	}
+
+	return []PlannedChange{{
+		Op:     "create",
+		Target: filterTarget(input.SourceName, input.FilterName),
+		After: map[string]interface{}{
+			"filter_kind":     input.FilterKind,
+			"filter_settings": input.FilterSettings,
+		},
+	}}, nil
+}
+
+func (s *Server) planRemoveSourceFilter(input RemoveSourceFilterInput) ([]PlannedChange, error) {
+	filter, err := s.obsClient.GetSourceFilter(input.SourceName, input.FilterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter: %w", err)
+	}
+	return []PlannedChange{{Op: "delete", Target: filterTarget(input.SourceName, input.FilterName), Before: filter}}, nil
+}
+
+func (s *Server) planToggleSourceFilter(input ToggleSourceFilterInput) ([]PlannedChange, error) {
+	filter, err := s.obsClient.GetSourceFilter(input.SourceName, input.FilterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter state: %w", err)
+	}
+	enabled := !filter.Enabled
+	if input.FilterEnabled != nil {
+		enabled = *input.FilterEnabled
+	}
+	if enabled == filter.Enabled {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: filterTarget(input.SourceName, input.FilterName), Field: "enabled", Before: filter.Enabled, After: enabled}}, nil
+}
+
+func (s *Server) planSetSourceFilterSettings(input SetSourceFilterSettingsInput) ([]PlannedChange, error) {
+	filter, err := s.obsClient.GetSourceFilter(input.SourceName, input.FilterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter: %w", err)
+	}
+
+	// Mirror OBS: overlay merges into the current settings, otherwise the
+	// provided settings replace them
+	updated := make(map[string]interface{}, len(filter.Settings)+len(input.FilterSettings))
+	if input.Overlay {
+		for k, v := range filter.Settings {
+			updated[k] = v
+		}
+	}
+	for k, v := range input.FilterSettings {
+		updated[k] = v
+	}
+
+	before := filter.Settings
+	if before == nil {
+		before = map[string]interface{}{}
+	}
+	return diffFields(filterTarget(input.SourceName, input.FilterName), "settings", before, updated)
+}
+
+// Transition planners
+
+func (s *Server) planSetCurrentTransition(input SetCurrentTransitionInput) ([]PlannedChange, error) {
+	transitions, current, err := s.obsClient.GetSceneTransitionList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transition list: %w", err)
+	}
+	found := false
+	for _, t := range transitions {
+		if t.Name == input.TransitionName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("transition '%s' not found", input.TransitionName)
+	}
+	if current == input.TransitionName {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: "current_transition", Before: current, After: input.TransitionName}}, nil
+}
+
+func (s *Server) planSetTransitionDuration(input SetTransitionDurationInput) ([]PlannedChange, error) {
+	if input.TransitionDuration <= 0 {
+		return nil, fmt.Errorf("transition_duration must be greater than 0")
+	}
+	current, err := s.obsClient.GetCurrentSceneTransition()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current transition: %w", err)
+	}
+	if current.Duration == input.TransitionDuration {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: "transition:" + current.Name, Field: "duration_ms", Before: current.Duration, After: input.TransitionDuration}}, nil
+}
+
+func (s *Server) planTriggerTransition() ([]PlannedChange, error) {
+	enabled, err := s.obsClient.GetStudioModeEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get studio mode status: %w", err)
+	}
+	if !enabled {
+		return nil, fmt.Errorf("studio mode is not enabled")
+	}
+	preview, err := s.obsClient.GetCurrentPreviewScene()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preview scene: %w", err)
+	}
+	_, program, err := s.obsClient.GetSceneList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene list: %w", err)
+	}
+	return []PlannedChange{{Op: "update", Target: "current_scene", Before: program, After: preview}}, nil
+}
+
+// Preset planner
+
+func (s *Server) planApplyScenePreset(ctx context.Context, input PresetNameInput) ([]PlannedChange, error) {
+	preset, err := s.storage.GetScenePreset(ctx, input.PresetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load preset: %w", err)
+	}
+	scene, err := s.obsClient.GetSceneByName(preset.SceneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene '%s': %w", preset.SceneName, err)
+	}
+
+	current := make(map[string]obs.SceneSource, len(scene.Sources))
+	for _, src := range scene.Sources {
+		current[src.Name] = src
+	}
+
+	var changes []PlannedChange
+	for _, src := range preset.Sources {
+		item, exists := current[src.Name]
+		if !exists {
+			// Apply skips sources missing from the scene as well
+			continue
+		}
+		if item.Enabled == src.Visible {
+			continue
+		}
+		changes = append(changes, PlannedChange{
+			Op:     "update",
+			Target: sceneItemTarget(preset.SceneName, item.ID),
+			Field:  "visible",
+			Before: item.Enabled,
+			After:  src.Visible,
+		})
+	}
+	return changes, nil
+}
+
+// Automation planners
+
+// ruleDefinition returns the user-editable fields of a rule for dry-run output.
+func ruleDefinition(rule storage.AutomationRule) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           rule.Name,
+		"description":    rule.Description,
+		"enabled":        rule.Enabled,
+		"trigger_type":   rule.TriggerType,
+		"trigger_config": redactTriggerConfig(rule.TriggerConfig),
+		"actions":        rule.Actions,
+		"condition":      rule.Condition,
+		"concurrency":    rule.Concurrency,
+		"cooldown_ms":    rule.CooldownMs,
+		"priority":       rule.Priority,
+	}
+}
+
+func ruleTarget(name string) string {
+	return "automation_rule:" + name
+}
+
+func (s *Server) planCreateAutomationRule(ctx context.Context, input CreateAutomationRuleInput) ([]PlannedChange, error) {
+	rule, err := buildAutomationRule(input)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.storage.GetAutomationRuleByName(ctx, input.Name); err == nil {
+		return nil, fmt.Errorf("automation rule '%s' already exists", input.Name)
+	}
+	return []PlannedChange{{Op: "create", Target: ruleTarget(rule.Name), After: ruleDefinition(rule)}}, nil
+}
+
+func (s *Server) planUpdateAutomationRule(ctx context.Context, input UpdateAutomationRuleInput) ([]PlannedChange, error) {
+	existing, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+	updated, err := applyAutomationRuleUpdate(*existing, input)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Name != existing.Name {
+		if _, err := s.storage.GetAutomationRuleByName(ctx, updated.Name); err == nil {
+			return nil, fmt.Errorf("automation rule '%s' already exists", updated.Name)
+		}
+	}
+	return diffFields(ruleTarget(existing.Name), "", ruleDefinition(*existing), ruleDefinition(updated))
+}
+
+func (s *Server) planDeleteAutomationRule(ctx context.Context, input DeleteAutomationRuleInput) ([]PlannedChange, error) {
+	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+	return []PlannedChange{{Op: "delete", Target: ruleTarget(rule.Name), Before: ruleDefinition(*rule)}}, nil
+}
+
+func (s *Server) planRestoreRuleRevision(ctx context.Context, input RestoreRuleRevisionInput) ([]PlannedChange, error) {
+	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+	if input.Revision == rule.Revision {
+		return nil, fmt.Errorf("revision %d is already the current revision of rule '%s'", input.Revision, rule.Name)
+	}
+	rev, err := s.storage.GetRuleRevision(ctx, rule.ID, input.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule revision: %w", err)
+	}
+	restored := restoredRule(*rule, rev)
+	if restored.Name != rule.Name {
+		if _, err := s.storage.GetAutomationRuleByName(ctx, restored.Name); err == nil {
+			return nil, fmt.Errorf("automation rule '%s' already exists", restored.Name)
+		}
+	}
+	return diffFields(ruleTarget(rule.Name), "", ruleDefinition(*rule), ruleDefinition(restored))
+}
+
+func (s *Server) planSetAutomationRuleEnabled(ctx context.Context, name string, enabled bool) ([]PlannedChange, error) {
+	rule, err := s.storage.GetAutomationRuleByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+	if rule.Enabled == enabled {
+		return nil, nil
+	}
+	return []PlannedChange{{Op: "update", Target: ruleTarget(rule.Name), Field: "enabled", Before: rule.Enabled, After: enabled}}, nil
+}
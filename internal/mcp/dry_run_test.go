@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dryRunChanges asserts that a handler returned a dry-run result and returns its changes.
+func dryRunChanges(t *testing.T, result any, err error) []PlannedChange {
+	t.Helper()
+	require.NoError(t, err)
+	dr, ok := result.(*DryRunResult)
+	require.True(t, ok, "expected *DryRunResult, got %T", result)
+	assert.True(t, dr.DryRun)
+	return dr.Changes
+}
+
+func TestDryRunOBSTools(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("set_current_scene plans the switch without applying it", func(t *testing.T) {
+		server, mock, db := testServerWithStorage(t)
+
+		_, result, err := server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Gaming", DryRun: true})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, PlannedChange{Op: "update", Target: "current_scene", Before: "Scene 1", After: "Gaming"}, changes[0])
+
+		_, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Scene 1", current)
+
+		actions, err := db.GetActionsByTool(ctx, "set_current_scene", 1)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.True(t, actions[0].DryRun)
+		assert.Equal(t, "Set current scene (dry run)", actions[0].Action)
+	})
+
+	t.Run("set_current_scene rejects unknown scenes", func(t *testing.T) {
+		server, _, db := testServerWithStorage(t)
+
+		_, _, err := server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Missing", DryRun: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "scene 'Missing' not found")
+
+		actions, err := db.GetActionsByTool(ctx, "set_current_scene", 1)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.True(t, actions[0].DryRun)
+		assert.False(t, actions[0].Success)
+	})
+
+	t.Run("remove_scene skips confirmation and keeps the scene", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, result, err := server.handleRemoveScene(ctx, nil, SceneNameInput{SceneName: "Gaming", DryRun: true})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "delete", changes[0].Op)
+		assert.Equal(t, "scene:Gaming", changes[0].Target)
+
+		scenes, _, _ := mock.GetSceneList()
+		assert.Contains(t, scenes, "Gaming")
+	})
+
+	t.Run("set_source_transform diffs only changed fields", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		x, rotation := 120.0, 0.0
+		_, result, err := server.handleSetSourceTransform(ctx, nil, SetSourceTransformInput{
+			SceneName: "Scene 1", SceneItemID: 1, X: &x, Rotation: &rotation, DryRun: true,
+		})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "scene_item:Scene 1/1", changes[0].Target)
+		assert.Equal(t, "position_x", changes[0].Field)
+		assert.Equal(t, float64(0), changes[0].Before)
+		assert.Equal(t, float64(120), changes[0].After)
+
+		transform, _ := mock.GetSceneItemTransform("Scene 1", 1)
+		assert.Equal(t, float64(0), transform.PositionX)
+	})
+
+	t.Run("set_source_bounds validates the bounds type", func(t *testing.T) {
+		server, _, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleSetSourceBounds(ctx, nil, SetSourceBoundsInput{
+			SceneName: "Scene 1", SceneItemID: 1, BoundsType: "OBS_BOUNDS_HUGE", DryRun: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid bounds_type")
+	})
+
+	t.Run("create_text_source validates the name and leaves sources unchanged", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+		before, _ := mock.ListSources()
+
+		_, result, err := server.handleCreateTextSource(ctx, nil, CreateTextSourceInput{
+			SceneName: "Scene 1", SourceName: "Title", Text: "Hello", DryRun: true,
+		})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "create", changes[0].Op)
+		assert.Equal(t, "input:Title", changes[0].Target)
+
+		after, _ := mock.ListSources()
+		assert.Len(t, after, len(before))
+
+		_, _, err = server.handleCreateTextSource(ctx, nil, CreateTextSourceInput{
+			SceneName: "Scene 1", SourceName: "Microphone", Text: "Hello", DryRun: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("filter settings diff respects overlay", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, result, err := server.handleSetSourceFilterSettings(ctx, nil, SetSourceFilterSettingsInput{
+			SourceName:     "Webcam",
+			FilterName:     "Color Correction",
+			FilterSettings: map[string]interface{}{"brightness": 0.25},
+			Overlay:        true,
+			DryRun:         true,
+		})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "settings.brightness", changes[0].Field)
+		assert.Equal(t, 0.25, changes[0].After)
+
+		// Without overlay the unspecified settings are removed
+		_, result, err = server.handleSetSourceFilterSettings(ctx, nil, SetSourceFilterSettingsInput{
+			SourceName:     "Webcam",
+			FilterName:     "Color Correction",
+			FilterSettings: map[string]interface{}{"brightness": 0.25},
+			DryRun:         true,
+		})
+		assert.Len(t, dryRunChanges(t, result, err), 3)
+
+		filter, _ := mock.GetSourceFilter("Webcam", "Color Correction")
+		assert.Equal(t, 0.0, filter.Settings["brightness"])
+	})
+
+	t.Run("create_source_filter rejects duplicates and unknown kinds", func(t *testing.T) {
+		server, _, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleCreateSourceFilter(ctx, nil, CreateSourceFilterInput{
+			SourceName: "Webcam", FilterName: "Sharpen", FilterKind: "sharpness_filter_v2", DryRun: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+
+		_, _, err = server.handleCreateSourceFilter(ctx, nil, CreateSourceFilterInput{
+			SourceName: "Webcam", FilterName: "Blur", FilterKind: "blur_filter", DryRun: true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown filter kind")
+	})
+
+	t.Run("trigger_transition requires studio mode", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleTriggerTransition(ctx, nil, TriggerTransitionInput{DryRun: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "studio mode is not enabled")
+
+		mock.SetStudioModeEnabledDirect(true)
+		mock.SetPreviewScene("Gaming")
+		_, result, err := server.handleTriggerTransition(ctx, nil, TriggerTransitionInput{DryRun: true})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "Gaming", changes[0].After)
+
+		_, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Scene 1", current)
+	})
+
+	t.Run("apply_scene_preset lists visibility changes", func(t *testing.T) {
+		server, mock, db := testServerWithStorage(t)
+		_, err := db.CreateScenePreset(ctx, storage.ScenePreset{
+			Name:      "Dry Preset",
+			SceneName: "Scene 1",
+			Sources: []storage.SourceState{
+				{Name: "Webcam", Visible: false},
+				{Name: "Text", Visible: true},
+			},
+		})
+		require.NoError(t, err)
+
+		_, result, err := server.handleApplyScenePreset(ctx, nil, PresetNameInput{PresetName: "Dry Preset", DryRun: true})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "scene_item:Scene 1/1", changes[0].Target)
+		assert.Equal(t, false, changes[0].After)
+
+		scene, _ := mock.GetSceneByName("Scene 1")
+		assert.True(t, scene.Sources[0].Enabled)
+	})
+}
+
+func TestDryRunAutomationRules(t *testing.T) {
+	ctx := context.Background()
+	server, _, db := testServerWithStorage(t)
+
+	_, err := db.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "existing",
+		Enabled:       true,
+		TriggerType:   automation.TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Gaming"}, OnError: automation.ActionErrorContinue},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("create validates without saving", func(t *testing.T) {
+		_, result, err := server.handleCreateAutomationRule(ctx, nil, CreateAutomationRuleInput{
+			Name:          "new-rule",
+			TriggerType:   automation.TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions:       []map[string]interface{}{{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "Gaming"}}},
+			DryRun:        true,
+		})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "automation_rule:new-rule", changes[0].Target)
+
+		_, err = db.GetAutomationRuleByName(ctx, "new-rule")
+		assert.Error(t, err)
+
+		_, _, err = server.handleCreateAutomationRule(ctx, nil, CreateAutomationRuleInput{
+			Name:        "bad-rule",
+			TriggerType: "sometimes",
+			DryRun:      true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid trigger_type")
+	})
+
+	t.Run("update diffs the rule definition", func(t *testing.T) {
+		priority := 7
+		_, result, err := server.handleUpdateAutomationRule(ctx, nil, UpdateAutomationRuleInput{Name: "existing", Priority: &priority, DryRun: true})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "priority", changes[0].Field)
+		assert.Equal(t, float64(0), changes[0].Before)
+		assert.Equal(t, float64(7), changes[0].After)
+
+		rule, err := db.GetAutomationRuleByName(ctx, "existing")
+		require.NoError(t, err)
+		assert.Equal(t, 0, rule.Priority)
+	})
+
+	t.Run("delete and disable leave the rule in place", func(t *testing.T) {
+		_, result, err := server.handleDeleteAutomationRule(ctx, nil, DeleteAutomationRuleInput{Name: "existing", DryRun: true})
+		changes := dryRunChanges(t, result, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "delete", changes[0].Op)
+
+		_, result, err = server.handleDisableAutomationRule(ctx, nil, EnableAutomationRuleInput{Name: "existing", DryRun: true})
+		require.Len(t, dryRunChanges(t, result, err), 1)
+
+		// Enabling an enabled rule plans nothing
+		_, result, err = server.handleEnableAutomationRule(ctx, nil, EnableAutomationRuleInput{Name: "existing", DryRun: true})
+		assert.Empty(t, dryRunChanges(t, result, err))
+
+		rule, err := db.GetAutomationRuleByName(ctx, "existing")
+		require.NoError(t, err)
+		assert.True(t, rule.Enabled)
+	})
+}
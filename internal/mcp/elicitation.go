@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ironystock/agentic-obs/internal/storage"
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -76,6 +77,24 @@ func ElicitFilterRemovalConfirmation(ctx context.Context, session *mcpsdk.Server
 		fmt.Sprintf("You are about to remove filter '%s' from source '%s'. This cannot be undone. Continue?", filterName, sourceName))
 }
 
+// ElicitPolicyConfirmation requests confirmation for a tool call that a policy
+// rule gates behind user approval.
+func ElicitPolicyConfirmation(ctx context.Context, session *mcpsdk.ServerSession, toolName string, rule storage.PolicyRule) (bool, error) {
+	message := fmt.Sprintf("Policy '%s' requires confirmation before calling %s.", rule.Name, toolName)
+	if rule.Message != "" {
+		message += " " + rule.Message
+	}
+	return ElicitConfirmation(ctx, session, message+" Continue?")
+}
+
+// ElicitPolicyChangeConfirmation requests confirmation for a set_policy call
+// that could let through calls the policy refuses. change describes it, e.g.
+// "delete deny rule 'no-audio'".
+func ElicitPolicyChangeConfirmation(ctx context.Context, session *mcpsdk.ServerSession, change string) (bool, error) {
+	return ElicitConfirmation(ctx, session,
+		fmt.Sprintf("The assistant wants to %s. This loosens the tool call policy. Continue?", change))
+}
+
 // CancelledResult returns a result indicating the action was cancelled by the user.
 func CancelledResult(action string) SimpleResult {
 	return SimpleResult{Message: fmt.Sprintf("%s cancelled by user", action)}
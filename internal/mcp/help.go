@@ -49,7 +49,7 @@ func (s *Server) handleHelp(ctx context.Context, request *mcpsdk.CallToolRequest
 		// Try to find help for a specific tool (using extracted content from help_tools.go)
 		helpText, err = getToolHelp(topic, input.Verbose)
 		if err != nil {
-			s.recordAction("help", "Get help", input, nil, false, time.Since(start))
+			s.recordAction(ctx, "help", "Get help", input, nil, false, time.Since(start))
 			return nil, nil, fmt.Errorf("unknown help topic '%s'. Try 'overview', 'tools', 'resources', 'prompts', 'workflows', 'troubleshooting', or a specific tool name", topic)
 		}
 	}
@@ -60,7 +60,7 @@ func (s *Server) handleHelp(ctx context.Context, request *mcpsdk.CallToolRequest
 		"verbose": input.Verbose,
 	}
 
-	s.recordAction("help", "Get help", input, result, true, time.Since(start))
+	s.recordAction(ctx, "help", "Get help", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -21,13 +21,13 @@ import "fmt"
 //
 // ============================================================================
 const (
-	HelpToolCount     = 81 // Total MCP tools (including meta-tools)
-	HelpResourceCount = 4  // Resource types: scenes, screenshots, screenshot-url, presets
-	HelpPromptCount   = 14 // Workflow prompts
+	HelpToolCount     = 104 // Total MCP tools (including meta-tools)
+	HelpResourceCount = 4   // Resource types: scenes, screenshots, screenshot-url, presets
+	HelpPromptCount   = 14  // Workflow prompts
 
 	// Tool counts by category (should sum to HelpToolCount)
-	HelpCoreToolCount        = 25 // Scene management, recording, streaming, status, virtual cam, replay buffer, studio mode, hotkeys
-	HelpMetaToolCount        = 4  // Meta-tools: help, get_tool_config, set_tool_config, list_tool_groups (FB-27)
+	HelpCoreToolCount        = 28 // Scene management, recording, streaming, status, virtual cam, replay buffer, studio mode, hotkeys, undo
+	HelpMetaToolCount        = 7  // Meta-tools: help, get_tool_config, set_tool_config, list_tool_groups (FB-27), execute_batch, get_policy, set_policy
 	HelpSourcesToolCount     = 3  // Source management
 	HelpAudioToolCount       = 4  // Audio control
 	HelpLayoutToolCount      = 6  // Scene presets
@@ -35,7 +35,7 @@ const (
 	HelpDesignToolCount      = 14 // Source creation and layout
 	HelpFiltersToolCount     = 7  // Filter management (FB-23)
 	HelpTransitionsToolCount = 5  // Transition control (FB-24)
-	HelpAutomationToolCount  = 9  // Automation rules (FB-20)
+	HelpAutomationToolCount  = 26 // Automation rules (FB-20)
 )
 
 // GetOverviewHelp returns high-level overview of agentic-obs
@@ -125,12 +125,20 @@ func GetToolsHelp(verbose bool) string {
 **Status:**
 - get_obs_status - Overall OBS connection and state
 
+**Undo:**
+- list_undoable_actions - List recent OBS changes that can be undone
+- undo_last_action - Undo the most recent OBS change
+- undo_to - Undo every change back to a given action ID
+
 ## Meta Tools (%d tools) - Always Enabled
 
 - help - Get detailed help on tools, resources, prompts, workflows, or troubleshooting
 - get_tool_config - Get current tool group configuration
 - set_tool_config - Enable/disable tool groups at runtime
 - list_tool_groups - List all tool groups with their status
+- execute_batch - Run an ordered list of tool calls as one unit, rolling back on failure
+- get_policy - List policy rules and how a call to a tool would be decided
+- set_policy - Create, replace, or delete an allow/deny/confirm rule for a tool or group
 
 ## Sources Tools (%d tools) - Source Management
 
@@ -212,6 +220,23 @@ func GetToolsHelp(verbose bool) string {
 - disable_automation_rule - Deactivate a rule
 - trigger_automation_rule - Manually trigger for testing
 - list_rule_executions - View execution history
+- list_running_executions - See executions in progress and their current action
+- cancel_rule_execution - Stop a running execution
+- simulate_automation_event - Test rules against a synthetic event without running them
+- get_next_runs - Preview the next fire times of a schedule rule
+- start_macro_recording - Record supported tool calls as a macro
+- stop_macro_recording - Save the recording as a manual automation rule
+- list_macros - List reusable macros
+- get_macro - Get a macro's parameters and actions
+- create_macro - Create a parameterized action list rules can call
+- update_macro - Modify an existing macro
+- delete_macro - Remove a macro (with confirmation)
+- run_macro - Run a macro with arguments
+- export_automation_bundle - Export rules, macros and presets as a JSON or YAML bundle
+- import_automation_bundle - Import a bundle with skip, rename or overwrite on conflicts
+- list_rule_revisions - List the saved revisions of a rule
+- diff_rule_revisions - Compare two revisions of a rule
+- restore_rule_revision - Roll a rule back to an earlier revision
 `, HelpToolCount, HelpCoreToolCount, HelpMetaToolCount, HelpSourcesToolCount,
 		HelpAudioToolCount, HelpLayoutToolCount, HelpVisualToolCount, HelpDesignToolCount,
 		HelpFiltersToolCount, HelpTransitionsToolCount, HelpAutomationToolCount)
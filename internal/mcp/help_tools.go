@@ -1531,6 +1531,62 @@ var toolHelpContent = map[string]string{
 
 **Note**: Hotkey names follow the pattern "Context.Action" (e.g., "OBSBasic.StartRecording").`,
 
+	// =========================================================================
+	// Undo Tools
+	// =========================================================================
+
+	"list_undoable_actions": `# list_undoable_actions
+
+**Category**: Core (Undo)
+
+**Description**: List recent OBS changes that can be undone, newest first. Each entry is keyed to an action history ID and lists the operations that restore the prior state.
+
+**Input**:
+- limit (integer, optional): Maximum number of actions to return (default: 20, max: 100)
+- include_undone (boolean, optional): Also list actions that were already undone
+
+**Output**:
+- actions: Array of undo entries (action_id, tool_name, description, operations, undone_at, created_at)
+- count: Number of entries returned
+
+**Use Case**: Find the action ID to pass to undo_to.`,
+
+	"undo_last_action": `# undo_last_action
+
+**Category**: Core (Undo)
+
+**Description**: Undo the most recent undoable OBS change by replaying the state captured before it.
+
+**Input**: None
+
+**Output**:
+- undone: The action that was undone (action_id, tool_name, description)
+- count: Number of actions undone
+- message: Human-readable summary
+
+**Note**: Recording, streaming and automation rule changes are not undoable.`,
+
+	"undo_to": `# undo_to
+
+**Category**: Core (Undo)
+
+**Description**: Undo every undoable OBS change back to and including the given action, newest first.
+
+**Input**:
+- action_id (integer, required): Action history ID to undo back to
+
+**Output**:
+- undone: Actions that were undone, in the order they were reversed
+- count: Number of actions undone
+- message: Human-readable summary
+
+**Example Input**:
+{
+  "action_id": 42
+}
+
+**Note**: Stops at the first failure; actions undone before it stay undone. Removed sources come back with new scene item IDs, and older entries are updated to match.`,
+
 	// Meta Tools - Tool Configuration
 	"get_tool_config": `# get_tool_config
 
@@ -1618,6 +1674,95 @@ var toolHelpContent = map[string]string{
 - Design (14 tools): Source creation and transform control
 - Filters (7 tools): Source filter management
 - Transitions (5 tools): Scene transition control`,
+
+	"execute_batch": `# execute_batch
+
+**Category**: Meta Tools
+
+**Description**: Run an ordered list of tool calls as one unit. Each step goes through the same handler as a direct call. The batch stops at the first failing step and rolls back the completed steps, newest first, using the prior state they captured.
+
+**Input**:
+- steps (array, required): Tool calls to run in order (max 50). Each step has:
+  - tool (string): Tool name
+  - arguments (object, optional): Arguments as they would be passed to a direct call
+
+**Output**:
+- success: True when every step completed
+- steps: Per-step results (index, tool, success, result, error, compensation)
+- completed: Number of steps that completed
+- total: Number of steps in the batch
+- failed_step: Index of the failing step (only on failure)
+- rolled_back: True when every completed step was reverted after a failure
+- message: Human-readable summary
+
+**Example Input**:
+{
+  "steps": [
+    {"tool": "create_scene", "arguments": {"scene_name": "Interview"}},
+    {"tool": "create_text_source", "arguments": {"scene_name": "Interview", "source_name": "Title", "text": "Live Q&A"}},
+    {"tool": "set_current_scene", "arguments": {"scene_name": "Interview"}}
+  ]
+}
+
+**Compensation**: After a failure each completed step reports "reverted", "not_undoable" (the tool has no undo support, e.g. start_streaming), "failed", or "skipped" (an earlier revert failed). Read-only steps and dry runs report nothing.
+
+**History**: The batch is recorded as a single action history entry with each step as a child record. A successful batch can be reverted as a whole with undo_last_action.
+
+**Notes**: Steps are validated before anything runs; unknown tools and nested execute_batch calls are rejected.`,
+
+	"get_policy": `# get_policy
+
+**Category**: Meta Tools
+
+**Description**: List the policy rules that allow, deny, or require confirmation for tool calls.
+
+**Input**:
+- tool (string, optional): Only list rules that apply to this tool, and report how a call to it would be decided now
+
+**Output**:
+- rules: Policy rules, highest priority first (name, tool or group, effect, conditions, message, priority, enabled)
+- count: Number of rules listed
+- decision: How a call to the given tool would be decided now (effect and deciding rule)
+- message: Human-readable summary
+
+**Example Input**:
+{
+  "tool": "start_streaming"
+}
+
+**Use Case**: Check why a call was refused, or review the guardrails before a show.`,
+
+	"set_policy": `# set_policy
+
+**Category**: Meta Tools
+
+**Description**: Create, replace, or delete a policy rule. Policy rules are checked before every tool call; the first enabled rule (highest priority first) that targets the tool and whose conditions all hold decides the call. Calls no rule decides are allowed.
+
+**Input**:
+- name (string, required): Unique rule name; an existing rule with this name is replaced
+- tool (string): Tool the rule applies to, or "*" for every tool
+- group (string): Tool group the rule applies to (Core, Sources, Audio, Layout, Visual, Design, Filters, Transitions, Automation, or Meta). Set tool or group, not both
+- effect (string): allow, deny, or confirm
+- conditions (object, optional): All set conditions must hold
+  - while_streaming (boolean): Only while the stream is (true) or is not (false) live
+  - while_recording (boolean): Only while recording is (true) or is not (false) active
+  - time_window (object): start and end as "HH:MM", optional days ("mon".."sun") and IANA timezone; end before start runs past midnight
+  - rate_limit (object): Applies once the tool was called max_calls times within window_seconds
+- message (string, optional): Explanation added to refusals and confirmation prompts
+- priority (integer, optional): Higher priority rules are checked first (default: 0)
+- enabled (boolean, optional): Default true
+- delete (boolean, optional): Delete the named rule instead
+
+**Example Input**:
+{
+  "name": "freeze-layout-live",
+  "group": "Layout",
+  "effect": "deny",
+  "conditions": {"while_streaming": true},
+  "message": "Layouts are frozen while live"
+}
+
+**Notes**: Refused calls fail with an error naming the rule and are recorded in action history. Confirm rules use MCP elicitation; clients that cannot confirm are refused. get_policy and set_policy are never subject to policy rules.`,
 }
 
 // GetToolHelpContent returns the help text for a specific tool, or empty if not found.
@@ -68,6 +68,7 @@ type OBSClient interface {
 	// Scene design - item management
 	SetSceneItemLocked(sceneName string, sceneItemID int, locked bool) error
 	GetSceneItemLocked(sceneName string, sceneItemID int) (bool, error)
+	CreateSceneItem(sceneName, sourceName string) (int, error)
 	DuplicateSceneItem(sceneName string, sceneItemID int, destScene string) (int, error)
 	RemoveSceneItem(sceneName string, sceneItemID int) error
 
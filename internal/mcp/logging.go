@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/logging"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// logForwardBuffer is the number of entries queued for delivery to MCP
+// sessions. Entries logged while the buffer is full are dropped rather than
+// blocking the component that produced them.
+const logForwardBuffer = 256
+
+// startLogForwarding relays entries from the logging package to every
+// connected MCP session as notifications/message. The SDK applies each
+// session's logging/setLevel threshold, and sessions that never set a level
+// receive nothing. The returned function stops forwarding.
+func (s *Server) startLogForwarding() func() {
+	entries := make(chan logging.Entry, logForwardBuffer)
+	done := make(chan struct{})
+
+	removeSink := logging.AddSink(func(entry logging.Entry) {
+		select {
+		case entries <- entry:
+		default:
+		}
+	})
+
+	go func() {
+		defer close(done)
+		for entry := range entries {
+			s.forwardLogEntry(entry)
+		}
+	}()
+
+	return func() {
+		removeSink()
+		close(entries)
+		<-done
+	}
+}
+
+// forwardLogEntry sends a single entry to all sessions.
+func (s *Server) forwardLogEntry(entry logging.Entry) {
+	if s.mcpServer == nil {
+		return
+	}
+
+	params := &mcpsdk.LoggingMessageParams{
+		Level:  mcpsdk.LoggingLevel(entry.Level.String()),
+		Logger: entry.Component,
+		Data: map[string]interface{}{
+			"message": entry.Message,
+			"time":    entry.Time.Format(time.RFC3339Nano),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for session := range s.mcpServer.Sessions() {
+		// Use the standard logger here: logging through the logging
+		// package would feed the failure back into this forwarder.
+		if err := session.Log(ctx, params); err != nil {
+			log.Printf("Warning: failed to forward log entry to MCP session: %v", err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestLogForwarding(t *testing.T) {
+	server, _, _ := testServerForToolConfig(t)
+
+	var mu sync.Mutex
+	var messages []*mcpsdk.LoggingMessageParams
+	session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+		LoggingMessageHandler: func(_ context.Context, req *mcpsdk.LoggingMessageRequest) {
+			// Ignore entries from other components logging in the background
+			if req.Params.Logger != "mcp-test" {
+				return
+			}
+			mu.Lock()
+			messages = append(messages, req.Params)
+			mu.Unlock()
+		},
+	})
+
+	stop := server.startLogForwarding()
+	t.Cleanup(stop)
+
+	received := func() []*mcpsdk.LoggingMessageParams {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*mcpsdk.LoggingMessageParams(nil), messages...)
+	}
+
+	logger := logging.New("mcp-test")
+
+	t.Run("nothing is sent before the client sets a level", func(t *testing.T) {
+		logger.Errorf("too early")
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, received())
+	})
+
+	t.Run("session level filters entries", func(t *testing.T) {
+		err := session.SetLoggingLevel(context.Background(), &mcpsdk.SetLoggingLevelParams{Level: "warning"})
+		require.NoError(t, err)
+
+		logger.Debugf("debug entry")
+		logger.Infof("info entry")
+		logger.Warnf("capture failed: %s", "timeout")
+		logger.Errorf("rule failed")
+
+		require.Eventually(t, func() bool { return len(received()) == 2 }, time.Second, 10*time.Millisecond)
+
+		msgs := received()
+		assert.Equal(t, mcpsdk.LoggingLevel("warning"), msgs[0].Level)
+		assert.Equal(t, "mcp-test", msgs[0].Logger)
+		data, ok := msgs[0].Data.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "capture failed: timeout", data["message"])
+		assert.Equal(t, mcpsdk.LoggingLevel("error"), msgs[1].Level)
+	})
+}
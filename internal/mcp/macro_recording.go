@@ -0,0 +1,310 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Macro recording.
+//
+// While a recording is active, successful calls to mutating tools that have
+// an automation action equivalent are captured as automation actions, with
+// the pauses between them optionally kept as delay actions. Stopping the
+// recording saves the actions as a manual-trigger automation rule.
+//
+// Recordings belong to the MCP session that started them: calls from other
+// sessions are not captured, and each session can record its own macro.
+
+// Defaults and limits for captured delays.
+const (
+	defaultMacroMinDelayMs = 250
+	maxMacroDelayMs        = 5 * 60 * 1000 // Matches the executor's cap
+)
+
+// macroMapping describes how a tool call becomes an automation action.
+type macroMapping struct {
+	actionType string
+	params     []string // Tool arguments copied to the action parameters
+}
+
+// macroActions maps the tools that can be recorded to automation actions.
+var macroActions = map[string]macroMapping{
+	"set_current_scene":        {automation.ActionTypeSetScene, []string{"scene_name"}},
+	"set_preview_scene":        {automation.ActionTypeSetPreviewScene, []string{"scene_name"}},
+	"trigger_transition":       {automation.ActionTypeTriggerTransition, nil},
+	"toggle_input_mute":        {automation.ActionTypeToggleMute, []string{"input_name"}},
+	"set_input_volume":         {automation.ActionTypeSetVolume, []string{"input_name", "volume_db", "volume_mul"}},
+	"toggle_source_visibility": {automation.ActionTypeToggleVisibility, []string{"scene_name", "source_id"}},
+	"start_recording":          {automation.ActionTypeStartRecording, nil},
+	"stop_recording":           {automation.ActionTypeStopRecording, nil},
+	"pause_recording":          {automation.ActionTypePauseRecording, nil},
+	"resume_recording":         {automation.ActionTypeResumeRecording, nil},
+	"start_streaming":          {automation.ActionTypeStartStreaming, nil},
+	"stop_streaming":           {automation.ActionTypeStopStreaming, nil},
+	"toggle_virtual_cam":       {automation.ActionTypeToggleVirtualCam, nil},
+	"toggle_replay_buffer":     {automation.ActionTypeToggleReplayBuffer, nil},
+	"save_replay_buffer":       {automation.ActionTypeSaveReplay, nil},
+	"trigger_hotkey_by_name":   {automation.ActionTypeTriggerHotkey, []string{"hotkey_name"}},
+}
+
+// macroUnrecorded lists mutating tools that are neither recorded nor reported
+// as skipped.
+var macroUnrecorded = map[string]bool{
+	"execute_batch":         true, // Steps are captured individually
+	"start_macro_recording": true,
+	"stop_macro_recording":  true,
+}
+
+// macroRecorder holds the active macro recordings. Each MCP session records
+// its own macro, and only its own calls are captured into it.
+type macroRecorder struct {
+	mu         sync.Mutex
+	recordings map[*mcpsdk.ServerSession]*macroRecording
+	now        func() time.Time
+}
+
+// macroRecording is the state of one session's recording.
+type macroRecording struct {
+	name          string
+	description   string
+	captureDelays bool
+	minDelay      time.Duration
+	startedAt     time.Time
+	last          time.Time // Time of the last captured action
+	actions       []MacroAction
+	skipped       []string // Mutating tools called that cannot be recorded
+}
+
+func (m *macroRecorder) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// forgetClosedLocked drops the recordings of sessions that are no longer
+// open, since they can never be stopped. Caller must hold m.mu.
+func (m *macroRecorder) forgetClosedLocked(open iter.Seq[*mcpsdk.ServerSession]) {
+	live := make(map[*mcpsdk.ServerSession]bool)
+	for session := range open {
+		live[session] = true
+	}
+	for session, rec := range m.recordings {
+		if session != nil && !live[session] {
+			log.Printf("Dropping macro recording '%s' of a closed session", rec.name)
+			delete(m.recordings, session)
+		}
+	}
+}
+
+// capture records a successful tool call made through session if that
+// session is recording. inputJSON is the call's arguments as stored in the
+// action history.
+func (m *macroRecorder) capture(session *mcpsdk.ServerSession, toolName, inputJSON string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec := m.recordings[session]
+	if rec == nil {
+		return
+	}
+
+	mapping, ok := macroActions[toolName]
+	if !ok {
+		if !toolSpecs[toolName].ReadOnly && !macroUnrecorded[toolName] && !slices.Contains(rec.skipped, toolName) {
+			rec.skipped = append(rec.skipped, toolName)
+		}
+		return
+	}
+
+	var args map[string]interface{}
+	if inputJSON != "" {
+		if err := json.Unmarshal([]byte(inputJSON), &args); err != nil {
+			log.Printf("Warning: macro recording could not read %s arguments: %v", toolName, err)
+			return
+		}
+	}
+
+	now := m.clock()
+	if rec.captureDelays && len(rec.actions) > 0 {
+		gap := now.Sub(rec.last)
+		if gap >= rec.minDelay {
+			delayMs := min(gap.Milliseconds(), maxMacroDelayMs)
+			rec.actions = append(rec.actions, MacroAction{
+				Type:       automation.ActionTypeDelay,
+				Parameters: map[string]interface{}{"delay_ms": delayMs},
+			})
+		}
+	}
+	rec.last = now
+
+	action := MacroAction{Type: mapping.actionType}
+	for _, param := range mapping.params {
+		if value, ok := args[param]; ok && value != nil {
+			if action.Parameters == nil {
+				action.Parameters = make(map[string]interface{})
+			}
+			action.Parameters[param] = value
+		}
+	}
+	rec.actions = append(rec.actions, action)
+}
+
+// StartMacroRecordingInput is the input for start_macro_recording
+type StartMacroRecordingInput struct {
+	Name          string `json:"name" jsonschema:"Name of the automation rule the recording is saved as"`
+	Description   string `json:"description,omitempty" jsonschema:"Description for the saved rule"`
+	CaptureDelays bool   `json:"capture_delays,omitempty" jsonschema:"Insert delay actions for the pauses between recorded calls (default: false)"`
+	MinDelayMs    int    `json:"min_delay_ms,omitempty" jsonschema:"Shortest pause kept as a delay when capture_delays is set (default: 250)"`
+}
+
+// StopMacroRecordingInput is the input for stop_macro_recording
+type StopMacroRecordingInput struct {
+	Discard bool `json:"discard,omitempty" jsonschema:"Stop without saving the recorded actions"`
+}
+
+func (s *Server) handleStartMacroRecording(ctx context.Context, request *mcpsdk.CallToolRequest, input StartMacroRecordingInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Starting macro recording: %s", input.Name)
+
+	if input.Name == "" {
+		return nil, nil, fmt.Errorf("name is required")
+	}
+	if input.MinDelayMs < 0 {
+		return nil, nil, fmt.Errorf("min_delay_ms must be non-negative")
+	}
+	if s.storage != nil {
+		if _, err := s.storage.GetAutomationRuleByName(ctx, input.Name); err == nil {
+			return nil, nil, fmt.Errorf("automation rule '%s' already exists", input.Name)
+		}
+	}
+
+	minDelay := input.MinDelayMs
+	if minDelay == 0 {
+		minDelay = defaultMacroMinDelayMs
+	}
+
+	session := sessionFrom(ctx)
+	m := &s.macro
+	m.mu.Lock()
+	if rec := m.recordings[session]; rec != nil {
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("macro '%s' is already being recorded; stop it first", rec.name)
+	}
+	if m.recordings == nil {
+		m.recordings = make(map[*mcpsdk.ServerSession]*macroRecording)
+	}
+	if s.mcpServer != nil {
+		m.forgetClosedLocked(s.mcpServer.Sessions())
+	}
+	now := m.clock()
+	m.recordings[session] = &macroRecording{
+		name:          input.Name,
+		description:   input.Description,
+		captureDelays: input.CaptureDelays,
+		minDelay:      time.Duration(minDelay) * time.Millisecond,
+		startedAt:     now,
+		last:          now,
+	}
+	m.mu.Unlock()
+
+	result := MacroRecordingResult{
+		Name:      input.Name,
+		Recording: true,
+		Message:   fmt.Sprintf("Recording macro '%s'. Supported tool calls are captured until stop_macro_recording is called", input.Name),
+	}
+
+	s.recordAction(ctx, "start_macro_recording", "Start macro recording", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+func (s *Server) handleStopMacroRecording(ctx context.Context, request *mcpsdk.CallToolRequest, input StopMacroRecordingInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Println("Stopping macro recording")
+
+	session := sessionFrom(ctx)
+	m := &s.macro
+	m.mu.Lock()
+	rec := m.recordings[session]
+	if rec == nil {
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("no macro is being recorded in this session")
+	}
+	delete(m.recordings, session)
+	name, description := rec.name, rec.description
+	actions, skipped := rec.actions, rec.skipped
+	duration := m.clock().Sub(rec.startedAt)
+	m.mu.Unlock()
+
+	result := MacroRecordingResult{
+		Name:        name,
+		Actions:     actions,
+		ActionCount: len(actions),
+		Skipped:     skipped,
+		DurationMs:  duration.Milliseconds(),
+	}
+
+	if input.Discard {
+		result.Message = fmt.Sprintf("Discarded macro '%s' (%d actions)", name, len(actions))
+		s.recordAction(ctx, "stop_macro_recording", "Stop macro recording", input, result, true, time.Since(start))
+		return nil, result, nil
+	}
+
+	if len(actions) == 0 {
+		s.recordAction(ctx, "stop_macro_recording", "Stop macro recording", input, result, false, time.Since(start))
+		return nil, nil, fmt.Errorf("no recordable tool calls were captured for macro '%s'; nothing was saved", name)
+	}
+
+	if description == "" {
+		description = fmt.Sprintf("Recorded macro (%d actions)", len(actions))
+	}
+	rule := storage.AutomationRule{
+		Name:          name,
+		Description:   description,
+		Enabled:       true,
+		TriggerType:   automation.TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions:       make([]storage.RuleAction, len(actions)),
+	}
+	for i, action := range actions {
+		rule.Actions[i] = storage.RuleAction{
+			Type:       action.Type,
+			Parameters: action.Parameters,
+			OnError:    automation.ActionErrorContinue,
+		}
+	}
+
+	if s.storage == nil {
+		return nil, nil, fmt.Errorf("storage is not available; macro '%s' was not saved", name)
+	}
+
+	id, err := s.storage.CreateAutomationRule(ctx, rule)
+	if err != nil {
+		s.recordAction(ctx, "stop_macro_recording", "Stop macro recording", input, result, false, time.Since(start))
+		return nil, nil, fmt.Errorf("failed to save macro '%s': %w", name, err)
+	}
+
+	if s.automationEngine != nil && s.automationEngine.IsRunning() {
+		s.automationEngine.NotifyRuleChange(id, false)
+	}
+
+	result.RuleID = id
+	result.Saved = true
+	result.Message = fmt.Sprintf("Saved macro '%s' as a manual automation rule with %d actions", name, len(actions))
+	if len(skipped) > 0 {
+		result.Message += fmt.Sprintf("; %d tools could not be recorded", len(skipped))
+	}
+
+	s.recordAction(ctx, "stop_macro_recording", "Stop macro recording", input, result, true, time.Since(start))
+	return nil, result, nil
+}
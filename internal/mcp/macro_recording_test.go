@@ -0,0 +1,252 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callTool calls a tool through an MCP session and fails the test if the
+// call itself errors.
+func callTool(t *testing.T, session *mcpsdk.ClientSession, name string, args map[string]any) *mcpsdk.CallToolResult {
+	t.Helper()
+
+	res, err := session.CallTool(context.Background(), &mcpsdk.CallToolParams{Name: name, Arguments: args})
+	require.NoError(t, err)
+	return res
+}
+
+// connectAnotherClient connects one more in-memory client to the MCP server
+// of a server already set up by connectTestClient, as a second session.
+func connectAnotherClient(t *testing.T, server *Server) *mcpsdk.ClientSession {
+	t.Helper()
+
+	ctx := context.Background()
+	clientTransport, serverTransport := mcpsdk.NewInMemoryTransports()
+	serverSession, err := server.mcpServer.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "0.0.0"}, nil)
+	clientSession, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		clientSession.Close()
+		serverSession.Wait()
+	})
+	return clientSession
+}
+
+func decodeMacroResult(t *testing.T, res *mcpsdk.CallToolResult) MacroRecordingResult {
+	t.Helper()
+
+	require.False(t, res.IsError, toolResultText(res))
+	var result MacroRecordingResult
+	data, err := json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestMacroRecording(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saves recorded calls as a manual rule", func(t *testing.T) {
+		server, _, db := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		decodeMacroResult(t, callTool(t, session, "start_macro_recording", map[string]any{"name": "Go Live"}))
+
+		callTool(t, session, "list_scenes", nil)
+		callTool(t, session, "set_current_scene", map[string]any{"scene_name": "Starting Soon"})
+		callTool(t, session, "set_current_scene", map[string]any{"scene_name": "Gaming", "dry_run": true})
+		callTool(t, session, "create_scene", map[string]any{"scene_name": "Interview"})
+		callTool(t, session, "toggle_input_mute", map[string]any{"input_name": "Microphone"})
+		callTool(t, session, "set_current_scene", map[string]any{"scene_name": "Missing"})
+		callTool(t, session, "start_streaming", nil)
+
+		result := decodeMacroResult(t, callTool(t, session, "stop_macro_recording", nil))
+		assert.True(t, result.Saved)
+		assert.Equal(t, []string{"create_scene"}, result.Skipped)
+		require.Len(t, result.Actions, 3)
+		assert.Equal(t, automation.ActionTypeSetScene, result.Actions[0].Type)
+		assert.Equal(t, "Starting Soon", result.Actions[0].Parameters["scene_name"])
+		assert.Equal(t, automation.ActionTypeToggleMute, result.Actions[1].Type)
+		assert.Equal(t, "Microphone", result.Actions[1].Parameters["input_name"])
+		assert.Equal(t, automation.ActionTypeStartStreaming, result.Actions[2].Type)
+
+		rule, err := db.GetAutomationRuleByName(ctx, "Go Live")
+		require.NoError(t, err)
+		assert.Equal(t, result.RuleID, rule.ID)
+		assert.Equal(t, automation.TriggerTypeManual, rule.TriggerType)
+		assert.True(t, rule.Enabled)
+		require.Len(t, rule.Actions, 3)
+		assert.Equal(t, automation.ActionErrorContinue, rule.Actions[0].OnError)
+
+		// Calls after stopping are not captured
+		callTool(t, session, "stop_streaming", nil)
+		assert.Empty(t, server.macro.recordings)
+	})
+
+	t.Run("captures delays between calls", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		now := time.Date(2026, 1, 15, 20, 0, 0, 0, time.UTC)
+		server.macro.now = func() time.Time { return now }
+
+		decodeMacroResult(t, callTool(t, session, "start_macro_recording", map[string]any{
+			"name": "Intro", "capture_delays": true, "min_delay_ms": 500,
+		}))
+
+		now = now.Add(3 * time.Second)
+		callTool(t, session, "set_current_scene", map[string]any{"scene_name": "Starting Soon"})
+		now = now.Add(1500 * time.Millisecond)
+		callTool(t, session, "set_current_scene", map[string]any{"scene_name": "Gaming"})
+		now = now.Add(100 * time.Millisecond)
+		callTool(t, session, "toggle_input_mute", map[string]any{"input_name": "Microphone"})
+		now = now.Add(time.Hour)
+		callTool(t, session, "start_streaming", nil)
+
+		result := decodeMacroResult(t, callTool(t, session, "stop_macro_recording", nil))
+		types := make([]string, len(result.Actions))
+		for i, action := range result.Actions {
+			types[i] = action.Type
+		}
+		// No delay before the first action; gaps under min_delay_ms are dropped
+		assert.Equal(t, []string{"set_scene", "delay", "set_scene", "toggle_mute", "delay", "start_streaming"}, types)
+		assert.EqualValues(t, 1500, result.Actions[1].Parameters["delay_ms"])
+		assert.EqualValues(t, maxMacroDelayMs, result.Actions[4].Parameters["delay_ms"])
+	})
+
+	t.Run("captures steps of a successful batch", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		decodeMacroResult(t, callTool(t, session, "start_macro_recording", map[string]any{"name": "Batch"}))
+
+		res, _ := callExecuteBatch(t, session,
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Gaming"}},
+			map[string]any{"tool": "toggle_source_visibility", "arguments": map[string]any{"scene_name": "Scene 1", "source_id": 1}},
+		)
+		require.False(t, res.IsError, toolResultText(res))
+
+		res, _ = callExecuteBatch(t, session,
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Scene 2"}},
+			map[string]any{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Missing"}},
+		)
+		require.True(t, res.IsError, "failed batch steps are not captured")
+
+		result := decodeMacroResult(t, callTool(t, session, "stop_macro_recording", nil))
+		assert.Empty(t, result.Skipped)
+		require.Len(t, result.Actions, 2)
+		assert.Equal(t, automation.ActionTypeSetScene, result.Actions[0].Type)
+		assert.Equal(t, automation.ActionTypeToggleVisibility, result.Actions[1].Type)
+		assert.EqualValues(t, 1, result.Actions[1].Parameters["source_id"])
+	})
+
+	t.Run("captures only the recording session's calls", func(t *testing.T) {
+		server, _, db := testServerForToolConfig(t)
+		recorder := connectTestClient(t, server, nil)
+		other := connectAnotherClient(t, server)
+
+		decodeMacroResult(t, callTool(t, recorder, "start_macro_recording", map[string]any{"name": "Mine"}))
+		callTool(t, other, "set_current_scene", map[string]any{"scene_name": "Gaming"})
+		callTool(t, other, "create_scene", map[string]any{"scene_name": "Interview"})
+		callTool(t, recorder, "toggle_input_mute", map[string]any{"input_name": "Microphone"})
+		res, _ := callExecuteBatch(t, other,
+			map[string]any{"tool": "start_streaming"},
+		)
+		require.False(t, res.IsError, toolResultText(res))
+
+		// The other session cannot stop it, but can record its own macro
+		res = callTool(t, other, "stop_macro_recording", nil)
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "no macro is being recorded in this session")
+		decodeMacroResult(t, callTool(t, other, "start_macro_recording", map[string]any{"name": "Theirs"}))
+		callTool(t, other, "start_recording", nil)
+
+		result := decodeMacroResult(t, callTool(t, recorder, "stop_macro_recording", nil))
+		assert.Empty(t, result.Skipped)
+		require.Len(t, result.Actions, 1)
+		assert.Equal(t, automation.ActionTypeToggleMute, result.Actions[0].Type)
+
+		theirs := decodeMacroResult(t, callTool(t, other, "stop_macro_recording", nil))
+		require.Len(t, theirs.Actions, 1)
+		assert.Equal(t, automation.ActionTypeStartRecording, theirs.Actions[0].Type)
+
+		rule, err := db.GetAutomationRuleByName(ctx, "Mine")
+		require.NoError(t, err)
+		assert.Len(t, rule.Actions, 1)
+		assert.Empty(t, server.macro.recordings)
+	})
+
+	t.Run("rejects invalid start and stop", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		res := callTool(t, session, "stop_macro_recording", nil)
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "no macro is being recorded")
+
+		decodeMacroResult(t, callTool(t, session, "start_macro_recording", map[string]any{"name": "First"}))
+		res = callTool(t, session, "start_macro_recording", map[string]any{"name": "Second"})
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "already being recorded")
+
+		// Nothing captured: nothing is saved and the recording ends
+		res = callTool(t, session, "stop_macro_recording", nil)
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "nothing was saved")
+		assert.Empty(t, server.macro.recordings)
+	})
+
+	t.Run("rejects an existing rule name", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		decodeMacroResult(t, callTool(t, session, "start_macro_recording", map[string]any{"name": "Dup"}))
+		callTool(t, session, "start_streaming", nil)
+		decodeMacroResult(t, callTool(t, session, "stop_macro_recording", nil))
+
+		res := callTool(t, session, "start_macro_recording", map[string]any{"name": "Dup"})
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "already exists")
+	})
+
+	t.Run("discard does not save", func(t *testing.T) {
+		server, _, db := testServerForToolConfig(t)
+		session := connectTestClient(t, server, nil)
+
+		decodeMacroResult(t, callTool(t, session, "start_macro_recording", map[string]any{"name": "Scratch"}))
+		callTool(t, session, "start_streaming", nil)
+
+		result := decodeMacroResult(t, callTool(t, session, "stop_macro_recording", map[string]any{"discard": true}))
+		assert.False(t, result.Saved)
+		assert.Equal(t, 1, result.ActionCount)
+
+		_, err := db.GetAutomationRuleByName(ctx, "Scratch")
+		assert.Error(t, err)
+	})
+}
+
+func TestMacroRecorderForgetsClosedSessions(t *testing.T) {
+	open, closed := &mcpsdk.ServerSession{}, &mcpsdk.ServerSession{}
+	m := macroRecorder{recordings: map[*mcpsdk.ServerSession]*macroRecording{
+		open:   {name: "open"},
+		closed: {name: "closed"},
+		nil:    {name: "direct"}, // Handler calls without a session
+	}}
+
+	m.forgetClosedLocked(slices.Values([]*mcpsdk.ServerSession{open}))
+	assert.Len(t, m.recordings, 2)
+	assert.NotNil(t, m.recordings[open])
+	assert.NotNil(t, m.recordings[nil])
+}
@@ -0,0 +1,538 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Tool call policies.
+//
+// Policy rules are stored in SQLite and evaluated before every tool handler.
+// Rules are checked highest priority first; the first enabled rule that
+// targets the tool and whose conditions all hold decides the call. Without a
+// deciding rule the call is allowed. The policy tools themselves are exempt so
+// a broad deny rule cannot lock out its own removal.
+//
+// Because set_policy is exempt, it guards itself: a change that could let
+// through calls the policy now refuses (deleting or changing a deny or
+// confirm rule, or saving an allow rule, which can override one) needs the
+// user's confirmation through elicitation. Otherwise the agent the policy
+// constrains could lift it in one call.
+
+// policyGroupMeta is the group name that targets the always-enabled meta-tools.
+const policyGroupMeta = "Meta"
+
+// maxRateLimitWindow bounds rate-limit windows, and with them how long call
+// times are kept.
+const maxRateLimitWindow = 24 * time.Hour
+
+// policyExemptTools are never subject to policy rules.
+var policyExemptTools = []string{"get_policy", "set_policy"}
+
+// weekdayNames maps the day names accepted in policy time windows.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// obsActivity is the OBS output state policy conditions can depend on.
+type obsActivity struct {
+	Streaming bool
+	Recording bool
+}
+
+// policyEngine holds the loaded policy rules and the recent call times used
+// for rate limits.
+type policyEngine struct {
+	mu    sync.Mutex
+	rules []storage.PolicyRule
+	calls map[string][]time.Time
+	now   func() time.Time
+}
+
+func newPolicyEngine() *policyEngine {
+	return &policyEngine{
+		calls: make(map[string][]time.Time),
+		now:   time.Now,
+	}
+}
+
+// setRules replaces the loaded rules. Rules must be ordered highest priority first.
+func (p *policyEngine) setRules(rules []storage.PolicyRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = rules
+}
+
+// decide returns the rule that decides a call to toolName, or nil when no
+// rule applies. activity is only called when a matching rule depends on OBS
+// state, and never with the lock held, so OBS round-trips do not hold up
+// other calls. With record set, a call the decision allows is counted for
+// rate limits in the same critical section as the check, so concurrent calls
+// cannot exceed a limit.
+func (p *policyEngine) decide(toolName string, activity func() (obsActivity, error), record bool) *storage.PolicyRule {
+	p.mu.Lock()
+	rules := p.rules // setRules replaces the slice, never its elements
+	p.mu.Unlock()
+
+	var state *obsActivity
+	var stateErr error
+	for _, rule := range rules {
+		c := rule.Conditions
+		if rule.Enabled && policyTargets(rule, toolName) && (c.WhileStreaming != nil || c.WhileRecording != nil) {
+			current, err := activity()
+			state, stateErr = &current, err
+			break
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled || !policyTargets(*rule, toolName) {
+			continue
+		}
+
+		c := rule.Conditions
+		if c.WhileStreaming != nil || c.WhileRecording != nil {
+			if stateErr != nil {
+				log.Printf("Warning: policy '%s' skipped: failed to read OBS state: %v", rule.Name, stateErr)
+				continue
+			}
+			if c.WhileStreaming != nil && *c.WhileStreaming != state.Streaming {
+				continue
+			}
+			if c.WhileRecording != nil && *c.WhileRecording != state.Recording {
+				continue
+			}
+		}
+		if c.TimeWindow != nil && !inTimeWindow(*c.TimeWindow, now) {
+			continue
+		}
+		if c.RateLimit != nil && p.recentCalls(toolName, now, c.RateLimit.WindowSeconds) < c.RateLimit.MaxCalls {
+			continue
+		}
+
+		decided := *rule
+		if record && decided.Effect == storage.PolicyEffectAllow {
+			p.addCall(toolName, now)
+		}
+		return &decided
+	}
+
+	if record {
+		p.addCall(toolName, now)
+	}
+	return nil
+}
+
+// recordCall notes an allowed call for rate limiting.
+func (p *policyEngine) recordCall(toolName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addCall(toolName, p.now())
+}
+
+// addCall notes a call at now, dropping calls older than any window. The
+// caller must hold p.mu.
+func (p *policyEngine) addCall(toolName string, now time.Time) {
+	calls := p.calls[toolName]
+	cutoff := now.Add(-maxRateLimitWindow)
+	for len(calls) > 0 && calls[0].Before(cutoff) {
+		calls = calls[1:]
+	}
+	p.calls[toolName] = append(calls, now)
+}
+
+// recentCalls counts calls to toolName within the last windowSeconds.
+func (p *policyEngine) recentCalls(toolName string, now time.Time, windowSeconds int) int {
+	cutoff := now.Add(-time.Duration(windowSeconds) * time.Second)
+	count := 0
+	for _, t := range p.calls[toolName] {
+		if t.After(cutoff) {
+			count++
+		}
+	}
+	return count
+}
+
+// policyTargets reports whether a rule applies to calls to toolName.
+func policyTargets(rule storage.PolicyRule, toolName string) bool {
+	if rule.Tool != "" {
+		return rule.Tool == "*" || rule.Tool == toolName
+	}
+	if rule.Group == policyGroupMeta {
+		return slices.Contains(MetaToolNames, toolName)
+	}
+	if meta := toolGroupMetadata[rule.Group]; meta != nil {
+		return slices.Contains(meta.ToolNames, toolName)
+	}
+	return false
+}
+
+// inTimeWindow reports whether now falls inside the window. A window whose
+// end is before its start runs past midnight and belongs to the day it started.
+func inTimeWindow(w storage.PolicyTimeWindow, now time.Time) bool {
+	loc := time.Local
+	if w.Timezone != "" {
+		l, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return false
+		}
+		loc = l
+	}
+	start, err1 := parseClock(w.Start)
+	end, err2 := parseClock(w.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	now = now.In(loc)
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+
+	switch {
+	case start <= end:
+		if minute < start || minute >= end {
+			return false
+		}
+	case minute >= start:
+		// Late part of an overnight window
+	case minute < end:
+		day = (day + 6) % 7 // Early part: the window started yesterday
+	default:
+		return false
+	}
+
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekdayNames[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s': use HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validatePolicyRule checks a rule before it is saved.
+func validatePolicyRule(rule storage.PolicyRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("policy name is required")
+	}
+	if (rule.Tool == "") == (rule.Group == "") {
+		return fmt.Errorf("set exactly one of tool or group")
+	}
+	if rule.Tool != "" && rule.Tool != "*" {
+		if _, ok := toolSpecs[rule.Tool]; !ok {
+			return fmt.Errorf("unknown tool '%s'", rule.Tool)
+		}
+	}
+	if rule.Group != "" && rule.Group != policyGroupMeta && toolGroupMetadata[rule.Group] == nil {
+		return fmt.Errorf("unknown group '%s'. Valid groups: %v and %s", rule.Group, ToolGroupOrder, policyGroupMeta)
+	}
+
+	switch rule.Effect {
+	case storage.PolicyEffectAllow, storage.PolicyEffectDeny, storage.PolicyEffectConfirm:
+	default:
+		return fmt.Errorf("invalid effect '%s': use allow, deny, or confirm", rule.Effect)
+	}
+
+	if w := rule.Conditions.TimeWindow; w != nil {
+		if _, err := parseClock(w.Start); err != nil {
+			return fmt.Errorf("time_window start: %w", err)
+		}
+		if _, err := parseClock(w.End); err != nil {
+			return fmt.Errorf("time_window end: %w", err)
+		}
+		if w.Start == w.End {
+			return fmt.Errorf("time_window start and end must differ")
+		}
+		for _, day := range w.Days {
+			if _, ok := weekdayNames[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("invalid day '%s': use mon, tue, wed, thu, fri, sat, or sun", day)
+			}
+		}
+		if w.Timezone != "" {
+			if _, err := time.LoadLocation(w.Timezone); err != nil {
+				return fmt.Errorf("invalid timezone '%s': %w", w.Timezone, err)
+			}
+		}
+	}
+
+	if r := rule.Conditions.RateLimit; r != nil {
+		if r.MaxCalls < 1 {
+			return fmt.Errorf("rate_limit max_calls must be at least 1")
+		}
+		if r.WindowSeconds < 1 || time.Duration(r.WindowSeconds)*time.Second > maxRateLimitWindow {
+			return fmt.Errorf("rate_limit window_seconds must be between 1 and %d", int(maxRateLimitWindow.Seconds()))
+		}
+	}
+
+	return nil
+}
+
+// withPolicy wraps a tool handler so policy rules are checked before it runs.
+func withPolicy[In any](s *Server, toolName string, handler mcpsdk.ToolHandlerFor[In, any]) mcpsdk.ToolHandlerFor[In, any] {
+	if slices.Contains(policyExemptTools, toolName) {
+		return handler
+	}
+	return func(ctx context.Context, request *mcpsdk.CallToolRequest, input In) (*mcpsdk.CallToolResult, any, error) {
+		if err := s.checkPolicy(ctx, request, toolName); err != nil {
+			return nil, nil, err
+		}
+		return handler(ctx, request, input)
+	}
+}
+
+// checkPolicy applies the policy rules to a call and returns an error when
+// the call is refused. Refusals are recorded in action history.
+func (s *Server) checkPolicy(ctx context.Context, request *mcpsdk.CallToolRequest, toolName string) error {
+	if s.policy == nil {
+		return nil
+	}
+
+	rule := s.policy.decide(toolName, s.obsActivity, true)
+	if rule == nil || rule.Effect == storage.PolicyEffectAllow {
+		return nil
+	}
+
+	var err error
+	switch rule.Effect {
+	case storage.PolicyEffectDeny:
+		err = fmt.Errorf("call to %s denied by policy '%s'", toolName, rule.Name)
+	case storage.PolicyEffectConfirm:
+		confirmed, elicitErr := ElicitPolicyConfirmation(ctx, getSession(request), toolName, *rule)
+		switch {
+		case elicitErr != nil:
+			err = fmt.Errorf("call to %s requires confirmation by policy '%s', which could not be requested: %w", toolName, rule.Name, elicitErr)
+		case !confirmed:
+			err = fmt.Errorf("call to %s was not confirmed (policy '%s')", toolName, rule.Name)
+		default:
+			s.policy.recordCall(toolName)
+			return nil
+		}
+	}
+
+	if rule.Message != "" && rule.Effect == storage.PolicyEffectDeny {
+		err = fmt.Errorf("%w: %s", err, rule.Message)
+	}
+	log.Printf("Policy refused %s: %v", toolName, err)
+	s.recordAction(ctx, toolName, fmt.Sprintf("Refused by policy '%s'", rule.Name), nil, map[string]string{"error": err.Error()}, false, 0)
+	return err
+}
+
+// obsActivity reads the streaming and recording state for policy conditions.
+func (s *Server) obsActivity() (obsActivity, error) {
+	streaming, err := s.obsClient.GetStreamingStatus()
+	if err != nil {
+		return obsActivity{}, err
+	}
+	recording, err := s.obsClient.GetRecordingStatus()
+	if err != nil {
+		return obsActivity{}, err
+	}
+	return obsActivity{Streaming: streaming.Active, Recording: recording.Active}, nil
+}
+
+// loadPolicies reads the policy rules from storage into the engine.
+func (s *Server) loadPolicies(ctx context.Context) error {
+	rules, err := s.storage.ListPolicyRules(ctx)
+	if err != nil {
+		return err
+	}
+	s.policy.setRules(rules)
+	return nil
+}
+
+// =============================================================================
+// Policy tools
+// =============================================================================
+
+// GetPolicyInput is the input for get_policy
+type GetPolicyInput struct {
+	Tool string `json:"tool,omitempty" jsonschema:"Only list rules that apply to this tool, and report how a call to it would be decided now"`
+}
+
+// SetPolicyInput is the input for set_policy
+type SetPolicyInput struct {
+	Name       string                   `json:"name" jsonschema:"Unique rule name; an existing rule with this name is replaced"`
+	Tool       string                   `json:"tool,omitempty" jsonschema:"Tool the rule applies to, or * for every tool (set tool or group)"`
+	Group      string                   `json:"group,omitempty" jsonschema:"Tool group the rule applies to, e.g. Core or Audio, or Meta for meta-tools (set tool or group)"`
+	Effect     string                   `json:"effect,omitempty" jsonschema:"allow, deny, or confirm (required unless deleting)"`
+	Conditions storage.PolicyConditions `json:"conditions,omitempty" jsonschema:"When the rule applies: while_streaming, while_recording, time_window {start, end, days, timezone}, rate_limit {max_calls, window_seconds}. All set conditions must hold"`
+	Message    string                   `json:"message,omitempty" jsonschema:"Explanation added to the error when a call is denied, or shown when asking for confirmation"`
+	Priority   int                      `json:"priority,omitempty" jsonschema:"Higher priority rules are checked first (default: 0)"`
+	Enabled    *bool                    `json:"enabled,omitempty" jsonschema:"Whether the rule is active (default: true)"`
+	Delete     bool                     `json:"delete,omitempty" jsonschema:"Delete the rule with this name instead of saving it"`
+}
+
+func (s *Server) handleGetPolicy(ctx context.Context, request *mcpsdk.CallToolRequest, input GetPolicyInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Getting policy rules (tool=%s)", input.Tool)
+
+	rules, err := s.storage.ListPolicyRules(ctx)
+	if err != nil {
+		s.recordAction(ctx, "get_policy", "Get policy", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("failed to list policy rules: %w", err)
+	}
+
+	result := PolicyResult{Rules: []storage.PolicyRule{}}
+	for _, rule := range rules {
+		if input.Tool == "" || policyTargets(rule, input.Tool) {
+			result.Rules = append(result.Rules, rule)
+		}
+	}
+	result.Count = len(result.Rules)
+	result.Message = fmt.Sprintf("Found %d policy rules", result.Count)
+
+	if input.Tool != "" {
+		decision := &PolicyDecision{Tool: input.Tool, Effect: storage.PolicyEffectAllow}
+		if slices.Contains(policyExemptTools, input.Tool) {
+			decision.Exempt = true
+		} else if s.policy != nil {
+			if rule := s.policy.decide(input.Tool, s.obsActivity, false); rule != nil {
+				decision.Effect = rule.Effect
+				decision.Rule = rule.Name
+			}
+		}
+		result.Decision = decision
+		result.Message += fmt.Sprintf("; a call to %s would currently be: %s", input.Tool, decision.Effect)
+	}
+
+	s.recordAction(ctx, "get_policy", "Get policy", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+func (s *Server) handleSetPolicy(ctx context.Context, request *mcpsdk.CallToolRequest, input SetPolicyInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Setting policy rule: %s (delete=%v)", input.Name, input.Delete)
+
+	action := "Set policy rule"
+	if input.Delete {
+		action = "Delete policy rule"
+	}
+	if err := s.confirmPolicyChange(ctx, request, input); err != nil {
+		s.recordAction(ctx, "set_policy", action, input, nil, false, time.Since(start))
+		return nil, nil, err
+	}
+
+	if input.Delete {
+		if err := s.storage.DeletePolicyRule(ctx, input.Name); err != nil {
+			s.recordAction(ctx, "set_policy", "Delete policy rule", input, nil, false, time.Since(start))
+			return nil, nil, err
+		}
+		if err := s.reloadPolicies(ctx); err != nil {
+			return nil, nil, err
+		}
+		result := PolicyChangeResult{Name: input.Name, Deleted: true, Message: fmt.Sprintf("Deleted policy rule '%s'", input.Name)}
+		s.recordAction(ctx, "set_policy", "Delete policy rule", input, result, true, time.Since(start))
+		return nil, result, nil
+	}
+
+	rule := storage.PolicyRule{
+		Name:       input.Name,
+		Tool:       input.Tool,
+		Group:      input.Group,
+		Effect:     input.Effect,
+		Conditions: input.Conditions,
+		Message:    input.Message,
+		Priority:   input.Priority,
+		Enabled:    input.Enabled == nil || *input.Enabled,
+	}
+	if err := validatePolicyRule(rule); err != nil {
+		s.recordAction(ctx, "set_policy", "Set policy rule", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("invalid policy rule: %w", err)
+	}
+
+	id, err := s.storage.SavePolicyRule(ctx, rule)
+	if err != nil {
+		s.recordAction(ctx, "set_policy", "Set policy rule", input, nil, false, time.Since(start))
+		return nil, nil, err
+	}
+	rule.ID = id
+	if err := s.reloadPolicies(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	target := rule.Tool
+	if target == "" {
+		target = "group " + rule.Group
+	}
+	result := PolicyChangeResult{
+		Name:    rule.Name,
+		Rule:    &rule,
+		Message: fmt.Sprintf("Saved policy rule '%s': %s %s", rule.Name, rule.Effect, target),
+	}
+	s.recordAction(ctx, "set_policy", "Set policy rule", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// confirmPolicyChange asks the user to confirm a set_policy call that could
+// weaken the policy: deleting or replacing a deny or confirm rule, or saving
+// an allow rule. Changes that only add restrictions go through as they are.
+func (s *Server) confirmPolicyChange(ctx context.Context, request *mcpsdk.CallToolRequest, input SetPolicyInput) error {
+	rules, err := s.storage.ListPolicyRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list policy rules: %w", err)
+	}
+	var existing *storage.PolicyRule
+	for i := range rules {
+		if rules[i].Name == input.Name {
+			existing = &rules[i]
+			break
+		}
+	}
+
+	var change string
+	switch {
+	case existing != nil && existing.Effect != storage.PolicyEffectAllow && input.Delete:
+		change = fmt.Sprintf("delete %s rule '%s'", existing.Effect, existing.Name)
+	case existing != nil && existing.Effect != storage.PolicyEffectAllow:
+		change = fmt.Sprintf("replace %s rule '%s'", existing.Effect, existing.Name)
+	case !input.Delete && input.Effect == storage.PolicyEffectAllow:
+		change = fmt.Sprintf("save allow rule '%s', which can override deny and confirm rules", input.Name)
+	default:
+		return nil
+	}
+
+	confirmed, err := ElicitPolicyChangeConfirmation(ctx, getSession(request), change)
+	if err != nil {
+		return fmt.Errorf("policy change requires confirmation (%s), which could not be requested: %w", change, err)
+	}
+	if !confirmed {
+		return fmt.Errorf("policy change was not confirmed (%s)", change)
+	}
+	return nil
+}
+
+// reloadPolicies refreshes the engine after a policy change.
+func (s *Server) reloadPolicies(ctx context.Context) error {
+	if s.policy == nil {
+		return nil
+	}
+	if err := s.loadPolicies(ctx); err != nil {
+		return fmt.Errorf("policy saved but failed to reload rules: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInTimeWindow(t *testing.T) {
+	// 2026-03-06 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window storage.PolicyTimeWindow
+		now    time.Time
+		want   bool
+	}{
+		{"inside same-day window", storage.PolicyTimeWindow{Start: "09:00", End: "17:00", Timezone: "UTC"}, at(6, 12, 0), true},
+		{"end is exclusive", storage.PolicyTimeWindow{Start: "09:00", End: "17:00", Timezone: "UTC"}, at(6, 17, 0), false},
+		{"late part of overnight window", storage.PolicyTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, at(6, 23, 30), true},
+		{"early part of overnight window", storage.PolicyTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, at(7, 2, 0), true},
+		{"outside overnight window", storage.PolicyTimeWindow{Start: "22:00", End: "06:00", Timezone: "UTC"}, at(6, 12, 0), false},
+		{"matching day", storage.PolicyTimeWindow{Start: "09:00", End: "17:00", Days: []string{"fri"}, Timezone: "UTC"}, at(6, 12, 0), true},
+		{"other day", storage.PolicyTimeWindow{Start: "09:00", End: "17:00", Days: []string{"Mon"}, Timezone: "UTC"}, at(6, 12, 0), false},
+		{"overnight window belongs to the day it started", storage.PolicyTimeWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}, Timezone: "UTC"}, at(7, 2, 0), true},
+		{"time zone is applied", storage.PolicyTimeWindow{Start: "09:00", End: "10:00", Timezone: "America/New_York"}, at(6, 14, 30), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, inTimeWindow(tt.window, tt.now))
+		})
+	}
+}
+
+func TestPolicyEngineDecide(t *testing.T) {
+	idle := func() (obsActivity, error) { return obsActivity{}, nil }
+	live := func() (obsActivity, error) { return obsActivity{Streaming: true}, nil }
+	streaming := true
+
+	t.Run("first matching rule by priority decides", func(t *testing.T) {
+		p := newPolicyEngine()
+		p.setRules([]storage.PolicyRule{
+			{Name: "allow-mute", Tool: "toggle_input_mute", Effect: storage.PolicyEffectAllow, Priority: 10, Enabled: true},
+			{Name: "no-audio", Group: "Audio", Effect: storage.PolicyEffectDeny, Enabled: true},
+		})
+
+		assert.Equal(t, "allow-mute", p.decide("toggle_input_mute", idle, false).Name)
+		assert.Equal(t, "no-audio", p.decide("set_input_volume", idle, false).Name)
+		assert.Nil(t, p.decide("list_scenes", idle, false))
+	})
+
+	t.Run("skips disabled rules and unmet conditions", func(t *testing.T) {
+		p := newPolicyEngine()
+		p.setRules([]storage.PolicyRule{
+			{Name: "disabled", Tool: "*", Effect: storage.PolicyEffectDeny},
+			{Name: "live-only", Tool: "set_current_scene", Effect: storage.PolicyEffectConfirm, Enabled: true,
+				Conditions: storage.PolicyConditions{WhileStreaming: &streaming}},
+		})
+
+		assert.Nil(t, p.decide("set_current_scene", idle, false))
+		assert.Equal(t, "live-only", p.decide("set_current_scene", live, false).Name)
+	})
+
+	t.Run("skips rules whose OBS state cannot be read", func(t *testing.T) {
+		p := newPolicyEngine()
+		p.setRules([]storage.PolicyRule{
+			{Name: "live-only", Tool: "*", Effect: storage.PolicyEffectDeny, Enabled: true,
+				Conditions: storage.PolicyConditions{WhileStreaming: &streaming}},
+		})
+
+		assert.Nil(t, p.decide("list_scenes", func() (obsActivity, error) { return obsActivity{}, errors.New("not connected") }, false))
+	})
+
+	t.Run("rate limit applies once the limit is reached", func(t *testing.T) {
+		now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+		p := newPolicyEngine()
+		p.now = func() time.Time { return now }
+		p.setRules([]storage.PolicyRule{
+			{Name: "throttle", Tool: "save_replay_buffer", Effect: storage.PolicyEffectDeny, Enabled: true,
+				Conditions: storage.PolicyConditions{RateLimit: &storage.PolicyRateLimit{MaxCalls: 2, WindowSeconds: 60}}},
+		})
+
+		for i := 0; i < 2; i++ {
+			require.Nil(t, p.decide("save_replay_buffer", idle, false))
+			p.recordCall("save_replay_buffer")
+		}
+		assert.NotNil(t, p.decide("save_replay_buffer", idle, false))
+		assert.Nil(t, p.decide("list_scenes", idle, false), "limits are counted per tool")
+
+		now = now.Add(61 * time.Second)
+		assert.Nil(t, p.decide("save_replay_buffer", idle, false))
+	})
+
+	t.Run("concurrent calls cannot exceed a rate limit", func(t *testing.T) {
+		p := newPolicyEngine()
+		p.setRules([]storage.PolicyRule{
+			{Name: "throttle", Tool: "save_replay_buffer", Effect: storage.PolicyEffectDeny, Enabled: true,
+				Conditions: storage.PolicyConditions{RateLimit: &storage.PolicyRateLimit{MaxCalls: 3, WindowSeconds: 60}}},
+		})
+
+		var allowed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if p.decide("save_replay_buffer", idle, true) == nil {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(3), allowed.Load())
+	})
+
+	t.Run("reads OBS state without holding the lock", func(t *testing.T) {
+		p := newPolicyEngine()
+		p.setRules([]storage.PolicyRule{
+			{Name: "live-only", Tool: "*", Effect: storage.PolicyEffectDeny, Enabled: true,
+				Conditions: storage.PolicyConditions{WhileStreaming: &streaming}},
+		})
+
+		unlocked := false
+		rule := p.decide("list_scenes", func() (obsActivity, error) {
+			if p.mu.TryLock() {
+				unlocked = true
+				p.mu.Unlock()
+			}
+			return obsActivity{Streaming: true}, nil
+		}, false)
+		assert.True(t, unlocked)
+		assert.Equal(t, "live-only", rule.Name)
+	})
+}
+
+func TestValidatePolicyRule(t *testing.T) {
+	valid := storage.PolicyRule{Name: "r", Tool: "start_streaming", Effect: storage.PolicyEffectDeny}
+	require.NoError(t, validatePolicyRule(valid))
+
+	tests := []struct {
+		name   string
+		modify func(r *storage.PolicyRule)
+		errMsg string
+	}{
+		{"missing name", func(r *storage.PolicyRule) { r.Name = "" }, "name is required"},
+		{"tool and group", func(r *storage.PolicyRule) { r.Group = "Audio" }, "exactly one of tool or group"},
+		{"unknown tool", func(r *storage.PolicyRule) { r.Tool = "format_disk" }, "unknown tool"},
+		{"unknown group", func(r *storage.PolicyRule) { r.Tool = ""; r.Group = "Lights" }, "unknown group"},
+		{"invalid effect", func(r *storage.PolicyRule) { r.Effect = "maybe" }, "invalid effect"},
+		{"invalid time", func(r *storage.PolicyRule) {
+			r.Conditions.TimeWindow = &storage.PolicyTimeWindow{Start: "25:00", End: "06:00"}
+		}, "time_window start"},
+		{"invalid day", func(r *storage.PolicyRule) {
+			r.Conditions.TimeWindow = &storage.PolicyTimeWindow{Start: "22:00", End: "06:00", Days: []string{"someday"}}
+		}, "invalid day"},
+		{"invalid timezone", func(r *storage.PolicyRule) {
+			r.Conditions.TimeWindow = &storage.PolicyTimeWindow{Start: "22:00", End: "06:00", Timezone: "Mars/Olympus"}
+		}, "invalid timezone"},
+		{"invalid rate limit", func(r *storage.PolicyRule) {
+			r.Conditions.RateLimit = &storage.PolicyRateLimit{MaxCalls: 0, WindowSeconds: 60}
+		}, "max_calls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.modify(&rule)
+			err := validatePolicyRule(rule)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestPolicyEnforcement(t *testing.T) {
+	ctx := context.Background()
+
+	// policySession returns a client session on a server with policies enabled.
+	policySession := func(t *testing.T) (*Server, *mcpsdk.ClientSession, *storage.DB) {
+		server, _, db := testServerForToolConfig(t)
+		server.policy = newPolicyEngine()
+		return server, connectTestClient(t, server, nil), db
+	}
+	call := func(t *testing.T, session *mcpsdk.ClientSession, tool string, args map[string]any) *mcpsdk.CallToolResult {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcpsdk.CallToolParams{Name: tool, Arguments: args})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("denies calls with a clear error and records the refusal", func(t *testing.T) {
+		_, session, db := policySession(t)
+
+		res := call(t, session, "set_policy", map[string]any{
+			"name": "no-audio", "group": "Audio", "effect": "deny", "message": "Audio is managed by the producer",
+		})
+		require.False(t, res.IsError, toolResultText(res))
+
+		res = call(t, session, "toggle_input_mute", map[string]any{"input_name": "Microphone"})
+		require.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "denied by policy 'no-audio'")
+		assert.Contains(t, toolResultText(res), "Audio is managed by the producer")
+
+		actions, err := db.GetActionsByTool(ctx, "toggle_input_mute", 1)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.False(t, actions[0].Success)
+		assert.Contains(t, actions[0].Action, "Refused by policy")
+
+		res = call(t, session, "list_scenes", nil)
+		assert.False(t, res.IsError)
+	})
+
+	t.Run("policy tools stay available under a deny-all rule", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		server.policy = newPolicyEngine()
+		session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+			ElicitationHandler: func(ctx context.Context, req *mcpsdk.ElicitRequest) (*mcpsdk.ElicitResult, error) {
+				return &mcpsdk.ElicitResult{Action: "accept", Content: map[string]any{"confirmed": true}}, nil
+			},
+		})
+
+		res := call(t, session, "set_policy", map[string]any{"name": "lockdown", "tool": "*", "effect": "deny"})
+		require.False(t, res.IsError, toolResultText(res))
+		assert.True(t, call(t, session, "list_scenes", nil).IsError)
+
+		res = call(t, session, "get_policy", map[string]any{"tool": "list_scenes"})
+		require.False(t, res.IsError, toolResultText(res))
+		assert.Contains(t, toolResultText(res), `\"effect\":\"deny\"`)
+
+		res = call(t, session, "set_policy", map[string]any{"name": "lockdown", "delete": true})
+		require.False(t, res.IsError, toolResultText(res))
+		assert.False(t, call(t, session, "list_scenes", nil).IsError)
+	})
+
+	t.Run("loosening the policy needs confirmation", func(t *testing.T) {
+		server, _, db := testServerForToolConfig(t)
+		server.policy = newPolicyEngine()
+		var asked []string
+		confirm := false
+		session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+			ElicitationHandler: func(ctx context.Context, req *mcpsdk.ElicitRequest) (*mcpsdk.ElicitResult, error) {
+				asked = append(asked, req.Params.Message)
+				return &mcpsdk.ElicitResult{Action: "accept", Content: map[string]any{"confirmed": confirm}}, nil
+			},
+		})
+		plain := connectTestClient(t, server, nil)
+
+		res := call(t, session, "set_policy", map[string]any{"name": "no-streaming", "tool": "start_streaming", "effect": "deny"})
+		require.False(t, res.IsError, toolResultText(res))
+		assert.Empty(t, asked, "adding a deny rule needs no confirmation")
+
+		for name, args := range map[string]map[string]any{
+			"delete":   {"name": "no-streaming", "delete": true},
+			"weaken":   {"name": "no-streaming", "tool": "start_streaming", "effect": "deny", "enabled": false},
+			"override": {"name": "let-me", "tool": "*", "effect": "allow", "priority": 100},
+		} {
+			res = call(t, plain, "set_policy", args)
+			require.True(t, res.IsError, name)
+			assert.Contains(t, toolResultText(res), "could not be requested", name)
+
+			res = call(t, session, "set_policy", args)
+			require.True(t, res.IsError, name)
+			assert.Contains(t, toolResultText(res), "was not confirmed", name)
+		}
+		require.Len(t, asked, 3)
+		assert.Contains(t, asked[0]+asked[1]+asked[2], "deny rule 'no-streaming'")
+
+		rules, err := db.ListPolicyRules(ctx)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.True(t, rules[0].Enabled)
+		res = call(t, plain, "start_streaming", nil)
+		require.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "denied by policy 'no-streaming'")
+
+		confirm = true
+		res = call(t, session, "set_policy", map[string]any{"name": "no-streaming", "delete": true})
+		require.False(t, res.IsError, toolResultText(res))
+		rules, err = db.ListPolicyRules(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("refuses confirm rules when the client cannot confirm", func(t *testing.T) {
+		_, session, _ := policySession(t)
+
+		res := call(t, session, "set_policy", map[string]any{"name": "confirm-scenes", "tool": "set_current_scene", "effect": "confirm"})
+		require.False(t, res.IsError, toolResultText(res))
+
+		res = call(t, session, "set_current_scene", map[string]any{"scene_name": "Gaming"})
+		require.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "requires confirmation by policy 'confirm-scenes'")
+	})
+
+	t.Run("asks the client to confirm", func(t *testing.T) {
+		server, mock, _ := testServerForToolConfig(t)
+		server.policy = newPolicyEngine()
+		confirm := true
+		session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+			ElicitationHandler: func(ctx context.Context, req *mcpsdk.ElicitRequest) (*mcpsdk.ElicitResult, error) {
+				return &mcpsdk.ElicitResult{Action: "accept", Content: map[string]any{"confirmed": confirm}}, nil
+			},
+		})
+
+		res := call(t, session, "set_policy", map[string]any{"name": "confirm-scenes", "tool": "set_current_scene", "effect": "confirm"})
+		require.False(t, res.IsError, toolResultText(res))
+
+		res = call(t, session, "set_current_scene", map[string]any{"scene_name": "Gaming"})
+		require.False(t, res.IsError, toolResultText(res))
+		_, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Gaming", current)
+
+		confirm = false
+		res = call(t, session, "set_current_scene", map[string]any{"scene_name": "Scene 1"})
+		require.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "was not confirmed")
+	})
+
+	t.Run("rate limits a tool", func(t *testing.T) {
+		_, session, _ := policySession(t)
+
+		res := call(t, session, "set_policy", map[string]any{
+			"name": "throttle-scenes", "tool": "list_scenes", "effect": "deny",
+			"conditions": map[string]any{"rate_limit": map[string]any{"max_calls": 2, "window_seconds": 60}},
+		})
+		require.False(t, res.IsError, toolResultText(res))
+
+		assert.False(t, call(t, session, "list_scenes", nil).IsError)
+		assert.False(t, call(t, session, "list_scenes", nil).IsError)
+		res = call(t, session, "list_scenes", nil)
+		require.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "throttle-scenes")
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		_, session, _ := policySession(t)
+
+		res := call(t, session, "set_policy", map[string]any{"name": "bad", "tool": "no_such_tool", "effect": "deny"})
+		require.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "unknown tool")
+	})
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"context"
+	"log"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// progressReporter sends notifications/progress messages for a tool call.
+// Notifications are only sent when the client attached a progress token to
+// the request; otherwise every method is a no-op, so handlers can report
+// progress unconditionally.
+type progressReporter struct {
+	session *mcpsdk.ServerSession
+	token   any
+}
+
+// newProgressReporter returns a reporter bound to the request's progress token.
+func newProgressReporter(request *mcpsdk.CallToolRequest) *progressReporter {
+	p := &progressReporter{}
+	if request == nil || request.Params == nil || request.Session == nil {
+		return p
+	}
+	p.token = request.Params.GetProgressToken()
+	if p.token != nil {
+		p.session = request.Session
+	}
+	return p
+}
+
+// enabled reports whether the client asked for progress notifications.
+func (p *progressReporter) enabled() bool {
+	return p.session != nil
+}
+
+// report sends a progress notification. Failures are logged and otherwise
+// ignored since progress is advisory.
+func (p *progressReporter) report(ctx context.Context, progress, total int, message string) {
+	if !p.enabled() {
+		return
+	}
+	err := p.session.NotifyProgress(ctx, &mcpsdk.ProgressNotificationParams{
+		ProgressToken: p.token,
+		Progress:      float64(progress),
+		Total:         float64(total),
+		Message:       message,
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Warning: failed to send progress notification: %v", err)
+	}
+}
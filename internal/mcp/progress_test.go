@@ -0,0 +1,246 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/mcp/testutil"
+	"github.com/ironystock/agentic-obs/internal/obs"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestProgressReporterWithoutToken(t *testing.T) {
+	t.Run("nil request", func(t *testing.T) {
+		p := newProgressReporter(nil)
+		assert.False(t, p.enabled())
+		p.report(context.Background(), 1, 2, "ignored") // must not panic
+	})
+
+	t.Run("request without progress token", func(t *testing.T) {
+		p := newProgressReporter(&mcpsdk.CallToolRequest{Params: &mcpsdk.CallToolParamsRaw{Name: "list_scenes"}})
+		assert.False(t, p.enabled())
+	})
+}
+
+func TestApplyScenePresetProgress(t *testing.T) {
+	server, _, db := testServerForToolConfig(t)
+
+	_, err := db.CreateScenePreset(context.Background(), storage.ScenePreset{
+		Name:      "Progress Preset",
+		SceneName: "Scene 1",
+		Sources: []storage.SourceState{
+			{Name: "Webcam", Visible: false},
+			{Name: "Text", Visible: true},
+		},
+	})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var notifications []*mcpsdk.ProgressNotificationParams
+	session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcpsdk.ProgressNotificationClientRequest) {
+			mu.Lock()
+			notifications = append(notifications, req.Params)
+			mu.Unlock()
+		},
+	})
+
+	params := &mcpsdk.CallToolParams{
+		Name:      "apply_scene_preset",
+		Arguments: map[string]any{"preset_name": "Progress Preset"},
+	}
+	params.SetProgressToken("preset-token")
+
+	res, err := session.CallTool(context.Background(), params)
+	require.NoError(t, err)
+	require.False(t, res.IsError, toolResultText(res))
+
+	// Notifications are delivered asynchronously to the client handler
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(notifications) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, n := range notifications {
+		assert.Equal(t, "preset-token", n.ProgressToken)
+		assert.Equal(t, float64(i+1), n.Progress)
+		assert.Equal(t, float64(2), n.Total)
+	}
+}
+
+func TestApplyScenePresetCancelled(t *testing.T) {
+	server, mock, db := testServerWithStorage(t)
+
+	_, err := db.CreateScenePreset(context.Background(), storage.ScenePreset{
+		Name:      "Cancelled Preset",
+		SceneName: "Scene 1",
+		Sources:   []storage.SourceState{{Name: "Webcam", Visible: false}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err = server.handleApplyScenePreset(ctx, nil, PresetNameInput{PresetName: "Cancelled Preset"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Nothing was applied
+	scene, _ := mock.GetSceneByName("Scene 1")
+	for _, src := range scene.Sources {
+		if src.Name == "Webcam" {
+			assert.True(t, src.Enabled)
+		}
+	}
+}
+
+// presetFailingClient fails the failAt'th ApplyScenePreset call.
+type presetFailingClient struct {
+	*testutil.MockOBSClient
+	calls, failAt int
+}
+
+func (c *presetFailingClient) ApplyScenePreset(sceneName string, sources []obs.SourceState) error {
+	c.calls++
+	if c.calls == c.failAt {
+		return errors.New("connection lost")
+	}
+	return c.MockOBSClient.ApplyScenePreset(sceneName, sources)
+}
+
+func TestApplyScenePresetPartial(t *testing.T) {
+	webcamEnabled := func(t *testing.T, mock *testutil.MockOBSClient) bool {
+		t.Helper()
+		scene, err := mock.GetSceneByName("Scene 1")
+		require.NoError(t, err)
+		for _, src := range scene.Sources {
+			if src.Name == "Webcam" {
+				return src.Enabled
+			}
+		}
+		t.Fatal("no Webcam source")
+		return false
+	}
+	setup := func(t *testing.T) (*Server, *testutil.MockOBSClient) {
+		server, mock, db := testServerForToolConfig(t)
+		_, err := db.CreateScenePreset(context.Background(), storage.ScenePreset{
+			Name:      "Partial Preset",
+			SceneName: "Scene 1",
+			Sources: []storage.SourceState{
+				{Name: "Webcam", Visible: false},
+				{Name: "Text", Visible: false},
+			},
+		})
+		require.NoError(t, err)
+		server.obsClient = &presetFailingClient{MockOBSClient: mock, failAt: 2}
+		return server, mock
+	}
+
+	t.Run("undo reverts the applied sources", func(t *testing.T) {
+		server, mock := setup(t)
+
+		_, _, err := server.handleApplyScenePreset(context.Background(), nil, PresetNameInput{PresetName: "Partial Preset"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed at 'Text' after 1 of 2 sources (applied: Webcam")
+		assert.False(t, webcamEnabled(t, mock))
+
+		_, result, err := server.handleUndoLastAction(context.Background(), nil, struct{}{})
+		require.NoError(t, err)
+		require.Len(t, result.(UndoResult).Undone, 1)
+		assert.Equal(t, "apply_scene_preset", result.(UndoResult).Undone[0].ToolName)
+		assert.True(t, webcamEnabled(t, mock))
+	})
+
+	t.Run("a batch rolls back the applied sources", func(t *testing.T) {
+		server, mock := setup(t)
+		session := connectTestClient(t, server, nil)
+
+		res, result := callExecuteBatch(t, session,
+			map[string]any{"tool": "apply_scene_preset", "arguments": map[string]any{"preset_name": "Partial Preset"}},
+		)
+		assert.True(t, res.IsError)
+		assert.True(t, result.RolledBack, result.Message)
+		assert.Equal(t, compensationReverted, result.Steps[0].Compensation)
+		assert.True(t, webcamEnabled(t, mock))
+	})
+}
+
+// testServerWithAutomation creates the given rules and returns a server
+// with a running automation engine.
+func testServerWithAutomation(t *testing.T, rules ...storage.AutomationRule) (*Server, *storage.DB) {
+	t.Helper()
+
+	server, mock, db := testServerWithStorage(t)
+	for _, rule := range rules {
+		_, err := db.CreateAutomationRule(context.Background(), rule)
+		require.NoError(t, err)
+	}
+
+	server.automationEngine = automation.NewAutomationEngine(db, mock)
+	require.NoError(t, server.automationEngine.Start())
+	t.Cleanup(server.automationEngine.Stop)
+
+	return server, db
+}
+
+func TestTriggerAutomationRuleWait(t *testing.T) {
+	server, db := testServerWithAutomation(t,
+		storage.AutomationRule{
+			Name:          "quick-rule",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions: []storage.RuleAction{
+				{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Gaming"}},
+			},
+		},
+		storage.AutomationRule{
+			Name:          "slow-rule",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions: []storage.RuleAction{
+				{Type: automation.ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": float64(5000)}},
+				{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Scene 2"}},
+			},
+		},
+	)
+
+	t.Run("waits for completion", func(t *testing.T) {
+		_, result, err := server.handleTriggerAutomationRule(context.Background(), nil, TriggerAutomationRuleInput{Name: "quick-rule", Wait: true})
+		require.NoError(t, err)
+
+		resultMap := result.(map[string]interface{})
+		assert.Equal(t, storage.ExecutionStatusCompleted, resultMap["status"])
+		assert.NotZero(t, resultMap["execution_id"])
+	})
+
+	t.Run("cancellation stops the rule", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, _, err := server.handleTriggerAutomationRule(ctx, nil, TriggerAutomationRuleInput{Name: "slow-rule", Wait: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cancelled")
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		rule, err := db.GetAutomationRuleByName(context.Background(), "slow-rule")
+		require.NoError(t, err)
+		executions, err := db.GetRuleExecutions(context.Background(), rule.ID, 1)
+		require.NoError(t, err)
+		require.Len(t, executions, 1)
+		assert.Equal(t, storage.ExecutionStatusCancelled, executions[0].Status)
+	})
+}
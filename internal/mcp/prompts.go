@@ -1184,6 +1184,8 @@ func (s *Server) handleAutomationSetup(ctx context.Context, req *mcpsdk.GetPromp
      * 'replay_buffer_started', 'replay_buffer_stopped', 'replay_buffer_saved'
      * 'studio_mode_state_changed'
    - Optional event_filter narrows matching (e.g., only when scene_name == "Gaming")
+     Values match by equality, or use operators: {"scene_name": {"prefix": "Game"}},
+     {"input_name": {"in": ["Mic", "Desktop Audio"]}}, {"volume_db": {"gt": -30}}
    - Configure one or more actions executed in order
    - Set cooldown_ms to prevent rapid re-triggering`
 	case "schedule":
@@ -1237,14 +1239,27 @@ func (s *Server) handleAutomationSetup(ctx context.Context, req *mcpsdk.GetPromp
      * Virtual cam & replay buffer: 'toggle_virtual_cam', 'save_replay'
      * Studio mode: 'toggle_studio_mode', 'trigger_transition'
      * Hotkeys & flow control: 'trigger_hotkey', 'delay'
+     * Variables: 'set_variable' stores a value that persists between runs
+     * Branching: 'if' with a condition and 'then'/'else' action lists
+     * Outbound calls: 'http_request' with url, method, headers, a templated body,
+       timeout_ms and retries
    - Each action has parameters (scene name, source name, value, milliseconds, etc.)
+   - String parameters can use template variables resolved at run time:
+     {{event.input_name}}, {{rule.name}}, {{obs.current_scene}}, {{var.name}}, with an
+     optional fallback: {{event.scene_name|Main}}
    - Per-action on_error: 'continue' (default) or 'stop' halts the chain
+   - Per-action on_missing for unresolved variables: 'error' (default, the action fails),
+     'empty', or 'keep' the placeholder text
+   - Add a rule 'condition' such as obs.streaming && event.scene_name != 'BRB' to run the
+     rule only when it holds
    - Order actions deliberately — they run sequentially`
 	}
 
 	promptText += `
 
 5. **Test the Rule Manually**
+   - Use simulate_automation_event with an event type and data to see which rules would
+     fire and their resolved actions, without changing OBS
    - Use trigger_automation_rule with the rule name to fire it on demand
    - Verify each action succeeded
    - Check list_rule_executions for the execution record and per-action results
@@ -1252,6 +1267,8 @@ func (s *Server) handleAutomationSetup(ctx context.Context, req *mcpsdk.GetPromp
 6. **Monitor Executions**
    - list_rule_executions — history with status (running/completed/failed), duration, errors
    - Filter by rule name to narrow scope
+   - list_running_executions — runs in progress and the action each is on;
+     cancel_rule_execution stops one at once
    - Investigate failed executions: action index, error text, trigger data captured at dispatch
 
 7. **Manage Existing Rules**
@@ -120,9 +120,15 @@ type Server struct {
 	screenshotMgr    *screenshot.Manager
 	httpServer       *agenthttp.Server
 	automationEngine *automation.AutomationEngine
+	forwarder        *automation.EventForwarder // POSTs OBS events to external URLs; nil when not configured
 	toolGroups       ToolGroupConfig
 	toolGroupMutex   sync.RWMutex // Protects toolGroups for runtime config changes
 	thumbnailCache   *thumbnailCache
+	batchTools       map[string]batchInvoker // Registered tools callable from execute_batch
+	policy           *policyEngine           // Policy rules checked before every tool call
+	readOnly         bool                    // Observer mode: mutating tools are not registered
+	macro            macroRecorder           // Active macro recordings, per session
+	stopLogs         func()                  // Stops relaying component logs to MCP sessions
 	ctx              context.Context
 	cancel           context.CancelFunc
 }
@@ -140,6 +146,9 @@ type ServerConfig struct {
 	HTTPEnabled       bool   // Whether to enable HTTP server (default: true)
 	ThumbnailCacheSec int    // Thumbnail cache duration in seconds (0 to disable)
 	ToolGroups        ToolGroupConfig
+	ReadOnly          bool     // Register only non-mutating tools and reject changes over HTTP
+	ForwardURLs       []string // URLs that OBS events are POSTed to (disabled when empty)
+	ForwardEventTypes []string // Event types to forward (empty forwards all)
 }
 
 // ToolGroupConfig controls which tool categories are enabled
@@ -178,6 +187,7 @@ func NewServer(config ServerConfig) (*Server, error) {
 		ctx:            ctx,
 		cancel:         cancel,
 		toolGroups:     config.ToolGroups,
+		readOnly:       config.ReadOnly,
 		thumbnailCache: newThumbnailCache(5 * time.Second), // 5-second TTL for thumbnails
 	}
 
@@ -189,6 +199,13 @@ func NewServer(config ServerConfig) (*Server, error) {
 	}
 	s.storage = db
 
+	// Load tool call policies
+	s.policy = newPolicyEngine()
+	if err := s.loadPolicies(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+
 	// Initialize OBS client
 	obsClient := obs.NewClient(obs.ConnectionConfig{
 		Host:     config.OBSHost,
@@ -214,6 +231,7 @@ func NewServer(config ServerConfig) (*Server, error) {
 		if config.ThumbnailCacheSec > 0 {
 			httpCfg.ThumbnailCacheSec = config.ThumbnailCacheSec
 		}
+		httpCfg.ReadOnly = config.ReadOnly
 		s.httpServer = agenthttp.NewServer(db, httpCfg)
 		// Set MCP server as status provider for UI endpoints
 		if err := s.httpServer.SetStatusProvider(s); err != nil {
@@ -226,10 +244,34 @@ func NewServer(config ServerConfig) (*Server, error) {
 	screenshotCfg := screenshot.DefaultConfig()
 	s.screenshotMgr = screenshot.NewManager(obsClient, db, screenshotCfg)
 
-	// Initialize automation engine (if enabled)
-	if config.ToolGroups.Automation {
+	// Initialize automation engine (if enabled; rules change OBS, so never in read-only mode)
+	if config.ReadOnly {
+		log.Println("Read-only mode: automation engine disabled")
+	} else if config.ToolGroups.Automation {
 		s.automationEngine = automation.NewAutomationEngine(db, obsClient)
 		log.Println("Automation engine initialized")
+
+		// Webhook rules are fired through the HTTP server
+		if s.httpServer != nil {
+			if err := s.httpServer.SetWebhookHandler(s.automationEngine); err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to set webhook handler: %w", err)
+			}
+		}
+	}
+
+	// Initialize event forwarder (if configured; it only reads events, so it runs in read-only mode too)
+	if len(config.ForwardURLs) > 0 {
+		forwarder, err := automation.NewEventForwarder(automation.ForwarderConfig{
+			URLs:       config.ForwardURLs,
+			EventTypes: config.ForwardEventTypes,
+		})
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to initialize event forwarder: %w", err)
+		}
+		s.forwarder = forwarder
+		log.Printf("Event forwarding initialized for %d URL(s)", len(config.ForwardURLs))
 	}
 
 	// Create MCP server with completion handler
@@ -244,6 +286,9 @@ func NewServer(config ServerConfig) (*Server, error) {
 	)
 	s.mcpServer = mcpServer
 
+	// Relay component logs to clients as notifications/message
+	s.stopLogs = s.startLogForwarding()
+
 	// Register resource handlers
 	s.registerResourceHandlers()
 
@@ -291,6 +336,11 @@ func (s *Server) Start() error {
 		log.Println("Automation engine started")
 	}
 
+	// Start event forwarder (if configured)
+	if s.forwarder != nil {
+		s.forwarder.Start()
+	}
+
 	return nil
 }
 
@@ -319,6 +369,12 @@ func (s *Server) Stop() error {
 		s.thumbnailCache.stop()
 	}
 
+	// Flush forwarded events before shutting down
+	if s.forwarder != nil {
+		s.forwarder.Stop()
+		log.Println("Event forwarder stopped")
+	}
+
 	// Stop automation engine first (depends on OBS connection)
 	if s.automationEngine != nil {
 		s.automationEngine.Stop()
@@ -350,17 +406,33 @@ func (s *Server) Stop() error {
 		log.Printf("Warning: error closing storage: %v", err)
 	}
 
+	// Stop relaying logs to MCP sessions
+	if s.stopLogs != nil {
+		s.stopLogs()
+	}
+
 	log.Println("MCP server stopped")
 	return nil
 }
 
 // recordAction logs a tool action to the action history database.
 // This should be called at the end of each tool handler.
-func (s *Server) recordAction(toolName, action string, input interface{}, output interface{}, success bool, duration time.Duration) {
-	// Skip if storage is not initialized (e.g., in tests)
-	if s.storage == nil {
-		return
-	}
+func (s *Server) recordAction(ctx context.Context, toolName, action string, input interface{}, output interface{}, success bool, duration time.Duration) {
+	s.storeAction(ctx, toolName, action, input, output, success, duration, false)
+}
+
+// recordDryRun logs a dry-run tool call. It is stored like any other action
+// but flagged so history consumers can tell it apart from applied changes.
+func (s *Server) recordDryRun(ctx context.Context, toolName, action string, input interface{}, output interface{}, success bool, duration time.Duration) {
+	s.storeAction(ctx, toolName, action+" (dry run)", input, output, success, duration, true)
+}
+
+// storeAction writes an action history record and returns its ID,
+// or 0 when nothing was stored.
+// Calls made as a step of execute_batch are handed to the batch instead
+// and stored later as children of its record.
+func (s *Server) storeAction(ctx context.Context, toolName, action string, input interface{}, output interface{}, success bool, duration time.Duration, dryRun bool) int64 {
+	batch := batchRecorderFrom(ctx)
 
 	// Convert input/output to JSON strings
 	inputStr := ""
@@ -384,11 +456,31 @@ func (s *Server) recordAction(toolName, action string, input interface{}, output
 		Output:     outputStr,
 		Success:    success,
 		DurationMs: duration.Milliseconds(),
+		DryRun:     dryRun,
+	}
+	a := actorFrom(ctx)
+	record.ActorType = a.Type
+	record.ActorID = a.ID
+	if batch != nil {
+		batch.records = append(batch.records, record)
+		return 0
+	}
+
+	if success && !dryRun {
+		s.macro.capture(sessionFrom(ctx), toolName, inputStr)
 	}
 
-	if _, err := s.storage.RecordAction(s.ctx, record); err != nil {
+	// Skip if storage is not initialized (e.g., in tests)
+	if s.storage == nil {
+		return 0
+	}
+
+	id, err := s.storage.RecordAction(s.ctx, record)
+	if err != nil {
 		log.Printf("Warning: failed to record action history: %v", err)
+		return 0
 	}
+	return id
 }
 
 // SendResourceUpdated notifies clients that a specific resource has been updated
@@ -411,6 +503,15 @@ func (s *Server) handleOBSEventNotification(eventType obs.EventType, data map[st
 		})
 	}
 
+	// Forward to external endpoints
+	if s.forwarder != nil {
+		s.forwarder.Forward(automation.EventPayload{
+			EventType: string(eventType),
+			Data:      data,
+			Timestamp: time.Now(),
+		})
+	}
+
 	// Check if list changed (scene created or removed)
 	if obs.ShouldTriggerListChanged(eventType) {
 		// Resource list changed - clients should re-list resources
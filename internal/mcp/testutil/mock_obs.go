@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/andreykaipov/goobs/api/typedefs"
@@ -75,6 +76,7 @@ type MockOBSClient struct {
 	ErrorOnSetSceneItemLocked    error
 	ErrorOnGetSceneItemLocked    error
 	ErrorOnDuplicateSceneItem    error
+	ErrorOnCreateSceneItem       error
 	ErrorOnRemoveSceneItem       error
 	ErrorOnGetInputKindList      error
 
@@ -371,7 +373,8 @@ func (m *MockOBSClient) GetSceneByName(name string) (*obs.Scene, error) {
 		return nil, fmt.Errorf("scene '%s' not found", name)
 	}
 
-	sources := m.sceneItems[name]
+	// Copy so callers hold a snapshot, as they would from OBS
+	sources := slices.Clone(m.sceneItems[name])
 	if sources == nil {
 		sources = []obs.SceneSource{}
 	}
@@ -768,6 +771,9 @@ func (m *MockOBSClient) SetInputVolume(inputName string, volumeDb *float64, volu
 	if volumeDb != nil {
 		m.inputVolumes[inputName] = *volumeDb
 	}
+	if volumeMul != nil {
+		m.inputVolumes[inputName] = *volumeMul
+	}
 
 	return nil
 }
@@ -1343,6 +1349,70 @@ func (m *MockOBSClient) DuplicateSceneItem(sceneName string, sceneItemID int, de
 	return newID, nil
 }
 
+// CreateSceneItem adds an existing source to a scene.
+func (m *MockOBSClient) CreateSceneItem(sceneName, sourceName string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ErrorOnCreateSceneItem != nil {
+		return 0, m.ErrorOnCreateSceneItem
+	}
+
+	if !m.connected {
+		return 0, fmt.Errorf("not connected to OBS")
+	}
+
+	if _, exists := m.sceneItems[sceneName]; !exists {
+		return 0, fmt.Errorf("scene '%s' not found", sceneName)
+	}
+
+	// The source must already exist as an input or as an item in some scene
+	var sourceKind string
+	found := false
+	for _, src := range m.sources {
+		if src.InputName == sourceName {
+			sourceKind, found = src.InputKind, true
+			break
+		}
+	}
+	for _, items := range m.sceneItems {
+		for _, item := range items {
+			if !found && item.Name == sourceName {
+				sourceKind, found = item.Type, true
+			}
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("source '%s' not found", sourceName)
+	}
+
+	m.nextSceneItemID++
+	newID := m.nextSceneItemID
+
+	m.sceneItems[sceneName] = append(m.sceneItems[sceneName], obs.SceneSource{
+		ID:      newID,
+		Name:    sourceName,
+		Type:    sourceKind,
+		Enabled: true,
+		Visible: true,
+	})
+
+	if m.sceneItemTransforms[sceneName] == nil {
+		m.sceneItemTransforms[sceneName] = make(map[int]*obs.SceneItemTransform)
+	}
+	m.sceneItemTransforms[sceneName][newID] = &obs.SceneItemTransform{
+		ScaleX: 1.0, ScaleY: 1.0,
+		Width: 1920, Height: 1080,
+	}
+
+	if m.sceneItemLocked[sceneName] == nil {
+		m.sceneItemLocked[sceneName] = make(map[int]bool)
+	}
+	m.sceneItemLocked[sceneName][newID] = false
+
+	return newID, nil
+}
+
 // RemoveSceneItem removes a scene item from a scene.
 func (m *MockOBSClient) RemoveSceneItem(sceneName string, sceneItemID int) error {
 	m.mu.Lock()
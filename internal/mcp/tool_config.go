@@ -27,8 +27,8 @@ var ToolGroupOrder = []string{"Core", "Sources", "Audio", "Layout", "Visual", "D
 var toolGroupMetadata = map[string]*ToolGroupMetadata{
 	"Core": {
 		Name:        "Core",
-		Description: "Core OBS tools: scenes, recording, streaming, status, virtual camera, replay buffer, studio mode, and hotkeys",
-		ToolCount:   25,
+		Description: "Core OBS tools: scenes, recording, streaming, status, virtual camera, replay buffer, studio mode, hotkeys, and undo",
+		ToolCount:   28,
 		ToolNames: []string{
 			"list_scenes", "set_current_scene", "create_scene", "remove_scene",
 			"start_recording", "stop_recording", "get_recording_status", "pause_recording", "resume_recording",
@@ -38,6 +38,7 @@ var toolGroupMetadata = map[string]*ToolGroupMetadata{
 			"get_replay_buffer_status", "toggle_replay_buffer", "save_replay_buffer", "get_last_replay",
 			"get_studio_mode_enabled", "toggle_studio_mode", "get_preview_scene", "set_preview_scene",
 			"list_hotkeys", "trigger_hotkey_by_name",
+			"list_undoable_actions", "undo_last_action", "undo_to",
 		},
 	},
 	"Sources": {
@@ -89,13 +90,13 @@ var toolGroupMetadata = map[string]*ToolGroupMetadata{
 	"Automation": {
 		Name:        "Automation",
 		Description: "Automation rule management: event-triggered and scheduled actions",
-		ToolCount:   9,
-		ToolNames:   []string{"list_automation_rules", "get_automation_rule", "create_automation_rule", "update_automation_rule", "delete_automation_rule", "enable_automation_rule", "disable_automation_rule", "trigger_automation_rule", "list_rule_executions"},
+		ToolCount:   26,
+		ToolNames:   []string{"list_automation_rules", "get_automation_rule", "create_automation_rule", "update_automation_rule", "delete_automation_rule", "enable_automation_rule", "disable_automation_rule", "trigger_automation_rule", "list_rule_executions", "list_running_executions", "cancel_rule_execution", "simulate_automation_event", "get_next_runs", "start_macro_recording", "stop_macro_recording", "list_macros", "get_macro", "create_macro", "update_macro", "delete_macro", "run_macro", "export_automation_bundle", "import_automation_bundle", "list_rule_revisions", "diff_rule_revisions", "restore_rule_revision"},
 	},
 }
 
 // MetaToolNames are tools that are always enabled and cannot be disabled.
-var MetaToolNames = []string{"help", "get_tool_config", "set_tool_config", "list_tool_groups"}
+var MetaToolNames = []string{"help", "get_tool_config", "set_tool_config", "list_tool_groups", "execute_batch", "get_policy", "set_policy"}
 
 // ToolGroupInfo represents information about a tool group for API responses.
 type ToolGroupInfo struct {
@@ -153,8 +154,14 @@ func (s *Server) handleGetToolConfig(ctx context.Context, request *mcpsdk.CallTo
 			ToolCount:   meta.ToolCount,
 		}
 
+		if s.readOnly {
+			info.ToolCount = len(readOnlyTools(meta.ToolNames))
+		}
 		if input.Verbose {
 			info.Tools = meta.ToolNames
+			if s.readOnly {
+				info.Tools = readOnlyTools(meta.ToolNames)
+			}
 		}
 
 		groups = append(groups, info)
@@ -171,18 +178,28 @@ func (s *Server) handleGetToolConfig(ctx context.Context, request *mcpsdk.CallTo
 	}
 
 	// Add meta-tools to count (always enabled)
-	totalTools += len(MetaToolNames)
-	enabledTools += len(MetaToolNames)
+	metaTools := MetaToolNames
+	if s.readOnly {
+		metaTools = readOnlyTools(MetaToolNames)
+	}
+	totalTools += len(metaTools)
+	enabledTools += len(metaTools)
+
+	message := fmt.Sprintf("%d of %d tools enabled across %d groups", enabledTools, totalTools, len(groups))
+	if s.readOnly {
+		message += " (read-only mode: mutating tools are not available)"
+	}
 
 	result := map[string]interface{}{
 		"groups":        groups,
 		"total_tools":   totalTools,
 		"enabled_tools": enabledTools,
-		"meta_tools":    MetaToolNames,
-		"message":       fmt.Sprintf("%d of %d tools enabled across %d groups", enabledTools, totalTools, len(groups)),
+		"meta_tools":    metaTools,
+		"read_only":     s.readOnly,
+		"message":       message,
 	}
 
-	s.recordAction("get_tool_config", "Get tool configuration", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_tool_config", "Get tool configuration", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -249,7 +266,7 @@ func (s *Server) handleSetToolConfig(ctx context.Context, request *mcpsdk.CallTo
 	//    the tool list when config changes.
 	// 3. Startup filtering: Only register enabled groups on server initialization.
 
-	s.recordAction("set_tool_config", "Set tool configuration", input, result, true, time.Since(start))
+	s.recordAction(ctx, "set_tool_config", "Set tool configuration", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -291,7 +308,7 @@ func (s *Server) handleListToolGroups(ctx context.Context, request *mcpsdk.CallT
 		"message":    fmt.Sprintf("Found %d tool groups", len(groups)),
 	}
 
-	s.recordAction("list_tool_groups", "List tool groups", input, result, true, time.Since(start))
+	s.recordAction(ctx, "list_tool_groups", "List tool groups", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -117,11 +117,14 @@ func TestHandleGetToolConfig(t *testing.T) {
 		resultMap := result.(map[string]interface{})
 
 		metaTools := resultMap["meta_tools"].([]string)
-		assert.Len(t, metaTools, 4, "should have 4 meta tools")
+		assert.Len(t, metaTools, 7, "should have 7 meta tools")
 		assert.Contains(t, metaTools, "help")
 		assert.Contains(t, metaTools, "get_tool_config")
 		assert.Contains(t, metaTools, "set_tool_config")
 		assert.Contains(t, metaTools, "list_tool_groups")
+		assert.Contains(t, metaTools, "execute_batch")
+		assert.Contains(t, metaTools, "get_policy")
+		assert.Contains(t, metaTools, "set_policy")
 	})
 }
 
@@ -330,7 +333,7 @@ func TestHandleListToolGroups(t *testing.T) {
 		resultMap := result.(map[string]interface{})
 
 		metaTools := resultMap["meta_tools"].([]string)
-		assert.Len(t, metaTools, 4)
+		assert.Len(t, metaTools, 7)
 	})
 }
 
@@ -424,7 +427,7 @@ func TestToolGroupMetadata(t *testing.T) {
 		hasTools  []string
 	}{
 		"Core": {
-			toolCount: 25,
+			toolCount: 28,
 			hasTools:  []string{"list_scenes", "start_recording", "toggle_virtual_cam", "toggle_studio_mode"},
 		},
 		"Sources": {
@@ -473,11 +476,14 @@ func TestToolGroupMetadata(t *testing.T) {
 }
 
 func TestMetaToolNames(t *testing.T) {
-	assert.Len(t, MetaToolNames, 4)
+	assert.Len(t, MetaToolNames, 7)
 	assert.Contains(t, MetaToolNames, "help")
 	assert.Contains(t, MetaToolNames, "get_tool_config")
 	assert.Contains(t, MetaToolNames, "set_tool_config")
 	assert.Contains(t, MetaToolNames, "list_tool_groups")
+	assert.Contains(t, MetaToolNames, "execute_batch")
+	assert.Contains(t, MetaToolNames, "get_policy")
+	assert.Contains(t, MetaToolNames, "set_policy")
 }
 
 // TestToolCountConsistency ensures ToolCount field matches len(ToolNames) for all groups.
@@ -521,7 +527,7 @@ func TestToolGroupOrderConsistency(t *testing.T) {
 }
 
 // TestTotalToolCountMatchesDocumentation validates that tool counts in metadata
-// sum to the documented total (81 tools = 77 group tools + 4 meta-tools).
+// sum to the documented total (104 tools = 97 group tools + 7 meta-tools).
 // This catches drift between code and documentation.
 func TestTotalToolCountMatchesDocumentation(t *testing.T) {
 	// Sum all tool counts from metadata
@@ -534,7 +540,7 @@ func TestTotalToolCountMatchesDocumentation(t *testing.T) {
 	totalTools := groupToolCount + len(MetaToolNames)
 
 	// Expected total from documentation (CLAUDE.md, README.md, verify-docs.sh)
-	const expectedTotal = 81
+	const expectedTotal = 104
 
 	assert.Equal(t, expectedTotal, totalTools,
 		"Total tool count (%d group tools + %d meta-tools = %d) should match documented %d",
@@ -649,3 +655,48 @@ func TestToolConfigPersistsAcrossRestarts(t *testing.T) {
 		assert.False(t, groups[0].Enabled, "Audio group should show as disabled")
 	})
 }
+
+func TestReadOnlyMode(t *testing.T) {
+	t.Run("registers only non-mutating tools", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		server.readOnly = true
+		session := connectTestClient(t, server, nil)
+
+		result, err := session.ListTools(context.Background(), nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Tools)
+
+		names := make(map[string]bool)
+		for _, tool := range result.Tools {
+			names[tool.Name] = true
+			require.NotNil(t, tool.Annotations)
+			assert.True(t, tool.Annotations.ReadOnlyHint, "tool %s should not be registered in read-only mode", tool.Name)
+		}
+		assert.True(t, names["get_obs_status"])
+		assert.True(t, names["get_tool_config"])
+		assert.False(t, names["set_current_scene"])
+		assert.False(t, names["set_tool_config"])
+		assert.False(t, names["execute_batch"])
+		assert.False(t, names["set_policy"])
+		assert.NotContains(t, server.batchTools, "set_current_scene")
+	})
+
+	t.Run("get_tool_config reports the mode", func(t *testing.T) {
+		server, _, _ := testServerForToolConfig(t)
+		server.readOnly = true
+
+		_, result, err := server.handleGetToolConfig(context.Background(), nil, GetToolConfigInput{Group: "Core", Verbose: true})
+		require.NoError(t, err)
+
+		resultMap := result.(map[string]interface{})
+		assert.Equal(t, true, resultMap["read_only"])
+		assert.NotContains(t, resultMap["meta_tools"], "set_tool_config")
+		assert.Contains(t, resultMap["meta_tools"], "get_tool_config")
+
+		groups := resultMap["groups"].([]ToolGroupInfo)
+		require.Len(t, groups, 1)
+		assert.Contains(t, groups[0].Tools, "get_obs_status")
+		assert.NotContains(t, groups[0].Tools, "start_recording")
+		assert.Equal(t, len(groups[0].Tools), groups[0].ToolCount)
+	})
+}
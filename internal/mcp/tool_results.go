@@ -0,0 +1,741 @@
+package mcp
+
+import (
+	"github.com/andreykaipov/goobs/api/typedefs"
+	"github.com/ironystock/agentic-obs/internal/obs"
+	"github.com/ironystock/agentic-obs/internal/storage"
+)
+
+// Tool result types
+//
+// These types describe the structured output of each tool. Handlers still build
+// their results as maps, but the JSON they produce must match the schema derived
+// from the corresponding type in toolSpecs, which is advertised to clients as the
+// tool's output schema and validated by the SDK on every call.
+
+// SceneListResult is the output of list_scenes
+type SceneListResult struct {
+	Scenes       []string `json:"scenes"`
+	CurrentScene string   `json:"current_scene"`
+}
+
+// ActiveStateResult is the output of virtual camera and replay buffer status tools
+type ActiveStateResult struct {
+	Active  bool   `json:"active"`
+	Message string `json:"message"`
+}
+
+// LastReplayResult is the output of get_last_replay
+type LastReplayResult struct {
+	SavedReplayPath string `json:"saved_replay_path"`
+	Message         string `json:"message"`
+}
+
+// StudioModeResult is the output of studio mode tools
+type StudioModeResult struct {
+	StudioModeEnabled bool   `json:"studio_mode_enabled"`
+	Message           string `json:"message"`
+}
+
+// PreviewSceneResult is the output of preview scene tools
+type PreviewSceneResult struct {
+	PreviewScene string `json:"preview_scene"`
+	Message      string `json:"message"`
+}
+
+// HotkeyResult is the output of trigger_hotkey_by_name
+type HotkeyResult struct {
+	HotkeyName string `json:"hotkey_name"`
+	Message    string `json:"message"`
+}
+
+// HotkeyListResult is the output of list_hotkeys
+type HotkeyListResult struct {
+	Hotkeys []string `json:"hotkeys"`
+	Count   int      `json:"count"`
+	Message string   `json:"message"`
+}
+
+// SourceListResult is the output of list_sources
+type SourceListResult struct {
+	Sources []*typedefs.Input `json:"sources"`
+	Count   int               `json:"count"`
+}
+
+// SourceVisibilityResult is the output of toggle_source_visibility
+type SourceVisibilityResult struct {
+	SceneName string `json:"scene_name"`
+	SourceID  int64  `json:"source_id"`
+	Visible   bool   `json:"visible"`
+}
+
+// SourceSettingsResult is the output of get_source_settings (free-form OBS settings)
+type SourceSettingsResult map[string]interface{}
+
+// InputMuteResult is the output of get_input_mute
+type InputMuteResult struct {
+	InputName string `json:"input_name"`
+	IsMuted   bool   `json:"is_muted"`
+}
+
+// InputVolumeResult is the output of get_input_volume
+type InputVolumeResult struct {
+	InputName string  `json:"input_name"`
+	VolumeDb  float64 `json:"volume_db"`
+	VolumeMul float64 `json:"volume_mul"`
+}
+
+// PresetSummary is a scene preset entry in list_scene_presets
+type PresetSummary struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	SceneName string `json:"scene_name"`
+	CreatedAt string `json:"created_at"`
+}
+
+// PresetListResult is the output of list_scene_presets
+type PresetListResult struct {
+	Presets []PresetSummary `json:"presets"`
+	Count   int             `json:"count"`
+}
+
+// PresetDetailsResult is the output of get_preset_details
+type PresetDetailsResult struct {
+	ID        int64                 `json:"id"`
+	Name      string                `json:"name"`
+	SceneName string                `json:"scene_name"`
+	Sources   []storage.SourceState `json:"sources"`
+	CreatedAt string                `json:"created_at"`
+}
+
+// SavePresetResult is the output of save_scene_preset
+type SavePresetResult struct {
+	ID          int64  `json:"id"`
+	PresetName  string `json:"preset_name"`
+	SceneName   string `json:"scene_name"`
+	SourceCount int    `json:"source_count"`
+	Message     string `json:"message"`
+}
+
+// ApplyPresetResult is the output of apply_scene_preset
+type ApplyPresetResult struct {
+	PresetName   string `json:"preset_name"`
+	SceneName    string `json:"scene_name"`
+	AppliedCount int    `json:"applied_count"`
+	Message      string `json:"message"`
+}
+
+// ScreenshotSourceResult is the output of create_screenshot_source
+type ScreenshotSourceResult struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	SourceName  string `json:"source_name"`
+	CadenceMs   int    `json:"cadence_ms"`
+	ImageFormat string `json:"image_format"`
+	Quality     int    `json:"quality"`
+	URL         string `json:"url"`
+	Message     string `json:"message"`
+}
+
+// ScreenshotSourceSummary is a screenshot source entry in list_screenshot_sources
+type ScreenshotSourceSummary struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	SourceName      string `json:"source_name"`
+	CadenceMs       int    `json:"cadence_ms"`
+	ImageFormat     string `json:"image_format"`
+	Quality         int    `json:"quality"`
+	Enabled         bool   `json:"enabled"`
+	URL             string `json:"url"`
+	ScreenshotCount int64  `json:"screenshot_count"`
+	CreatedAt       string `json:"created_at"`
+}
+
+// ScreenshotSourceListResult is the output of list_screenshot_sources
+type ScreenshotSourceListResult struct {
+	Sources []ScreenshotSourceSummary `json:"sources"`
+	Count   int                       `json:"count"`
+}
+
+// ScreenshotCadenceResult is the output of configure_screenshot_cadence
+type ScreenshotCadenceResult struct {
+	Name      string `json:"name"`
+	CadenceMs int    `json:"cadence_ms"`
+	Message   string `json:"message"`
+}
+
+// CreateSourceResult is the output of the create_*_source design tools.
+// Optional fields are only present for the source kinds that use them.
+type CreateSourceResult struct {
+	SceneName   string `json:"scene_name"`
+	SourceName  string `json:"source_name"`
+	SceneItemID int    `json:"scene_item_id"`
+	FilePath    string `json:"file_path,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Loop        bool   `json:"loop,omitempty"`
+	Message     string `json:"message"`
+}
+
+// SourceTransformResult is the output of set_source_transform
+type SourceTransformResult struct {
+	SceneName   string  `json:"scene_name"`
+	SceneItemID int     `json:"scene_item_id"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	ScaleX      float64 `json:"scale_x"`
+	ScaleY      float64 `json:"scale_y"`
+	Rotation    float64 `json:"rotation"`
+	Message     string  `json:"message"`
+}
+
+// SourceTransformDetailsResult is the output of get_source_transform
+type SourceTransformDetailsResult struct {
+	SceneName    string  `json:"scene_name"`
+	SceneItemID  int     `json:"scene_item_id"`
+	X            float64 `json:"x"`
+	Y            float64 `json:"y"`
+	ScaleX       float64 `json:"scale_x"`
+	ScaleY       float64 `json:"scale_y"`
+	Rotation     float64 `json:"rotation"`
+	Width        float64 `json:"width"`
+	Height       float64 `json:"height"`
+	SourceWidth  float64 `json:"source_width"`
+	SourceHeight float64 `json:"source_height"`
+	BoundsType   string  `json:"bounds_type"`
+	BoundsWidth  float64 `json:"bounds_width"`
+	BoundsHeight float64 `json:"bounds_height"`
+	CropTop      int     `json:"crop_top"`
+	CropBottom   int     `json:"crop_bottom"`
+	CropLeft     int     `json:"crop_left"`
+	CropRight    int     `json:"crop_right"`
+}
+
+// SourceCropResult is the output of set_source_crop
+type SourceCropResult struct {
+	SceneName   string `json:"scene_name"`
+	SceneItemID int    `json:"scene_item_id"`
+	CropTop     int    `json:"crop_top"`
+	CropBottom  int    `json:"crop_bottom"`
+	CropLeft    int    `json:"crop_left"`
+	CropRight   int    `json:"crop_right"`
+	Message     string `json:"message"`
+}
+
+// SourceBoundsResult is the output of set_source_bounds
+type SourceBoundsResult struct {
+	SceneName    string  `json:"scene_name"`
+	SceneItemID  int     `json:"scene_item_id"`
+	BoundsType   string  `json:"bounds_type"`
+	BoundsWidth  float64 `json:"bounds_width"`
+	BoundsHeight float64 `json:"bounds_height"`
+	Message      string  `json:"message"`
+}
+
+// SourceOrderResult is the output of set_source_order
+type SourceOrderResult struct {
+	SceneName   string `json:"scene_name"`
+	SceneItemID int    `json:"scene_item_id"`
+	Index       int    `json:"index"`
+	Message     string `json:"message"`
+}
+
+// SourceLockedResult is the output of set_source_locked
+type SourceLockedResult struct {
+	SceneName   string `json:"scene_name"`
+	SceneItemID int    `json:"scene_item_id"`
+	Locked      bool   `json:"locked"`
+	Message     string `json:"message"`
+}
+
+// DuplicateSourceResult is the output of duplicate_source
+type DuplicateSourceResult struct {
+	SourceScene    string `json:"source_scene"`
+	SourceItemID   int    `json:"source_item_id"`
+	DestScene      string `json:"dest_scene"`
+	NewSceneItemID int    `json:"new_scene_item_id"`
+	Message        string `json:"message"`
+}
+
+// SceneItemResult is the output of remove_source
+type SceneItemResult struct {
+	SceneName   string `json:"scene_name"`
+	SceneItemID int    `json:"scene_item_id"`
+	Message     string `json:"message"`
+}
+
+// InputKindListResult is the output of list_input_kinds
+type InputKindListResult struct {
+	InputKinds []string `json:"input_kinds"`
+	Count      int      `json:"count"`
+}
+
+// FilterListResult is the output of list_source_filters
+type FilterListResult struct {
+	SourceName string           `json:"source_name"`
+	Filters    []obs.FilterInfo `json:"filters"`
+	Count      int              `json:"count"`
+}
+
+// FilterDetailsResult is the output of get_source_filter
+type FilterDetailsResult struct {
+	SourceName string             `json:"source_name"`
+	Filter     *obs.FilterDetails `json:"filter"`
+}
+
+// FilterResult is the output of the filter mutation tools.
+// Source and filter names are omitted when the user cancels a removal.
+type FilterResult struct {
+	SourceName    string `json:"source_name,omitempty"`
+	FilterName    string `json:"filter_name,omitempty"`
+	FilterKind    string `json:"filter_kind,omitempty"`
+	FilterEnabled *bool  `json:"filter_enabled,omitempty"`
+	Overlay       *bool  `json:"overlay,omitempty"`
+	Message       string `json:"message"`
+}
+
+// FilterKindListResult is the output of list_filter_kinds
+type FilterKindListResult struct {
+	FilterKinds []string `json:"filter_kinds"`
+	Count       int      `json:"count"`
+}
+
+// TransitionListResult is the output of list_transitions
+type TransitionListResult struct {
+	Transitions       []obs.TransitionInfo `json:"transitions"`
+	CurrentTransition string               `json:"current_transition"`
+	Count             int                  `json:"count"`
+}
+
+// TransitionDetailsResult is the output of get_current_transition
+type TransitionDetailsResult struct {
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	DurationMs   int                    `json:"duration_ms"`
+	Configurable bool                   `json:"configurable"`
+	Settings     map[string]interface{} `json:"settings"`
+}
+
+// TransitionResult is the output of set_current_transition
+type TransitionResult struct {
+	TransitionName string `json:"transition_name"`
+	Message        string `json:"message"`
+}
+
+// TransitionDurationResult is the output of set_transition_duration
+type TransitionDurationResult struct {
+	DurationMs int    `json:"duration_ms"`
+	Message    string `json:"message"`
+}
+
+// AutomationRuleSummary is a rule entry in list_automation_rules
+type AutomationRuleSummary struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	TriggerType string `json:"trigger_type"`
+	Priority    int    `json:"priority"`
+	RunCount    int64  `json:"run_count"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	LastRun     string `json:"last_run,omitempty"`
+}
+
+// AutomationRuleListResult is the output of list_automation_rules
+type AutomationRuleListResult struct {
+	Rules   []AutomationRuleSummary `json:"rules"`
+	Count   int                     `json:"count"`
+	Message string                  `json:"message"`
+}
+
+// AutomationActionInfo is an action entry in get_automation_rule
+type AutomationActionInfo struct {
+	Type       string                     `json:"type"`
+	Parameters map[string]interface{}     `json:"parameters"`
+	OnError    string                     `json:"on_error"`
+	OnMissing  string                     `json:"on_missing,omitempty"`
+	Condition  string                     `json:"condition,omitempty"`
+	Then       []map[string]interface{}   `json:"then,omitempty"`
+	Else       []map[string]interface{}   `json:"else,omitempty"`
+	Branches   [][]map[string]interface{} `json:"branches,omitempty"`
+	Actions    []map[string]interface{}   `json:"actions,omitempty"`
+	Count      int                        `json:"count,omitempty"`
+	Until      string                     `json:"until,omitempty"`
+	Retry      map[string]interface{}     `json:"retry,omitempty"`
+	TimeoutMs  int                        `json:"timeout_ms,omitempty"`
+	Fallback   []map[string]interface{}   `json:"fallback,omitempty"`
+}
+
+// AutomationRuleDetailsResult is the output of get_automation_rule
+type AutomationRuleDetailsResult struct {
+	ID            int64                  `json:"id"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Enabled       bool                   `json:"enabled"`
+	TriggerType   string                 `json:"trigger_type"`
+	TriggerConfig map[string]interface{} `json:"trigger_config"`
+	Actions       []AutomationActionInfo `json:"actions"`
+	Condition     string                 `json:"condition,omitempty"`
+	Concurrency   string                 `json:"concurrency,omitempty"`
+	CooldownMs    int                    `json:"cooldown_ms"`
+	Priority      int                    `json:"priority"`
+	Revision      int                    `json:"revision,omitempty"`
+	RunCount      int64                  `json:"run_count"`
+	CreatedAt     string                 `json:"created_at"`
+	UpdatedAt     string                 `json:"updated_at"`
+	LastRun       string                 `json:"last_run,omitempty"`
+}
+
+// AutomationRuleChangeResult is the output of the automation rule mutation tools.
+// Only the fields relevant to the operation are present.
+type AutomationRuleChangeResult struct {
+	ID        int64  `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Enabled   *bool  `json:"enabled,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+	Triggered bool   `json:"triggered,omitempty"`
+	Message   string `json:"message"`
+
+	// Set by trigger_automation_rule when it waits for the execution
+	ExecutionID int64  `json:"execution_id,omitempty"`
+	Status      string `json:"status,omitempty"`
+	DurationMs  int64  `json:"duration_ms,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// MacroAction is a recorded action in macro recording output
+type MacroAction struct {
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// MacroRecordingResult is the output of start_macro_recording and stop_macro_recording
+type MacroRecordingResult struct {
+	Name        string        `json:"name"`
+	Recording   bool          `json:"recording,omitempty"`
+	Saved       bool          `json:"saved,omitempty"`
+	RuleID      int64         `json:"rule_id,omitempty"`
+	Actions     []MacroAction `json:"actions,omitempty"`
+	ActionCount int           `json:"action_count,omitempty"`
+	Skipped     []string      `json:"skipped_tools,omitempty"`
+	DurationMs  int64         `json:"duration_ms,omitempty"`
+	Message     string        `json:"message"`
+}
+
+// MacroSummary is a macro entry in list_macros
+type MacroSummary struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Parameters  []string `json:"parameters"`
+	ActionCount int      `json:"action_count"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// MacroListResult is the output of list_macros
+type MacroListResult struct {
+	Macros  []MacroSummary `json:"macros"`
+	Count   int            `json:"count"`
+	Message string         `json:"message"`
+}
+
+// MacroParameterInfo is a parameter entry in get_macro
+type MacroParameterInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+// MacroDetailsResult is the output of get_macro
+type MacroDetailsResult struct {
+	ID          int64                  `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  []MacroParameterInfo   `json:"parameters"`
+	Actions     []AutomationActionInfo `json:"actions"`
+	CreatedAt   string                 `json:"created_at"`
+	UpdatedAt   string                 `json:"updated_at"`
+}
+
+// MacroChangeResult is the output of create_macro, update_macro and
+// delete_macro
+type MacroChangeResult struct {
+	ID        int64  `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+	Message   string `json:"message"`
+}
+
+// RunMacroResult is the output of run_macro
+type RunMacroResult struct {
+	Macro      string                   `json:"macro"`
+	Success    bool                     `json:"success"`
+	Results    []map[string]interface{} `json:"results"`
+	DurationMs int64                    `json:"duration_ms"`
+	Error      string                   `json:"error,omitempty"`
+	Message    string                   `json:"message"`
+}
+
+// BundleExportResult is the output of export_automation_bundle
+type BundleExportResult struct {
+	Bundle      string `json:"bundle"`
+	Format      string `json:"format"`
+	Version     int    `json:"version"`
+	RuleCount   int    `json:"rule_count"`
+	MacroCount  int    `json:"macro_count"`
+	PresetCount int    `json:"preset_count"`
+	Message     string `json:"message"`
+}
+
+// RuleExecutionSummary is an execution entry in list_rule_executions
+type RuleExecutionSummary struct {
+	ID          int64  `json:"id"`
+	RuleID      int64  `json:"rule_id"`
+	RuleName    string `json:"rule_name"`
+	TriggerType string `json:"trigger_type"`
+	Status      string `json:"status"`
+	StartedAt   string `json:"started_at"`
+	DurationMs  int64  `json:"duration_ms"`
+	CompletedAt string `json:"completed_at,omitempty"`
+	Error       string `json:"error,omitempty"`
+	ActionCount int    `json:"action_count,omitempty"`
+	Revision    int    `json:"revision,omitempty"` // Rule revision that ran
+
+	// Macros run by the execution's actions, nested calls as "outer > inner"
+	MacroRuns []string `json:"macro_runs,omitempty"`
+}
+
+// RuleExecutionListResult is the output of list_rule_executions
+type RuleExecutionListResult struct {
+	Executions []RuleExecutionSummary `json:"executions"`
+	Count      int                    `json:"count"`
+	Message    string                 `json:"message"`
+}
+
+// RuleRevisionSummary describes one saved revision of a rule
+type RuleRevisionSummary struct {
+	Revision     int    `json:"revision"`
+	Change       string `json:"change"` // created, updated, enabled, disabled or restored
+	RestoredFrom int    `json:"restored_from,omitempty"`
+	Name         string `json:"name"` // Rule name in this revision
+	Enabled      bool   `json:"enabled"`
+	ActionCount  int    `json:"action_count"`
+	ActorType    string `json:"actor_type,omitempty"`
+	ActorID      string `json:"actor_id,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	Current      bool   `json:"current,omitempty"`
+}
+
+// RuleRevisionListResult is the output of list_rule_revisions
+type RuleRevisionListResult struct {
+	RuleID          int64                 `json:"rule_id"`
+	RuleName        string                `json:"rule_name"`
+	CurrentRevision int                   `json:"current_revision"`
+	Revisions       []RuleRevisionSummary `json:"revisions"`
+	Count           int                   `json:"count"`
+	Message         string                `json:"message"`
+}
+
+// RuleRevisionDiffResult is the output of diff_rule_revisions
+type RuleRevisionDiffResult struct {
+	RuleName string              `json:"rule_name"`
+	From     RuleRevisionSummary `json:"from"`
+	To       RuleRevisionSummary `json:"to"`
+	Changes  []PlannedChange     `json:"changes"`
+	Message  string              `json:"message"`
+}
+
+// RuleRevisionRestoreResult is the output of restore_rule_revision
+type RuleRevisionRestoreResult struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Revision     int    `json:"revision"` // New revision holding the restored definition
+	RestoredFrom int    `json:"restored_from"`
+	Message      string `json:"message"`
+}
+
+// RunningExecutionInfo is an entry in list_running_executions
+type RunningExecutionInfo struct {
+	ExecutionID int64  `json:"execution_id"`
+	RuleID      int64  `json:"rule_id"`
+	RuleName    string `json:"rule_name"`
+	TriggerType string `json:"trigger_type"`
+	ActionIndex int    `json:"action_index"`
+	ActionCount int    `json:"action_count"`
+	ActionType  string `json:"action_type"`
+	StartedAt   string `json:"started_at"`
+	RunningMs   int64  `json:"running_ms"`
+}
+
+// RunningExecutionListResult is the output of list_running_executions
+type RunningExecutionListResult struct {
+	Executions []RunningExecutionInfo `json:"executions"`
+	Count      int                    `json:"count"`
+	Message    string                 `json:"message"`
+}
+
+// CancelRuleExecutionResult is the output of cancel_rule_execution
+type CancelRuleExecutionResult struct {
+	ExecutionID int64  `json:"execution_id"`
+	Cancelled   bool   `json:"cancelled"`
+	Message     string `json:"message"`
+}
+
+// NextRunInfo is a fire time in get_next_runs
+type NextRunInfo struct {
+	Time      string `json:"time"`
+	InSeconds int64  `json:"in_seconds"`
+}
+
+// NextRunsResult is the output of get_next_runs
+type NextRunsResult struct {
+	RuleName string        `json:"rule_name"`
+	Enabled  bool          `json:"enabled"`
+	Schedule string        `json:"schedule"`
+	TimeZone string        `json:"timezone"`
+	NextRuns []NextRunInfo `json:"next_runs"`
+	Count    int           `json:"count"`
+	Message  string        `json:"message"`
+}
+
+// SimulatedRuleInfo is a rule's outcome in simulate_automation_event
+type SimulatedRuleInfo struct {
+	RuleID   int64                    `json:"rule_id"`
+	RuleName string                   `json:"rule_name"`
+	Priority int                      `json:"priority"`
+	Reason   string                   `json:"reason,omitempty"`
+	Note     string                   `json:"note,omitempty"`
+	Actions  []map[string]interface{} `json:"actions,omitempty"`
+}
+
+// SimulateAutomationEventResult is the output of simulate_automation_event
+type SimulateAutomationEventResult struct {
+	EventType  string              `json:"event_type"`
+	Fired      []SimulatedRuleInfo `json:"fired"`
+	Skipped    []SimulatedRuleInfo `json:"skipped"`
+	FiredCount int                 `json:"fired_count"`
+	Message    string              `json:"message"`
+}
+
+// HelpResult is the output of help
+type HelpResult struct {
+	Topic   string `json:"topic"`
+	Help    string `json:"help"`
+	Verbose bool   `json:"verbose"`
+}
+
+// ToolConfigResult is the output of get_tool_config
+type ToolConfigResult struct {
+	Groups       []ToolGroupInfo `json:"groups"`
+	TotalTools   int             `json:"total_tools"`
+	EnabledTools int             `json:"enabled_tools"`
+	MetaTools    []string        `json:"meta_tools"`
+	ReadOnly     bool            `json:"read_only"`
+	Message      string          `json:"message"`
+}
+
+// SetToolConfigResult is the output of set_tool_config
+type SetToolConfigResult struct {
+	Group         string `json:"group"`
+	PreviousState bool   `json:"previous_state"`
+	NewState      bool   `json:"new_state"`
+	ToolsAffected int    `json:"tools_affected"`
+	Persisted     bool   `json:"persisted"`
+	PersistError  string `json:"persist_error,omitempty"`
+	Message       string `json:"message"`
+}
+
+// ToolGroupListResult is the output of list_tool_groups
+type ToolGroupListResult struct {
+	Groups    []ToolGroupInfo `json:"groups"`
+	Count     int             `json:"count"`
+	MetaTools []string        `json:"meta_tools"`
+	Message   string          `json:"message"`
+}
+
+// PlannedChange is one change a dry run would make
+type PlannedChange struct {
+	Op     string      `json:"op"`               // create, update or delete
+	Target string      `json:"target"`           // e.g. "scene:Gaming", "scene_item:Scene 1/3", "filter:Webcam/Sharpen"
+	Field  string      `json:"field,omitempty"`  // Changed property for updates
+	Before interface{} `json:"before,omitempty"` // Live value before the change
+	After  interface{} `json:"after,omitempty"`  // Value after the change
+}
+
+// DryRunResult is the output of any mutating tool called with dry_run set
+type DryRunResult struct {
+	DryRun  bool            `json:"dry_run"`
+	Tool    string          `json:"tool"`
+	Changes []PlannedChange `json:"changes"`
+	Message string          `json:"message"`
+}
+
+// UndoableActionListResult is the output of list_undoable_actions
+type UndoableActionListResult struct {
+	Actions []storage.UndoEntry `json:"actions"`
+	Count   int                 `json:"count"`
+}
+
+// UndoneAction identifies an action reversed by an undo tool
+type UndoneAction struct {
+	ActionID    int64  `json:"action_id"`
+	ToolName    string `json:"tool_name"`
+	Description string `json:"description,omitempty"`
+}
+
+// UndoResult is the output of undo_last_action and undo_to
+type UndoResult struct {
+	Undone  []UndoneAction `json:"undone"`
+	Count   int            `json:"count"`
+	Message string         `json:"message"`
+}
+
+// BatchStepResult is the outcome of one step of execute_batch
+type BatchStepResult struct {
+	Index        int    `json:"index"`
+	Tool         string `json:"tool"`
+	Success      bool   `json:"success"`
+	Result       any    `json:"result,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Compensation string `json:"compensation,omitempty" jsonschema:"How a completed step was handled after a later step failed: reverted, not_undoable, failed, or skipped"`
+}
+
+// BatchResult is the output of execute_batch
+type BatchResult struct {
+	Success    bool              `json:"success"`
+	Steps      []BatchStepResult `json:"steps"`
+	Completed  int               `json:"completed"`
+	Total      int               `json:"total"`
+	FailedStep *int              `json:"failed_step,omitempty"`
+	RolledBack bool              `json:"rolled_back,omitempty"`
+	Message    string            `json:"message"`
+}
+
+// PolicyDecision is how a call to a tool would be decided right now
+type PolicyDecision struct {
+	Tool   string `json:"tool"`
+	Effect string `json:"effect"`
+	Rule   string `json:"rule,omitempty"`
+	Exempt bool   `json:"exempt,omitempty"`
+}
+
+// PolicyResult is the output of get_policy
+type PolicyResult struct {
+	Rules    []storage.PolicyRule `json:"rules"`
+	Count    int                  `json:"count"`
+	Decision *PolicyDecision      `json:"decision,omitempty"`
+	Message  string               `json:"message"`
+}
+
+// PolicyChangeResult is the output of set_policy
+type PolicyChangeResult struct {
+	Name    string              `json:"name"`
+	Rule    *storage.PolicyRule `json:"rule,omitempty"`
+	Deleted bool                `json:"deleted,omitempty"`
+	Message string              `json:"message"`
+}
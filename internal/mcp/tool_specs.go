@@ -0,0 +1,266 @@
+package mcp
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/ironystock/agentic-obs/internal/obs"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toolSpec describes the behavioral hints and result type of a tool.
+// It is attached to the tool when it is registered via addTool.
+type toolSpec struct {
+	Title       string       // Human-readable title
+	ReadOnly    bool         // Tool does not modify OBS or stored state
+	Destructive bool         // Tool may delete or end something (only meaningful when not read-only)
+	Idempotent  bool         // Repeating the call with the same arguments has no additional effect
+	OpenWorld   bool         // Tool interacts with entities outside OBS and local storage
+	DryRun      bool         // Tool accepts dry_run and may return a DryRunResult instead of Output
+	Output      reflect.Type // Result type the output schema is derived from
+}
+
+// annotations converts the spec into MCP tool annotations.
+// All hints are set explicitly so clients never fall back to protocol defaults.
+func (t toolSpec) annotations() *mcpsdk.ToolAnnotations {
+	destructive := t.Destructive && !t.ReadOnly
+	openWorld := t.OpenWorld
+	return &mcpsdk.ToolAnnotations{
+		Title:           t.Title,
+		ReadOnlyHint:    t.ReadOnly,
+		DestructiveHint: &destructive,
+		IdempotentHint:  t.ReadOnly || t.Idempotent,
+		OpenWorldHint:   &openWorld,
+	}
+}
+
+// outputSchema derives the JSON schema for the spec's result type.
+// Free-form settings maps may be absent in OBS responses, so they accept null.
+// Tools that support dry runs accept either the result type or a DryRunResult.
+func (t toolSpec) outputSchema() (*jsonschema.Schema, error) {
+	opts := &jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[map[string]interface{}](): {Types: []string{"null", "object"}},
+		},
+	}
+	schema, err := jsonschema.ForType(t.Output, opts)
+	if err != nil || !t.DryRun {
+		return schema, err
+	}
+
+	dryRun, err := jsonschema.ForType(reflect.TypeFor[DryRunResult](), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonschema.Schema{Type: "object", AnyOf: []*jsonschema.Schema{schema, dryRun}}, nil
+}
+
+// inputSchemaWithoutDryRun derives the input schema for In with the dry_run
+// property removed. Input types are shared between tools, so tools that do
+// not support dry runs must not advertise the flag. It returns nil when In
+// has no dry_run property, leaving schema generation to the SDK.
+func inputSchemaWithoutDryRun[In any]() (*jsonschema.Schema, error) {
+	schema, err := jsonschema.For[In](&jsonschema.ForOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := schema.Properties["dry_run"]; !ok {
+		return nil, nil
+	}
+	delete(schema.Properties, "dry_run")
+	return schema, nil
+}
+
+// toolSpecs declares annotations and output types for every registered tool.
+// Every tool passed to addTool must have an entry here.
+var toolSpecs = map[string]toolSpec{
+	// Core: scene management
+	"list_scenes":       {Title: "List Scenes", ReadOnly: true, Output: reflect.TypeFor[SceneListResult]()},
+	"set_current_scene": {Title: "Set Current Scene", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SimpleResult]()},
+	"create_scene":      {Title: "Create Scene", DryRun: true, Output: reflect.TypeFor[SimpleResult]()},
+	"remove_scene":      {Title: "Remove Scene", Destructive: true, Idempotent: true, DryRun: true, Output: reflect.TypeFor[SimpleResult]()},
+
+	// Core: recording and streaming
+	"start_recording":      {Title: "Start Recording", Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"stop_recording":       {Title: "Stop Recording", Destructive: true, Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"get_recording_status": {Title: "Get Recording Status", ReadOnly: true, Output: reflect.TypeFor[obs.RecordingStatus]()},
+	"pause_recording":      {Title: "Pause Recording", Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"resume_recording":     {Title: "Resume Recording", Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"start_streaming":      {Title: "Start Streaming", Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"stop_streaming":       {Title: "Stop Streaming", Destructive: true, Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"get_streaming_status": {Title: "Get Streaming Status", ReadOnly: true, Output: reflect.TypeFor[obs.StreamingStatus]()},
+	"get_obs_status":       {Title: "Get OBS Status", ReadOnly: true, Output: reflect.TypeFor[obs.OBSStatus]()},
+
+	// Core: virtual camera and replay buffer (FB-25)
+	"get_virtual_cam_status":   {Title: "Get Virtual Camera Status", ReadOnly: true, Output: reflect.TypeFor[ActiveStateResult]()},
+	"toggle_virtual_cam":       {Title: "Toggle Virtual Camera", Output: reflect.TypeFor[ActiveStateResult]()},
+	"get_replay_buffer_status": {Title: "Get Replay Buffer Status", ReadOnly: true, Output: reflect.TypeFor[ActiveStateResult]()},
+	"toggle_replay_buffer":     {Title: "Toggle Replay Buffer", Output: reflect.TypeFor[ActiveStateResult]()},
+	"save_replay_buffer":       {Title: "Save Replay Buffer", Output: reflect.TypeFor[SimpleResult]()},
+	"get_last_replay":          {Title: "Get Last Replay", ReadOnly: true, Output: reflect.TypeFor[LastReplayResult]()},
+
+	// Core: studio mode and hotkeys (FB-26)
+	"get_studio_mode_enabled": {Title: "Get Studio Mode", ReadOnly: true, Output: reflect.TypeFor[StudioModeResult]()},
+	"toggle_studio_mode":      {Title: "Set Studio Mode", Idempotent: true, Output: reflect.TypeFor[StudioModeResult]()},
+	"get_preview_scene":       {Title: "Get Preview Scene", ReadOnly: true, Output: reflect.TypeFor[PreviewSceneResult]()},
+	"set_preview_scene":       {Title: "Set Preview Scene", Idempotent: true, Output: reflect.TypeFor[PreviewSceneResult]()},
+	"trigger_hotkey_by_name":  {Title: "Trigger Hotkey", Destructive: true, Output: reflect.TypeFor[HotkeyResult]()},
+	"list_hotkeys":            {Title: "List Hotkeys", ReadOnly: true, Output: reflect.TypeFor[HotkeyListResult]()},
+
+	// Core: undo journal
+	"list_undoable_actions": {Title: "List Undoable Actions", ReadOnly: true, Output: reflect.TypeFor[UndoableActionListResult]()},
+	"undo_last_action":      {Title: "Undo Last Action", Destructive: true, Output: reflect.TypeFor[UndoResult]()},
+	"undo_to":               {Title: "Undo To Action", Destructive: true, Output: reflect.TypeFor[UndoResult]()},
+
+	// Sources
+	"list_sources":             {Title: "List Sources", ReadOnly: true, Output: reflect.TypeFor[SourceListResult]()},
+	"toggle_source_visibility": {Title: "Toggle Source Visibility", DryRun: true, Output: reflect.TypeFor[SourceVisibilityResult]()},
+	"get_source_settings":      {Title: "Get Source Settings", ReadOnly: true, Output: reflect.TypeFor[SourceSettingsResult]()},
+
+	// Audio
+	"get_input_mute":    {Title: "Get Input Mute", ReadOnly: true, Output: reflect.TypeFor[InputMuteResult]()},
+	"toggle_input_mute": {Title: "Toggle Input Mute", DryRun: true, Output: reflect.TypeFor[SimpleResult]()},
+	"set_input_volume":  {Title: "Set Input Volume", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SimpleResult]()},
+	"get_input_volume":  {Title: "Get Input Volume", ReadOnly: true, Output: reflect.TypeFor[InputVolumeResult]()},
+
+	// Layout: scene presets
+	"list_scene_presets":  {Title: "List Scene Presets", ReadOnly: true, Output: reflect.TypeFor[PresetListResult]()},
+	"get_preset_details":  {Title: "Get Preset Details", ReadOnly: true, Output: reflect.TypeFor[PresetDetailsResult]()},
+	"delete_scene_preset": {Title: "Delete Scene Preset", Destructive: true, Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"rename_scene_preset": {Title: "Rename Scene Preset", Output: reflect.TypeFor[SimpleResult]()},
+	"save_scene_preset":   {Title: "Save Scene Preset", Output: reflect.TypeFor[SavePresetResult]()},
+	"apply_scene_preset":  {Title: "Apply Scene Preset", Idempotent: true, DryRun: true, Output: reflect.TypeFor[ApplyPresetResult]()},
+
+	// Visual: screenshot sources
+	"create_screenshot_source":     {Title: "Create Screenshot Source", Output: reflect.TypeFor[ScreenshotSourceResult]()},
+	"remove_screenshot_source":     {Title: "Remove Screenshot Source", Destructive: true, Idempotent: true, Output: reflect.TypeFor[SimpleResult]()},
+	"list_screenshot_sources":      {Title: "List Screenshot Sources", ReadOnly: true, Output: reflect.TypeFor[ScreenshotSourceListResult]()},
+	"configure_screenshot_cadence": {Title: "Configure Screenshot Cadence", Idempotent: true, Output: reflect.TypeFor[ScreenshotCadenceResult]()},
+
+	// Design: source creation and layout
+	"create_text_source":    {Title: "Create Text Source", DryRun: true, Output: reflect.TypeFor[CreateSourceResult]()},
+	"create_image_source":   {Title: "Create Image Source", DryRun: true, Output: reflect.TypeFor[CreateSourceResult]()},
+	"create_color_source":   {Title: "Create Color Source", DryRun: true, Output: reflect.TypeFor[CreateSourceResult]()},
+	"create_browser_source": {Title: "Create Browser Source", OpenWorld: true, DryRun: true, Output: reflect.TypeFor[CreateSourceResult]()},
+	"create_media_source":   {Title: "Create Media Source", DryRun: true, Output: reflect.TypeFor[CreateSourceResult]()},
+	"set_source_transform":  {Title: "Set Source Transform", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SourceTransformResult]()},
+	"get_source_transform":  {Title: "Get Source Transform", ReadOnly: true, Output: reflect.TypeFor[SourceTransformDetailsResult]()},
+	"set_source_crop":       {Title: "Set Source Crop", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SourceCropResult]()},
+	"set_source_bounds":     {Title: "Set Source Bounds", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SourceBoundsResult]()},
+	"set_source_order":      {Title: "Set Source Order", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SourceOrderResult]()},
+	"set_source_locked":     {Title: "Set Source Locked", Idempotent: true, DryRun: true, Output: reflect.TypeFor[SourceLockedResult]()},
+	"duplicate_source":      {Title: "Duplicate Source", DryRun: true, Output: reflect.TypeFor[DuplicateSourceResult]()},
+	"remove_source":         {Title: "Remove Source", Destructive: true, Idempotent: true, DryRun: true, Output: reflect.TypeFor[SceneItemResult]()},
+	"list_input_kinds":      {Title: "List Input Kinds", ReadOnly: true, Output: reflect.TypeFor[InputKindListResult]()},
+
+	// Filters (FB-23)
+	"list_source_filters":        {Title: "List Source Filters", ReadOnly: true, Output: reflect.TypeFor[FilterListResult]()},
+	"get_source_filter":          {Title: "Get Source Filter", ReadOnly: true, Output: reflect.TypeFor[FilterDetailsResult]()},
+	"create_source_filter":       {Title: "Create Source Filter", DryRun: true, Output: reflect.TypeFor[FilterResult]()},
+	"remove_source_filter":       {Title: "Remove Source Filter", Destructive: true, Idempotent: true, DryRun: true, Output: reflect.TypeFor[FilterResult]()},
+	"toggle_source_filter":       {Title: "Toggle Source Filter", DryRun: true, Output: reflect.TypeFor[FilterResult]()},
+	"set_source_filter_settings": {Title: "Set Source Filter Settings", Idempotent: true, DryRun: true, Output: reflect.TypeFor[FilterResult]()},
+	"list_filter_kinds":          {Title: "List Filter Kinds", ReadOnly: true, Output: reflect.TypeFor[FilterKindListResult]()},
+
+	// Transitions (FB-24)
+	"list_transitions":        {Title: "List Transitions", ReadOnly: true, Output: reflect.TypeFor[TransitionListResult]()},
+	"get_current_transition":  {Title: "Get Current Transition", ReadOnly: true, Output: reflect.TypeFor[TransitionDetailsResult]()},
+	"set_current_transition":  {Title: "Set Current Transition", Idempotent: true, DryRun: true, Output: reflect.TypeFor[TransitionResult]()},
+	"set_transition_duration": {Title: "Set Transition Duration", Idempotent: true, DryRun: true, Output: reflect.TypeFor[TransitionDurationResult]()},
+	"trigger_transition":      {Title: "Trigger Transition", DryRun: true, Output: reflect.TypeFor[SimpleResult]()},
+
+	// Automation (FB-20)
+	"list_automation_rules":     {Title: "List Automation Rules", ReadOnly: true, Output: reflect.TypeFor[AutomationRuleListResult]()},
+	"get_automation_rule":       {Title: "Get Automation Rule", ReadOnly: true, Output: reflect.TypeFor[AutomationRuleDetailsResult]()},
+	"create_automation_rule":    {Title: "Create Automation Rule", DryRun: true, Output: reflect.TypeFor[AutomationRuleChangeResult]()},
+	"update_automation_rule":    {Title: "Update Automation Rule", Destructive: true, Idempotent: true, DryRun: true, Output: reflect.TypeFor[AutomationRuleChangeResult]()},
+	"delete_automation_rule":    {Title: "Delete Automation Rule", Destructive: true, Idempotent: true, DryRun: true, Output: reflect.TypeFor[AutomationRuleChangeResult]()},
+	"enable_automation_rule":    {Title: "Enable Automation Rule", Idempotent: true, DryRun: true, Output: reflect.TypeFor[AutomationRuleChangeResult]()},
+	"disable_automation_rule":   {Title: "Disable Automation Rule", Idempotent: true, DryRun: true, Output: reflect.TypeFor[AutomationRuleChangeResult]()},
+	"trigger_automation_rule":   {Title: "Trigger Automation Rule", Destructive: true, Output: reflect.TypeFor[AutomationRuleChangeResult]()},
+	"list_rule_executions":      {Title: "List Rule Executions", ReadOnly: true, Output: reflect.TypeFor[RuleExecutionListResult]()},
+	"list_running_executions":   {Title: "List Running Executions", ReadOnly: true, Output: reflect.TypeFor[RunningExecutionListResult]()},
+	"cancel_rule_execution":     {Title: "Cancel Rule Execution", Destructive: true, Output: reflect.TypeFor[CancelRuleExecutionResult]()},
+	"simulate_automation_event": {Title: "Simulate Automation Event", ReadOnly: true, Output: reflect.TypeFor[SimulateAutomationEventResult]()},
+	"get_next_runs":             {Title: "Get Next Runs", ReadOnly: true, Output: reflect.TypeFor[NextRunsResult]()},
+	"start_macro_recording":     {Title: "Start Macro Recording", Output: reflect.TypeFor[MacroRecordingResult]()},
+	"stop_macro_recording":      {Title: "Stop Macro Recording", Output: reflect.TypeFor[MacroRecordingResult]()},
+	"list_macros":               {Title: "List Macros", ReadOnly: true, Output: reflect.TypeFor[MacroListResult]()},
+	"get_macro":                 {Title: "Get Macro", ReadOnly: true, Output: reflect.TypeFor[MacroDetailsResult]()},
+	"create_macro":              {Title: "Create Macro", Output: reflect.TypeFor[MacroChangeResult]()},
+	"update_macro":              {Title: "Update Macro", Destructive: true, Idempotent: true, Output: reflect.TypeFor[MacroChangeResult]()},
+	"delete_macro":              {Title: "Delete Macro", Destructive: true, Idempotent: true, Output: reflect.TypeFor[MacroChangeResult]()},
+	"run_macro":                 {Title: "Run Macro", Destructive: true, Output: reflect.TypeFor[RunMacroResult]()},
+	"export_automation_bundle":  {Title: "Export Automation Bundle", ReadOnly: true, Output: reflect.TypeFor[BundleExportResult]()},
+	"import_automation_bundle":  {Title: "Import Automation Bundle", Destructive: true, DryRun: true, Output: reflect.TypeFor[BundleImportResult]()},
+	"list_rule_revisions":       {Title: "List Rule Revisions", ReadOnly: true, Output: reflect.TypeFor[RuleRevisionListResult]()},
+	"diff_rule_revisions":       {Title: "Diff Rule Revisions", ReadOnly: true, Output: reflect.TypeFor[RuleRevisionDiffResult]()},
+	"restore_rule_revision":     {Title: "Restore Rule Revision", Destructive: true, DryRun: true, Output: reflect.TypeFor[RuleRevisionRestoreResult]()},
+
+	// Meta tools
+	"help":             {Title: "Help", ReadOnly: true, Output: reflect.TypeFor[HelpResult]()},
+	"get_tool_config":  {Title: "Get Tool Configuration", ReadOnly: true, Output: reflect.TypeFor[ToolConfigResult]()},
+	"set_tool_config":  {Title: "Set Tool Configuration", Idempotent: true, Output: reflect.TypeFor[SetToolConfigResult]()},
+	"list_tool_groups": {Title: "List Tool Groups", ReadOnly: true, Output: reflect.TypeFor[ToolGroupListResult]()},
+	"execute_batch":    {Title: "Execute Batch", Destructive: true, Output: reflect.TypeFor[BatchResult]()},
+	"get_policy":       {Title: "Get Policy", ReadOnly: true, Output: reflect.TypeFor[PolicyResult]()},
+	"set_policy":       {Title: "Set Policy", Destructive: true, Idempotent: true, Output: reflect.TypeFor[PolicyChangeResult]()},
+}
+
+// addTool registers a tool handler, attaching the annotations and output schema
+// declared for it in toolSpecs. Tools without a spec are registered as-is and
+// logged so the omission is caught in tests. Every handler is wrapped so policy
+// rules are checked before it runs and its actions are attributed to the
+// calling client. In read-only mode only tools whose spec
+// marks them read-only are registered; a tool without a spec counts as
+// mutating.
+func addTool[In any](s *Server, tool *mcpsdk.Tool, handler mcpsdk.ToolHandlerFor[In, any]) {
+	spec, ok := toolSpecs[tool.Name]
+	if s.readOnly && !spec.ReadOnly {
+		return
+	}
+
+	handler = withRequestActor(withPolicy(s, tool.Name, handler))
+
+	if !ok {
+		log.Printf("Warning: tool %s has no spec; registering without annotations", tool.Name)
+		registerBatchTool(s, tool, handler)
+		mcpsdk.AddTool(s.mcpServer, tool, handler)
+		return
+	}
+
+	tool.Annotations = spec.annotations()
+	if spec.Output != nil {
+		schema, err := spec.outputSchema()
+		if err != nil {
+			panic(fmt.Sprintf("addTool: tool %q: output schema: %v", tool.Name, err))
+		}
+		tool.OutputSchema = schema
+	}
+	if !spec.DryRun && tool.InputSchema == nil {
+		schema, err := inputSchemaWithoutDryRun[In]()
+		if err != nil {
+			panic(fmt.Sprintf("addTool: tool %q: input schema: %v", tool.Name, err))
+		}
+		if schema != nil {
+			tool.InputSchema = schema
+		}
+	}
+
+	registerBatchTool(s, tool, handler)
+	mcpsdk.AddTool(s.mcpServer, tool, handler)
+}
+
+// readOnlyTools returns the tools in names that are registered in read-only
+// mode.
+func readOnlyTools(names []string) []string {
+	var tools []string
+	for _, name := range names {
+		if toolSpecs[name].ReadOnly {
+			tools = append(tools, name)
+		}
+	}
+	return tools
+}
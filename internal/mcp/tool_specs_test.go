@@ -0,0 +1,292 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// testClientSession registers all tools on a test server and connects an
+// in-memory MCP client to it, so tools can be exercised end to end through the SDK.
+func testClientSession(t *testing.T) *mcpsdk.ClientSession {
+	t.Helper()
+
+	server, _, _ := testServerForToolConfig(t)
+	return connectTestClient(t, server, nil)
+}
+
+// connectTestClient registers all tools on server and connects an in-memory
+// MCP client created with the given options.
+func connectTestClient(t *testing.T, server *Server, opts *mcpsdk.ClientOptions) *mcpsdk.ClientSession {
+	t.Helper()
+
+	server.mcpServer = mcpsdk.NewServer(&mcpsdk.Implementation{Name: "agentic-obs-test", Version: "0.0.0"}, nil)
+	server.registerToolHandlers()
+
+	ctx := context.Background()
+	clientTransport, serverTransport := mcpsdk.NewInMemoryTransports()
+
+	serverSession, err := server.mcpServer.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+
+	client := mcpsdk.NewClient(&mcpsdk.Implementation{Name: "test-client", Version: "0.0.0"}, opts)
+	clientSession, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		clientSession.Close()
+		serverSession.Wait()
+	})
+
+	return clientSession
+}
+
+func TestAllToolsHaveAnnotations(t *testing.T) {
+	session := testClientSession(t)
+
+	result, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Tools)
+
+	for _, tool := range result.Tools {
+		t.Run(tool.Name, func(t *testing.T) {
+			require.NotNil(t, tool.Annotations, "tool %s must declare annotations in toolSpecs", tool.Name)
+			assert.NotEmpty(t, tool.Annotations.Title, "tool %s should have a title", tool.Name)
+			assert.NotNil(t, tool.Annotations.DestructiveHint, "tool %s should set destructiveHint explicitly", tool.Name)
+			assert.NotNil(t, tool.Annotations.OpenWorldHint, "tool %s should set openWorldHint explicitly", tool.Name)
+			assert.NotNil(t, tool.OutputSchema, "tool %s should declare an output schema", tool.Name)
+
+			if tool.Annotations.ReadOnlyHint {
+				assert.False(t, *tool.Annotations.DestructiveHint, "read-only tool %s cannot be destructive", tool.Name)
+			}
+		})
+	}
+}
+
+func TestToolSpecsMatchToolGroups(t *testing.T) {
+	expected := make(map[string]bool)
+	for _, meta := range toolGroupMetadata {
+		for _, name := range meta.ToolNames {
+			expected[name] = true
+		}
+	}
+	for _, name := range MetaToolNames {
+		expected[name] = true
+	}
+
+	for name := range expected {
+		_, ok := toolSpecs[name]
+		assert.True(t, ok, "tool %s has no entry in toolSpecs", name)
+	}
+	for name := range toolSpecs {
+		assert.True(t, expected[name], "toolSpecs entry %s is not a known tool", name)
+	}
+}
+
+func TestToolSpecOutputSchemas(t *testing.T) {
+	for name, spec := range toolSpecs {
+		require.NotNil(t, spec.Output, "tool %s has no output type", name)
+		schema, err := spec.outputSchema()
+		require.NoError(t, err, "tool %s", name)
+		assert.Equal(t, "object", schema.Type, "tool %s output schema must be an object", name)
+	}
+}
+
+func TestToolSpecAnnotations(t *testing.T) {
+	t.Run("read-only implies idempotent and non-destructive", func(t *testing.T) {
+		ann := toolSpec{Title: "Read", ReadOnly: true, Destructive: true}.annotations()
+		assert.True(t, ann.ReadOnlyHint)
+		assert.True(t, ann.IdempotentHint)
+		assert.False(t, *ann.DestructiveHint)
+	})
+
+	t.Run("mutating hints pass through", func(t *testing.T) {
+		ann := toolSpec{Title: "Remove", Destructive: true, Idempotent: true}.annotations()
+		assert.False(t, ann.ReadOnlyHint)
+		assert.True(t, ann.IdempotentHint)
+		assert.True(t, *ann.DestructiveHint)
+		assert.False(t, *ann.OpenWorldHint)
+	})
+}
+
+func TestToolSpecDryRunInputs(t *testing.T) {
+	session := testClientSession(t)
+
+	result, err := session.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+
+	for _, tool := range result.Tools {
+		t.Run(tool.Name, func(t *testing.T) {
+			data, err := json.Marshal(tool.InputSchema)
+			require.NoError(t, err)
+			var schema struct {
+				Properties map[string]any `json:"properties"`
+			}
+			require.NoError(t, json.Unmarshal(data, &schema))
+
+			_, hasDryRun := schema.Properties["dry_run"]
+			assert.Equal(t, toolSpecs[tool.Name].DryRun, hasDryRun, "dry_run input must be advertised exactly when the spec supports it")
+		})
+	}
+}
+
+// TestToolOutputsMatchSchemas calls tools through the SDK, which validates each
+// structured result against the tool's declared output schema.
+func TestToolOutputsMatchSchemas(t *testing.T) {
+	server, _, _ := testServerForToolConfig(t)
+	// Confirms the policy change that deletes schema-policy
+	session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+		ElicitationHandler: func(ctx context.Context, req *mcpsdk.ElicitRequest) (*mcpsdk.ElicitResult, error) {
+			return &mcpsdk.ElicitResult{Action: "accept", Content: map[string]any{"confirmed": true}}, nil
+		},
+	})
+
+	// Calls run in order against a shared mock, so later calls can rely on
+	// state created by earlier ones.
+	calls := []struct {
+		tool string
+		args map[string]any
+	}{
+		// Core
+		{"list_scenes", nil},
+		{"set_current_scene", map[string]any{"scene_name": "Gaming", "dry_run": true}},
+		{"set_current_scene", map[string]any{"scene_name": "Gaming"}},
+		{"create_scene", map[string]any{"scene_name": "Schema Test"}},
+		{"remove_scene", map[string]any{"scene_name": "Schema Test"}},
+		{"start_recording", nil},
+		{"pause_recording", nil},
+		{"resume_recording", nil},
+		{"get_recording_status", nil},
+		{"stop_recording", nil},
+		{"start_streaming", nil},
+		{"get_streaming_status", nil},
+		{"stop_streaming", nil},
+		{"get_obs_status", nil},
+		{"get_virtual_cam_status", nil},
+		{"toggle_virtual_cam", nil},
+		{"get_replay_buffer_status", nil},
+		{"toggle_replay_buffer", nil},
+		{"save_replay_buffer", nil},
+		{"get_last_replay", nil},
+		{"get_studio_mode_enabled", nil},
+		{"toggle_studio_mode", map[string]any{"studio_mode_enabled": true}},
+		{"get_preview_scene", nil},
+		{"set_preview_scene", map[string]any{"scene_name": "Gaming"}},
+		{"list_hotkeys", nil},
+		{"trigger_hotkey_by_name", map[string]any{"hotkey_name": "OBSBasic.Screenshot"}},
+
+		// Sources and audio
+		{"list_sources", nil},
+		{"toggle_source_visibility", map[string]any{"scene_name": "Scene 1", "source_id": 1}},
+		{"get_source_settings", map[string]any{"source_name": "Microphone"}},
+		{"get_input_mute", map[string]any{"input_name": "Microphone"}},
+		{"toggle_input_mute", map[string]any{"input_name": "Microphone"}},
+		{"set_input_volume", map[string]any{"input_name": "Microphone", "volume_db": -6.5}},
+		{"get_input_volume", map[string]any{"input_name": "Microphone"}},
+
+		// Layout
+		{"save_scene_preset", map[string]any{"preset_name": "schema", "scene_name": "Scene 1"}},
+		{"list_scene_presets", nil},
+		{"get_preset_details", map[string]any{"preset_name": "schema"}},
+		{"apply_scene_preset", map[string]any{"preset_name": "schema"}},
+		{"rename_scene_preset", map[string]any{"old_name": "schema", "new_name": "schema2"}},
+		{"delete_scene_preset", map[string]any{"preset_name": "schema2"}},
+
+		// Visual
+		{"list_screenshot_sources", nil},
+
+		// Design
+		{"create_text_source", map[string]any{"scene_name": "Scene 1", "source_name": "Title", "text": "Hello"}},
+		{"create_image_source", map[string]any{"scene_name": "Scene 1", "source_name": "Logo", "file_path": "/tmp/logo.png"}},
+		{"create_color_source", map[string]any{"scene_name": "Scene 1", "source_name": "Background", "color": 4278190335}},
+		{"create_browser_source", map[string]any{"scene_name": "Scene 1", "source_name": "Alerts", "url": "http://localhost/alerts"}},
+		{"create_media_source", map[string]any{"scene_name": "Scene 1", "source_name": "Intro", "file_path": "/tmp/intro.mp4", "loop": true}},
+		{"get_source_transform", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1}},
+		{"set_source_transform", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1, "x": 12.5, "dry_run": true}},
+		{"set_source_transform", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1, "x": 12.5}},
+		{"set_source_crop", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1, "crop_top": 10}},
+		{"set_source_bounds", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1, "bounds_type": "OBS_BOUNDS_NONE"}},
+		{"set_source_order", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1, "index": 0}},
+		{"set_source_locked", map[string]any{"scene_name": "Scene 1", "scene_item_id": 1, "locked": true}},
+		{"duplicate_source", map[string]any{"scene_name": "Scene 1", "scene_item_id": 2}},
+		{"remove_source", map[string]any{"scene_name": "Scene 1", "scene_item_id": 2}},
+		{"list_input_kinds", nil},
+
+		// Filters
+		{"list_source_filters", map[string]any{"source_name": "Webcam"}},
+		{"get_source_filter", map[string]any{"source_name": "Webcam", "filter_name": "Sharpen"}},
+		{"create_source_filter", map[string]any{"source_name": "Webcam", "filter_name": "Crop", "filter_kind": "crop_filter"}},
+		{"toggle_source_filter", map[string]any{"source_name": "Webcam", "filter_name": "Crop"}},
+		{"set_source_filter_settings", map[string]any{"source_name": "Webcam", "filter_name": "Crop", "filter_settings": map[string]any{"top": 10}}},
+		{"remove_source_filter", map[string]any{"source_name": "Webcam", "filter_name": "Crop"}},
+		{"list_filter_kinds", nil},
+
+		// Transitions
+		{"list_transitions", nil},
+		{"get_current_transition", nil},
+		{"set_current_transition", map[string]any{"transition_name": "Cut"}},
+		{"set_transition_duration", map[string]any{"transition_duration": 500}},
+		{"trigger_transition", nil},
+
+		// Automation
+		{"create_automation_rule", map[string]any{
+			"name":           "schema-rule",
+			"trigger_type":   "manual",
+			"trigger_config": map[string]any{},
+			"actions": []map[string]any{
+				{"type": "set_scene", "parameters": map[string]any{"scene": "Gaming"}},
+				{"type": "if", "condition": "obs.recording", "then": []any{map[string]any{"type": "save_replay"}}},
+			},
+			"condition": "!obs.streaming",
+		}},
+		{"list_automation_rules", nil},
+		{"get_automation_rule", map[string]any{"name": "schema-rule"}},
+		{"update_automation_rule", map[string]any{"name": "schema-rule", "priority": 5, "dry_run": true}},
+		{"update_automation_rule", map[string]any{"name": "schema-rule", "priority": 5}},
+		{"delete_automation_rule", map[string]any{"name": "schema-rule", "dry_run": true}},
+		{"disable_automation_rule", map[string]any{"name": "schema-rule"}},
+		{"enable_automation_rule", map[string]any{"name": "schema-rule"}},
+		{"list_rule_executions", nil},
+
+		// Undo (runs last so the journal holds the changes above)
+		{"list_undoable_actions", map[string]any{"limit": 5}},
+		{"undo_last_action", nil},
+
+		// Meta
+		{"help", map[string]any{"topic": "overview"}},
+		{"get_tool_config", map[string]any{"verbose": true}},
+		{"set_tool_config", map[string]any{"group": "Audio", "enabled": true}},
+		{"list_tool_groups", map[string]any{"include_disabled": true}},
+		{"execute_batch", map[string]any{"steps": []map[string]any{
+			{"tool": "get_preview_scene"},
+			{"tool": "set_current_scene", "arguments": map[string]any{"scene_name": "Scene 1"}},
+		}}},
+		{"set_policy", map[string]any{"name": "schema-policy", "tool": "start_streaming", "effect": "confirm"}},
+		{"get_policy", map[string]any{"tool": "start_streaming"}},
+		{"set_policy", map[string]any{"name": "schema-policy", "delete": true}},
+	}
+
+	for _, call := range calls {
+		res, err := session.CallTool(context.Background(), &mcpsdk.CallToolParams{
+			Name:      call.tool,
+			Arguments: call.args,
+		})
+		require.NoError(t, err, "tool %s output failed schema validation", call.tool)
+		require.False(t, res.IsError, "tool %s returned an error: %s", call.tool, toolResultText(res))
+		assert.NotNil(t, res.StructuredContent, "tool %s should return structured content", call.tool)
+	}
+}
+
+// toolResultText extracts the text content of a tool result for error messages.
+func toolResultText(res *mcpsdk.CallToolResult) string {
+	if res == nil {
+		return ""
+	}
+	data, _ := json.Marshal(res.Content)
+	return string(data)
+}
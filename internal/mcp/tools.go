@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/ironystock/agentic-obs/internal/obs"
@@ -16,6 +17,7 @@ import (
 // SceneNameInput is the input for scene operations
 type SceneNameInput struct {
 	SceneName string `json:"scene_name"`
+	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SimpleResult is a simple text result
@@ -32,11 +34,13 @@ type SourceNameInput struct {
 type SourceVisibilityInput struct {
 	SceneName string `json:"scene_name"`
 	SourceID  int64  `json:"source_id"`
+	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // InputNameInput is the input for audio input operations
 type InputNameInput struct {
 	InputName string `json:"input_name"`
+	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetVolumeInput is the input for setting audio input volume
@@ -44,6 +48,7 @@ type SetVolumeInput struct {
 	InputName string   `json:"input_name"`
 	VolumeDb  *float64 `json:"volume_db,omitempty"`
 	VolumeMul *float64 `json:"volume_mul,omitempty"`
+	DryRun    bool     `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // ListPresetsInput is the input for listing scene presets
@@ -54,6 +59,7 @@ type ListPresetsInput struct {
 // PresetNameInput is the input for preset operations by name
 type PresetNameInput struct {
 	PresetName string `json:"preset_name" jsonschema:"Name of the preset to operate on"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // RenamePresetInput is the input for renaming a preset
@@ -100,6 +106,7 @@ type CreateTextSourceInput struct {
 	FontName   string `json:"font_name,omitempty" jsonschema:"Font face name (default: Arial)"`
 	FontSize   int    `json:"font_size,omitempty" jsonschema:"Font size in points (default: 36)"`
 	Color      int64  `json:"color,omitempty" jsonschema:"Text color as ABGR integer (default: white)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // CreateImageSourceInput is the input for creating an image source
@@ -107,6 +114,7 @@ type CreateImageSourceInput struct {
 	SceneName  string `json:"scene_name" jsonschema:"Name of the scene to add the source to"`
 	SourceName string `json:"source_name" jsonschema:"Name for the new image source"`
 	FilePath   string `json:"file_path" jsonschema:"Path to the image file"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // CreateColorSourceInput is the input for creating a color source
@@ -116,6 +124,7 @@ type CreateColorSourceInput struct {
 	Color      int64  `json:"color" jsonschema:"Color as ABGR integer (e.g., 0xFF0000FF for red)"`
 	Width      int    `json:"width,omitempty" jsonschema:"Width in pixels (default: 1920)"`
 	Height     int    `json:"height,omitempty" jsonschema:"Height in pixels (default: 1080)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // CreateBrowserSourceInput is the input for creating a browser source
@@ -126,6 +135,7 @@ type CreateBrowserSourceInput struct {
 	Width      int    `json:"width,omitempty" jsonschema:"Browser width in pixels (default: 800)"`
 	Height     int    `json:"height,omitempty" jsonschema:"Browser height in pixels (default: 600)"`
 	FPS        int    `json:"fps,omitempty" jsonschema:"Frame rate (default: 30)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // CreateMediaSourceInput is the input for creating a media/video source
@@ -134,6 +144,7 @@ type CreateMediaSourceInput struct {
 	SourceName string `json:"source_name" jsonschema:"Name for the new media source"`
 	FilePath   string `json:"file_path" jsonschema:"Path to the media file"`
 	Loop       bool   `json:"loop,omitempty" jsonschema:"Whether to loop the media (default: false)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetSourceTransformInput is the input for setting source transform properties
@@ -145,6 +156,7 @@ type SetSourceTransformInput struct {
 	ScaleX      *float64 `json:"scale_x,omitempty" jsonschema:"X scale factor (1.0 = 100%)"`
 	ScaleY      *float64 `json:"scale_y,omitempty" jsonschema:"Y scale factor (1.0 = 100%)"`
 	Rotation    *float64 `json:"rotation,omitempty" jsonschema:"Rotation in degrees"`
+	DryRun      bool     `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // GetSourceTransformInput is the input for getting source transform properties
@@ -161,6 +173,7 @@ type SetSourceCropInput struct {
 	CropBottom  int    `json:"crop_bottom,omitempty" jsonschema:"Pixels to crop from bottom"`
 	CropLeft    int    `json:"crop_left,omitempty" jsonschema:"Pixels to crop from left"`
 	CropRight   int    `json:"crop_right,omitempty" jsonschema:"Pixels to crop from right"`
+	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetSourceBoundsInput is the input for setting source bounds
@@ -170,6 +183,7 @@ type SetSourceBoundsInput struct {
 	BoundsType   string  `json:"bounds_type" jsonschema:"Bounds type: OBS_BOUNDS_NONE, OBS_BOUNDS_STRETCH, OBS_BOUNDS_SCALE_INNER, OBS_BOUNDS_SCALE_OUTER, OBS_BOUNDS_SCALE_TO_WIDTH, OBS_BOUNDS_SCALE_TO_HEIGHT, OBS_BOUNDS_MAX_ONLY"`
 	BoundsWidth  float64 `json:"bounds_width,omitempty" jsonschema:"Bounds width in pixels"`
 	BoundsHeight float64 `json:"bounds_height,omitempty" jsonschema:"Bounds height in pixels"`
+	DryRun       bool    `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetSourceOrderInput is the input for setting source z-order
@@ -177,6 +191,7 @@ type SetSourceOrderInput struct {
 	SceneName   string `json:"scene_name" jsonschema:"Name of the scene containing the source"`
 	SceneItemID int    `json:"scene_item_id" jsonschema:"Scene item ID of the source"`
 	Index       int    `json:"index" jsonschema:"New index position (0 = bottom, higher = front)"`
+	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetSourceLockedInput is the input for locking/unlocking a source
@@ -184,6 +199,7 @@ type SetSourceLockedInput struct {
 	SceneName   string `json:"scene_name" jsonschema:"Name of the scene containing the source"`
 	SceneItemID int    `json:"scene_item_id" jsonschema:"Scene item ID of the source"`
 	Locked      bool   `json:"locked" jsonschema:"Whether the source should be locked"`
+	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // DuplicateSourceInput is the input for duplicating a source
@@ -191,12 +207,14 @@ type DuplicateSourceInput struct {
 	SceneName     string `json:"scene_name" jsonschema:"Name of the scene containing the source"`
 	SceneItemID   int    `json:"scene_item_id" jsonschema:"Scene item ID of the source to duplicate"`
 	DestSceneName string `json:"dest_scene_name,omitempty" jsonschema:"Destination scene name (default: same scene)"`
+	DryRun        bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // RemoveSourceInput is the input for removing a source from a scene
 type RemoveSourceInput struct {
 	SceneName   string `json:"scene_name" jsonschema:"Name of the scene containing the source"`
 	SceneItemID int    `json:"scene_item_id" jsonschema:"Scene item ID of the source to remove"`
+	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // Filter tool input types (FB-23)
@@ -218,12 +236,14 @@ type CreateSourceFilterInput struct {
 	FilterName     string                 `json:"filter_name" jsonschema:"Name for the new filter"`
 	FilterKind     string                 `json:"filter_kind" jsonschema:"Type of filter (use list_filter_kinds to see available types)"`
 	FilterSettings map[string]interface{} `json:"filter_settings,omitempty" jsonschema:"Optional initial settings for the filter"`
+	DryRun         bool                   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // RemoveSourceFilterInput is the input for removing a filter from a source
 type RemoveSourceFilterInput struct {
 	SourceName string `json:"source_name" jsonschema:"Name of the source containing the filter"`
 	FilterName string `json:"filter_name" jsonschema:"Name of the filter to remove"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // ToggleSourceFilterInput is the input for enabling/disabling a filter
@@ -231,6 +251,7 @@ type ToggleSourceFilterInput struct {
 	SourceName    string `json:"source_name" jsonschema:"Name of the source containing the filter"`
 	FilterName    string `json:"filter_name" jsonschema:"Name of the filter to toggle"`
 	FilterEnabled *bool  `json:"filter_enabled,omitempty" jsonschema:"Set to true/false to enable/disable; omit to toggle"`
+	DryRun        bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetSourceFilterSettingsInput is the input for updating filter settings
@@ -239,6 +260,7 @@ type SetSourceFilterSettingsInput struct {
 	FilterName     string                 `json:"filter_name" jsonschema:"Name of the filter to update"`
 	FilterSettings map[string]interface{} `json:"filter_settings" jsonschema:"Settings to apply to the filter"`
 	Overlay        bool                   `json:"overlay,omitempty" jsonschema:"If true, merge with existing settings; if false, replace entirely (default: true)"`
+	DryRun         bool                   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // Transition tool input types (FB-24)
@@ -246,11 +268,18 @@ type SetSourceFilterSettingsInput struct {
 // SetCurrentTransitionInput is the input for setting the current scene transition
 type SetCurrentTransitionInput struct {
 	TransitionName string `json:"transition_name" jsonschema:"Name of the transition to set as current"`
+	DryRun         bool   `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // SetTransitionDurationInput is the input for setting the transition duration
 type SetTransitionDurationInput struct {
-	TransitionDuration int `json:"transition_duration" jsonschema:"Transition duration in milliseconds"`
+	TransitionDuration int  `json:"transition_duration" jsonschema:"Transition duration in milliseconds"`
+	DryRun             bool `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
+}
+
+// TriggerTransitionInput is the input for triggering a studio mode transition
+type TriggerTransitionInput struct {
+	DryRun bool `json:"dry_run,omitempty" jsonschema:"Validate against live OBS state and return the planned changes without applying them"`
 }
 
 // Virtual Camera and Replay Buffer input types (FB-25)
@@ -283,7 +312,7 @@ func (s *Server) registerToolHandlers() {
 	// Core tools: Scene management, Recording, Streaming, Status
 	if s.toolGroups.Core {
 		// Scene management tools
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_current_scene",
 				Description: "Switch to a different scene in OBS",
@@ -291,7 +320,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetCurrentScene,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_scene",
 				Description: "Create a new scene in OBS",
@@ -299,7 +328,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateScene,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "remove_scene",
 				Description: "Remove a scene from OBS",
@@ -307,7 +336,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleRemoveScene,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_scenes",
 				Description: "List all available scenes in OBS and identify the current scene",
@@ -316,7 +345,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Recording tools
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "start_recording",
 				Description: "Start recording in OBS",
@@ -324,7 +353,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleStartRecording,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "stop_recording",
 				Description: "Stop the current recording in OBS",
@@ -332,7 +361,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleStopRecording,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_recording_status",
 				Description: "Get the current recording status from OBS",
@@ -340,7 +369,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetRecordingStatus,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "pause_recording",
 				Description: "Pause the current recording in OBS (recording must be active)",
@@ -348,7 +377,7 @@ func (s *Server) registerToolHandlers() {
 			s.handlePauseRecording,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "resume_recording",
 				Description: "Resume a paused recording in OBS (recording must be paused)",
@@ -357,7 +386,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Streaming tools
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "start_streaming",
 				Description: "Start streaming in OBS",
@@ -365,7 +394,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleStartStreaming,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "stop_streaming",
 				Description: "Stop the current stream in OBS",
@@ -373,7 +402,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleStopStreaming,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_streaming_status",
 				Description: "Get the current streaming status from OBS",
@@ -382,7 +411,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Status tool
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_obs_status",
 				Description: "Get overall OBS status including version, connection state, and active scene",
@@ -391,7 +420,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Virtual camera tools (FB-25)
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_virtual_cam_status",
 				Description: "Check if the virtual camera is currently active",
@@ -399,7 +428,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetVirtualCamStatus,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "toggle_virtual_cam",
 				Description: "Start or stop the virtual camera",
@@ -408,7 +437,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Replay buffer tools (FB-25)
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_replay_buffer_status",
 				Description: "Check if the replay buffer is currently active",
@@ -416,7 +445,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetReplayBufferStatus,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "toggle_replay_buffer",
 				Description: "Start or stop the replay buffer",
@@ -424,7 +453,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleToggleReplayBuffer,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "save_replay_buffer",
 				Description: "Save the current replay buffer to disk (replay buffer must be active)",
@@ -432,7 +461,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSaveReplayBuffer,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_last_replay",
 				Description: "Get the file path of the last saved replay buffer",
@@ -441,7 +470,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Studio mode tools (FB-26)
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_studio_mode_enabled",
 				Description: "Check if studio mode is currently enabled in OBS",
@@ -449,7 +478,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetStudioModeEnabled,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "toggle_studio_mode",
 				Description: "Enable or disable studio mode in OBS",
@@ -457,7 +486,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleToggleStudioMode,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_preview_scene",
 				Description: "Get the current preview scene in studio mode",
@@ -465,7 +494,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetPreviewScene,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_preview_scene",
 				Description: "Set the preview scene in studio mode",
@@ -474,7 +503,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Hotkey tools (FB-26)
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "trigger_hotkey_by_name",
 				Description: "Trigger an OBS hotkey by its name (use list_hotkeys to see available hotkeys)",
@@ -482,7 +511,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleTriggerHotkeyByName,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_hotkeys",
 				Description: "List all available OBS hotkey names",
@@ -490,13 +519,38 @@ func (s *Server) registerToolHandlers() {
 			s.handleListHotkeys,
 		)
 
-		toolCount += 25
-		log.Println("Core tools registered (25 tools)")
+		// Undo tools
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "list_undoable_actions",
+				Description: "List recent OBS changes that can be undone, newest first, with the operations that reverse them",
+			},
+			s.handleListUndoableActions,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "undo_last_action",
+				Description: "Undo the most recent undoable OBS change by restoring the state captured before it",
+			},
+			s.handleUndoLastAction,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "undo_to",
+				Description: "Undo every undoable OBS change back to and including the given action ID, newest first; stops at the first failure",
+			},
+			s.handleUndoTo,
+		)
+
+		toolCount += 28
+		log.Println("Core tools registered (28 tools)")
 	}
 
 	// Source tools
 	if s.toolGroups.Sources {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_sources",
 				Description: "List all input sources (audio and video) available in OBS",
@@ -504,7 +558,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleListSources,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "toggle_source_visibility",
 				Description: "Toggle the visibility of a source in a specific scene",
@@ -512,7 +566,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleToggleSourceVisibility,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_source_settings",
 				Description: "Retrieve configuration settings for a specific source",
@@ -526,7 +580,7 @@ func (s *Server) registerToolHandlers() {
 
 	// Audio tools
 	if s.toolGroups.Audio {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_input_mute",
 				Description: "Check whether an audio input is currently muted",
@@ -534,7 +588,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetInputMute,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "toggle_input_mute",
 				Description: "Toggle the mute state of an audio input (muted <-> unmuted)",
@@ -542,7 +596,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleToggleInputMute,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_input_volume",
 				Description: "Set the volume level of an audio input (supports dB or multiplier format)",
@@ -550,7 +604,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetInputVolume,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_input_volume",
 				Description: "Get the current volume level of an audio input (returns dB and multiplier values)",
@@ -564,7 +618,7 @@ func (s *Server) registerToolHandlers() {
 
 	// Layout tools: Scene presets
 	if s.toolGroups.Layout {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_scene_presets",
 				Description: "List all saved scene presets, optionally filtered by scene name",
@@ -572,7 +626,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleListScenePresets,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_preset_details",
 				Description: "Get detailed information about a specific scene preset including source states",
@@ -580,7 +634,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetPresetDetails,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "delete_scene_preset",
 				Description: "Delete a saved scene preset by name",
@@ -588,7 +642,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleDeleteScenePreset,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "rename_scene_preset",
 				Description: "Rename an existing scene preset",
@@ -596,7 +650,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleRenameScenePreset,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "save_scene_preset",
 				Description: "Save the current state of a scene as a named preset",
@@ -604,10 +658,10 @@ func (s *Server) registerToolHandlers() {
 			s.handleSaveScenePreset,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "apply_scene_preset",
-				Description: "Apply a saved preset to restore source visibility states",
+				Description: "Apply a saved preset to restore source visibility states. If it stops partway, the error lists the sources already applied and undo_last_action reverts them",
 			},
 			s.handleApplyScenePreset,
 		)
@@ -618,7 +672,7 @@ func (s *Server) registerToolHandlers() {
 
 	// Visual tools: Screenshot sources
 	if s.toolGroups.Visual {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_screenshot_source",
 				Description: "Create a periodic screenshot capture source for visual monitoring of OBS scenes",
@@ -626,7 +680,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateScreenshotSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "remove_screenshot_source",
 				Description: "Stop and remove a screenshot capture source",
@@ -634,7 +688,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleRemoveScreenshotSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_screenshot_sources",
 				Description: "List all configured screenshot sources with their status and HTTP URLs",
@@ -642,7 +696,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleListScreenshotSources,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "configure_screenshot_cadence",
 				Description: "Update the capture interval for a screenshot source",
@@ -657,7 +711,7 @@ func (s *Server) registerToolHandlers() {
 	// Design tools: Source creation and manipulation
 	if s.toolGroups.Design {
 		// Source creation tools
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_text_source",
 				Description: "Create a text/label source in a scene with customizable font and color",
@@ -665,7 +719,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateTextSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_image_source",
 				Description: "Create an image source in a scene from a file path",
@@ -673,7 +727,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateImageSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_color_source",
 				Description: "Create a solid color source in a scene",
@@ -681,7 +735,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateColorSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_browser_source",
 				Description: "Create a browser source in a scene to display web content",
@@ -689,7 +743,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateBrowserSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_media_source",
 				Description: "Create a media/video source in a scene from a file path",
@@ -698,7 +752,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Layout control tools
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_source_transform",
 				Description: "Set position, scale, and rotation of a source in a scene",
@@ -706,7 +760,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetSourceTransform,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_source_transform",
 				Description: "Get the current transform properties of a source in a scene",
@@ -714,7 +768,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetSourceTransform,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_source_crop",
 				Description: "Set crop values for a source in a scene",
@@ -722,7 +776,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetSourceCrop,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_source_bounds",
 				Description: "Set bounds type and size for a source in a scene",
@@ -730,7 +784,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetSourceBounds,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_source_order",
 				Description: "Set the z-order index of a source in a scene (0 = back, higher = front)",
@@ -739,7 +793,7 @@ func (s *Server) registerToolHandlers() {
 		)
 
 		// Advanced tools
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_source_locked",
 				Description: "Lock or unlock a source to prevent accidental changes",
@@ -747,7 +801,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetSourceLocked,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "duplicate_source",
 				Description: "Duplicate a source within the same scene or to another scene",
@@ -755,7 +809,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleDuplicateSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "remove_source",
 				Description: "Remove a source from a scene",
@@ -763,7 +817,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleRemoveSource,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_input_kinds",
 				Description: "List all available input source types in OBS",
@@ -777,7 +831,7 @@ func (s *Server) registerToolHandlers() {
 
 	// Filter tools (FB-23)
 	if s.toolGroups.Filters {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_source_filters",
 				Description: "List all filters applied to a source",
@@ -785,7 +839,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleListSourceFilters,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_source_filter",
 				Description: "Get detailed information about a specific filter on a source",
@@ -793,7 +847,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetSourceFilter,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_source_filter",
 				Description: "Add a new filter to a source (e.g., color correction, noise suppression)",
@@ -801,7 +855,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateSourceFilter,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "remove_source_filter",
 				Description: "Remove a filter from a source",
@@ -809,7 +863,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleRemoveSourceFilter,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "toggle_source_filter",
 				Description: "Enable or disable a filter on a source",
@@ -817,7 +871,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleToggleSourceFilter,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_source_filter_settings",
 				Description: "Modify the configuration settings of a filter",
@@ -825,7 +879,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetSourceFilterSettings,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_filter_kinds",
 				Description: "List all available filter types in OBS",
@@ -839,7 +893,7 @@ func (s *Server) registerToolHandlers() {
 
 	// Transition tools (FB-24)
 	if s.toolGroups.Transitions {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_transitions",
 				Description: "List all available scene transitions and identify the current one",
@@ -847,7 +901,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleListTransitions,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_current_transition",
 				Description: "Get details about the current scene transition including duration and settings",
@@ -855,7 +909,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetCurrentTransition,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_current_transition",
 				Description: "Change the active scene transition (e.g., Cut, Fade, Swipe)",
@@ -863,7 +917,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetCurrentTransition,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "set_transition_duration",
 				Description: "Set the duration of the current scene transition in milliseconds",
@@ -871,7 +925,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleSetTransitionDuration,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "trigger_transition",
 				Description: "Trigger the current transition in studio mode (swaps preview and program scenes)",
@@ -885,7 +939,7 @@ func (s *Server) registerToolHandlers() {
 
 	// Automation tools
 	if s.toolGroups.Automation {
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_automation_rules",
 				Description: "List all automation rules with their status, trigger type, and execution stats",
@@ -893,7 +947,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleListAutomationRules,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "get_automation_rule",
 				Description: "Get detailed configuration of a specific automation rule by name",
@@ -901,7 +955,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleGetAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "create_automation_rule",
 				Description: "Create a new automation rule with triggers and actions",
@@ -909,7 +963,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleCreateAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "update_automation_rule",
 				Description: "Update an existing automation rule's configuration",
@@ -917,7 +971,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleUpdateAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "delete_automation_rule",
 				Description: "Delete an automation rule and its execution history",
@@ -925,7 +979,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleDeleteAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "enable_automation_rule",
 				Description: "Enable an automation rule so it can be triggered",
@@ -933,7 +987,7 @@ func (s *Server) registerToolHandlers() {
 			s.handleEnableAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "disable_automation_rule",
 				Description: "Disable an automation rule without deleting it",
@@ -941,15 +995,15 @@ func (s *Server) registerToolHandlers() {
 			s.handleDisableAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "trigger_automation_rule",
-				Description: "Manually trigger an automation rule for testing or one-off execution",
+				Description: "Manually trigger an automation rule for testing or one-off execution. Set wait (or send a progress token) to follow the run with per-action progress and cancellation",
 			},
 			s.handleTriggerAutomationRule,
 		)
 
-		mcpsdk.AddTool(s.mcpServer,
+		addTool(s,
 			&mcpsdk.Tool{
 				Name:        "list_rule_executions",
 				Description: "List recent automation rule execution history with status and results",
@@ -957,14 +1011,150 @@ func (s *Server) registerToolHandlers() {
 			s.handleListRuleExecutions,
 		)
 
-		toolCount += 9
-		log.Println("Automation tools registered (9 tools)")
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "list_running_executions",
+				Description: "List rule executions in progress with the action each is running and how long it has run",
+			},
+			s.handleListRunningExecutions,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "cancel_rule_execution",
+				Description: "Cancel a running rule execution by ID. Delays stop at once, no further actions run, and the execution is recorded as cancelled. An OBS request already sent cannot be withdrawn: the action waiting for it is marked abandoned and may still take effect",
+			},
+			s.handleCancelRuleExecution,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "simulate_automation_event",
+				Description: "Test rules against a synthetic event: returns which rules would fire in priority order, why the others are skipped (event type, filter, cooldown, condition, concurrency policy against the executions in flight), schedule rules that would run delay_seconds after the event, composite rules the event would complete (their trigger state is read, not advanced), and each fired rule's actions with template variables resolved. Nothing is executed and cooldowns are not started",
+			},
+			s.handleSimulateAutomationEvent,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "get_next_runs",
+				Description: "Preview the next fire times of a schedule rule in its time zone, skipping times outside its active and blackout windows. For rules that fire after an event, lists the runs waiting to fire",
+			},
+			s.handleGetNextRuns,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "start_macro_recording",
+				Description: "Start recording supported tool calls (scene switches, mute, volume, visibility, recording, streaming, hotkeys) as a macro, optionally keeping the pauses between them as delays. Only calls from this client session are captured",
+			},
+			s.handleStartMacroRecording,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "stop_macro_recording",
+				Description: "Stop this session's macro recording and save it as a manual-trigger automation rule that can be edited, triggered by name, or bound to events",
+			},
+			s.handleStopMacroRecording,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "list_macros",
+				Description: "List reusable macros with their parameters and action counts",
+			},
+			s.handleListMacros,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "get_macro",
+				Description: "Get a macro's parameters and actions by name",
+			},
+			s.handleGetMacro,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "create_macro",
+				Description: "Create a named, parameterized action list that rules call with the run_macro action and that can be run directly with run_macro",
+			},
+			s.handleCreateMacro,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "update_macro",
+				Description: "Update a macro's name, description, parameters or actions. Rules calling it use the new version on their next run",
+			},
+			s.handleUpdateMacro,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "delete_macro",
+				Description: "Delete a macro. Rules that still call it fail at their run_macro action",
+			},
+			s.handleDeleteMacro,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "run_macro",
+				Description: "Run a macro with arguments and return the result of each of its actions, including those of macros it calls",
+			},
+			s.handleRunMacro,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "export_automation_bundle",
+				Description: "Export automation rules, the macros they call and related scene presets as a versioned JSON or YAML bundle for another installation",
+			},
+			s.handleExportAutomationBundle,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "import_automation_bundle",
+				Description: "Import an automation bundle, skipping, renaming or overwriting items whose names are taken. Validates every rule and macro and reports scenes and inputs missing in OBS",
+			},
+			s.handleImportAutomationBundle,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "list_rule_revisions",
+				Description: "List the saved revisions of an automation rule, newest first, with what changed each one and who saved it",
+			},
+			s.handleListRuleRevisions,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "diff_rule_revisions",
+				Description: "Compare two revisions of an automation rule field by field. Defaults to the current revision and the one before it",
+			},
+			s.handleDiffRuleRevisions,
+		)
+
+		addTool(s,
+			&mcpsdk.Tool{
+				Name:        "restore_rule_revision",
+				Description: "Roll an automation rule back to an earlier revision. The restore is saved as a new revision and the rule keeps its enabled state",
+			},
+			s.handleRestoreRuleRevision,
+		)
+
+		toolCount += 26
+		log.Println("Automation tools registered (26 tools)")
 	}
 
 	// Meta tools - always enabled, cannot be disabled
 	// These provide help and runtime tool configuration
 
-	mcpsdk.AddTool(s.mcpServer,
+	addTool(s,
 		&mcpsdk.Tool{
 			Name:        "help",
 			Description: "Get detailed help on agentic-obs features, tools, resources, prompts, and workflows. Use topic='overview' for a quick start guide, topic='tools' for all available tools, or topic='<tool_name>' for specific tool help.",
@@ -972,7 +1162,7 @@ func (s *Server) registerToolHandlers() {
 		s.handleHelp,
 	)
 
-	mcpsdk.AddTool(s.mcpServer,
+	addTool(s,
 		&mcpsdk.Tool{
 			Name:        "get_tool_config",
 			Description: "Get current tool group configuration showing which tool groups are enabled/disabled. Use group parameter to filter by specific group, verbose=true to include tool names.",
@@ -980,7 +1170,7 @@ func (s *Server) registerToolHandlers() {
 		s.handleGetToolConfig,
 	)
 
-	mcpsdk.AddTool(s.mcpServer,
+	addTool(s,
 		&mcpsdk.Tool{
 			Name:        "set_tool_config",
 			Description: "Enable or disable a tool group at runtime. Changes are session-only by default; use persist=true to save to database for future sessions.",
@@ -988,7 +1178,7 @@ func (s *Server) registerToolHandlers() {
 		s.handleSetToolConfig,
 	)
 
-	mcpsdk.AddTool(s.mcpServer,
+	addTool(s,
 		&mcpsdk.Tool{
 			Name:        "list_tool_groups",
 			Description: "List all available tool groups with their descriptions and enabled status. Use include_disabled=false to only show enabled groups.",
@@ -996,8 +1186,42 @@ func (s *Server) registerToolHandlers() {
 		s.handleListToolGroups,
 	)
 
-	toolCount += 4 // 4 meta-tools (help + 3 config tools)
-	log.Println("Meta tools registered (help, get_tool_config, set_tool_config, list_tool_groups)")
+	addTool(s,
+		&mcpsdk.Tool{
+			Name:        "execute_batch",
+			Description: "Run an ordered list of tool calls (tool name plus arguments) as one unit. Stops at the first failing step and rolls back completed steps using their captured prior state. Returns per-step results and is recorded as a single action history entry.",
+		},
+		s.handleExecuteBatch,
+	)
+
+	addTool(s,
+		&mcpsdk.Tool{
+			Name:        "get_policy",
+			Description: "List the policy rules that allow, deny, or require confirmation for tool calls. Use tool parameter to only show rules for one tool and see how a call to it would be decided now.",
+		},
+		s.handleGetPolicy,
+	)
+
+	addTool(s,
+		&mcpsdk.Tool{
+			Name:        "set_policy",
+			Description: "Create, replace, or delete a policy rule for a tool or tool group. Effects: allow, deny, confirm. Optional conditions: while_streaming, while_recording, time_window, rate_limit. Rules are stored and apply to every later tool call. Deleting or replacing a deny or confirm rule, or saving an allow rule, asks the user to confirm.",
+		},
+		s.handleSetPolicy,
+	)
+
+	toolCount += 7 // 7 meta-tools (help + 3 config tools + execute_batch + 2 policy tools)
+	log.Println("Meta tools registered (help, get_tool_config, set_tool_config, list_tool_groups, execute_batch, get_policy, set_policy)")
+
+	if s.readOnly {
+		toolCount = len(readOnlyTools(MetaToolNames))
+		for _, group := range ToolGroupOrder {
+			if s.getGroupEnabled(group) {
+				toolCount += len(readOnlyTools(toolGroupMetadata[group].ToolNames))
+			}
+		}
+		log.Println("Read-only mode: mutating tools were not registered")
+	}
 
 	log.Printf("Tool handlers registered successfully (%d tools total)", toolCount)
 }
@@ -1008,13 +1232,19 @@ func (s *Server) handleSetCurrentScene(ctx context.Context, request *mcpsdk.Call
 	start := time.Now()
 	log.Printf("Setting current scene to: %s", input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planSetCurrentScene(input)
+		return s.dryRunResult(ctx, "set_current_scene", "Set current scene", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureCurrentScene())
 	if err := s.obsClient.SetCurrentScene(input.SceneName); err != nil {
-		s.recordAction("set_current_scene", "Set current scene", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_current_scene", "Set current scene", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set current scene: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully switched to scene: %s", input.SceneName)}
-	s.recordAction("set_current_scene", "Set current scene", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_current_scene", "Set current scene", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -1022,13 +1252,18 @@ func (s *Server) handleCreateScene(ctx context.Context, request *mcpsdk.CallTool
 	start := time.Now()
 	log.Printf("Creating scene: %s", input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planCreateScene(input)
+		return s.dryRunResult(ctx, "create_scene", "Create scene", input, changes, err, start)
+	}
+
 	if err := s.obsClient.CreateScene(input.SceneName); err != nil {
-		s.recordAction("create_scene", "Create scene", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_scene", "Create scene", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create scene: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully created scene: %s", input.SceneName)}
-	s.recordAction("create_scene", "Create scene", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_scene", "Create scene", input, result, time.Since(start), undoCreateScene(input.SceneName))
 	return nil, result, nil
 }
 
@@ -1036,6 +1271,11 @@ func (s *Server) handleRemoveScene(ctx context.Context, request *mcpsdk.CallTool
 	start := time.Now()
 	log.Printf("Removing scene: %s - requesting confirmation", input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planRemoveScene(input)
+		return s.dryRunResult(ctx, "remove_scene", "Remove scene", input, changes, err, start)
+	}
+
 	// Request user confirmation before deleting scene
 	confirmed, err := ElicitDeleteConfirmation(ctx, getSession(request), "scene", input.SceneName)
 	if err != nil {
@@ -1043,17 +1283,18 @@ func (s *Server) handleRemoveScene(ctx context.Context, request *mcpsdk.CallTool
 		// Continue without confirmation if elicitation fails
 	} else if !confirmed {
 		result := CancelledResult("Scene removal")
-		s.recordAction("remove_scene", "Remove scene (cancelled)", input, result, false, time.Since(start))
+		s.recordAction(ctx, "remove_scene", "Remove scene (cancelled)", input, result, false, time.Since(start))
 		return nil, result, nil
 	}
 
+	undo := s.undoOps(s.captureRemoveScene(input.SceneName))
 	if err := s.obsClient.RemoveScene(input.SceneName); err != nil {
-		s.recordAction("remove_scene", "Remove scene", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "remove_scene", "Remove scene", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to remove scene: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully removed scene: %s", input.SceneName)}
-	s.recordAction("remove_scene", "Remove scene", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "remove_scene", "Remove scene", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -1062,12 +1303,12 @@ func (s *Server) handleStartRecording(ctx context.Context, request *mcpsdk.CallT
 	log.Println("Starting recording")
 
 	if err := s.obsClient.StartRecording(); err != nil {
-		s.recordAction("start_recording", "Start recording", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "start_recording", "Start recording", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to start recording: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully started recording"}
-	s.recordAction("start_recording", "Start recording", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "start_recording", "Start recording", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1077,12 +1318,12 @@ func (s *Server) handleStopRecording(ctx context.Context, request *mcpsdk.CallTo
 
 	outputPath, err := s.obsClient.StopRecording()
 	if err != nil {
-		s.recordAction("stop_recording", "Stop recording", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "stop_recording", "Stop recording", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to stop recording: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully stopped recording. Output saved to: %s", outputPath)}
-	s.recordAction("stop_recording", "Stop recording", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "stop_recording", "Stop recording", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1092,11 +1333,11 @@ func (s *Server) handleGetRecordingStatus(ctx context.Context, request *mcpsdk.C
 
 	status, err := s.obsClient.GetRecordingStatus()
 	if err != nil {
-		s.recordAction("get_recording_status", "Get recording status", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_recording_status", "Get recording status", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get recording status: %w", err)
 	}
 
-	s.recordAction("get_recording_status", "Get recording status", nil, status, true, time.Since(start))
+	s.recordAction(ctx, "get_recording_status", "Get recording status", nil, status, true, time.Since(start))
 	return nil, status, nil
 }
 
@@ -1111,17 +1352,17 @@ func (s *Server) handleStartStreaming(ctx context.Context, request *mcpsdk.CallT
 		// Continue without confirmation if elicitation fails
 	} else if !confirmed {
 		result := CancelledResult("Streaming start")
-		s.recordAction("start_streaming", "Start streaming (cancelled)", nil, result, false, time.Since(start))
+		s.recordAction(ctx, "start_streaming", "Start streaming (cancelled)", nil, result, false, time.Since(start))
 		return nil, result, nil
 	}
 
 	if err := s.obsClient.StartStreaming(); err != nil {
-		s.recordAction("start_streaming", "Start streaming", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "start_streaming", "Start streaming", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to start streaming: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully started streaming"}
-	s.recordAction("start_streaming", "Start streaming", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "start_streaming", "Start streaming", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1136,17 +1377,17 @@ func (s *Server) handleStopStreaming(ctx context.Context, request *mcpsdk.CallTo
 		// Continue without confirmation if elicitation fails
 	} else if !confirmed {
 		result := CancelledResult("Streaming stop")
-		s.recordAction("stop_streaming", "Stop streaming (cancelled)", nil, result, false, time.Since(start))
+		s.recordAction(ctx, "stop_streaming", "Stop streaming (cancelled)", nil, result, false, time.Since(start))
 		return nil, result, nil
 	}
 
 	if err := s.obsClient.StopStreaming(); err != nil {
-		s.recordAction("stop_streaming", "Stop streaming", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "stop_streaming", "Stop streaming", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to stop streaming: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully stopped streaming"}
-	s.recordAction("stop_streaming", "Stop streaming", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "stop_streaming", "Stop streaming", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1156,11 +1397,11 @@ func (s *Server) handleGetStreamingStatus(ctx context.Context, request *mcpsdk.C
 
 	status, err := s.obsClient.GetStreamingStatus()
 	if err != nil {
-		s.recordAction("get_streaming_status", "Get streaming status", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_streaming_status", "Get streaming status", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get streaming status: %w", err)
 	}
 
-	s.recordAction("get_streaming_status", "Get streaming status", nil, status, true, time.Since(start))
+	s.recordAction(ctx, "get_streaming_status", "Get streaming status", nil, status, true, time.Since(start))
 	return nil, status, nil
 }
 
@@ -1170,11 +1411,11 @@ func (s *Server) handleGetOBSStatus(ctx context.Context, request *mcpsdk.CallToo
 
 	status, err := s.obsClient.GetOBSStatus()
 	if err != nil {
-		s.recordAction("get_obs_status", "Get OBS status", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_obs_status", "Get OBS status", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get OBS status: %w", err)
 	}
 
-	s.recordAction("get_obs_status", "Get OBS status", nil, status, true, time.Since(start))
+	s.recordAction(ctx, "get_obs_status", "Get OBS status", nil, status, true, time.Since(start))
 	return nil, status, nil
 }
 
@@ -1186,7 +1427,7 @@ func (s *Server) handleListScenes(ctx context.Context, request *mcpsdk.CallToolR
 
 	scenes, currentScene, err := s.obsClient.GetSceneList()
 	if err != nil {
-		s.recordAction("list_scenes", "List scenes", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_scenes", "List scenes", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list scenes: %w", err)
 	}
 
@@ -1194,7 +1435,7 @@ func (s *Server) handleListScenes(ctx context.Context, request *mcpsdk.CallToolR
 		"scenes":        scenes,
 		"current_scene": currentScene,
 	}
-	s.recordAction("list_scenes", "List scenes", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_scenes", "List scenes", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1203,12 +1444,12 @@ func (s *Server) handlePauseRecording(ctx context.Context, request *mcpsdk.CallT
 	log.Println("Pausing recording")
 
 	if err := s.obsClient.PauseRecording(); err != nil {
-		s.recordAction("pause_recording", "Pause recording", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "pause_recording", "Pause recording", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to pause recording: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully paused recording"}
-	s.recordAction("pause_recording", "Pause recording", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "pause_recording", "Pause recording", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1217,12 +1458,12 @@ func (s *Server) handleResumeRecording(ctx context.Context, request *mcpsdk.Call
 	log.Println("Resuming recording")
 
 	if err := s.obsClient.ResumeRecording(); err != nil {
-		s.recordAction("resume_recording", "Resume recording", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "resume_recording", "Resume recording", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to resume recording: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully resumed recording"}
-	s.recordAction("resume_recording", "Resume recording", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "resume_recording", "Resume recording", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1232,7 +1473,7 @@ func (s *Server) handleListSources(ctx context.Context, request *mcpsdk.CallTool
 
 	sources, err := s.obsClient.ListSources()
 	if err != nil {
-		s.recordAction("list_sources", "List sources", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_sources", "List sources", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list sources: %w", err)
 	}
 
@@ -1240,7 +1481,7 @@ func (s *Server) handleListSources(ctx context.Context, request *mcpsdk.CallTool
 		"sources": sources,
 		"count":   len(sources),
 	}
-	s.recordAction("list_sources", "List sources", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_sources", "List sources", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1248,9 +1489,15 @@ func (s *Server) handleToggleSourceVisibility(ctx context.Context, request *mcps
 	start := time.Now()
 	log.Printf("Toggling visibility for source %d in scene: %s", input.SourceID, input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planToggleSourceVisibility(input)
+		return s.dryRunResult(ctx, "toggle_source_visibility", "Toggle source visibility", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureSceneItemEnabled(input.SceneName, int(input.SourceID)))
 	newState, err := s.obsClient.ToggleSourceVisibility(input.SceneName, int(input.SourceID))
 	if err != nil {
-		s.recordAction("toggle_source_visibility", "Toggle source visibility", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "toggle_source_visibility", "Toggle source visibility", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to toggle source visibility: %w", err)
 	}
 
@@ -1259,7 +1506,7 @@ func (s *Server) handleToggleSourceVisibility(ctx context.Context, request *mcps
 		"source_id":  input.SourceID,
 		"visible":    newState,
 	}
-	s.recordAction("toggle_source_visibility", "Toggle source visibility", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "toggle_source_visibility", "Toggle source visibility", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -1269,11 +1516,11 @@ func (s *Server) handleGetSourceSettings(ctx context.Context, request *mcpsdk.Ca
 
 	settings, err := s.obsClient.GetSourceSettings(input.SourceName)
 	if err != nil {
-		s.recordAction("get_source_settings", "Get source settings", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_source_settings", "Get source settings", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get source settings: %w", err)
 	}
 
-	s.recordAction("get_source_settings", "Get source settings", input, settings, true, time.Since(start))
+	s.recordAction(ctx, "get_source_settings", "Get source settings", input, settings, true, time.Since(start))
 	return nil, settings, nil
 }
 
@@ -1283,7 +1530,7 @@ func (s *Server) handleGetInputMute(ctx context.Context, request *mcpsdk.CallToo
 
 	isMuted, err := s.obsClient.GetInputMute(input.InputName)
 	if err != nil {
-		s.recordAction("get_input_mute", "Get input mute", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_input_mute", "Get input mute", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get input mute status: %w", err)
 	}
 
@@ -1291,7 +1538,7 @@ func (s *Server) handleGetInputMute(ctx context.Context, request *mcpsdk.CallToo
 		"input_name": input.InputName,
 		"is_muted":   isMuted,
 	}
-	s.recordAction("get_input_mute", "Get input mute", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_input_mute", "Get input mute", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1299,13 +1546,19 @@ func (s *Server) handleToggleInputMute(ctx context.Context, request *mcpsdk.Call
 	start := time.Now()
 	log.Printf("Toggling mute for input: %s", input.InputName)
 
+	if input.DryRun {
+		changes, err := s.planToggleInputMute(input)
+		return s.dryRunResult(ctx, "toggle_input_mute", "Toggle input mute", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureInputMute(input.InputName))
 	if err := s.obsClient.ToggleInputMute(input.InputName); err != nil {
-		s.recordAction("toggle_input_mute", "Toggle input mute", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "toggle_input_mute", "Toggle input mute", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to toggle input mute: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully toggled mute for input: %s", input.InputName)}
-	s.recordAction("toggle_input_mute", "Toggle input mute", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "toggle_input_mute", "Toggle input mute", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -1313,13 +1566,19 @@ func (s *Server) handleSetInputVolume(ctx context.Context, request *mcpsdk.CallT
 	start := time.Now()
 	log.Printf("Setting volume for input: %s", input.InputName)
 
+	if input.DryRun {
+		changes, err := s.planSetInputVolume(input)
+		return s.dryRunResult(ctx, "set_input_volume", "Set input volume", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureInputVolume(input.InputName))
 	if err := s.obsClient.SetInputVolume(input.InputName, input.VolumeDb, input.VolumeMul); err != nil {
-		s.recordAction("set_input_volume", "Set input volume", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_input_volume", "Set input volume", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set input volume: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully set volume for input: %s", input.InputName)}
-	s.recordAction("set_input_volume", "Set input volume", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_input_volume", "Set input volume", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -1333,7 +1592,7 @@ func (s *Server) handleListScenePresets(ctx context.Context, request *mcpsdk.Cal
 
 	presets, err := s.storage.ListScenePresets(ctx, input.SceneName)
 	if err != nil {
-		s.recordAction("list_scene_presets", "List scene presets", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_scene_presets", "List scene presets", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list scene presets: %w", err)
 	}
 
@@ -1352,7 +1611,7 @@ func (s *Server) handleListScenePresets(ctx context.Context, request *mcpsdk.Cal
 		"presets": presetList,
 		"count":   len(presets),
 	}
-	s.recordAction("list_scene_presets", "List scene presets", input, result, true, time.Since(start))
+	s.recordAction(ctx, "list_scene_presets", "List scene presets", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1365,7 +1624,7 @@ func (s *Server) handleGetPresetDetails(ctx context.Context, request *mcpsdk.Cal
 
 	preset, err := s.storage.GetScenePreset(ctx, input.PresetName)
 	if err != nil {
-		s.recordAction("get_preset_details", "Get preset details", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_preset_details", "Get preset details", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get preset details: %w", err)
 	}
 
@@ -1376,7 +1635,7 @@ func (s *Server) handleGetPresetDetails(ctx context.Context, request *mcpsdk.Cal
 		"sources":    preset.Sources,
 		"created_at": preset.CreatedAt.Format("2006-01-02T15:04:05Z"),
 	}
-	s.recordAction("get_preset_details", "Get preset details", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_preset_details", "Get preset details", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1393,17 +1652,17 @@ func (s *Server) handleDeleteScenePreset(ctx context.Context, request *mcpsdk.Ca
 		// Continue without confirmation if elicitation fails
 	} else if !confirmed {
 		result := CancelledResult("Preset deletion")
-		s.recordAction("delete_scene_preset", "Delete scene preset (cancelled)", input, result, false, time.Since(start))
+		s.recordAction(ctx, "delete_scene_preset", "Delete scene preset (cancelled)", input, result, false, time.Since(start))
 		return nil, result, nil
 	}
 
 	if err := s.storage.DeleteScenePreset(ctx, input.PresetName); err != nil {
-		s.recordAction("delete_scene_preset", "Delete scene preset", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "delete_scene_preset", "Delete scene preset", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to delete preset: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully deleted preset: %s", input.PresetName)}
-	s.recordAction("delete_scene_preset", "Delete scene preset", input, result, true, time.Since(start))
+	s.recordAction(ctx, "delete_scene_preset", "Delete scene preset", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1414,12 +1673,12 @@ func (s *Server) handleRenameScenePreset(ctx context.Context, request *mcpsdk.Ca
 	log.Printf("Renaming preset from '%s' to '%s'", input.OldName, input.NewName)
 
 	if err := s.storage.RenameScenePreset(ctx, input.OldName, input.NewName); err != nil {
-		s.recordAction("rename_scene_preset", "Rename scene preset", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "rename_scene_preset", "Rename scene preset", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to rename preset: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully renamed preset from '%s' to '%s'", input.OldName, input.NewName)}
-	s.recordAction("rename_scene_preset", "Rename scene preset", input, result, true, time.Since(start))
+	s.recordAction(ctx, "rename_scene_preset", "Rename scene preset", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1432,7 +1691,7 @@ func (s *Server) handleGetInputVolume(ctx context.Context, request *mcpsdk.CallT
 
 	volumeDb, volumeMul, err := s.obsClient.GetInputVolume(input.InputName)
 	if err != nil {
-		s.recordAction("get_input_volume", "Get input volume", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_input_volume", "Get input volume", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get input volume: %w", err)
 	}
 
@@ -1441,7 +1700,7 @@ func (s *Server) handleGetInputVolume(ctx context.Context, request *mcpsdk.CallT
 		"volume_db":  volumeDb,
 		"volume_mul": volumeMul,
 	}
-	s.recordAction("get_input_volume", "Get input volume", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_input_volume", "Get input volume", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1456,7 +1715,7 @@ func (s *Server) handleSaveScenePreset(ctx context.Context, request *mcpsdk.Call
 	// Capture current scene state from OBS
 	states, err := s.obsClient.CaptureSceneState(input.SceneName)
 	if err != nil {
-		s.recordAction("save_scene_preset", "Save scene preset", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "save_scene_preset", "Save scene preset", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to capture scene state: %w", err)
 	}
 
@@ -1478,7 +1737,7 @@ func (s *Server) handleSaveScenePreset(ctx context.Context, request *mcpsdk.Call
 
 	id, err := s.storage.CreateScenePreset(ctx, preset)
 	if err != nil {
-		s.recordAction("save_scene_preset", "Save scene preset", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "save_scene_preset", "Save scene preset", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to save preset: %w", err)
 	}
 
@@ -1489,7 +1748,7 @@ func (s *Server) handleSaveScenePreset(ctx context.Context, request *mcpsdk.Call
 		"source_count": len(sources),
 		"message":      fmt.Sprintf("Successfully saved preset '%s' with %d sources", input.PresetName, len(sources)),
 	}
-	s.recordAction("save_scene_preset", "Save scene preset", input, result, true, time.Since(start))
+	s.recordAction(ctx, "save_scene_preset", "Save scene preset", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1501,17 +1760,22 @@ func (s *Server) handleApplyScenePreset(ctx context.Context, request *mcpsdk.Cal
 	start := time.Now()
 	log.Printf("Applying scene preset: %s", input.PresetName)
 
+	if input.DryRun {
+		changes, err := s.planApplyScenePreset(ctx, input)
+		return s.dryRunResult(ctx, "apply_scene_preset", "Apply scene preset", input, changes, err, start)
+	}
+
 	// Load preset from storage
 	preset, err := s.storage.GetScenePreset(ctx, input.PresetName)
 	if err != nil {
-		s.recordAction("apply_scene_preset", "Apply scene preset", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "apply_scene_preset", "Apply scene preset", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to load preset: %w", err)
 	}
 
 	// Get current scene items to map names to IDs
 	scene, err := s.obsClient.GetSceneByName(preset.SceneName)
 	if err != nil {
-		s.recordAction("apply_scene_preset", "Apply scene preset", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "apply_scene_preset", "Apply scene preset", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get scene '%s': %w", preset.SceneName, err)
 	}
 
@@ -1536,10 +1800,39 @@ func (s *Server) handleApplyScenePreset(ctx context.Context, request *mcpsdk.Cal
 		})
 	}
 
-	// Apply preset to OBS
-	if err := s.obsClient.ApplyScenePreset(preset.SceneName, obsStates); err != nil {
-		s.recordAction("apply_scene_preset", "Apply scene preset", input, nil, false, time.Since(start))
-		return nil, nil, fmt.Errorf("failed to apply preset: %w", err)
+	// Apply preset to OBS one source at a time so large scenes report
+	// progress and a cancelled request stops between sources. If it stops
+	// partway, the sources already applied are reported and journalled so
+	// undo can revert them.
+	progress := newProgressReporter(request)
+	for i, state := range obsStates {
+		err := ctx.Err()
+		if err == nil {
+			err = s.obsClient.ApplyScenePreset(preset.SceneName, []obs.SourceState{state})
+		}
+		if err != nil {
+			applied := make([]string, i)
+			for j := range applied {
+				applied[j] = obsStates[j].Name
+			}
+			stopped := "failed at '" + state.Name + "'"
+			if ctx.Err() != nil {
+				stopped = "cancelled"
+			}
+			partial := map[string]interface{}{
+				"preset_name":     input.PresetName,
+				"scene_name":      preset.SceneName,
+				"applied_count":   i,
+				"applied_sources": applied,
+			}
+			s.recordPartialAction(ctx, "apply_scene_preset", "Apply scene preset", input, partial, time.Since(start), captureScenePreset(preset.SceneName, scene, obsStates[:i]))
+			if i == 0 {
+				return nil, nil, fmt.Errorf("apply preset %s before any source was applied: %w", stopped, err)
+			}
+			return nil, nil, fmt.Errorf("apply preset %s after %d of %d sources (applied: %s; undo_last_action reverts them): %w",
+				stopped, i, len(obsStates), strings.Join(applied, ", "), err)
+		}
+		progress.report(ctx, i+1, len(obsStates), fmt.Sprintf("Applied '%s'", state.Name))
 	}
 
 	result := map[string]interface{}{
@@ -1548,7 +1841,7 @@ func (s *Server) handleApplyScenePreset(ctx context.Context, request *mcpsdk.Cal
 		"applied_count": len(obsStates),
 		"message":       fmt.Sprintf("Successfully applied preset '%s' to scene '%s'", input.PresetName, preset.SceneName),
 	}
-	s.recordAction("apply_scene_preset", "Apply scene preset", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "apply_scene_preset", "Apply scene preset", input, result, time.Since(start), captureScenePreset(preset.SceneName, scene, obsStates))
 	return nil, result, nil
 }
 
@@ -1585,14 +1878,14 @@ func (s *Server) handleCreateScreenshotSource(ctx context.Context, request *mcps
 
 	id, err := s.storage.CreateScreenshotSource(ctx, source)
 	if err != nil {
-		s.recordAction("create_screenshot_source", "Create screenshot source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_screenshot_source", "Create screenshot source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create screenshot source: %w", err)
 	}
 
 	// Retrieve the full source with defaults applied
 	createdSource, err := s.storage.GetScreenshotSource(ctx, id)
 	if err != nil {
-		s.recordAction("create_screenshot_source", "Create screenshot source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_screenshot_source", "Create screenshot source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to retrieve created source: %w", err)
 	}
 
@@ -1615,7 +1908,7 @@ func (s *Server) handleCreateScreenshotSource(ctx context.Context, request *mcps
 		"url":          screenshotURL,
 		"message":      fmt.Sprintf("Successfully created screenshot source '%s'. Access at: %s", input.Name, screenshotURL),
 	}
-	s.recordAction("create_screenshot_source", "Create screenshot source", input, result, true, time.Since(start))
+	s.recordAction(ctx, "create_screenshot_source", "Create screenshot source", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1627,7 +1920,7 @@ func (s *Server) handleRemoveScreenshotSource(ctx context.Context, request *mcps
 	// Get the source to find its ID
 	source, err := s.storage.GetScreenshotSourceByName(ctx, input.Name)
 	if err != nil {
-		s.recordAction("remove_screenshot_source", "Remove screenshot source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "remove_screenshot_source", "Remove screenshot source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to find screenshot source: %w", err)
 	}
 
@@ -1638,12 +1931,12 @@ func (s *Server) handleRemoveScreenshotSource(ctx context.Context, request *mcps
 
 	// Delete from storage (cascades to delete screenshots)
 	if err := s.storage.DeleteScreenshotSource(ctx, source.ID); err != nil {
-		s.recordAction("remove_screenshot_source", "Remove screenshot source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "remove_screenshot_source", "Remove screenshot source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to delete screenshot source: %w", err)
 	}
 
 	result := SimpleResult{Message: fmt.Sprintf("Successfully removed screenshot source '%s'", input.Name)}
-	s.recordAction("remove_screenshot_source", "Remove screenshot source", input, result, true, time.Since(start))
+	s.recordAction(ctx, "remove_screenshot_source", "Remove screenshot source", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1654,7 +1947,7 @@ func (s *Server) handleListScreenshotSources(ctx context.Context, request *mcpsd
 
 	sources, err := s.storage.ListScreenshotSources(ctx)
 	if err != nil {
-		s.recordAction("list_screenshot_sources", "List screenshot sources", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_screenshot_sources", "List screenshot sources", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list screenshot sources: %w", err)
 	}
 
@@ -1681,7 +1974,7 @@ func (s *Server) handleListScreenshotSources(ctx context.Context, request *mcpsd
 		"sources": sourceList,
 		"count":   len(sources),
 	}
-	s.recordAction("list_screenshot_sources", "List screenshot sources", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_screenshot_sources", "List screenshot sources", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1691,21 +1984,21 @@ func (s *Server) handleConfigureScreenshotCadence(ctx context.Context, request *
 	log.Printf("Updating cadence for screenshot source '%s' to %dms", input.Name, input.CadenceMs)
 
 	if input.CadenceMs <= 0 {
-		s.recordAction("configure_screenshot_cadence", "Configure screenshot cadence", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "configure_screenshot_cadence", "Configure screenshot cadence", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("cadence_ms must be greater than 0")
 	}
 
 	// Get the source
 	source, err := s.storage.GetScreenshotSourceByName(ctx, input.Name)
 	if err != nil {
-		s.recordAction("configure_screenshot_cadence", "Configure screenshot cadence", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "configure_screenshot_cadence", "Configure screenshot cadence", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to find screenshot source: %w", err)
 	}
 
 	// Update in storage
 	source.CadenceMs = input.CadenceMs
 	if err := s.storage.UpdateScreenshotSource(ctx, *source); err != nil {
-		s.recordAction("configure_screenshot_cadence", "Configure screenshot cadence", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "configure_screenshot_cadence", "Configure screenshot cadence", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to update screenshot source: %w", err)
 	}
 
@@ -1722,18 +2015,16 @@ func (s *Server) handleConfigureScreenshotCadence(ctx context.Context, request *
 		"cadence_ms": input.CadenceMs,
 		"message":    fmt.Sprintf("Successfully updated cadence for '%s' to %dms", input.Name, input.CadenceMs),
 	}
-	s.recordAction("configure_screenshot_cadence", "Configure screenshot cadence", input, result, true, time.Since(start))
+	s.recordAction(ctx, "configure_screenshot_cadence", "Configure screenshot cadence", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
 // Design tool handlers
 
-// handleCreateTextSource creates a text source in a scene
-func (s *Server) handleCreateTextSource(ctx context.Context, request *mcpsdk.CallToolRequest, input CreateTextSourceInput) (*mcpsdk.CallToolResult, any, error) {
-	start := time.Now()
-	log.Printf("Creating text source '%s' in scene '%s'", input.SourceName, input.SceneName)
+// Source settings builders shared by the create handlers and their dry runs
 
-	// Build settings map
+// textSourceSettings builds the OBS settings for a text source
+func textSourceSettings(input CreateTextSourceInput) map[string]interface{} {
 	settings := map[string]interface{}{
 		"text": input.Text,
 	}
@@ -1753,11 +2044,78 @@ func (s *Server) handleCreateTextSource(ctx context.Context, request *mcpsdk.Cal
 	if input.Color != 0 {
 		settings["color"] = input.Color
 	}
+	return settings
+}
+
+// colorSourceSettings builds the OBS settings for a color source, applying
+// the default 1920x1080 size
+func colorSourceSettings(input CreateColorSourceInput) map[string]interface{} {
+	width := input.Width
+	if width <= 0 {
+		width = 1920
+	}
+	height := input.Height
+	if height <= 0 {
+		height = 1080
+	}
+
+	return map[string]interface{}{
+		"color":  input.Color,
+		"width":  width,
+		"height": height,
+	}
+}
+
+// browserSourceSettings builds the OBS settings for a browser source,
+// applying the default 800x600 size and 30 fps
+func browserSourceSettings(input CreateBrowserSourceInput) map[string]interface{} {
+	width := input.Width
+	if width <= 0 {
+		width = 800
+	}
+	height := input.Height
+	if height <= 0 {
+		height = 600
+	}
+	fps := input.FPS
+	if fps <= 0 {
+		fps = 30
+	}
+
+	return map[string]interface{}{
+		"url":    input.URL,
+		"width":  width,
+		"height": height,
+		"fps":    fps,
+	}
+}
+
+// mediaSourceSettings builds the OBS settings for a media source
+func mediaSourceSettings(input CreateMediaSourceInput) map[string]interface{} {
+	return map[string]interface{}{
+		"local_file":   input.FilePath,
+		"looping":      input.Loop,
+		"hw_decode":    true,
+		"clear_on_end": false,
+	}
+}
+
+// handleCreateTextSource creates a text source in a scene
+func (s *Server) handleCreateTextSource(ctx context.Context, request *mcpsdk.CallToolRequest, input CreateTextSourceInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Creating text source '%s' in scene '%s'", input.SourceName, input.SceneName)
+
+	settings := textSourceSettings(input)
+
+	if input.DryRun {
+		changes, err := s.planCreateInput(input.SceneName, input.SourceName, "text_gdiplus_v3", settings)
+		return s.dryRunResult(ctx, "create_text_source", "Create text source", input, changes, err, start)
+	}
 
 	// Create the input using the generic method
 	sceneItemID, err := s.obsClient.CreateInput(input.SceneName, input.SourceName, "text_gdiplus_v3", settings)
 	if err != nil {
-		s.recordAction("create_text_source", "Create text source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_text_source", "Create text source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create text source: %w", err)
 	}
 
@@ -1767,7 +2125,7 @@ func (s *Server) handleCreateTextSource(ctx context.Context, request *mcpsdk.Cal
 		"scene_item_id": sceneItemID,
 		"message":       fmt.Sprintf("Successfully created text source '%s' in scene '%s'", input.SourceName, input.SceneName),
 	}
-	s.recordAction("create_text_source", "Create text source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_text_source", "Create text source", input, result, time.Since(start), undoCreateSceneItem(input.SceneName, sceneItemID))
 	return nil, result, nil
 }
 
@@ -1780,9 +2138,14 @@ func (s *Server) handleCreateImageSource(ctx context.Context, request *mcpsdk.Ca
 		"file": input.FilePath,
 	}
 
+	if input.DryRun {
+		changes, err := s.planCreateInput(input.SceneName, input.SourceName, "image_source", settings)
+		return s.dryRunResult(ctx, "create_image_source", "Create image source", input, changes, err, start)
+	}
+
 	sceneItemID, err := s.obsClient.CreateInput(input.SceneName, input.SourceName, "image_source", settings)
 	if err != nil {
-		s.recordAction("create_image_source", "Create image source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_image_source", "Create image source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create image source: %w", err)
 	}
 
@@ -1793,7 +2156,7 @@ func (s *Server) handleCreateImageSource(ctx context.Context, request *mcpsdk.Ca
 		"file_path":     input.FilePath,
 		"message":       fmt.Sprintf("Successfully created image source '%s' in scene '%s'", input.SourceName, input.SceneName),
 	}
-	s.recordAction("create_image_source", "Create image source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_image_source", "Create image source", input, result, time.Since(start), undoCreateSceneItem(input.SceneName, sceneItemID))
 	return nil, result, nil
 }
 
@@ -1802,25 +2165,16 @@ func (s *Server) handleCreateColorSource(ctx context.Context, request *mcpsdk.Ca
 	start := time.Now()
 	log.Printf("Creating color source '%s' in scene '%s'", input.SourceName, input.SceneName)
 
-	// Set defaults
-	width := input.Width
-	if width <= 0 {
-		width = 1920
-	}
-	height := input.Height
-	if height <= 0 {
-		height = 1080
-	}
+	settings := colorSourceSettings(input)
 
-	settings := map[string]interface{}{
-		"color":  input.Color,
-		"width":  width,
-		"height": height,
+	if input.DryRun {
+		changes, err := s.planCreateInput(input.SceneName, input.SourceName, "color_source_v3", settings)
+		return s.dryRunResult(ctx, "create_color_source", "Create color source", input, changes, err, start)
 	}
 
 	sceneItemID, err := s.obsClient.CreateInput(input.SceneName, input.SourceName, "color_source_v3", settings)
 	if err != nil {
-		s.recordAction("create_color_source", "Create color source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_color_source", "Create color source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create color source: %w", err)
 	}
 
@@ -1828,11 +2182,11 @@ func (s *Server) handleCreateColorSource(ctx context.Context, request *mcpsdk.Ca
 		"scene_name":    input.SceneName,
 		"source_name":   input.SourceName,
 		"scene_item_id": sceneItemID,
-		"width":         width,
-		"height":        height,
+		"width":         settings["width"],
+		"height":        settings["height"],
 		"message":       fmt.Sprintf("Successfully created color source '%s' in scene '%s'", input.SourceName, input.SceneName),
 	}
-	s.recordAction("create_color_source", "Create color source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_color_source", "Create color source", input, result, time.Since(start), undoCreateSceneItem(input.SceneName, sceneItemID))
 	return nil, result, nil
 }
 
@@ -1841,30 +2195,16 @@ func (s *Server) handleCreateBrowserSource(ctx context.Context, request *mcpsdk.
 	start := time.Now()
 	log.Printf("Creating browser source '%s' in scene '%s'", input.SourceName, input.SceneName)
 
-	// Set defaults
-	width := input.Width
-	if width <= 0 {
-		width = 800
-	}
-	height := input.Height
-	if height <= 0 {
-		height = 600
-	}
-	fps := input.FPS
-	if fps <= 0 {
-		fps = 30
-	}
+	settings := browserSourceSettings(input)
 
-	settings := map[string]interface{}{
-		"url":    input.URL,
-		"width":  width,
-		"height": height,
-		"fps":    fps,
+	if input.DryRun {
+		changes, err := s.planCreateInput(input.SceneName, input.SourceName, "browser_source", settings)
+		return s.dryRunResult(ctx, "create_browser_source", "Create browser source", input, changes, err, start)
 	}
 
 	sceneItemID, err := s.obsClient.CreateInput(input.SceneName, input.SourceName, "browser_source", settings)
 	if err != nil {
-		s.recordAction("create_browser_source", "Create browser source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_browser_source", "Create browser source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create browser source: %w", err)
 	}
 
@@ -1873,11 +2213,11 @@ func (s *Server) handleCreateBrowserSource(ctx context.Context, request *mcpsdk.
 		"source_name":   input.SourceName,
 		"scene_item_id": sceneItemID,
 		"url":           input.URL,
-		"width":         width,
-		"height":        height,
+		"width":         settings["width"],
+		"height":        settings["height"],
 		"message":       fmt.Sprintf("Successfully created browser source '%s' in scene '%s'", input.SourceName, input.SceneName),
 	}
-	s.recordAction("create_browser_source", "Create browser source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_browser_source", "Create browser source", input, result, time.Since(start), undoCreateSceneItem(input.SceneName, sceneItemID))
 	return nil, result, nil
 }
 
@@ -1886,16 +2226,16 @@ func (s *Server) handleCreateMediaSource(ctx context.Context, request *mcpsdk.Ca
 	start := time.Now()
 	log.Printf("Creating media source '%s' in scene '%s'", input.SourceName, input.SceneName)
 
-	settings := map[string]interface{}{
-		"local_file":   input.FilePath,
-		"looping":      input.Loop,
-		"hw_decode":    true,
-		"clear_on_end": false,
+	settings := mediaSourceSettings(input)
+
+	if input.DryRun {
+		changes, err := s.planCreateInput(input.SceneName, input.SourceName, "ffmpeg_source", settings)
+		return s.dryRunResult(ctx, "create_media_source", "Create media source", input, changes, err, start)
 	}
 
 	sceneItemID, err := s.obsClient.CreateInput(input.SceneName, input.SourceName, "ffmpeg_source", settings)
 	if err != nil {
-		s.recordAction("create_media_source", "Create media source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_media_source", "Create media source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create media source: %w", err)
 	}
 
@@ -1907,41 +2247,69 @@ func (s *Server) handleCreateMediaSource(ctx context.Context, request *mcpsdk.Ca
 		"loop":          input.Loop,
 		"message":       fmt.Sprintf("Successfully created media source '%s' in scene '%s'", input.SourceName, input.SceneName),
 	}
-	s.recordAction("create_media_source", "Create media source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_media_source", "Create media source", input, result, time.Since(start), undoCreateSceneItem(input.SceneName, sceneItemID))
 	return nil, result, nil
 }
 
+// applySourceTransform applies the provided position, scale, and rotation
+// values to t, leaving omitted values unchanged
+func applySourceTransform(t *obs.SceneItemTransform, input SetSourceTransformInput) {
+	if input.X != nil {
+		t.PositionX = *input.X
+	}
+	if input.Y != nil {
+		t.PositionY = *input.Y
+	}
+	if input.ScaleX != nil {
+		t.ScaleX = *input.ScaleX
+	}
+	if input.ScaleY != nil {
+		t.ScaleY = *input.ScaleY
+	}
+	if input.Rotation != nil {
+		t.Rotation = *input.Rotation
+	}
+}
+
+// applySourceCrop applies the crop values to t
+func applySourceCrop(t *obs.SceneItemTransform, input SetSourceCropInput) {
+	t.CropTop = input.CropTop
+	t.CropBottom = input.CropBottom
+	t.CropLeft = input.CropLeft
+	t.CropRight = input.CropRight
+}
+
+// applySourceBounds applies the bounds type and size to t
+func applySourceBounds(t *obs.SceneItemTransform, input SetSourceBoundsInput) {
+	t.BoundsType = input.BoundsType
+	t.BoundsWidth = input.BoundsWidth
+	t.BoundsHeight = input.BoundsHeight
+}
+
 // handleSetSourceTransform sets the position, scale, and rotation of a source
 func (s *Server) handleSetSourceTransform(ctx context.Context, request *mcpsdk.CallToolRequest, input SetSourceTransformInput) (*mcpsdk.CallToolResult, any, error) {
 	start := time.Now()
 	log.Printf("Setting transform for scene item %d in scene '%s'", input.SceneItemID, input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planTransform(input.SceneName, input.SceneItemID, func(t *obs.SceneItemTransform) {
+			applySourceTransform(t, input)
+		})
+		return s.dryRunResult(ctx, "set_source_transform", "Set source transform", input, changes, err, start)
+	}
+
 	// Get current transform first
 	current, err := s.obsClient.GetSceneItemTransform(input.SceneName, input.SceneItemID)
 	if err != nil {
-		s.recordAction("set_source_transform", "Set source transform", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_transform", "Set source transform", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get current transform: %w", err)
 	}
 
-	// Apply changes only for provided values
-	if input.X != nil {
-		current.PositionX = *input.X
-	}
-	if input.Y != nil {
-		current.PositionY = *input.Y
-	}
-	if input.ScaleX != nil {
-		current.ScaleX = *input.ScaleX
-	}
-	if input.ScaleY != nil {
-		current.ScaleY = *input.ScaleY
-	}
-	if input.Rotation != nil {
-		current.Rotation = *input.Rotation
-	}
+	undo := undoSceneItemTransform(input.SceneName, input.SceneItemID, *current)
+	applySourceTransform(current, input)
 
 	if err := s.obsClient.SetSceneItemTransform(input.SceneName, input.SceneItemID, current); err != nil {
-		s.recordAction("set_source_transform", "Set source transform", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_transform", "Set source transform", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set transform: %w", err)
 	}
 
@@ -1955,7 +2323,7 @@ func (s *Server) handleSetSourceTransform(ctx context.Context, request *mcpsdk.C
 		"rotation":      current.Rotation,
 		"message":       "Successfully updated source transform",
 	}
-	s.recordAction("set_source_transform", "Set source transform", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_source_transform", "Set source transform", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -1966,7 +2334,7 @@ func (s *Server) handleGetSourceTransform(ctx context.Context, request *mcpsdk.C
 
 	transform, err := s.obsClient.GetSceneItemTransform(input.SceneName, input.SceneItemID)
 	if err != nil {
-		s.recordAction("get_source_transform", "Get source transform", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_source_transform", "Get source transform", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get transform: %w", err)
 	}
 
@@ -1990,7 +2358,7 @@ func (s *Server) handleGetSourceTransform(ctx context.Context, request *mcpsdk.C
 		"crop_left":     transform.CropLeft,
 		"crop_right":    transform.CropRight,
 	}
-	s.recordAction("get_source_transform", "Get source transform", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_source_transform", "Get source transform", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -1999,21 +2367,25 @@ func (s *Server) handleSetSourceCrop(ctx context.Context, request *mcpsdk.CallTo
 	start := time.Now()
 	log.Printf("Setting crop for scene item %d in scene '%s'", input.SceneItemID, input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planTransform(input.SceneName, input.SceneItemID, func(t *obs.SceneItemTransform) {
+			applySourceCrop(t, input)
+		})
+		return s.dryRunResult(ctx, "set_source_crop", "Set source crop", input, changes, err, start)
+	}
+
 	// Get current transform
 	current, err := s.obsClient.GetSceneItemTransform(input.SceneName, input.SceneItemID)
 	if err != nil {
-		s.recordAction("set_source_crop", "Set source crop", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_crop", "Set source crop", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get current transform: %w", err)
 	}
 
-	// Apply crop values
-	current.CropTop = input.CropTop
-	current.CropBottom = input.CropBottom
-	current.CropLeft = input.CropLeft
-	current.CropRight = input.CropRight
+	undo := undoSceneItemTransform(input.SceneName, input.SceneItemID, *current)
+	applySourceCrop(current, input)
 
 	if err := s.obsClient.SetSceneItemTransform(input.SceneName, input.SceneItemID, current); err != nil {
-		s.recordAction("set_source_crop", "Set source crop", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_crop", "Set source crop", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set crop: %w", err)
 	}
 
@@ -2026,7 +2398,7 @@ func (s *Server) handleSetSourceCrop(ctx context.Context, request *mcpsdk.CallTo
 		"crop_right":    input.CropRight,
 		"message":       "Successfully updated source crop",
 	}
-	s.recordAction("set_source_crop", "Set source crop", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_source_crop", "Set source crop", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2035,20 +2407,23 @@ func (s *Server) handleSetSourceBounds(ctx context.Context, request *mcpsdk.Call
 	start := time.Now()
 	log.Printf("Setting bounds for scene item %d in scene '%s'", input.SceneItemID, input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planSetSourceBounds(input)
+		return s.dryRunResult(ctx, "set_source_bounds", "Set source bounds", input, changes, err, start)
+	}
+
 	// Get current transform
 	current, err := s.obsClient.GetSceneItemTransform(input.SceneName, input.SceneItemID)
 	if err != nil {
-		s.recordAction("set_source_bounds", "Set source bounds", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_bounds", "Set source bounds", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get current transform: %w", err)
 	}
 
-	// Apply bounds values
-	current.BoundsType = input.BoundsType
-	current.BoundsWidth = input.BoundsWidth
-	current.BoundsHeight = input.BoundsHeight
+	undo := undoSceneItemTransform(input.SceneName, input.SceneItemID, *current)
+	applySourceBounds(current, input)
 
 	if err := s.obsClient.SetSceneItemTransform(input.SceneName, input.SceneItemID, current); err != nil {
-		s.recordAction("set_source_bounds", "Set source bounds", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_bounds", "Set source bounds", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set bounds: %w", err)
 	}
 
@@ -2060,7 +2435,7 @@ func (s *Server) handleSetSourceBounds(ctx context.Context, request *mcpsdk.Call
 		"bounds_height": input.BoundsHeight,
 		"message":       "Successfully updated source bounds",
 	}
-	s.recordAction("set_source_bounds", "Set source bounds", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_source_bounds", "Set source bounds", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2069,8 +2444,14 @@ func (s *Server) handleSetSourceOrder(ctx context.Context, request *mcpsdk.CallT
 	start := time.Now()
 	log.Printf("Setting order for scene item %d in scene '%s' to index %d", input.SceneItemID, input.SceneName, input.Index)
 
+	if input.DryRun {
+		changes, err := s.planSetSourceOrder(input)
+		return s.dryRunResult(ctx, "set_source_order", "Set source order", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureSceneItemIndex(input.SceneName, input.SceneItemID))
 	if err := s.obsClient.SetSceneItemIndex(input.SceneName, input.SceneItemID, input.Index); err != nil {
-		s.recordAction("set_source_order", "Set source order", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_order", "Set source order", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set order: %w", err)
 	}
 
@@ -2080,7 +2461,7 @@ func (s *Server) handleSetSourceOrder(ctx context.Context, request *mcpsdk.CallT
 		"index":         input.Index,
 		"message":       fmt.Sprintf("Successfully set source order to index %d", input.Index),
 	}
-	s.recordAction("set_source_order", "Set source order", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_source_order", "Set source order", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2089,8 +2470,14 @@ func (s *Server) handleSetSourceLocked(ctx context.Context, request *mcpsdk.Call
 	start := time.Now()
 	log.Printf("Setting locked=%v for scene item %d in scene '%s'", input.Locked, input.SceneItemID, input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planSetSourceLocked(input)
+		return s.dryRunResult(ctx, "set_source_locked", "Set source locked", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureSceneItemLocked(input.SceneName, input.SceneItemID))
 	if err := s.obsClient.SetSceneItemLocked(input.SceneName, input.SceneItemID, input.Locked); err != nil {
-		s.recordAction("set_source_locked", "Set source locked", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_locked", "Set source locked", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set locked state: %w", err)
 	}
 
@@ -2105,7 +2492,7 @@ func (s *Server) handleSetSourceLocked(ctx context.Context, request *mcpsdk.Call
 		"locked":        input.Locked,
 		"message":       fmt.Sprintf("Successfully %s source", status),
 	}
-	s.recordAction("set_source_locked", "Set source locked", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_source_locked", "Set source locked", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2118,9 +2505,14 @@ func (s *Server) handleDuplicateSource(ctx context.Context, request *mcpsdk.Call
 	}
 	log.Printf("Duplicating scene item %d from scene '%s' to '%s'", input.SceneItemID, input.SceneName, destScene)
 
+	if input.DryRun {
+		changes, err := s.planDuplicateSource(input, destScene)
+		return s.dryRunResult(ctx, "duplicate_source", "Duplicate source", input, changes, err, start)
+	}
+
 	newItemID, err := s.obsClient.DuplicateSceneItem(input.SceneName, input.SceneItemID, destScene)
 	if err != nil {
-		s.recordAction("duplicate_source", "Duplicate source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "duplicate_source", "Duplicate source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to duplicate source: %w", err)
 	}
 
@@ -2131,7 +2523,7 @@ func (s *Server) handleDuplicateSource(ctx context.Context, request *mcpsdk.Call
 		"new_scene_item_id": newItemID,
 		"message":           fmt.Sprintf("Successfully duplicated source to scene '%s' with item ID %d", destScene, newItemID),
 	}
-	s.recordAction("duplicate_source", "Duplicate source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "duplicate_source", "Duplicate source", input, result, time.Since(start), undoCreateSceneItem(destScene, newItemID))
 	return nil, result, nil
 }
 
@@ -2140,8 +2532,14 @@ func (s *Server) handleRemoveSource(ctx context.Context, request *mcpsdk.CallToo
 	start := time.Now()
 	log.Printf("Removing scene item %d from scene '%s'", input.SceneItemID, input.SceneName)
 
+	if input.DryRun {
+		changes, err := s.planRemoveSource(input)
+		return s.dryRunResult(ctx, "remove_source", "Remove source", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureRemoveSceneItem(input.SceneName, input.SceneItemID))
 	if err := s.obsClient.RemoveSceneItem(input.SceneName, input.SceneItemID); err != nil {
-		s.recordAction("remove_source", "Remove source", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "remove_source", "Remove source", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to remove source: %w", err)
 	}
 
@@ -2150,7 +2548,7 @@ func (s *Server) handleRemoveSource(ctx context.Context, request *mcpsdk.CallToo
 		"scene_item_id": input.SceneItemID,
 		"message":       "Successfully removed source from scene",
 	}
-	s.recordAction("remove_source", "Remove source", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "remove_source", "Remove source", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2161,7 +2559,7 @@ func (s *Server) handleListInputKinds(ctx context.Context, request *mcpsdk.CallT
 
 	kinds, err := s.obsClient.GetInputKindList()
 	if err != nil {
-		s.recordAction("list_input_kinds", "List input kinds", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_input_kinds", "List input kinds", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list input kinds: %w", err)
 	}
 
@@ -2169,7 +2567,7 @@ func (s *Server) handleListInputKinds(ctx context.Context, request *mcpsdk.CallT
 		"input_kinds": kinds,
 		"count":       len(kinds),
 	}
-	s.recordAction("list_input_kinds", "List input kinds", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_input_kinds", "List input kinds", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2184,7 +2582,7 @@ func (s *Server) handleListSourceFilters(ctx context.Context, request *mcpsdk.Ca
 
 	filters, err := s.obsClient.GetSourceFilterList(input.SourceName)
 	if err != nil {
-		s.recordAction("list_source_filters", "List source filters", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_source_filters", "List source filters", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list filters: %w", err)
 	}
 
@@ -2193,7 +2591,7 @@ func (s *Server) handleListSourceFilters(ctx context.Context, request *mcpsdk.Ca
 		"filters":     filters,
 		"count":       len(filters),
 	}
-	s.recordAction("list_source_filters", "List source filters", input, result, true, time.Since(start))
+	s.recordAction(ctx, "list_source_filters", "List source filters", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2204,7 +2602,7 @@ func (s *Server) handleGetSourceFilter(ctx context.Context, request *mcpsdk.Call
 
 	filter, err := s.obsClient.GetSourceFilter(input.SourceName, input.FilterName)
 	if err != nil {
-		s.recordAction("get_source_filter", "Get source filter", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_source_filter", "Get source filter", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get filter: %w", err)
 	}
 
@@ -2212,7 +2610,7 @@ func (s *Server) handleGetSourceFilter(ctx context.Context, request *mcpsdk.Call
 		"source_name": input.SourceName,
 		"filter":      filter,
 	}
-	s.recordAction("get_source_filter", "Get source filter", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_source_filter", "Get source filter", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2221,8 +2619,13 @@ func (s *Server) handleCreateSourceFilter(ctx context.Context, request *mcpsdk.C
 	start := time.Now()
 	log.Printf("Creating filter '%s' of type '%s' on source '%s'", input.FilterName, input.FilterKind, input.SourceName)
 
+	if input.DryRun {
+		changes, err := s.planCreateSourceFilter(input)
+		return s.dryRunResult(ctx, "create_source_filter", "Create source filter", input, changes, err, start)
+	}
+
 	if err := s.obsClient.CreateSourceFilter(input.SourceName, input.FilterName, input.FilterKind, input.FilterSettings); err != nil {
-		s.recordAction("create_source_filter", "Create source filter", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "create_source_filter", "Create source filter", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to create filter: %w", err)
 	}
 
@@ -2232,7 +2635,7 @@ func (s *Server) handleCreateSourceFilter(ctx context.Context, request *mcpsdk.C
 		"filter_kind": input.FilterKind,
 		"message":     fmt.Sprintf("Successfully created filter '%s' on source '%s'", input.FilterName, input.SourceName),
 	}
-	s.recordAction("create_source_filter", "Create source filter", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "create_source_filter", "Create source filter", input, result, time.Since(start), undoCreateSourceFilter(input.SourceName, input.FilterName))
 	return nil, result, nil
 }
 
@@ -2241,6 +2644,11 @@ func (s *Server) handleRemoveSourceFilter(ctx context.Context, request *mcpsdk.C
 	start := time.Now()
 	log.Printf("Removing filter '%s' from source '%s' - requesting confirmation", input.FilterName, input.SourceName)
 
+	if input.DryRun {
+		changes, err := s.planRemoveSourceFilter(input)
+		return s.dryRunResult(ctx, "remove_source_filter", "Remove source filter", input, changes, err, start)
+	}
+
 	// Request user confirmation before removing filter
 	confirmed, err := ElicitFilterRemovalConfirmation(ctx, getSession(request), input.SourceName, input.FilterName)
 	if err != nil {
@@ -2248,12 +2656,13 @@ func (s *Server) handleRemoveSourceFilter(ctx context.Context, request *mcpsdk.C
 		// Continue without confirmation if elicitation fails
 	} else if !confirmed {
 		result := CancelledResult("Filter removal")
-		s.recordAction("remove_source_filter", "Remove source filter (cancelled)", input, result, false, time.Since(start))
+		s.recordAction(ctx, "remove_source_filter", "Remove source filter (cancelled)", input, result, false, time.Since(start))
 		return nil, result, nil
 	}
 
+	undo := s.undoOps(s.captureRemoveSourceFilter(input.SourceName, input.FilterName))
 	if err := s.obsClient.RemoveSourceFilter(input.SourceName, input.FilterName); err != nil {
-		s.recordAction("remove_source_filter", "Remove source filter", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "remove_source_filter", "Remove source filter", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to remove filter: %w", err)
 	}
 
@@ -2262,7 +2671,7 @@ func (s *Server) handleRemoveSourceFilter(ctx context.Context, request *mcpsdk.C
 		"filter_name": input.FilterName,
 		"message":     fmt.Sprintf("Successfully removed filter '%s' from source '%s'", input.FilterName, input.SourceName),
 	}
-	s.recordAction("remove_source_filter", "Remove source filter", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "remove_source_filter", "Remove source filter", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2271,6 +2680,11 @@ func (s *Server) handleToggleSourceFilter(ctx context.Context, request *mcpsdk.C
 	start := time.Now()
 	log.Printf("Toggling filter '%s' on source '%s'", input.FilterName, input.SourceName)
 
+	if input.DryRun {
+		changes, err := s.planToggleSourceFilter(input)
+		return s.dryRunResult(ctx, "toggle_source_filter", "Toggle source filter", input, changes, err, start)
+	}
+
 	var enabled bool
 	if input.FilterEnabled != nil {
 		// Explicit enable/disable
@@ -2279,14 +2693,15 @@ func (s *Server) handleToggleSourceFilter(ctx context.Context, request *mcpsdk.C
 		// Toggle: get current state and flip it
 		filter, err := s.obsClient.GetSourceFilter(input.SourceName, input.FilterName)
 		if err != nil {
-			s.recordAction("toggle_source_filter", "Toggle source filter", input, nil, false, time.Since(start))
+			s.recordAction(ctx, "toggle_source_filter", "Toggle source filter", input, nil, false, time.Since(start))
 			return nil, nil, fmt.Errorf("failed to get filter state: %w", err)
 		}
 		enabled = !filter.Enabled
 	}
 
+	undo := s.undoOps(s.captureSourceFilterEnabled(input.SourceName, input.FilterName))
 	if err := s.obsClient.SetSourceFilterEnabled(input.SourceName, input.FilterName, enabled); err != nil {
-		s.recordAction("toggle_source_filter", "Toggle source filter", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "toggle_source_filter", "Toggle source filter", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to toggle filter: %w", err)
 	}
 
@@ -2301,7 +2716,7 @@ func (s *Server) handleToggleSourceFilter(ctx context.Context, request *mcpsdk.C
 		"filter_enabled": enabled,
 		"message":        fmt.Sprintf("Filter '%s' is now %s", input.FilterName, status),
 	}
-	s.recordAction("toggle_source_filter", "Toggle source filter", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "toggle_source_filter", "Toggle source filter", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2310,14 +2725,20 @@ func (s *Server) handleSetSourceFilterSettings(ctx context.Context, request *mcp
 	start := time.Now()
 	log.Printf("Setting filter settings for '%s' on source '%s'", input.FilterName, input.SourceName)
 
+	if input.DryRun {
+		changes, err := s.planSetSourceFilterSettings(input)
+		return s.dryRunResult(ctx, "set_source_filter_settings", "Set source filter settings", input, changes, err, start)
+	}
+
 	// Default to overlay mode (merge settings)
 	overlay := true
 	if !input.Overlay {
 		overlay = false
 	}
 
+	undo := s.undoOps(s.captureSourceFilterSettings(input.SourceName, input.FilterName))
 	if err := s.obsClient.SetSourceFilterSettings(input.SourceName, input.FilterName, input.FilterSettings, overlay); err != nil {
-		s.recordAction("set_source_filter_settings", "Set source filter settings", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_source_filter_settings", "Set source filter settings", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set filter settings: %w", err)
 	}
 
@@ -2332,7 +2753,7 @@ func (s *Server) handleSetSourceFilterSettings(ctx context.Context, request *mcp
 		"overlay":     overlay,
 		"message":     fmt.Sprintf("Settings %s existing settings for filter '%s'", mode, input.FilterName),
 	}
-	s.recordAction("set_source_filter_settings", "Set source filter settings", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_source_filter_settings", "Set source filter settings", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2343,7 +2764,7 @@ func (s *Server) handleListFilterKinds(ctx context.Context, request *mcpsdk.Call
 
 	kinds, err := s.obsClient.GetSourceFilterKindList()
 	if err != nil {
-		s.recordAction("list_filter_kinds", "List filter kinds", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_filter_kinds", "List filter kinds", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list filter kinds: %w", err)
 	}
 
@@ -2351,7 +2772,7 @@ func (s *Server) handleListFilterKinds(ctx context.Context, request *mcpsdk.Call
 		"filter_kinds": kinds,
 		"count":        len(kinds),
 	}
-	s.recordAction("list_filter_kinds", "List filter kinds", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_filter_kinds", "List filter kinds", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2366,7 +2787,7 @@ func (s *Server) handleListTransitions(ctx context.Context, request *mcpsdk.Call
 
 	transitions, currentName, err := s.obsClient.GetSceneTransitionList()
 	if err != nil {
-		s.recordAction("list_transitions", "List transitions", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_transitions", "List transitions", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list transitions: %w", err)
 	}
 
@@ -2375,7 +2796,7 @@ func (s *Server) handleListTransitions(ctx context.Context, request *mcpsdk.Call
 		"current_transition": currentName,
 		"count":              len(transitions),
 	}
-	s.recordAction("list_transitions", "List transitions", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_transitions", "List transitions", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2386,7 +2807,7 @@ func (s *Server) handleGetCurrentTransition(ctx context.Context, request *mcpsdk
 
 	transition, err := s.obsClient.GetCurrentSceneTransition()
 	if err != nil {
-		s.recordAction("get_current_transition", "Get current transition", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_current_transition", "Get current transition", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get current transition: %w", err)
 	}
 
@@ -2397,7 +2818,7 @@ func (s *Server) handleGetCurrentTransition(ctx context.Context, request *mcpsdk
 		"configurable": transition.Configurable,
 		"settings":     transition.Settings,
 	}
-	s.recordAction("get_current_transition", "Get current transition", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "get_current_transition", "Get current transition", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2406,8 +2827,14 @@ func (s *Server) handleSetCurrentTransition(ctx context.Context, request *mcpsdk
 	start := time.Now()
 	log.Printf("Setting current transition to: %s", input.TransitionName)
 
+	if input.DryRun {
+		changes, err := s.planSetCurrentTransition(input)
+		return s.dryRunResult(ctx, "set_current_transition", "Set current transition", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureCurrentTransition())
 	if err := s.obsClient.SetCurrentSceneTransition(input.TransitionName); err != nil {
-		s.recordAction("set_current_transition", "Set current transition", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_current_transition", "Set current transition", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set current transition: %w", err)
 	}
 
@@ -2415,7 +2842,7 @@ func (s *Server) handleSetCurrentTransition(ctx context.Context, request *mcpsdk
 		"transition_name": input.TransitionName,
 		"message":         fmt.Sprintf("Successfully set transition to '%s'", input.TransitionName),
 	}
-	s.recordAction("set_current_transition", "Set current transition", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_current_transition", "Set current transition", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2424,13 +2851,19 @@ func (s *Server) handleSetTransitionDuration(ctx context.Context, request *mcpsd
 	start := time.Now()
 	log.Printf("Setting transition duration to: %dms", input.TransitionDuration)
 
+	if input.DryRun {
+		changes, err := s.planSetTransitionDuration(input)
+		return s.dryRunResult(ctx, "set_transition_duration", "Set transition duration", input, changes, err, start)
+	}
+
 	if input.TransitionDuration <= 0 {
-		s.recordAction("set_transition_duration", "Set transition duration", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_transition_duration", "Set transition duration", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("transition_duration must be greater than 0")
 	}
 
+	undo := s.undoOps(s.captureTransitionDuration())
 	if err := s.obsClient.SetCurrentSceneTransitionDuration(input.TransitionDuration); err != nil {
-		s.recordAction("set_transition_duration", "Set transition duration", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_transition_duration", "Set transition duration", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set transition duration: %w", err)
 	}
 
@@ -2438,22 +2871,28 @@ func (s *Server) handleSetTransitionDuration(ctx context.Context, request *mcpsd
 		"duration_ms": input.TransitionDuration,
 		"message":     fmt.Sprintf("Successfully set transition duration to %dms", input.TransitionDuration),
 	}
-	s.recordAction("set_transition_duration", "Set transition duration", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_transition_duration", "Set transition duration", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
 // handleTriggerTransition triggers the current transition in studio mode
-func (s *Server) handleTriggerTransition(ctx context.Context, request *mcpsdk.CallToolRequest, input struct{}) (*mcpsdk.CallToolResult, any, error) {
+func (s *Server) handleTriggerTransition(ctx context.Context, request *mcpsdk.CallToolRequest, input TriggerTransitionInput) (*mcpsdk.CallToolResult, any, error) {
 	start := time.Now()
 	log.Println("Triggering studio mode transition")
 
+	if input.DryRun {
+		changes, err := s.planTriggerTransition()
+		return s.dryRunResult(ctx, "trigger_transition", "Trigger transition", input, changes, err, start)
+	}
+
+	undo := s.undoOps(s.captureProgramAndPreview())
 	if err := s.obsClient.TriggerStudioModeTransition(); err != nil {
-		s.recordAction("trigger_transition", "Trigger transition", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "trigger_transition", "Trigger transition", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to trigger transition: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully triggered studio mode transition"}
-	s.recordAction("trigger_transition", "Trigger transition", nil, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "trigger_transition", "Trigger transition", nil, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2468,7 +2907,7 @@ func (s *Server) handleGetVirtualCamStatus(ctx context.Context, request *mcpsdk.
 
 	status, err := s.obsClient.GetVirtualCamStatus()
 	if err != nil {
-		s.recordAction("get_virtual_cam_status", "Get virtual camera status", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_virtual_cam_status", "Get virtual camera status", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get virtual camera status: %w", err)
 	}
 
@@ -2476,7 +2915,7 @@ func (s *Server) handleGetVirtualCamStatus(ctx context.Context, request *mcpsdk.
 		"active":  status.Active,
 		"message": fmt.Sprintf("Virtual camera is %s", map[bool]string{true: "active", false: "inactive"}[status.Active]),
 	}
-	s.recordAction("get_virtual_cam_status", "Get virtual camera status", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "get_virtual_cam_status", "Get virtual camera status", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2487,7 +2926,7 @@ func (s *Server) handleToggleVirtualCam(ctx context.Context, request *mcpsdk.Cal
 
 	active, err := s.obsClient.ToggleVirtualCam()
 	if err != nil {
-		s.recordAction("toggle_virtual_cam", "Toggle virtual camera", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "toggle_virtual_cam", "Toggle virtual camera", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to toggle virtual camera: %w", err)
 	}
 
@@ -2495,7 +2934,7 @@ func (s *Server) handleToggleVirtualCam(ctx context.Context, request *mcpsdk.Cal
 		"active":  active,
 		"message": fmt.Sprintf("Virtual camera is now %s", map[bool]string{true: "active", false: "inactive"}[active]),
 	}
-	s.recordAction("toggle_virtual_cam", "Toggle virtual camera", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "toggle_virtual_cam", "Toggle virtual camera", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2506,7 +2945,7 @@ func (s *Server) handleGetReplayBufferStatus(ctx context.Context, request *mcpsd
 
 	status, err := s.obsClient.GetReplayBufferStatus()
 	if err != nil {
-		s.recordAction("get_replay_buffer_status", "Get replay buffer status", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_replay_buffer_status", "Get replay buffer status", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get replay buffer status: %w", err)
 	}
 
@@ -2514,7 +2953,7 @@ func (s *Server) handleGetReplayBufferStatus(ctx context.Context, request *mcpsd
 		"active":  status.Active,
 		"message": fmt.Sprintf("Replay buffer is %s", map[bool]string{true: "active", false: "inactive"}[status.Active]),
 	}
-	s.recordAction("get_replay_buffer_status", "Get replay buffer status", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "get_replay_buffer_status", "Get replay buffer status", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2525,7 +2964,7 @@ func (s *Server) handleToggleReplayBuffer(ctx context.Context, request *mcpsdk.C
 
 	active, err := s.obsClient.ToggleReplayBuffer()
 	if err != nil {
-		s.recordAction("toggle_replay_buffer", "Toggle replay buffer", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "toggle_replay_buffer", "Toggle replay buffer", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to toggle replay buffer: %w", err)
 	}
 
@@ -2533,7 +2972,7 @@ func (s *Server) handleToggleReplayBuffer(ctx context.Context, request *mcpsdk.C
 		"active":  active,
 		"message": fmt.Sprintf("Replay buffer is now %s", map[bool]string{true: "active", false: "inactive"}[active]),
 	}
-	s.recordAction("toggle_replay_buffer", "Toggle replay buffer", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "toggle_replay_buffer", "Toggle replay buffer", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2543,12 +2982,12 @@ func (s *Server) handleSaveReplayBuffer(ctx context.Context, request *mcpsdk.Cal
 	log.Println("Saving replay buffer")
 
 	if err := s.obsClient.SaveReplayBuffer(); err != nil {
-		s.recordAction("save_replay_buffer", "Save replay buffer", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "save_replay_buffer", "Save replay buffer", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to save replay buffer: %w", err)
 	}
 
 	result := SimpleResult{Message: "Successfully saved replay buffer"}
-	s.recordAction("save_replay_buffer", "Save replay buffer", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "save_replay_buffer", "Save replay buffer", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2559,7 +2998,7 @@ func (s *Server) handleGetLastReplay(ctx context.Context, request *mcpsdk.CallTo
 
 	path, err := s.obsClient.GetLastReplayBufferReplay()
 	if err != nil {
-		s.recordAction("get_last_replay", "Get last replay", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_last_replay", "Get last replay", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get last replay path: %w", err)
 	}
 
@@ -2567,7 +3006,7 @@ func (s *Server) handleGetLastReplay(ctx context.Context, request *mcpsdk.CallTo
 		"saved_replay_path": path,
 		"message":           fmt.Sprintf("Last replay saved to: %s", path),
 	}
-	s.recordAction("get_last_replay", "Get last replay", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "get_last_replay", "Get last replay", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2582,7 +3021,7 @@ func (s *Server) handleGetStudioModeEnabled(ctx context.Context, request *mcpsdk
 
 	enabled, err := s.obsClient.GetStudioModeEnabled()
 	if err != nil {
-		s.recordAction("get_studio_mode_enabled", "Get studio mode status", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_studio_mode_enabled", "Get studio mode status", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get studio mode status: %w", err)
 	}
 
@@ -2590,7 +3029,7 @@ func (s *Server) handleGetStudioModeEnabled(ctx context.Context, request *mcpsdk
 		"studio_mode_enabled": enabled,
 		"message":             fmt.Sprintf("Studio mode is %s", map[bool]string{true: "enabled", false: "disabled"}[enabled]),
 	}
-	s.recordAction("get_studio_mode_enabled", "Get studio mode status", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "get_studio_mode_enabled", "Get studio mode status", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2599,8 +3038,9 @@ func (s *Server) handleToggleStudioMode(ctx context.Context, request *mcpsdk.Cal
 	start := time.Now()
 	log.Printf("Setting studio mode enabled=%v", input.StudioModeEnabled)
 
+	undo := s.undoOps(s.captureStudioMode())
 	if err := s.obsClient.SetStudioModeEnabled(input.StudioModeEnabled); err != nil {
-		s.recordAction("toggle_studio_mode", "Toggle studio mode", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "toggle_studio_mode", "Toggle studio mode", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set studio mode: %w", err)
 	}
 
@@ -2608,7 +3048,7 @@ func (s *Server) handleToggleStudioMode(ctx context.Context, request *mcpsdk.Cal
 		"studio_mode_enabled": input.StudioModeEnabled,
 		"message":             fmt.Sprintf("Studio mode is now %s", map[bool]string{true: "enabled", false: "disabled"}[input.StudioModeEnabled]),
 	}
-	s.recordAction("toggle_studio_mode", "Toggle studio mode", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "toggle_studio_mode", "Toggle studio mode", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2619,7 +3059,7 @@ func (s *Server) handleGetPreviewScene(ctx context.Context, request *mcpsdk.Call
 
 	sceneName, err := s.obsClient.GetCurrentPreviewScene()
 	if err != nil {
-		s.recordAction("get_preview_scene", "Get preview scene", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "get_preview_scene", "Get preview scene", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to get preview scene: %w", err)
 	}
 
@@ -2627,7 +3067,7 @@ func (s *Server) handleGetPreviewScene(ctx context.Context, request *mcpsdk.Call
 		"preview_scene": sceneName,
 		"message":       fmt.Sprintf("Current preview scene: %s", sceneName),
 	}
-	s.recordAction("get_preview_scene", "Get preview scene", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "get_preview_scene", "Get preview scene", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2636,8 +3076,9 @@ func (s *Server) handleSetPreviewScene(ctx context.Context, request *mcpsdk.Call
 	start := time.Now()
 	log.Printf("Setting preview scene to: %s", input.SceneName)
 
+	undo := s.undoOps(s.capturePreviewScene())
 	if err := s.obsClient.SetCurrentPreviewScene(input.SceneName); err != nil {
-		s.recordAction("set_preview_scene", "Set preview scene", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "set_preview_scene", "Set preview scene", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to set preview scene: %w", err)
 	}
 
@@ -2645,7 +3086,7 @@ func (s *Server) handleSetPreviewScene(ctx context.Context, request *mcpsdk.Call
 		"preview_scene": input.SceneName,
 		"message":       fmt.Sprintf("Preview scene set to: %s", input.SceneName),
 	}
-	s.recordAction("set_preview_scene", "Set preview scene", input, result, true, time.Since(start))
+	s.recordUndoableAction(ctx, "set_preview_scene", "Set preview scene", input, result, time.Since(start), undo)
 	return nil, result, nil
 }
 
@@ -2659,7 +3100,7 @@ func (s *Server) handleTriggerHotkeyByName(ctx context.Context, request *mcpsdk.
 	log.Printf("Triggering hotkey: %s", input.HotkeyName)
 
 	if err := s.obsClient.TriggerHotkeyByName(input.HotkeyName); err != nil {
-		s.recordAction("trigger_hotkey_by_name", "Trigger hotkey", input, nil, false, time.Since(start))
+		s.recordAction(ctx, "trigger_hotkey_by_name", "Trigger hotkey", input, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to trigger hotkey: %w", err)
 	}
 
@@ -2667,7 +3108,7 @@ func (s *Server) handleTriggerHotkeyByName(ctx context.Context, request *mcpsdk.
 		"hotkey_name": input.HotkeyName,
 		"message":     fmt.Sprintf("Successfully triggered hotkey: %s", input.HotkeyName),
 	}
-	s.recordAction("trigger_hotkey_by_name", "Trigger hotkey", input, result, true, time.Since(start))
+	s.recordAction(ctx, "trigger_hotkey_by_name", "Trigger hotkey", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -2678,7 +3119,7 @@ func (s *Server) handleListHotkeys(ctx context.Context, request *mcpsdk.CallTool
 
 	hotkeys, err := s.obsClient.GetHotkeyList()
 	if err != nil {
-		s.recordAction("list_hotkeys", "List hotkeys", nil, nil, false, time.Since(start))
+		s.recordAction(ctx, "list_hotkeys", "List hotkeys", nil, nil, false, time.Since(start))
 		return nil, nil, fmt.Errorf("failed to list hotkeys: %w", err)
 	}
 
@@ -2687,6 +3128,6 @@ func (s *Server) handleListHotkeys(ctx context.Context, request *mcpsdk.CallTool
 		"count":   len(hotkeys),
 		"message": fmt.Sprintf("Found %d available hotkeys", len(hotkeys)),
 	}
-	s.recordAction("list_hotkeys", "List hotkeys", nil, result, true, time.Since(start))
+	s.recordAction(ctx, "list_hotkeys", "List hotkeys", nil, result, true, time.Since(start))
 	return nil, result, nil
 }
@@ -1,9 +1,12 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/ironystock/agentic-obs/internal/automation"
@@ -27,12 +30,15 @@ type GetAutomationRuleInput struct {
 type CreateAutomationRuleInput struct {
 	Name          string                   `json:"name" jsonschema:"Unique name for the rule"`
 	Description   string                   `json:"description,omitempty" jsonschema:"Description of what the rule does"`
-	TriggerType   string                   `json:"trigger_type" jsonschema:"Trigger type: 'event', 'schedule', or 'manual'"`
-	TriggerConfig map[string]interface{}   `json:"trigger_config" jsonschema:"Trigger configuration (event_type+event_filter for event, schedule for schedule)"`
-	Actions       []map[string]interface{} `json:"actions" jsonschema:"List of actions to execute (type, parameters, on_error)"`
+	TriggerType   string                   `json:"trigger_type" jsonschema:"Trigger type: 'event', 'schedule', 'manual', 'webhook', or 'composite'"`
+	TriggerConfig map[string]interface{}   `json:"trigger_config" jsonschema:"Trigger configuration (event_type+event_filter for event; schedule (5-field cron), at (one-shot time) or after_event+delay_seconds for schedule, each with optional timezone (IANA name), active_windows and blackout_windows ([{days, start, end}] with HH:MM times); secret+optional body_mapping and event_filter for webhook; mode (all, any, sequence or count), events ([{event_type, event_filter}]), window_seconds, count, hold_seconds or debounce_seconds for composite). event_filter values match by equality or take operator objects: eq, ne, in, not_in, regex, prefix, contains, gt, lt"`
+	Actions       []map[string]interface{} `json:"actions" jsonschema:"List of actions to execute (type, parameters, on_error, on_missing, retry, timeout_ms). String parameters may contain {{event.*}}, {{rule.*}}, {{obs.*}} and {{var.*}} placeholders. An 'if' action takes a condition and then/else action lists. A 'parallel' action runs its 'branches' (a list of action lists) at once. A 'repeat' action runs its 'actions' list 'count' times or until its 'until' condition holds. A 'run_macro' action runs the macro named by its 'macro' parameter with its 'args' object. retry is {max_attempts, backoff_ms, max_backoff_ms, jitter}; timed-out attempts are not retried and http_request uses its own 'retries' parameter instead. on_error 'goto' runs the action's 'fallback' list when it fails"`
+	Condition     string                   `json:"condition,omitempty" jsonschema:"Expression that must be true for the rule to run, e.g. obs.streaming && event.scene_name != 'BRB'"`
+	Concurrency   string                   `json:"concurrency,omitempty" jsonschema:"What to do when the rule is triggered while it is still running: 'parallel' (default), 'skip_if_running', 'queue', or 'restart'"`
 	CooldownMs    int                      `json:"cooldown_ms,omitempty" jsonschema:"Minimum time between rule executions in milliseconds (default: 0)"`
 	Priority      int                      `json:"priority,omitempty" jsonschema:"Higher priority rules execute first (default: 0)"`
 	Enabled       *bool                    `json:"enabled,omitempty" jsonschema:"Whether the rule is enabled (default: true)"`
+	DryRun        bool                     `json:"dry_run,omitempty" jsonschema:"Validate the rule and return the planned changes without saving them"`
 }
 
 // UpdateAutomationRuleInput is the input for updating an automation rule.
@@ -43,23 +49,29 @@ type UpdateAutomationRuleInput struct {
 	TriggerType   string                   `json:"trigger_type,omitempty" jsonschema:"New trigger type"`
 	TriggerConfig map[string]interface{}   `json:"trigger_config,omitempty" jsonschema:"New trigger configuration"`
 	Actions       []map[string]interface{} `json:"actions,omitempty" jsonschema:"New list of actions"`
+	Condition     *string                  `json:"condition,omitempty" jsonschema:"New condition expression (empty string removes the condition)"`
+	Concurrency   *string                  `json:"concurrency,omitempty" jsonschema:"New concurrency policy: 'parallel', 'skip_if_running', 'queue', or 'restart'"`
 	CooldownMs    *int                     `json:"cooldown_ms,omitempty" jsonschema:"New cooldown in milliseconds"`
 	Priority      *int                     `json:"priority,omitempty" jsonschema:"New priority value"`
+	DryRun        bool                     `json:"dry_run,omitempty" jsonschema:"Validate the rule and return the planned changes without saving them"`
 }
 
 // DeleteAutomationRuleInput is the input for deleting an automation rule.
 type DeleteAutomationRuleInput struct {
-	Name string `json:"name" jsonschema:"Name of the rule to delete"`
+	Name   string `json:"name" jsonschema:"Name of the rule to delete"`
+	DryRun bool   `json:"dry_run,omitempty" jsonschema:"Validate the rule and return the planned changes without saving them"`
 }
 
 // EnableAutomationRuleInput is the input for enabling/disabling an automation rule.
 type EnableAutomationRuleInput struct {
-	Name string `json:"name" jsonschema:"Name of the rule to enable"`
+	Name   string `json:"name" jsonschema:"Name of the rule to enable"`
+	DryRun bool   `json:"dry_run,omitempty" jsonschema:"Validate the rule and return the planned changes without saving them"`
 }
 
 // TriggerAutomationRuleInput is the input for manually triggering an automation rule.
 type TriggerAutomationRuleInput struct {
 	Name string `json:"name" jsonschema:"Name of the rule to trigger"`
+	Wait bool   `json:"wait,omitempty" jsonschema:"Wait for the rule to finish and return its execution status (implied when the request carries a progress token)"`
 }
 
 // ListRuleExecutionsInput is the input for listing rule execution history.
@@ -68,6 +80,28 @@ type ListRuleExecutionsInput struct {
 	Limit    int    `json:"limit,omitempty" jsonschema:"Maximum number of executions to return (default: 20, max: 100)"`
 }
 
+// ListRunningExecutionsInput is the input for listing executions in progress.
+type ListRunningExecutionsInput struct {
+	RuleName string `json:"rule_name,omitempty" jsonschema:"Filter by rule name (optional)"`
+}
+
+// CancelRuleExecutionInput is the input for cancelling a running execution.
+type CancelRuleExecutionInput struct {
+	ExecutionID int64 `json:"execution_id" jsonschema:"ID of the running execution, from list_running_executions"`
+}
+
+// GetNextRunsInput is the input for previewing a schedule rule's fire times.
+type GetNextRunsInput struct {
+	Name  string `json:"name" jsonschema:"Name of the schedule rule"`
+	Count int    `json:"count,omitempty" jsonschema:"Number of fire times to return (default: 5, max: 50)"`
+}
+
+// SimulateAutomationEventInput is the input for simulating an event against the rules.
+type SimulateAutomationEventInput struct {
+	EventType string                 `json:"event_type" jsonschema:"Event type to simulate, e.g. scene_changed"`
+	Data      map[string]interface{} `json:"data,omitempty" jsonschema:"Event data as the real event would carry it, e.g. {\"scene_name\": \"Gaming\"}"`
+}
+
 // handleListAutomationRules lists all automation rules.
 func (s *Server) handleListAutomationRules(ctx context.Context, request *mcpsdk.CallToolRequest, input ListAutomationRulesInput) (*mcpsdk.CallToolResult, any, error) {
 	start := time.Now()
@@ -103,7 +137,7 @@ func (s *Server) handleListAutomationRules(ctx context.Context, request *mcpsdk.
 		"message": fmt.Sprintf("Found %d automation rules", len(rules)),
 	}
 
-	s.recordAction("list_automation_rules", "List automation rules", input, result, true, time.Since(start))
+	s.recordAction(ctx, "list_automation_rules", "List automation rules", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -117,58 +151,116 @@ func (s *Server) handleGetAutomationRule(ctx context.Context, request *mcpsdk.Ca
 		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
 	}
 
-	// Convert actions to response format
-	actions := make([]map[string]interface{}, len(rule.Actions))
-	for i, action := range rule.Actions {
-		actions[i] = map[string]interface{}{
-			"type":       action.Type,
-			"parameters": action.Parameters,
-			"on_error":   action.OnError,
-		}
-	}
-
 	result := map[string]interface{}{
 		"id":             rule.ID,
 		"name":           rule.Name,
 		"description":    rule.Description,
 		"enabled":        rule.Enabled,
 		"trigger_type":   rule.TriggerType,
-		"trigger_config": rule.TriggerConfig,
-		"actions":        actions,
+		"trigger_config": redactTriggerConfig(rule.TriggerConfig),
+		"actions":        ruleActionMaps(rule.Actions),
 		"cooldown_ms":    rule.CooldownMs,
 		"priority":       rule.Priority,
+		"revision":       rule.Revision,
 		"run_count":      rule.RunCount,
 		"created_at":     rule.CreatedAt.Format(time.RFC3339),
 		"updated_at":     rule.UpdatedAt.Format(time.RFC3339),
 	}
+	if rule.Condition != "" {
+		result["condition"] = rule.Condition
+	}
+	if rule.Concurrency != "" {
+		result["concurrency"] = rule.Concurrency
+	}
 	if rule.LastRun != nil {
 		result["last_run"] = rule.LastRun.Format(time.RFC3339)
 	}
 
-	s.recordAction("get_automation_rule", "Get automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "get_automation_rule", "Get automation rule", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
-// handleCreateAutomationRule creates a new automation rule.
-func (s *Server) handleCreateAutomationRule(ctx context.Context, request *mcpsdk.CallToolRequest, input CreateAutomationRuleInput) (*mcpsdk.CallToolResult, any, error) {
-	start := time.Now()
-	log.Printf("Creating automation rule: %s", input.Name)
+// redactTriggerConfig returns config with a webhook secret masked, so it is
+// never echoed back to clients or stored in action history.
+func redactTriggerConfig(config map[string]interface{}) map[string]interface{} {
+	if _, ok := config["secret"]; !ok {
+		return config
+	}
+	out := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		out[k] = v
+	}
+	out["secret"] = "********"
+	return out
+}
+
+// ruleActionMaps converts actions to response format, including nested
+// action lists.
+func ruleActionMaps(actions []storage.RuleAction) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(actions))
+	for i, action := range actions {
+		out[i] = map[string]interface{}{
+			"type":       action.Type,
+			"parameters": action.Parameters,
+			"on_error":   action.OnError,
+		}
+		if action.OnMissing != "" {
+			out[i]["on_missing"] = action.OnMissing
+		}
+		if action.Type == automation.ActionTypeIf {
+			out[i]["condition"] = action.Condition
+			if len(action.Then) > 0 {
+				out[i]["then"] = ruleActionMaps(action.Then)
+			}
+			if len(action.Else) > 0 {
+				out[i]["else"] = ruleActionMaps(action.Else)
+			}
+		}
+		if action.Type == automation.ActionTypeParallel {
+			branches := make([][]map[string]interface{}, len(action.Branches))
+			for b, branch := range action.Branches {
+				branches[b] = ruleActionMaps(branch)
+			}
+			out[i]["branches"] = branches
+		}
+		if action.Type == automation.ActionTypeRepeat {
+			out[i]["actions"] = ruleActionMaps(action.Actions)
+			if action.Count > 0 {
+				out[i]["count"] = action.Count
+			}
+			if action.Until != "" {
+				out[i]["until"] = action.Until
+			}
+		}
+		if action.Retry != nil {
+			out[i]["retry"] = action.Retry
+		}
+		if action.TimeoutMs > 0 {
+			out[i]["timeout_ms"] = action.TimeoutMs
+		}
+		if len(action.Fallback) > 0 {
+			out[i]["fallback"] = ruleActionMaps(action.Fallback)
+		}
+	}
+	return out
+}
 
+// buildAutomationRule validates the create input and converts it into a
+// storage rule.
+func buildAutomationRule(input CreateAutomationRuleInput) (storage.AutomationRule, error) {
 	// Validate trigger type
 	if input.TriggerType != automation.TriggerTypeEvent &&
 		input.TriggerType != automation.TriggerTypeSchedule &&
-		input.TriggerType != automation.TriggerTypeManual {
-		return nil, nil, fmt.Errorf("invalid trigger_type '%s'. Must be 'event', 'schedule', or 'manual'", input.TriggerType)
+		input.TriggerType != automation.TriggerTypeManual &&
+		input.TriggerType != automation.TriggerTypeWebhook &&
+		input.TriggerType != automation.TriggerTypeComposite {
+		return storage.AutomationRule{}, fmt.Errorf("invalid trigger_type '%s'. Must be 'event', 'schedule', 'manual', 'webhook', or 'composite'", input.TriggerType)
 	}
 
 	// Validate schedule if trigger type is schedule
 	if input.TriggerType == automation.TriggerTypeSchedule {
-		schedule, ok := input.TriggerConfig["schedule"].(string)
-		if !ok || schedule == "" {
-			return nil, nil, fmt.Errorf("schedule trigger requires 'schedule' in trigger_config")
-		}
-		if err := automation.ValidateCronExpression(schedule); err != nil {
-			return nil, nil, fmt.Errorf("invalid cron schedule: %w", err)
+		if err := validateScheduleConfig(input.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
 		}
 	}
 
@@ -176,7 +268,7 @@ func (s *Server) handleCreateAutomationRule(ctx context.Context, request *mcpsdk
 	if input.TriggerType == automation.TriggerTypeEvent {
 		eventType, ok := input.TriggerConfig["event_type"].(string)
 		if !ok || eventType == "" {
-			return nil, nil, fmt.Errorf("event trigger requires 'event_type' in trigger_config")
+			return storage.AutomationRule{}, fmt.Errorf("event trigger requires 'event_type' in trigger_config")
 		}
 		// Validate event type is known
 		validEvent := false
@@ -187,21 +279,121 @@ func (s *Server) handleCreateAutomationRule(ctx context.Context, request *mcpsdk
 			}
 		}
 		if !validEvent {
-			return nil, nil, fmt.Errorf("unknown event_type '%s'. Valid types: %v", eventType, automation.SupportedEventTypes())
+			return storage.AutomationRule{}, fmt.Errorf("unknown event_type '%s'. Valid types: %v", eventType, automation.SupportedEventTypes())
+		}
+		if err := validateEventFilter(input.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
+		}
+	}
+
+	// Validate secret, body mapping and filter if trigger type is webhook
+	if input.TriggerType == automation.TriggerTypeWebhook {
+		if err := automation.ValidateWebhookConfig(input.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
+		}
+		if err := validateEventFilter(input.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
+		}
+	}
+
+	// Validate mode, events and timing if trigger type is composite
+	if input.TriggerType == automation.TriggerTypeComposite {
+		if _, err := automation.ParseCompositeConfig(input.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
 		}
 	}
 
 	// Validate actions
 	if len(input.Actions) == 0 {
-		return nil, nil, fmt.Errorf("at least one action is required")
+		return storage.AutomationRule{}, fmt.Errorf("at least one action is required")
+	}
+
+	actions, err := parseRuleActions(input.Actions)
+	if err != nil {
+		return storage.AutomationRule{}, err
 	}
 
-	// Convert actions to storage format
-	actions := make([]storage.RuleAction, len(input.Actions))
-	for i, actionMap := range input.Actions {
+	if input.Condition != "" {
+		if err := automation.ValidateCondition(input.Condition); err != nil {
+			return storage.AutomationRule{}, err
+		}
+	}
+
+	if err := validateConcurrency(input.Concurrency); err != nil {
+		return storage.AutomationRule{}, err
+	}
+
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+
+	return storage.AutomationRule{
+		Name:          input.Name,
+		Description:   input.Description,
+		Enabled:       enabled,
+		TriggerType:   input.TriggerType,
+		TriggerConfig: input.TriggerConfig,
+		Actions:       actions,
+		Condition:     input.Condition,
+		Concurrency:   input.Concurrency,
+		CooldownMs:    input.CooldownMs,
+		Priority:      input.Priority,
+	}, nil
+}
+
+// validateConcurrency checks a rule's concurrency policy. Empty means the
+// default, parallel.
+func validateConcurrency(policy string) error {
+	if policy == "" || slices.Contains(automation.SupportedConcurrencyPolicies(), policy) {
+		return nil
+	}
+	return fmt.Errorf("invalid concurrency '%s'. Valid policies: %v", policy, automation.SupportedConcurrencyPolicies())
+}
+
+// validateEventFilter checks the event_filter in an event trigger config.
+func validateEventFilter(triggerConfig map[string]interface{}) error {
+	raw, ok := triggerConfig["event_filter"]
+	if !ok || raw == nil {
+		return nil
+	}
+	filter, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("event_filter must be an object mapping event data keys to values or operators")
+	}
+	return automation.ValidateEventFilter(filter)
+}
+
+// validateScheduleConfig checks a schedule trigger config. A one-shot 'at'
+// time must be in the future.
+func validateScheduleConfig(config map[string]interface{}) error {
+	spec, err := automation.ParseScheduleConfig(config)
+	if err != nil {
+		return err
+	}
+	if !spec.At.IsZero() && !spec.At.After(time.Now()) {
+		return fmt.Errorf("'at' time %s is in the past", spec.At.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// parseRuleActions converts tool input actions to storage format, checking
+// action types, error and missing-variable policies, template placeholders,
+// and the conditions and branches of if actions.
+func parseRuleActions(actionMaps []map[string]interface{}) ([]storage.RuleAction, error) {
+	return parseRuleActionList(actionMaps, "", 0)
+}
+
+// parseRuleActionList parses one action list. prefix locates nested lists in
+// error messages, e.g. "2.then." for the then branch of action 2. depth is
+// how many lists the list is nested in.
+func parseRuleActionList(actionMaps []map[string]interface{}, prefix string, depth int) ([]storage.RuleAction, error) {
+	actions := make([]storage.RuleAction, len(actionMaps))
+	for n, actionMap := range actionMaps {
+		i := fmt.Sprintf("%s%d", prefix, n)
 		actionType, ok := actionMap["type"].(string)
 		if !ok || actionType == "" {
-			return nil, nil, fmt.Errorf("action %d missing 'type'", i)
+			return nil, fmt.Errorf("action %s missing 'type'", i)
 		}
 
 		// Validate action type is known
@@ -213,37 +405,244 @@ func (s *Server) handleCreateAutomationRule(ctx context.Context, request *mcpsdk
 			}
 		}
 		if !validAction {
-			return nil, nil, fmt.Errorf("unknown action type '%s'. Valid types: %v", actionType, automation.SupportedActionTypes())
+			return nil, fmt.Errorf("unknown action type '%s'. Valid types: %v", actionType, automation.SupportedActionTypes())
 		}
 
 		params, _ := actionMap["parameters"].(map[string]interface{})
+		if err := automation.ValidateTemplates(params); err != nil {
+			return nil, fmt.Errorf("action %s (%s): %w", i, actionType, err)
+		}
+		if actionType == automation.ActionTypeHTTPRequest {
+			if err := automation.ValidateHTTPRequestParams(params); err != nil {
+				return nil, fmt.Errorf("action %s: %w", i, err)
+			}
+		}
+		if actionType == automation.ActionTypeRunMacro {
+			if err := automation.ValidateRunMacroParams(params); err != nil {
+				return nil, fmt.Errorf("action %s: %w", i, err)
+			}
+		}
+
 		onError, _ := actionMap["on_error"].(string)
 		if onError == "" {
 			onError = automation.ActionErrorContinue
 		}
+		if !slices.Contains(automation.SupportedErrorPolicies(), onError) {
+			return nil, fmt.Errorf("action %s has invalid on_error '%s'. Valid values: %v", i, onError, automation.SupportedErrorPolicies())
+		}
+
+		onMissing, _ := actionMap["on_missing"].(string)
+		if onMissing != "" && !slices.Contains(automation.SupportedMissingVarPolicies(), onMissing) {
+			return nil, fmt.Errorf("action %s has invalid on_missing '%s'. Valid values: %v", i, onMissing, automation.SupportedMissingVarPolicies())
+		}
 
-		actions[i] = storage.RuleAction{
+		actions[n] = storage.RuleAction{
 			Type:       actionType,
 			Parameters: params,
 			OnError:    onError,
+			OnMissing:  onMissing,
+		}
+
+		var err error
+		switch actionType {
+		case automation.ActionTypeIf:
+			err = parseIfAction(&actions[n], actionMap, i, depth)
+		case automation.ActionTypeParallel:
+			err = parseParallelAction(&actions[n], actionMap, i, depth)
+		case automation.ActionTypeRepeat:
+			err = parseRepeatAction(&actions[n], actionMap, i, depth)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := parseActionRecovery(&actions[n], actionMap, i, depth); err != nil {
+			return nil, err
 		}
 	}
+	return actions, nil
+}
 
-	// Create the rule
-	enabled := true
-	if input.Enabled != nil {
-		enabled = *input.Enabled
+// parseActionRecovery parses an action's retry policy, timeout_ms and
+// fallback list.
+func parseActionRecovery(action *storage.RuleAction, actionMap map[string]interface{}, i string, depth int) error {
+	if raw, ok := actionMap["retry"]; ok && raw != nil {
+		if automation.IsControlAction(action.Type) {
+			return fmt.Errorf("action %s (%s) cannot have a retry policy; set it on the nested actions", i, action.Type)
+		}
+		if action.Type == automation.ActionTypeHTTPRequest {
+			// Its own retries would multiply with the policy's attempts
+			return fmt.Errorf("action %s (http_request) cannot have a retry policy; use its 'retries' and 'retry_delay_ms' parameters", i)
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("action %s: invalid retry: %w", i, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		var retry storage.ActionRetry
+		if err := decoder.Decode(&retry); err != nil {
+			return fmt.Errorf("action %s: 'retry' must be an object with max_attempts, backoff_ms, max_backoff_ms and jitter: %w", i, err)
+		}
+		if err := automation.ValidateRetryPolicy(automation.RetryPolicy(retry)); err != nil {
+			return fmt.Errorf("action %s: %w", i, err)
+		}
+		action.Retry = &retry
 	}
 
-	rule := storage.AutomationRule{
-		Name:          input.Name,
-		Description:   input.Description,
-		Enabled:       enabled,
-		TriggerType:   input.TriggerType,
-		TriggerConfig: input.TriggerConfig,
-		Actions:       actions,
-		CooldownMs:    input.CooldownMs,
-		Priority:      input.Priority,
+	if raw, ok := actionMap["timeout_ms"]; ok && raw != nil {
+		timeout, ok := raw.(float64)
+		if !ok || timeout != float64(int(timeout)) {
+			return fmt.Errorf("action %s: 'timeout_ms' must be a whole number of milliseconds", i)
+		}
+		if automation.IsControlAction(action.Type) {
+			return fmt.Errorf("action %s (%s) cannot have a timeout; set it on the nested actions", i, action.Type)
+		}
+		if err := automation.ValidateActionTimeout(int(timeout)); err != nil {
+			return fmt.Errorf("action %s: %w", i, err)
+		}
+		action.TimeoutMs = int(timeout)
+	}
+
+	fallback, err := parseNestedActions(actionMap["fallback"], i, "fallback", depth)
+	if err != nil {
+		return err
+	}
+	switch {
+	case action.OnError == automation.ActionErrorGoto && len(fallback) == 0:
+		return fmt.Errorf("action %s has on_error 'goto' but no 'fallback' actions", i)
+	case action.OnError != automation.ActionErrorGoto && len(fallback) > 0:
+		return fmt.Errorf("action %s has 'fallback' actions, which only run with on_error 'goto'", i)
+	}
+	action.Fallback = fallback
+	return nil
+}
+
+// parseNestedActions parses a nested action list such as an if branch or a
+// fallback, belonging to an action in a list at depth. A missing list parses
+// as nil.
+func parseNestedActions(raw interface{}, i, name string, depth int) ([]storage.RuleAction, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("action %s: '%s' must be a list of actions", i, name)
+	}
+	if depth >= automation.MaxActionDepth {
+		return nil, fmt.Errorf("action %s: action lists are nested too deeply (max %d levels)", i, automation.MaxActionDepth)
+	}
+	maps := make([]map[string]interface{}, len(items))
+	for j, item := range items {
+		if maps[j], ok = item.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("action %s.%s.%d must be an object", i, name, j)
+		}
+	}
+	return parseRuleActionList(maps, fmt.Sprintf("%s.%s.", i, name), depth+1)
+}
+
+// parseIfAction fills in the condition and branches of an if action.
+func parseIfAction(action *storage.RuleAction, actionMap map[string]interface{}, i string, depth int) error {
+	condition, _ := actionMap["condition"].(string)
+	if condition == "" {
+		return fmt.Errorf("action %s (if) requires a 'condition'", i)
+	}
+	if err := automation.ValidateCondition(condition); err != nil {
+		return fmt.Errorf("action %s (if): %w", i, err)
+	}
+	action.Condition = condition
+
+	for _, branch := range []string{"then", "else"} {
+		parsed, err := parseNestedActions(actionMap[branch], i, branch, depth)
+		if err != nil {
+			return err
+		}
+		if branch == "then" {
+			action.Then = parsed
+		} else {
+			action.Else = parsed
+		}
+	}
+
+	if len(action.Then) == 0 && len(action.Else) == 0 {
+		return fmt.Errorf("action %s (if) needs a 'then' or 'else' list of actions", i)
+	}
+	return nil
+}
+
+// parseParallelAction fills in the branches of a parallel action.
+func parseParallelAction(action *storage.RuleAction, actionMap map[string]interface{}, i string, depth int) error {
+	branches, ok := actionMap["branches"].([]interface{})
+	if !ok || len(branches) == 0 {
+		return fmt.Errorf("action %s (parallel) requires 'branches', a list of action lists", i)
+	}
+	if len(branches) > automation.MaxParallelBranches {
+		return fmt.Errorf("action %s (parallel) has %d branches; the maximum is %d", i, len(branches), automation.MaxParallelBranches)
+	}
+
+	for b, raw := range branches {
+		parsed, err := parseNestedActions(raw, i, fmt.Sprintf("branches.%d", b), depth)
+		if err != nil {
+			return err
+		}
+		if len(parsed) == 0 {
+			return fmt.Errorf("action %s (parallel) branch %d has no actions", i, b)
+		}
+		action.Branches = append(action.Branches, parsed)
+	}
+	return nil
+}
+
+// parseRepeatAction fills in the actions, count and until condition of a
+// repeat action.
+func parseRepeatAction(action *storage.RuleAction, actionMap map[string]interface{}, i string, depth int) error {
+	parsed, err := parseNestedActions(actionMap["actions"], i, "actions", depth)
+	if err != nil {
+		return err
+	}
+	if len(parsed) == 0 {
+		return fmt.Errorf("action %s (repeat) requires an 'actions' list", i)
+	}
+	action.Actions = parsed
+
+	if raw, ok := actionMap["count"]; ok && raw != nil {
+		count, ok := raw.(float64)
+		if !ok || count != float64(int(count)) || count < 1 || count > automation.MaxRepeatCount {
+			return fmt.Errorf("action %s (repeat): 'count' must be a whole number between 1 and %d", i, automation.MaxRepeatCount)
+		}
+		action.Count = int(count)
+	}
+
+	until, _ := actionMap["until"].(string)
+	if until != "" {
+		if err := automation.ValidateCondition(until); err != nil {
+			return fmt.Errorf("action %s (repeat) 'until': %w", i, err)
+		}
+		action.Until = until
+	}
+
+	if action.Count == 0 && action.Until == "" {
+		return fmt.Errorf("action %s (repeat) requires a 'count', an 'until' condition, or both", i)
+	}
+	return nil
+}
+
+// handleCreateAutomationRule creates a new automation rule.
+func (s *Server) handleCreateAutomationRule(ctx context.Context, request *mcpsdk.CallToolRequest, input CreateAutomationRuleInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Creating automation rule: %s", input.Name)
+
+	// The webhook secret is not kept in action history
+	record := input
+	record.TriggerConfig = redactTriggerConfig(input.TriggerConfig)
+
+	if input.DryRun {
+		changes, err := s.planCreateAutomationRule(ctx, input)
+		return s.dryRunResult(ctx, "create_automation_rule", "Create automation rule", record, changes, err, start)
+	}
+
+	rule, err := buildAutomationRule(input)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	id, err := s.storage.CreateAutomationRule(ctx, rule)
@@ -259,27 +658,18 @@ func (s *Server) handleCreateAutomationRule(ctx context.Context, request *mcpsdk
 	result := map[string]interface{}{
 		"id":      id,
 		"name":    input.Name,
-		"enabled": enabled,
+		"enabled": rule.Enabled,
 		"message": fmt.Sprintf("Automation rule '%s' created successfully", input.Name),
 	}
 
-	s.recordAction("create_automation_rule", "Create automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "create_automation_rule", "Create automation rule", record, result, true, time.Since(start))
 	return nil, result, nil
 }
 
-// handleUpdateAutomationRule updates an existing automation rule.
-func (s *Server) handleUpdateAutomationRule(ctx context.Context, request *mcpsdk.CallToolRequest, input UpdateAutomationRuleInput) (*mcpsdk.CallToolResult, any, error) {
-	start := time.Now()
-	log.Printf("Updating automation rule: %s", input.Name)
-
-	// Get existing rule
-	existing, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
-	}
-
-	// Apply updates
-	updated := *existing
+// applyAutomationRuleUpdate returns existing with the provided update fields
+// applied, validating the result.
+func applyAutomationRuleUpdate(existing storage.AutomationRule, input UpdateAutomationRuleInput) (storage.AutomationRule, error) {
+	updated := existing
 
 	if input.NewName != "" {
 		updated.Name = input.NewName
@@ -294,25 +684,26 @@ func (s *Server) handleUpdateAutomationRule(ctx context.Context, request *mcpsdk
 		updated.TriggerConfig = input.TriggerConfig
 	}
 	if input.Actions != nil {
-		actions := make([]storage.RuleAction, len(input.Actions))
-		for i, actionMap := range input.Actions {
-			actionType, ok := actionMap["type"].(string)
-			if !ok || actionType == "" {
-				return nil, nil, fmt.Errorf("action %d missing 'type'", i)
-			}
-			params, _ := actionMap["parameters"].(map[string]interface{})
-			onError, _ := actionMap["on_error"].(string)
-			if onError == "" {
-				onError = automation.ActionErrorContinue
-			}
-			actions[i] = storage.RuleAction{
-				Type:       actionType,
-				Parameters: params,
-				OnError:    onError,
-			}
+		actions, err := parseRuleActions(input.Actions)
+		if err != nil {
+			return storage.AutomationRule{}, err
 		}
 		updated.Actions = actions
 	}
+	if input.Condition != nil {
+		if *input.Condition != "" {
+			if err := automation.ValidateCondition(*input.Condition); err != nil {
+				return storage.AutomationRule{}, err
+			}
+		}
+		updated.Condition = *input.Condition
+	}
+	if input.Concurrency != nil {
+		if err := validateConcurrency(*input.Concurrency); err != nil {
+			return storage.AutomationRule{}, err
+		}
+		updated.Concurrency = *input.Concurrency
+	}
 	if input.CooldownMs != nil {
 		updated.CooldownMs = *input.CooldownMs
 	}
@@ -320,16 +711,55 @@ func (s *Server) handleUpdateAutomationRule(ctx context.Context, request *mcpsdk
 		updated.Priority = *input.Priority
 	}
 
-	// Validate if trigger type changed
-	if input.TriggerType == automation.TriggerTypeSchedule {
-		schedule, ok := updated.TriggerConfig["schedule"].(string)
-		if !ok || schedule == "" {
-			return nil, nil, fmt.Errorf("schedule trigger requires 'schedule' in trigger_config")
+	// Validate if trigger type or config changed
+	if input.TriggerType == automation.TriggerTypeSchedule || (updated.TriggerType == automation.TriggerTypeSchedule && input.TriggerConfig != nil) {
+		if err := validateScheduleConfig(updated.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
 		}
-		if err := automation.ValidateCronExpression(schedule); err != nil {
-			return nil, nil, fmt.Errorf("invalid cron schedule: %w", err)
+	}
+	if input.TriggerType == automation.TriggerTypeWebhook || (updated.TriggerType == automation.TriggerTypeWebhook && input.TriggerConfig != nil) {
+		if err := automation.ValidateWebhookConfig(updated.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
 		}
 	}
+	if input.TriggerType == automation.TriggerTypeComposite || (updated.TriggerType == automation.TriggerTypeComposite && input.TriggerConfig != nil) {
+		if _, err := automation.ParseCompositeConfig(updated.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
+		}
+	}
+	if (updated.TriggerType == automation.TriggerTypeEvent || updated.TriggerType == automation.TriggerTypeWebhook) && input.TriggerConfig != nil {
+		if err := validateEventFilter(updated.TriggerConfig); err != nil {
+			return storage.AutomationRule{}, err
+		}
+	}
+
+	return updated, nil
+}
+
+// handleUpdateAutomationRule updates an existing automation rule.
+func (s *Server) handleUpdateAutomationRule(ctx context.Context, request *mcpsdk.CallToolRequest, input UpdateAutomationRuleInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Updating automation rule: %s", input.Name)
+
+	// The webhook secret is not kept in action history
+	record := input
+	record.TriggerConfig = redactTriggerConfig(input.TriggerConfig)
+
+	if input.DryRun {
+		changes, err := s.planUpdateAutomationRule(ctx, input)
+		return s.dryRunResult(ctx, "update_automation_rule", "Update automation rule", record, changes, err, start)
+	}
+
+	// Get existing rule
+	existing, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+
+	updated, err := applyAutomationRuleUpdate(*existing, input)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	if err := s.storage.UpdateAutomationRule(ctx, updated); err != nil {
 		return nil, nil, fmt.Errorf("failed to update automation rule: %w", err)
@@ -346,7 +776,7 @@ func (s *Server) handleUpdateAutomationRule(ctx context.Context, request *mcpsdk
 		"message": fmt.Sprintf("Automation rule '%s' updated successfully", updated.Name),
 	}
 
-	s.recordAction("update_automation_rule", "Update automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "update_automation_rule", "Update automation rule", record, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -355,6 +785,11 @@ func (s *Server) handleDeleteAutomationRule(ctx context.Context, request *mcpsdk
 	start := time.Now()
 	log.Printf("Deleting automation rule: %s", input.Name)
 
+	if input.DryRun {
+		changes, err := s.planDeleteAutomationRule(ctx, input)
+		return s.dryRunResult(ctx, "delete_automation_rule", "Delete automation rule", input, changes, err, start)
+	}
+
 	// Get rule to confirm it exists and get ID
 	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
 	if err != nil {
@@ -391,7 +826,7 @@ func (s *Server) handleDeleteAutomationRule(ctx context.Context, request *mcpsdk
 		"message": fmt.Sprintf("Automation rule '%s' deleted successfully", input.Name),
 	}
 
-	s.recordAction("delete_automation_rule", "Delete automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "delete_automation_rule", "Delete automation rule", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -400,6 +835,11 @@ func (s *Server) handleEnableAutomationRule(ctx context.Context, request *mcpsdk
 	start := time.Now()
 	log.Printf("Enabling automation rule: %s", input.Name)
 
+	if input.DryRun {
+		changes, err := s.planSetAutomationRuleEnabled(ctx, input.Name, true)
+		return s.dryRunResult(ctx, "enable_automation_rule", "Enable automation rule", input, changes, err, start)
+	}
+
 	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
@@ -421,7 +861,7 @@ func (s *Server) handleEnableAutomationRule(ctx context.Context, request *mcpsdk
 		"message": fmt.Sprintf("Automation rule '%s' enabled", input.Name),
 	}
 
-	s.recordAction("enable_automation_rule", "Enable automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "enable_automation_rule", "Enable automation rule", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -430,6 +870,11 @@ func (s *Server) handleDisableAutomationRule(ctx context.Context, request *mcpsd
 	start := time.Now()
 	log.Printf("Disabling automation rule: %s", input.Name)
 
+	if input.DryRun {
+		changes, err := s.planSetAutomationRuleEnabled(ctx, input.Name, false)
+		return s.dryRunResult(ctx, "disable_automation_rule", "Disable automation rule", input, changes, err, start)
+	}
+
 	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
@@ -451,7 +896,7 @@ func (s *Server) handleDisableAutomationRule(ctx context.Context, request *mcpsd
 		"message": fmt.Sprintf("Automation rule '%s' disabled", input.Name),
 	}
 
-	s.recordAction("disable_automation_rule", "Disable automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "disable_automation_rule", "Disable automation rule", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -468,6 +913,37 @@ func (s *Server) handleTriggerAutomationRule(ctx context.Context, request *mcpsd
 		return nil, nil, fmt.Errorf("automation engine is not running")
 	}
 
+	// Run synchronously when the caller wants to follow the execution, so
+	// progress is reported per action and a cancelled request stops the rule
+	progress := newProgressReporter(request)
+	if input.Wait || progress.enabled() {
+		exec, err := s.automationEngine.ExecuteRuleByName(ctx, input.Name, func(completed, total int, message string) {
+			progress.report(ctx, completed, total, message)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to trigger automation rule: %w", err)
+		}
+		if exec.Status == storage.ExecutionStatusCancelled {
+			s.recordAction(ctx, "trigger_automation_rule", "Trigger automation rule", input, nil, false, time.Since(start))
+			return nil, nil, fmt.Errorf("automation rule '%s' was cancelled", input.Name)
+		}
+
+		result := map[string]interface{}{
+			"triggered":    true,
+			"name":         input.Name,
+			"execution_id": exec.ExecutionID,
+			"status":       exec.Status,
+			"duration_ms":  exec.DurationMs,
+			"message":      fmt.Sprintf("Automation rule '%s' %s in %dms", input.Name, exec.Status, exec.DurationMs),
+		}
+		if exec.Error != "" {
+			result["error"] = exec.Error
+		}
+
+		s.recordAction(ctx, "trigger_automation_rule", "Trigger automation rule", input, result, exec.Status == storage.ExecutionStatusCompleted, time.Since(start))
+		return nil, result, nil
+	}
+
 	if err := s.automationEngine.TriggerRuleByName(input.Name); err != nil {
 		return nil, nil, fmt.Errorf("failed to trigger automation rule: %w", err)
 	}
@@ -478,7 +954,7 @@ func (s *Server) handleTriggerAutomationRule(ctx context.Context, request *mcpsd
 		"message":   fmt.Sprintf("Automation rule '%s' triggered", input.Name),
 	}
 
-	s.recordAction("trigger_automation_rule", "Trigger automation rule", input, result, true, time.Since(start))
+	s.recordAction(ctx, "trigger_automation_rule", "Trigger automation rule", input, result, true, time.Since(start))
 	return nil, result, nil
 }
 
@@ -536,6 +1012,12 @@ func (s *Server) handleListRuleExecutions(ctx context.Context, request *mcpsdk.C
 		if len(exec.ActionResults) > 0 {
 			execItem["action_count"] = len(exec.ActionResults)
 		}
+		if exec.Revision > 0 {
+			execItem["revision"] = exec.Revision
+		}
+		if runs := macroRuns(exec.ActionResults, ""); len(runs) > 0 {
+			execItem["macro_runs"] = runs
+		}
 		execList[i] = execItem
 	}
 
@@ -545,6 +1027,240 @@ func (s *Server) handleListRuleExecutions(ctx context.Context, request *mcpsdk.C
 		"message":    fmt.Sprintf("Found %d rule executions", len(executions)),
 	}
 
-	s.recordAction("list_rule_executions", "List rule executions", input, result, true, time.Since(start))
+	s.recordAction(ctx, "list_rule_executions", "List rule executions", input, result, true, time.Since(start))
 	return nil, result, nil
 }
+
+// handleListRunningExecutions lists the rule executions in progress.
+func (s *Server) handleListRunningExecutions(ctx context.Context, request *mcpsdk.CallToolRequest, input ListRunningExecutionsInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Listing running rule executions")
+
+	if s.automationEngine == nil {
+		return nil, nil, fmt.Errorf("automation engine is not available")
+	}
+
+	now := time.Now()
+	execList := []map[string]interface{}{}
+	for _, exec := range s.automationEngine.RunningExecutions() {
+		if input.RuleName != "" && exec.RuleName != input.RuleName {
+			continue
+		}
+		execList = append(execList, map[string]interface{}{
+			"execution_id": exec.ExecutionID,
+			"rule_id":      exec.RuleID,
+			"rule_name":    exec.RuleName,
+			"trigger_type": exec.TriggerType,
+			"action_index": exec.ActionIndex,
+			"action_count": exec.ActionCount,
+			"action_type":  exec.ActionType,
+			"started_at":   exec.StartedAt.Format(time.RFC3339),
+			"running_ms":   now.Sub(exec.StartedAt).Milliseconds(),
+		})
+	}
+
+	result := map[string]interface{}{
+		"executions": execList,
+		"count":      len(execList),
+		"message":    fmt.Sprintf("Found %d running executions", len(execList)),
+	}
+
+	s.recordAction(ctx, "list_running_executions", "List running executions", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleCancelRuleExecution cancels a running rule execution.
+func (s *Server) handleCancelRuleExecution(ctx context.Context, request *mcpsdk.CallToolRequest, input CancelRuleExecutionInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Cancelling rule execution: %d", input.ExecutionID)
+
+	if s.automationEngine == nil {
+		return nil, nil, fmt.Errorf("automation engine is not available")
+	}
+
+	if err := s.automationEngine.CancelExecution(input.ExecutionID); err != nil {
+		s.recordAction(ctx, "cancel_rule_execution", "Cancel rule execution", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("failed to cancel rule execution: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"execution_id": input.ExecutionID,
+		"cancelled":    true,
+		"message":      fmt.Sprintf("Execution %d cancelled", input.ExecutionID),
+	}
+
+	s.recordAction(ctx, "cancel_rule_execution", "Cancel rule execution", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleGetNextRuns previews when a schedule rule fires next.
+func (s *Server) handleGetNextRuns(ctx context.Context, request *mcpsdk.CallToolRequest, input GetNextRunsInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Previewing next runs of automation rule: %s", input.Name)
+
+	count := input.Count
+	if count <= 0 {
+		count = 5
+	}
+	count = min(count, automation.MaxNextRuns)
+
+	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+	if rule.TriggerType != automation.TriggerTypeSchedule {
+		return nil, nil, fmt.Errorf("rule '%s' has trigger type '%s'; only schedule rules have fire times", rule.Name, rule.TriggerType)
+	}
+	spec, err := automation.ParseScheduleConfig(rule.TriggerConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rule '%s' has an invalid schedule: %w", rule.Name, err)
+	}
+
+	// After-event rules have no fixed fire times; their waiting runs are
+	// listed instead
+	var runs []time.Time
+	if spec.AfterEvent != "" {
+		if s.automationEngine != nil {
+			runs = s.automationEngine.PendingScheduledRuns(rule.ID)
+		}
+		runs = runs[:min(len(runs), count)]
+	} else {
+		runs = spec.NextRuns(start, count)
+	}
+
+	runList := make([]map[string]interface{}, len(runs))
+	for i, run := range runs {
+		runList[i] = map[string]interface{}{
+			"time":       run.In(spec.Location).Format(time.RFC3339),
+			"in_seconds": int64(run.Sub(start).Seconds()),
+		}
+	}
+
+	var message string
+	switch {
+	case spec.AfterEvent != "":
+		message = fmt.Sprintf("Rule '%s' fires %s; %d run(s) waiting", rule.Name, spec, len(runs))
+	case len(runs) == 0:
+		message = fmt.Sprintf("Rule '%s' has no upcoming runs", rule.Name)
+	default:
+		message = fmt.Sprintf("Next %d run(s) of rule '%s'", len(runs), rule.Name)
+	}
+	if !rule.Enabled {
+		message += " (the rule is disabled and will not fire until enabled)"
+	}
+
+	result := map[string]interface{}{
+		"rule_name": rule.Name,
+		"enabled":   rule.Enabled,
+		"schedule":  spec.String(),
+		"timezone":  spec.Location.String(),
+		"next_runs": runList,
+		"count":     len(runList),
+		"message":   message,
+	}
+
+	s.recordAction(ctx, "get_next_runs", "Get next runs", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleSimulateAutomationEvent reports which rules an event would fire and
+// the actions they would run, without running them or starting cooldowns.
+func (s *Server) handleSimulateAutomationEvent(ctx context.Context, request *mcpsdk.CallToolRequest, input SimulateAutomationEventInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Simulating automation event: %s", input.EventType)
+
+	if !slices.Contains(automation.SupportedEventTypes(), input.EventType) {
+		return nil, nil, fmt.Errorf("unknown event type '%s'. Valid types: %v", input.EventType, automation.SupportedEventTypes())
+	}
+
+	if s.automationEngine == nil {
+		return nil, nil, fmt.Errorf("automation engine is not available")
+	}
+
+	sim, err := s.automationEngine.Simulate(automation.EventPayload{EventType: input.EventType, Data: input.Data})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to simulate event: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"event_type":  input.EventType,
+		"fired":       simulatedRuleMaps(sim.Fired),
+		"skipped":     simulatedRuleMaps(sim.Skipped),
+		"fired_count": len(sim.Fired),
+		"message":     fmt.Sprintf("%d rule(s) would fire, %d skipped", len(sim.Fired), len(sim.Skipped)),
+	}
+
+	s.recordAction(ctx, "simulate_automation_event", "Simulate automation event", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// simulatedRuleMaps converts simulated rules to response format.
+func simulatedRuleMaps(rules []automation.SimulatedRule) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(rules))
+	for i, rule := range rules {
+		out[i] = map[string]interface{}{
+			"rule_id":   rule.RuleID,
+			"rule_name": rule.RuleName,
+			"priority":  rule.Priority,
+		}
+		if rule.Reason != "" {
+			out[i]["reason"] = rule.Reason
+		}
+		if rule.Note != "" {
+			out[i]["note"] = rule.Note
+		}
+		if len(rule.Actions) > 0 {
+			out[i]["actions"] = simulatedActionMaps(rule.Actions)
+		}
+	}
+	return out
+}
+
+// simulatedActionMaps converts simulated actions to response format,
+// including the branch an if action would take.
+func simulatedActionMaps(actions []automation.SimulatedAction) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(actions))
+	for i, action := range actions {
+		item := map[string]interface{}{
+			"index": action.Index,
+			"type":  action.Type,
+		}
+		if len(action.Parameters) > 0 {
+			item["parameters"] = action.Parameters
+		}
+		if action.OnError != "" {
+			item["on_error"] = action.OnError
+		}
+		if action.Condition != "" {
+			item["condition"] = action.Condition
+		}
+		if action.Branch != "" {
+			item["branch"] = action.Branch
+			item["actions"] = simulatedActionMaps(action.Actions)
+		}
+		if len(action.Branches) > 0 {
+			branches := make([][]map[string]interface{}, len(action.Branches))
+			for b, branch := range action.Branches {
+				branches[b] = simulatedActionMaps(branch)
+			}
+			item["branches"] = branches
+		}
+		if action.Type == automation.ActionTypeRepeat {
+			item["actions"] = simulatedActionMaps(action.Actions)
+			if action.Count > 0 {
+				item["count"] = action.Count
+			}
+			if action.Until != "" {
+				item["until"] = action.Until
+			}
+		}
+		if len(action.Fallback) > 0 {
+			item["fallback"] = simulatedActionMaps(action.Fallback)
+		}
+		if action.Error != "" {
+			item["error"] = action.Error
+		}
+		out[i] = item
+	}
+	return out
+}
@@ -0,0 +1,506 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuleActions(t *testing.T) {
+	t.Run("keeps templates and policies", func(t *testing.T) {
+		actions, err := parseRuleActions([]map[string]interface{}{
+			{"type": "toggle_mute", "parameters": map[string]interface{}{"input_name": "{{event.input_name}}"}, "on_missing": "keep"},
+			{"type": "set_variable", "parameters": map[string]interface{}{"name": "last", "value": "{{input_name|none}}"}, "on_error": "stop"},
+		})
+		require.NoError(t, err)
+		require.Len(t, actions, 2)
+		assert.Equal(t, automation.MissingVarKeep, actions[0].OnMissing)
+		assert.Equal(t, automation.ActionErrorContinue, actions[0].OnError)
+		assert.Empty(t, actions[1].OnMissing)
+		assert.Equal(t, automation.ActionErrorStop, actions[1].OnError)
+	})
+
+	t.Run("parses if branches", func(t *testing.T) {
+		actions, err := parseRuleActions([]map[string]interface{}{
+			{
+				"type":      "if",
+				"condition": "obs.streaming && event.scene_name != 'BRB'",
+				"then": []interface{}{
+					map[string]interface{}{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "Live"}},
+				},
+				"else": []interface{}{
+					map[string]interface{}{"type": "if", "condition": "obs.recording", "then": []interface{}{
+						map[string]interface{}{"type": "stop_recording", "on_error": "stop"},
+					}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "obs.streaming && event.scene_name != 'BRB'", actions[0].Condition)
+		require.Len(t, actions[0].Then, 1)
+		assert.Equal(t, "Live", actions[0].Then[0].Parameters["scene_name"])
+		require.Len(t, actions[0].Else, 1)
+		require.Len(t, actions[0].Else[0].Then, 1)
+		assert.Equal(t, automation.ActionErrorStop, actions[0].Else[0].Then[0].OnError)
+	})
+
+	t.Run("parses retry, timeout and fallback", func(t *testing.T) {
+		actions, err := parseRuleActions([]map[string]interface{}{
+			{
+				"type":       "set_scene",
+				"parameters": map[string]interface{}{"scene_name": "Live"},
+				"retry":      map[string]interface{}{"max_attempts": float64(4), "backoff_ms": float64(250), "jitter": 0.2},
+				"timeout_ms": float64(2000),
+				"on_error":   "goto",
+				"fallback": []interface{}{
+					map[string]interface{}{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "BRB"}, "on_error": "stop"},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, &storage.ActionRetry{MaxAttempts: 4, BackoffMs: 250, Jitter: 0.2}, actions[0].Retry)
+		assert.Equal(t, 2000, actions[0].TimeoutMs)
+		assert.Equal(t, automation.ActionErrorGoto, actions[0].OnError)
+		require.Len(t, actions[0].Fallback, 1)
+		assert.Equal(t, "BRB", actions[0].Fallback[0].Parameters["scene_name"])
+
+		maps := ruleActionMaps(actions)
+		assert.Equal(t, 2000, maps[0]["timeout_ms"])
+		assert.Len(t, maps[0]["fallback"], 1)
+	})
+
+	t.Run("parses parallel and repeat", func(t *testing.T) {
+		actions, err := parseRuleActions([]map[string]interface{}{
+			{
+				"type": "parallel",
+				"branches": []interface{}{
+					[]interface{}{map[string]interface{}{"type": "start_recording"}},
+					[]interface{}{
+						map[string]interface{}{"type": "repeat", "count": float64(3), "until": "obs.streaming", "actions": []interface{}{
+							map[string]interface{}{"type": "start_streaming"},
+							map[string]interface{}{"type": "delay", "parameters": map[string]interface{}{"delay_ms": float64(1000)}},
+						}},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		require.Len(t, actions[0].Branches, 2)
+		repeat := actions[0].Branches[1][0]
+		assert.Equal(t, 3, repeat.Count)
+		assert.Equal(t, "obs.streaming", repeat.Until)
+		require.Len(t, repeat.Actions, 2)
+
+		maps := ruleActionMaps(actions)
+		branches, ok := maps[0]["branches"].([][]map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, 3, branches[1][0]["count"])
+		assert.Len(t, branches[1][0]["actions"], 2)
+	})
+
+	t.Run("limits nesting depth", func(t *testing.T) {
+		nest := func(levels int) map[string]interface{} {
+			action := map[string]interface{}{"type": "save_replay"}
+			for range levels {
+				action = map[string]interface{}{"type": "repeat", "count": float64(2), "actions": []interface{}{action}}
+			}
+			return action
+		}
+		_, err := parseRuleActions([]map[string]interface{}{nest(automation.MaxActionDepth)})
+		require.NoError(t, err)
+		_, err = parseRuleActions([]map[string]interface{}{nest(automation.MaxActionDepth + 1)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nested too deeply")
+	})
+
+	tooMany := make([]interface{}, automation.MaxParallelBranches+1)
+	for b := range tooMany {
+		tooMany[b] = []interface{}{map[string]interface{}{"type": "save_replay"}}
+	}
+
+	for name, tc := range map[string]struct {
+		action map[string]interface{}
+		want   string
+	}{
+		"bad on_error":          {map[string]interface{}{"type": "save_replay", "on_error": "retry"}, "invalid on_error"},
+		"goto no fallback":      {map[string]interface{}{"type": "save_replay", "on_error": "goto"}, "no 'fallback' actions"},
+		"fallback no goto":      {map[string]interface{}{"type": "save_replay", "fallback": []interface{}{map[string]interface{}{"type": "start_recording"}}}, "only run with on_error 'goto'"},
+		"fallback nested":       {map[string]interface{}{"type": "save_replay", "on_error": "goto", "fallback": []interface{}{map[string]interface{}{}}}, "action 0.fallback.0 missing 'type'"},
+		"retry unknown field":   {map[string]interface{}{"type": "save_replay", "retry": map[string]interface{}{"attempts": float64(3)}}, "'retry' must be an object"},
+		"retry too many":        {map[string]interface{}{"type": "save_replay", "retry": map[string]interface{}{"max_attempts": float64(20)}}, "'max_attempts' must be between"},
+		"retry on if":           {map[string]interface{}{"type": "if", "condition": "true", "then": []interface{}{map[string]interface{}{"type": "save_replay"}}, "retry": map[string]interface{}{}}, "cannot have a retry policy"},
+		"retry on http_request": {map[string]interface{}{"type": "http_request", "parameters": map[string]interface{}{"url": "https://example.com"}, "retry": map[string]interface{}{}}, "use its 'retries'"},
+		"timeout not a number":  {map[string]interface{}{"type": "save_replay", "timeout_ms": "5s"}, "whole number of milliseconds"},
+		"timeout too long":      {map[string]interface{}{"type": "save_replay", "timeout_ms": float64(3600000)}, "'timeout_ms' must be between"},
+		"missing type":          {map[string]interface{}{}, "missing 'type'"},
+		"unknown type":          {map[string]interface{}{"type": "explode"}, "unknown action type"},
+		"bad on_missing":        {map[string]interface{}{"type": "set_scene", "on_missing": "guess"}, "invalid on_missing"},
+		"unknown field":         {map[string]interface{}{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "{{obs.scene}}"}}, "unknown placeholder"},
+		"unterminated tag":      {map[string]interface{}{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "{{event.scene_name"}}, "unterminated"},
+		"if without condition":  {map[string]interface{}{"type": "if", "then": []interface{}{map[string]interface{}{"type": "save_replay"}}}, "requires a 'condition'"},
+		"if bad condition":      {map[string]interface{}{"type": "if", "condition": "obs.streaming ==", "then": []interface{}{map[string]interface{}{"type": "save_replay"}}}, "unexpected end"},
+		"if without branches":   {map[string]interface{}{"type": "if", "condition": "true"}, "needs a 'then' or 'else'"},
+		"if branch not a list":  {map[string]interface{}{"type": "if", "condition": "true", "then": "save_replay"}, "must be a list"},
+		"if nested error":       {map[string]interface{}{"type": "if", "condition": "true", "else": []interface{}{map[string]interface{}{"type": "explode"}}}, "unknown action type"},
+		"if nested missing":     {map[string]interface{}{"type": "if", "condition": "true", "then": []interface{}{map[string]interface{}{}}}, "action 0.then.0 missing 'type'"},
+		"parallel no branches":  {map[string]interface{}{"type": "parallel"}, "requires 'branches'"},
+		"parallel empty branch": {map[string]interface{}{"type": "parallel", "branches": []interface{}{[]interface{}{}}}, "branch 0 has no actions"},
+		"parallel flat list":    {map[string]interface{}{"type": "parallel", "branches": []interface{}{map[string]interface{}{"type": "save_replay"}}}, "must be a list of actions"},
+		"parallel too many":     {map[string]interface{}{"type": "parallel", "branches": tooMany}, "the maximum is 10"},
+		"parallel nested":       {map[string]interface{}{"type": "parallel", "branches": []interface{}{[]interface{}{map[string]interface{}{}}}}, "action 0.branches.0.0 missing 'type'"},
+		"parallel timeout":      {map[string]interface{}{"type": "parallel", "branches": []interface{}{[]interface{}{map[string]interface{}{"type": "save_replay"}}}, "timeout_ms": float64(100)}, "cannot have a timeout"},
+		"repeat no actions":     {map[string]interface{}{"type": "repeat", "count": float64(2)}, "requires an 'actions' list"},
+		"repeat no count":       {map[string]interface{}{"type": "repeat", "actions": []interface{}{map[string]interface{}{"type": "save_replay"}}}, "requires a 'count', an 'until'"},
+		"repeat count too big":  {map[string]interface{}{"type": "repeat", "count": float64(1000), "actions": []interface{}{map[string]interface{}{"type": "save_replay"}}}, "'count' must be a whole number"},
+		"repeat bad until":      {map[string]interface{}{"type": "repeat", "until": "obs.streaming ==", "actions": []interface{}{map[string]interface{}{"type": "save_replay"}}}, "'until'"},
+		"http without url":      {map[string]interface{}{"type": "http_request", "parameters": map[string]interface{}{"body": "hi"}}, "requires 'url'"},
+		"http bad method":       {map[string]interface{}{"type": "http_request", "parameters": map[string]interface{}{"url": "http://x", "method": "TRACE"}}, "'method' must be one of"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseRuleActions([]map[string]interface{}{tc.action})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestBuildAutomationRuleEventFilter(t *testing.T) {
+	input := CreateAutomationRuleInput{
+		Name:        "filtered",
+		TriggerType: automation.TriggerTypeEvent,
+		TriggerConfig: map[string]interface{}{
+			"event_type": automation.EventSceneChanged,
+			"event_filter": map[string]interface{}{
+				"scene_name": map[string]interface{}{"in": []interface{}{"BRB", "Starting Soon"}},
+			},
+		},
+		Actions: []map[string]interface{}{{"type": "save_replay"}},
+	}
+	_, err := buildAutomationRule(input)
+	require.NoError(t, err)
+
+	input.TriggerConfig["event_filter"] = map[string]interface{}{"scene_name": map[string]interface{}{"like": "BRB%"}}
+	_, err = buildAutomationRule(input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown operator 'like'")
+
+	input.TriggerConfig["event_filter"] = "scene_name == BRB"
+	_, err = buildAutomationRule(input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "event_filter must be an object")
+}
+
+func TestBuildAutomationRuleWebhook(t *testing.T) {
+	input := CreateAutomationRuleInput{
+		Name:        "deck",
+		TriggerType: automation.TriggerTypeWebhook,
+		TriggerConfig: map[string]interface{}{
+			"secret":       "0123456789abcdef-secret",
+			"body_mapping": map[string]interface{}{"scene_name": "scene"},
+		},
+		Actions: []map[string]interface{}{{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "{{scene_name}}"}}},
+	}
+	rule, err := buildAutomationRule(input)
+	require.NoError(t, err)
+
+	redacted := redactTriggerConfig(rule.TriggerConfig)
+	assert.Equal(t, "********", redacted["secret"])
+	assert.Equal(t, "0123456789abcdef-secret", rule.TriggerConfig["secret"], "the stored config is not changed")
+
+	input.TriggerConfig = map[string]interface{}{"secret": "short"}
+	_, err = buildAutomationRule(input)
+	assert.ErrorContains(t, err, "at least 16")
+
+	_, err = applyAutomationRuleUpdate(rule, UpdateAutomationRuleInput{TriggerConfig: map[string]interface{}{"secret": "********"}})
+	assert.ErrorContains(t, err, "at least 16", "a redacted secret cannot be saved back")
+}
+
+func TestAutomationRuleHistoryRedactsSecret(t *testing.T) {
+	server, db := testServerWithAutomation(t)
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, nil)
+
+	webhookRule := func(name, secret string) map[string]any {
+		return map[string]any{
+			"name":           name,
+			"trigger_type":   "webhook",
+			"trigger_config": map[string]any{"secret": secret},
+			"actions":        []any{map[string]any{"type": "start_recording"}},
+		}
+	}
+
+	res := callTool(t, session, "create_automation_rule", webhookRule("deck", "created-secret-0123456789"))
+	require.False(t, res.IsError, toolResultText(res))
+	dryRun := webhookRule("deck-2", "dry-run-secret-0123456789")
+	dryRun["dry_run"] = true
+	res = callTool(t, session, "create_automation_rule", dryRun)
+	require.False(t, res.IsError, toolResultText(res))
+	res = callTool(t, session, "update_automation_rule", map[string]any{
+		"name":           "deck",
+		"trigger_config": map[string]any{"secret": "updated-secret-0123456789"},
+	})
+	require.False(t, res.IsError, toolResultText(res))
+	res = callTool(t, session, "execute_batch", map[string]any{"steps": []any{
+		map[string]any{"tool": "create_automation_rule", "arguments": webhookRule("deck-3", "batch-secret-0123456789")},
+	}})
+	require.False(t, res.IsError, toolResultText(res))
+
+	records, err := db.GetRecentActions(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, records, 4, "create, dry run, update and batch")
+	steps, err := db.GetChildActions(context.Background(), records[0].ID)
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+	records = append(records, steps...)
+	for _, record := range records {
+		for _, secret := range []string{"created-secret", "dry-run-secret", "updated-secret", "batch-secret"} {
+			assert.NotContains(t, record.Input, secret, record.ToolName)
+			assert.NotContains(t, record.Output, secret, record.ToolName)
+		}
+	}
+
+	// The rules themselves keep their secrets
+	rule, err := db.GetAutomationRuleByName(context.Background(), "deck-3")
+	require.NoError(t, err)
+	assert.Equal(t, "batch-secret-0123456789", rule.TriggerConfig["secret"])
+}
+
+func TestBuildAutomationRuleConcurrency(t *testing.T) {
+	input := CreateAutomationRuleInput{
+		Name:          "intermission",
+		TriggerType:   automation.TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions:       []map[string]interface{}{{"type": "save_replay"}},
+		Concurrency:   automation.ConcurrencyQueue,
+	}
+	rule, err := buildAutomationRule(input)
+	require.NoError(t, err)
+	assert.Equal(t, automation.ConcurrencyQueue, rule.Concurrency)
+
+	input.Concurrency = "skip-if-running"
+	_, err = buildAutomationRule(input)
+	assert.ErrorContains(t, err, "invalid concurrency 'skip-if-running'")
+
+	restart := automation.ConcurrencyRestart
+	updated, err := applyAutomationRuleUpdate(rule, UpdateAutomationRuleInput{Concurrency: &restart})
+	require.NoError(t, err)
+	assert.Equal(t, automation.ConcurrencyRestart, updated.Concurrency)
+
+	invalid := "serial"
+	_, err = applyAutomationRuleUpdate(rule, UpdateAutomationRuleInput{Concurrency: &invalid})
+	assert.ErrorContains(t, err, "invalid concurrency")
+}
+
+func TestSimulateAutomationEvent(t *testing.T) {
+	server, _ := testServerWithAutomation(t,
+		storage.AutomationRule{
+			Name:          "follow-scene",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeEvent,
+			TriggerConfig: map[string]interface{}{"event_type": automation.EventSceneChanged},
+			Actions: []storage.RuleAction{
+				{Type: automation.ActionTypeSetPreviewScene, Parameters: map[string]interface{}{"scene_name": "{{event.scene_name}}"}},
+			},
+		},
+		storage.AutomationRule{
+			Name:          "brb-only",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeEvent,
+			TriggerConfig: map[string]interface{}{"event_type": automation.EventSceneChanged, "event_filter": map[string]interface{}{"scene_name": "BRB"}},
+			Actions:       []storage.RuleAction{{Type: automation.ActionTypeSaveReplay}},
+		},
+	)
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, nil)
+
+	res := callTool(t, session, "simulate_automation_event", map[string]any{
+		"event_type": "scene_changed",
+		"data":       map[string]any{"scene_name": "Gaming"},
+	})
+	require.False(t, res.IsError, toolResultText(res))
+
+	var result SimulateAutomationEventResult
+	data, err := json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &result))
+
+	assert.Equal(t, 1, result.FiredCount)
+	require.Len(t, result.Fired, 1)
+	assert.Equal(t, "follow-scene", result.Fired[0].RuleName)
+	require.Len(t, result.Fired[0].Actions, 1)
+	assert.Equal(t, map[string]interface{}{"scene_name": "Gaming"}, result.Fired[0].Actions[0]["parameters"])
+	require.Len(t, result.Skipped, 1)
+	assert.Contains(t, result.Skipped[0].Reason, "filter")
+
+	res = callTool(t, session, "simulate_automation_event", map[string]any{"event_type": "nonsense"})
+	assert.True(t, res.IsError)
+	assert.Contains(t, toolResultText(res), "unknown event type")
+}
+
+func TestCancelRuleExecution(t *testing.T) {
+	server, db := testServerWithAutomation(t,
+		storage.AutomationRule{
+			Name:          "long-intermission",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions: []storage.RuleAction{
+				{Type: automation.ActionTypeDelay, Parameters: map[string]interface{}{"delay_ms": float64(60000)}},
+				{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Live"}},
+			},
+		},
+	)
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, nil)
+
+	res := callTool(t, session, "trigger_automation_rule", map[string]any{"name": "long-intermission"})
+	require.False(t, res.IsError, toolResultText(res))
+
+	var running RunningExecutionListResult
+	require.Eventually(t, func() bool {
+		res := callTool(t, session, "list_running_executions", map[string]any{"rule_name": "long-intermission"})
+		require.False(t, res.IsError, toolResultText(res))
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &running))
+		return running.Count == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	exec := running.Executions[0]
+	assert.Equal(t, 0, exec.ActionIndex)
+	assert.Equal(t, automation.ActionTypeDelay, exec.ActionType)
+	assert.Equal(t, 2, exec.ActionCount)
+
+	res = callTool(t, session, "cancel_rule_execution", map[string]any{"execution_id": exec.ExecutionID})
+	require.False(t, res.IsError, toolResultText(res))
+
+	require.Eventually(t, func() bool {
+		executions, err := db.GetRecentRuleExecutions(context.Background(), 1)
+		return err == nil && len(executions) == 1 && executions[0].Status == storage.ExecutionStatusCancelled
+	}, time.Second, 10*time.Millisecond)
+
+	res = callTool(t, session, "cancel_rule_execution", map[string]any{"execution_id": exec.ExecutionID})
+	assert.True(t, res.IsError)
+	assert.Contains(t, toolResultText(res), "not running")
+}
+
+func TestBuildAutomationRuleSchedule(t *testing.T) {
+	input := CreateAutomationRuleInput{
+		Name:        "scheduled",
+		TriggerType: automation.TriggerTypeSchedule,
+		TriggerConfig: map[string]interface{}{
+			"schedule":         "0 20 * * 5",
+			"timezone":         "Europe/Berlin",
+			"blackout_windows": []interface{}{map[string]interface{}{"days": []interface{}{"fri"}, "start": "22:00", "end": "23:00"}},
+		},
+		Actions: []map[string]interface{}{{"type": "save_replay"}},
+	}
+	_, err := buildAutomationRule(input)
+	require.NoError(t, err)
+
+	input.TriggerConfig = map[string]interface{}{"at": time.Now().Add(-time.Hour).Format(time.RFC3339)}
+	_, err = buildAutomationRule(input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is in the past")
+
+	input.TriggerConfig = map[string]interface{}{"after_event": automation.EventStreamingStarted}
+	_, err = buildAutomationRule(input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "'delay_seconds'")
+}
+
+func TestBuildAutomationRuleComposite(t *testing.T) {
+	input := CreateAutomationRuleInput{
+		Name:        "mic-trouble",
+		TriggerType: automation.TriggerTypeComposite,
+		TriggerConfig: map[string]interface{}{
+			"mode":           "count",
+			"events":         []interface{}{map[string]interface{}{"event_type": automation.EventInputMuteChanged, "event_filter": map[string]interface{}{"muted": true}}},
+			"count":          3.0,
+			"window_seconds": 60.0,
+		},
+		Actions: []map[string]interface{}{{"type": "save_replay"}},
+	}
+	rule, err := buildAutomationRule(input)
+	require.NoError(t, err)
+	assert.Equal(t, automation.TriggerTypeComposite, rule.TriggerType)
+
+	input.TriggerConfig = map[string]interface{}{"mode": "sequence", "events": []interface{}{map[string]interface{}{"event_type": automation.EventStreamingStarted}}}
+	_, err = buildAutomationRule(input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least 2 events")
+
+	_, err = applyAutomationRuleUpdate(rule, UpdateAutomationRuleInput{
+		Name:          "mic-trouble",
+		TriggerConfig: map[string]interface{}{"mode": "count", "events": input.TriggerConfig["events"], "count": 3.0},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires 'window_seconds'")
+}
+
+func TestGetNextRuns(t *testing.T) {
+	server, _ := testServerWithAutomation(t,
+		storage.AutomationRule{
+			Name:          "hourly",
+			Enabled:       false,
+			TriggerType:   automation.TriggerTypeSchedule,
+			TriggerConfig: map[string]interface{}{"schedule": "0 * * * *", "timezone": "Asia/Tokyo"},
+			Actions:       []storage.RuleAction{{Type: automation.ActionTypeSaveReplay}},
+		},
+		storage.AutomationRule{
+			Name:          "after-stream",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeSchedule,
+			TriggerConfig: map[string]interface{}{"after_event": automation.EventStreamingStarted, "delay_seconds": 600.0},
+			Actions:       []storage.RuleAction{{Type: automation.ActionTypeSaveReplay}},
+		},
+		storage.AutomationRule{
+			Name:          "manual",
+			Enabled:       true,
+			TriggerType:   automation.TriggerTypeManual,
+			TriggerConfig: map[string]interface{}{},
+			Actions:       []storage.RuleAction{{Type: automation.ActionTypeSaveReplay}},
+		},
+	)
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, nil)
+
+	decode := func(res *mcpsdk.CallToolResult) NextRunsResult {
+		t.Helper()
+		require.False(t, res.IsError, toolResultText(res))
+		var result NextRunsResult
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &result))
+		return result
+	}
+
+	result := decode(callTool(t, session, "get_next_runs", map[string]any{"name": "hourly", "count": 3}))
+	assert.Equal(t, "Asia/Tokyo", result.TimeZone)
+	require.Len(t, result.NextRuns, 3)
+	assert.Contains(t, result.NextRuns[0].Time, ":00:00+09:00")
+	assert.LessOrEqual(t, result.NextRuns[0].InSeconds, int64(3600))
+	assert.Contains(t, result.Message, "disabled")
+
+	result = decode(callTool(t, session, "get_next_runs", map[string]any{"name": "after-stream"}))
+	assert.Empty(t, result.NextRuns)
+
+	server.automationEngine.HandleEvent(automation.EventPayload{EventType: automation.EventStreamingStarted})
+	require.Eventually(t, func() bool {
+		return decode(callTool(t, session, "get_next_runs", map[string]any{"name": "after-stream"})).Count == 1
+	}, time.Second, 10*time.Millisecond)
+
+	res := callTool(t, session, "get_next_runs", map[string]any{"name": "manual"})
+	assert.True(t, res.IsError)
+	assert.Contains(t, toolResultText(res), "only schedule rules have fire times")
+}
@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Bundle tool input types
+
+// ExportAutomationBundleInput is the input for exporting a bundle.
+type ExportAutomationBundleInput struct {
+	Rules          []string `json:"rules,omitempty" jsonschema:"Names of the rules to export (default: all rules). Macros they call are included"`
+	Presets        []string `json:"presets,omitempty" jsonschema:"Names of scene presets to include besides those for scenes the rules switch to"`
+	Format         string   `json:"format,omitempty" jsonschema:"Bundle format: 'json' (default) or 'yaml'"`
+	IncludeSecrets bool     `json:"include_secrets,omitempty" jsonschema:"Keep webhook secrets in the bundle (default: false)"`
+}
+
+// ImportAutomationBundleInput is the input for importing a bundle.
+type ImportAutomationBundleInput struct {
+	Bundle   string `json:"bundle" jsonschema:"Bundle document, JSON or YAML, as produced by export_automation_bundle"`
+	Strategy string `json:"strategy,omitempty" jsonschema:"What to do when a rule, macro or preset name is taken: 'skip' (default), 'rename', or 'overwrite'"`
+	DryRun   bool   `json:"dry_run,omitempty" jsonschema:"Validate the bundle and report what would be imported without saving anything"`
+}
+
+// handleExportAutomationBundle exports rules, macros and presets as a bundle.
+func (s *Server) handleExportAutomationBundle(ctx context.Context, request *mcpsdk.CallToolRequest, input ExportAutomationBundleInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Exporting automation bundle (rules=%v, format=%s)", input.Rules, input.Format)
+
+	format := input.Format
+	if format == "" {
+		format = BundleFormatJSON
+	}
+
+	bundle, err := ExportBundle(ctx, s.storage, ExportOptions{
+		Rules:          input.Rules,
+		Presets:        input.Presets,
+		IncludeSecrets: input.IncludeSecrets,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export automation bundle: %w", err)
+	}
+
+	data, err := EncodeBundle(bundle, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := map[string]interface{}{
+		"bundle":       string(data),
+		"format":       format,
+		"version":      bundle.Version,
+		"rule_count":   len(bundle.Rules),
+		"macro_count":  len(bundle.Macros),
+		"preset_count": len(bundle.Presets),
+		"message": fmt.Sprintf("Exported %d rules, %d macros and %d scene presets",
+			len(bundle.Rules), len(bundle.Macros), len(bundle.Presets)),
+	}
+
+	// The bundle itself is not kept in action history
+	summary := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		if k != "bundle" {
+			summary[k] = v
+		}
+	}
+	s.recordAction(ctx, "export_automation_bundle", "Export automation bundle", input, summary, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleImportAutomationBundle imports a bundle, reporting the outcome of
+// each item and the scenes and inputs missing in OBS.
+func (s *Server) handleImportAutomationBundle(ctx context.Context, request *mcpsdk.CallToolRequest, input ImportAutomationBundleInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Importing automation bundle (strategy=%s, dry_run=%v)", input.Strategy, input.DryRun)
+
+	bundle, err := DecodeBundle([]byte(input.Bundle))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := ImportOptions{Strategy: input.Strategy, DryRun: input.DryRun}
+	if s.obsClient != nil && s.obsClient.IsConnected() {
+		opts.OBS = s.obsClient
+	}
+
+	result, err := ImportBundle(ctx, s.storage, bundle, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to import automation bundle: %w", err)
+	}
+
+	// Load the imported rules into the engine
+	if s.automationEngine != nil && s.automationEngine.IsRunning() {
+		for _, id := range result.ruleIDs {
+			s.automationEngine.NotifyRuleChange(id, false)
+		}
+	}
+
+	// The bundle itself is not kept in action history
+	record := input
+	record.Bundle = ""
+	if input.DryRun {
+		s.recordDryRun(ctx, "import_automation_bundle", "Import automation bundle", record, result, result.Failed == 0, time.Since(start))
+	} else {
+		s.recordAction(ctx, "import_automation_bundle", "Import automation bundle", record, result, result.Failed == 0, time.Since(start))
+	}
+	return nil, result, nil
+}
@@ -0,0 +1,399 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Macro tool input types
+
+// ListMacrosInput is the input for listing macros.
+type ListMacrosInput struct{}
+
+// GetMacroInput is the input for getting a macro.
+type GetMacroInput struct {
+	Name string `json:"name" jsonschema:"Name of the macro to retrieve"`
+}
+
+// MacroParameterInput declares a parameter a macro takes.
+type MacroParameterInput struct {
+	Name        string      `json:"name" jsonschema:"Parameter name, read by the macro's actions as {{args.<name>}}"`
+	Description string      `json:"description,omitempty" jsonschema:"What the parameter is for"`
+	Required    bool        `json:"required,omitempty" jsonschema:"Whether callers must pass the argument (default: false)"`
+	Default     interface{} `json:"default,omitempty" jsonschema:"Value used when an optional argument is not passed"`
+}
+
+// CreateMacroInput is the input for creating a macro.
+type CreateMacroInput struct {
+	Name        string                   `json:"name" jsonschema:"Unique name for the macro"`
+	Description string                   `json:"description,omitempty" jsonschema:"Description of what the macro does"`
+	Parameters  []MacroParameterInput    `json:"parameters,omitempty" jsonschema:"Parameters the macro takes"`
+	Actions     []map[string]interface{} `json:"actions" jsonschema:"List of actions to execute, in the same format as automation rule actions. String parameters may contain {{args.<name>}} placeholders for the macro's parameters"`
+}
+
+// UpdateMacroInput is the input for updating a macro.
+type UpdateMacroInput struct {
+	Name        string                   `json:"name" jsonschema:"Name of the macro to update"`
+	NewName     string                   `json:"new_name,omitempty" jsonschema:"New name for the macro"`
+	Description *string                  `json:"description,omitempty" jsonschema:"New description"`
+	Parameters  *[]MacroParameterInput   `json:"parameters,omitempty" jsonschema:"New list of parameters (replaces the existing list)"`
+	Actions     []map[string]interface{} `json:"actions,omitempty" jsonschema:"New list of actions"`
+}
+
+// DeleteMacroInput is the input for deleting a macro.
+type DeleteMacroInput struct {
+	Name string `json:"name" jsonschema:"Name of the macro to delete"`
+}
+
+// RunMacroInput is the input for running a macro.
+type RunMacroInput struct {
+	Name string                 `json:"name" jsonschema:"Name of the macro to run"`
+	Args map[string]interface{} `json:"args,omitempty" jsonschema:"Arguments for the macro's parameters"`
+}
+
+// macroParameters converts parameter inputs to storage parameters.
+func macroParameters(inputs []MacroParameterInput) []storage.MacroParameter {
+	params := make([]storage.MacroParameter, len(inputs))
+	for i, p := range inputs {
+		params[i] = storage.MacroParameter(p)
+	}
+	return params
+}
+
+// validateMacro checks a macro's name, parameters and actions.
+func validateMacro(macro storage.Macro) error {
+	if strings.TrimSpace(macro.Name) == "" {
+		return fmt.Errorf("macro name is required")
+	}
+	if len(macro.Actions) == 0 {
+		return fmt.Errorf("at least one action is required")
+	}
+	if err := automation.ConvertStorageMacro(&macro).Validate(); err != nil {
+		return fmt.Errorf("invalid macro '%s': %w", macro.Name, err)
+	}
+	return nil
+}
+
+// buildMacro validates the create input and converts it into a storage
+// macro.
+func buildMacro(input CreateMacroInput) (storage.Macro, error) {
+	actions, err := parseRuleActions(input.Actions)
+	if err != nil {
+		return storage.Macro{}, err
+	}
+
+	macro := storage.Macro{
+		Name:        input.Name,
+		Description: input.Description,
+		Parameters:  macroParameters(input.Parameters),
+		Actions:     actions,
+	}
+	if err := validateMacro(macro); err != nil {
+		return storage.Macro{}, err
+	}
+	return macro, nil
+}
+
+// applyMacroUpdate returns existing with the provided update fields applied,
+// validating the result.
+func applyMacroUpdate(existing storage.Macro, input UpdateMacroInput) (storage.Macro, error) {
+	updated := existing
+
+	if input.NewName != "" {
+		updated.Name = input.NewName
+	}
+	if input.Description != nil {
+		updated.Description = *input.Description
+	}
+	if input.Parameters != nil {
+		updated.Parameters = macroParameters(*input.Parameters)
+	}
+	if input.Actions != nil {
+		actions, err := parseRuleActions(input.Actions)
+		if err != nil {
+			return storage.Macro{}, err
+		}
+		updated.Actions = actions
+	}
+
+	if err := validateMacro(updated); err != nil {
+		return storage.Macro{}, err
+	}
+	return updated, nil
+}
+
+// macroParameterMaps converts parameters to response format.
+func macroParameterMaps(params []storage.MacroParameter) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(params))
+	for i, p := range params {
+		out[i] = map[string]interface{}{
+			"name":     p.Name,
+			"required": p.Required,
+		}
+		if p.Description != "" {
+			out[i]["description"] = p.Description
+		}
+		if p.Default != nil {
+			out[i]["default"] = p.Default
+		}
+	}
+	return out
+}
+
+// handleListMacros lists all macros.
+func (s *Server) handleListMacros(ctx context.Context, request *mcpsdk.CallToolRequest, input ListMacrosInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Println("Listing macros")
+
+	macros, err := s.storage.ListMacros(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list macros: %w", err)
+	}
+
+	macroList := make([]map[string]interface{}, len(macros))
+	for i, macro := range macros {
+		names := make([]string, len(macro.Parameters))
+		for j, p := range macro.Parameters {
+			names[j] = p.Name
+		}
+		macroList[i] = map[string]interface{}{
+			"id":           macro.ID,
+			"name":         macro.Name,
+			"description":  macro.Description,
+			"parameters":   names,
+			"action_count": len(macro.Actions),
+			"updated_at":   macro.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+
+	result := map[string]interface{}{
+		"macros":  macroList,
+		"count":   len(macros),
+		"message": fmt.Sprintf("Found %d macros", len(macros)),
+	}
+
+	s.recordAction(ctx, "list_macros", "List macros", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleGetMacro retrieves a macro by name.
+func (s *Server) handleGetMacro(ctx context.Context, request *mcpsdk.CallToolRequest, input GetMacroInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Getting macro: %s", input.Name)
+
+	macro, err := s.storage.GetMacroByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get macro: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":          macro.ID,
+		"name":        macro.Name,
+		"description": macro.Description,
+		"parameters":  macroParameterMaps(macro.Parameters),
+		"actions":     ruleActionMaps(macro.Actions),
+		"created_at":  macro.CreatedAt.Format(time.RFC3339),
+		"updated_at":  macro.UpdatedAt.Format(time.RFC3339),
+	}
+
+	s.recordAction(ctx, "get_macro", "Get macro", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleCreateMacro creates a new macro.
+func (s *Server) handleCreateMacro(ctx context.Context, request *mcpsdk.CallToolRequest, input CreateMacroInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Creating macro: %s", input.Name)
+
+	macro, err := buildMacro(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := s.storage.CreateMacro(ctx, macro)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create macro: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":      id,
+		"name":    macro.Name,
+		"message": fmt.Sprintf("Macro '%s' created successfully", macro.Name),
+	}
+
+	s.recordAction(ctx, "create_macro", "Create macro", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleUpdateMacro updates an existing macro.
+func (s *Server) handleUpdateMacro(ctx context.Context, request *mcpsdk.CallToolRequest, input UpdateMacroInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Updating macro: %s", input.Name)
+
+	existing, err := s.storage.GetMacroByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get macro: %w", err)
+	}
+
+	updated, err := applyMacroUpdate(*existing, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.storage.UpdateMacro(ctx, updated); err != nil {
+		return nil, nil, fmt.Errorf("failed to update macro: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":      existing.ID,
+		"name":    updated.Name,
+		"message": fmt.Sprintf("Macro '%s' updated successfully", updated.Name),
+	}
+
+	s.recordAction(ctx, "update_macro", "Update macro", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleDeleteMacro deletes a macro. Rules that still call it fail at their
+// run_macro action until it is recreated.
+func (s *Server) handleDeleteMacro(ctx context.Context, request *mcpsdk.CallToolRequest, input DeleteMacroInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Deleting macro: %s", input.Name)
+
+	macro, err := s.storage.GetMacroByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get macro: %w", err)
+	}
+
+	// Require confirmation via elicitation, as for automation rules
+	confirmed, err := ElicitDeleteConfirmation(ctx, getSession(request), "macro", input.Name)
+	if err != nil {
+		log.Printf("Elicitation error: %v", err)
+		return nil, nil, fmt.Errorf("delete confirmation unavailable: %w", err)
+	}
+	if !confirmed {
+		return nil, map[string]interface{}{
+			"cancelled": true,
+			"message":   "Deletion cancelled by user",
+		}, nil
+	}
+
+	if err := s.storage.DeleteMacroByName(ctx, input.Name); err != nil {
+		return nil, nil, fmt.Errorf("failed to delete macro: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"id":      macro.ID,
+		"deleted": true,
+		"name":    input.Name,
+		"message": fmt.Sprintf("Macro '%s' deleted successfully", input.Name),
+	}
+
+	s.recordAction(ctx, "delete_macro", "Delete macro", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleRunMacro runs a macro with arguments and returns the result of each
+// of its actions.
+func (s *Server) handleRunMacro(ctx context.Context, request *mcpsdk.CallToolRequest, input RunMacroInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Running macro: %s", input.Name)
+
+	if s.automationEngine == nil {
+		return nil, nil, fmt.Errorf("automation engine is not available")
+	}
+
+	if !s.automationEngine.IsRunning() {
+		return nil, nil, fmt.Errorf("automation engine is not running")
+	}
+
+	results, err := s.automationEngine.RunMacro(ctx, input.Name, input.Args)
+	if err != nil && results == nil {
+		// The macro never started: unknown name or bad arguments
+		return nil, nil, fmt.Errorf("failed to run macro: %w", err)
+	}
+
+	resultMaps, convErr := actionResultMaps(results)
+	if convErr != nil {
+		return nil, nil, convErr
+	}
+
+	// Actions that continue on error leave the run going, so a failed
+	// action does not always surface as err
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	} else {
+		for _, r := range results {
+			if !r.Success {
+				errMsg = r.Error
+				break
+			}
+		}
+	}
+
+	durationMs := time.Since(start).Milliseconds()
+	success := err == nil && errMsg == ""
+	result := map[string]interface{}{
+		"macro":       input.Name,
+		"success":     success,
+		"results":     resultMaps,
+		"duration_ms": durationMs,
+		"message":     fmt.Sprintf("Macro '%s' completed in %dms", input.Name, durationMs),
+	}
+	if !success {
+		result["error"] = errMsg
+		result["message"] = fmt.Sprintf("Macro '%s' failed: %s", input.Name, errMsg)
+	}
+
+	s.recordAction(ctx, "run_macro", "Run macro", input, result, success, time.Since(start))
+	return nil, result, nil
+}
+
+// actionResultMaps converts action results to response format, keeping the
+// nested results of control and macro actions.
+func actionResultMaps(results []automation.ActionResult) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize action results: %w", err)
+	}
+	out := []map[string]interface{}{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to serialize action results: %w", err)
+	}
+	return out, nil
+}
+
+// macroRuns returns the names of the macros run by an execution's actions,
+// with nested calls written as "outer > inner".
+func macroRuns(results []storage.ActionResult, caller string) []string {
+	var runs []string
+	for _, r := range results {
+		if r.Macro != "" {
+			name := r.Macro
+			if caller != "" {
+				name = caller + " > " + r.Macro
+			}
+			runs = append(runs, name)
+			runs = append(runs, macroRuns(r.Results, name)...)
+		} else {
+			runs = append(runs, macroRuns(r.Results, caller)...)
+		}
+		runs = append(runs, macroRuns(r.Fallback, caller)...)
+		for _, branch := range r.Branches {
+			runs = append(runs, macroRuns(branch, caller)...)
+		}
+		for _, iteration := range r.Iterations {
+			runs = append(runs, macroRuns(iteration, caller)...)
+		}
+	}
+	return runs
+}
@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMacro(t *testing.T) {
+	input := CreateMacroInput{
+		Name:       "go-to",
+		Parameters: []MacroParameterInput{{Name: "scene", Required: true}},
+		Actions: []map[string]interface{}{
+			{"type": "set_scene", "parameters": map[string]interface{}{"scene_name": "{{args.scene}}"}},
+		},
+	}
+	macro, err := buildMacro(input)
+	require.NoError(t, err)
+	assert.Equal(t, "scene", macro.Parameters[0].Name)
+	assert.True(t, macro.Parameters[0].Required)
+
+	input.Parameters = nil
+	_, err = buildMacro(input)
+	assert.ErrorContains(t, err, "undeclared parameters: scene")
+
+	input.Parameters = []MacroParameterInput{{Name: "scene"}, {Name: "scene"}}
+	_, err = buildMacro(input)
+	assert.ErrorContains(t, err, "declared twice")
+
+	input.Parameters = []MacroParameterInput{{Name: "scene name"}}
+	_, err = buildMacro(input)
+	assert.ErrorContains(t, err, "invalid parameter name")
+
+	_, err = buildMacro(CreateMacroInput{Name: "empty"})
+	assert.ErrorContains(t, err, "at least one action")
+
+	_, err = buildMacro(CreateMacroInput{Name: "bad-call", Actions: []map[string]interface{}{{"type": "run_macro"}}})
+	assert.ErrorContains(t, err, "requires 'macro' parameter")
+}
+
+func TestMacroTools(t *testing.T) {
+	server, db := testServerWithAutomation(t, storage.AutomationRule{
+		Name:          "break",
+		Enabled:       true,
+		TriggerType:   automation.TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: automation.ActionTypeRunMacro, Parameters: map[string]interface{}{"macro": "go-to", "args": map[string]interface{}{"scene": "Gaming"}}},
+		},
+	})
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, &mcpsdk.ClientOptions{
+		ElicitationHandler: func(ctx context.Context, req *mcpsdk.ElicitRequest) (*mcpsdk.ElicitResult, error) {
+			return &mcpsdk.ElicitResult{Action: "accept", Content: map[string]any{"confirmed": true}}, nil
+		},
+	})
+
+	decode := func(res *mcpsdk.CallToolResult, out any) {
+		t.Helper()
+		require.False(t, res.IsError, toolResultText(res))
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, out))
+	}
+
+	var change MacroChangeResult
+	decode(callTool(t, session, "create_macro", map[string]any{
+		"name":       "go-to",
+		"parameters": []any{map[string]any{"name": "scene", "required": true}},
+		"actions":    []any{map[string]any{"type": "set_scene", "parameters": map[string]any{"scene_name": "{{args.scene}}"}}},
+	}), &change)
+	assert.NotZero(t, change.ID)
+
+	var details MacroDetailsResult
+	decode(callTool(t, session, "get_macro", map[string]any{"name": "go-to"}), &details)
+	require.Len(t, details.Parameters, 1)
+	assert.True(t, details.Parameters[0].Required)
+	assert.Equal(t, "set_scene", details.Actions[0].Type)
+
+	var list MacroListResult
+	decode(callTool(t, session, "list_macros", nil), &list)
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, []string{"scene"}, list.Macros[0].Parameters)
+
+	t.Run("runs with arguments", func(t *testing.T) {
+		var run RunMacroResult
+		decode(callTool(t, session, "run_macro", map[string]any{"name": "go-to", "args": map[string]any{"scene": "Gaming"}}), &run)
+		assert.True(t, run.Success)
+		require.Len(t, run.Results, 1)
+		assert.Equal(t, "set_scene", run.Results[0]["action_type"])
+
+		res := callTool(t, session, "run_macro", map[string]any{"name": "go-to"})
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "requires argument 'scene'")
+	})
+
+	t.Run("reports recursion", func(t *testing.T) {
+		decode(callTool(t, session, "create_macro", map[string]any{
+			"name":    "loop",
+			"actions": []any{map[string]any{"type": "run_macro", "parameters": map[string]any{"macro": "loop"}}},
+		}), &change)
+
+		var run RunMacroResult
+		decode(callTool(t, session, "run_macro", map[string]any{"name": "loop"}), &run)
+		assert.False(t, run.Success)
+		assert.Contains(t, run.Error, "macro 'loop' calls itself")
+	})
+
+	t.Run("lists macro runs in executions", func(t *testing.T) {
+		_, err := server.automationEngine.ExecuteRuleByName(context.Background(), "break", nil)
+		require.NoError(t, err)
+
+		var executions RuleExecutionListResult
+		decode(callTool(t, session, "list_rule_executions", map[string]any{"rule_name": "break"}), &executions)
+		require.Len(t, executions.Executions, 1)
+		assert.Equal(t, []string{"go-to"}, executions.Executions[0].MacroRuns)
+	})
+
+	t.Run("updates and deletes", func(t *testing.T) {
+		decode(callTool(t, session, "update_macro", map[string]any{"name": "go-to", "new_name": "switch-to"}), &change)
+		assert.Equal(t, "switch-to", change.Name)
+
+		decode(callTool(t, session, "delete_macro", map[string]any{"name": "switch-to"}), &change)
+		assert.True(t, change.Deleted)
+		_, err := db.GetMacroByName(context.Background(), "switch-to")
+		assert.ErrorContains(t, err, "not found")
+	})
+}
+
+func TestMacroRuns(t *testing.T) {
+	results := []storage.ActionResult{
+		{ActionType: "run_macro", Macro: "intro", Results: []storage.ActionResult{
+			{ActionType: "run_macro", Macro: "lower-third"},
+		}},
+		{ActionType: "parallel", Branches: [][]storage.ActionResult{
+			{{ActionType: "run_macro", Macro: "outro"}},
+		}},
+	}
+	assert.Equal(t, []string{"intro", "intro > lower-third", "outro"}, macroRuns(results, ""))
+}
@@ -0,0 +1,200 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Rule revision tool input types
+
+// ListRuleRevisionsInput is the input for listing a rule's revisions.
+type ListRuleRevisionsInput struct {
+	Name  string `json:"name" jsonschema:"Name of the automation rule"`
+	Limit int    `json:"limit,omitempty" jsonschema:"Maximum number of revisions to return (default: 20, max: 100)"`
+}
+
+// DiffRuleRevisionsInput is the input for comparing two revisions of a rule.
+type DiffRuleRevisionsInput struct {
+	Name string `json:"name" jsonschema:"Name of the automation rule"`
+	From int    `json:"from,omitempty" jsonschema:"Older revision to compare (default: the revision before 'to')"`
+	To   int    `json:"to,omitempty" jsonschema:"Newer revision to compare (default: the current revision)"`
+}
+
+// RestoreRuleRevisionInput is the input for restoring a rule revision.
+type RestoreRuleRevisionInput struct {
+	Name     string `json:"name" jsonschema:"Name of the automation rule"`
+	Revision int    `json:"revision" jsonschema:"Revision to restore, as listed by list_rule_revisions"`
+	DryRun   bool   `json:"dry_run,omitempty" jsonschema:"Show the changes the restore would make without saving them"`
+}
+
+// ruleRevisionSummary converts a stored revision for list output.
+func ruleRevisionSummary(rev storage.RuleRevision, current int) RuleRevisionSummary {
+	return RuleRevisionSummary{
+		Revision:     rev.Revision,
+		Change:       rev.Change,
+		RestoredFrom: rev.RestoredFrom,
+		Name:         rev.Rule.Name,
+		Enabled:      rev.Rule.Enabled,
+		ActionCount:  len(rev.Rule.Actions),
+		ActorType:    rev.ActorType,
+		ActorID:      rev.ActorID,
+		CreatedAt:    rev.CreatedAt.Format(time.RFC3339),
+		Current:      rev.Revision == current,
+	}
+}
+
+// restoredRule returns rule with the definition of rev, keeping the rule's
+// ID, enabled state and run stats, as RestoreRuleRevision does.
+func restoredRule(rule storage.AutomationRule, rev *storage.RuleRevision) storage.AutomationRule {
+	restored := rev.Rule
+	restored.ID = rule.ID
+	restored.Enabled = rule.Enabled
+	restored.CreatedAt = rule.CreatedAt
+	restored.LastRun = rule.LastRun
+	restored.RunCount = rule.RunCount
+	return restored
+}
+
+// handleListRuleRevisions lists the saved revisions of a rule, newest first.
+func (s *Server) handleListRuleRevisions(ctx context.Context, request *mcpsdk.CallToolRequest, input ListRuleRevisionsInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Listing revisions of automation rule: %s", input.Name)
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+
+	revisions, err := s.storage.ListRuleRevisions(ctx, rule.ID, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list rule revisions: %w", err)
+	}
+
+	summaries := make([]RuleRevisionSummary, 0, len(revisions))
+	for _, rev := range revisions {
+		summaries = append(summaries, ruleRevisionSummary(rev, rule.Revision))
+	}
+
+	result := RuleRevisionListResult{
+		RuleID:          rule.ID,
+		RuleName:        rule.Name,
+		CurrentRevision: rule.Revision,
+		Revisions:       summaries,
+		Count:           len(summaries),
+		Message:         fmt.Sprintf("Found %d revisions of rule '%s'", len(summaries), rule.Name),
+	}
+
+	s.recordAction(ctx, "list_rule_revisions", "List rule revisions", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleDiffRuleRevisions lists the fields that differ between two
+// revisions of a rule.
+func (s *Server) handleDiffRuleRevisions(ctx context.Context, request *mcpsdk.CallToolRequest, input DiffRuleRevisionsInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Comparing revisions of automation rule: %s (%d..%d)", input.Name, input.From, input.To)
+
+	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+
+	to := input.To
+	if to == 0 {
+		to = rule.Revision
+	}
+	from := input.From
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 {
+		return nil, nil, fmt.Errorf("revision %d is the rule's first revision; give 'from' and 'to' to compare others", to)
+	}
+
+	older, err := s.storage.GetRuleRevision(ctx, rule.ID, from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get rule revision: %w", err)
+	}
+	newer, err := s.storage.GetRuleRevision(ctx, rule.ID, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get rule revision: %w", err)
+	}
+
+	changes, err := diffFields(ruleTarget(rule.Name), "", ruleDefinition(older.Rule), ruleDefinition(newer.Rule))
+	if err != nil {
+		return nil, nil, err
+	}
+	if changes == nil {
+		changes = []PlannedChange{}
+	}
+
+	result := RuleRevisionDiffResult{
+		RuleName: rule.Name,
+		From:     ruleRevisionSummary(*older, rule.Revision),
+		To:       ruleRevisionSummary(*newer, rule.Revision),
+		Changes:  changes,
+		Message:  fmt.Sprintf("%d fields differ between revisions %d and %d of rule '%s'", len(changes), from, to, rule.Name),
+	}
+
+	s.recordAction(ctx, "diff_rule_revisions", "Diff rule revisions", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+// handleRestoreRuleRevision restores an earlier revision of a rule as a new
+// revision.
+func (s *Server) handleRestoreRuleRevision(ctx context.Context, request *mcpsdk.CallToolRequest, input RestoreRuleRevisionInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Restoring revision %d of automation rule: %s", input.Revision, input.Name)
+
+	if input.DryRun {
+		changes, err := s.planRestoreRuleRevision(ctx, input)
+		return s.dryRunResult(ctx, "restore_rule_revision", "Restore rule revision", input, changes, err, start)
+	}
+
+	rule, err := s.storage.GetAutomationRuleByName(ctx, input.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+	if input.Revision == rule.Revision {
+		return nil, nil, fmt.Errorf("revision %d is already the current revision of rule '%s'", input.Revision, rule.Name)
+	}
+
+	if err := s.storage.RestoreRuleRevision(ctx, rule.ID, input.Revision); err != nil {
+		return nil, nil, fmt.Errorf("failed to restore rule revision: %w", err)
+	}
+
+	restored, err := s.storage.GetAutomationRule(ctx, rule.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get automation rule: %w", err)
+	}
+
+	// Notify automation engine if running
+	if s.automationEngine != nil && s.automationEngine.IsRunning() {
+		s.automationEngine.NotifyRuleChange(rule.ID, false)
+	}
+
+	result := RuleRevisionRestoreResult{
+		ID:           restored.ID,
+		Name:         restored.Name,
+		Revision:     restored.Revision,
+		RestoredFrom: input.Revision,
+		Message: fmt.Sprintf("Restored revision %d of rule '%s' as revision %d",
+			input.Revision, restored.Name, restored.Revision),
+	}
+
+	s.recordAction(ctx, "restore_rule_revision", "Restore rule revision", input, result, true, time.Since(start))
+	return nil, result, nil
+}
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/automation"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleRevisionTools(t *testing.T) {
+	server, db := testServerWithAutomation(t, storage.AutomationRule{
+		Name:          "go-live",
+		Enabled:       true,
+		TriggerType:   automation.TriggerTypeManual,
+		TriggerConfig: map[string]interface{}{},
+		Actions: []storage.RuleAction{
+			{Type: automation.ActionTypeSetScene, Parameters: map[string]interface{}{"scene_name": "Gaming"}},
+		},
+	})
+	server.toolGroups = ToolGroupConfig{Automation: true}
+	session := connectTestClient(t, server, nil)
+	ctx := context.Background()
+
+	decode := func(res *mcpsdk.CallToolResult, out any) {
+		t.Helper()
+		require.False(t, res.IsError, toolResultText(res))
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, out))
+	}
+
+	res := callTool(t, session, "update_automation_rule", map[string]any{
+		"name":    "go-live",
+		"actions": []any{map[string]any{"type": "set_scene", "parameters": map[string]any{"scene_name": "Scene 1"}}},
+	})
+	require.False(t, res.IsError, toolResultText(res))
+
+	var list RuleRevisionListResult
+	decode(callTool(t, session, "list_rule_revisions", map[string]any{"name": "go-live"}), &list)
+	assert.Equal(t, 2, list.CurrentRevision)
+	require.Len(t, list.Revisions, 2)
+	assert.Equal(t, storage.RevisionUpdated, list.Revisions[0].Change)
+	assert.True(t, list.Revisions[0].Current)
+	assert.Equal(t, storage.ActorMCP, list.Revisions[0].ActorType)
+	assert.Equal(t, storage.RevisionCreated, list.Revisions[1].Change)
+
+	t.Run("diff defaults to the last change", func(t *testing.T) {
+		var diff RuleRevisionDiffResult
+		decode(callTool(t, session, "diff_rule_revisions", map[string]any{"name": "go-live"}), &diff)
+		assert.Equal(t, 1, diff.From.Revision)
+		assert.Equal(t, 2, diff.To.Revision)
+		require.Len(t, diff.Changes, 1)
+		assert.Equal(t, "actions", diff.Changes[0].Field)
+
+		res := callTool(t, session, "diff_rule_revisions", map[string]any{"name": "go-live", "to": 1})
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "first revision")
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		var dryRun DryRunResult
+		decode(callTool(t, session, "restore_rule_revision", map[string]any{"name": "go-live", "revision": 1, "dry_run": true}), &dryRun)
+		require.Len(t, dryRun.Changes, 1)
+		assert.Equal(t, "actions", dryRun.Changes[0].Field)
+
+		var restore RuleRevisionRestoreResult
+		decode(callTool(t, session, "restore_rule_revision", map[string]any{"name": "go-live", "revision": 1}), &restore)
+		assert.Equal(t, 3, restore.Revision)
+		assert.Equal(t, 1, restore.RestoredFrom)
+
+		rule, err := db.GetAutomationRuleByName(ctx, "go-live")
+		require.NoError(t, err)
+		assert.Equal(t, "Gaming", rule.Actions[0].Parameters["scene_name"])
+
+		res := callTool(t, session, "restore_rule_revision", map[string]any{"name": "go-live", "revision": 3})
+		assert.True(t, res.IsError)
+		assert.Contains(t, toolResultText(res), "already the current revision")
+	})
+
+	t.Run("executions link to the revision that ran", func(t *testing.T) {
+		_, err := server.automationEngine.ExecuteRuleByName(ctx, "go-live", nil)
+		require.NoError(t, err)
+
+		var executions RuleExecutionListResult
+		decode(callTool(t, session, "list_rule_executions", map[string]any{"rule_name": "go-live"}), &executions)
+		require.Len(t, executions.Executions, 1)
+		assert.Equal(t, 3, executions.Executions[0].Revision)
+	})
+}
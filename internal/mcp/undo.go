@@ -0,0 +1,691 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ironystock/agentic-obs/internal/obs"
+	"github.com/ironystock/agentic-obs/internal/storage"
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Undo journal for agent-made OBS changes.
+//
+// Before a mutating tool changes OBS it captures the operations that restore
+// the prior state. After the change succeeds they are stored in the undo
+// journal, keyed to the tool call's action_history row. The undo tools replay
+// them against OBSClient, newest action first. Failing to capture prior state
+// never blocks the tool call itself; the action is just not undoable.
+
+// Undo operation types. Each one maps to a single OBSClient call, except
+// restore_scene_item which rebuilds a removed scene item.
+const (
+	undoOpSetCurrentScene         = "set_current_scene"
+	undoOpSetPreviewScene         = "set_preview_scene"
+	undoOpSetStudioMode           = "set_studio_mode"
+	undoOpCreateScene             = "create_scene"
+	undoOpRemoveScene             = "remove_scene"
+	undoOpSetSceneItemEnabled     = "set_scene_item_enabled"
+	undoOpSetSceneItemTransform   = "set_scene_item_transform"
+	undoOpSetSceneItemIndex       = "set_scene_item_index"
+	undoOpSetSceneItemLocked      = "set_scene_item_locked"
+	undoOpRemoveSceneItem         = "remove_scene_item"
+	undoOpRestoreSceneItem        = "restore_scene_item"
+	undoOpSetInputMute            = "set_input_mute"
+	undoOpSetInputVolume          = "set_input_volume"
+	undoOpCreateSourceFilter      = "create_source_filter"
+	undoOpRemoveSourceFilter      = "remove_source_filter"
+	undoOpSetSourceFilterEnabled  = "set_source_filter_enabled"
+	undoOpSetSourceFilterSettings = "set_source_filter_settings"
+	undoOpSetCurrentTransition    = "set_current_transition"
+	undoOpSetTransitionDuration   = "set_transition_duration"
+)
+
+// undoParams holds the parameters of every undo operation type. Each
+// operation sets only the fields it needs; zero values are omitted when the
+// operation is stored and read back as zero values when it is replayed.
+type undoParams struct {
+	SceneName      string                  `json:"scene_name,omitempty"`
+	SceneItemID    int                     `json:"scene_item_id,omitempty"`
+	SourceName     string                  `json:"source_name,omitempty"`
+	InputKind      string                  `json:"input_kind,omitempty"`
+	InputSettings  map[string]interface{}  `json:"input_settings,omitempty"`
+	Enabled        bool                    `json:"enabled,omitempty"`
+	Locked         bool                    `json:"locked,omitempty"`
+	Index          int                     `json:"index,omitempty"`
+	Transform      *obs.SceneItemTransform `json:"transform,omitempty"`
+	InputName      string                  `json:"input_name,omitempty"`
+	Muted          bool                    `json:"muted,omitempty"`
+	VolumeMul      float64                 `json:"volume_mul,omitempty"`
+	FilterName     string                  `json:"filter_name,omitempty"`
+	FilterKind     string                  `json:"filter_kind,omitempty"`
+	FilterSettings map[string]interface{}  `json:"filter_settings,omitempty"`
+	TransitionName string                  `json:"transition_name,omitempty"`
+	DurationMs     int                     `json:"duration_ms,omitempty"`
+}
+
+// undoOp builds a journal operation from typed parameters.
+func undoOp(opType string, p undoParams) storage.UndoOperation {
+	params, err := jsonFields(p)
+	if err != nil {
+		// undoParams holds only JSON-safe values read from OBS
+		log.Printf("Warning: failed to encode %s undo parameters: %v", opType, err)
+	}
+	return storage.UndoOperation{Type: opType, Parameters: params}
+}
+
+// decodeUndoParams reads typed parameters back from a journal operation.
+func decodeUndoParams(op storage.UndoOperation) (undoParams, error) {
+	var p undoParams
+	data, err := json.Marshal(op.Parameters)
+	if err != nil {
+		return p, fmt.Errorf("failed to encode %s parameters: %w", op.Type, err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("failed to decode %s parameters: %w", op.Type, err)
+	}
+	return p, nil
+}
+
+// recordUndoableAction records a successful mutating tool call together with
+// the operations that reverse it. Without operations the call is recorded
+// like any other action. Within execute_batch the operations go to the batch,
+// which reverts or journals them as a whole.
+func (s *Server) recordUndoableAction(ctx context.Context, toolName, action string, input interface{}, output interface{}, duration time.Duration, ops []storage.UndoOperation) {
+	s.storeUndoableAction(ctx, toolName, action, input, output, true, duration, ops)
+}
+
+// recordPartialAction records a mutating tool call that failed after
+// applying part of its change, with the operations that reverse that part,
+// so undo can revert it. Within execute_batch the batch reverts them along
+// with the completed steps.
+func (s *Server) recordPartialAction(ctx context.Context, toolName, action string, input interface{}, output interface{}, duration time.Duration, ops []storage.UndoOperation) {
+	s.storeUndoableAction(ctx, toolName, action, input, output, false, duration, ops)
+}
+
+func (s *Server) storeUndoableAction(ctx context.Context, toolName, action string, input interface{}, output interface{}, success bool, duration time.Duration, ops []storage.UndoOperation) {
+	if batch := batchRecorderFrom(ctx); batch != nil {
+		s.storeAction(ctx, toolName, action, input, output, success, duration, false)
+		batch.undo = append(batch.undo, ops...)
+		return
+	}
+
+	actionID := s.storeAction(ctx, toolName, action, input, output, success, duration, false)
+	if actionID == 0 || len(ops) == 0 {
+		return
+	}
+
+	entry := storage.UndoEntry{
+		ActionID:    actionID,
+		ToolName:    toolName,
+		Description: action,
+		Operations:  ops,
+	}
+	if _, err := s.storage.RecordUndoEntry(s.ctx, entry); err != nil {
+		log.Printf("Warning: failed to record undo entry for %s: %v", toolName, err)
+	}
+}
+
+// undoOps unwraps a capture result. A failed capture is logged and leaves
+// the action without an undo entry.
+func (s *Server) undoOps(ops []storage.UndoOperation, err error) []storage.UndoOperation {
+	if err != nil {
+		log.Printf("Warning: failed to capture undo state: %v", err)
+		return nil
+	}
+	return ops
+}
+
+// =============================================================================
+// Capturing prior state
+// =============================================================================
+
+func (s *Server) captureCurrentScene() ([]storage.UndoOperation, error) {
+	_, current, err := s.obsClient.GetSceneList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current scene: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetCurrentScene, undoParams{SceneName: current})}, nil
+}
+
+func (s *Server) capturePreviewScene() ([]storage.UndoOperation, error) {
+	preview, err := s.obsClient.GetCurrentPreviewScene()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preview scene: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetPreviewScene, undoParams{SceneName: preview})}, nil
+}
+
+// captureProgramAndPreview restores both scenes swapped by a studio mode transition.
+func (s *Server) captureProgramAndPreview() ([]storage.UndoOperation, error) {
+	program, err := s.captureCurrentScene()
+	if err != nil {
+		return nil, err
+	}
+	preview, err := s.capturePreviewScene()
+	if err != nil {
+		return nil, err
+	}
+	return append(program, preview...), nil
+}
+
+func (s *Server) captureStudioMode() ([]storage.UndoOperation, error) {
+	enabled, err := s.obsClient.GetStudioModeEnabled()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get studio mode status: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetStudioMode, undoParams{Enabled: enabled})}, nil
+}
+
+// undoCreateScene removes a scene created by a tool call.
+func undoCreateScene(sceneName string) []storage.UndoOperation {
+	return []storage.UndoOperation{undoOp(undoOpRemoveScene, undoParams{SceneName: sceneName})}
+}
+
+// captureRemoveScene recreates the scene and every item in it. A removed
+// program scene is switched back to as well.
+func (s *Server) captureRemoveScene(sceneName string) ([]storage.UndoOperation, error) {
+	scene, err := s.obsClient.GetSceneByName(sceneName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scene '%s': %w", sceneName, err)
+	}
+
+	ops := []storage.UndoOperation{undoOp(undoOpCreateScene, undoParams{SceneName: sceneName})}
+	for i, item := range scene.Sources {
+		snapshot, err := s.snapshotSceneItem(sceneName, item, i)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, undoOp(undoOpRestoreSceneItem, snapshot))
+	}
+
+	_, current, err := s.obsClient.GetSceneList()
+	if err == nil && current == sceneName {
+		ops = append(ops, undoOp(undoOpSetCurrentScene, undoParams{SceneName: sceneName}))
+	}
+	return ops, nil
+}
+
+// snapshotSceneItem captures everything needed to rebuild a scene item,
+// including the input definition in case OBS released the input with it.
+func (s *Server) snapshotSceneItem(sceneName string, item obs.SceneSource, index int) (undoParams, error) {
+	transform, err := s.obsClient.GetSceneItemTransform(sceneName, item.ID)
+	if err != nil {
+		return undoParams{}, fmt.Errorf("failed to get transform of item %d: %w", item.ID, err)
+	}
+	locked, err := s.obsClient.GetSceneItemLocked(sceneName, item.ID)
+	if err != nil {
+		return undoParams{}, fmt.Errorf("failed to get locked state of item %d: %w", item.ID, err)
+	}
+
+	p := undoParams{
+		SceneName:   sceneName,
+		SceneItemID: item.ID,
+		SourceName:  item.Name,
+		InputKind:   item.Type,
+		Enabled:     item.Enabled,
+		Locked:      locked,
+		Index:       index,
+		Transform:   transform,
+	}
+
+	// Nested scenes have no input settings; they are re-added by name only
+	if settings, err := s.obsClient.GetSourceSettings(item.Name); err == nil {
+		p.InputSettings = settings
+	}
+	if inputs, err := s.obsClient.ListSources(); err == nil {
+		for _, in := range inputs {
+			if in.InputName == item.Name {
+				p.InputKind = in.InputKind
+				break
+			}
+		}
+	}
+	return p, nil
+}
+
+func (s *Server) captureRemoveSceneItem(sceneName string, sceneItemID int) ([]storage.UndoOperation, error) {
+	item, index, _, err := s.findSceneItem(sceneName, sceneItemID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.snapshotSceneItem(sceneName, *item, index)
+	if err != nil {
+		return nil, err
+	}
+	return []storage.UndoOperation{undoOp(undoOpRestoreSceneItem, snapshot)}, nil
+}
+
+func (s *Server) captureSceneItemEnabled(sceneName string, sceneItemID int) ([]storage.UndoOperation, error) {
+	item, _, _, err := s.findSceneItem(sceneName, sceneItemID)
+	if err != nil {
+		return nil, err
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetSceneItemEnabled, undoParams{
+		SceneName: sceneName, SceneItemID: sceneItemID, SourceName: item.Name, Enabled: item.Enabled,
+	})}, nil
+}
+
+func (s *Server) captureSceneItemIndex(sceneName string, sceneItemID int) ([]storage.UndoOperation, error) {
+	_, index, _, err := s.findSceneItem(sceneName, sceneItemID)
+	if err != nil {
+		return nil, err
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetSceneItemIndex, undoParams{
+		SceneName: sceneName, SceneItemID: sceneItemID, Index: index,
+	})}, nil
+}
+
+func (s *Server) captureSceneItemLocked(sceneName string, sceneItemID int) ([]storage.UndoOperation, error) {
+	locked, err := s.obsClient.GetSceneItemLocked(sceneName, sceneItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locked state: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetSceneItemLocked, undoParams{
+		SceneName: sceneName, SceneItemID: sceneItemID, Locked: locked,
+	})}, nil
+}
+
+// undoSceneItemTransform restores a transform read before it was changed.
+func undoSceneItemTransform(sceneName string, sceneItemID int, before obs.SceneItemTransform) []storage.UndoOperation {
+	return []storage.UndoOperation{undoOp(undoOpSetSceneItemTransform, undoParams{
+		SceneName: sceneName, SceneItemID: sceneItemID, Transform: &before,
+	})}
+}
+
+// undoCreateSceneItem removes a scene item created by a tool call.
+func undoCreateSceneItem(sceneName string, sceneItemID int) []storage.UndoOperation {
+	return []storage.UndoOperation{undoOp(undoOpRemoveSceneItem, undoParams{SceneName: sceneName, SceneItemID: sceneItemID})}
+}
+
+// captureScenePreset restores the visibility of every item a preset changes.
+func captureScenePreset(sceneName string, scene *obs.Scene, states []obs.SourceState) []storage.UndoOperation {
+	current := make(map[int]bool, len(scene.Sources))
+	for _, src := range scene.Sources {
+		current[src.ID] = src.Enabled
+	}
+
+	var ops []storage.UndoOperation
+	for _, state := range states {
+		if enabled, ok := current[state.ID]; ok && enabled != state.Enabled {
+			ops = append(ops, undoOp(undoOpSetSceneItemEnabled, undoParams{
+				SceneName: sceneName, SceneItemID: state.ID, SourceName: state.Name, Enabled: enabled,
+			}))
+		}
+	}
+	return ops
+}
+
+func (s *Server) captureInputMute(inputName string) ([]storage.UndoOperation, error) {
+	muted, err := s.obsClient.GetInputMute(inputName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input mute: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetInputMute, undoParams{InputName: inputName, Muted: muted})}, nil
+}
+
+// captureInputVolume restores the volume multiplier, which OBS reports
+// exactly, rather than the derived dB value.
+func (s *Server) captureInputVolume(inputName string) ([]storage.UndoOperation, error) {
+	volumeMul, _, err := s.obsClient.GetInputVolume(inputName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input volume: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetInputVolume, undoParams{InputName: inputName, VolumeMul: volumeMul})}, nil
+}
+
+// undoCreateSourceFilter removes a filter created by a tool call.
+func undoCreateSourceFilter(sourceName, filterName string) []storage.UndoOperation {
+	return []storage.UndoOperation{undoOp(undoOpRemoveSourceFilter, undoParams{SourceName: sourceName, FilterName: filterName})}
+}
+
+func (s *Server) captureRemoveSourceFilter(sourceName, filterName string) ([]storage.UndoOperation, error) {
+	filter, err := s.obsClient.GetSourceFilter(sourceName, filterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpCreateSourceFilter, undoParams{
+		SourceName:     sourceName,
+		FilterName:     filterName,
+		FilterKind:     filter.Kind,
+		FilterSettings: filter.Settings,
+		Enabled:        filter.Enabled,
+	})}, nil
+}
+
+func (s *Server) captureSourceFilterEnabled(sourceName, filterName string) ([]storage.UndoOperation, error) {
+	filter, err := s.obsClient.GetSourceFilter(sourceName, filterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetSourceFilterEnabled, undoParams{
+		SourceName: sourceName, FilterName: filterName, Enabled: filter.Enabled,
+	})}, nil
+}
+
+func (s *Server) captureSourceFilterSettings(sourceName, filterName string) ([]storage.UndoOperation, error) {
+	filter, err := s.obsClient.GetSourceFilter(sourceName, filterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetSourceFilterSettings, undoParams{
+		SourceName: sourceName, FilterName: filterName, FilterSettings: filter.Settings,
+	})}, nil
+}
+
+func (s *Server) captureCurrentTransition() ([]storage.UndoOperation, error) {
+	current, err := s.obsClient.GetCurrentSceneTransition()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current transition: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetCurrentTransition, undoParams{TransitionName: current.Name})}, nil
+}
+
+func (s *Server) captureTransitionDuration() ([]storage.UndoOperation, error) {
+	current, err := s.obsClient.GetCurrentSceneTransition()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current transition: %w", err)
+	}
+	return []storage.UndoOperation{undoOp(undoOpSetTransitionDuration, undoParams{DurationMs: current.Duration})}, nil
+}
+
+// =============================================================================
+// Replaying undo operations
+// =============================================================================
+
+// sceneItemIDs tracks scene items recreated during an undo run. OBS assigns
+// restored items new IDs, so older journal entries that refer to the removed
+// item are rewritten to the new ID before they are replayed.
+type sceneItemIDs map[string]int
+
+func sceneItemKey(sceneName string, sceneItemID int) string {
+	return fmt.Sprintf("%s/%d", sceneName, sceneItemID)
+}
+
+// resolve returns the current ID of a scene item that may have been recreated.
+func (ids sceneItemIDs) resolve(sceneName string, sceneItemID int) int {
+	if id, ok := ids[sceneItemKey(sceneName, sceneItemID)]; ok {
+		return id
+	}
+	return sceneItemID
+}
+
+// remap rewrites scene item IDs in ops and reports whether anything changed.
+func (ids sceneItemIDs) remap(ops []storage.UndoOperation) bool {
+	changed := false
+	for _, op := range ops {
+		scene, _ := op.Parameters["scene_name"].(string)
+		id, ok := op.Parameters["scene_item_id"].(float64)
+		if !ok {
+			continue
+		}
+		if newID := ids.resolve(scene, int(id)); newID != int(id) {
+			op.Parameters["scene_item_id"] = float64(newID)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// applyUndoOperation replays one journal operation against OBS.
+func (s *Server) applyUndoOperation(op storage.UndoOperation, ids sceneItemIDs) error {
+	p, err := decodeUndoParams(op)
+	if err != nil {
+		return err
+	}
+	itemID := p.SceneItemID
+
+	switch op.Type {
+	case undoOpSetCurrentScene:
+		return s.obsClient.SetCurrentScene(p.SceneName)
+	case undoOpSetPreviewScene:
+		return s.obsClient.SetCurrentPreviewScene(p.SceneName)
+	case undoOpSetStudioMode:
+		return s.obsClient.SetStudioModeEnabled(p.Enabled)
+	case undoOpCreateScene:
+		return s.obsClient.CreateScene(p.SceneName)
+	case undoOpRemoveScene:
+		return s.obsClient.RemoveScene(p.SceneName)
+	case undoOpSetSceneItemEnabled:
+		return s.obsClient.ApplyScenePreset(p.SceneName, []obs.SourceState{{ID: itemID, Name: p.SourceName, Enabled: p.Enabled}})
+	case undoOpSetSceneItemTransform:
+		if p.Transform == nil {
+			return fmt.Errorf("%s is missing the transform", op.Type)
+		}
+		return s.obsClient.SetSceneItemTransform(p.SceneName, itemID, p.Transform)
+	case undoOpSetSceneItemIndex:
+		return s.obsClient.SetSceneItemIndex(p.SceneName, itemID, p.Index)
+	case undoOpSetSceneItemLocked:
+		return s.obsClient.SetSceneItemLocked(p.SceneName, itemID, p.Locked)
+	case undoOpRemoveSceneItem:
+		return s.obsClient.RemoveSceneItem(p.SceneName, itemID)
+	case undoOpRestoreSceneItem:
+		return s.restoreSceneItem(p, ids)
+	case undoOpSetInputMute:
+		muted, err := s.obsClient.GetInputMute(p.InputName)
+		if err != nil {
+			return err
+		}
+		if muted == p.Muted {
+			return nil
+		}
+		return s.obsClient.ToggleInputMute(p.InputName)
+	case undoOpSetInputVolume:
+		return s.obsClient.SetInputVolume(p.InputName, nil, &p.VolumeMul)
+	case undoOpCreateSourceFilter:
+		if err := s.obsClient.CreateSourceFilter(p.SourceName, p.FilterName, p.FilterKind, p.FilterSettings); err != nil {
+			return err
+		}
+		if !p.Enabled {
+			return s.obsClient.SetSourceFilterEnabled(p.SourceName, p.FilterName, false)
+		}
+		return nil
+	case undoOpRemoveSourceFilter:
+		return s.obsClient.RemoveSourceFilter(p.SourceName, p.FilterName)
+	case undoOpSetSourceFilterEnabled:
+		return s.obsClient.SetSourceFilterEnabled(p.SourceName, p.FilterName, p.Enabled)
+	case undoOpSetSourceFilterSettings:
+		return s.obsClient.SetSourceFilterSettings(p.SourceName, p.FilterName, p.FilterSettings, false)
+	case undoOpSetCurrentTransition:
+		return s.obsClient.SetCurrentSceneTransition(p.TransitionName)
+	case undoOpSetTransitionDuration:
+		return s.obsClient.SetCurrentSceneTransitionDuration(p.DurationMs)
+	default:
+		return fmt.Errorf("unknown undo operation type: %s", op.Type)
+	}
+}
+
+// restoreSceneItem re-adds a removed source to its scene. The existing input
+// is reused when OBS still has it; otherwise the input is recreated from the
+// captured kind and settings. Transform, lock, visibility and z-order follow.
+func (s *Server) restoreSceneItem(p undoParams, ids sceneItemIDs) error {
+	newID, err := s.obsClient.CreateSceneItem(p.SceneName, p.SourceName)
+	if err != nil {
+		if p.InputKind == "" {
+			return err
+		}
+		newID, err = s.obsClient.CreateInput(p.SceneName, p.SourceName, p.InputKind, p.InputSettings)
+		if err != nil {
+			return err
+		}
+	}
+	ids[sceneItemKey(p.SceneName, p.SceneItemID)] = newID
+
+	if p.Transform != nil {
+		if err := s.obsClient.SetSceneItemTransform(p.SceneName, newID, p.Transform); err != nil {
+			return err
+		}
+	}
+	if p.Locked {
+		if err := s.obsClient.SetSceneItemLocked(p.SceneName, newID, true); err != nil {
+			return err
+		}
+	}
+	if !p.Enabled {
+		if err := s.obsClient.ApplyScenePreset(p.SceneName, []obs.SourceState{{ID: newID, Name: p.SourceName, Enabled: false}}); err != nil {
+			return err
+		}
+	}
+	return s.obsClient.SetSceneItemIndex(p.SceneName, newID, p.Index)
+}
+
+// undoEntries replays entries in the given order, newest action first, and
+// marks each one undone as it completes. It stops at the first failure and
+// returns the actions undone so far alongside the error.
+func (s *Server) undoEntries(ctx context.Context, request *mcpsdk.CallToolRequest, entries []storage.UndoEntry) ([]UndoneAction, error) {
+	ids := sceneItemIDs{}
+	progress := newProgressReporter(request)
+	undone := []UndoneAction{}
+
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return undone, fmt.Errorf("undo cancelled after %d of %d actions: %w", i, len(entries), err)
+		}
+
+		ids.remap(entry.Operations)
+		for _, op := range entry.Operations {
+			if err := s.applyUndoOperation(op, ids); err != nil {
+				s.persistSceneItemIDs(ctx, ids)
+				return undone, fmt.Errorf("failed to undo action %d (%s) at %s: %w", entry.ActionID, entry.ToolName, op.Type, err)
+			}
+		}
+		if err := s.storage.MarkUndone(ctx, entry.ActionID); err != nil {
+			return undone, err
+		}
+
+		undone = append(undone, UndoneAction{ActionID: entry.ActionID, ToolName: entry.ToolName, Description: entry.Description})
+		progress.report(ctx, i+1, len(entries), fmt.Sprintf("Undid '%s'", entry.Description))
+	}
+
+	s.persistSceneItemIDs(ctx, ids)
+	return undone, nil
+}
+
+// persistSceneItemIDs rewrites pending journal entries that refer to scene
+// items recreated during an undo run, so later undo calls find them.
+func (s *Server) persistSceneItemIDs(ctx context.Context, ids sceneItemIDs) {
+	if len(ids) == 0 {
+		return
+	}
+	pending, err := s.storage.GetUndoEntriesSince(ctx, 0)
+	if err != nil {
+		log.Printf("Warning: failed to load undo entries for ID remapping: %v", err)
+		return
+	}
+	for _, entry := range pending {
+		if !ids.remap(entry.Operations) {
+			continue
+		}
+		if err := s.storage.UpdateUndoOperations(ctx, entry.ActionID, entry.Operations); err != nil {
+			log.Printf("Warning: failed to remap scene items in undo entry %d: %v", entry.ActionID, err)
+		}
+	}
+}
+
+// =============================================================================
+// Undo tools
+// =============================================================================
+
+// ListUndoableActionsInput is the input for list_undoable_actions
+type ListUndoableActionsInput struct {
+	Limit         int  `json:"limit,omitempty" jsonschema:"Maximum number of actions to return (default: 20, max: 100)"`
+	IncludeUndone bool `json:"include_undone,omitempty" jsonschema:"Also list actions that were already undone"`
+}
+
+// UndoToInput is the input for undo_to
+type UndoToInput struct {
+	ActionID int64 `json:"action_id" jsonschema:"Action history ID to undo back to; this action and every later undoable action are reversed"`
+}
+
+func (s *Server) handleListUndoableActions(ctx context.Context, request *mcpsdk.CallToolRequest, input ListUndoableActionsInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Println("Listing undoable actions")
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	entries, err := s.storage.ListUndoEntries(ctx, limit, input.IncludeUndone)
+	if err != nil {
+		s.recordAction(ctx, "list_undoable_actions", "List undoable actions", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("failed to list undoable actions: %w", err)
+	}
+	if entries == nil {
+		entries = []storage.UndoEntry{}
+	}
+
+	result := UndoableActionListResult{Actions: entries, Count: len(entries)}
+	s.recordAction(ctx, "list_undoable_actions", "List undoable actions", input, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+func (s *Server) handleUndoLastAction(ctx context.Context, request *mcpsdk.CallToolRequest, input struct{}) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Println("Undoing last action")
+
+	entries, err := s.storage.ListUndoEntries(ctx, 1, false)
+	if err != nil {
+		s.recordAction(ctx, "undo_last_action", "Undo last action", nil, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("failed to load undo journal: %w", err)
+	}
+	if len(entries) == 0 {
+		s.recordAction(ctx, "undo_last_action", "Undo last action", nil, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("no undoable actions")
+	}
+
+	undone, err := s.undoEntries(ctx, request, entries)
+	if err != nil {
+		s.recordAction(ctx, "undo_last_action", "Undo last action", nil, nil, false, time.Since(start))
+		return nil, nil, err
+	}
+
+	result := UndoResult{
+		Undone:  undone,
+		Count:   len(undone),
+		Message: fmt.Sprintf("Undid action %d (%s)", entries[0].ActionID, entries[0].Description),
+	}
+	s.recordAction(ctx, "undo_last_action", "Undo last action", nil, result, true, time.Since(start))
+	return nil, result, nil
+}
+
+func (s *Server) handleUndoTo(ctx context.Context, request *mcpsdk.CallToolRequest, input UndoToInput) (*mcpsdk.CallToolResult, any, error) {
+	start := time.Now()
+	log.Printf("Undoing actions back to %d", input.ActionID)
+
+	target, err := s.storage.GetUndoEntry(ctx, input.ActionID)
+	if err != nil {
+		s.recordAction(ctx, "undo_to", "Undo to action", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("action %d is not undoable: %w", input.ActionID, err)
+	}
+	if target.UndoneAt != nil {
+		s.recordAction(ctx, "undo_to", "Undo to action", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("action %d was already undone", input.ActionID)
+	}
+
+	entries, err := s.storage.GetUndoEntriesSince(ctx, input.ActionID)
+	if err != nil {
+		s.recordAction(ctx, "undo_to", "Undo to action", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("failed to load undo journal: %w", err)
+	}
+
+	undone, err := s.undoEntries(ctx, request, entries)
+	if err != nil {
+		s.recordAction(ctx, "undo_to", "Undo to action", input, nil, false, time.Since(start))
+		return nil, nil, fmt.Errorf("undo stopped after %d of %d actions: %w", len(undone), len(entries), err)
+	}
+
+	result := UndoResult{
+		Undone:  undone,
+		Count:   len(undone),
+		Message: fmt.Sprintf("Undid %d action(s) back to action %d", len(undone), input.ActionID),
+	}
+	s.recordAction(ctx, "undo_to", "Undo to action", input, result, true, time.Since(start))
+	return nil, result, nil
+}
@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastActionID returns the action history ID of the most recent call to a tool.
+func lastActionID(t *testing.T, server *Server, toolName string) int64 {
+	t.Helper()
+	actions, err := server.storage.GetActionsByTool(context.Background(), toolName, 1)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	return actions[0].ID
+}
+
+func TestUndoLastAction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("restores the previous scene", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Gaming"})
+		require.NoError(t, err)
+
+		_, result, err := server.handleUndoLastAction(ctx, nil, struct{}{})
+		require.NoError(t, err)
+		undo := result.(UndoResult)
+		require.Equal(t, 1, undo.Count)
+		assert.Equal(t, "set_current_scene", undo.Undone[0].ToolName)
+
+		_, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Scene 1", current)
+
+		_, _, err = server.handleUndoLastAction(ctx, nil, struct{}{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no undoable actions")
+	})
+
+	t.Run("restores the previous volume", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+		before, _, _ := mock.GetInputVolume("Microphone")
+
+		volume := -6.0
+		_, _, err := server.handleSetInputVolume(ctx, nil, SetVolumeInput{InputName: "Microphone", VolumeDb: &volume})
+		require.NoError(t, err)
+
+		_, _, err = server.handleUndoLastAction(ctx, nil, struct{}{})
+		require.NoError(t, err)
+
+		after, _, _ := mock.GetInputVolume("Microphone")
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("removes a created source", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleCreateTextSource(ctx, nil, CreateTextSourceInput{SceneName: "Scene 1", SourceName: "Title", Text: "Hello"})
+		require.NoError(t, err)
+		scene, _ := mock.GetSceneByName("Scene 1")
+		require.Len(t, scene.Sources, 3)
+
+		_, _, err = server.handleUndoLastAction(ctx, nil, struct{}{})
+		require.NoError(t, err)
+
+		scene, _ = mock.GetSceneByName("Scene 1")
+		assert.Len(t, scene.Sources, 2)
+	})
+
+	t.Run("recreates a removed scene with its sources", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleRemoveScene(ctx, nil, SceneNameInput{SceneName: "Gaming"})
+		require.NoError(t, err)
+		scenes, _, _ := mock.GetSceneList()
+		require.NotContains(t, scenes, "Gaming")
+
+		_, _, err = server.handleUndoLastAction(ctx, nil, struct{}{})
+		require.NoError(t, err)
+
+		scene, err := mock.GetSceneByName("Gaming")
+		require.NoError(t, err)
+		assert.Len(t, scene.Sources, 2)
+	})
+
+	t.Run("does not journal dry runs", func(t *testing.T) {
+		server, _, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Gaming", DryRun: true})
+		require.NoError(t, err)
+
+		_, result, err := server.handleListUndoableActions(ctx, nil, ListUndoableActionsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.(UndoableActionListResult).Count)
+	})
+}
+
+func TestUndoTo(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("undoes changes to a removed source after restoring it", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		x := 120.0
+		_, _, err := server.handleSetSourceTransform(ctx, nil, SetSourceTransformInput{SceneName: "Scene 1", SceneItemID: 1, X: &x})
+		require.NoError(t, err)
+		firstID := lastActionID(t, server, "set_source_transform")
+
+		_, _, err = server.handleSetSourceLocked(ctx, nil, SetSourceLockedInput{SceneName: "Scene 1", SceneItemID: 1, Locked: true})
+		require.NoError(t, err)
+		_, _, err = server.handleRemoveSource(ctx, nil, RemoveSourceInput{SceneName: "Scene 1", SceneItemID: 1})
+		require.NoError(t, err)
+
+		_, result, err := server.handleListUndoableActions(ctx, nil, ListUndoableActionsInput{})
+		require.NoError(t, err)
+		list := result.(UndoableActionListResult)
+		require.Equal(t, 3, list.Count)
+		assert.Equal(t, "remove_source", list.Actions[0].ToolName)
+
+		_, result, err = server.handleUndoTo(ctx, nil, UndoToInput{ActionID: firstID})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.(UndoResult).Count)
+
+		// The restored Webcam has a new scene item ID; earlier entries followed it
+		scene, _ := mock.GetSceneByName("Scene 1")
+		require.Len(t, scene.Sources, 2)
+		var webcamID int
+		for _, src := range scene.Sources {
+			if src.Name == "Webcam" {
+				webcamID = src.ID
+			}
+		}
+		require.NotZero(t, webcamID)
+		assert.NotEqual(t, 1, webcamID)
+
+		transform, err := mock.GetSceneItemTransform("Scene 1", webcamID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, transform.PositionX)
+
+		locked, err := mock.GetSceneItemLocked("Scene 1", webcamID)
+		require.NoError(t, err)
+		assert.False(t, locked)
+
+		_, result, err = server.handleListUndoableActions(ctx, nil, ListUndoableActionsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.(UndoableActionListResult).Count)
+	})
+
+	t.Run("restores filter settings and removed filters", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleSetSourceFilterSettings(ctx, nil, SetSourceFilterSettingsInput{
+			SourceName:     "Webcam",
+			FilterName:     "Color Correction",
+			FilterSettings: map[string]interface{}{"brightness": 0.5},
+			Overlay:        true,
+		})
+		require.NoError(t, err)
+		firstID := lastActionID(t, server, "set_source_filter_settings")
+
+		_, _, err = server.handleRemoveSourceFilter(ctx, nil, RemoveSourceFilterInput{SourceName: "Webcam", FilterName: "Sharpen"})
+		require.NoError(t, err)
+
+		_, _, err = server.handleUndoTo(ctx, nil, UndoToInput{ActionID: firstID})
+		require.NoError(t, err)
+
+		filter, err := mock.GetSourceFilter("Webcam", "Color Correction")
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, filter.Settings["brightness"])
+
+		_, err = mock.GetSourceFilter("Webcam", "Sharpen")
+		assert.NoError(t, err)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		server, mock, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleCreateScene(ctx, nil, SceneNameInput{SceneName: "Outro"})
+		require.NoError(t, err)
+		firstID := lastActionID(t, server, "create_scene")
+		_, _, err = server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Outro"})
+		require.NoError(t, err)
+
+		mock.ErrorOnRemoveScene = errors.New("scene in use")
+		_, _, err = server.handleUndoTo(ctx, nil, UndoToInput{ActionID: firstID})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "undo stopped after 1 of 2 actions")
+
+		_, current, _ := mock.GetSceneList()
+		assert.Equal(t, "Scene 1", current)
+
+		_, result, err := server.handleListUndoableActions(ctx, nil, ListUndoableActionsInput{IncludeUndone: true})
+		require.NoError(t, err)
+		list := result.(UndoableActionListResult)
+		require.Equal(t, 2, list.Count)
+		assert.NotNil(t, list.Actions[0].UndoneAt)
+		assert.Nil(t, list.Actions[1].UndoneAt)
+	})
+
+	t.Run("rejects actions without a pending undo entry", func(t *testing.T) {
+		server, _, _ := testServerWithStorage(t)
+
+		_, _, err := server.handleUndoTo(ctx, nil, UndoToInput{ActionID: 999})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not undoable")
+
+		_, _, err = server.handleSetCurrentScene(ctx, nil, SceneNameInput{SceneName: "Gaming"})
+		require.NoError(t, err)
+		id := lastActionID(t, server, "set_current_scene")
+		_, _, err = server.handleUndoTo(ctx, nil, UndoToInput{ActionID: id})
+		require.NoError(t, err)
+
+		_, _, err = server.handleUndoTo(ctx, nil, UndoToInput{ActionID: id})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already undone")
+	})
+}
@@ -262,10 +262,9 @@ func (c *Client) monitorConnection() {
 				// Attempt reconnection
 				if err := c.Connect(); err != nil {
 					// Log error but continue trying
-					// In production, you might want to use a proper logger here
-					fmt.Printf("Auto-reconnect failed: %v\n", err)
+					logger.Warnf("Auto-reconnect failed: %v", err)
 				} else {
-					fmt.Println("Successfully reconnected to OBS")
+					logger.Infof("Successfully reconnected to OBS")
 				}
 			} else {
 				// Perform health check on connected client
@@ -283,7 +282,7 @@ func (c *Client) monitorConnection() {
 							c.client = nil
 						}
 						c.mu.Unlock()
-						fmt.Printf("OBS connection lost: %v\n", err)
+						logger.Errorf("OBS connection lost: %v", err)
 					}
 				}
 			}
@@ -889,6 +889,25 @@ func (c *Client) DuplicateSceneItem(sceneName string, sceneItemID int, destScene
 	return int(resp.SceneItemId), nil
 }
 
+// CreateSceneItem adds an existing source to a scene.
+// Returns the scene item ID of the new item.
+func (c *Client) CreateSceneItem(sceneName, sourceName string) (int, error) {
+	client, err := c.getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.SceneItems.CreateSceneItem(&sceneitems.CreateSceneItemParams{
+		SceneName:  &sceneName,
+		SourceName: &sourceName,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add source '%s' to scene '%s': %w", sourceName, sceneName, err)
+	}
+
+	return resp.SceneItemId, nil
+}
+
 // RemoveSceneItem removes a scene item from a scene.
 func (c *Client) RemoveSceneItem(sceneName string, sceneItemID int) error {
 	client, err := c.getClient()
@@ -2,9 +2,13 @@ package obs
 
 import (
 	"fmt"
-	"log"
+
+	"github.com/ironystock/agentic-obs/internal/logging"
 )
 
+// logger receives OBS connection and event diagnostics.
+var logger = logging.New("obs")
+
 // EventHandler implements the EventCallback interface and handles OBS events,
 // dispatching them to the MCP server for resource notifications.
 type EventHandler struct {
@@ -69,7 +73,7 @@ func NewEventHandler(notificationFunc NotificationFunc) *EventHandler {
 // OnSceneCreated is called when a new scene is created in OBS.
 // This triggers a "resources/list_changed" notification to MCP clients.
 func (h *EventHandler) OnSceneCreated(sceneName string) {
-	log.Printf("[OBS Event] Scene created: %s", sceneName)
+	logger.Debugf("Event: Scene created: %s", sceneName)
 
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeSceneCreated, map[string]interface{}{
@@ -82,7 +86,7 @@ func (h *EventHandler) OnSceneCreated(sceneName string) {
 // OnSceneRemoved is called when a scene is removed/deleted from OBS.
 // This triggers a "resources/list_changed" notification to MCP clients.
 func (h *EventHandler) OnSceneRemoved(sceneName string) {
-	log.Printf("[OBS Event] Scene removed: %s", sceneName)
+	logger.Debugf("Event: Scene removed: %s", sceneName)
 
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeSceneRemoved, map[string]interface{}{
@@ -95,7 +99,7 @@ func (h *EventHandler) OnSceneRemoved(sceneName string) {
 // OnCurrentProgramSceneChanged is called when the active scene changes in OBS.
 // This triggers a "resources/updated" notification for the specific scene URI.
 func (h *EventHandler) OnCurrentProgramSceneChanged(sceneName string) {
-	log.Printf("[OBS Event] Current program scene changed to: %s", sceneName)
+	logger.Debugf("Event: Current program scene changed to: %s", sceneName)
 
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeSceneChanged, map[string]interface{}{
@@ -107,7 +111,7 @@ func (h *EventHandler) OnCurrentProgramSceneChanged(sceneName string) {
 
 // OnRecordingStarted is called when recording begins.
 func (h *EventHandler) OnRecordingStarted() {
-	log.Printf("[OBS Event] Recording started")
+	logger.Debugf("Event: Recording started")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeRecordingStarted, map[string]interface{}{})
 	}
@@ -115,7 +119,7 @@ func (h *EventHandler) OnRecordingStarted() {
 
 // OnRecordingStopped is called when recording stops.
 func (h *EventHandler) OnRecordingStopped(outputPath string) {
-	log.Printf("[OBS Event] Recording stopped: %s", outputPath)
+	logger.Debugf("Event: Recording stopped: %s", outputPath)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeRecordingStopped, map[string]interface{}{
 			"output_path": outputPath,
@@ -125,7 +129,7 @@ func (h *EventHandler) OnRecordingStopped(outputPath string) {
 
 // OnRecordingPaused is called when recording is paused.
 func (h *EventHandler) OnRecordingPaused() {
-	log.Printf("[OBS Event] Recording paused")
+	logger.Debugf("Event: Recording paused")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeRecordingPaused, map[string]interface{}{})
 	}
@@ -133,7 +137,7 @@ func (h *EventHandler) OnRecordingPaused() {
 
 // OnRecordingResumed is called when recording resumes after being paused.
 func (h *EventHandler) OnRecordingResumed() {
-	log.Printf("[OBS Event] Recording resumed")
+	logger.Debugf("Event: Recording resumed")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeRecordingResumed, map[string]interface{}{})
 	}
@@ -142,7 +146,7 @@ func (h *EventHandler) OnRecordingResumed() {
 // OnRecordingFileChanged is called when the record output rotates to a new file
 // (e.g. OBS 30+ file splits).
 func (h *EventHandler) OnRecordingFileChanged(newOutputPath string) {
-	log.Printf("[OBS Event] Recording file changed: %s", newOutputPath)
+	logger.Debugf("Event: Recording file changed: %s", newOutputPath)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeRecordingFileChanged, map[string]interface{}{
 			"new_output_path": newOutputPath,
@@ -152,7 +156,7 @@ func (h *EventHandler) OnRecordingFileChanged(newOutputPath string) {
 
 // OnStreamingStarted is called when streaming begins.
 func (h *EventHandler) OnStreamingStarted() {
-	log.Printf("[OBS Event] Streaming started")
+	logger.Debugf("Event: Streaming started")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeStreamingStarted, map[string]interface{}{})
 	}
@@ -160,7 +164,7 @@ func (h *EventHandler) OnStreamingStarted() {
 
 // OnStreamingStopped is called when streaming stops.
 func (h *EventHandler) OnStreamingStopped() {
-	log.Printf("[OBS Event] Streaming stopped")
+	logger.Debugf("Event: Streaming stopped")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeStreamingStopped, map[string]interface{}{})
 	}
@@ -168,7 +172,7 @@ func (h *EventHandler) OnStreamingStopped() {
 
 // OnVirtualCamStarted is called when the virtual camera is started.
 func (h *EventHandler) OnVirtualCamStarted() {
-	log.Printf("[OBS Event] Virtual camera started")
+	logger.Debugf("Event: Virtual camera started")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeVirtualCamStarted, map[string]interface{}{})
 	}
@@ -176,7 +180,7 @@ func (h *EventHandler) OnVirtualCamStarted() {
 
 // OnVirtualCamStopped is called when the virtual camera is stopped.
 func (h *EventHandler) OnVirtualCamStopped() {
-	log.Printf("[OBS Event] Virtual camera stopped")
+	logger.Debugf("Event: Virtual camera stopped")
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeVirtualCamStopped, map[string]interface{}{})
 	}
@@ -184,7 +188,7 @@ func (h *EventHandler) OnVirtualCamStopped() {
 
 // OnReplayBufferSaved is called when a replay buffer is saved.
 func (h *EventHandler) OnReplayBufferSaved(savedPath string) {
-	log.Printf("[OBS Event] Replay buffer saved: %s", savedPath)
+	logger.Debugf("Event: Replay buffer saved: %s", savedPath)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeReplayBufferSaved, map[string]interface{}{
 			"saved_path": savedPath,
@@ -194,7 +198,7 @@ func (h *EventHandler) OnReplayBufferSaved(savedPath string) {
 
 // OnInputMuteChanged is called when an input's mute state changes.
 func (h *EventHandler) OnInputMuteChanged(inputName string, muted bool) {
-	log.Printf("[OBS Event] Input mute changed: %s = %v", inputName, muted)
+	logger.Debugf("Event: Input mute changed: %s = %v", inputName, muted)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeInputMuteChanged, map[string]interface{}{
 			"input_name": inputName,
@@ -205,7 +209,7 @@ func (h *EventHandler) OnInputMuteChanged(inputName string, muted bool) {
 
 // OnSceneItemVisibilityChanged is called when a scene item's visibility changes.
 func (h *EventHandler) OnSceneItemVisibilityChanged(sceneName string, sceneItemId int, visible bool) {
-	log.Printf("[OBS Event] Scene item visibility changed: %s item %d = %v", sceneName, sceneItemId, visible)
+	logger.Debugf("Event: Scene item visibility changed: %s item %d = %v", sceneName, sceneItemId, visible)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeSourceVisibilityChanged, map[string]interface{}{
 			"scene_name":    sceneName,
@@ -217,7 +221,7 @@ func (h *EventHandler) OnSceneItemVisibilityChanged(sceneName string, sceneItemI
 
 // OnTransitionStarted is called when a scene transition starts.
 func (h *EventHandler) OnTransitionStarted(transitionName string) {
-	log.Printf("[OBS Event] Transition started: %s", transitionName)
+	logger.Debugf("Event: Transition started: %s", transitionName)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeTransitionStarted, map[string]interface{}{
 			"transition_name": transitionName,
@@ -227,7 +231,7 @@ func (h *EventHandler) OnTransitionStarted(transitionName string) {
 
 // OnStudioModeChanged is called when studio mode is enabled or disabled.
 func (h *EventHandler) OnStudioModeChanged(enabled bool) {
-	log.Printf("[OBS Event] Studio mode changed: %v", enabled)
+	logger.Debugf("Event: Studio mode changed: %v", enabled)
 	if h.notificationFunc != nil {
 		h.notificationFunc(EventTypeStudioModeChanged, map[string]interface{}{
 			"enabled": enabled,
@@ -246,87 +250,87 @@ func NewEventLogger() *EventLogger {
 
 // OnSceneCreated logs scene creation events.
 func (l *EventLogger) OnSceneCreated(sceneName string) {
-	log.Printf("[OBS Event Logger] Scene created: %s", sceneName)
+	logger.Infof("Event logger: Scene created: %s", sceneName)
 }
 
 // OnSceneRemoved logs scene removal events.
 func (l *EventLogger) OnSceneRemoved(sceneName string) {
-	log.Printf("[OBS Event Logger] Scene removed: %s", sceneName)
+	logger.Infof("Event logger: Scene removed: %s", sceneName)
 }
 
 // OnCurrentProgramSceneChanged logs scene change events.
 func (l *EventLogger) OnCurrentProgramSceneChanged(sceneName string) {
-	log.Printf("[OBS Event Logger] Current program scene changed to: %s", sceneName)
+	logger.Infof("Event logger: Current program scene changed to: %s", sceneName)
 }
 
 // OnRecordingStarted logs recording start events.
 func (l *EventLogger) OnRecordingStarted() {
-	log.Printf("[OBS Event Logger] Recording started")
+	logger.Infof("Event logger: Recording started")
 }
 
 // OnRecordingStopped logs recording stop events.
 func (l *EventLogger) OnRecordingStopped(outputPath string) {
-	log.Printf("[OBS Event Logger] Recording stopped: %s", outputPath)
+	logger.Infof("Event logger: Recording stopped: %s", outputPath)
 }
 
 // OnRecordingPaused logs recording pause events.
 func (l *EventLogger) OnRecordingPaused() {
-	log.Printf("[OBS Event Logger] Recording paused")
+	logger.Infof("Event logger: Recording paused")
 }
 
 // OnRecordingResumed logs recording resume events.
 func (l *EventLogger) OnRecordingResumed() {
-	log.Printf("[OBS Event Logger] Recording resumed")
+	logger.Infof("Event logger: Recording resumed")
 }
 
 // OnRecordingFileChanged logs recording file-rotation events.
 func (l *EventLogger) OnRecordingFileChanged(newOutputPath string) {
-	log.Printf("[OBS Event Logger] Recording file changed: %s", newOutputPath)
+	logger.Infof("Event logger: Recording file changed: %s", newOutputPath)
 }
 
 // OnStreamingStarted logs streaming start events.
 func (l *EventLogger) OnStreamingStarted() {
-	log.Printf("[OBS Event Logger] Streaming started")
+	logger.Infof("Event logger: Streaming started")
 }
 
 // OnStreamingStopped logs streaming stop events.
 func (l *EventLogger) OnStreamingStopped() {
-	log.Printf("[OBS Event Logger] Streaming stopped")
+	logger.Infof("Event logger: Streaming stopped")
 }
 
 // OnVirtualCamStarted logs virtual camera start events.
 func (l *EventLogger) OnVirtualCamStarted() {
-	log.Printf("[OBS Event Logger] Virtual camera started")
+	logger.Infof("Event logger: Virtual camera started")
 }
 
 // OnVirtualCamStopped logs virtual camera stop events.
 func (l *EventLogger) OnVirtualCamStopped() {
-	log.Printf("[OBS Event Logger] Virtual camera stopped")
+	logger.Infof("Event logger: Virtual camera stopped")
 }
 
 // OnReplayBufferSaved logs replay buffer saved events.
 func (l *EventLogger) OnReplayBufferSaved(savedPath string) {
-	log.Printf("[OBS Event Logger] Replay buffer saved: %s", savedPath)
+	logger.Infof("Event logger: Replay buffer saved: %s", savedPath)
 }
 
 // OnInputMuteChanged logs input mute change events.
 func (l *EventLogger) OnInputMuteChanged(inputName string, muted bool) {
-	log.Printf("[OBS Event Logger] Input mute changed: %s = %v", inputName, muted)
+	logger.Infof("Event logger: Input mute changed: %s = %v", inputName, muted)
 }
 
 // OnSceneItemVisibilityChanged logs scene item visibility change events.
 func (l *EventLogger) OnSceneItemVisibilityChanged(sceneName string, sceneItemId int, visible bool) {
-	log.Printf("[OBS Event Logger] Scene item visibility changed: %s item %d = %v", sceneName, sceneItemId, visible)
+	logger.Infof("Event logger: Scene item visibility changed: %s item %d = %v", sceneName, sceneItemId, visible)
 }
 
 // OnTransitionStarted logs transition start events.
 func (l *EventLogger) OnTransitionStarted(transitionName string) {
-	log.Printf("[OBS Event Logger] Transition started: %s", transitionName)
+	logger.Infof("Event logger: Transition started: %s", transitionName)
 }
 
 // OnStudioModeChanged logs studio mode change events.
 func (l *EventLogger) OnStudioModeChanged(enabled bool) {
-	log.Printf("[OBS Event Logger] Studio mode changed: %v", enabled)
+	logger.Infof("Event logger: Studio mode changed: %v", enabled)
 }
 
 // FormatEventNotification formats an event into a structured notification message
@@ -406,19 +410,19 @@ func NewEventMetricsTracker() *EventMetricsTracker {
 // OnSceneCreated increments the scene created counter.
 func (t *EventMetricsTracker) OnSceneCreated(sceneName string) {
 	t.metrics.SceneCreatedCount++
-	log.Printf("[Metrics] Scene created: %s (total: %d)", sceneName, t.metrics.SceneCreatedCount)
+	logger.Debugf("Metrics: Scene created: %s (total: %d)", sceneName, t.metrics.SceneCreatedCount)
 }
 
 // OnSceneRemoved increments the scene removed counter.
 func (t *EventMetricsTracker) OnSceneRemoved(sceneName string) {
 	t.metrics.SceneRemovedCount++
-	log.Printf("[Metrics] Scene removed: %s (total: %d)", sceneName, t.metrics.SceneRemovedCount)
+	logger.Debugf("Metrics: Scene removed: %s (total: %d)", sceneName, t.metrics.SceneRemovedCount)
 }
 
 // OnCurrentProgramSceneChanged increments the scene changed counter.
 func (t *EventMetricsTracker) OnCurrentProgramSceneChanged(sceneName string) {
 	t.metrics.SceneChangedCount++
-	log.Printf("[Metrics] Scene changed: %s (total: %d)", sceneName, t.metrics.SceneChangedCount)
+	logger.Debugf("Metrics: Scene changed: %s (total: %d)", sceneName, t.metrics.SceneChangedCount)
 }
 
 // OnRecordingStarted increments the recording started counter.
@@ -3,14 +3,16 @@ package screenshot
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
+	"github.com/ironystock/agentic-obs/internal/logging"
 	"github.com/ironystock/agentic-obs/internal/obs"
 	"github.com/ironystock/agentic-obs/internal/storage"
 )
 
+var logger = logging.New("screenshot")
+
 // OBSScreenshotter defines the interface for taking screenshots.
 // This allows the manager to work with both real and mock OBS clients.
 type OBSScreenshotter interface {
@@ -344,7 +346,7 @@ func (w *worker) capture() {
 
 	imageData, err := w.obsClient.TakeSourceScreenshot(opts)
 	if err != nil {
-		log.Printf("Screenshot capture failed for source %q: %v", w.source.Name, err)
+		logger.Warnf("Screenshot capture failed for source %q: %v", w.source.Name, err)
 		return
 	}
 
@@ -365,6 +367,6 @@ func (w *worker) capture() {
 	}
 
 	if _, err := w.storage.SaveScreenshot(w.ctx, screenshot); err != nil {
-		log.Printf("Failed to save screenshot for source %q: %v", w.source.Name, err)
+		logger.Errorf("Failed to save screenshot for source %q: %v", w.source.Name, err)
 	}
 }
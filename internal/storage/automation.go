@@ -11,9 +11,11 @@ import (
 
 // TriggerType defines the category of automation trigger
 const (
-	TriggerTypeEvent    = "event"
-	TriggerTypeSchedule = "schedule"
-	TriggerTypeManual   = "manual"
+	TriggerTypeEvent     = "event"
+	TriggerTypeSchedule  = "schedule"
+	TriggerTypeManual    = "manual"
+	TriggerTypeWebhook   = "webhook"
+	TriggerTypeComposite = "composite"
 )
 
 // ExecutionStatus defines the state of a rule execution
@@ -22,6 +24,7 @@ const (
 	ExecutionStatusCompleted = "completed"
 	ExecutionStatusFailed    = "failed"
 	ExecutionStatusSkipped   = "skipped"
+	ExecutionStatusCancelled = "cancelled"
 )
 
 // AutomationRule represents an automation rule with trigger and actions.
@@ -35,6 +38,9 @@ type AutomationRule struct {
 	Actions       []RuleAction           `json:"actions"`
 	CooldownMs    int                    `json:"cooldown_ms,omitempty"`
 	Priority      int                    `json:"priority,omitempty"`
+	Condition     string                 `json:"condition,omitempty"`   // Guard expression; the rule only runs when it holds
+	Concurrency   string                 `json:"concurrency,omitempty"` // What to do when triggered while running; empty means parallel
+	Revision      int                    `json:"revision,omitempty"`    // Current revision number (see rule_revisions)
 	CreatedAt     time.Time              `json:"created_at"`
 	UpdatedAt     time.Time              `json:"updated_at"`
 	LastRun       *time.Time             `json:"last_run,omitempty"`
@@ -45,7 +51,36 @@ type AutomationRule struct {
 type RuleAction struct {
 	Type       string                 `json:"type"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
-	OnError    string                 `json:"on_error,omitempty"` // "continue" or "stop"
+	OnError    string                 `json:"on_error,omitempty"`   // "continue", "stop" or "goto"
+	OnMissing  string                 `json:"on_missing,omitempty"` // "error", "empty" or "keep"
+
+	// if actions: the condition and the actions run when it is true or false
+	Condition string       `json:"condition,omitempty"`
+	Then      []RuleAction `json:"then,omitempty"`
+	Else      []RuleAction `json:"else,omitempty"`
+
+	// parallel actions: the action lists run concurrently
+	Branches [][]RuleAction `json:"branches,omitempty"`
+
+	// repeat actions: the actions repeated, the number of iterations and
+	// the condition that ends the loop
+	Actions []RuleAction `json:"actions,omitempty"`
+	Count   int          `json:"count,omitempty"`
+	Until   string       `json:"until,omitempty"`
+
+	Retry     *ActionRetry `json:"retry,omitempty"`
+	TimeoutMs int          `json:"timeout_ms,omitempty"` // Time limit per attempt
+
+	// on_error "goto": actions run when this action fails
+	Fallback []RuleAction `json:"fallback,omitempty"`
+}
+
+// ActionRetry is the retry policy of an action.
+type ActionRetry struct {
+	MaxAttempts  int     `json:"max_attempts,omitempty"`   // Including the first attempt
+	BackoffMs    int     `json:"backoff_ms,omitempty"`     // Wait before the first retry, doubled for each further one
+	MaxBackoffMs int     `json:"max_backoff_ms,omitempty"` // Upper bound on the wait
+	Jitter       float64 `json:"jitter,omitempty"`         // Fraction of the wait randomly added or removed
 }
 
 // RuleExecution represents a single execution of an automation rule.
@@ -61,6 +96,7 @@ type RuleExecution struct {
 	ActionResults []ActionResult         `json:"action_results,omitempty"`
 	Error         string                 `json:"error,omitempty"`
 	DurationMs    int64                  `json:"duration_ms,omitempty"`
+	Revision      int                    `json:"revision,omitempty"` // Rule revision that ran
 }
 
 // ActionResult represents the result of a single action execution.
@@ -70,6 +106,37 @@ type ActionResult struct {
 	Success    bool   `json:"success"`
 	Error      string `json:"error,omitempty"`
 	DurationMs int64  `json:"duration_ms"`
+	Cancelled  bool   `json:"cancelled,omitempty"`
+	Abandoned  bool   `json:"abandoned,omitempty"` // Its OBS request was in flight and may still have taken effect
+
+	// Parameters after template variables were resolved
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+
+	// if actions: the branch taken and the results of its actions.
+	// run_macro actions: the macro run and the results of its actions.
+	Branch  string         `json:"branch,omitempty"`
+	Macro   string         `json:"macro,omitempty"`
+	Results []ActionResult `json:"results,omitempty"`
+
+	// parallel actions: the results of each branch. repeat actions: the
+	// results of each iteration.
+	Branches   [][]ActionResult `json:"branches,omitempty"`
+	Iterations [][]ActionResult `json:"iterations,omitempty"`
+
+	// Each attempt of an action with a retry policy
+	Attempts []ActionAttempt `json:"attempts,omitempty"`
+
+	// on_error "goto": the fallback results and whether they recovered
+	Fallback  []ActionResult `json:"fallback,omitempty"`
+	Recovered bool           `json:"recovered,omitempty"`
+}
+
+// ActionAttempt is one attempt at running an action.
+type ActionAttempt struct {
+	Attempt    int    `json:"attempt"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 // CreateAutomationRule creates a new automation rule in the database.
@@ -96,10 +163,16 @@ func (db *DB) CreateAutomationRule(ctx context.Context, rule AutomationRule) (in
 		enabled = 0
 	}
 
-	result, err := db.conn.ExecContext(ctx, `
-		INSERT INTO automation_rules (name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-	`, rule.Name, rule.Description, enabled, rule.TriggerType, string(triggerJSON), string(actionsJSON), rule.CooldownMs, rule.Priority)
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO automation_rules (name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, condition, concurrency, revision, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, rule.Name, rule.Description, enabled, rule.TriggerType, string(triggerJSON), string(actionsJSON), rule.CooldownMs, rule.Priority, rule.Condition, rule.Concurrency)
 
 	if err != nil {
 		// Check for unique constraint violation
@@ -114,6 +187,14 @@ func (db *DB) CreateAutomationRule(ctx context.Context, rule AutomationRule) (in
 		return 0, fmt.Errorf("failed to get inserted rule ID: %w", err)
 	}
 
+	if err := recordRuleRevision(ctx, tx, id, RevisionCreated, 0); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit automation rule: %w", err)
+	}
+
 	return id, nil
 }
 
@@ -126,13 +207,14 @@ func (db *DB) GetAutomationRule(ctx context.Context, id int64) (*AutomationRule,
 	var triggerJSON, actionsJSON string
 	var enabled int
 	var createdAt, updatedAt string
-	var lastRun sql.NullString
+	var lastRun, condition, concurrency sql.NullString
+	var revision sql.NullInt64
 
 	err := db.conn.QueryRowContext(ctx, `
-		SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count
+		SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count, condition, concurrency, revision
 		FROM automation_rules
 		WHERE id = ?
-	`, id).Scan(&rule.ID, &rule.Name, &rule.Description, &enabled, &rule.TriggerType, &triggerJSON, &actionsJSON, &rule.CooldownMs, &rule.Priority, &createdAt, &updatedAt, &lastRun, &rule.RunCount)
+	`, id).Scan(&rule.ID, &rule.Name, &rule.Description, &enabled, &rule.TriggerType, &triggerJSON, &actionsJSON, &rule.CooldownMs, &rule.Priority, &createdAt, &updatedAt, &lastRun, &rule.RunCount, &condition, &concurrency, &revision)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("automation rule with ID %d not found", id)
@@ -141,6 +223,9 @@ func (db *DB) GetAutomationRule(ctx context.Context, id int64) (*AutomationRule,
 	}
 
 	rule.Enabled = enabled == 1
+	rule.Condition = condition.String
+	rule.Concurrency = concurrency.String
+	rule.Revision = int(revision.Int64)
 
 	// Parse trigger_config JSON
 	if triggerJSON != "" {
@@ -184,13 +269,14 @@ func (db *DB) GetAutomationRuleByName(ctx context.Context, name string) (*Automa
 	var triggerJSON, actionsJSON string
 	var enabled int
 	var createdAt, updatedAt string
-	var lastRun sql.NullString
+	var lastRun, condition, concurrency sql.NullString
+	var revision sql.NullInt64
 
 	err := db.conn.QueryRowContext(ctx, `
-		SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count
+		SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count, condition, concurrency, revision
 		FROM automation_rules
 		WHERE name = ?
-	`, name).Scan(&rule.ID, &rule.Name, &rule.Description, &enabled, &rule.TriggerType, &triggerJSON, &actionsJSON, &rule.CooldownMs, &rule.Priority, &createdAt, &updatedAt, &lastRun, &rule.RunCount)
+	`, name).Scan(&rule.ID, &rule.Name, &rule.Description, &enabled, &rule.TriggerType, &triggerJSON, &actionsJSON, &rule.CooldownMs, &rule.Priority, &createdAt, &updatedAt, &lastRun, &rule.RunCount, &condition, &concurrency, &revision)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("automation rule '%s' not found", name)
@@ -199,6 +285,9 @@ func (db *DB) GetAutomationRuleByName(ctx context.Context, name string) (*Automa
 	}
 
 	rule.Enabled = enabled == 1
+	rule.Condition = condition.String
+	rule.Concurrency = concurrency.String
+	rule.Revision = int(revision.Int64)
 
 	// Parse trigger_config JSON
 	if triggerJSON != "" {
@@ -243,14 +332,14 @@ func (db *DB) ListAutomationRules(ctx context.Context, enabledOnly bool) ([]*Aut
 
 	if enabledOnly {
 		rows, err = db.conn.QueryContext(ctx, `
-			SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count
+			SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count, condition, concurrency, revision
 			FROM automation_rules
 			WHERE enabled = 1
 			ORDER BY priority DESC, created_at ASC
 		`)
 	} else {
 		rows, err = db.conn.QueryContext(ctx, `
-			SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count
+			SELECT id, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, created_at, updated_at, last_run, run_count, condition, concurrency, revision
 			FROM automation_rules
 			ORDER BY priority DESC, created_at ASC
 		`)
@@ -267,13 +356,17 @@ func (db *DB) ListAutomationRules(ctx context.Context, enabledOnly bool) ([]*Aut
 		var triggerJSON, actionsJSON string
 		var enabled int
 		var createdAt, updatedAt string
-		var lastRun sql.NullString
+		var lastRun, condition, concurrency sql.NullString
+		var revision sql.NullInt64
 
-		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Description, &enabled, &rule.TriggerType, &triggerJSON, &actionsJSON, &rule.CooldownMs, &rule.Priority, &createdAt, &updatedAt, &lastRun, &rule.RunCount); err != nil {
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Description, &enabled, &rule.TriggerType, &triggerJSON, &actionsJSON, &rule.CooldownMs, &rule.Priority, &createdAt, &updatedAt, &lastRun, &rule.RunCount, &condition, &concurrency, &revision); err != nil {
 			return nil, fmt.Errorf("failed to scan automation rule row: %w", err)
 		}
 
 		rule.Enabled = enabled == 1
+		rule.Condition = condition.String
+		rule.Concurrency = concurrency.String
+		rule.Revision = int(revision.Int64)
 
 		// Parse trigger_config JSON
 		if triggerJSON != "" {
@@ -332,11 +425,17 @@ func (db *DB) UpdateAutomationRule(ctx context.Context, rule AutomationRule) err
 		enabled = 1
 	}
 
-	result, err := db.conn.ExecContext(ctx, `
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed
+
+	result, err := tx.ExecContext(ctx, `
 		UPDATE automation_rules
-		SET name = ?, description = ?, enabled = ?, trigger_type = ?, trigger_config = ?, actions = ?, cooldown_ms = ?, priority = ?, updated_at = CURRENT_TIMESTAMP
+		SET name = ?, description = ?, enabled = ?, trigger_type = ?, trigger_config = ?, actions = ?, cooldown_ms = ?, priority = ?, condition = ?, concurrency = ?, revision = revision + 1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, rule.Name, rule.Description, enabled, rule.TriggerType, string(triggerJSON), string(actionsJSON), rule.CooldownMs, rule.Priority, rule.ID)
+	`, rule.Name, rule.Description, enabled, rule.TriggerType, string(triggerJSON), string(actionsJSON), rule.CooldownMs, rule.Priority, rule.Condition, rule.Concurrency, rule.ID)
 
 	if err != nil {
 		// Check for unique constraint violation
@@ -355,6 +454,14 @@ func (db *DB) UpdateAutomationRule(ctx context.Context, rule AutomationRule) err
 		return fmt.Errorf("automation rule with ID %d not found", rule.ID)
 	}
 
+	if err := recordRuleRevision(ctx, tx, rule.ID, RevisionUpdated, 0); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update of rule '%s': %w", rule.Name, err)
+	}
+
 	return nil
 }
 
@@ -411,31 +518,48 @@ func (db *DB) DeleteAutomationRuleByName(ctx context.Context, name string) error
 }
 
 // SetAutomationRuleEnabled enables or disables an automation rule.
+// Changing the state saves a new revision; setting the current state is a no-op.
 func (db *DB) SetAutomationRuleEnabled(ctx context.Context, id int64, enabled bool) error {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed
+
+	var current int
+	err = tx.QueryRowContext(ctx, "SELECT enabled FROM automation_rules WHERE id = ?", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("automation rule with ID %d not found", id)
+	} else if err != nil {
+		return fmt.Errorf("failed to get enabled state for rule ID %d: %w", id, err)
+	}
+
 	enabledInt := 0
+	change := RevisionDisabled
 	if enabled {
 		enabledInt = 1
+		change = RevisionEnabled
+	}
+	if current == enabledInt {
+		return nil
 	}
 
-	result, err := db.conn.ExecContext(ctx,
-		"UPDATE automation_rules SET enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE automation_rules SET enabled = ?, revision = revision + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
 		enabledInt, id,
-	)
-
-	if err != nil {
+	); err != nil {
 		return fmt.Errorf("failed to update enabled state for rule ID %d: %w", id, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check update result for rule ID %d: %w", id, err)
+	if err := recordRuleRevision(ctx, tx, id, change, 0); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("automation rule with ID %d not found", id)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit enabled state for rule ID %d: %w", id, err)
 	}
 
 	return nil
@@ -510,9 +634,9 @@ func (db *DB) CreateRuleExecution(ctx context.Context, exec RuleExecution) (int6
 	}
 
 	result, err := db.conn.ExecContext(ctx, `
-		INSERT INTO rule_executions (rule_id, rule_name, trigger_type, trigger_data, started_at, status, action_results, error, duration_ms)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, exec.RuleID, exec.RuleName, exec.TriggerType, triggerDataJSON, exec.StartedAt.Format(time.RFC3339), exec.Status, actionResultsJSON, exec.Error, exec.DurationMs)
+		INSERT INTO rule_executions (rule_id, rule_name, trigger_type, trigger_data, started_at, status, action_results, error, duration_ms, revision)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, exec.RuleID, exec.RuleName, exec.TriggerType, triggerDataJSON, exec.StartedAt.Format(time.RFC3339), exec.Status, actionResultsJSON, exec.Error, exec.DurationMs, exec.Revision)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to create rule execution record: %w", err)
@@ -579,7 +703,7 @@ func (db *DB) GetRecentRuleExecutions(ctx context.Context, limit int) ([]RuleExe
 	}
 
 	rows, err := db.conn.QueryContext(ctx, `
-		SELECT id, rule_id, rule_name, trigger_type, trigger_data, started_at, completed_at, status, action_results, error, duration_ms
+		SELECT id, rule_id, rule_name, trigger_type, trigger_data, started_at, completed_at, status, action_results, error, duration_ms, revision
 		FROM rule_executions
 		ORDER BY started_at DESC
 		LIMIT ?
@@ -603,7 +727,7 @@ func (db *DB) GetRuleExecutions(ctx context.Context, ruleID int64, limit int) ([
 	}
 
 	rows, err := db.conn.QueryContext(ctx, `
-		SELECT id, rule_id, rule_name, trigger_type, trigger_data, started_at, completed_at, status, action_results, error, duration_ms
+		SELECT id, rule_id, rule_name, trigger_type, trigger_data, started_at, completed_at, status, action_results, error, duration_ms, revision
 		FROM rule_executions
 		WHERE rule_id = ?
 		ORDER BY started_at DESC
@@ -627,10 +751,12 @@ func scanRuleExecutions(rows *sql.Rows) ([]RuleExecution, error) {
 		var triggerDataJSON, actionResultsJSON sql.NullString
 		var startedAt string
 		var completedAt sql.NullString
+		var revision sql.NullInt64
 
-		if err := rows.Scan(&exec.ID, &exec.RuleID, &exec.RuleName, &exec.TriggerType, &triggerDataJSON, &startedAt, &completedAt, &exec.Status, &actionResultsJSON, &exec.Error, &exec.DurationMs); err != nil {
+		if err := rows.Scan(&exec.ID, &exec.RuleID, &exec.RuleName, &exec.TriggerType, &triggerDataJSON, &startedAt, &completedAt, &exec.Status, &actionResultsJSON, &exec.Error, &exec.DurationMs, &revision); err != nil {
 			return nil, fmt.Errorf("failed to scan rule execution row: %w", err)
 		}
+		exec.Revision = int(revision.Int64)
 
 		// Parse trigger_data JSON
 		if triggerDataJSON.Valid && triggerDataJSON.String != "" {
@@ -200,6 +200,8 @@ func TestUpdateAutomationRule(t *testing.T) {
 		rule.ID = id
 		rule.Description = "Updated description"
 		rule.Enabled = false
+		rule.Condition = "obs.streaming && event.scene_name != 'BRB'"
+		rule.Concurrency = "queue"
 		rule.Actions = []RuleAction{
 			{Type: "set_scene", Parameters: map[string]interface{}{"scene_name": "New"}},
 			{Type: "delay", Parameters: map[string]interface{}{"delay_ms": float64(1000)}},
@@ -213,7 +215,14 @@ func TestUpdateAutomationRule(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "Updated description", updated.Description)
 		assert.False(t, updated.Enabled)
+		assert.Equal(t, rule.Condition, updated.Condition)
+		assert.Equal(t, "queue", updated.Concurrency)
 		assert.Len(t, updated.Actions, 2)
+
+		byName, err := db.GetAutomationRuleByName(ctx, "update-me")
+		require.NoError(t, err)
+		assert.Equal(t, rule.Condition, byName.Condition)
+		assert.Equal(t, "queue", byName.Concurrency)
 	})
 
 	t.Run("fails for non-existent rule", func(t *testing.T) {
@@ -45,7 +45,9 @@ func New(ctx context.Context, cfg Config) (*DB, error) {
 
 	// Open database connection with appropriate settings
 	// modernc.org/sqlite uses the same connection string format as mattn/go-sqlite3
-	conn, err := sql.Open("sqlite", dbPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)")
+	// busy_timeout makes concurrent writers (tool handlers and the automation
+	// engine) wait for the write lock instead of failing with SQLITE_BUSY
+	conn, err := sql.Open("sqlite", dbPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database at %s: %w", dbPath, err)
 	}
@@ -209,6 +211,78 @@ func (db *DB) migrate(ctx context.Context) error {
 
 		// Migration 18: Create index for rule_executions lookup by rule and time
 		`CREATE INDEX IF NOT EXISTS idx_rule_executions_rule_started ON rule_executions(rule_id, started_at DESC)`,
+
+		// Migration 19: Create undo_journal table for reversing tool calls
+		`CREATE TABLE IF NOT EXISTS undo_journal (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action_id INTEGER NOT NULL UNIQUE,
+			tool_name TEXT NOT NULL,
+			description TEXT,
+			operations TEXT NOT NULL,
+			undone_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (action_id) REFERENCES action_history(id) ON DELETE CASCADE
+		)`,
+
+		// Migration 20: Create index for finding the most recent undoable entries
+		`CREATE INDEX IF NOT EXISTS idx_undo_journal_pending ON undo_journal(undone_at, action_id DESC)`,
+
+		// Migration 21: Create tool_policies table for allow/deny/confirm rules on tool calls
+		`CREATE TABLE IF NOT EXISTS tool_policies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			tool TEXT,
+			tool_group TEXT,
+			effect TEXT NOT NULL,
+			conditions TEXT,
+			message TEXT,
+			priority INTEGER DEFAULT 0,
+			enabled INTEGER DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Migration 22: Create automation_variables table for template variables shared by rules
+		`CREATE TABLE IF NOT EXISTS automation_variables (
+			name TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Migration 23: Create automation_macros table for action lists shared by rules
+		`CREATE TABLE IF NOT EXISTS automation_macros (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL,
+			description TEXT,
+			parameters TEXT,
+			actions TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Migration 24: Create rule_revisions table keeping every saved version of a rule
+		`CREATE TABLE IF NOT EXISTS rule_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER NOT NULL,
+			revision INTEGER NOT NULL,
+			change TEXT NOT NULL,
+			restored_from INTEGER,
+			name TEXT NOT NULL,
+			description TEXT,
+			enabled INTEGER,
+			trigger_type TEXT NOT NULL,
+			trigger_config TEXT NOT NULL,
+			actions TEXT NOT NULL,
+			cooldown_ms INTEGER,
+			priority INTEGER,
+			condition TEXT,
+			concurrency TEXT,
+			actor_type TEXT,
+			actor_id TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (rule_id, revision),
+			FOREIGN KEY (rule_id) REFERENCES automation_rules(id) ON DELETE CASCADE
+		)`,
 	}
 
 	// Execute each migration in a transaction
@@ -224,10 +298,46 @@ func (db *DB) migrate(ctx context.Context) error {
 		}
 	}
 
+	// Columns added to existing tables. SQLite has no ADD COLUMN IF NOT
+	// EXISTS, so each column is added only when the table lacks it.
+	columnMigrations := []columnMigration{
+		// Column migration 0: Flag dry-run tool calls in action history
+		{table: "action_history", column: "dry_run", definition: "INTEGER DEFAULT 0"},
+		// Column migration 1: Link batch step actions to their execute_batch entry
+		{table: "action_history", column: "parent_id", definition: "INTEGER REFERENCES action_history(id) ON DELETE CASCADE"},
+		// Column migrations 2-3: Record who performed each action
+		{table: "action_history", column: "actor_type", definition: "TEXT"},
+		{table: "action_history", column: "actor_id", definition: "TEXT"},
+		// Column migration 4: Guard expression evaluated before a rule runs
+		{table: "automation_rules", column: "condition", definition: "TEXT"},
+		// Column migration 5: Policy for triggers that arrive while a rule is running
+		{table: "automation_rules", column: "concurrency", definition: "TEXT"},
+		// Column migration 6: Current revision number of each rule
+		{table: "automation_rules", column: "revision", definition: "INTEGER DEFAULT 0"},
+		// Column migration 7: Rule revision each execution ran
+		{table: "rule_executions", column: "revision", definition: "INTEGER"},
+	}
+
+	for i, m := range columnMigrations {
+		if err := addColumnIfMissing(ctx, tx, m); err != nil {
+			return fmt.Errorf("failed to execute column migration %d: %w", i, err)
+		}
+	}
+
+	// Rules saved before revisions were kept start their history at
+	// revision 1. Both statements are no-ops once every rule has one.
+	if _, err := tx.ExecContext(ctx, "UPDATE automation_rules SET revision = 1 WHERE revision IS NULL OR revision = 0"); err != nil {
+		return fmt.Errorf("failed to number existing rule revisions: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, insertRuleRevisionSQL+" WHERE NOT EXISTS (SELECT 1 FROM rule_revisions WHERE rule_id = automation_rules.id)",
+		RevisionCreated, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to record existing rule revisions: %w", err)
+	}
+
 	// Record successful migration
 	if _, err := tx.ExecContext(ctx,
 		"INSERT OR REPLACE INTO schema_version (version) VALUES (?)",
-		len(migrations),
+		len(migrations)+len(columnMigrations),
 	); err != nil {
 		return fmt.Errorf("failed to record schema version: %w", err)
 	}
@@ -239,6 +349,47 @@ func (db *DB) migrate(ctx context.Context) error {
 	return nil
 }
 
+// columnMigration adds a column to an existing table.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string // Column type and constraints, e.g. "INTEGER DEFAULT 0"
+}
+
+// addColumnIfMissing adds the migration's column unless the table already has it.
+func addColumnIfMissing(ctx context.Context, tx *sql.Tx, m columnMigration) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", m.table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", m.table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return fmt.Errorf("failed to scan column info for %s: %w", m.table, err)
+		}
+		if name == m.column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read column info for %s: %w", m.table, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+	}
+	return nil
+}
+
 // Close closes the database connection pool.
 // It's safe to call multiple times.
 func (db *DB) Close() error {
@@ -105,6 +105,42 @@ func TestNew(t *testing.T) {
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, count, 4) // At least our 4 main tables
 	})
+
+	t.Run("adds columns to tables from older schemas", func(t *testing.T) {
+		tempDir, err := os.MkdirTemp("", "agentic-obs-test-*")
+		require.NoError(t, err)
+		defer os.RemoveAll(tempDir)
+
+		dbPath := filepath.Join(tempDir, "test.db")
+
+		// Simulate a database created before action_history.dry_run existed
+		conn, err := sql.Open("sqlite", dbPath)
+		require.NoError(t, err)
+		_, err = conn.Exec(`CREATE TABLE action_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			tool_name TEXT,
+			input TEXT,
+			output TEXT,
+			success INTEGER DEFAULT 1,
+			duration_ms INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`)
+		require.NoError(t, err)
+		_, err = conn.Exec(`INSERT INTO action_history (action, tool_name) VALUES ('Old action', 'list_scenes')`)
+		require.NoError(t, err)
+		conn.Close()
+
+		db, err := New(context.Background(), Config{Path: dbPath})
+		require.NoError(t, err)
+		defer db.Close()
+
+		actions, err := db.GetRecentActions(context.Background(), 10)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "Old action", actions[0].Action)
+		assert.False(t, actions[0].DryRun)
+	})
 }
 
 func TestDB_Ping(t *testing.T) {
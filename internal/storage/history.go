@@ -4,9 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
+// Actor types identify who performed an action.
+const (
+	ActorMCP        = "mcp"        // MCP client; actor ID is the client's name/version from initialize
+	ActorHTTP       = "http"       // Web UI; actor ID identifies the bearer token, or the remote address without one
+	ActorAutomation = "automation" // Automation rule; actor ID is the rule ID
+	ActorTUI        = "tui"        // Terminal dashboard (it only reads today, so no actions carry this yet)
+)
+
+// ActorTypes lists the actor types in display order.
+var ActorTypes = []string{ActorMCP, ActorHTTP, ActorAutomation, ActorTUI}
+
 // ActionRecord represents a single action in the history log.
 type ActionRecord struct {
 	ID         int64     `json:"id"`
@@ -16,9 +28,21 @@ type ActionRecord struct {
 	Output     string    `json:"output,omitempty"`
 	Success    bool      `json:"success"`
 	DurationMs int64     `json:"duration_ms,omitempty"`
+	DryRun     bool      `json:"dry_run,omitempty"`    // Call was validated but nothing was applied
+	ParentID   int64     `json:"parent_id,omitempty"`  // Batch call this action ran as a step of (0 = top level)
+	ActorType  string    `json:"actor_type,omitempty"` // Who performed the action (ActorMCP, ActorHTTP, ...)
+	ActorID    string    `json:"actor_id,omitempty"`   // Which client, token or rule, depending on ActorType
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// ActionFilter narrows the actions returned by FilterActions. Empty fields
+// match everything.
+type ActionFilter struct {
+	ToolName  string
+	ActorType string
+	ActorID   string
+}
+
 // RecordAction adds a new action to the history log.
 func (db *DB) RecordAction(ctx context.Context, record ActionRecord) (int64, error) {
 	db.mu.RLock()
@@ -28,16 +52,29 @@ func (db *DB) RecordAction(ctx context.Context, record ActionRecord) (int64, err
 	if record.Success {
 		successInt = 1
 	}
+	dryRunInt := 0
+	if record.DryRun {
+		dryRunInt = 1
+	}
+
+	var parentID sql.NullInt64
+	if record.ParentID != 0 {
+		parentID = sql.NullInt64{Int64: record.ParentID, Valid: true}
+	}
 
 	result, err := db.conn.ExecContext(ctx,
-		`INSERT INTO action_history (action, tool_name, input, output, success, duration_ms, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO action_history (action, tool_name, input, output, success, duration_ms, dry_run, parent_id, actor_type, actor_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		record.Action,
 		record.ToolName,
 		record.Input,
 		record.Output,
 		successInt,
 		record.DurationMs,
+		dryRunInt,
+		parentID,
+		record.ActorType,
+		record.ActorID,
 		time.Now(),
 	)
 	if err != nil {
@@ -63,8 +100,9 @@ func (db *DB) GetRecentActions(ctx context.Context, limit int) ([]ActionRecord,
 	}
 
 	rows, err := db.conn.QueryContext(ctx,
-		`SELECT id, action, tool_name, input, output, success, duration_ms, created_at
+		`SELECT id, action, tool_name, input, output, success, duration_ms, dry_run, parent_id, actor_type, actor_id, created_at
 		 FROM action_history
+		 WHERE parent_id IS NULL
 		 ORDER BY created_at DESC
 		 LIMIT ?`,
 		limit,
@@ -87,9 +125,9 @@ func (db *DB) GetActionsByTool(ctx context.Context, toolName string, limit int)
 	}
 
 	rows, err := db.conn.QueryContext(ctx,
-		`SELECT id, action, tool_name, input, output, success, duration_ms, created_at
+		`SELECT id, action, tool_name, input, output, success, duration_ms, dry_run, parent_id, actor_type, actor_id, created_at
 		 FROM action_history
-		 WHERE tool_name = ?
+		 WHERE tool_name = ? AND parent_id IS NULL
 		 ORDER BY created_at DESC
 		 LIMIT ?`,
 		toolName,
@@ -113,9 +151,9 @@ func (db *DB) GetActionsSince(ctx context.Context, since time.Time, limit int) (
 	}
 
 	rows, err := db.conn.QueryContext(ctx,
-		`SELECT id, action, tool_name, input, output, success, duration_ms, created_at
+		`SELECT id, action, tool_name, input, output, success, duration_ms, dry_run, parent_id, actor_type, actor_id, created_at
 		 FROM action_history
-		 WHERE created_at >= ?
+		 WHERE created_at >= ? AND parent_id IS NULL
 		 ORDER BY created_at DESC
 		 LIMIT ?`,
 		since,
@@ -129,6 +167,68 @@ func (db *DB) GetActionsSince(ctx context.Context, since time.Time, limit int) (
 	return scanActionRecords(rows)
 }
 
+// FilterActions retrieves top-level actions matching filter, newest first.
+func (db *DB) FilterActions(ctx context.Context, filter ActionFilter, limit int) ([]ActionRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	conditions := []string{"parent_id IS NULL"}
+	var args []interface{}
+	if filter.ToolName != "" {
+		conditions = append(conditions, "tool_name = ?")
+		args = append(args, filter.ToolName)
+	}
+	if filter.ActorType != "" {
+		conditions = append(conditions, "actor_type = ?")
+		args = append(args, filter.ActorType)
+	}
+	if filter.ActorID != "" {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	args = append(args, limit)
+
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, action, tool_name, input, output, success, duration_ms, dry_run, parent_id, actor_type, actor_id, created_at
+		 FROM action_history
+		 WHERE `+strings.Join(conditions, " AND ")+`
+		 ORDER BY created_at DESC
+		 LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query filtered actions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanActionRecords(rows)
+}
+
+// GetChildActions retrieves the step actions recorded under a batch action,
+// in the order they ran.
+func (db *DB) GetChildActions(ctx context.Context, parentID int64) ([]ActionRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, action, tool_name, input, output, success, duration_ms, dry_run, parent_id, actor_type, actor_id, created_at
+		 FROM action_history
+		 WHERE parent_id = ?
+		 ORDER BY id ASC`,
+		parentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query child actions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanActionRecords(rows)
+}
+
 // GetActionStats returns statistics about recorded actions.
 func (db *DB) GetActionStats(ctx context.Context) (map[string]interface{}, error) {
 	db.mu.RLock()
@@ -225,8 +325,8 @@ func scanActionRecords(rows *sql.Rows) ([]ActionRecord, error) {
 
 	for rows.Next() {
 		var r ActionRecord
-		var toolName, input, output sql.NullString
-		var durationMs sql.NullInt64
+		var toolName, input, output, actorType, actorID sql.NullString
+		var durationMs, dryRun, parentID sql.NullInt64
 		var success int
 		var createdAt string
 
@@ -238,6 +338,10 @@ func scanActionRecords(rows *sql.Rows) ([]ActionRecord, error) {
 			&output,
 			&success,
 			&durationMs,
+			&dryRun,
+			&parentID,
+			&actorType,
+			&actorID,
 			&createdAt,
 		)
 		if err != nil {
@@ -248,6 +352,10 @@ func scanActionRecords(rows *sql.Rows) ([]ActionRecord, error) {
 		r.Input = input.String
 		r.Output = output.String
 		r.Success = success == 1
+		r.DryRun = dryRun.Int64 == 1
+		r.ParentID = parentID.Int64
+		r.ActorType = actorType.String
+		r.ActorID = actorID.String
 		if durationMs.Valid {
 			r.DurationMs = durationMs.Int64
 		}
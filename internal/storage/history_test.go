@@ -391,4 +391,105 @@ func TestActionRecordFields(t *testing.T) {
 		assert.True(t, retrieved.Success)
 		assert.Equal(t, int64(0), retrieved.DurationMs)
 	})
+
+	t.Run("round-trips dry run flag", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		_, err := db.RecordAction(context.Background(), ActionRecord{Action: "Remove scene", ToolName: "remove_scene", Success: true, DryRun: true})
+		require.NoError(t, err)
+
+		actions, err := db.GetActionsByTool(context.Background(), "remove_scene", 1)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.True(t, actions[0].DryRun)
+	})
+}
+
+func TestGetChildActions(t *testing.T) {
+	t.Run("returns batch steps in order and hides them from top-level lists", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+		ctx := context.Background()
+
+		parentID, err := db.RecordAction(ctx, ActionRecord{Action: "Execute batch", ToolName: "execute_batch", Success: true})
+		require.NoError(t, err)
+		for _, tool := range []string{"create_scene", "set_current_scene"} {
+			_, err := db.RecordAction(ctx, ActionRecord{Action: tool, ToolName: tool, Success: true, ParentID: parentID})
+			require.NoError(t, err)
+		}
+
+		children, err := db.GetChildActions(ctx, parentID)
+		require.NoError(t, err)
+		require.Len(t, children, 2)
+		assert.Equal(t, "create_scene", children[0].ToolName)
+		assert.Equal(t, "set_current_scene", children[1].ToolName)
+		assert.Equal(t, parentID, children[0].ParentID)
+
+		recent, err := db.GetRecentActions(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 1)
+		assert.Equal(t, parentID, recent[0].ID)
+
+		byTool, err := db.GetActionsByTool(ctx, "create_scene", 0)
+		require.NoError(t, err)
+		assert.Empty(t, byTool)
+	})
+
+	t.Run("rejects unknown parent", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		_, err := db.RecordAction(context.Background(), ActionRecord{Action: "Orphan", Success: true, ParentID: 999})
+		assert.Error(t, err)
+	})
+}
+
+func TestFilterActions(t *testing.T) {
+	db, cleanup := testDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	records := []ActionRecord{
+		{Action: "Set scene", ToolName: "set_current_scene", Success: true, ActorType: ActorMCP, ActorID: "client-a/1.0"},
+		{Action: "Set scene", ToolName: "set_current_scene", Success: true, ActorType: ActorHTTP, ActorID: "127.0.0.1"},
+		{Action: "Run rule", ToolName: "automation", Success: true, ActorType: ActorAutomation, ActorID: "7"},
+		{Action: "Mute", ToolName: "toggle_input_mute", Success: true, ActorType: ActorMCP, ActorID: "client-b/2.0"},
+	}
+	for _, r := range records {
+		_, err := db.RecordAction(ctx, r)
+		require.NoError(t, err)
+	}
+
+	t.Run("round-trips actor fields", func(t *testing.T) {
+		actions, err := db.FilterActions(ctx, ActionFilter{ActorType: ActorAutomation}, 0)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, ActorAutomation, actions[0].ActorType)
+		assert.Equal(t, "7", actions[0].ActorID)
+	})
+
+	t.Run("filters by actor type and ID", func(t *testing.T) {
+		actions, err := db.FilterActions(ctx, ActionFilter{ActorType: ActorMCP}, 0)
+		require.NoError(t, err)
+		assert.Len(t, actions, 2)
+
+		actions, err = db.FilterActions(ctx, ActionFilter{ActorType: ActorMCP, ActorID: "client-b/2.0"}, 0)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "toggle_input_mute", actions[0].ToolName)
+	})
+
+	t.Run("combines tool and actor filters", func(t *testing.T) {
+		actions, err := db.FilterActions(ctx, ActionFilter{ToolName: "set_current_scene", ActorType: ActorHTTP}, 0)
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		assert.Equal(t, "127.0.0.1", actions[0].ActorID)
+	})
+
+	t.Run("empty filter returns all top-level actions", func(t *testing.T) {
+		actions, err := db.FilterActions(ctx, ActionFilter{}, 0)
+		require.NoError(t, err)
+		assert.Len(t, actions, 4)
+	})
 }
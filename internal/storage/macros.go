@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Automation macros are named action lists that rules call with the
+// run_macro action. A macro declares the parameters it takes; its actions
+// read the arguments through "{{args.name}}" templates.
+
+// Macro is a named, parameterized action list.
+type Macro struct {
+	ID          int64            `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Parameters  []MacroParameter `json:"parameters,omitempty"`
+	Actions     []RuleAction     `json:"actions"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// MacroParameter is a parameter a macro takes.
+type MacroParameter struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"` // Used when an optional argument is not given
+}
+
+// CreateMacro creates a new macro and returns its ID.
+func (db *DB) CreateMacro(ctx context.Context, macro Macro) (int64, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	paramsJSON, actionsJSON, err := marshalMacro(macro)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO automation_macros (name, description, parameters, actions, created_at, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, macro.Name, macro.Description, paramsJSON, actionsJSON)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: automation_macros.name") {
+			return 0, fmt.Errorf("macro with name '%s' already exists", macro.Name)
+		}
+		return 0, fmt.Errorf("failed to create macro: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get inserted macro ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetMacroByName retrieves a macro by name.
+func (db *DB) GetMacroByName(ctx context.Context, name string) (*Macro, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	row := db.conn.QueryRowContext(ctx, `
+		SELECT id, name, description, parameters, actions, created_at, updated_at
+		FROM automation_macros
+		WHERE name = ?
+	`, name)
+	macro, err := scanMacro(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("macro '%s' not found", name)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get macro '%s': %w", name, err)
+	}
+
+	return macro, nil
+}
+
+// ListMacros returns all macros ordered by name.
+func (db *DB) ListMacros(ctx context.Context) ([]*Macro, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, name, description, parameters, actions, created_at, updated_at
+		FROM automation_macros
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list macros: %w", err)
+	}
+	defer rows.Close()
+
+	var macros []*Macro
+	for rows.Next() {
+		macro, err := scanMacro(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan macro row: %w", err)
+		}
+		macros = append(macros, macro)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating macro rows: %w", err)
+	}
+
+	return macros, nil
+}
+
+// UpdateMacro replaces the name, description, parameters and actions of
+// the macro with macro.ID.
+func (db *DB) UpdateMacro(ctx context.Context, macro Macro) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	paramsJSON, actionsJSON, err := marshalMacro(macro)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE automation_macros
+		SET name = ?, description = ?, parameters = ?, actions = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, macro.Name, macro.Description, paramsJSON, actionsJSON, macro.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: automation_macros.name") {
+			return fmt.Errorf("macro with name '%s' already exists", macro.Name)
+		}
+		return fmt.Errorf("failed to update macro '%s': %w", macro.Name, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check update result for macro '%s': %w", macro.Name, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("macro with ID %d not found", macro.ID)
+	}
+
+	return nil
+}
+
+// DeleteMacroByName deletes a macro by name.
+func (db *DB) DeleteMacroByName(ctx context.Context, name string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	result, err := db.conn.ExecContext(ctx, "DELETE FROM automation_macros WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete macro '%s': %w", name, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check delete result for macro '%s': %w", name, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("macro '%s' not found", name)
+	}
+
+	return nil
+}
+
+// marshalMacro serializes a macro's parameters and actions to JSON.
+func marshalMacro(macro Macro) (string, string, error) {
+	paramsJSON, err := json.Marshal(macro.Parameters)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to serialize parameters to JSON: %w", err)
+	}
+
+	actionsJSON, err := json.Marshal(macro.Actions)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to serialize actions to JSON: %w", err)
+	}
+
+	return string(paramsJSON), string(actionsJSON), nil
+}
+
+// scanMacro reads a macro from a row of id, name, description, parameters,
+// actions, created_at and updated_at.
+func scanMacro(row interface{ Scan(dest ...any) error }) (*Macro, error) {
+	var macro Macro
+	var description, paramsJSON sql.NullString
+	var actionsJSON, createdAt, updatedAt string
+
+	if err := row.Scan(&macro.ID, &macro.Name, &description, &paramsJSON, &actionsJSON, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	macro.Description = description.String
+
+	if paramsJSON.String != "" {
+		if err := json.Unmarshal([]byte(paramsJSON.String), &macro.Parameters); err != nil {
+			return nil, fmt.Errorf("failed to parse parameters JSON for macro '%s': %w", macro.Name, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(actionsJSON), &macro.Actions); err != nil {
+		return nil, fmt.Errorf("failed to parse actions JSON for macro '%s': %w", macro.Name, err)
+	}
+
+	var err error
+	macro.CreatedAt, err = parseTimestamp(createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse created_at timestamp for macro '%s': %w", macro.Name, err)
+	}
+	macro.UpdatedAt, err = parseTimestamp(updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse updated_at timestamp for macro '%s': %w", macro.Name, err)
+	}
+
+	return &macro, nil
+}
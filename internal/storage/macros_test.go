@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacros(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	macro := Macro{
+		Name:        "go-to-brb",
+		Description: "Switch to the BRB scene",
+		Parameters: []MacroParameter{
+			{Name: "scene", Required: true},
+			{Name: "mic", Default: "Mic/Aux"},
+		},
+		Actions: []RuleAction{
+			{Type: "set_scene", Parameters: map[string]interface{}{"scene_name": "{{args.scene}}"}},
+			{Type: "toggle_mute", Parameters: map[string]interface{}{"input_name": "{{args.mic}}"}},
+		},
+	}
+	id, err := db.CreateMacro(ctx, macro)
+	require.NoError(t, err)
+
+	_, err = db.CreateMacro(ctx, macro)
+	assert.ErrorContains(t, err, "already exists")
+
+	got, err := db.GetMacroByName(ctx, "go-to-brb")
+	require.NoError(t, err)
+	assert.Equal(t, id, got.ID)
+	assert.Equal(t, macro.Parameters, got.Parameters)
+	assert.Equal(t, macro.Actions, got.Actions)
+	assert.False(t, got.CreatedAt.IsZero())
+
+	got.Name = "brb"
+	got.Parameters = nil
+	require.NoError(t, db.UpdateMacro(ctx, *got))
+	_, err = db.GetMacroByName(ctx, "go-to-brb")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = db.CreateMacro(ctx, Macro{Name: "outro", Actions: []RuleAction{{Type: "stop_streaming"}}})
+	require.NoError(t, err)
+	macros, err := db.ListMacros(ctx)
+	require.NoError(t, err)
+	require.Len(t, macros, 2)
+	assert.Equal(t, "brb", macros[0].Name)
+	assert.Empty(t, macros[0].Parameters)
+	assert.Equal(t, "outro", macros[1].Name)
+
+	require.NoError(t, db.DeleteMacroByName(ctx, "brb"))
+	assert.ErrorContains(t, db.DeleteMacroByName(ctx, "brb"), "not found")
+	assert.ErrorContains(t, db.UpdateMacro(ctx, Macro{ID: id, Name: "brb"}), "not found")
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Policy effects decide what happens to a tool call a rule applies to.
+const (
+	PolicyEffectAllow   = "allow"
+	PolicyEffectDeny    = "deny"
+	PolicyEffectConfirm = "confirm"
+)
+
+// PolicyRule allows, refuses, or requires confirmation for calls to a tool
+// or a tool group. A rule targets either Tool ("*" matches every tool) or
+// Group.
+type PolicyRule struct {
+	ID         int64            `json:"id"`
+	Name       string           `json:"name"`
+	Tool       string           `json:"tool,omitempty"`
+	Group      string           `json:"group,omitempty"`
+	Effect     string           `json:"effect"`
+	Conditions PolicyConditions `json:"conditions"`
+	Message    string           `json:"message,omitempty"` // Shown when a call is refused
+	Priority   int              `json:"priority,omitempty"`
+	Enabled    bool             `json:"enabled"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// PolicyConditions limit when a policy rule applies. Every condition that is
+// set must hold; a rule without conditions always applies.
+type PolicyConditions struct {
+	WhileStreaming *bool             `json:"while_streaming,omitempty"`
+	WhileRecording *bool             `json:"while_recording,omitempty"`
+	TimeWindow     *PolicyTimeWindow `json:"time_window,omitempty"`
+	RateLimit      *PolicyRateLimit  `json:"rate_limit,omitempty"`
+}
+
+// PolicyTimeWindow holds during a daily time range. End before Start wraps
+// past midnight. Days restricts the window to weekdays ("mon".."sun") of its
+// start; Timezone is an IANA name and defaults to local time.
+type PolicyTimeWindow struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Days     []string `json:"days,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// PolicyRateLimit holds once the tool has been called MaxCalls times within
+// the last WindowSeconds.
+type PolicyRateLimit struct {
+	MaxCalls      int `json:"max_calls"`
+	WindowSeconds int `json:"window_seconds"`
+}
+
+// SavePolicyRule creates a policy rule, or replaces the rule with the same name.
+// Returns the ID of the saved rule.
+func (db *DB) SavePolicyRule(ctx context.Context, rule PolicyRule) (int64, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	conditionsJSON, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize policy conditions to JSON: %w", err)
+	}
+
+	enabled := 0
+	if rule.Enabled {
+		enabled = 1
+	}
+
+	var id int64
+	err = db.conn.QueryRowContext(ctx, `
+		INSERT INTO tool_policies (name, tool, tool_group, effect, conditions, message, priority, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET
+			tool = excluded.tool,
+			tool_group = excluded.tool_group,
+			effect = excluded.effect,
+			conditions = excluded.conditions,
+			message = excluded.message,
+			priority = excluded.priority,
+			enabled = excluded.enabled,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`, rule.Name, rule.Tool, rule.Group, rule.Effect, string(conditionsJSON), rule.Message, rule.Priority, enabled).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save policy rule '%s': %w", rule.Name, err)
+	}
+
+	return id, nil
+}
+
+// ListPolicyRules returns all policy rules, highest priority first.
+func (db *DB) ListPolicyRules(ctx context.Context) ([]PolicyRule, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, name, tool, tool_group, effect, conditions, message, priority, enabled, created_at, updated_at
+		FROM tool_policies
+		ORDER BY priority DESC, id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []PolicyRule
+	for rows.Next() {
+		var rule PolicyRule
+		var tool, group, conditionsJSON, message sql.NullString
+		var enabled int
+		var createdAt, updatedAt string
+
+		if err := rows.Scan(&rule.ID, &rule.Name, &tool, &group, &rule.Effect, &conditionsJSON, &message, &rule.Priority, &enabled, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan policy rule row: %w", err)
+		}
+
+		rule.Tool = tool.String
+		rule.Group = group.String
+		rule.Message = message.String
+		rule.Enabled = enabled == 1
+		if conditionsJSON.String != "" {
+			if err := json.Unmarshal([]byte(conditionsJSON.String), &rule.Conditions); err != nil {
+				return nil, fmt.Errorf("failed to parse conditions JSON for policy rule '%s': %w", rule.Name, err)
+			}
+		}
+		rule.CreatedAt, _ = parseTimestamp(createdAt)
+		rule.UpdatedAt, _ = parseTimestamp(updatedAt)
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating policy rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// DeletePolicyRule removes a policy rule by name.
+func (db *DB) DeletePolicyRule(ctx context.Context, name string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	result, err := db.conn.ExecContext(ctx, "DELETE FROM tool_policies WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete policy rule '%s': %w", name, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("policy rule '%s' not found", name)
+	}
+
+	return nil
+}
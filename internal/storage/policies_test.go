@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyRules(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("round-trips a rule with conditions", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		streaming := true
+		id, err := db.SavePolicyRule(ctx, PolicyRule{
+			Name:    "no-scene-changes-live",
+			Group:   "Layout",
+			Effect:  PolicyEffectDeny,
+			Message: "Layouts are frozen while live",
+			Enabled: true,
+			Conditions: PolicyConditions{
+				WhileStreaming: &streaming,
+				TimeWindow:     &PolicyTimeWindow{Start: "22:00", End: "06:00", Days: []string{"fri", "sat"}, Timezone: "UTC"},
+				RateLimit:      &PolicyRateLimit{MaxCalls: 3, WindowSeconds: 60},
+			},
+		})
+		require.NoError(t, err)
+		assert.Greater(t, id, int64(0))
+
+		rules, err := db.ListPolicyRules(ctx)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		rule := rules[0]
+		assert.Equal(t, "Layout", rule.Group)
+		assert.Empty(t, rule.Tool)
+		assert.Equal(t, PolicyEffectDeny, rule.Effect)
+		assert.True(t, rule.Enabled)
+		require.NotNil(t, rule.Conditions.WhileStreaming)
+		assert.True(t, *rule.Conditions.WhileStreaming)
+		assert.Nil(t, rule.Conditions.WhileRecording)
+		assert.Equal(t, []string{"fri", "sat"}, rule.Conditions.TimeWindow.Days)
+		assert.Equal(t, 3, rule.Conditions.RateLimit.MaxCalls)
+		assert.False(t, rule.CreatedAt.IsZero())
+	})
+
+	t.Run("replaces a rule with the same name", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		first, err := db.SavePolicyRule(ctx, PolicyRule{Name: "stream", Tool: "start_streaming", Effect: PolicyEffectConfirm, Enabled: true})
+		require.NoError(t, err)
+		second, err := db.SavePolicyRule(ctx, PolicyRule{Name: "stream", Tool: "start_streaming", Effect: PolicyEffectDeny})
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+
+		rules, err := db.ListPolicyRules(ctx)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.Equal(t, PolicyEffectDeny, rules[0].Effect)
+		assert.False(t, rules[0].Enabled)
+	})
+
+	t.Run("lists highest priority first", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		_, err := db.SavePolicyRule(ctx, PolicyRule{Name: "low", Tool: "*", Effect: PolicyEffectAllow, Enabled: true})
+		require.NoError(t, err)
+		_, err = db.SavePolicyRule(ctx, PolicyRule{Name: "high", Tool: "*", Effect: PolicyEffectDeny, Priority: 10, Enabled: true})
+		require.NoError(t, err)
+
+		rules, err := db.ListPolicyRules(ctx)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, "high", rules[0].Name)
+		assert.Equal(t, "low", rules[1].Name)
+	})
+
+	t.Run("deletes a rule by name", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		_, err := db.SavePolicyRule(ctx, PolicyRule{Name: "stream", Tool: "start_streaming", Effect: PolicyEffectDeny, Enabled: true})
+		require.NoError(t, err)
+		require.NoError(t, db.DeletePolicyRule(ctx, "stream"))
+
+		rules, err := db.ListPolicyRules(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+
+		err = db.DeletePolicyRule(ctx, "stream")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
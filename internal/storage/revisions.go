@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rule revisions keep every saved version of an automation rule. Creating,
+// updating, enabling, disabling and restoring a rule each bump the rule's
+// revision number and copy the saved row into rule_revisions in the same
+// transaction, so the history always matches what the engine loaded.
+
+// Changes recorded with a rule revision.
+const (
+	RevisionCreated  = "created"
+	RevisionUpdated  = "updated"
+	RevisionEnabled  = "enabled"
+	RevisionDisabled = "disabled"
+	RevisionRestored = "restored"
+)
+
+// RuleRevision is a saved version of an automation rule.
+type RuleRevision struct {
+	ID           int64          `json:"id"`
+	RuleID       int64          `json:"rule_id"`
+	Revision     int            `json:"revision"`
+	Change       string         `json:"change"`                  // What produced the revision (RevisionCreated, ...)
+	RestoredFrom int            `json:"restored_from,omitempty"` // Revision copied by a restore
+	Rule         AutomationRule `json:"rule"`                    // The rule as saved; run stats are not kept
+	ActorType    string         `json:"actor_type,omitempty"`    // Who saved the revision (ActorMCP, ...)
+	ActorID      string         `json:"actor_id,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type actorContextKey struct{}
+
+type contextActor struct {
+	actorType string
+	actorID   string
+}
+
+// WithActor returns a context that attributes the rule revisions saved with
+// it to the given actor.
+func WithActor(ctx context.Context, actorType, actorID string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, contextActor{actorType: actorType, actorID: actorID})
+}
+
+// actorFromContext returns the actor set with WithActor, if any.
+func actorFromContext(ctx context.Context) (string, string) {
+	if a, ok := ctx.Value(actorContextKey{}).(contextActor); ok {
+		return a.actorType, a.actorID
+	}
+	return "", ""
+}
+
+// insertRuleRevisionSQL copies automation_rules rows into rule_revisions.
+// Callers append the WHERE clause selecting the rules and bind the change,
+// restored_from, actor_type and actor_id.
+const insertRuleRevisionSQL = `
+	INSERT INTO rule_revisions (rule_id, revision, change, restored_from, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, condition, concurrency, actor_type, actor_id, created_at)
+	SELECT id, revision, ?, ?, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, condition, concurrency, ?, ?, updated_at
+	FROM automation_rules`
+
+// recordRuleRevision saves the current state of a rule as its latest
+// revision. The rule's revision number must already be bumped.
+func recordRuleRevision(ctx context.Context, tx *sql.Tx, ruleID int64, change string, restoredFrom int) error {
+	actorType, actorID := actorFromContext(ctx)
+	var from interface{}
+	if restoredFrom > 0 {
+		from = restoredFrom
+	}
+	if _, err := tx.ExecContext(ctx, insertRuleRevisionSQL+" WHERE id = ?", change, from, actorType, actorID, ruleID); err != nil {
+		return fmt.Errorf("failed to record revision of rule ID %d: %w", ruleID, err)
+	}
+	return nil
+}
+
+const ruleRevisionColumns = `id, rule_id, revision, change, restored_from, name, description, enabled, trigger_type, trigger_config, actions, cooldown_ms, priority, condition, concurrency, actor_type, actor_id, created_at`
+
+// ListRuleRevisions returns the revisions of a rule, newest first.
+// limit specifies maximum number of records to return (0 = use default of 20).
+func (db *DB) ListRuleRevisions(ctx context.Context, ruleID int64, limit int) ([]RuleRevision, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+ruleRevisionColumns+`
+		FROM rule_revisions
+		WHERE rule_id = ?
+		ORDER BY revision DESC
+		LIMIT ?
+	`, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions for rule ID %d: %w", ruleID, err)
+	}
+	defer rows.Close()
+
+	var revisions []RuleRevision
+	for rows.Next() {
+		rev, err := scanRuleRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// GetRuleRevision returns one revision of a rule.
+func (db *DB) GetRuleRevision(ctx context.Context, ruleID int64, revision int) (*RuleRevision, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	row := db.conn.QueryRowContext(ctx, `
+		SELECT `+ruleRevisionColumns+`
+		FROM rule_revisions
+		WHERE rule_id = ? AND revision = ?
+	`, ruleID, revision)
+
+	rev, err := scanRuleRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("revision %d of rule ID %d not found", revision, ruleID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// RestoreRuleRevision replaces a rule's definition with that of an earlier
+// revision and saves the result as a new revision. The rule keeps its ID,
+// enabled state and run stats.
+func (db *DB) RestoreRuleRevision(ctx context.Context, ruleID int64, revision int) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Safe to call even if committed
+
+	var name string
+	err = tx.QueryRowContext(ctx,
+		"SELECT name FROM rule_revisions WHERE rule_id = ? AND revision = ?",
+		ruleID, revision,
+	).Scan(&name)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("revision %d of rule ID %d not found", revision, ruleID)
+	} else if err != nil {
+		return fmt.Errorf("failed to get revision %d of rule ID %d: %w", revision, ruleID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE automation_rules
+		SET (name, description, trigger_type, trigger_config, actions, cooldown_ms, priority, condition, concurrency) = (
+				SELECT name, description, trigger_type, trigger_config, actions, cooldown_ms, priority, condition, concurrency
+				FROM rule_revisions
+				WHERE rule_id = ? AND revision = ?
+			),
+			revision = revision + 1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, ruleID, revision, ruleID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: automation_rules.name") {
+			return fmt.Errorf("automation rule with name '%s' already exists", name)
+		}
+		return fmt.Errorf("failed to restore revision %d of rule ID %d: %w", revision, ruleID, err)
+	}
+
+	if err := recordRuleRevision(ctx, tx, ruleID, RevisionRestored, revision); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit restore of rule ID %d: %w", ruleID, err)
+	}
+
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRuleRevision scans a row selected with ruleRevisionColumns.
+func scanRuleRevision(row rowScanner) (*RuleRevision, error) {
+	var rev RuleRevision
+	var triggerJSON, actionsJSON, createdAt string
+	var enabled int
+	var restoredFrom sql.NullInt64
+	var description, condition, concurrency, actorType, actorID sql.NullString
+	var cooldownMs, priority sql.NullInt64
+
+	if err := row.Scan(&rev.ID, &rev.RuleID, &rev.Revision, &rev.Change, &restoredFrom, &rev.Rule.Name, &description, &enabled,
+		&rev.Rule.TriggerType, &triggerJSON, &actionsJSON, &cooldownMs, &priority, &condition, &concurrency, &actorType, &actorID, &createdAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan rule revision row: %w", err)
+	}
+
+	rev.RestoredFrom = int(restoredFrom.Int64)
+	rev.ActorType = actorType.String
+	rev.ActorID = actorID.String
+	rev.CreatedAt, _ = parseTimestamp(createdAt)
+
+	rev.Rule.ID = rev.RuleID
+	rev.Rule.Revision = rev.Revision
+	rev.Rule.Description = description.String
+	rev.Rule.Enabled = enabled == 1
+	rev.Rule.CooldownMs = int(cooldownMs.Int64)
+	rev.Rule.Priority = int(priority.Int64)
+	rev.Rule.Condition = condition.String
+	rev.Rule.Concurrency = concurrency.String
+	rev.Rule.UpdatedAt = rev.CreatedAt
+
+	if triggerJSON != "" {
+		if err := json.Unmarshal([]byte(triggerJSON), &rev.Rule.TriggerConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse trigger_config JSON for revision %d of rule ID %d: %w", rev.Revision, rev.RuleID, err)
+		}
+	}
+	if actionsJSON != "" {
+		if err := json.Unmarshal([]byte(actionsJSON), &rev.Rule.Actions); err != nil {
+			return nil, fmt.Errorf("failed to parse actions JSON for revision %d of rule ID %d: %w", rev.Revision, rev.RuleID, err)
+		}
+	}
+
+	return &rev, nil
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleRevisions(t *testing.T) {
+	ctx := WithActor(context.Background(), ActorMCP, "claude-desktop/1.0")
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	rule := AutomationRule{
+		Name:          "go-live",
+		Enabled:       true,
+		TriggerType:   "manual",
+		TriggerConfig: map[string]interface{}{},
+		Actions:       []RuleAction{{Type: "start_streaming"}},
+	}
+	id, err := db.CreateAutomationRule(ctx, rule)
+	require.NoError(t, err)
+
+	got, err := db.GetAutomationRule(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Revision)
+
+	got.Actions = append(got.Actions, RuleAction{Type: "start_recording"})
+	got.Priority = 5
+	require.NoError(t, db.UpdateAutomationRule(ctx, *got))
+	require.NoError(t, db.SetAutomationRuleEnabled(ctx, id, false))
+	// Setting the current state saves nothing
+	require.NoError(t, db.SetAutomationRuleEnabled(ctx, id, false))
+
+	revisions, err := db.ListRuleRevisions(ctx, id, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, []string{RevisionDisabled, RevisionUpdated, RevisionCreated},
+		[]string{revisions[0].Change, revisions[1].Change, revisions[2].Change})
+	assert.Equal(t, 3, revisions[0].Revision)
+	assert.False(t, revisions[0].Rule.Enabled)
+	assert.Equal(t, ActorMCP, revisions[2].ActorType)
+	assert.Equal(t, "claude-desktop/1.0", revisions[2].ActorID)
+	assert.Equal(t, rule.Actions, revisions[2].Rule.Actions)
+
+	t.Run("restore", func(t *testing.T) {
+		require.NoError(t, db.RestoreRuleRevision(ctx, id, 1))
+
+		restored, err := db.GetAutomationRule(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, 4, restored.Revision)
+		assert.Equal(t, rule.Actions, restored.Actions)
+		assert.Zero(t, restored.Priority)
+		assert.False(t, restored.Enabled, "restore keeps the enabled state")
+
+		rev, err := db.GetRuleRevision(ctx, id, 4)
+		require.NoError(t, err)
+		assert.Equal(t, RevisionRestored, rev.Change)
+		assert.Equal(t, 1, rev.RestoredFrom)
+
+		assert.ErrorContains(t, db.RestoreRuleRevision(ctx, id, 99), "revision 99 of rule ID")
+		_, err = db.GetRuleRevision(ctx, id, 99)
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("restore to a taken name", func(t *testing.T) {
+		renamed, err := db.GetAutomationRule(ctx, id)
+		require.NoError(t, err)
+		renamed.Name = "stream"
+		require.NoError(t, db.UpdateAutomationRule(ctx, *renamed))
+		_, err = db.CreateAutomationRule(ctx, rule)
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, db.RestoreRuleRevision(ctx, id, 1), "'go-live' already exists")
+	})
+
+	t.Run("executions record the revision", func(t *testing.T) {
+		current, err := db.GetAutomationRule(ctx, id)
+		require.NoError(t, err)
+		_, err = db.CreateRuleExecution(ctx, RuleExecution{
+			RuleID:    id,
+			RuleName:  current.Name,
+			StartedAt: time.Now(),
+			Status:    ExecutionStatusCompleted,
+			Revision:  current.Revision,
+		})
+		require.NoError(t, err)
+
+		executions, err := db.GetRuleExecutions(ctx, id, 0)
+		require.NoError(t, err)
+		require.Len(t, executions, 1)
+		assert.Equal(t, current.Revision, executions[0].Revision)
+	})
+
+	t.Run("deleting the rule deletes its revisions", func(t *testing.T) {
+		require.NoError(t, db.DeleteAutomationRule(ctx, id))
+		revisions, err := db.ListRuleRevisions(ctx, id, 0)
+		require.NoError(t, err)
+		assert.Empty(t, revisions)
+	})
+}
+
+func TestRuleRevisionsMigration(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	id, err := db.CreateAutomationRule(ctx, AutomationRule{
+		Name:          "legacy",
+		TriggerType:   "manual",
+		TriggerConfig: map[string]interface{}{},
+		Actions:       []RuleAction{{Type: "start_recording"}},
+	})
+	require.NoError(t, err)
+
+	// Rules saved before revisions were kept have neither a number nor history
+	_, err = db.conn.ExecContext(ctx, "DELETE FROM rule_revisions")
+	require.NoError(t, err)
+	_, err = db.conn.ExecContext(ctx, "UPDATE automation_rules SET revision = 0")
+	require.NoError(t, err)
+
+	require.NoError(t, db.migrate(ctx))
+	require.NoError(t, db.migrate(ctx))
+
+	rule, err := db.GetAutomationRule(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rule.Revision)
+
+	revisions, err := db.ListRuleRevisions(ctx, id, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, RevisionCreated, revisions[0].Change)
+	assert.Equal(t, "legacy", revisions[0].Rule.Name)
+}
@@ -20,6 +20,7 @@ const (
 	StateKeyLastConnected = "last_connected" // Timestamp of last successful OBS connection
 	StateKeyAppVersion    = "app_version"    // Application version
 	StateKeyAutoReconnect = "auto_reconnect" // Auto-reconnect preference
+	StateKeyReadOnly      = "read_only"      // Read-only observer mode
 )
 
 // Tool group state keys - control which tool categories are enabled
@@ -300,6 +301,29 @@ func (db *DB) GetAutoReconnect(ctx context.Context) (bool, error) {
 	return value == "true", nil
 }
 
+// SetReadOnly saves the read-only observer mode preference.
+func (db *DB) SetReadOnly(ctx context.Context, enabled bool) error {
+	value := "false"
+	if enabled {
+		value = "true"
+	}
+	return db.SetState(ctx, StateKeyReadOnly, value)
+}
+
+// GetReadOnly retrieves the read-only observer mode preference.
+// Defaults to false if not set.
+func (db *DB) GetReadOnly(ctx context.Context) (bool, error) {
+	value, err := db.GetState(ctx, StateKeyReadOnly)
+	if err != nil {
+		if err.Error() == fmt.Sprintf("state key '%s' not found", StateKeyReadOnly) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return value == "true", nil
+}
+
 // SetAppVersion records the application version.
 // This is useful for tracking which version of the app created/modified the database.
 func (db *DB) SetAppVersion(ctx context.Context, version string) error {
@@ -352,6 +352,32 @@ func TestGetAutoReconnect(t *testing.T) {
 	})
 }
 
+func TestReadOnly(t *testing.T) {
+	t.Run("defaults to false when not set", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		enabled, err := db.GetReadOnly(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, enabled)
+	})
+
+	t.Run("round-trips the preference", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		require.NoError(t, db.SetReadOnly(context.Background(), true))
+		enabled, err := db.GetReadOnly(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, enabled)
+
+		require.NoError(t, db.SetReadOnly(context.Background(), false))
+		enabled, err = db.GetReadOnly(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, enabled)
+	})
+}
+
 func TestSetAppVersion(t *testing.T) {
 	t.Run("sets app version", func(t *testing.T) {
 		db, cleanup := testDB(t)
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UndoOperation is a single inverse operation that restores OBS state
+// changed by a tool call.
+type UndoOperation struct {
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// UndoEntry records how to reverse one action_history row.
+// Operations are replayed in order when the action is undone.
+type UndoEntry struct {
+	ID          int64           `json:"id"`
+	ActionID    int64           `json:"action_id"`
+	ToolName    string          `json:"tool_name"`
+	Description string          `json:"description,omitempty"`
+	Operations  []UndoOperation `json:"operations"`
+	UndoneAt    *time.Time      `json:"undone_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// RecordUndoEntry stores the inverse operations for an action.
+// Returns the ID of the newly created entry.
+func (db *DB) RecordUndoEntry(ctx context.Context, entry UndoEntry) (int64, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	opsJSON, err := json.Marshal(entry.Operations)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize undo operations to JSON: %w", err)
+	}
+
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO undo_journal (action_id, tool_name, description, operations, created_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, entry.ActionID, entry.ToolName, entry.Description, string(opsJSON))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record undo entry for action %d: %w", entry.ActionID, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get inserted undo entry ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetUndoEntry retrieves the undo entry for an action_history row.
+func (db *DB) GetUndoEntry(ctx context.Context, actionID int64) (*UndoEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, action_id, tool_name, description, operations, undone_at, created_at
+		FROM undo_journal
+		WHERE action_id = ?
+	`, actionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get undo entry for action %d: %w", actionID, err)
+	}
+	defer rows.Close()
+
+	entries, err := scanUndoEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no undo entry for action %d", actionID)
+	}
+
+	return &entries[0], nil
+}
+
+// ListUndoEntries returns undo entries, most recent action first.
+// limit specifies maximum number of entries to return (0 = use default of 50).
+// Entries that were already undone are only included when includeUndone is set.
+func (db *DB) ListUndoEntries(ctx context.Context, limit int, includeUndone bool) ([]UndoEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, action_id, tool_name, description, operations, undone_at, created_at
+		FROM undo_journal`
+	if !includeUndone {
+		query += `
+		WHERE undone_at IS NULL`
+	}
+	query += `
+		ORDER BY action_id DESC
+		LIMIT ?`
+
+	rows, err := db.conn.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list undo entries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUndoEntries(rows)
+}
+
+// GetUndoEntriesSince returns pending undo entries for actions with an ID
+// greater than or equal to actionID, most recent action first.
+func (db *DB) GetUndoEntriesSince(ctx context.Context, actionID int64) ([]UndoEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, action_id, tool_name, description, operations, undone_at, created_at
+		FROM undo_journal
+		WHERE undone_at IS NULL AND action_id >= ?
+		ORDER BY action_id DESC
+	`, actionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get undo entries since action %d: %w", actionID, err)
+	}
+	defer rows.Close()
+
+	return scanUndoEntries(rows)
+}
+
+// MarkUndone flags an undo entry as applied so it is not replayed again.
+func (db *DB) MarkUndone(ctx context.Context, actionID int64) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE undo_journal SET undone_at = CURRENT_TIMESTAMP
+		WHERE action_id = ? AND undone_at IS NULL
+	`, actionID)
+	if err != nil {
+		return fmt.Errorf("failed to mark action %d undone: %w", actionID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("no pending undo entry for action %d", actionID)
+	}
+
+	return nil
+}
+
+// UpdateUndoOperations replaces the operations of a pending undo entry.
+// Used when replaying one entry changes identifiers that later entries
+// refer to, such as the scene item ID of a restored source.
+func (db *DB) UpdateUndoOperations(ctx context.Context, actionID int64, ops []UndoOperation) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	opsJSON, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("failed to serialize undo operations to JSON: %w", err)
+	}
+
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE undo_journal SET operations = ?
+		WHERE action_id = ? AND undone_at IS NULL
+	`, string(opsJSON), actionID)
+	if err != nil {
+		return fmt.Errorf("failed to update undo entry for action %d: %w", actionID, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("no pending undo entry for action %d", actionID)
+	}
+
+	return nil
+}
+
+// scanUndoEntries is a helper to scan multiple undo entries from query results.
+func scanUndoEntries(rows *sql.Rows) ([]UndoEntry, error) {
+	var entries []UndoEntry
+
+	for rows.Next() {
+		var e UndoEntry
+		var description, undoneAt sql.NullString
+		var opsJSON, createdAt string
+
+		if err := rows.Scan(&e.ID, &e.ActionID, &e.ToolName, &description, &opsJSON, &undoneAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan undo entry: %w", err)
+		}
+
+		e.Description = description.String
+		if err := json.Unmarshal([]byte(opsJSON), &e.Operations); err != nil {
+			return nil, fmt.Errorf("failed to parse operations JSON for undo entry %d: %w", e.ID, err)
+		}
+
+		if t, err := parseTimestamp(createdAt); err == nil {
+			e.CreatedAt = t
+		}
+		if undoneAt.Valid {
+			if t, err := parseTimestamp(undoneAt.String); err == nil {
+				e.UndoneAt = &t
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating undo entries: %w", err)
+	}
+
+	return entries, nil
+}
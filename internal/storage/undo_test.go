@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordUndoableAction(t *testing.T, db *DB, tool string, ops ...UndoOperation) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	actionID, err := db.RecordAction(ctx, ActionRecord{Action: tool, ToolName: tool, Success: true})
+	require.NoError(t, err)
+
+	_, err = db.RecordUndoEntry(ctx, UndoEntry{ActionID: actionID, ToolName: tool, Description: "Undo " + tool, Operations: ops})
+	require.NoError(t, err)
+
+	return actionID
+}
+
+func TestUndoJournal(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("round-trips operations", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		actionID := recordUndoableAction(t, db, "set_current_scene",
+			UndoOperation{Type: "set_current_scene", Parameters: map[string]interface{}{"scene_name": "Scene 1"}})
+
+		entry, err := db.GetUndoEntry(ctx, actionID)
+		require.NoError(t, err)
+		assert.Equal(t, actionID, entry.ActionID)
+		assert.Equal(t, "set_current_scene", entry.ToolName)
+		assert.Equal(t, "Undo set_current_scene", entry.Description)
+		require.Len(t, entry.Operations, 1)
+		assert.Equal(t, "Scene 1", entry.Operations[0].Parameters["scene_name"])
+		assert.Nil(t, entry.UndoneAt)
+		assert.False(t, entry.CreatedAt.IsZero())
+	})
+
+	t.Run("rejects a second entry for the same action", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		actionID := recordUndoableAction(t, db, "create_scene")
+		_, err := db.RecordUndoEntry(ctx, UndoEntry{ActionID: actionID, ToolName: "create_scene"})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects entries for unknown actions", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		_, err := db.RecordUndoEntry(ctx, UndoEntry{ActionID: 999, ToolName: "create_scene"})
+		assert.Error(t, err)
+	})
+
+	t.Run("lists pending entries newest first", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		first := recordUndoableAction(t, db, "create_scene")
+		second := recordUndoableAction(t, db, "remove_scene")
+		third := recordUndoableAction(t, db, "set_current_scene")
+
+		require.NoError(t, db.MarkUndone(ctx, third))
+
+		pending, err := db.ListUndoEntries(ctx, 0, false)
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, second, pending[0].ActionID)
+		assert.Equal(t, first, pending[1].ActionID)
+
+		all, err := db.ListUndoEntries(ctx, 0, true)
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		assert.Equal(t, third, all[0].ActionID)
+		assert.NotNil(t, all[0].UndoneAt)
+
+		limited, err := db.ListUndoEntries(ctx, 1, true)
+		require.NoError(t, err)
+		assert.Len(t, limited, 1)
+	})
+
+	t.Run("returns pending entries since an action", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		first := recordUndoableAction(t, db, "create_scene")
+		second := recordUndoableAction(t, db, "remove_scene")
+		third := recordUndoableAction(t, db, "set_current_scene")
+		require.NoError(t, db.MarkUndone(ctx, third))
+
+		entries, err := db.GetUndoEntriesSince(ctx, second)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, second, entries[0].ActionID)
+
+		entries, err = db.GetUndoEntriesSince(ctx, first)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("marks an entry undone only once", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		actionID := recordUndoableAction(t, db, "create_scene")
+		require.NoError(t, db.MarkUndone(ctx, actionID))
+
+		err := db.MarkUndone(ctx, actionID)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no pending undo entry")
+	})
+
+	t.Run("updates operations of pending entries", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		actionID := recordUndoableAction(t, db, "set_source_locked",
+			UndoOperation{Type: "set_scene_item_locked", Parameters: map[string]interface{}{"scene_item_id": 1}})
+
+		err := db.UpdateUndoOperations(ctx, actionID, []UndoOperation{
+			{Type: "set_scene_item_locked", Parameters: map[string]interface{}{"scene_item_id": 7}},
+		})
+		require.NoError(t, err)
+
+		entry, err := db.GetUndoEntry(ctx, actionID)
+		require.NoError(t, err)
+		assert.Equal(t, float64(7), entry.Operations[0].Parameters["scene_item_id"])
+
+		require.NoError(t, db.MarkUndone(ctx, actionID))
+		assert.Error(t, db.UpdateUndoOperations(ctx, actionID, nil))
+	})
+
+	t.Run("returns error for missing entry", func(t *testing.T) {
+		db, cleanup := testDB(t)
+		defer cleanup()
+
+		_, err := db.GetUndoEntry(ctx, 42)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no undo entry")
+	})
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Automation variables are named values that persist across rule executions.
+// Rules read them through "{{var.name}}" templates in action parameters and
+// write them with the set_variable action. Values are stored as JSON.
+
+// SetAutomationVariable creates or replaces an automation variable.
+func (db *DB) SetAutomationVariable(ctx context.Context, name string, value interface{}) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to serialize variable '%s' to JSON: %w", name, err)
+	}
+
+	_, err = db.conn.ExecContext(ctx, `
+		INSERT INTO automation_variables (name, value, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET
+			value = excluded.value,
+			updated_at = CURRENT_TIMESTAMP
+	`, name, string(valueJSON))
+	if err != nil {
+		return fmt.Errorf("failed to set variable '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// GetAutomationVariable returns the value of an automation variable.
+// The boolean result is false if the variable does not exist.
+func (db *DB) GetAutomationVariable(ctx context.Context, name string) (interface{}, bool, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var valueJSON string
+	err := db.conn.QueryRowContext(ctx,
+		"SELECT value FROM automation_variables WHERE name = ?",
+		name,
+	).Scan(&valueJSON)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to get variable '%s': %w", name, err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, false, fmt.Errorf("failed to parse value of variable '%s': %w", name, err)
+	}
+
+	return value, true, nil
+}
+
+// ListAutomationVariables returns all automation variables by name.
+func (db *DB) ListAutomationVariables(ctx context.Context) (map[string]interface{}, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.QueryContext(ctx,
+		"SELECT name, value FROM automation_variables ORDER BY name",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variables: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]interface{})
+	for rows.Next() {
+		var name, valueJSON string
+		if err := rows.Scan(&name, &valueJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan variable row: %w", err)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+			return nil, fmt.Errorf("failed to parse value of variable '%s': %w", name, err)
+		}
+		result[name] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating variable rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteAutomationVariable removes an automation variable. Deleting a
+// variable that does not exist is not an error.
+func (db *DB) DeleteAutomationVariable(ctx context.Context, name string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if _, err := db.conn.ExecContext(ctx, "DELETE FROM automation_variables WHERE name = ?", name); err != nil {
+		return fmt.Errorf("failed to delete variable '%s': %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutomationVariables(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	_, found, err := db.GetAutomationVariable(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, db.SetAutomationVariable(ctx, "overlay_scene", "Overlay"))
+	require.NoError(t, db.SetAutomationVariable(ctx, "count", 3))
+	require.NoError(t, db.SetAutomationVariable(ctx, "count", 4))
+
+	value, found, err := db.GetAutomationVariable(ctx, "count")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, float64(4), value, "values round-trip through JSON")
+
+	vars, err := db.ListAutomationVariables(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"count": float64(4), "overlay_scene": "Overlay"}, vars)
+
+	require.NoError(t, db.DeleteAutomationVariable(ctx, "count"))
+	require.NoError(t, db.DeleteAutomationVariable(ctx, "count"))
+	_, found, err = db.GetAutomationVariable(ctx, "count")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
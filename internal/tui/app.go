@@ -75,6 +75,7 @@ type Model struct {
 	// History data
 	actions       []storage.ActionRecord
 	historyOffset int
+	historyActor  string // Actor type the history is filtered to ("" = all)
 
 	// Docs data
 	docsList       []docs.Doc
@@ -131,7 +132,7 @@ func (m Model) Init() tea.Cmd {
 		m.spinner.Tick,
 		tickCmd(),
 		fetchStatusCmd(m.db),
-		fetchHistoryCmd(m.db, historyFetchLimit),
+		fetchHistoryCmd(m.db, historyFetchLimit, m.historyActor),
 	)
 }
 
@@ -148,7 +149,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.currentView = ViewConfig
 		case "3":
 			m.currentView = ViewHistory
-			return m, fetchHistoryCmd(m.db, historyFetchLimit)
+			return m, fetchHistoryCmd(m.db, historyFetchLimit, m.historyActor)
 		case "4":
 			m.currentView = ViewDocs
 			return m, fetchDocsListCmd()
@@ -158,9 +159,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "shift+tab", "left":
 			m.currentView = (m.currentView + numViews - 1) % numViews
 			return m, m.fetchViewDataCmd()
+		case "a":
+			// Cycle the history actor filter
+			if m.currentView == ViewHistory {
+				m.historyActor = nextHistoryActor(m.historyActor)
+				m.historyOffset = 0
+				return m, fetchHistoryCmd(m.db, historyFetchLimit, m.historyActor)
+			}
 		case "r":
 			// Refresh current view
-			return m, tea.Batch(fetchStatusCmd(m.db), fetchHistoryCmd(m.db, historyFetchLimit))
+			return m, tea.Batch(fetchStatusCmd(m.db), fetchHistoryCmd(m.db, historyFetchLimit, m.historyActor))
 		case "j", "down":
 			if m.currentView == ViewHistory && m.historyOffset < len(m.actions)-scrollMargin {
 				m.historyOffset++
@@ -472,13 +480,18 @@ func (m Model) renderConfigView() string {
 func (m Model) renderHistoryView() string {
 	box := styleBox.Copy().Width(m.width - boxWidthOffset)
 
+	title := styleTitle.Render("Action History")
+	if m.historyActor != "" {
+		title += styleMuted.Render(fmt.Sprintf("  actor: %s", m.historyActor))
+	}
+
 	if len(m.actions) == 0 {
-		return box.Render(styleTitle.Render("Action History") + "\n\n" + styleMuted.Render("No actions recorded yet"))
+		return box.Render(title + "\n\n" + styleMuted.Render("No actions recorded yet"))
 	}
 
 	// Calculate column widths dynamically based on terminal width
 	availableWidth := m.width - tablePadding
-	colTool := availableWidth - colWidthTimestamp - colWidthStatus - colWidthDuration - columnSpacing
+	colTool := availableWidth - colWidthTimestamp - colWidthActor - colWidthStatus - colWidthDuration - columnSpacing - 2
 	if colTool < colWidthToolMin {
 		colTool = colWidthToolMin
 	}
@@ -494,6 +507,7 @@ func (m Model) renderHistoryView() string {
 
 	// Header - pad AFTER styling
 	header := padStyled(styleTableHeader.Render("Timestamp"), colWidthTimestamp) + "  " +
+		padStyled(styleTableHeader.Render("Actor"), colWidthActor) + "  " +
 		padStyled(styleTableHeader.Render("Tool"), colTool) + "  " +
 		padStyled(styleTableHeader.Render("Status"), colWidthStatus) + "  " +
 		styleTableHeader.Render("Duration")
@@ -537,6 +551,7 @@ func (m Model) renderHistoryView() string {
 
 		// Build row with proper padding
 		row := padStyled(styleDim.Render(action.CreatedAt.Format("2006-01-02 15:04:05")), colWidthTimestamp) + "  " +
+			padStyled(action.ActorType, colWidthActor) + "  " +
 			padStyled(toolName, colTool) + "  " +
 			padStyled(status, colWidthStatus) + "  " +
 			duration
@@ -551,7 +566,7 @@ func (m Model) renderHistoryView() string {
 			styleMuted.Render(fmt.Sprintf("Showing %d-%d of %d (↑/↓ or j/k to scroll)", start+1, end, len(m.actions))))
 	}
 
-	content := styleTitle.Render("Action History") + "\n\n" + lipgloss.JoinVertical(lipgloss.Left, rows...) + scrollInfo
+	content := title + "\n\n" + lipgloss.JoinVertical(lipgloss.Left, rows...) + scrollInfo
 	return box.Render(content)
 }
 
@@ -626,6 +641,9 @@ func (m Model) renderHelp() string {
 		styleHelpKey.Render("[↑/↓]"),
 		styleHelpKey.Render("[q]"),
 	)
+	if m.currentView == ViewHistory {
+		help = fmt.Sprintf("%s Actor • %s", styleHelpKey.Render("[a]"), help)
+	}
 
 	return styleHelpText.Copy().Padding(0, 1).Render(help)
 }
@@ -665,10 +683,16 @@ func fetchStatusCmd(db *storage.DB) tea.Cmd {
 	}
 }
 
-func fetchHistoryCmd(db *storage.DB, limit int) tea.Cmd {
+func fetchHistoryCmd(db *storage.DB, limit int, actorType string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		actions, err := db.GetRecentActions(ctx, limit)
+		var actions []storage.ActionRecord
+		var err error
+		if actorType != "" {
+			actions, err = db.FilterActions(ctx, storage.ActionFilter{ActorType: actorType}, limit)
+		} else {
+			actions, err = db.GetRecentActions(ctx, limit)
+		}
 		if err != nil {
 			return errMsg{err}
 		}
@@ -676,6 +700,20 @@ func fetchHistoryCmd(db *storage.DB, limit int) tea.Cmd {
 	}
 }
 
+// nextHistoryActor returns the actor filter after current: all actors, then
+// each actor type in turn.
+func nextHistoryActor(current string) string {
+	if current == "" {
+		return storage.ActorTypes[0]
+	}
+	for i, actorType := range storage.ActorTypes {
+		if actorType == current && i+1 < len(storage.ActorTypes) {
+			return storage.ActorTypes[i+1]
+		}
+	}
+	return ""
+}
+
 func fetchDocsListCmd() tea.Cmd {
 	return func() tea.Msg {
 		docsList, err := docs.List()
@@ -700,7 +738,7 @@ func fetchDocsContentCmd(name string, width int) tea.Cmd {
 func (m Model) fetchViewDataCmd() tea.Cmd {
 	switch m.currentView {
 	case ViewHistory:
-		return fetchHistoryCmd(m.db, historyFetchLimit)
+		return fetchHistoryCmd(m.db, historyFetchLimit, m.historyActor)
 	case ViewDocs:
 		return fetchDocsListCmd()
 	default:
@@ -0,0 +1,21 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+)
+
+func TestNextHistoryActor(t *testing.T) {
+	actor := ""
+	var seen []string
+	for range len(storage.ActorTypes) + 1 {
+		actor = nextHistoryActor(actor)
+		seen = append(seen, actor)
+	}
+
+	assert.Equal(t, append(append([]string{}, storage.ActorTypes...), ""), seen)
+	assert.Equal(t, "", nextHistoryActor("unknown"))
+}
@@ -98,6 +98,7 @@ const (
 
 	// Table column widths
 	colWidthTimestamp = 19 // "2006-01-02 15:04:05"
+	colWidthActor     = 10 // "automation"
 	colWidthStatus    = 6  // "OK" or "FAIL"
 	colWidthDuration  = 10 // "12345ms"
 	colWidthToolMin   = 15 // Minimum tool column width
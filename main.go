@@ -19,6 +19,11 @@ import (
 const appName = "agentic-obs"
 
 func main() {
+	// Subcommands run instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		os.Exit(runRulesCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	// Parse command-line flags
 	tuiMode := flag.Bool("tui", false, "Run in TUI dashboard mode instead of MCP server mode")
 	flag.BoolVar(tuiMode, "t", false, "Run in TUI dashboard mode (shorthand)")
@@ -26,6 +31,7 @@ func main() {
 	flag.BoolVar(showHelp, "h", false, "Show usage information (shorthand)")
 	showVersion := flag.Bool("version", false, "Show version information")
 	flag.BoolVar(showVersion, "v", false, "Show version information (shorthand)")
+	readOnly := flag.Bool("read-only", false, "Only register non-mutating tools and reject changes over HTTP")
 	flag.Parse()
 
 	if *showVersion {
@@ -57,6 +63,11 @@ func main() {
 		log.Fatalf("FATAL: Failed to load configuration: %v", err)
 	}
 
+	// The flag enables read-only mode for this run without persisting it
+	if *readOnly {
+		cfg.ReadOnly = true
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("FATAL: Invalid configuration: %v", err)
@@ -98,7 +109,11 @@ func main() {
 			Design:      cfg.ToolGroups.Design,
 			Filters:     cfg.ToolGroups.Filters,
 			Transitions: cfg.ToolGroups.Transitions,
+			Automation:  cfg.ToolGroups.Automation,
 		},
+		ReadOnly:          cfg.ReadOnly,
+		ForwardURLs:       cfg.EventForwarding.URLs,
+		ForwardEventTypes: cfg.EventForwarding.EventTypes,
 	}
 
 	server, err := mcp.NewServer(serverConfig)
@@ -310,11 +325,18 @@ func runTUIMode(ctx context.Context, cfg *config.Config) error {
 // printUsage prints usage information
 func printUsage() {
 	fmt.Fprintf(os.Stderr, `Usage: %s [OPTIONS]
+       %s rules <export|import> [OPTIONS]
 
 An MCP server that provides AI assistants with programmatic control over OBS Studio.
 
+Commands:
+  rules export    Export automation rules, macros and presets as a bundle
+  rules import    Import a bundle (run "%s rules help" for options)
+
 Options:
   -t, --tui       Run in TUI dashboard mode instead of MCP server mode
+  --read-only     Observer mode: only register non-mutating tools, reject
+                  changes over HTTP, and keep the automation engine off
   -v, --version   Show version information
   -h, --help      Show this help message
 
@@ -325,6 +347,7 @@ Environment Variables:
   AGENTIC_OBS_DB             Database file path (default: ~/.agentic-obs/db.sqlite)
   AGENTIC_OBS_HTTP_PORT      HTTP server port (default: 8765)
   AGENTIC_OBS_HTTP_ENABLED   Enable/disable HTTP server (default: true)
+  AGENTIC_OBS_READ_ONLY      Enable/disable read-only observer mode (default: false)
 
 Examples:
   # Run MCP server (default mode)
@@ -339,6 +362,12 @@ Examples:
   # Run with password
   OBS_PASSWORD=mysecret %s
 
+  # Run as a read-only observer
+  %s --read-only
+
+  # Copy automation rules to another machine
+  %s rules export -o rules.yaml
+
 For more information, see: https://github.com/ironystock/agentic-obs
-`, appName, appName, appName, appName, appName)
+`, appName, appName, appName, appName, appName, appName, appName, appName, appName)
 }
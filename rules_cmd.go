@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ironystock/agentic-obs/config"
+	"github.com/ironystock/agentic-obs/internal/mcp"
+	"github.com/ironystock/agentic-obs/internal/obs"
+	"github.com/ironystock/agentic-obs/internal/storage"
+)
+
+// stringList is a flag that may be given several times.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runRulesCommand runs "agentic-obs rules <export|import>" and returns the
+// process exit code.
+func runRulesCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printRulesUsage(stderr)
+		return 2
+	}
+
+	ctx := context.Background()
+	var err error
+	switch args[0] {
+	case "export":
+		err = rulesExport(ctx, args[1:], stdout, stderr)
+	case "import":
+		err = rulesImport(ctx, args[1:], stdin, stdout, stderr)
+	case "help", "-h", "--help":
+		printRulesUsage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown rules command: %s\n\n", args[0])
+		printRulesUsage(stderr)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// rulesExport writes a bundle of the stored rules to a file or stdout.
+func rulesExport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("rules export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var rules, presets stringList
+	fs.Var(&rules, "rule", "Rule to export (repeatable; default: all rules)")
+	fs.Var(&presets, "preset", "Scene preset to include (repeatable)")
+	format := fs.String("format", "", "Bundle format: json or yaml (default: from the output file extension, else json)")
+	includeSecrets := fs.Bool("include-secrets", false, "Keep webhook secrets in the bundle")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	dbPath := fs.String("db", "", "Database file path (default: the server's database)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = mcp.BundleFormatJSON
+		if ext := strings.ToLower(filepath.Ext(*output)); ext == ".yaml" || ext == ".yml" {
+			*format = mcp.BundleFormatYAML
+		}
+	}
+
+	db, err := storage.New(ctx, storage.Config{Path: rulesDBPath(*dbPath)})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	bundle, err := mcp.ExportBundle(ctx, db, mcp.ExportOptions{
+		Rules:          rules,
+		Presets:        presets,
+		IncludeSecrets: *includeSecrets,
+	})
+	if err != nil {
+		return err
+	}
+	data, err := mcp.EncodeBundle(bundle, *format)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	fmt.Fprintf(stdout, "Exported %d rules, %d macros and %d scene presets to %s\n",
+		len(bundle.Rules), len(bundle.Macros), len(bundle.Presets), *output)
+	return nil
+}
+
+// rulesImport reads a bundle from a file or stdin and imports it.
+func rulesImport(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("rules import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	strategy := fs.String("strategy", mcp.ConflictSkip, "What to do when a name is taken: skip, rename or overwrite")
+	dryRun := fs.Bool("dry-run", false, "Validate and report without saving anything")
+	noOBS := fs.Bool("no-obs", false, "Do not connect to OBS to check scene and input references")
+	dbPath := fs.String("db", "", "Database file path (default: the server's database)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s rules import [options] <file|->", appName)
+	}
+
+	var data []byte
+	var err error
+	if path := fs.Arg(0); path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+	bundle, err := mcp.DecodeBundle(data)
+	if err != nil {
+		return err
+	}
+
+	path := rulesDBPath(*dbPath)
+	db, err := storage.New(ctx, storage.Config{Path: path})
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	opts := mcp.ImportOptions{Strategy: *strategy, DryRun: *dryRun}
+	if !*noOBS {
+		client, err := connectBundleOBS(ctx, path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: %v; scene and input references were not checked\n", err)
+		} else {
+			defer client.Close()
+			opts.OBS = client
+		}
+	}
+
+	result, err := mcp.ImportBundle(ctx, db, bundle, opts)
+	if err != nil {
+		return err
+	}
+
+	printImportResult(stdout, result)
+	if result.Failed > 0 {
+		return fmt.Errorf("%d items could not be imported", result.Failed)
+	}
+	return nil
+}
+
+// printImportResult prints one line per bundle item, then the missing
+// references and a summary.
+func printImportResult(w io.Writer, result *mcp.BundleImportResult) {
+	rulesChanged := false
+	for _, item := range result.Items {
+		status := item.Status
+		switch {
+		case item.Error != "":
+			status += ": " + item.Error
+		case item.ImportedAs != "":
+			status += " to " + item.ImportedAs
+		}
+		fmt.Fprintf(w, "%-7s %-30s %s\n", item.Kind, item.Name, status)
+		if item.Kind == "rule" && item.Status != mcp.BundleItemSkipped && item.Status != mcp.BundleItemFailed {
+			rulesChanged = true
+		}
+	}
+	if len(result.MissingScenes) > 0 {
+		fmt.Fprintf(w, "Scenes missing in OBS: %s\n", strings.Join(result.MissingScenes, ", "))
+	}
+	if len(result.MissingInputs) > 0 {
+		fmt.Fprintf(w, "Inputs missing in OBS: %s\n", strings.Join(result.MissingInputs, ", "))
+	}
+	fmt.Fprintln(w, result.Message)
+	if rulesChanged && !result.DryRun {
+		fmt.Fprintf(w, "Restart %s if it is running to load the imported rules\n", appName)
+	}
+}
+
+// rulesDBPath returns the database the rules commands use: the flag value,
+// else the server's database, honoring the environment overrides.
+func rulesDBPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if path := os.Getenv(config.EnvDBPath); path != "" {
+		return path
+	}
+	if path := os.Getenv(config.EnvDBPathAlt); path != "" {
+		return path
+	}
+	return config.DefaultConfig().DBPath
+}
+
+// connectBundleOBS connects to OBS with the connection settings stored in
+// the database at dbPath and the environment overrides.
+func connectBundleOBS(ctx context.Context, dbPath string) (*obs.Client, error) {
+	cfg, err := config.LoadFromStorage(ctx, dbPath)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	cfg.ApplyEnvOverrides()
+
+	client := obs.NewClient(obs.ConnectionConfig{Host: cfg.OBSHost, Port: cfg.OBSPort, Password: cfg.OBSPassword})
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("could not connect to OBS at %s:%s: %w", cfg.OBSHost, cfg.OBSPort, err)
+	}
+	return client, nil
+}
+
+// printRulesUsage prints usage information for the rules commands.
+func printRulesUsage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: %s rules <command> [options]
+
+Move automation rules, the macros they call and related scene presets
+between installations as versioned JSON or YAML bundles.
+
+Commands:
+  export    Write a bundle to stdout or a file
+            --rule NAME          Rule to export (repeatable; default: all rules)
+            --preset NAME        Extra scene preset to include (repeatable)
+            --format json|yaml   Bundle format (default: from -o extension, else json)
+            --include-secrets    Keep webhook secrets in the bundle
+            -o FILE              Output file (default: stdout)
+
+  import    Import a bundle from a file, or stdin with "-"
+            --strategy skip|rename|overwrite
+                                 What to do when a name is taken (default: skip)
+            --dry-run            Validate and report without saving anything
+            --no-obs             Skip checking scene and input references in OBS
+
+Both commands take --db PATH to use a database other than the server's.
+
+Examples:
+  %s rules export -o studio-a.yaml
+  %s rules import --strategy rename studio-a.yaml
+`, appName, appName, appName)
+}
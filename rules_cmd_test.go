@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ironystock/agentic-obs/internal/storage"
+)
+
+func TestRulesCommand(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source.db")
+	targetPath := filepath.Join(dir, "target.db")
+	bundlePath := filepath.Join(dir, "rules.yaml")
+
+	source, err := storage.New(ctx, storage.Config{Path: sourcePath})
+	if err != nil {
+		t.Fatalf("failed to open source database: %v", err)
+	}
+	_, err = source.CreateAutomationRule(ctx, storage.AutomationRule{
+		Name:          "go-live",
+		Enabled:       true,
+		TriggerType:   "manual",
+		TriggerConfig: map[string]interface{}{},
+		Actions:       []storage.RuleAction{{Type: "start_streaming"}},
+	})
+	source.Close()
+	if err != nil {
+		t.Fatalf("failed to create rule: %v", err)
+	}
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runRulesCommand(args, strings.NewReader(""), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, errOut := run("export", "--db", sourcePath, "-o", bundlePath)
+	if code != 0 {
+		t.Fatalf("export exited with %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "Exported 1 rules") {
+		t.Errorf("export output = %q", out)
+	}
+
+	code, out, errOut = run("import", "--db", targetPath, "--no-obs", bundlePath)
+	if code != 0 {
+		t.Fatalf("import exited with %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "go-live") || !strings.Contains(out, "created") {
+		t.Errorf("import output = %q", out)
+	}
+
+	code, out, _ = run("import", "--db", targetPath, "--no-obs", "--strategy", "rename", bundlePath)
+	if code != 0 || !strings.Contains(out, "go-live (2)") {
+		t.Errorf("rename import exited with %d: %q", code, out)
+	}
+
+	if code, _, _ := run("import", "--db", targetPath, "--no-obs"); code != 1 {
+		t.Errorf("import without a file exited with %d, want 1", code)
+	}
+	if code, _, _ := run("publish"); code != 2 {
+		t.Errorf("unknown command exited with %d, want 2", code)
+	}
+}
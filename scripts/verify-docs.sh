@@ -14,10 +14,10 @@ YELLOW='\033[1;33m'
 NC='\033[0m' # No Color
 
 # Current expected values - UPDATE THESE AFTER EACH PHASE
-EXPECTED_TOOLS=81
+EXPECTED_TOOLS=104
 EXPECTED_RESOURCES=4
 EXPECTED_PROMPTS=14
-EXPECTED_API_ENDPOINTS=8
+EXPECTED_API_ENDPOINTS=9
 CURRENT_PHASE=13
 
 echo "=========================================="
@@ -142,6 +142,7 @@ API_ENDPOINTS=(
     "/api/history/stats"
     "/api/screenshots"
     "/api/config"
+    "/api/hooks/"
     "/screenshot/"
 )
 